@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
 
 	core "github.com/nox-hq/nox/core"
 	"github.com/nox-hq/nox/core/catalog"
+	"github.com/nox-hq/nox/core/findings"
 )
 
 const defaultBatchSize = 10
@@ -152,6 +154,7 @@ func (e *Explainer) Explain(ctx context.Context, result *core.ScanResult) (*Expl
 			break
 		}
 
+		addReferenceURLs(explanations, batch)
 		report.Explanations = append(report.Explanations, explanations...)
 	}
 
@@ -173,6 +176,24 @@ func (e *Explainer) Explain(ctx context.Context, result *core.ScanResult) (*Expl
 	return report, nil
 }
 
+// addReferenceURLs adds the reference URL the config gave each finding of
+// batch to the references of its explanation, in case the provider left it
+// out.
+func addReferenceURLs(explanations []FindingExplanation, batch []findings.Finding) {
+	urls := make(map[string]string, len(batch))
+	for i := range batch {
+		if u := batch[i].Metadata[findings.MetaReferenceURL]; u != "" {
+			urls[batch[i].ID] = u
+		}
+	}
+	for i := range explanations {
+		e := &explanations[i]
+		if u, ok := urls[e.FindingID]; ok && !slices.Contains(e.References, u) {
+			e.References = append(e.References, u)
+		}
+	}
+}
+
 // generateSummary asks the provider for an executive summary of all
 // explained findings.
 func (e *Explainer) generateSummary(ctx context.Context, explanations []FindingExplanation) (string, error) {
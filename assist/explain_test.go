@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	core "github.com/nox-hq/nox/core"
@@ -108,6 +109,34 @@ func TestExplain_SingleFinding(t *testing.T) {
 	}
 }
 
+func TestExplain_ReferenceURL(t *testing.T) {
+	const url = "https://wiki.corp/security/nox/SEC-001"
+	mock := &MockProvider{
+		Responses: []Response{
+			{Content: jsonExplanations([]FindingExplanation{{FindingID: "f1", RuleID: "SEC-001", References: []string{"https://cwe.mitre.org/data/definitions/798.html"}}})},
+			{Content: "summary"},
+		},
+	}
+	result := makeScanResult([]findings.Finding{{
+		ID:       "f1",
+		RuleID:   "SEC-001",
+		Severity: findings.SeverityHigh,
+		Message:  "Hardcoded AWS key",
+		Metadata: map[string]string{findings.MetaReferenceURL: url},
+	}})
+
+	report, err := NewExplainer(mock).Explain(context.Background(), result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prompt := mock.Calls[0][2].Content; !strings.Contains(prompt, "Internal reference (include it in references): "+url) {
+		t.Errorf("prompt does not name the reference URL:\n%s", prompt)
+	}
+	if refs := report.Explanations[0].References; len(refs) != 2 || refs[1] != url {
+		t.Errorf("references = %v, want the reference URL added", refs)
+	}
+}
+
 func TestExplain_MultipleBatches(t *testing.T) {
 	// Create 15 findings with batch size 10 → 2 batches.
 	var ff []findings.Finding
@@ -46,9 +46,15 @@ func formatFindings(ff []findings.Finding, basePath string, allFindings []findin
 		fmt.Fprintf(&b, "Message: %s\n", f.Message)
 		if len(f.Metadata) > 0 {
 			for k, v := range f.Metadata {
+				if k == findings.MetaReferenceURL {
+					continue
+				}
 				fmt.Fprintf(&b, "Metadata %s: %s\n", k, v)
 			}
 		}
+		if u := f.Metadata[findings.MetaReferenceURL]; u != "" {
+			fmt.Fprintf(&b, "Internal reference (include it in references): %s\n", u)
+		}
 
 		// Enrich with source context and rule metadata.
 		d := detail.Enrich(&f, basePath, allFindings, cat, 3)
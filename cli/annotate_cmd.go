@@ -1,33 +1,85 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 
+	nox "github.com/nox-hq/nox/core"
 	"github.com/nox-hq/nox/core/annotate"
 	"github.com/nox-hq/nox/core/findings"
 	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/github"
+	"github.com/nox-hq/nox/core/policy"
 	"github.com/nox-hq/nox/core/report"
 )
 
+// Annotation modes for nox annotate.
+const (
+	annotateModeComment  = "comment"
+	annotateModeCheckRun = "check-run"
+	annotateModeSummary  = "summary"
+)
+
 func runAnnotate(args []string) int {
 	fs := flag.NewFlagSet("annotate", flag.ContinueOnError)
 	var (
-		inputPath string
-		prNumber  string
-		repo      string
+		inputPath   string
+		prNumber    string
+		repo        string
+		mode        string
+		headSHA     string
+		failOn      string
+		summaryPath string
+		apiURL      string
+		serverURL   string
+		maxFindings int
 	)
 	fs.StringVar(&inputPath, "input", "findings.json", "path to findings.json")
+	fs.StringVar(&inputPath, "report", "findings.json", "path to a findings.json report written under a custom name (same as --input)")
 	fs.StringVar(&prNumber, "pr", "", "PR number (auto-detected from GITHUB_REF)")
 	fs.StringVar(&repo, "repo", "", "repository owner/name (auto-detected from GITHUB_REPOSITORY)")
+	fs.StringVar(&mode, "mode", annotateModeComment, "annotation mode: comment, check-run or summary")
+	fs.StringVar(&headSHA, "sha", "", "commit SHA for the check run (default: GITHUB_SHA)")
+	fs.StringVar(&failOn, "fail-on", "", "severity that fails the check run, optionally scoped as severity:changed or severity:all (default: policy.fail_on and policy.scope from .nox.yaml)")
+	fs.StringVar(&summaryPath, "summary-file", "", "also write a markdown job summary to this path (summary mode default: $GITHUB_STEP_SUMMARY)")
+	fs.StringVar(&apiURL, "github-api-url", "", "GitHub API URL, e.g. https://ghe.example.com/api/v3 (default: $GITHUB_API_URL, or https://api.github.com)")
+	fs.StringVar(&serverURL, "github-server-url", "", "GitHub web URL for links to findings (default: $GITHUB_SERVER_URL, or derived from --github-api-url)")
+	fs.IntVar(&maxFindings, "max-findings", annotate.DefaultMaxFindings, "most findings listed in the review summary and commented inline; the rest are counted")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
 
+	if maxFindings < 1 {
+		fmt.Fprintf(os.Stderr, "error: --max-findings must be at least 1, got %d\n", maxFindings)
+		return 2
+	}
+	if mode != annotateModeComment && mode != annotateModeCheckRun && mode != annotateModeSummary {
+		fmt.Fprintf(os.Stderr, "error: unknown --mode %q (want comment, check-run or summary)\n", mode)
+		return 2
+	}
+	// Comments and check runs are posted to GitHub, so refuse them up
+	// front.
+	if offlineMode && mode != annotateModeSummary {
+		fmt.Fprintf(os.Stderr, "error: annotate --mode %s posts to GitHub and is unavailable in offline mode; use --mode summary, or run without --offline\n", mode)
+		return 2
+	}
+	if mode == annotateModeSummary && summaryPath == "" {
+		summaryPath = os.Getenv("GITHUB_STEP_SUMMARY")
+		if summaryPath == "" {
+			fmt.Fprintln(os.Stderr, "error: could not determine summary file (use --summary-file or set GITHUB_STEP_SUMMARY)")
+			return 2
+		}
+	}
+
 	// Auto-detect PR number from GITHUB_REF.
 	if prNumber == "" {
 		ref := os.Getenv("GITHUB_REF")
@@ -43,18 +95,26 @@ func runAnnotate(args []string) int {
 	if repo == "" {
 		repo = os.Getenv("GITHUB_REPOSITORY")
 	}
+	if headSHA == "" {
+		headSHA = os.Getenv("GITHUB_SHA")
+	}
+	host := githubHost(apiURL, serverURL)
 
-	if prNumber == "" {
+	if mode == annotateModeComment && prNumber == "" {
 		fmt.Fprintln(os.Stderr, "error: could not determine PR number (use --pr or set GITHUB_REF)")
 		return 2
 	}
-	if repo == "" {
+	if repo == "" && mode != annotateModeSummary {
 		fmt.Fprintln(os.Stderr, "error: could not determine repository (use --repo or set GITHUB_REPOSITORY)")
 		return 2
 	}
+	if mode == annotateModeCheckRun && headSHA == "" {
+		fmt.Fprintln(os.Stderr, "error: could not determine commit SHA (use --sha or set GITHUB_SHA)")
+		return 2
+	}
 
 	// Read findings.
-	data, err := os.ReadFile(inputPath)
+	data, err := report.ReadFile(inputPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: reading %s: %v\n", inputPath, err)
 		return 2
@@ -67,45 +127,166 @@ func runAnnotate(args []string) int {
 	}
 
 	ff := jsonReport.Findings
-	if len(ff) == 0 {
-		fmt.Println("annotate: no findings to annotate")
-		return 0
+	total := len(ff)
+	assignMissingOwners(ff, ".")
+
+	// --fail-on and policy.fail_on may name a severity_mapping label, and
+	// --fail-on may add the scope that policy.scope sets otherwise.
+	failOn, scope, err := policy.SplitFailOn(failOn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: --fail-on: %v\n", err)
+		return 2
+	}
+	var mapping findings.SeverityMapping
+	failOnSource := "--fail-on"
+	if cfg, err := nox.LoadScanConfig("."); err == nil {
+		mapping = cfg.SeverityMapping
+		if failOn == "" && (mode == annotateModeCheckRun || summaryPath != "") {
+			failOn, failOnSource = cfg.Policy.FailOn, "policy.fail_on"
+		}
+		if scope == "" {
+			if scope, err = policy.ParseScope(cfg.Policy.Scope); err != nil {
+				fmt.Fprintf(os.Stderr, "error: policy.scope: %v\n", err)
+				return 2
+			}
+		}
+	}
+	gate := policy.Config{Scope: scope}
+	if failOn != "" {
+		var err error
+		if gate.FailOn, err = mapping.ParseThreshold(failOn); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", failOnSource, err)
+			return 2
+		}
 	}
 
-	// Filter to changed files if possible.
-	changedSet := getChangedFilesSet()
-	if changedSet != nil {
-		var filtered []findings.Finding
-		for _, f := range ff {
-			if _, ok := changedSet[f.Location.FilePath]; ok {
-				filtered = append(filtered, f)
+	// The job summary covers the whole report, not only changed files.
+	if summaryPath != "" {
+		if err := writeJobSummary(summaryPath, ff, host, repo, headSHA, gate); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing job summary: %v\n", err)
+			return 2
+		}
+		if mode == annotateModeSummary {
+			fmt.Printf("annotate: wrote job summary for %d finding(s) to %s\n", total, summaryPath)
+			return 0
+		}
+	}
+
+	// Findings tagged by nox scan --changed-files-from are all kept: those
+	// outside the changed files are listed in the summary rather than
+	// commented inline. Otherwise keep only the files the PR changes, if
+	// they can be found.
+	tagged := slices.ContainsFunc(ff, func(f findings.Finding) bool { return f.InChangedFiles != nil })
+	if total > 0 && !tagged {
+		if changedSet := getChangedFilesSet(); changedSet != nil {
+			var filtered []findings.Finding
+			for _, f := range ff {
+				if _, ok := changedSet[f.Location.FilePath]; ok {
+					filtered = append(filtered, f)
+				}
 			}
+			ff = filtered
+		}
+	}
+
+	if mode == annotateModeCheckRun {
+		err := postCheckRun(host, repo, headSHA, ff, gate)
+		if err == nil {
+			fmt.Printf("annotate: created check run with %d annotation(s) on %s@%s\n", countInline(ff), repo, headSHA)
+			return 0
+		}
+		if !errors.Is(err, errChecksNotPermitted) {
+			fmt.Fprintf(os.Stderr, "error: creating check run: %v\n", err)
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v; falling back to PR comments\n", err)
+		if prNumber == "" {
+			fmt.Fprintln(os.Stderr, "error: could not determine PR number for comment fallback (use --pr or set GITHUB_REF)")
+			return 2
 		}
-		ff = filtered
 	}
 
+	if total == 0 {
+		fmt.Println("annotate: no findings to annotate")
+		return 0
+	}
 	if len(ff) == 0 {
 		fmt.Println("annotate: no findings in changed files")
 		return 0
 	}
 
 	// Build payload using core/annotate.
-	payload := annotate.BuildReviewPayload(ff)
+	payload := annotate.BuildReviewPayload(ff, annotate.SummaryOptions{RepoURL: host.RepoURL(repo), Commit: headSHA, MaxFindings: maxFindings})
 	if payload == nil {
 		fmt.Println("annotate: no findings to annotate")
 		return 0
 	}
 
-	// Post review comments via gh CLI.
-	if err := postReviewComments(repo, prNumber, payload); err != nil {
+	if err := postReviewComments(host, repo, prNumber, payload); err != nil {
 		fmt.Fprintf(os.Stderr, "error: posting annotations: %v\n", err)
 		return 2
 	}
 
+	if outside := len(ff) - countInline(ff); outside > 0 {
+		fmt.Printf("annotate: posted %d finding(s) inline and %d outside the changed files in the review summary to %s#%s\n", len(ff)-outside, outside, repo, prNumber)
+		return 0
+	}
 	fmt.Printf("annotate: posted %d finding(s) to %s#%s\n", len(ff), repo, prNumber)
 	return 0
 }
 
+// countInline returns the number of findings in ff that are annotated
+// inline: all but those outside the changed files.
+func countInline(ff []findings.Finding) int {
+	n := 0
+	for i := range ff {
+		if !ff[i].OutsideChangedFiles() {
+			n++
+		}
+	}
+	return n
+}
+
+// githubHost returns the GitHub instance that the --github-api-url and
+// --github-server-url flags name, or that GITHUB_API_URL and
+// GITHUB_SERVER_URL name when neither flag is set. GitHub Actions sets the
+// variables on github.com and GitHub Enterprise Server runners alike.
+func githubHost(apiURL, serverURL string) github.Host {
+	if apiURL == "" && serverURL == "" {
+		return github.FromEnv(os.Getenv)
+	}
+	return github.NewHost(apiURL, serverURL)
+}
+
+// writeJobSummary renders the markdown summary of ff and writes it to path.
+// The summary is appended when path is $GITHUB_STEP_SUMMARY, which earlier
+// steps of the job share, and replaces the file otherwise. Finding locations
+// link to headSHA in repo on host when both are known.
+func writeJobSummary(path string, ff []findings.Finding, host github.Host, repo, headSHA string, gate policy.Config) error {
+	opts := annotate.SummaryOptions{
+		Commit: headSHA,
+		Policy: policy.Evaluate(gate, ff),
+	}
+	if repo != "" {
+		opts.RepoURL = host.RepoURL(repo)
+	}
+	summary := annotate.Summary(ff, opts)
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if path == os.Getenv("GITHUB_STEP_SUMMARY") {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(summary + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func getChangedFilesSet() map[string]struct{} {
 	if !git.IsGitRepo(".") {
 		return nil
@@ -133,20 +314,217 @@ func getChangedFilesSet() map[string]struct{} {
 	return set
 }
 
-func postReviewComments(repo, prNumber string, payload *annotate.ReviewPayload) error {
+// postReviewComments posts payload as a review of the PR. When an earlier
+// run left a review, its body is replaced with the new summary instead,
+// and only the inline comments not posted before go into a new review.
+func postReviewComments(host github.Host, repo, prNumber string, payload *annotate.ReviewPayload) error {
+	endpoint := fmt.Sprintf("repos/%s/pulls/%s/reviews", repo, prNumber)
+	id, err := findReview(host, endpoint)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		body, err := json.Marshal(map[string]string{"body": payload.Body})
+		if err != nil {
+			return fmt.Errorf("marshalling review: %w", err)
+		}
+		if _, err := ghAPI(host, http.MethodPut, fmt.Sprintf("%s/%d", endpoint, id), body); err != nil {
+			return fmt.Errorf("updating review %d: %w", id, err)
+		}
+		posted, err := postedFindingMarkers(host, fmt.Sprintf("repos/%s/pulls/%s/comments", repo, prNumber))
+		if err != nil {
+			return err
+		}
+		var comments []annotate.ReviewComment
+		for _, c := range payload.Comments {
+			marker, _, _ := strings.Cut(c.Body, "\n")
+			if !posted[marker] {
+				comments = append(comments, c)
+			}
+		}
+		if len(comments) == 0 {
+			return nil
+		}
+		payload = &annotate.ReviewPayload{
+			Event:    payload.Event,
+			Body:     fmt.Sprintf("Nox found %d new finding(s); the [first review](#pullrequestreview-%d) has the summary.", len(comments), id),
+			Comments: comments,
+		}
+	}
+
 	payloadData, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshalling payload: %w", err)
 	}
+	_, err = ghAPI(host, http.MethodPost, endpoint, payloadData)
+	return err
+}
 
-	endpoint := fmt.Sprintf("repos/%s/pulls/%s/reviews", repo, prNumber)
-	cmd := exec.Command("gh", "api", endpoint, "--method", "POST", "--input", "-")
-	cmd.Stdin = strings.NewReader(string(payloadData))
-	cmd.Stderr = os.Stderr
+// findReview returns the ID of the last review of a PR whose body starts
+// with annotate.ReviewMarker, or 0 if there is none. endpoint is the
+// reviews endpoint of the PR.
+func findReview(host github.Host, endpoint string) (int64, error) {
+	var id int64
+	for page := 1; page <= maxReviewPages; page++ {
+		out, err := ghAPI(host, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", endpoint, page), nil)
+		if err != nil {
+			return 0, fmt.Errorf("listing reviews: %w", err)
+		}
+		var reviews []struct {
+			ID   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		if json.Unmarshal(out, &reviews) != nil {
+			break
+		}
+		for _, r := range reviews {
+			if strings.HasPrefix(r.Body, annotate.ReviewMarker) {
+				id = r.ID
+			}
+		}
+		if len(reviews) < 100 {
+			break
+		}
+	}
+	return id, nil
+}
+
+// postedFindingMarkers returns the finding markers that start the review
+// comments of a PR. endpoint is the comments endpoint of the PR.
+func postedFindingMarkers(host github.Host, endpoint string) (map[string]bool, error) {
+	posted := make(map[string]bool)
+	for page := 1; page <= maxReviewPages; page++ {
+		out, err := ghAPI(host, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", endpoint, page), nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing review comments: %w", err)
+		}
+		var comments []struct {
+			Body string `json:"body"`
+		}
+		if json.Unmarshal(out, &comments) != nil {
+			break
+		}
+		for _, c := range comments {
+			marker, _, _ := strings.Cut(c.Body, "\n")
+			posted[marker] = true
+		}
+		if len(comments) < 100 {
+			break
+		}
+	}
+	return posted, nil
+}
+
+// maxReviewPages bounds the pages of reviews and review comments read to
+// find those of an earlier run.
+const maxReviewPages = 10
+
+// errChecksNotPermitted indicates the GitHub token cannot create check runs.
+var errChecksNotPermitted = errors.New("token cannot create check runs (a GitHub App token with checks:write is required)")
+
+// postCheckRun creates a check run on headSHA and adds the annotations in
+// batches the Checks API accepts. It returns errChecksNotPermitted when the
+// token cannot use the Checks API, so callers can fall back to comments.
+func postCheckRun(host github.Host, repo, headSHA string, ff []findings.Finding, gate policy.Config) error {
+	if kind := githubTokenKind(githubToken()); kind != "" && kind != tokenKindApp {
+		return fmt.Errorf("%w: found a %s token", errChecksNotPermitted, kind)
+	}
+
+	payloads := annotate.BuildCheckRun(ff, headSHA, gate)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("gh api: %w", err)
+	body, err := json.Marshal(payloads[0])
+	if err != nil {
+		return fmt.Errorf("marshalling check run: %w", err)
+	}
+	out, err := ghAPI(host, http.MethodPost, fmt.Sprintf("repos/%s/check-runs", repo), body)
+	if err != nil {
+		if isForbidden(err) {
+			return fmt.Errorf("%w: %v", errChecksNotPermitted, err)
+		}
+		return err
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(out, &created); err != nil || created.ID == 0 {
+		return fmt.Errorf("unexpected check run response: %s", strings.TrimSpace(string(out)))
 	}
 
+	endpoint := fmt.Sprintf("repos/%s/check-runs/%d", repo, created.ID)
+	for _, p := range payloads[1:] {
+		body, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("marshalling check run update: %w", err)
+		}
+		if _, err := ghAPI(host, http.MethodPatch, endpoint, body); err != nil {
+			return fmt.Errorf("updating check run %d: %w", created.ID, err)
+		}
+	}
 	return nil
 }
+
+// GitHub token kinds, identified by their documented prefixes.
+const (
+	tokenKindApp   = "GitHub App"
+	tokenKindPAT   = "personal access"
+	tokenKindOAuth = "OAuth"
+)
+
+// githubToken returns the token gh uses, if one is set in the environment.
+func githubToken() string {
+	if t := os.Getenv("GH_TOKEN"); t != "" {
+		return t
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// githubTokenKind classifies a token by prefix. It returns "" for an empty
+// or unrecognised token. Only GitHub App installation tokens (including the
+// Actions GITHUB_TOKEN) can create check runs.
+func githubTokenKind(token string) string {
+	switch {
+	case strings.HasPrefix(token, "ghs_"):
+		return tokenKindApp
+	case strings.HasPrefix(token, "ghp_"), strings.HasPrefix(token, "github_pat_"):
+		return tokenKindPAT
+	case strings.HasPrefix(token, "gho_"), strings.HasPrefix(token, "ghu_"):
+		return tokenKindOAuth
+	default:
+		return ""
+	}
+}
+
+// isForbidden reports whether a GitHub API error was an HTTP 403.
+func isForbidden(err error) bool {
+	return strings.Contains(err.Error(), "HTTP 403")
+}
+
+// ghAPI calls the REST API of host and returns the response body. With a
+// token in the environment it calls the API directly; otherwise it goes
+// through the gh CLI, which uses the credentials of "gh auth login" for
+// the host. It is a variable so tests can stub it.
+var ghAPI = func(host github.Host, method, endpoint string, body []byte) ([]byte, error) {
+	if token := githubToken(); token != "" {
+		c := &github.Client{Host: host, Token: token}
+		return c.Do(context.Background(), method, endpoint, body)
+	}
+	args := []string{"api", endpoint, "--method", method}
+	if body != nil {
+		args = append(args, "--input", "-")
+	}
+	if !host.IsDotCom() {
+		args = append(args, "--hostname", host.Hostname())
+	}
+	cmd := exec.Command("gh", args...)
+	if body != nil {
+		cmd.Stdin = bytes.NewReader(body)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("gh api: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/nox-hq/nox/core/annotate"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/github"
 )
 
 func TestRunAnnotate_NoInput(t *testing.T) {
@@ -288,3 +296,335 @@ func TestGetChangedFilesSet_InGitRepo(t *testing.T) {
 	// In a repo without a remote, this returns nil.
 	_ = result
 }
+
+// stubGHAPI replaces ghAPI for the duration of a test and records calls.
+func stubGHAPI(t *testing.T, fn func(method, endpoint string, body []byte) ([]byte, error)) *[]string {
+	t.Helper()
+	var calls []string
+	orig := ghAPI
+	ghAPI = func(_ github.Host, method, endpoint string, body []byte) ([]byte, error) {
+		calls = append(calls, method+" "+endpoint)
+		return fn(method, endpoint, body)
+	}
+	t.Cleanup(func() { ghAPI = orig })
+	return &calls
+}
+
+func writeAnnotateFindings(t *testing.T, n int) string {
+	t.Helper()
+	var ff []string
+	for i := 0; i < n; i++ {
+		ff = append(ff, fmt.Sprintf(`{"RuleID":"SEC-001","Severity":"high","Message":"secret","Location":{"FilePath":"f%d.env","StartLine":1}}`, i))
+	}
+	path := filepath.Join(t.TempDir(), "findings.json")
+	content := `{"findings":[` + strings.Join(ff, ",") + `]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunAnnotate_CheckRunBatches(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("GH_TOKEN", "ghs_apptoken")
+	input := writeAnnotateFindings(t, 75)
+
+	var conclusion string
+	calls := stubGHAPI(t, func(method, endpoint string, body []byte) ([]byte, error) {
+		var p annotate.CheckRunPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		if p.Conclusion != "" {
+			conclusion = p.Conclusion
+		}
+		return []byte(`{"id":7}`), nil
+	})
+
+	code := runAnnotate([]string{"--mode", "check-run", "--input", input, "--repo", "owner/repo", "--sha", "abc"})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	want := []string{"POST repos/owner/repo/check-runs", "PATCH repos/owner/repo/check-runs/7"}
+	if strings.Join(*calls, ";") != strings.Join(want, ";") {
+		t.Fatalf("calls = %v, want %v", *calls, want)
+	}
+	if conclusion != "failure" {
+		t.Fatalf("conclusion = %q, want failure", conclusion)
+	}
+}
+
+func TestRunAnnotate_CheckRunFailOnChanged(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("GH_TOKEN", "ghs_apptoken")
+	input := filepath.Join(t.TempDir(), "findings.json")
+	content := `{"findings":[
+		{"RuleID":"SEC-001","Severity":"critical","Message":"old","Location":{"FilePath":"old.env","StartLine":1},"InChangedFiles":false},
+		{"RuleID":"SEC-002","Severity":"low","Message":"new","Location":{"FilePath":"new.env","StartLine":1},"InChangedFiles":true}]}`
+	if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got annotate.CheckRunPayload
+	stubGHAPI(t, func(method, endpoint string, body []byte) ([]byte, error) {
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		return []byte(`{"id":7}`), nil
+	})
+
+	for failOn, want := range map[string]string{"high:changed": "success", "high": "failure"} {
+		code, out := captureOutput(t, func() int {
+			return runAnnotate([]string{"--mode", "check-run", "--input", input, "--repo", "owner/repo", "--sha", "abc", "--fail-on", failOn})
+		})
+		if code != 0 {
+			t.Fatalf("--fail-on %s: exit %d", failOn, code)
+		}
+		if got.Conclusion != want {
+			t.Errorf("--fail-on %s: conclusion = %q, want %q", failOn, got.Conclusion, want)
+		}
+		if len(got.Output.Annotations) != 1 || got.Output.Annotations[0].Path != "new.env" {
+			t.Errorf("--fail-on %s: annotations = %+v, want only new.env", failOn, got.Output.Annotations)
+		}
+		if !strings.Contains(out, "created check run with 1 annotation(s)") {
+			t.Errorf("--fail-on %s: output = %q", failOn, out)
+		}
+	}
+
+	if code := runAnnotate([]string{"--mode", "check-run", "--input", input, "--repo", "owner/repo", "--sha", "abc", "--fail-on", "high:touched"}); code != 2 {
+		t.Errorf("unknown scope: exit %d, want 2", code)
+	}
+}
+
+func TestRunAnnotate_CheckRunFallsBackToComments(t *testing.T) {
+	t.Chdir(t.TempDir())
+	input := writeAnnotateFindings(t, 1)
+
+	tests := []struct {
+		name  string
+		token string
+		fail  bool
+	}{
+		{"personal access token", "ghp_personal", false},
+		{"forbidden", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GH_TOKEN", tt.token)
+			t.Setenv("GITHUB_TOKEN", "")
+			calls := stubGHAPI(t, func(method, endpoint string, body []byte) ([]byte, error) {
+				if tt.fail && strings.HasSuffix(endpoint, "/check-runs") {
+					return nil, errors.New("gh api: exit status 1: HTTP 403: Resource not accessible by integration")
+				}
+				return []byte(`{}`), nil
+			})
+
+			code := runAnnotate([]string{"--mode", "check-run", "--input", input, "--repo", "owner/repo", "--sha", "abc", "--pr", "5"})
+			if code != 0 {
+				t.Fatalf("expected exit 0, got %d", code)
+			}
+			last := (*calls)[len(*calls)-1]
+			if last != "POST repos/owner/repo/pulls/5/reviews" {
+				t.Fatalf("expected fallback to review comments, got calls %v", *calls)
+			}
+		})
+	}
+}
+
+func TestRunAnnotate_UpdatesEarlierReview(t *testing.T) {
+	t.Chdir(t.TempDir())
+	input := writeAnnotateFindings(t, 3)
+	first := annotate.BuildReviewPayload([]findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "secret", Location: findings.Location{FilePath: "f0.env", StartLine: 1}},
+	}, annotate.SummaryOptions{})
+	posted, _ := json.Marshal([]map[string]string{{"body": first.Comments[0].Body}, {"body": "LGTM"}})
+
+	var updated map[string]string
+	var review annotate.ReviewPayload
+	calls := stubGHAPI(t, func(method, endpoint string, body []byte) ([]byte, error) {
+		switch {
+		case strings.HasPrefix(endpoint, "repos/owner/repo/pulls/5/reviews?"):
+			return []byte(`[{"id":3,"body":"thanks"},{"id":9,"body":"` + annotate.ReviewMarker + `\n## Nox scan results"}]`), nil
+		case strings.HasPrefix(endpoint, "repos/owner/repo/pulls/5/comments?"):
+			return posted, nil
+		case method == http.MethodPut:
+			_ = json.Unmarshal(body, &updated)
+		case method == http.MethodPost:
+			_ = json.Unmarshal(body, &review)
+		}
+		return []byte(`{}`), nil
+	})
+
+	if code := runAnnotate([]string{"--input", input, "--repo", "owner/repo", "--pr", "5", "--max-findings", "2"}); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	want := []string{
+		"GET repos/owner/repo/pulls/5/reviews?per_page=100&page=1",
+		"PUT repos/owner/repo/pulls/5/reviews/9",
+		"GET repos/owner/repo/pulls/5/comments?per_page=100&page=1",
+		"POST repos/owner/repo/pulls/5/reviews",
+	}
+	if strings.Join(*calls, ";") != strings.Join(want, ";") {
+		t.Fatalf("calls = %v, want %v", *calls, want)
+	}
+	if !strings.Contains(updated["body"], "_…and 1 more — see the full report artifact._") {
+		t.Errorf("updated review body:\n%s", updated["body"])
+	}
+	// f0.env was commented on before and f2.env is over --max-findings.
+	if len(review.Comments) != 1 || review.Comments[0].Path != "f1.env" {
+		t.Errorf("new review comments = %+v, want only f1.env", review.Comments)
+	}
+
+	if code := runAnnotate([]string{"--input", input, "--repo", "owner/repo", "--pr", "5", "--max-findings", "0"}); code != 2 {
+		t.Errorf("--max-findings 0: expected exit 2, got %d", code)
+	}
+}
+
+func TestRunAnnotate_SummaryAppendsToStepSummary(t *testing.T) {
+	t.Chdir(t.TempDir())
+	input := writeAnnotateFindings(t, 12)
+	stepSummary := filepath.Join(t.TempDir(), "step_summary.md")
+	if err := os.WriteFile(stepSummary, []byte("# Build\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", stepSummary)
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_SHA", "abc123")
+	t.Setenv("GITHUB_SERVER_URL", "")
+	t.Setenv("GITHUB_REF", "")
+	stubGHAPI(t, func(method, endpoint string, body []byte) ([]byte, error) {
+		t.Fatalf("summary mode called the API: %s %s", method, endpoint)
+		return nil, nil
+	})
+
+	if code := runAnnotate([]string{"--mode", "summary", "--input", input}); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	data, err := os.ReadFile(stepSummary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# Build\n## Nox scan results",
+		":x: **Failed**",
+		"| :orange_circle: high | 12 |",
+		"[f0.env:1](https://github.com/owner/repo/blob/abc123/f0.env#L1)",
+		"_…and 2 more finding(s)._",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunAnnotate_SummaryFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	input := writeAnnotateFindings(t, 1)
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	t.Setenv("GITHUB_REPOSITORY", "")
+	if code := runAnnotate([]string{"--mode", "summary", "--input", input}); code != 2 {
+		t.Fatalf("expected exit 2 without a summary file, got %d", code)
+	}
+
+	out := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(out, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := runAnnotate([]string{"--mode", "summary", "--summary-file", out, "--fail-on", "critical", "--input", input}); code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "stale") {
+		t.Error("summary file was appended to instead of replaced")
+	}
+	if !strings.Contains(string(data), ":white_check_mark: **Passed**") || !strings.Contains(string(data), "`f0.env:1`") {
+		t.Errorf("unexpected summary:\n%s", data)
+	}
+}
+
+func TestRunAnnotate_InvalidMode(t *testing.T) {
+	if code := runAnnotate([]string{"--mode", "bogus"}); code != 2 {
+		t.Fatalf("expected exit 2 for invalid mode, got %d", code)
+	}
+}
+
+func TestGithubTokenKind(t *testing.T) {
+	tests := map[string]string{
+		"ghs_x":        tokenKindApp,
+		"ghp_x":        tokenKindPAT,
+		"github_pat_x": tokenKindPAT,
+		"gho_x":        tokenKindOAuth,
+		"":             "",
+		"custom":       "",
+	}
+	for token, want := range tests {
+		if got := githubTokenKind(token); got != want {
+			t.Errorf("githubTokenKind(%q) = %q, want %q", token, got, want)
+		}
+	}
+}
+
+func TestRunAnnotate_OfflineFailsFast(t *testing.T) {
+	t.Chdir(t.TempDir())
+	input := writeAnnotateFindings(t, 1)
+	offlineMode = true
+	t.Cleanup(func() { offlineMode = false })
+	stubGHAPI(t, func(method, endpoint string, body []byte) ([]byte, error) {
+		t.Fatalf("offline annotate called the API: %s %s", method, endpoint)
+		return nil, nil
+	})
+
+	for _, mode := range []string{"comment", "check-run"} {
+		if code := runAnnotate([]string{"--mode", mode, "--input", input, "--repo", "owner/repo", "--pr", "1", "--sha", "abc"}); code != 2 {
+			t.Errorf("--mode %s: expected exit 2 in offline mode, got %d", mode, code)
+		}
+	}
+
+	// The job summary is written locally and still works.
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	if code := runAnnotate([]string{"--mode", "summary", "--input", input, "--summary-file", summary}); code != 0 {
+		t.Fatalf("summary mode: expected exit 0 in offline mode, got %d", code)
+	}
+}
+
+func TestRunAnnotate_EnterpriseServer(t *testing.T) {
+	t.Chdir(t.TempDir())
+	input := writeAnnotateFindings(t, 1)
+	t.Setenv("GH_TOKEN", "ghs_apptoken")
+	t.Setenv("GITHUB_API_URL", "https://api.github.com")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+
+	var requests []string
+	var review annotate.ReviewPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasSuffix(r.URL.Path, "/reviews") {
+			_ = json.Unmarshal(body, &review)
+		}
+		_, _ = io.WriteString(w, `{"id":7}`)
+	}))
+	defer srv.Close()
+
+	// The flags replace the github.com endpoints in the environment; the
+	// bare address gets the /api/v3 prefix of GitHub Enterprise Server.
+	args := []string{"--input", input, "--repo", "owner/repo", "--sha", "abc", "--pr", "5",
+		"--github-api-url", srv.URL, "--github-server-url", "https://ghe.example.com"}
+	if code := runAnnotate(append([]string{"--mode", "check-run"}, args...)); code != 0 {
+		t.Fatalf("check-run: expected exit 0, got %d", code)
+	}
+	if code := runAnnotate(append([]string{"--mode", "comment"}, args...)); code != 0 {
+		t.Fatalf("comment: expected exit 0, got %d", code)
+	}
+	want := []string{"POST /api/v3/repos/owner/repo/check-runs", "GET /api/v3/repos/owner/repo/pulls/5/reviews", "POST /api/v3/repos/owner/repo/pulls/5/reviews"}
+	if strings.Join(requests, ";") != strings.Join(want, ";") {
+		t.Fatalf("requests = %v, want %v", requests, want)
+	}
+	if link := "https://ghe.example.com/owner/repo/blob/abc/f0.env#L1"; !strings.Contains(review.Body, link) {
+		t.Errorf("review body does not link to %s:\n%s", link, review.Body)
+	}
+}
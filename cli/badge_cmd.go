@@ -1,19 +1,28 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	noxapi "github.com/nox-hq/nox"
 	nox "github.com/nox-hq/nox/core"
 	"github.com/nox-hq/nox/core/badge"
 	"github.com/nox-hq/nox/core/findings"
 	"github.com/nox-hq/nox/core/report"
 )
 
+// Files written next to the badge SVG.
+const (
+	badgeEndpointFile = "badge.endpoint.json"
+	badgeHistoryFile  = "badge.history.jsonl"
+)
+
 // runBadge implements the "nox badge" command.
 func runBadge(args []string) int {
 	var flagArgs []string
@@ -37,22 +46,54 @@ func runBadge(args []string) int {
 		output     string
 		label      string
 		bySeverity bool
+		trend      bool
 	)
 
 	fs.StringVar(&input, "input", "", "path to findings.json (default: run scan)")
+	fs.StringVar(&input, "report", "", "path to a findings.json report written under a custom name (same as --input)")
 	fs.StringVar(&output, "output", ".github/nox-badge.svg", "output SVG file path")
 	fs.StringVar(&label, "label", "nox", "badge label text")
 	fs.BoolVar(&bySeverity, "by-severity", false, "generate additional badges per severity level")
+	fs.BoolVar(&trend, "trend", false, "print a sparkline of the last 30 badge history entries and exit")
 
 	if err := fs.Parse(flagArgs); err != nil {
 		return 2
 	}
 	positionalArgs = append(positionalArgs, fs.Args()...)
 
-	var findingsList []findings.Finding
+	historyPath := filepath.Join(filepath.Dir(output), badgeHistoryFile)
+	if trend {
+		entries, err := badge.ReadHistory(historyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: reading %s: %v\n", historyPath, err)
+			return 2
+		}
+		fmt.Println(badge.Trend(entries, 30))
+		return 0
+	}
+
+	target := "."
+	if len(positionalArgs) > 0 {
+		target = positionalArgs[0]
+	}
+	// Weights in severity_mapping replace the default severity weights
+	// of the grade, and the badge weights of the categories multiply
+	// them.
+	cfg, err := nox.LoadRootConfig(target, configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: loading config: %v\n", err)
+		return 2
+	}
+
+	var (
+		findingsList []findings.Finding
+		// categoryWeight weighs a finding by the badge weight of its
+		// category.
+		categoryWeight func(*findings.Finding) float64
+	)
 
 	if input != "" {
-		data, err := os.ReadFile(input)
+		data, err := report.ReadFile(input)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: reading %s: %v\n", input, err)
 			return 2
@@ -67,18 +108,25 @@ func runBadge(args []string) int {
 				findingsList = append(findingsList, rep.Findings[i])
 			}
 		}
-	} else {
-		target := "."
-		if len(positionalArgs) > 0 {
-			target = positionalArgs[0]
+		categories, err := nox.ReportCategories(cfg, &rep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
 		}
+		categoryWeight = func(f *findings.Finding) float64 {
+			return categories.Weight(nox.ReportFindingCategory(f))
+		}
+	} else {
 		fmt.Printf("nox — scanning %s\n", target)
-		result, err := nox.RunScan(target)
+		result, err := scanDir(context.Background(), target, noxapi.WithConfigFile(configPath))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
 			return 2
 		}
 		findingsList = result.Findings.ActiveFindings()
+		categoryWeight = func(f *findings.Finding) float64 {
+			return result.Categories.Weight(result.Rules.CategoryOf(f))
+		}
 		suppressed := len(result.Findings.Findings()) - len(findingsList)
 		if suppressed > 0 {
 			fmt.Printf("[results] %d findings (%d suppressed)\n", len(findingsList), suppressed)
@@ -87,7 +135,7 @@ func runBadge(args []string) int {
 		}
 	}
 
-	badgeResult := badge.GenerateFromFindings(findingsList, label)
+	badgeResult := badge.GenerateCategoryWeighted(findingsList, label, cfg.SeverityMapping.Weights(), categoryWeight)
 
 	// Ensure parent directory exists.
 	if dir := filepath.Dir(output); dir != "." && dir != "" {
@@ -104,6 +152,25 @@ func runBadge(args []string) int {
 
 	fmt.Printf("[badge] wrote %s (%s: %s)\n", output, label, badgeResult.Value)
 
+	endpointPath := filepath.Join(filepath.Dir(output), badgeEndpointFile)
+	endpoint, err := badge.MarshalEndpoint(badgeResult)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: encoding endpoint JSON: %v\n", err)
+		return 2
+	}
+	if err := os.WriteFile(endpointPath, endpoint, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", endpointPath, err)
+		return 2
+	}
+	fmt.Printf("[badge] wrote %s\n", endpointPath)
+
+	entry := badge.NewHistoryEntry(time.Now().UTC().Format(time.DateOnly), findingsList)
+	entry.Grade, entry.Score = badgeResult.Grade, badgeResult.Score
+	if err := badge.AppendHistory(historyPath, entry, badge.MaxHistoryEntries); err != nil {
+		fmt.Fprintf(os.Stderr, "error: updating %s: %v\n", historyPath, err)
+		return 2
+	}
+
 	// Generate per-severity badges if requested.
 	if bySeverity {
 		dir := filepath.Dir(output)
@@ -382,3 +382,39 @@ func TestGenerateBadgeSVG(t *testing.T) {
 		t.Fatal("expected aria-label in SVG")
 	}
 }
+
+func TestBadge_EndpointAndHistory(t *testing.T) {
+	dir := t.TempDir()
+	input := writeFindingsJSON(t, dir, []findings.Finding{{RuleID: "SEC-001", Severity: findings.SeverityHigh}})
+	output := filepath.Join(dir, "out", "nox-badge.svg")
+
+	for range 2 {
+		if code := runBadge([]string{"--input", input, "--output", output}); code != 0 {
+			t.Fatalf("expected exit 0, got %d", code)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", "badge.endpoint.json"))
+	if err != nil {
+		t.Fatalf("reading endpoint: %v", err)
+	}
+	var ep badge.Endpoint
+	if err := json.Unmarshal(data, &ep); err != nil {
+		t.Fatalf("parsing endpoint: %v", err)
+	}
+	if ep.SchemaVersion != 1 || ep.Message != "C" || ep.Color != "dfb317" {
+		t.Errorf("endpoint = %+v", ep)
+	}
+
+	entries, err := badge.ReadHistory(filepath.Join(dir, "out", "badge.history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].High != 1 || entries[1].Grade != "C" {
+		t.Errorf("history = %+v, want 2 entries with one high finding", entries)
+	}
+
+	if code := runBadge([]string{"--trend", "--output", output}); code != 0 {
+		t.Fatalf("--trend: expected exit 0, got %d", code)
+	}
+}
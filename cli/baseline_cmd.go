@@ -1,17 +1,26 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"context"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
-	nox "github.com/nox-hq/nox/core"
+	noxapi "github.com/nox-hq/nox"
 	"github.com/nox-hq/nox/core/baseline"
+	"github.com/nox-hq/nox/core/detail"
+	"github.com/nox-hq/nox/core/findings"
 )
 
 func runBaseline(args []string) int {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Usage: nox baseline <write|update|show> [path]")
+		fmt.Fprintln(os.Stderr, "Usage: nox baseline <init|write|update|show|review> [path]")
 		return 2
 	}
 
@@ -19,19 +28,177 @@ func runBaseline(args []string) int {
 	remaining := args[1:]
 
 	switch subcommand {
+	case "init":
+		return baselineInit(remaining)
 	case "write":
 		return baselineWrite(remaining)
 	case "update":
 		return baselineUpdate(remaining)
 	case "show":
 		return baselineShow(remaining)
+	case "review":
+		return baselineReview(remaining)
 	default:
 		fmt.Fprintf(os.Stderr, "unknown baseline subcommand: %s\n", subcommand)
-		fmt.Fprintln(os.Stderr, "Usage: nox baseline <write|update|show> [path]")
+		fmt.Fprintln(os.Stderr, "Usage: nox baseline <init|write|update|show|review> [path]")
 		return 2
 	}
 }
 
+// adoptionReason is the reason of the entries nox baseline init writes, so
+// that they can be told apart and reviewed later.
+const adoptionReason = "initial adoption"
+
+// adoptionSelector chooses the findings nox baseline init accepts. A
+// finding is accepted when it matches every selector that is set.
+type adoptionSelector struct {
+	all           bool
+	belowSeverity findings.Severity
+	belowConf     findings.Confidence
+	categories    []string
+	pathGlobs     []string
+}
+
+// empty reports whether no selector is set.
+func (s adoptionSelector) empty() bool {
+	return !s.all && s.belowSeverity == "" && s.belowConf == "" && len(s.categories) == 0 && len(s.pathGlobs) == 0
+}
+
+// matches reports whether s accepts f, whose rule has the given category.
+// Findings of an unknown severity or confidence are never below a
+// threshold.
+func (s adoptionSelector) matches(f *findings.Finding, category string) bool {
+	if s.all {
+		return true
+	}
+	if s.belowSeverity != "" && (!f.Severity.Valid() || f.Severity.Rank() <= s.belowSeverity.Rank()) {
+		return false
+	}
+	if s.belowConf != "" && (f.Confidence.Rank() >= len(findings.Confidences) || f.Confidence.Rank() <= s.belowConf.Rank()) {
+		return false
+	}
+	if len(s.categories) > 0 && !slices.Contains(s.categories, category) {
+		return false
+	}
+	if len(s.pathGlobs) > 0 && !slices.ContainsFunc(s.pathGlobs, func(g string) bool { return detail.MatchGlob(g, f.Location.FilePath) }) {
+		return false
+	}
+	return true
+}
+
+// baselineInit scans target and writes a baseline of the findings the
+// selectors accept, for adopting nox on a codebase with many existing
+// findings. Each entry gets the reason "initial adoption", so that baseline
+// review and expiry can find them later. An existing baseline is only
+// replaced with --force.
+func baselineInit(args []string) int {
+	fs := flag.NewFlagSet("baseline init", flag.ContinueOnError)
+	var (
+		outputPath, belowSeverity, belowConfidence, category, pathGlob string
+		force                                                          bool
+		sel                                                            adoptionSelector
+	)
+	fs.StringVar(&outputPath, "output", "", "baseline file path (default: .nox/baseline.json)")
+	fs.BoolVar(&force, "force", false, "overwrite an existing baseline")
+	fs.BoolVar(&sel.all, "all", false, "accept every finding")
+	fs.StringVar(&belowSeverity, "below-severity", "", "accept findings less severe than this severity")
+	fs.StringVar(&belowConfidence, "below-confidence", "", "accept findings less certain than this confidence: high, medium")
+	fs.StringVar(&category, "category", "", "accept findings of these comma-separated rule categories")
+	fs.StringVar(&pathGlob, "path-glob", "", "accept findings in files matching these comma-separated globs (** matches directories)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	if belowSeverity != "" {
+		if sel.belowSeverity, err = findings.ParseSeverity(belowSeverity); err != nil {
+			fmt.Fprintf(os.Stderr, "error: --below-severity: %v\n", err)
+			return 2
+		}
+	}
+	if belowConfidence != "" {
+		if sel.belowConf, err = findings.ParseConfidence(belowConfidence); err != nil {
+			fmt.Fprintf(os.Stderr, "error: --below-confidence: %v\n", err)
+			return 2
+		}
+	}
+	sel.categories = splitList(category)
+	sel.pathGlobs = splitList(pathGlob)
+	switch {
+	case sel.empty():
+		fmt.Fprintln(os.Stderr, "error: choose the findings to accept with --below-severity, --below-confidence, --category, --path-glob or --all")
+		return 2
+	case sel.all && (sel.belowSeverity != "" || sel.belowConf != "" || len(sel.categories) > 0 || len(sel.pathGlobs) > 0):
+		fmt.Fprintln(os.Stderr, "error: --all cannot be combined with other selectors")
+		return 2
+	}
+
+	target := "."
+	if fs.NArg() > 0 {
+		target = fs.Arg(0)
+	}
+	if outputPath == "" {
+		outputPath = baseline.DefaultPath(target)
+	}
+	if _, err := os.Stat(outputPath); err == nil && !force {
+		fmt.Fprintf(os.Stderr, "error: baseline %s already exists; use --force to replace it, or nox baseline update to add new findings\n", outputPath)
+		return 2
+	}
+
+	result, err := scanDir(context.Background(), target, noxapi.WithConfigFile(configPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
+		return 2
+	}
+	// The categories the rules files and config declare are known once
+	// the scan has loaded them.
+	for _, c := range sel.categories {
+		if !result.Categories.Valid(c) {
+			fmt.Fprintf(os.Stderr, "error: --category: unknown category %q (want %s)\n", c, result.Categories)
+			return 2
+		}
+	}
+
+	// Findings that the baseline being replaced covers are candidates
+	// again; inline suppressions and VEX statements stay as they are.
+	var candidates, accepted []findings.Finding
+	for _, f := range result.Findings.Findings() {
+		if f.Status.IsActive() || f.Status == findings.StatusBaselined {
+			candidates = append(candidates, f)
+		}
+	}
+	byRule := make(map[string]int)
+	for i := range candidates {
+		f := &candidates[i]
+		if sel.matches(f, result.Rules.CategoryOf(f)) {
+			accepted = append(accepted, *f)
+			byRule[f.RuleID]++
+		}
+	}
+
+	bl := &baseline.Baseline{}
+	entries := baseline.FromFindings(accepted)
+	for i := range entries {
+		entries[i].Reason = adoptionReason
+		bl.Add(&entries[i])
+	}
+	if err := bl.Save(outputPath); err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing baseline: %v\n", err)
+		return 2
+	}
+
+	fmt.Printf("baseline: accepted %d of %d findings as %q — %s\n", len(accepted), len(candidates), adoptionReason, outputPath)
+	ruleIDs := slices.Collect(maps.Keys(byRule))
+	slices.SortFunc(ruleIDs, func(a, b string) int {
+		return cmp.Or(cmp.Compare(byRule[b], byRule[a]), cmp.Compare(a, b))
+	})
+	for _, id := range ruleIDs {
+		fmt.Printf("  %-12s %d\n", id, byRule[id])
+	}
+	fmt.Printf("baseline: %d findings remain active\n", len(candidates)-len(accepted))
+	return 0
+}
+
 func baselineWrite(args []string) int {
 	fs := flag.NewFlagSet("baseline write", flag.ContinueOnError)
 	var outputPath string
@@ -49,7 +216,7 @@ func baselineWrite(args []string) int {
 		outputPath = baseline.DefaultPath(target)
 	}
 
-	result, err := nox.RunScan(target)
+	result, err := scanDir(context.Background(), target, noxapi.WithConfigFile(configPath))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
 		return 2
@@ -88,7 +255,7 @@ func baselineUpdate(args []string) int {
 		baselinePath = baseline.DefaultPath(target)
 	}
 
-	result, err := nox.RunScan(target)
+	result, err := scanDir(context.Background(), target, noxapi.WithConfigFile(configPath))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
 		return 2
@@ -101,6 +268,7 @@ func baselineUpdate(args []string) int {
 	}
 
 	ff := result.Findings.Findings()
+	migrated := bl.Migrate(ff)
 
 	// Add new findings not already in baseline.
 	added := 0
@@ -126,6 +294,9 @@ func baselineUpdate(args []string) int {
 	}
 
 	fmt.Printf("baseline: %d total, %d added, %d pruned — %s\n", bl.Len(), added, pruned, baselinePath)
+	if migrated > 0 {
+		fmt.Printf("baseline: %d entries moved to their current paths\n", migrated)
+	}
 	return 0
 }
 
@@ -155,13 +326,209 @@ func baselineShow(args []string) int {
 	fmt.Printf("baseline: %d entries (%d expired) — %s\n", bl.Len(), bl.ExpiredCount(), baselinePath)
 
 	// Show per-severity counts.
-	counts := make(map[string]int)
+	counts := make(map[findings.Severity]int)
 	for _, e := range bl.Entries {
-		counts[string(e.Severity)]++
+		counts[e.Severity]++
+	}
+	for _, sev := range findings.Severities {
+		if count := counts[sev]; count > 0 {
+			fmt.Printf("  %s: %d\n", sev, count)
+		}
 	}
-	for sev, count := range counts {
-		fmt.Printf("  %s: %d\n", sev, count)
+
+	return 0
+}
+
+// baselineReview scans target and walks through the baseline entries:
+// entries whose finding no longer exists are offered for removal, and
+// entries that still match show the current code and can be kept, given a
+// new reason, or un-baselined. With --yes nothing is asked; live entries are
+// kept and stale ones are removed only with --prune-stale. The previous
+// baseline is saved to <baseline>.bak before any change is written.
+func baselineReview(args []string) int {
+	fs := flag.NewFlagSet("baseline review", flag.ContinueOnError)
+	var (
+		baselinePath string
+		pruneStale   bool
+		yes          bool
+		contextLines int
+	)
+	fs.StringVar(&baselinePath, "baseline", "", "baseline file path (default: .nox/baseline.json)")
+	fs.BoolVar(&pruneStale, "prune-stale", false, "remove entries whose finding no longer exists without asking")
+	fs.BoolVar(&yes, "yes", false, "do not prompt; keep entries that still match")
+	fs.IntVar(&contextLines, "context", 3, "number of source lines shown around each finding")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	target := "."
+	if fs.NArg() > 0 {
+		target = fs.Arg(0)
+	}
+	if baselinePath == "" {
+		baselinePath = baseline.DefaultPath(target)
+	}
+
+	if !yes && !interactiveTerminal() {
+		fmt.Fprintln(os.Stderr, "error: baseline review prompts on a terminal; pass --yes (with --prune-stale to remove stale entries) to run unattended")
+		return 2
+	}
+
+	bl, err := baseline.Load(baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: loading baseline: %v\n", err)
+		return 2
+	}
+	if bl.Len() == 0 {
+		fmt.Printf("baseline: no entries in %s\n", baselinePath)
+		return 0
 	}
 
+	result, err := scanDir(context.Background(), target, noxapi.WithConfigFile(configPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
+		return 2
+	}
+
+	r := &baselineReviewer{
+		in:           bufio.NewReader(os.Stdin),
+		out:          os.Stdout,
+		basePath:     target,
+		contextLines: contextLines,
+		pruneStale:   pruneStale,
+		yes:          yes,
+	}
+	ff := result.Findings.Findings()
+	migrated := bl.Migrate(ff)
+	sum := r.review(bl, bl.Correlate(ff))
+
+	if sum.changed() || migrated > 0 {
+		backup, err := baseline.Backup(baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		if err := bl.Save(baselinePath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: saving baseline: %v\n", err)
+			return 2
+		}
+		if backup != "" {
+			fmt.Printf("baseline: previous version saved to %s\n", backup)
+		}
+	}
+	fmt.Printf("baseline: %d kept, %d removed, %d resurrected, %d reasons updated — %s\n",
+		sum.kept, sum.removed, sum.resurrected, sum.reasoned, baselinePath)
 	return 0
 }
+
+// reviewSummary counts the outcome of a baseline review. Resurrected
+// entries were un-baselined, so their findings are active again.
+type reviewSummary struct {
+	kept, removed, resurrected, reasoned int
+}
+
+func (s reviewSummary) changed() bool {
+	return s.removed > 0 || s.resurrected > 0 || s.reasoned > 0
+}
+
+// baselineReviewer asks about each baseline entry on in and out.
+type baselineReviewer struct {
+	in           *bufio.Reader
+	out          io.Writer
+	basePath     string
+	contextLines int
+	pruneStale   bool
+	yes          bool
+}
+
+// review applies the answers to bl. Quitting, or reaching the end of the
+// input, keeps the remaining entries unchanged.
+func (r *baselineReviewer) review(bl *baseline.Baseline, items []baseline.Correlation) reviewSummary {
+	var sum reviewSummary
+	for i, item := range items {
+		e := item.Entry
+		if item.Finding == nil {
+			answer := "k"
+			switch {
+			case r.pruneStale:
+				answer = "r"
+			case !r.yes:
+				fmt.Fprintf(r.out, "\n[%d/%d] %s %s — the finding no longer exists\n", i+1, len(items), e.RuleID, e.FilePath)
+				answer = r.ask("Remove this entry? [R]emove, [k]eep, [q]uit: ", "r")
+			}
+			switch answer {
+			case "r":
+				bl.Remove(e.Fingerprint)
+				sum.removed++
+			case "q":
+				sum.kept += len(items) - i
+				return sum
+			default:
+				sum.kept++
+			}
+			continue
+		}
+
+		if r.yes {
+			sum.kept++
+			continue
+		}
+		r.show(i+1, len(items), &e, item.Finding)
+		switch r.ask("[K]eep, new [r]eason, [u]n-baseline, [q]uit: ", "k") {
+		case "r":
+			if reason := r.ask("Reason: ", ""); reason != "" && reason != "q" {
+				bl.SetReason(e.Fingerprint, reason)
+				sum.reasoned++
+			}
+			sum.kept++
+		case "u":
+			bl.Remove(e.Fingerprint)
+			sum.resurrected++
+		case "q":
+			sum.kept += len(items) - i
+			return sum
+		default:
+			sum.kept++
+		}
+	}
+	return sum
+}
+
+// show prints a live entry with its current finding and source context.
+func (r *baselineReviewer) show(n, total int, e *baseline.Entry, f *findings.Finding) {
+	fmt.Fprintf(r.out, "\n[%d/%d] %s %s %s:%d\n", n, total, f.RuleID, f.Severity, f.Location.FilePath, f.Location.StartLine)
+	fmt.Fprintf(r.out, "  %s\n", f.Message)
+	reason := e.Reason
+	if reason == "" {
+		reason = "none"
+	}
+	fmt.Fprintf(r.out, "  baselined %s, reason: %s\n", e.CreatedAt.Format("2006-01-02"), reason)
+	if src := detail.ReadSourceContext(r.basePath, f.Location, r.contextLines); src != nil {
+		for _, line := range src.Lines {
+			marker := " "
+			if line.IsMatch {
+				marker = ">"
+			}
+			fmt.Fprintf(r.out, "  %s %4d | %s\n", marker, line.Number, line.Text)
+		}
+	}
+}
+
+// ask prints prompt and returns the answer, lower-cased to its first
+// letter unless def is empty (free text). An empty answer returns def and
+// the end of the input returns "q".
+func (r *baselineReviewer) ask(prompt, def string) string {
+	fmt.Fprint(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && line == "" {
+		return "q"
+	}
+	if def == "" {
+		return line
+	}
+	if line == "" {
+		return def
+	}
+	return strings.ToLower(line[:1])
+}
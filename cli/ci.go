@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nox-hq/nox/core/ci"
+)
+
+// Global output settings, reset by run on every invocation from --no-ci and
+// --color.
+var (
+	// ciDisabled ignores the CI environment, as if nox ran locally.
+	ciDisabled bool
+	// colorMode is "auto", "always" or "never".
+	colorMode = "auto"
+)
+
+// detectCI returns the CI environment nox is running in, or nil outside CI
+// or with --no-ci.
+func detectCI() *ci.Env {
+	if ciDisabled {
+		return nil
+	}
+	return ci.Detect()
+}
+
+// inGitHubActions reports whether nox is running in a GitHub Actions job,
+// where findings can be printed as workflow command annotations.
+func inGitHubActions() bool {
+	env := detectCI()
+	return env != nil && env.SupportsAnnotations()
+}
+
+// useColor reports whether terminal output should use ANSI colours.
+// --color always and never decide alone. With --color auto, a non-empty
+// NO_COLOR turns colour off and otherwise a non-empty FORCE_COLOR other
+// than "0" or "false" turns it on, in CI and when output is redirected
+// too; without either, colour is used only on a terminal outside CI.
+func useColor() bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	return detectCI() == nil && isTerminal()
+}
+
+// interactiveTerminal reports whether nox may prompt or redraw the
+// terminal: stdout is a terminal and nox is not running in CI.
+func interactiveTerminal() bool {
+	return isTerminal() && detectCI() == nil
+}
+
+// checkColorMode returns an error unless mode is a valid --color value.
+func checkColorMode(mode string) error {
+	switch mode {
+	case "auto", "always", "never":
+		return nil
+	}
+	return fmt.Errorf("invalid --color %q (want auto, always or never)", mode)
+}
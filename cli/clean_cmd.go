@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/report"
+)
+
+// runClean implements the "nox clean" command, which removes report files
+// written by nox scan. Only files recognised by report.IsReportFile are
+// deleted.
+func runClean(args []string) int {
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	var output string
+	fs.StringVar(&output, "output", "", "report directory to clean (default: output.directory from .nox.yaml, or .)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var dirs []string
+	if output != "" {
+		dirs = []string{output}
+	} else {
+		cfg, err := nox.LoadScanConfig(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: loading .nox.yaml: %v\n", err)
+			return 2
+		}
+		dir := cfg.Output.Directory
+		if dir == "" {
+			dir = "."
+		}
+		dirs = []string{dir}
+		if dir == "." {
+			dirs = append(dirs, report.RunsDir)
+		}
+	}
+
+	total := 0
+	for _, dir := range dirs {
+		removed, err := report.Clean(dir)
+		for _, path := range removed {
+			fmt.Printf("removed %s\n", path)
+		}
+		total += len(removed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: cleaning %s: %v\n", dir, err)
+			return 2
+		}
+		// Drop the runs directory once it is empty.
+		if filepath.Base(dir) == report.RunsDir && os.Remove(dir) == nil {
+			fmt.Printf("removed %s\n", dir)
+			total++
+		}
+	}
+	if total == 0 {
+		fmt.Println("[clean] no nox reports found")
+	}
+	return 0
+}
+
+// gitignoreHint returns a one-line suggestion to add the reports in
+// outputDir to .gitignore when they are untracked files of a git
+// repository, or "" otherwise. runsBase is the timestamped runs directory,
+// if any, which is suggested instead of the individual run.
+func gitignoreHint(outputDir, runsBase string) string {
+	if !git.IsGitRepo(outputDir) {
+		return ""
+	}
+	var names []string
+	for _, name := range report.ReportFiles {
+		for _, n := range []string{name, name + report.EncryptedSuffix} {
+			if _, err := os.Stat(filepath.Join(outputDir, n)); err == nil {
+				names = append(names, n)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	untracked, err := git.UntrackedFiles(outputDir, names...)
+	if err != nil || len(untracked) == 0 {
+		return ""
+	}
+
+	dir := outputDir
+	if runsBase != "" {
+		dir = runsBase
+	}
+	entry := strings.Join(untracked, " ")
+	if rel := repoRelative(dir); rel != "" && rel != "." {
+		entry = filepath.ToSlash(rel) + "/"
+	}
+	return fmt.Sprintf("[hint] reports are untracked in git; add %s to .gitignore (nox clean removes them)", entry)
+}
+
+// repoRelative returns dir relative to the root of its git repository, or
+// "" if that cannot be determined.
+func repoRelative(dir string) string {
+	root, err := git.RepoRoot(dir)
+	if err != nil {
+		return ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return rel
+}
@@ -36,7 +36,7 @@ _nox_completions() {
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
-    commands="scan show explain badge serve registry plugin version baseline diff watch protect completion annotate"
+    commands="scan show explain fix badge serve registry plugin upgrade version baseline clean config policy report diff watch protect completion annotate rules trend init"
 
     case "${prev}" in
         nox)
@@ -44,15 +44,31 @@ _nox_completions() {
             return 0
             ;;
         --format)
-            COMPREPLY=( $(compgen -W "json sarif cdx spdx all" -- "${cur}") )
+            COMPREPLY=( $(compgen -W "json sarif cdx spdx csv xlsx gitlab gitlab-sast gitlab-secret-detection github all" -- "${cur}") )
             return 0
             ;;
         baseline)
-            COMPREPLY=( $(compgen -W "write update show" -- "${cur}") )
+            COMPREPLY=( $(compgen -W "init write update show review" -- "${cur}") )
+            return 0
+            ;;
+        config)
+            COMPREPLY=( $(compgen -W "show" -- "${cur}") )
+            return 0
+            ;;
+        policy)
+            COMPREPLY=( $(compgen -W "test" -- "${cur}") )
+            return 0
+            ;;
+        report)
+            COMPREPLY=( $(compgen -W "decrypt" -- "${cur}") )
             return 0
             ;;
         protect)
-            COMPREPLY=( $(compgen -W "install uninstall status" -- "${cur}") )
+            COMPREPLY=( $(compgen -W "install uninstall status pre-receive" -- "${cur}") )
+            return 0
+            ;;
+        rules)
+            COMPREPLY=( $(compgen -W "list test export" -- "${cur}") )
             return 0
             ;;
         completion)
@@ -62,7 +78,7 @@ _nox_completions() {
     esac
 
     if [[ "${cur}" == -* ]]; then
-        COMPREPLY=( $(compgen -W "--format --output --quiet --verbose --version --json --base --head --debounce" -- "${cur}") )
+        COMPREPLY=( $(compgen -W "--format --output --quiet --verbose --version --json --base --head --debounce --notify --exec --lsp --effective --path --write --force --check --apply --table --sort --min-confidence --below-severity --below-confidence --category --all --path-glob --owner --rescan --findings --expression --since --changed-files-from --dry-run --verify-pins --only-category --skip-category --only-analyzer --disable-analyzer --analyzers --color --no-ci --config --offline --encrypt-report --identity --yes --workflow --profile --output-template --report --max-findings" -- "${cur}") )
         return 0
     fi
 
@@ -80,25 +96,38 @@ _nox() {
         'scan:Scan a directory for security issues'
         'show:Inspect findings interactively'
         'explain:Explain findings using an LLM'
+        'fix:Generate patches for fixable findings'
         'badge:Generate an SVG status badge'
-        'serve:Start MCP server on stdio'
+        'serve:Start MCP server on stdio, or a web UI with --web'
         'registry:Manage plugin registries'
         'plugin:Manage and invoke plugins'
+        'upgrade:Upgrade nox to the latest release'
         'version:Print version and exit'
         'baseline:Manage finding baselines'
+        'clean:Remove nox report files'
+        'config:Show the project or effective config'
+        'policy:Evaluate the policy against a saved report'
+        'report:Decrypt an encrypted report'
         'diff:Show findings in changed files'
         'watch:Watch for changes and re-scan'
         'completion:Generate shell completions'
         'protect:Manage git pre-commit hook'
         'annotate:Annotate a PR with findings'
+        'rules:List, test and export detection rules'
+        'trend:Summarize the recorded scan history'
+        'init:Generate a .nox.yaml for this repository'
     )
 
     _arguments -C \
-        '--format[Output format]:format:(json sarif cdx spdx all)' \
+        '--format[Output format]:format:(json sarif cdx spdx csv xlsx gitlab gitlab-sast gitlab-secret-detection github all)' \
         '--output[Output directory]:directory:_files -/' \
         '(-q --quiet)'{-q,--quiet}'[Suppress output]' \
         '(-v --verbose)'{-v,--verbose}'[Verbose output]' \
         '--version[Print version]' \
+        '--color[Colorize output]:when:(auto always never)' \
+        '--no-ci[Ignore CI environment detection]' \
+        '--config[Config file instead of .nox.yaml]:file:_files' \
+        '--offline[Make no network connections]' \
         '1:command:->cmds' \
         '*::arg:->args'
 
@@ -108,14 +137,26 @@ _nox() {
             ;;
         args)
             case "${words[1]}" in
-                scan|show|explain|badge|diff|watch)
+                scan|show|explain|fix|badge|diff|watch|init)
                     _files -/
                     ;;
                 baseline)
-                    _values 'subcommand' write update show
+                    _values 'subcommand' init write update show review
+                    ;;
+                config)
+                    _values 'subcommand' show
+                    ;;
+                policy)
+                    _values 'subcommand' test
+                    ;;
+                report)
+                    _values 'subcommand' decrypt
                     ;;
                 protect)
-                    _values 'subcommand' install uninstall status
+                    _values 'subcommand' install uninstall status pre-receive
+                    ;;
+                rules)
+                    _values 'subcommand' list test export
                     ;;
                 completion)
                     _values 'shell' bash zsh fish powershell
@@ -132,24 +173,53 @@ const fishCompletion = `# nox fish completion
 complete -c nox -n '__fish_use_subcommand' -a 'scan' -d 'Scan a directory for security issues'
 complete -c nox -n '__fish_use_subcommand' -a 'show' -d 'Inspect findings interactively'
 complete -c nox -n '__fish_use_subcommand' -a 'explain' -d 'Explain findings using an LLM'
+complete -c nox -n '__fish_use_subcommand' -a 'fix' -d 'Generate patches for fixable findings'
 complete -c nox -n '__fish_use_subcommand' -a 'badge' -d 'Generate an SVG status badge'
-complete -c nox -n '__fish_use_subcommand' -a 'serve' -d 'Start MCP server on stdio'
+complete -c nox -n '__fish_use_subcommand' -a 'serve' -d 'Start MCP server on stdio, or a web UI with --web'
 complete -c nox -n '__fish_use_subcommand' -a 'registry' -d 'Manage plugin registries'
 complete -c nox -n '__fish_use_subcommand' -a 'plugin' -d 'Manage and invoke plugins'
+complete -c nox -n '__fish_use_subcommand' -a 'upgrade' -d 'Upgrade nox to the latest release'
 complete -c nox -n '__fish_use_subcommand' -a 'version' -d 'Print version and exit'
 complete -c nox -n '__fish_use_subcommand' -a 'baseline' -d 'Manage finding baselines'
+complete -c nox -n '__fish_use_subcommand' -a 'init' -d 'Generate a .nox.yaml for this repository'
+complete -c nox -n '__fish_use_subcommand' -a 'clean' -d 'Remove nox report files'
+complete -c nox -n '__fish_use_subcommand' -a 'config' -d 'Show the project or effective config'
+complete -c nox -n '__fish_use_subcommand' -a 'policy' -d 'Evaluate the policy against a saved report'
+complete -c nox -n '__fish_use_subcommand' -a 'report' -d 'Decrypt an encrypted report'
 complete -c nox -n '__fish_use_subcommand' -a 'diff' -d 'Show findings in changed files'
 complete -c nox -n '__fish_use_subcommand' -a 'watch' -d 'Watch for changes and re-scan'
 complete -c nox -n '__fish_use_subcommand' -a 'completion' -d 'Generate shell completions'
 complete -c nox -n '__fish_use_subcommand' -a 'protect' -d 'Manage git pre-commit hook'
 complete -c nox -n '__fish_use_subcommand' -a 'annotate' -d 'Annotate a PR with findings'
-complete -c nox -l format -d 'Output format' -a 'json sarif cdx spdx all'
+complete -c nox -n '__fish_use_subcommand' -a 'rules' -d 'List, test and export detection rules'
+complete -c nox -n '__fish_use_subcommand' -a 'trend' -d 'Summarize the recorded scan history'
+complete -c nox -l format -d 'Output format' -a 'json sarif cdx spdx csv xlsx gitlab gitlab-sast gitlab-secret-detection github all'
 complete -c nox -l output -d 'Output directory' -rF
 complete -c nox -s q -l quiet -d 'Suppress output'
 complete -c nox -s v -l verbose -d 'Verbose output'
 complete -c nox -l version -d 'Print version'
-complete -c nox -n '__fish_seen_subcommand_from baseline' -a 'write update show'
-complete -c nox -n '__fish_seen_subcommand_from protect' -a 'install uninstall status'
+complete -c nox -l color -d 'Colorize output' -a 'auto always never'
+complete -c nox -l no-ci -d 'Ignore CI environment detection'
+complete -c nox -l config -d 'Config file instead of .nox.yaml' -rF
+complete -c nox -l offline -d 'Make no network connections'
+complete -c nox -n '__fish_seen_subcommand_from scan' -l only-category -d 'Rule categories to scan' -a 'secrets data ai iac deps container audit custom'
+complete -c nox -n '__fish_seen_subcommand_from scan' -l skip-category -d 'Rule categories to leave out' -a 'secrets data ai iac deps container audit custom'
+complete -c nox -n '__fish_seen_subcommand_from scan' -l only-analyzer -d 'Run only this analyzer' -a 'secrets dotenv data iac ai deps container'
+complete -c nox -n '__fish_seen_subcommand_from scan' -l disable-analyzer -d 'Do not run this analyzer' -a 'secrets dotenv data iac ai deps container'
+complete -c nox -n '__fish_seen_subcommand_from scan' -l profile -d 'Write CPU and heap profiles to this directory' -rF
+complete -c nox -n '__fish_seen_subcommand_from scan' -l output-template -d 'Report file name template' -r
+complete -c nox -n '__fish_seen_subcommand_from scan' -l changed-files-from -d 'File listing changed files, or a git diff spec' -rF
+complete -c nox -n '__fish_seen_subcommand_from watch' -l lsp -d 'Serve LSP diagnostics over stdio'
+complete -c nox -n '__fish_seen_subcommand_from scan' -l dry-run -d 'List the files a scan would analyze without scanning'
+complete -c nox -n '__fish_seen_subcommand_from scan' -l verify-pins -d 'Look up pinned image digests and action SHAs online'
+complete -c nox -n '__fish_seen_subcommand_from annotate' -l max-findings -d 'Most findings listed and commented inline' -r
+complete -c nox -n '__fish_seen_subcommand_from show annotate badge' -l report -d 'Path to a findings.json report' -rF
+complete -c nox -n '__fish_seen_subcommand_from baseline' -a 'init write update show review'
+complete -c nox -n '__fish_seen_subcommand_from config' -a 'show'
+complete -c nox -n '__fish_seen_subcommand_from policy' -a 'test'
+complete -c nox -n '__fish_seen_subcommand_from report' -a 'decrypt'
+complete -c nox -n '__fish_seen_subcommand_from protect' -a 'install uninstall status pre-receive'
+complete -c nox -n '__fish_seen_subcommand_from rules' -a 'list test export'
 complete -c nox -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish powershell'
 `
 
@@ -157,7 +227,7 @@ const powershellCompletion = `# nox PowerShell completion
 Register-ArgumentCompleter -CommandName nox -ScriptBlock {
     param($wordToComplete, $commandAst, $cursorPosition)
 
-    $commands = @('scan', 'show', 'explain', 'badge', 'serve', 'registry', 'plugin', 'version', 'baseline', 'diff', 'watch', 'protect', 'completion', 'annotate')
+    $commands = @('scan', 'show', 'explain', 'fix', 'badge', 'serve', 'registry', 'plugin', 'upgrade', 'version', 'baseline', 'clean', 'config', 'policy', 'report', 'diff', 'watch', 'protect', 'completion', 'annotate', 'rules', 'trend', 'init')
 
     $commands | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
         [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
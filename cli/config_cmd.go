@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	nox "github.com/nox-hq/nox/core"
+	"gopkg.in/yaml.v3"
+)
+
+func runConfig(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: nox config show [--effective] [--path <file>] [dir]")
+		return 2
+	}
+
+	switch args[0] {
+	case "show":
+		return configShow(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown config subcommand: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: nox config show [--effective] [--path <file>] [dir]")
+		return 2
+	}
+}
+
+func configShow(args []string) int {
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	var (
+		effective bool
+		filePath  string
+	)
+	fs.BoolVar(&effective, "effective", false, "merge nested .nox.yaml files that apply to --path")
+	fs.StringVar(&filePath, "path", "", "file to resolve the effective config for, relative to dir")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+
+	if !effective {
+		if filePath != "" {
+			fmt.Fprintln(os.Stderr, "error: --path requires --effective")
+			return 2
+		}
+		cfg, err := nox.LoadScanConfig(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		return printConfig(cfg)
+	}
+
+	if filePath == "" {
+		filePath = "."
+	}
+	display := filepath.ToSlash(filepath.Clean(filePath))
+	rel := display
+	if info, err := os.Stat(filepath.Join(root, filePath)); err == nil && info.IsDir() {
+		// Resolve the directory itself rather than its parent.
+		rel = path.Join(rel, nox.ConfigFileName)
+	}
+	tree, err := nox.LoadEffectiveConfig(root, rel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	fmt.Printf("# effective config for %s\n", display)
+	for _, src := range tree.Sources(rel) {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(src))); err == nil {
+			fmt.Printf("# from %s\n", src)
+		}
+	}
+	if tree.Excluded(rel) {
+		fmt.Println("# excluded from scanning")
+	}
+	return printConfig(tree.Effective(rel))
+}
+
+func printConfig(cfg *nox.ScanConfig) int {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: encoding config: %v\n", err)
+		return 2
+	}
+	if string(data) == "{}\n" {
+		data = nil
+	}
+	fmt.Print(string(data))
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConfig_Usage(t *testing.T) {
+	if code := runConfig(nil); code != 2 {
+		t.Fatalf("expected exit code 2 for no args, got %d", code)
+	}
+	if code := runConfig([]string{"edit"}); code != 2 {
+		t.Fatalf("expected exit code 2 for unknown subcommand, got %d", code)
+	}
+	if code := runConfig([]string{"show", "--path", "main.go", t.TempDir()}); code != 2 {
+		t.Fatalf("expected exit code 2 for --path without --effective, got %d", code)
+	}
+}
+
+func TestRunConfig_ShowEffective(t *testing.T) {
+	dir := t.TempDir()
+	for rel, content := range map[string]string{
+		".nox.yaml":       "scan:\n  rules:\n    disable: [IAC-001, SEC-001]\npolicy:\n  fail_on: high\n",
+		"infra/.nox.yaml": "scan:\n  exclude: [fixtures/]\n  rules:\n    enable: [IAC-001]\n    severity_override:\n      IAC-002: critical\n",
+		"docs/.nox.yaml":  "scan:\n  rules:\n    disable: [SEC-002]\n",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, out := captureRulesOutput(t, func() int {
+		return runConfig([]string{"show", "--effective", "--path", "infra/main.tf", dir})
+	})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	for _, want := range []string{
+		"# effective config for infra/main.tf",
+		"# from .nox.yaml",
+		"# from infra/.nox.yaml",
+		"- SEC-001",
+		"IAC-002: critical",
+		"- infra/**/fixtures/",
+		"fail_on: high",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"IAC-001", "SEC-002", "docs/.nox.yaml", "excluded"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, out)
+		}
+	}
+
+	_, out = captureRulesOutput(t, func() int {
+		return runConfig([]string{"show", "--effective", "--path", "infra/fixtures/x.tf", dir})
+	})
+	if !strings.Contains(out, "# excluded from scanning") {
+		t.Errorf("expected excluded note, got:\n%s", out)
+	}
+
+	_, out = captureRulesOutput(t, func() int {
+		return runConfig([]string{"show", dir})
+	})
+	if !strings.Contains(out, "- IAC-001") || strings.Contains(out, "#") {
+		t.Errorf("expected the root config only, got:\n%s", out)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -8,7 +9,6 @@ import (
 	"path/filepath"
 	"runtime"
 
-	nox "github.com/nox-hq/nox/core"
 	"github.com/nox-hq/nox/server"
 )
 
@@ -30,7 +30,7 @@ func runDashboard(args []string) int {
 	}
 
 	fmt.Printf("nox — scanning %s\n", target)
-	result, err := nox.RunScan(target)
+	result, err := scanDir(context.Background(), target)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
 		return 2
@@ -4,9 +4,15 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
+	"github.com/nox-hq/nox/core/annotate"
+	"github.com/nox-hq/nox/core/codeowners"
 	"github.com/nox-hq/nox/core/diff"
+	"github.com/nox-hq/nox/core/findings"
 )
 
 func runDiff(args []string) int {
@@ -16,24 +22,44 @@ func runDiff(args []string) int {
 		head      string
 		rulesPath string
 		jsonFlag  bool
+		format    string
+		noRenames bool
 	)
 	fs.StringVar(&base, "base", "main", "base ref for comparison")
 	fs.StringVar(&head, "head", "HEAD", "head ref for comparison")
 	fs.StringVar(&rulesPath, "rules", "", "path to custom rules YAML file or directory")
-	fs.BoolVar(&jsonFlag, "json", false, "output as JSON")
+	fs.BoolVar(&jsonFlag, "json", false, "output as JSON (same as --format json)")
+	fs.StringVar(&format, "format", "", "output format: text, json, github (default: github in GitHub Actions, text otherwise)")
+	fs.BoolVar(&noRenames, "no-renames", false, "do not detect renamed files; report every finding in a renamed file as new")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
 
+	switch {
+	case jsonFlag:
+		format = "json"
+	case format == "" && inGitHubActions():
+		format = "github"
+	case format == "":
+		format = "text"
+	}
+	if format != "text" && format != "json" && format != "github" {
+		fmt.Fprintf(os.Stderr, "error: unknown format %q (want text, json or github)\n", format)
+		return 2
+	}
+
 	target := "."
 	if fs.NArg() > 0 {
 		target = fs.Arg(0)
 	}
 
 	result, err := diff.Run(target, diff.Options{
-		Base:      base,
-		Head:      head,
-		RulesPath: rulesPath,
+		Base:       base,
+		Head:       head,
+		RulesPath:  rulesPath,
+		ConfigPath: configPath,
+		NoRenames:  noRenames,
+		Offline:    offlineMode,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -45,19 +71,23 @@ func runDiff(args []string) int {
 		return 0
 	}
 
-	if jsonFlag {
+	switch format {
+	case "json":
 		data, err := json.MarshalIndent(result.Findings, "", "  ")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: marshalling: %v\n", err)
 			return 2
 		}
 		fmt.Println(string(data))
-	} else {
-		fmt.Printf("diff: %d finding(s) in %d changed file(s) (%s...%s)\n",
-			len(result.Findings), len(result.ChangedFiles), result.Base, result.Head)
-		for _, f := range result.Findings {
-			fmt.Printf("  [%s] %s:%d — %s (%s)\n", f.Severity, f.File, f.Line, f.Message, f.RuleID)
+	case "github":
+		// Only new findings in changed files are annotated.
+		printDiffSummary(result)
+		for _, line := range annotate.WorkflowCommands(diffFindings(result.Findings)) {
+			fmt.Println(line)
 		}
+	default:
+		printDiffSummary(result)
+		printDiffFindings(result.Findings)
 	}
 
 	if len(result.Findings) > 0 {
@@ -65,3 +95,60 @@ func runDiff(args []string) int {
 	}
 	return 0
 }
+
+// printDiffSummary prints the number of new and unchanged findings.
+func printDiffSummary(result *diff.Result) {
+	fmt.Printf("diff: %d new finding(s), %d unchanged in %d changed file(s) (%s...%s)\n",
+		len(result.Findings), len(result.Unchanged), len(result.ChangedFiles), result.Base, result.Head)
+}
+
+// printDiffFindings prints ff, grouped under each CODEOWNERS owner when
+// the repository has a CODEOWNERS file. A finding with several owners is
+// listed under each.
+func printDiffFindings(ff []diff.Finding) {
+	byOwner := make(map[string][]diff.Finding)
+	for _, f := range ff {
+		for _, o := range f.Owners {
+			byOwner[o] = append(byOwner[o], f)
+		}
+	}
+	if len(byOwner) == 0 {
+		for _, f := range ff {
+			printDiffFinding(f)
+		}
+		return
+	}
+	owners := slices.Sorted(maps.Keys(byOwner))
+	// The unowned bucket goes last.
+	if i := slices.Index(owners, codeowners.Unowned); i >= 0 {
+		owners = append(slices.Delete(owners, i, i+1), codeowners.Unowned)
+	}
+	for _, o := range owners {
+		fmt.Printf("%s (%d):\n", o, len(byOwner[o]))
+		for _, f := range byOwner[o] {
+			printDiffFinding(f)
+		}
+	}
+}
+
+func printDiffFinding(f diff.Finding) {
+	fmt.Printf("  [%s] %s:%d — %s (%s)\n", colorSeverity(findings.Severity(f.Severity), f.Severity), f.File, f.Line, f.Message, f.RuleID)
+}
+
+// diffFindings converts diff findings back to findings for annotation.
+func diffFindings(ff []diff.Finding) []findings.Finding {
+	out := make([]findings.Finding, 0, len(ff))
+	for _, f := range ff {
+		finding := findings.Finding{
+			RuleID:   f.RuleID,
+			Severity: findings.Severity(f.Severity),
+			Message:  f.Message,
+			Location: findings.Location{FilePath: f.File, StartLine: f.Line, EndLine: f.EndLine},
+		}
+		if len(f.Owners) > 0 {
+			finding.Metadata = map[string]string{findings.MetaOwners: strings.Join(f.Owners, " ")}
+		}
+		out = append(out, finding)
+	}
+	return out
+}
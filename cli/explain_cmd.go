@@ -11,6 +11,7 @@ import (
 
 	"github.com/nox-hq/nox/assist"
 	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/offline"
 	"github.com/nox-hq/nox/plugin"
 )
 
@@ -24,7 +25,7 @@ func runExplain(args []string) int {
 		if strings.HasPrefix(args[i], "-") {
 			flagArgs = append(flagArgs, args[i])
 			// If this flag takes a value (not a boolean), consume the next arg too.
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") && !isFixBoolFlag(args[i]) {
 				i++
 				flagArgs = append(flagArgs, args[i])
 			}
@@ -43,6 +44,8 @@ func runExplain(args []string) int {
 		pluginDir string
 		enrich    string
 		timeout   time.Duration
+		apply     applyMode
+		force     bool
 	)
 
 	fs.StringVar(&model, "model", "gpt-4o", "LLM model name")
@@ -52,6 +55,8 @@ func runExplain(args []string) int {
 	fs.StringVar(&pluginDir, "plugin-dir", "", "directory containing plugin binaries for enrichment")
 	fs.StringVar(&enrich, "enrich", "", "comma-separated list of read-only plugin tools to invoke for enrichment")
 	fs.DurationVar(&timeout, "timeout", 2*time.Minute, "timeout per LLM request")
+	fs.Var(&apply, "apply", "print patches for fixable findings instead of explaining them; --apply=write applies them")
+	fs.BoolVar(&force, "force", false, "with --apply=write, also modify files that have unstaged changes")
 
 	if err := fs.Parse(flagArgs); err != nil {
 		return 2
@@ -72,6 +77,23 @@ func runExplain(args []string) int {
 	}
 	applyExplainDefaults(fs, cfg)
 
+	// Fixers are deterministic and need no LLM.
+	if apply != "" {
+		fmt.Fprintf(os.Stderr, "nox — scanning %s\n", target)
+		result, err := scanDir(context.Background(), target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
+			return 2
+		}
+		return applyFixes(target, result.Findings.ActiveFindings(), fixOptions{write: apply == applyWrite, force: force})
+	}
+
+	// Offline, only a model served on this machine may be used.
+	if offlineMode && !offline.IsLoopbackURL(baseURL) {
+		fmt.Fprintln(os.Stderr, "error: explain needs a remote LLM provider, which offline mode forbids; set --base-url to a local endpoint such as http://localhost:11434/v1, or use --apply")
+		return 2
+	}
+
 	// Check for API key.
 	apiKeyEnv := "OPENAI_API_KEY"
 	if cfg.Explain.APIKeyEnv != "" {
@@ -84,7 +106,7 @@ func runExplain(args []string) int {
 
 	// Run scan.
 	fmt.Printf("nox — scanning %s\n", target)
-	result, err := nox.RunScan(target)
+	result, err := scanDir(context.Background(), target)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
 		return 2
@@ -177,6 +199,34 @@ func runExplain(args []string) int {
 	return 0
 }
 
+// applyMode is the value of the explain --apply flag. A bare --apply prints
+// patches; --apply=write also applies them.
+type applyMode string
+
+const (
+	applyDiff  applyMode = "diff"
+	applyWrite applyMode = "write"
+)
+
+func (m *applyMode) String() string { return string(*m) }
+
+func (m *applyMode) Set(s string) error {
+	switch s {
+	case "true", "diff":
+		*m = applyDiff
+	case "write":
+		*m = applyWrite
+	case "false":
+		*m = ""
+	default:
+		return fmt.Errorf("invalid --apply value %q: want diff or write", s)
+	}
+	return nil
+}
+
+// IsBoolFlag lets --apply be given without a value.
+func (m *applyMode) IsBoolFlag() bool { return true }
+
 // applyExplainDefaults applies .nox.yaml explain settings as defaults for any
 // flags that were not explicitly set on the command line.
 func applyExplainDefaults(fs *flag.FlagSet, cfg *nox.ScanConfig) {
@@ -313,3 +313,24 @@ func TestRunExplain_LoadConfigError(t *testing.T) {
 		t.Fatalf("expected exit code 2 for config load error, got %d", code)
 	}
 }
+
+func TestRunExplain_OfflineRefusesRemoteProvider(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("NOX_OFFLINE", "1")
+	t.Cleanup(func() { offlineMode = false })
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"explain", dir},
+		{"explain", dir, "--base-url", "https://llm.example.com/v1"},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("%v: expected exit code 2 in offline mode, got %d", args, code)
+		}
+	}
+
+	// A local endpoint is allowed; the empty directory has nothing to explain.
+	if code := run([]string{"explain", dir, "--base-url", "http://localhost:11434/v1"}); code != 0 {
+		t.Fatalf("expected exit code 0 with a local endpoint, got %d", code)
+	}
+}
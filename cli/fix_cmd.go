@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/fix"
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/offline"
+)
+
+// fixOptions controls how generated fixes are applied.
+type fixOptions struct {
+	// write applies the patches instead of only printing them.
+	write bool
+	// force writes files that have unstaged changes.
+	force bool
+	// rules limits fixes to these rule IDs. Empty means all fixable rules.
+	rules []string
+	// githubAPIURL is the GitHub API that action refs are resolved
+	// against. Empty means GITHUB_API_URL, or github.com.
+	githubAPIURL string
+	// registry overrides the built-in fixers, for tests.
+	registry *fix.Registry
+}
+
+// runFix implements the "nox fix" command.
+func runFix(args []string) int {
+	var flagArgs []string
+	var positionalArgs []string
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") {
+			flagArgs = append(flagArgs, args[i])
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") && !isFixBoolFlag(args[i]) {
+				i++
+				flagArgs = append(flagArgs, args[i])
+			}
+		} else {
+			positionalArgs = append(positionalArgs, args[i])
+		}
+	}
+
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	var (
+		opts  fixOptions
+		rules string
+	)
+	fs.BoolVar(&opts.write, "write", false, "apply the patches to the files")
+	fs.BoolVar(&opts.force, "force", false, "with --write, also modify files that have unstaged changes")
+	fs.StringVar(&rules, "rules", "", "comma-separated rule IDs to fix (default: all fixable rules)")
+	fs.StringVar(&opts.githubAPIURL, "github-api-url", "", "GitHub API URL to resolve action tags with, e.g. https://ghe.example.com/api/v3 (default: $GITHUB_API_URL, or https://api.github.com)")
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	positionalArgs = append(positionalArgs, fs.Args()...)
+	if rules != "" {
+		for _, id := range strings.Split(rules, ",") {
+			opts.rules = append(opts.rules, strings.TrimSpace(id))
+		}
+	}
+
+	target := "."
+	if len(positionalArgs) > 0 {
+		target = positionalArgs[0]
+	}
+
+	fmt.Fprintf(os.Stderr, "nox — scanning %s\n", target)
+	result, err := scanDir(context.Background(), target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
+		return 2
+	}
+	return applyFixes(target, result.Findings.ActiveFindings(), opts)
+}
+
+// isFixBoolFlag reports whether a flag of nox fix or nox explain takes no
+// value.
+func isFixBoolFlag(arg string) bool {
+	switch strings.TrimLeft(arg, "-") {
+	case "write", "force", "apply":
+		return true
+	}
+	return false
+}
+
+// applyFixes generates patches for the findings that have a fixer, prints
+// them as a unified diff on stdout and, with opts.write, applies them.
+// Progress and skipped findings are reported on stderr so that the diff can
+// be piped to git apply.
+func applyFixes(target string, ff []findings.Finding, opts fixOptions) int {
+	reg := opts.registry
+	if reg == nil {
+		resolverOpts := []fix.ResolverOption{
+			fix.WithGitHubBaseURL(githubHost(opts.githubAPIURL, "").APIURL),
+			fix.WithGitHubToken(githubToken()),
+		}
+		if offlineMode {
+			resolverOpts = append(resolverOpts, fix.WithHTTPClient(offline.Client()))
+		}
+		reg = fix.Builtin(fix.NewHTTPResolver(resolverOpts...))
+	}
+	if len(opts.rules) > 0 {
+		keep := make(map[string]bool, len(opts.rules))
+		for _, id := range opts.rules {
+			keep[id] = true
+		}
+		var filtered []findings.Finding
+		for _, f := range ff {
+			if keep[f.RuleID] {
+				filtered = append(filtered, f)
+			}
+		}
+		ff = filtered
+	}
+
+	patches, skipped := reg.Plan(context.Background(), target, ff)
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "[fix] skipped %s at %s:%d: %v\n", s.Finding.RuleID, s.Finding.Location.FilePath, s.Finding.Location.StartLine, s.Err)
+	}
+	if len(patches) == 0 {
+		fmt.Fprintln(os.Stderr, "[fix] nothing to fix")
+		return 0
+	}
+	for _, p := range patches {
+		fmt.Print(p.Diff())
+	}
+	if !opts.write {
+		fmt.Fprintf(os.Stderr, "[fix] %d file(s) can be fixed; re-run with --write to apply\n", len(patches))
+		return 0
+	}
+
+	if !opts.force {
+		dirty, err := unstagedPatchFiles(target, patches)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		if len(dirty) > 0 {
+			fmt.Fprintf(os.Stderr, "error: refusing to modify files with unstaged changes (use --force): %s\n", strings.Join(dirty, ", "))
+			return 2
+		}
+	}
+	if err := fix.Apply(target, patches); err != nil {
+		fmt.Fprintf(os.Stderr, "error: applying fixes: %v\n", err)
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "[fix] wrote %d file(s)\n", len(patches))
+	return 0
+}
+
+// unstagedPatchFiles returns the paths of patched files that have unstaged
+// changes or are untracked. Outside a git repository nothing is reported.
+func unstagedPatchFiles(target string, patches []*fix.Patch) ([]string, error) {
+	if !git.IsGitRepo(target) {
+		return nil, nil
+	}
+	root, err := git.RepoRoot(target)
+	if err != nil {
+		return nil, err
+	}
+	files, err := git.UnstagedFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	unstaged := make(map[string]bool, len(files))
+	for _, f := range files {
+		unstaged[filepath.FromSlash(f)] = true
+	}
+
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(absTarget); err == nil {
+		absTarget = resolved
+	}
+	var dirty []string
+	for _, p := range patches {
+		rel, err := filepath.Rel(root, filepath.Join(absTarget, p.Path))
+		if err != nil || unstaged[rel] {
+			dirty = append(dirty, p.Path)
+		}
+	}
+	return dirty, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/fix"
+)
+
+type stubResolver struct{}
+
+func (stubResolver) ActionCommit(context.Context, string, string) (string, error) {
+	return strings.Repeat("a", 40), nil
+}
+
+func (stubResolver) ImageDigest(context.Context, string, string) (string, error) {
+	return "sha256:" + strings.Repeat("b", 64), nil
+}
+
+func fixTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine:3.20\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"add", "."},
+		{"commit", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if err := cmd.Run(); err != nil {
+			t.Skipf("git %s: %v", args[0], err)
+		}
+	}
+	return dir
+}
+
+var dockerfileFinding = []findings.Finding{{
+	RuleID:   "CONT-001",
+	Location: findings.Location{FilePath: "Dockerfile", StartLine: 1},
+}}
+
+func TestApplyFixes_PrintsDiff(t *testing.T) {
+	dir := fixTestRepo(t)
+	opts := fixOptions{registry: fix.Builtin(stubResolver{})}
+
+	code, out := captureRulesOutput(t, func() int { return applyFixes(dir, dockerfileFinding, opts) })
+	if code != 0 {
+		t.Fatalf("exit code = %d", code)
+	}
+	want := "-FROM alpine:3.20\n+FROM alpine:3.20@sha256:" + strings.Repeat("b", 64) + "\n"
+	if !strings.Contains(out, "--- a/Dockerfile\n+++ b/Dockerfile\n") || !strings.Contains(out, want) {
+		t.Errorf("unexpected diff:\n%s", out)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if string(got) != "FROM alpine:3.20\n" {
+		t.Errorf("Dockerfile was modified without --write: %q", got)
+	}
+}
+
+func TestApplyFixes_Write(t *testing.T) {
+	dir := fixTestRepo(t)
+	opts := fixOptions{write: true, registry: fix.Builtin(stubResolver{})}
+
+	if code, _ := captureRulesOutput(t, func() int { return applyFixes(dir, dockerfileFinding, opts) }); code != 0 {
+		t.Fatalf("exit code = %d", code)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if !strings.HasPrefix(string(got), "FROM alpine:3.20@sha256:") {
+		t.Errorf("Dockerfile = %q", got)
+	}
+}
+
+func TestApplyFixes_RefusesUnstagedChanges(t *testing.T) {
+	dir := fixTestRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine:3.19\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := fixOptions{write: true, registry: fix.Builtin(stubResolver{})}
+
+	if code, _ := captureRulesOutput(t, func() int { return applyFixes(dir, dockerfileFinding, opts) }); code != 2 {
+		t.Fatalf("expected exit code 2 for a file with unstaged changes, got %d", code)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if string(got) != "FROM alpine:3.19\n" {
+		t.Fatalf("Dockerfile was modified: %q", got)
+	}
+
+	opts.force = true
+	if code, _ := captureRulesOutput(t, func() int { return applyFixes(dir, dockerfileFinding, opts) }); code != 0 {
+		t.Fatalf("expected --force to write, got exit code %d", code)
+	}
+	got, _ = os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if !strings.HasPrefix(string(got), "FROM alpine:3.19@sha256:") {
+		t.Errorf("Dockerfile = %q", got)
+	}
+}
+
+func TestApplyFixes_RulesFilter(t *testing.T) {
+	dir := fixTestRepo(t)
+	opts := fixOptions{rules: []string{"IAC-013"}, registry: fix.Builtin(stubResolver{})}
+
+	code, out := captureRulesOutput(t, func() int { return applyFixes(dir, dockerfileFinding, opts) })
+	if code != 0 || out != "" {
+		t.Fatalf("expected no patches for other rules, got %d %q", code, out)
+	}
+}
+
+func TestApplyMode(t *testing.T) {
+	tests := []struct {
+		args []string
+		want applyMode
+	}{
+		{nil, ""},
+		{[]string{"--apply"}, applyDiff},
+		{[]string{"--apply=write"}, applyWrite},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+		var m applyMode
+		fs.Var(&m, "apply", "")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if m != tt.want {
+			t.Errorf("%v: mode = %q, want %q", tt.args, m, tt.want)
+		}
+	}
+	var m applyMode
+	if err := m.Set("all"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// initWorkflowPath is where nox init writes the GitHub Actions workflow,
+// relative to the repository root.
+var initWorkflowPath = filepath.Join(".github", "workflows", "nox.yml")
+
+// runInit inspects a repository, asks a few questions and writes a
+// commented .nox.yaml and, optionally, a GitHub Actions workflow.
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	var (
+		yes      bool
+		force    bool
+		workflow bool
+	)
+	fs.BoolVar(&yes, "yes", false, "do not prompt; accept the suggested settings")
+	fs.BoolVar(&force, "force", false, "overwrite an existing .nox.yaml and workflow")
+	fs.BoolVar(&workflow, "workflow", false, "also write "+filepath.ToSlash(initWorkflowPath)+" (default: suggested when .github exists)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	workflowSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "workflow" {
+			workflowSet = true
+		}
+	})
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "error: %s is not a directory\n", root)
+		return 2
+	}
+
+	configFile := filepath.Join(root, nox.ConfigFileName)
+	if _, err := os.Stat(configFile); err == nil && !force {
+		fmt.Fprintf(os.Stderr, "error: %s already exists; use --force to overwrite it\n", configFile)
+		return 2
+	}
+	if !yes && !interactiveTerminal() {
+		fmt.Fprintln(os.Stderr, "error: nox init prompts on a terminal; pass --yes to accept the suggested settings")
+		return 2
+	}
+
+	profile, err := inspectRepo(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: inspecting %s: %v\n", root, err)
+		return 2
+	}
+
+	settings := profile.defaults()
+	if workflowSet {
+		settings.Workflow = workflow
+	}
+	fmt.Println("nox init — detected:")
+	for _, line := range profile.describe() {
+		fmt.Printf("  %s\n", line)
+	}
+	if !yes {
+		p := &initPrompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		settings = p.ask(profile, settings, !workflowSet)
+	}
+
+	workflowFile := filepath.Join(root, initWorkflowPath)
+	if settings.Workflow && !force {
+		if _, err := os.Stat(workflowFile); err == nil {
+			fmt.Fprintf(os.Stderr, "error: %s already exists; use --force to overwrite it\n", workflowFile)
+			return 2
+		}
+	}
+
+	config, err := renderInitConfig(profile, settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := os.WriteFile(configFile, config, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", configFile, err)
+		return 2
+	}
+	fmt.Printf("Wrote %s\n", configFile)
+
+	if settings.Workflow {
+		wf, err := renderInitWorkflow(settings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		if err := os.MkdirAll(filepath.Dir(workflowFile), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		if err := os.WriteFile(workflowFile, wf, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", workflowFile, err)
+			return 2
+		}
+		fmt.Printf("Wrote %s\n", workflowFile)
+	}
+
+	fmt.Println("\nNext steps:")
+	fmt.Printf("  nox scan %s\n", root)
+	if settings.Workflow {
+		// The template refers to actions by tag, which IAC-013 reports.
+		fmt.Printf("  nox fix --rules IAC-013 --write %s   # pin the workflow's actions to commit SHAs\n", root)
+	}
+	return 0
+}
+
+// initSettings are the choices nox init writes to .nox.yaml.
+type initSettings struct {
+	FailOn           string
+	Formats          string
+	Exclude          []string
+	InternalPrefixes []string
+	Workflow         bool
+}
+
+// repoProfile is what nox init learns about a repository.
+type repoProfile struct {
+	// Languages are ordered by file count, most first.
+	Languages   []string
+	Dockerfiles []string
+	Workflows   []string
+	IaC         []string
+	// Packages are the directories with a package manifest, when there is
+	// more than one or a workspace file, i.e. a monorepo.
+	Packages []string
+	// Vendored are gitignore patterns for vendored dependency directories
+	// that the repository's .gitignore does not already exclude.
+	Vendored []string
+	// PublicRegistries is set when npm or PyPI manifests were found, which
+	// the dependency confusion checks apply to.
+	PublicRegistries bool
+	// GitHub is set when the repository has a .github directory.
+	GitHub bool
+}
+
+// Files and directories recognized by inspectRepo.
+var (
+	initLanguages = map[string]string{
+		".go": "Go", ".py": "Python", ".js": "JavaScript", ".jsx": "JavaScript",
+		".mjs": "JavaScript", ".cjs": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript",
+		".java": "Java", ".kt": "Kotlin", ".scala": "Scala", ".rb": "Ruby", ".rs": "Rust",
+		".php": "PHP", ".cs": "C#", ".swift": "Swift", ".c": "C", ".h": "C",
+		".cc": "C++", ".cpp": "C++", ".hpp": "C++", ".sh": "Shell",
+	}
+	initManifests = map[string]bool{
+		"go.mod": true, "package.json": true, "pyproject.toml": true, "setup.py": true,
+		"Cargo.toml": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true,
+		"Gemfile": true, "composer.json": true,
+	}
+	initWorkspaceFiles = map[string]bool{
+		"go.work": true, "pnpm-workspace.yaml": true, "lerna.json": true, "nx.json": true, "turbo.json": true,
+	}
+	initPublicManifests = map[string]bool{
+		"package.json": true, "pyproject.toml": true, "setup.py": true, "Pipfile": true,
+	}
+	initVendorDirs = map[string]bool{
+		"vendor": true, "node_modules": true, "third_party": true, "third-party": true,
+		"bower_components": true, "Pods": true, ".venv": true, "venv": true,
+	}
+)
+
+// inspectRepo walks root, skipping .git, gitignored paths and vendored
+// directories, and records what the generated config should cover.
+func inspectRepo(root string) (*repoProfile, error) {
+	ignore, _ := discovery.LoadGitignore(root)
+	prof := &repoProfile{}
+	langCount := make(map[string]int)
+	manifestDirs := make(map[string]bool)
+	iac := make(map[string]bool)
+	vendored := make(map[string]bool)
+	workspace := false
+
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if file == root {
+				return err
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		name := d.Name()
+		if d.IsDir() {
+			switch {
+			case name == ".git":
+				return filepath.SkipDir
+			case discovery.IsIgnored(rel+"/", ignore) || discovery.IsIgnored(rel, ignore):
+				return filepath.SkipDir
+			case initVendorDirs[name]:
+				vendored[name+"/"] = true
+				return filepath.SkipDir
+			case rel == ".github":
+				prof.GitHub = true
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || discovery.IsIgnored(rel, ignore) {
+			return nil
+		}
+
+		dir := path.Dir(rel)
+		ext := strings.ToLower(path.Ext(name))
+		if lang, ok := initLanguages[ext]; ok {
+			langCount[lang]++
+		}
+		if initManifests[name] {
+			manifestDirs[dir] = true
+		}
+		if initWorkspaceFiles[name] {
+			workspace = true
+		}
+		if initPublicManifests[name] || strings.HasPrefix(name, "requirements") && ext == ".txt" {
+			prof.PublicRegistries = true
+		}
+		switch {
+		case name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || ext == ".dockerfile" || name == "Containerfile":
+			prof.Dockerfiles = append(prof.Dockerfiles, rel)
+		case dir == ".github/workflows" && (ext == ".yml" || ext == ".yaml"):
+			prof.Workflows = append(prof.Workflows, rel)
+		case ext == ".tf":
+			iac["Terraform"] = true
+		case name == "Chart.yaml":
+			iac["Helm"] = true
+		case name == "kustomization.yaml" || name == "kustomization.yml":
+			iac["Kustomize"] = true
+		case ext == ".yml" || ext == ".yaml" || ext == ".json" || ext == ".template":
+			if kind := sniffIaC(file); kind != "" {
+				iac[kind] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prof.Languages = slices.SortedFunc(maps.Keys(langCount), func(a, b string) int {
+		return cmp.Or(cmp.Compare(langCount[b], langCount[a]), cmp.Compare(a, b))
+	})
+	prof.IaC = slices.Sorted(maps.Keys(iac))
+	prof.Vendored = slices.Sorted(maps.Keys(vendored))
+	if len(manifestDirs) > 1 || workspace {
+		prof.Packages = slices.Sorted(maps.Keys(manifestDirs))
+	}
+	return prof, nil
+}
+
+// sniffIaC reports whether the start of a YAML or JSON file looks like a
+// Kubernetes manifest or a CloudFormation template.
+func sniffIaC(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	head := make([]byte, 4096)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	switch {
+	case bytes.Contains(head, []byte("AWSTemplateFormatVersion")):
+		return "CloudFormation"
+	case bytes.Contains(head, []byte("apiVersion:")) && bytes.Contains(head, []byte("kind:")):
+		return "Kubernetes"
+	}
+	return ""
+}
+
+// describe returns one line per detected property, for the summary
+// printed by nox init and the header of the generated config.
+func (p *repoProfile) describe() []string {
+	var lines []string
+	add := func(label string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		if len(items) > 5 {
+			items = append(slices.Clip(items[:5]), fmt.Sprintf("and %d more", len(items)-5))
+		}
+		lines = append(lines, label+": "+strings.Join(items, ", "))
+	}
+	add("languages", p.Languages)
+	add("Dockerfiles", p.Dockerfiles)
+	add("workflows", p.Workflows)
+	add("infrastructure as code", p.IaC)
+	add("packages", p.Packages)
+	add("vendored directories", p.Vendored)
+	if len(lines) == 0 {
+		lines = append(lines, "nothing nox recognizes yet")
+	}
+	return lines
+}
+
+// defaults returns the settings nox init suggests for the repository.
+func (p *repoProfile) defaults() initSettings {
+	return initSettings{
+		FailOn:   string(findings.SeverityHigh),
+		Formats:  "json,sarif",
+		Exclude:  slices.Clone(p.Vendored),
+		Workflow: p.GitHub,
+	}
+}
+
+// initPrompter asks the nox init questions on in and out.
+type initPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask returns s updated with the answers. Empty answers, and the end of
+// the input, keep the suggested value. The workflow question is only asked
+// when askWorkflow is set, i.e. --workflow was not given.
+func (r *initPrompter) ask(p *repoProfile, s initSettings, askWorkflow bool) initSettings {
+	fmt.Fprintln(r.out)
+	for {
+		answer := strings.ToLower(r.line(fmt.Sprintf("Fail on findings of severity (critical, high, medium, low) [%s]: ", s.FailOn)))
+		if answer == "" {
+			break
+		}
+		if sev := findings.Severity(answer); sev != findings.SeverityInfo && slices.Contains(findings.Severities, sev) {
+			s.FailOn = answer
+			break
+		}
+		fmt.Fprintf(r.out, "  %q is not a severity\n", answer)
+	}
+	for {
+		answer := strings.ToLower(strings.ReplaceAll(r.line(fmt.Sprintf("Report formats (json, sarif, cdx, spdx, csv, xlsx, gitlab, github, all) [%s]: ", s.Formats)), " ", ""))
+		if answer == "" {
+			break
+		}
+		if err := checkInitFormats(answer); err != nil {
+			fmt.Fprintf(r.out, "  %v\n", err)
+			continue
+		}
+		s.Formats = answer
+		break
+	}
+	if len(p.Vendored) > 0 && !r.confirm(fmt.Sprintf("Exclude vendored directories %s? [Y/n]: ", strings.Join(p.Vendored, ", ")), true) {
+		s.Exclude = nil
+	}
+	if extra := splitList(r.line("Other paths to exclude, comma-separated (e.g. testdata/) []: ")); len(extra) > 0 {
+		s.Exclude = append(s.Exclude, extra...)
+	}
+	if p.PublicRegistries {
+		s.InternalPrefixes = splitList(r.line("Internal npm scopes or package prefixes you publish, comma-separated (e.g. @acme/) []: "))
+	}
+	if askWorkflow {
+		def := "y/N"
+		if s.Workflow {
+			def = "Y/n"
+		}
+		s.Workflow = r.confirm(fmt.Sprintf("Write a GitHub Actions workflow to %s? [%s]: ", filepath.ToSlash(initWorkflowPath), def), s.Workflow)
+	}
+	return s
+}
+
+// line prints prompt and returns the trimmed answer.
+func (r *initPrompter) line(prompt string) string {
+	fmt.Fprint(r.out, prompt)
+	answer, _ := r.in.ReadString('\n')
+	return strings.TrimSpace(answer)
+}
+
+// confirm asks a yes/no question, returning def for an empty answer.
+func (r *initPrompter) confirm(prompt string, def bool) bool {
+	answer := strings.ToLower(r.line(prompt))
+	if answer == "" {
+		return def
+	}
+	return strings.HasPrefix(answer, "y")
+}
+
+// checkInitFormats reports formats that nox scan does not write.
+func checkInitFormats(formats string) error {
+	for _, f := range splitList(formats) {
+		switch f {
+		case "json", "sarif", "cdx", "spdx", "csv", "xlsx", "gitlab", "gitlab-sast", "gitlab-secret-detection", "github", "all":
+		default:
+			return fmt.Errorf("unknown format %q", f)
+		}
+	}
+	if len(splitList(formats)) == 0 {
+		return errors.New("no formats given")
+	}
+	return nil
+}
+
+// renderInitConfig renders the commented .nox.yaml for s.
+func renderInitConfig(p *repoProfile, s initSettings) ([]byte, error) {
+	var detected []string
+	if len(p.Languages)+len(p.Dockerfiles)+len(p.Workflows)+len(p.IaC)+len(p.Packages)+len(p.Vendored) > 0 {
+		detected = p.describe()
+	}
+	return renderInitTemplate("templates/init_nox.yaml.tmpl", map[string]any{
+		"Detected":         detected,
+		"Exclude":          s.Exclude,
+		"Packages":         p.Packages,
+		"Formats":          s.Formats,
+		"FailOn":           s.FailOn,
+		"AskPrefixes":      p.PublicRegistries,
+		"InternalPrefixes": s.InternalPrefixes,
+	})
+}
+
+// renderInitWorkflow renders the GitHub Actions workflow for s. SARIF is
+// uploaded to code scanning when s writes it.
+func renderInitWorkflow(s initSettings) ([]byte, error) {
+	formats := parseFormats(s.Formats)
+	return renderInitTemplate("templates/init_workflow.yml.tmpl", map[string]any{
+		"Formats":     s.Formats,
+		"UploadSARIF": slices.Contains(formats, "sarif"),
+	})
+}
+
+func renderInitTemplate(name string, data any) ([]byte, error) {
+	content, err := templateFS.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading template %s: %w", name, err)
+	}
+	tmpl, err := template.New(name).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("executing template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
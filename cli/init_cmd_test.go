@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	nox "github.com/nox-hq/nox/core"
+	"gopkg.in/yaml.v3"
+)
+
+// writeInitRepo creates a small monorepo with a Dockerfile, a workflow,
+// Terraform and a vendored directory.
+func writeInitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                   "module example.com/app\n",
+		"main.go":                  "package main\n",
+		"cmd/tool/main.go":         "package main\n",
+		"web/package.json":         `{"name": "web"}`,
+		"web/index.ts":             "export {}\n",
+		"Dockerfile":               "FROM alpine:3.20\n",
+		".github/workflows/ci.yml": "on: push\n",
+		"infra/main.tf":            "terraform {}\n",
+		"deploy/app.yaml":          "apiVersion: v1\nkind: Service\n",
+		"vendor/lib/lib.go":        "package lib\n",
+		"web/node_modules/x/x.js":  "module.exports = {}\n",
+		".gitignore":               "node_modules/\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInspectRepo(t *testing.T) {
+	p, err := inspectRepo(writeInitRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Languages) < 2 || p.Languages[0] != "Go" || !slices.Contains(p.Languages, "TypeScript") {
+		t.Errorf("Languages = %v, want Go first and TypeScript", p.Languages)
+	}
+	if !slices.Equal(p.Dockerfiles, []string{"Dockerfile"}) {
+		t.Errorf("Dockerfiles = %v", p.Dockerfiles)
+	}
+	if !slices.Equal(p.Workflows, []string{".github/workflows/ci.yml"}) {
+		t.Errorf("Workflows = %v", p.Workflows)
+	}
+	if !slices.Equal(p.IaC, []string{"Kubernetes", "Terraform"}) {
+		t.Errorf("IaC = %v, want [Kubernetes Terraform]", p.IaC)
+	}
+	if !slices.Equal(p.Packages, []string{".", "web"}) {
+		t.Errorf("Packages = %v, want [. web]", p.Packages)
+	}
+	// node_modules/ is already gitignored.
+	if !slices.Equal(p.Vendored, []string{"vendor/"}) {
+		t.Errorf("Vendored = %v, want [vendor/]", p.Vendored)
+	}
+	if !p.PublicRegistries || !p.GitHub {
+		t.Errorf("PublicRegistries = %v, GitHub = %v, want both set", p.PublicRegistries, p.GitHub)
+	}
+}
+
+func TestRunInit_Yes(t *testing.T) {
+	dir := writeInitRepo(t)
+	if code := run([]string{"init", "--yes", dir}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	cfg, err := nox.LoadScanConfig(dir)
+	if err != nil {
+		t.Fatalf("generated .nox.yaml does not load: %v", err)
+	}
+	if cfg.Policy.FailOn != "high" {
+		t.Errorf("policy.fail_on = %q, want high", cfg.Policy.FailOn)
+	}
+	if cfg.Output.Format != "json,sarif" {
+		t.Errorf("output.format = %q, want json,sarif", cfg.Output.Format)
+	}
+	if !slices.Equal(cfg.Scan.Exclude, []string{"vendor/"}) {
+		t.Errorf("scan.exclude = %v, want [vendor/]", cfg.Scan.Exclude)
+	}
+
+	// .github exists, so the workflow is written by default.
+	data, err := os.ReadFile(filepath.Join(dir, initWorkflowPath))
+	if err != nil {
+		t.Fatalf("reading workflow: %v", err)
+	}
+	var wf map[string]any
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		t.Fatalf("workflow is not valid YAML: %v", err)
+	}
+	for _, want := range []string{"uses: nox-hq/nox@v1", "format: json,sarif", "upload-sarif"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("workflow missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestRunInit_NoWorkflow(t *testing.T) {
+	dir := writeInitRepo(t)
+	if code := run([]string{"init", "--yes", "--workflow=false", dir}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, initWorkflowPath)); !os.IsNotExist(err) {
+		t.Fatalf("expected no workflow with --workflow=false, got err %v", err)
+	}
+}
+
+func TestRunInit_RefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, ".nox.yaml")
+	if err := os.WriteFile(existing, []byte("policy:\n  fail_on: low\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := run([]string{"init", "--yes", dir}); code != 2 {
+		t.Fatalf("expected exit code 2 for an existing config, got %d", code)
+	}
+	data, _ := os.ReadFile(existing)
+	if string(data) != "policy:\n  fail_on: low\n" {
+		t.Fatalf("existing config was modified:\n%s", data)
+	}
+
+	if code := run([]string{"init", "--yes", "--force", dir}); code != 0 {
+		t.Fatalf("expected exit code 0 with --force, got %d", code)
+	}
+	cfg, err := nox.LoadScanConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Policy.FailOn != "high" {
+		t.Errorf("policy.fail_on = %q after --force, want high", cfg.Policy.FailOn)
+	}
+}
+
+func TestRunInit_RefusesExistingWorkflow(t *testing.T) {
+	dir := writeInitRepo(t)
+	wf := filepath.Join(dir, initWorkflowPath)
+	if err := os.WriteFile(wf, []byte("name: mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"init", "--yes", dir}); code != 2 {
+		t.Fatalf("expected exit code 2 for an existing workflow, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".nox.yaml")); !os.IsNotExist(err) {
+		t.Fatal("expected no .nox.yaml to be written when the workflow exists")
+	}
+}
+
+func TestRunInit_RequiresTerminalOrYes(t *testing.T) {
+	if code := run([]string{"init", t.TempDir()}); code != 2 {
+		t.Fatalf("expected exit code 2 without a terminal or --yes, got %d", code)
+	}
+}
+
+func TestInitPrompter(t *testing.T) {
+	p, err := inspectRepo(writeInitRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	r := &initPrompter{
+		// An invalid severity and format are asked again.
+		in:  bufio.NewReader(strings.NewReader("urgent\ncritical\nxml\nsarif, github\nn\ntestdata/\n@acme/, acme-\nn\n")),
+		out: &out,
+	}
+	got := r.ask(p, p.defaults(), true)
+
+	if got.FailOn != "critical" || got.Formats != "sarif,github" {
+		t.Errorf("FailOn, Formats = %q, %q, want critical, sarif,github", got.FailOn, got.Formats)
+	}
+	if !slices.Equal(got.Exclude, []string{"testdata/"}) {
+		t.Errorf("Exclude = %v, want [testdata/]", got.Exclude)
+	}
+	if !slices.Equal(got.InternalPrefixes, []string{"@acme/", "acme-"}) {
+		t.Errorf("InternalPrefixes = %v", got.InternalPrefixes)
+	}
+	if got.Workflow {
+		t.Error("Workflow = true, want false")
+	}
+	for _, want := range []string{`"urgent" is not a severity`, `unknown format "xml"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("prompt output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	config, err := renderInitConfig(p, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg nox.ScanConfig
+	if err := yaml.Unmarshal(config, &cfg); err != nil {
+		t.Fatalf("rendered config is not valid YAML: %v\n%s", err, config)
+	}
+	if !slices.Equal(cfg.Dependencies.InternalPrefixes, []string{"@acme/", "acme-"}) {
+		t.Errorf("dependencies.internal_prefixes = %v", cfg.Dependencies.InternalPrefixes)
+	}
+}
+
+func TestInitPrompter_EndOfInputKeepsDefaults(t *testing.T) {
+	p, err := inspectRepo(writeInitRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := p.defaults()
+	r := &initPrompter{in: bufio.NewReader(strings.NewReader("")), out: &strings.Builder{}}
+	got := r.ask(p, want, true)
+	if got.FailOn != want.FailOn || got.Formats != want.Formats || !slices.Equal(got.Exclude, want.Exclude) || got.Workflow != want.Workflow {
+		t.Errorf("ask with no input = %+v, want %+v", got, want)
+	}
+}
@@ -2,18 +2,40 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"filippo.io/age"
+	noxapi "github.com/nox-hq/nox"
 	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/analyzers/secrets"
+	"github.com/nox-hq/nox/core/annotate"
 	"github.com/nox-hq/nox/core/compliance"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/logging"
+	"github.com/nox-hq/nox/core/metrics"
+	"github.com/nox-hq/nox/core/offline"
 	"github.com/nox-hq/nox/core/report"
+	"github.com/nox-hq/nox/core/report/gitlab"
 	"github.com/nox-hq/nox/core/report/sarif"
 	"github.com/nox-hq/nox/core/report/sbom"
+	"github.com/nox-hq/nox/core/rules"
 	"github.com/nox-hq/nox/server"
 )
 
@@ -23,6 +45,16 @@ var (
 	date    = "unknown"
 )
 
+// configPath is the config file given with --config or NOX_CONFIG, made
+// absolute, or "" to use the .nox.yaml of the scan target. It is reset by
+// run on every invocation.
+var configPath string
+
+// offlineMode is set by --offline or NOX_OFFLINE. Commands skip or refuse
+// network access in offline mode, and run installs offline.Install for the
+// duration of the command. It is reset by run on every invocation.
+var offlineMode bool
+
 func main() {
 	os.Exit(run(os.Args[1:]))
 }
@@ -34,7 +66,8 @@ func main() {
 //
 // The string flags --format and --output are only extracted for the "scan"
 // subcommand, since other subcommands may define their own --output flag.
-// Bool flags (-q, -v, --version) are always extracted regardless of subcommand.
+// Bool flags (-q, -v, --version) and the logging flags (--log-level,
+// --log-format) are always extracted regardless of subcommand.
 func extractInterspersedArgs(args []string) []string {
 	// Determine the subcommand so we know whether to extract --format/--output.
 	subcommand := ""
@@ -63,7 +96,7 @@ func extractInterspersedArgs(args []string) []string {
 		}
 		if isTopLevelBoolFlag(name) {
 			flags = append(flags, arg)
-		} else if subcommand == "scan" && isTopLevelStringFlag(name) {
+		} else if isGlobalStringFlag(name) || (subcommand == "scan" && isTopLevelStringFlag(name)) {
 			flags = append(flags, arg)
 			// Consume the value unless it was --flag=value.
 			if !strings.Contains(arg, "=") && i+1 < len(args) {
@@ -80,7 +113,7 @@ func extractInterspersedArgs(args []string) []string {
 
 func isTopLevelBoolFlag(name string) bool {
 	switch name {
-	case "quiet", "q", "verbose", "v", "version":
+	case "quiet", "q", "verbose", "v", "version", "no-ci":
 		return true
 	}
 	return false
@@ -94,8 +127,38 @@ func isTopLevelStringFlag(name string) bool {
 	return false
 }
 
+// isGlobalStringFlag reports whether name is a top-level string flag that
+// applies to every subcommand.
+func isGlobalStringFlag(name string) bool {
+	switch name {
+	case "log-level", "log-format", "color", "config":
+		return true
+	}
+	return false
+}
+
+// setupLogging installs the process-wide structured logger. Logs always go
+// to stderr so that human-facing results on stdout are unaffected. When
+// --verbose is set and no explicit level was given, debug logging is enabled.
+func setupLogging(level, format string, levelSet, verbose bool) error {
+	if verbose && !levelSet {
+		level = "debug"
+	}
+	logger, err := logging.New(os.Stderr, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// exitPartial is returned when a scan was interrupted by --timeout or a
+// signal and only partial results were written.
+const exitPartial = 3
+
 // run executes the CLI and returns the exit code.
-// 0 = clean (no findings), 1 = findings detected, 2 = error.
+// 0 = clean (no findings), 1 = findings detected, 2 = error,
+// 3 = scan interrupted (partial results).
 func run(args []string) int {
 	args = extractInterspersedArgs(args)
 	fs := flag.NewFlagSet("nox", flag.ContinueOnError)
@@ -107,9 +170,15 @@ func run(args []string) int {
 		quietFlag   bool
 		verboseFlag bool
 		versionFlag bool
+		logLevel    string
+		logFormat   string
+		noCIFlag    bool
+		colorFlag   string
+		configFlag  string
+		offlineFlag bool
 	)
 
-	fs.StringVar(&formatFlag, "format", "json", "output formats: json,sarif,cdx,spdx,all (comma-separated)")
+	fs.StringVar(&formatFlag, "format", "", "output formats: json,sarif,cdx,spdx,csv,xlsx,gitlab,github,all (comma-separated; default json, or json,sarif,github in GitHub Actions)")
 	fs.StringVar(&outputDir, "output", ".", "output directory for report files")
 	fs.StringVar(&rulesFlag, "rules", "", "path to custom rules YAML file or directory")
 	fs.BoolVar(&quietFlag, "quiet", false, "suppress all output except errors")
@@ -117,24 +186,39 @@ func run(args []string) int {
 	fs.BoolVar(&verboseFlag, "verbose", false, "enable verbose output")
 	fs.BoolVar(&verboseFlag, "v", false, "enable verbose output (shorthand)")
 	fs.BoolVar(&versionFlag, "version", false, "print version and exit")
+	fs.StringVar(&logLevel, "log-level", "warn", "log level: debug, info, warn, error")
+	fs.StringVar(&logFormat, "log-format", "text", "log format: text, json")
+	fs.BoolVar(&noCIFlag, "no-ci", false, "ignore CI environment detection and use local defaults")
+	fs.StringVar(&colorFlag, "color", "auto", "colorize output: auto, always, never")
+	fs.StringVar(&configFlag, "config", "", "config file to use instead of the target's .nox.yaml (default $NOX_CONFIG)")
+	fs.BoolVar(&offlineFlag, "offline", false, "make no network connections (default $NOX_OFFLINE)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nox <command> [flags]\n\n")
 		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  init [path]      Generate a .nox.yaml for a repository\n")
 		fmt.Fprintf(os.Stderr, "  scan <path>      Scan a directory for security issues\n")
 		fmt.Fprintf(os.Stderr, "  show [path]      Inspect findings interactively\n")
 		fmt.Fprintf(os.Stderr, "  explain <path>   Explain findings using an LLM\n")
+		fmt.Fprintf(os.Stderr, "  fix [path]       Generate patches for mechanically fixable findings\n")
 		fmt.Fprintf(os.Stderr, "  badge [path]     Generate an SVG status badge\n")
 		fmt.Fprintf(os.Stderr, "  baseline <cmd>   Manage finding baselines\n")
+		fmt.Fprintf(os.Stderr, "  clean            Remove report files written by nox scan\n")
+		fmt.Fprintf(os.Stderr, "  config show      Show the project or effective config\n")
+		fmt.Fprintf(os.Stderr, "  policy test      Evaluate the policy against a saved report\n")
+		fmt.Fprintf(os.Stderr, "  report decrypt   Decrypt a report written with --encrypt-report\n")
+		fmt.Fprintf(os.Stderr, "  rules <cmd>      List, test and export detection rules\n")
 		fmt.Fprintf(os.Stderr, "  diff [path]      Show findings in changed files\n")
 		fmt.Fprintf(os.Stderr, "  watch [path]     Watch for changes and re-scan\n")
 		fmt.Fprintf(os.Stderr, "  protect <cmd>    Manage git pre-commit hook\n")
 		fmt.Fprintf(os.Stderr, "  annotate         Annotate a PR with findings\n")
 		fmt.Fprintf(os.Stderr, "  dashboard [path] Generate HTML security dashboard\n")
+		fmt.Fprintf(os.Stderr, "  trend [path]     Summarize the recorded scan history\n")
 		fmt.Fprintf(os.Stderr, "  completion <sh>  Generate shell completions\n") // nox:ignore AI-006 -- CLI help text
-		fmt.Fprintf(os.Stderr, "  serve            Start MCP server on stdio\n")
+		fmt.Fprintf(os.Stderr, "  serve            Start MCP server on stdio, or a web UI with --web\n")
 		fmt.Fprintf(os.Stderr, "  registry         Manage plugin registries\n")
 		fmt.Fprintf(os.Stderr, "  plugin           Manage and invoke plugins\n")
+		fmt.Fprintf(os.Stderr, "  upgrade          Upgrade nox to the latest release\n")
 		fmt.Fprintf(os.Stderr, "  version          Print version and exit\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		fs.PrintDefaults()
@@ -144,6 +228,41 @@ func run(args []string) int {
 		return 2
 	}
 
+	levelSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "log-level" {
+			levelSet = true
+		}
+	})
+	if err := setupLogging(logLevel, logFormat, levelSet, verboseFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := checkColorMode(colorFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	ciDisabled, colorMode = noCIFlag, colorFlag
+
+	if configFlag == "" {
+		configFlag = os.Getenv("NOX_CONFIG")
+	}
+	configPath = ""
+	if configFlag != "" {
+		abs, err := filepath.Abs(configFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: --config: %v\n", err)
+			return 2
+		}
+		configPath = abs
+	}
+
+	offlineMode = offlineFlag || offline.FromEnv()
+	if offlineMode {
+		defer offline.Install()()
+	}
+
 	if versionFlag {
 		fmt.Printf("nox %s (commit: %s, built: %s)\n", version, commit, date)
 		return 0
@@ -165,6 +284,8 @@ func run(args []string) int {
 		return runShow(remaining[1:])
 	case "explain":
 		return runExplain(remaining[1:])
+	case "fix":
+		return runFix(remaining[1:])
 	case "badge":
 		return runBadge(remaining[1:])
 	case "serve":
@@ -175,6 +296,18 @@ func run(args []string) int {
 		return runPlugin(remaining[1:])
 	case "baseline":
 		return runBaseline(remaining[1:])
+	case "init":
+		return runInit(remaining[1:])
+	case "config":
+		return runConfig(remaining[1:])
+	case "clean":
+		return runClean(remaining[1:])
+	case "policy":
+		return runPolicy(remaining[1:])
+	case "report":
+		return runReport(remaining[1:])
+	case "rules":
+		return runRules(remaining[1:])
 	case "diff":
 		return runDiff(remaining[1:])
 	case "watch":
@@ -185,6 +318,10 @@ func run(args []string) int {
 		return runAnnotate(remaining[1:])
 	case "dashboard":
 		return runDashboard(remaining[1:])
+	case "trend":
+		return runTrend(remaining[1:])
+	case "upgrade":
+		return runUpgrade(remaining[1:])
 	case "version":
 		fmt.Printf("nox %s (commit: %s, built: %s)\n", version, commit, date)
 		return 0
@@ -200,20 +337,73 @@ func runScan(args []string, formatFlag, outputDir, rulesPath string, quiet, verb
 	scanFS := flag.NewFlagSet("scan", flag.ContinueOnError)
 	var (
 		stagedFlag    bool
+		fullFlag      bool
 		thresholdFlag string
 		noOSVFlag     bool
+		verifyPins    bool
+		strictIOFlag  bool
+		timeoutFlag   time.Duration
+		reportAllFlag bool
 	)
 	var (
-		vexFlag        string
-		complianceFlag string
-		tfPlanFlag     string
+		vexFlag          string
+		complianceFlag   string
+		tfPlanFlag       string
+		onlyCategoryFlag string
+		skipCategoryFlag string
+		commitMsgFlag    string
+		blameFlag        bool
+		sinceFlag        string
+		changedFromFlag  string
+		gitTokenFlag     string
+		maxCloneSizeFlag string
+		metricsFileFlag  string
+		profileFlag      string
+		templateFlag     string
+		encryptFlag      []string
+		onlyAnalyzers    []string
+		disableAnalyzers []string
+		dryRunFlag       bool
+		jsonFlag         bool
 	)
 	scanFS.BoolVar(&stagedFlag, "staged", false, "scan only git-staged files (index content)")
-	scanFS.StringVar(&thresholdFlag, "severity-threshold", "", "minimum severity to report (critical, high, medium, low)")
-	scanFS.BoolVar(&noOSVFlag, "no-osv", false, "disable OSV.dev vulnerability lookups (offline mode)")
+	scanFS.BoolVar(&fullFlag, "full", false, "with --staged, run every analyzer and the dependency scan instead of the fast path")
+	scanFS.BoolVar(&blameFlag, "blame", false, "attribute secrets findings to the commit that last changed their lines (git blame)")
+	scanFS.StringVar(&sinceFlag, "since", "", "analyze only files changed since this git ref or duration (e.g., v1.4.0, 30d); manifests are still checked for vulnerabilities")
+	scanFS.StringVar(&changedFromFlag, "changed-files-from", "", "tag findings in the files a change touches: a file listing paths, one per line, or a git diff spec (e.g., origin/main...HEAD); see policy.scope")
+	scanFS.StringVar(&commitMsgFlag, "commit-msg", "", "with --staged, apply the Nox-Override trailers of this commit message file")
+	scanFS.BoolVar(&dryRunFlag, "dry-run", false, "list the files the scan would analyze, with their analyzers, and why others are skipped, without scanning")
+	scanFS.BoolVar(&jsonFlag, "json", false, "with --dry-run, print the list as JSON")
+	scanFS.StringVar(&thresholdFlag, "severity-threshold", "", "minimum severity to report (critical, high, medium, low, or a severity_mapping label)")
+	scanFS.BoolVar(&reportAllFlag, "report-all-severities", false, "write findings below --severity-threshold to report files (the threshold still gates the exit code)")
+	scanFS.BoolVar(&noOSVFlag, "no-osv", false, "disable OSV.dev vulnerability lookups")
+	scanFS.BoolVar(&verifyPins, "verify-pins", false, "look up pinned image digests and action commit SHAs online and report missing or mismatched pins (uses $GITHUB_TOKEN if set)")
 	scanFS.StringVar(&vexFlag, "vex", "", "path to OpenVEX document for vulnerability status overrides")
 	scanFS.StringVar(&complianceFlag, "compliance", "", "filter output by compliance framework (CIS, PCI-DSS, SOC2, NIST-800-53, HIPAA, OWASP-Top-10)")
 	scanFS.StringVar(&tfPlanFlag, "tf-plan", "", "path to terraform plan JSON file to scan")
+	scanFS.StringVar(&onlyCategoryFlag, "only-category", "", "comma-separated rule categories to scan (secrets, data, ai, iac, deps, container, audit, custom)")
+	scanFS.StringVar(&skipCategoryFlag, "skip-category", "", "comma-separated rule categories to leave out")
+	scanFS.Func("only-analyzer", "run only this analyzer (repeatable; see nox rules list --analyzers)", func(s string) error {
+		onlyAnalyzers = append(onlyAnalyzers, splitList(s)...)
+		return nil
+	})
+	scanFS.Func("disable-analyzer", "do not run this analyzer (repeatable; see nox rules list --analyzers)", func(s string) error {
+		disableAnalyzers = append(disableAnalyzers, splitList(s)...)
+		return nil
+	})
+	scanFS.StringVar(&templateFlag, "output-template", "", "name report files from this template, relative to --output (variables: {target}, {format}, {date}, {commit}, {ext})")
+	scanFS.Func("encrypt-report", "encrypt report files to these age recipients (age1… or SSH public keys, comma-separated or repeated); writes findings.json.age etc.", func(s string) error {
+		encryptFlag = append(encryptFlag, s)
+		return nil
+	})
+	scanFS.StringVar(&gitTokenFlag, "git-token", os.Getenv("NOX_GIT_TOKEN"), "token for cloning a private remote repository over HTTPS (default $NOX_GIT_TOKEN)")
+	scanFS.StringVar(&maxCloneSizeFlag, "max-clone-size", defaultMaxCloneSize, "refuse remote repositories larger than this (e.g., 2GB; 0 = no limit)")
+	metricsLabelFlag := labelList{}
+	scanFS.StringVar(&metricsFileFlag, "metrics-file", "", "write scan metrics in the OpenMetrics text format to this file (e.g., for the node exporter textfile collector)")
+	scanFS.Var(metricsLabelFlag, "metrics-label", "add name=value to every metric (repeatable; repo= overrides the git remote)")
+	scanFS.StringVar(&profileFlag, "profile", "", "write CPU and heap profiles of the scan (cpu.pprof, heap.pprof) to this directory")
+	scanFS.BoolVar(&strictIOFlag, "strict-io", false, "fail on the first unreadable file instead of skipping it")
+	scanFS.DurationVar(&timeoutFlag, "timeout", 0, "abort the scan after this duration and write partial results (e.g., 5m)")
 	var (
 		historyFlag      bool
 		historyDepthFlag int
@@ -229,23 +419,172 @@ func runScan(args []string, formatFlag, outputDir, rulesPath string, quiet, verb
 		return 2
 	}
 	target := scanFS.Arg(0)
+	if sinceFlag != "" && (stagedFlag || historyFlag) {
+		fmt.Fprintln(os.Stderr, "error: --since cannot be combined with --staged or --history")
+		return 2
+	}
+	if changedFromFlag != "" && (stagedFlag || historyFlag) {
+		fmt.Fprintln(os.Stderr, "error: --changed-files-from cannot be combined with --staged or --history")
+		return 2
+	}
+	if dryRunFlag && (stagedFlag || historyFlag) {
+		fmt.Fprintln(os.Stderr, "error: --dry-run cannot be combined with --staged or --history")
+		return 2
+	}
+	if jsonFlag && !dryRunFlag {
+		fmt.Fprintln(os.Stderr, "error: --json requires --dry-run; use --format json for scan reports")
+		return 2
+	}
+
+	// Ctrl-C and --timeout both cancel the scan, and the clone of a remote
+	// target; the partial results of a scan are still written below.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if timeoutFlag > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeoutFlag)
+		defer cancel()
+	}
+
+	// A git URL or owner/repo target is cloned into a temporary directory,
+	// which is removed however the scan ends.
+	scanConfig := configPath
+	displayTarget := target
+	remote, isRemote := remoteTarget(target)
+	var remoteCommit string
+	if isRemote {
+		if stagedFlag || historyFlag || blameFlag || sinceFlag != "" {
+			fmt.Fprintln(os.Stderr, "error: --staged, --history, --blame and --since need a local repository, not a remote target")
+			return 2
+		}
+		maxSize, err := nox.ParseByteSize(maxCloneSizeFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: --max-clone-size: %v\n", err)
+			return 2
+		}
+		displayTarget = remote.String()
+		if !quiet {
+			fmt.Printf("nox %s — cloning %s\n", version, displayTarget)
+		}
+		dir, sha, emptyConfig, cleanup, err := cloneForScan(ctx, remote, gitTokenFlag, maxSize)
+		defer cleanup()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: cloning %s: %v\n", displayTarget, err)
+			return 2
+		}
+		target, remoteCommit = dir, sha
+		if scanConfig == "" {
+			scanConfig = emptyConfig
+		}
+	}
+
+	// Scans go through the public scanner API so that embedders get the
+	// same behaviour as the CLI.
+	scanOpts := []noxapi.Option{noxapi.WithRules(rulesPath), noxapi.WithConfigFile(scanConfig)}
+	filters := []noxapi.Option{
+		noxapi.WithCategories(splitList(onlyCategoryFlag)...),
+		noxapi.WithoutCategories(splitList(skipCategoryFlag)...),
+		noxapi.WithAnalyzers(onlyAnalyzers...),
+		noxapi.WithoutAnalyzers(disableAnalyzers...),
+	}
+
+	// A dry run stops after discovery: no analyzer, lookup or report.
+	if dryRunFlag {
+		scanOpts = append(scanOpts, filters...)
+		if strictIOFlag {
+			scanOpts = append(scanOpts, noxapi.WithStrictIO())
+		}
+		if sinceFlag != "" {
+			scanOpts = append(scanOpts, noxapi.WithSince(sinceFlag))
+		}
+		return dryRun(ctx, target, displayTarget, jsonFlag, quiet, scanOpts...)
+	}
+
+	var commitMsg string
+	if commitMsgFlag != "" {
+		if !stagedFlag {
+			fmt.Fprintln(os.Stderr, "error: --commit-msg requires --staged")
+			return 2
+		}
+		msg, err := os.ReadFile(commitMsgFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: reading commit message: %v\n", err)
+			return 2
+		}
+		commitMsg = string(msg)
+	}
 
 	// Load project config for output defaults.
-	cfg, err := nox.LoadScanConfig(target)
+	cfg, err := nox.LoadRootConfig(target, scanConfig)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: loading .nox.yaml: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error: loading config: %v\n", err)
 		return 2
 	}
+	// The threshold may be a nox severity or a label from severity_mapping.
+	var threshold findings.Severity
+	if thresholdFlag != "" {
+		if threshold, err = cfg.SeverityMapping.ParseThreshold(thresholdFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "error: --severity-threshold: %v\n", err)
+			return 2
+		}
+	}
 
-	// Apply output defaults from config (CLI flags take precedence).
-	if formatFlag == "json" && cfg.Output.Format != "" {
+	// Apply output defaults: --format, then output.format from config, then
+	// the CI default, then json.
+	var ciNotes []string
+	env := detectCI()
+	if env != nil {
+		ciNotes = append(ciNotes, fmt.Sprintf("detected %s; disabling color and interactive output", env.Name()))
+	}
+	if formatFlag == "" {
 		formatFlag = cfg.Output.Format
 	}
+	if formatFlag == "" && env != nil && env.SupportsAnnotations() {
+		// Write SARIF for code scanning upload and print findings as
+		// annotations alongside findings.json.
+		formatFlag = "json,sarif,github"
+		ciNotes = append(ciNotes, fmt.Sprintf("detected %s; defaulting --format %s", env.Name(), formatFlag))
+	}
+	if formatFlag == "" {
+		formatFlag = "json"
+	}
 	if outputDir == "." && cfg.Output.Directory != "" {
 		outputDir = cfg.Output.Directory
 	}
 
 	formats := parseFormats(formatFlag)
+	names := report.OutputNames{Template: templateFlag, Files: cfg.Output.Files}
+	if names.Template != "" || len(names.Files) > 0 {
+		names.Vars = report.NameVars{Target: filepath.Base(target), Commit: remoteCommit, Date: time.Now()}
+		if isRemote {
+			names.Vars.Target = path.Base(git.RepoName(remote.URL))
+		} else {
+			if abs, err := filepath.Abs(target); err == nil {
+				names.Vars.Target = filepath.Base(abs)
+			}
+			names.Vars.Commit, _ = git.HeadCommit(target)
+		}
+	}
+	if err := names.Validate(reportFormats(formats)); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if len(encryptFlag) == 0 {
+		encryptFlag = cfg.Output.EncryptRecipients
+	}
+	recipients, err := report.ParseRecipients(encryptFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: --encrypt-report: %v\n", err)
+		return 2
+	}
+
+	// The passive update check runs alongside interactive scans; the
+	// pre-commit hook (--staged) stays quiet.
+	var updates *updateCheck
+	if !quiet && !stagedFlag {
+		updates = startUpdateCheck(cfg)
+	}
 
 	if !quiet {
 		if stagedFlag {
@@ -256,130 +595,300 @@ func runScan(args []string, formatFlag, outputDir, rulesPath string, quiet, verb
 			} else {
 				fmt.Printf("nox %s — scanning git history in %s\n", version, target)
 			}
+		} else if sinceFlag != "" {
+			fmt.Printf("nox %s — scanning files changed since %s in %s\n", version, sinceFlag, displayTarget)
 		} else {
-			fmt.Printf("nox %s — scanning %s\n", version, target)
+			fmt.Printf("nox %s — scanning %s\n", version, displayTarget)
 		}
 	}
 
 	if verbose {
+		for _, note := range ciNotes {
+			fmt.Printf("[ci] %s\n", note)
+		}
 		fmt.Println("[discover] walking directory...")
 	}
 
-	var result *nox.ScanResult
-	if stagedFlag {
-		result, err = nox.RunStagedScan(target)
-	} else if historyFlag {
-		historyOpts := nox.HistoryScanOptions{
-			MaxDepth:    historyDepthFlag,
-			ScanOptions: nox.ScanOptions{CustomRulesPath: rulesPath},
+	if noOSVFlag {
+		filters = append(filters, noxapi.WithoutOSV())
+	}
+	switch {
+	case stagedFlag:
+		scanOpts = append(scanOpts, noxapi.WithStaged(), noxapi.WithCommitMessage(commitMsg))
+		scanOpts = append(scanOpts, filters...)
+		if !fullFlag {
+			scanOpts = append(scanOpts, noxapi.WithFast())
+		}
+	case historyFlag:
+		scanOpts = append(scanOpts, noxapi.WithHistory(historyDepthFlag))
+	default:
+		scanOpts = append(scanOpts, filters...)
+		scanOpts = append(scanOpts, noxapi.WithVEX(vexFlag), noxapi.WithTerraformPlan(tfPlanFlag))
+		if strictIOFlag {
+			scanOpts = append(scanOpts, noxapi.WithStrictIO())
+		}
+		if blameFlag {
+			scanOpts = append(scanOpts, noxapi.WithBlame())
 		}
-		result, err = nox.RunHistoryScan(target, &historyOpts)
-	} else {
-		opts := nox.ScanOptions{
-			CustomRulesPath:   rulesPath,
-			DisableOSV:        noOSVFlag,
-			VEXPath:           vexFlag,
-			TerraformPlanPath: tfPlanFlag,
+		if sinceFlag != "" {
+			scanOpts = append(scanOpts, noxapi.WithSince(sinceFlag))
+		}
+		if changedFromFlag != "" {
+			scanOpts = append(scanOpts, noxapi.WithChangedFiles(changedFromFlag))
+		}
+		if verifyPins {
+			if offlineMode && !quiet {
+				fmt.Fprintln(os.Stderr, "nox: warning: --verify-pins makes network requests and is skipped in offline mode")
+			}
+			scanOpts = append(scanOpts, noxapi.WithPinVerification())
 		}
-		result, err = nox.RunScanWithOptions(target, opts)
 	}
-	if err != nil {
+	var stopProfile func() error
+	if profileFlag != "" {
+		if stopProfile, err = startProfile(profileFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "error: --profile: %v\n", err)
+			return 2
+		}
+	}
+	result, err := scanDir(ctx, target, scanOpts...)
+	if stopProfile != nil {
+		if profErr := stopProfile(); profErr != nil {
+			fmt.Fprintf(os.Stderr, "[warn] writing profiles: %v\n", profErr)
+		} else if verbose {
+			fmt.Printf("[profile] wrote %s and %s to %s\n", cpuProfileFile, heapProfileFile, profileFlag)
+		}
+	}
+	if err != nil && (result == nil || !result.Partial) {
 		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
 		return 2
 	}
 
+	// Overrides are reported even with --quiet: they are what the commit
+	// hook prints when it lets a finding through or refuses to.
+	for _, e := range result.Overrides {
+		fmt.Fprintf(os.Stderr, "nox: override: let %s through (reason: %s); recorded in .nox/overrides.log\n", e.FindingID, e.Reason)
+	}
+	for _, r := range result.RejectedOverrides {
+		fmt.Fprintf(os.Stderr, "nox: override: %s not applied: %s\n", r.Override.FindingID, r.Reason)
+	}
+
+	provenance := result.Provenance
+	if provenance == nil {
+		provenance = &report.Provenance{Target: target}
+	}
+	provenance.ToolVersion, provenance.ToolCommit = version, commit
+	if isRemote {
+		provenance.Target, provenance.Remote, provenance.GitCommit = displayTarget, displayTarget, remoteCommit
+	}
+	provenance.Parameters.SeverityThreshold = string(threshold)
+	provenance.Seal()
+
+	result.Findings.ApplySeverityMapping(cfg.SeverityMapping)
 	activeFindings := result.Findings.ActiveFindings()
+	totalCount := len(result.Findings.Findings())
+	suppressedCount := totalCount - len(activeFindings)
 
-	// Apply severity threshold filtering if specified.
+	// Apply severity threshold filtering if specified. Findings below the
+	// threshold are left out of the exit code and, unless
+	// --report-all-severities is set, out of the report files too.
+	reportFindings := result.Findings
+	belowThreshold := 0
 	if thresholdFlag != "" {
-		threshold := findings.Severity(thresholdFlag)
 		var filtered []findings.Finding
 		for _, f := range activeFindings {
 			if nox.SeverityMeetsThreshold(f.Severity, threshold) {
 				filtered = append(filtered, f)
 			}
 		}
+		belowThreshold = len(activeFindings) - len(filtered)
 		activeFindings = filtered
+		if !reportAllFlag {
+			reportFindings = filterBySeverity(result.Findings, threshold)
+		}
 	}
 
 	findingCount := len(activeFindings)
-	totalCount := len(result.Findings.Findings())
-	suppressedCount := totalCount - findingCount
 	pkgCount := len(result.Inventory.Packages())
 
 	if !quiet {
+		var notes []string
 		if suppressedCount > 0 {
-			fmt.Printf("[results] %d findings (%d suppressed), %d dependencies, %d AI components\n",
-				findingCount, suppressedCount, pkgCount, len(result.AIInventory.Components))
+			notes = append(notes, fmt.Sprintf("%d suppressed", suppressedCount))
+		}
+		if belowThreshold > 0 {
+			note := fmt.Sprintf("%d below %s threshold", belowThreshold, thresholdFlag)
+			if reportAllFlag {
+				note += ", kept in reports"
+			}
+			notes = append(notes, note)
+		}
+		if len(notes) > 0 {
+			fmt.Printf("[results] %d findings (%s), %d dependencies, %d AI components\n",
+				findingCount, strings.Join(notes, ", "), pkgCount, len(result.AIInventory.Components))
 		} else {
 			fmt.Printf("[results] %d findings, %d dependencies, %d AI components\n",
 				findingCount, pkgCount, len(result.AIInventory.Components))
 		}
 	}
 
-	// Generate reports.
+	// Generate reports. In timestamped mode each scan gets its own run
+	// directory under the configured output directory.
+	runsBase := ""
+	if cfg.Output.Timestamped {
+		runsBase = outputDir
+		if runsBase == "." {
+			runsBase = report.RunsDir
+		}
+		run, err := report.NewRunDir(runsBase, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: creating output directory: %v\n", err)
+			return 2
+		}
+		outputDir = run
+	}
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "error: creating output directory: %v\n", err)
 		return 2
 	}
 
+	// The report files are written concurrently. Sorting the findings
+	// first leaves the JSON and SARIF reporters only reading the set.
+	reportFindings.SortDeterministic()
+	var project *sbom.Project
+	switch {
+	case !slices.Contains(formats, "cdx") && !slices.Contains(formats, "spdx"):
+	case isRemote:
+		project = sbom.RemoteProject(target, remote.URL, remoteCommit)
+	default:
+		project = sbom.DescribeProject(target)
+	}
+	var jobs []reportJob
+	gitlabReports := make(map[gitlab.ReportType]bool)
 	for _, format := range formats {
 		switch format {
 		case "json":
-			path := filepath.Join(outputDir, "findings.json")
 			r := report.NewJSONReporter(version)
-			if err := r.WriteToFile(result.Findings, path); err != nil {
-				fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", path, err)
-				return 2
-			}
-			if verbose {
-				fmt.Printf("[report] wrote %s\n", path)
-			}
+			r.Errors = result.Errors
+			r.Truncated = result.Truncated
+			r.LineNotes = result.LineNotes
+			r.Partial = result.Partial
+			r.Rules = result.Rules
+			r.Categories = result.Categories
+			r.CI = env
+			r.Provenance = provenance
+			r.Suppressions = result.Suppressions
+			r.Performance = result.Performance()
+			jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name("json")), func(w io.Writer) error {
+				return r.Write(w, reportFindings)
+			}})
 
 		case "sarif":
-			path := filepath.Join(outputDir, "results.sarif")
 			r := sarif.NewReporter(version, result.Rules)
-			if err := r.WriteToFile(result.Findings, path); err != nil {
-				fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", path, err)
-				return 2
-			}
-			if verbose {
-				fmt.Printf("[report] wrote %s\n", path)
+			r.Provenance = provenance
+			if v := cfg.SARIF.IncludeSuppressed; v != nil {
+				r.IncludeSuppressed = *v
 			}
+			jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name("sarif")), func(w io.Writer) error {
+				return r.Write(w, reportFindings)
+			}})
 
 		case "cdx":
-			path := filepath.Join(outputDir, "sbom.cdx.json")
 			r := sbom.NewCycloneDXReporter(version)
-			if err := r.WriteToFile(result.Inventory, path); err != nil {
-				fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", path, err)
-				return 2
-			}
-			if verbose {
-				fmt.Printf("[report] wrote %s\n", path)
-			}
+			r.Project = project
+			jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name("cdx")), generated(func() ([]byte, error) {
+				return r.Generate(result.Inventory)
+			})})
 
 		case "spdx":
-			path := filepath.Join(outputDir, "sbom.spdx.json")
 			r := sbom.NewSPDXReporter(version)
-			if err := r.WriteToFile(result.Inventory, path); err != nil {
-				fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", path, err)
-				return 2
+			r.Project = project
+			jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name("spdx")), generated(func() ([]byte, error) {
+				return r.Generate(result.Inventory)
+			})})
+
+		case "csv":
+			r := report.NewCSVReporter(result.Rules)
+			jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name("csv")), func(w io.Writer) error {
+				return r.Write(w, reportFindings)
+			}})
+
+		case "xlsx":
+			r := report.NewXLSXReporter(version, result.Rules)
+			jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name("xlsx")), func(w io.Writer) error {
+				return r.Write(w, reportFindings)
+			}})
+
+		case "gitlab", "gitlab-sast", "gitlab-secret-detection":
+			for _, t := range gitlabFormats[format] {
+				if gitlabReports[t] {
+					continue
+				}
+				gitlabReports[t] = true
+				r := gitlab.NewReporter(t, version, result.Rules)
+				r.Provenance = provenance
+				jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name(gitlabFormat(t))), func(w io.Writer) error {
+					return r.Write(w, reportFindings)
+				}})
 			}
-			if verbose {
-				fmt.Printf("[report] wrote %s\n", path)
+
+		case "github":
+			// Workflow commands go to stdout, where the Actions runner
+			// turns them into file annotations.
+			for _, line := range annotate.WorkflowCommands(activeFindings) {
+				fmt.Println(line)
 			}
 		}
 	}
 
 	// Always write AI inventory if components were found.
 	if len(result.AIInventory.Components) > 0 {
-		path := filepath.Join(outputDir, "ai.inventory.json")
-		if err := result.AIInventory.WriteFile(path); err != nil {
-			fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", path, err)
+		jobs = append(jobs, reportJob{filepath.Join(outputDir, names.Name("ai-inventory")), generated(result.AIInventory.JSON)})
+	}
+	for _, job := range jobs {
+		if err := os.MkdirAll(filepath.Dir(job.path), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "error: creating output directory: %v\n", err)
+			return 2
+		}
+	}
+	if !writeReports(jobs, recipients, verbose) {
+		return 2
+	}
+	if metricsFileFlag != "" {
+		var repo string
+		if isRemote {
+			repo = git.RepoName(remote.URL)
+		} else {
+			repo = metrics.RepoLabel(target)
+		}
+		snap := metrics.Snapshot{Labels: metricsLabels(metricsLabelFlag, repo), Result: result, Time: time.Now()}
+		if err := metrics.WriteFile(metricsFileFlag, buildInfo(), snap); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing metrics: %v\n", err)
 			return 2
 		}
 		if verbose {
-			fmt.Printf("[report] wrote %s\n", path)
+			fmt.Printf("[report] wrote %s\n", metricsFileFlag)
+		}
+	}
+
+	if runsBase != "" {
+		if err := report.LinkLatest(runsBase, outputDir); err != nil {
+			fmt.Fprintf(os.Stderr, "[warn] updating %s link: %v\n", report.LatestLink, err)
+		}
+		keep := cfg.Output.Keep
+		if keep <= 0 {
+			keep = report.DefaultKeep
+		}
+		removed, err := report.PruneRuns(runsBase, keep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[warn] pruning old runs: %v\n", err)
+		}
+		if verbose && len(removed) > 0 {
+			fmt.Printf("[report] pruned %d file(s) from old runs\n", len(removed))
+		}
+	}
+	if !quiet {
+		if hint := gitignoreHint(outputDir, runsBase); hint != "" {
+			fmt.Println(hint)
 		}
 	}
 
@@ -411,9 +920,58 @@ func runScan(args []string, formatFlag, outputDir, rulesPath string, quiet, verb
 		}
 	}
 
+	if len(result.Errors) > 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "[skipped] %d file(s) could not be read; results are partial\n", len(result.Errors))
+		for _, fe := range result.Errors {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", fe.Path, fe.Message)
+		}
+	}
+	if len(result.Truncated) > 0 && !quiet {
+		fmt.Fprintf(os.Stderr, "[truncated] %d file(s) larger than scan.max_file_size were scanned only in part\n", len(result.Truncated))
+		for _, tf := range result.Truncated {
+			fmt.Fprintf(os.Stderr, "  %s: first %d of %d bytes scanned\n", tf.Path, tf.Scanned, tf.Size)
+		}
+	}
+	if len(result.LineNotes) > 0 && !quiet {
+		capped := 0
+		for _, n := range result.LineNotes {
+			if n.RuleID == "" {
+				capped++
+			}
+		}
+		if capped > 0 {
+			fmt.Fprintf(os.Stderr, "[truncated] %d line(s) longer than %d bytes were matched only in part\n", capped, rules.DefaultMaxLineLength)
+		}
+		for _, n := range result.LineNotes {
+			if n.RuleID != "" {
+				fmt.Fprintf(os.Stderr, "[slow] %s:%d: %s exceeded the line matching budget; it skipped the later long lines of the file\n", n.Path, n.Line, n.RuleID)
+			}
+		}
+	}
+	if !quiet {
+		for _, s := range result.Submodules {
+			if !s.Initialized {
+				fmt.Fprintf(os.Stderr, "[skipped] submodule %s is not checked out; run git submodule update --init to scan it\n", s.Path)
+			}
+		}
+	}
+
+	if verbose {
+		printPerformance(result)
+		printSecretFilters(result.SecretFilters)
+	}
+
+	if result.Partial {
+		fmt.Fprintf(os.Stderr, "[partial] %v; reports contain findings gathered so far\n", err)
+		return exitPartial
+	}
+
 	if !quiet {
 		fmt.Println("[done]")
 	}
+	if notice := updates.notice(); notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
 
 	// If policy is configured, use its exit code.
 	if result.PolicyResult != nil {
@@ -426,14 +984,65 @@ func runScan(args []string, formatFlag, outputDir, rulesPath string, quiet, verb
 	return 0
 }
 
+// filterBySeverity returns a copy of fs holding only findings at or above
+// threshold, whatever their status.
+func filterBySeverity(fs *findings.FindingSet, threshold findings.Severity) *findings.FindingSet {
+	out := findings.NewFindingSet()
+	for _, f := range fs.Findings() {
+		if nox.SeverityMeetsThreshold(f.Severity, threshold) {
+			out.Add(f)
+		}
+	}
+	return out
+}
+
+// printSecretFilters prints how many secrets matches the placeholder and
+// test directory heuristics suppressed, downgraded or lowered, by kind.
+func printSecretFilters(stats secrets.FilterStats) {
+	actions := []struct {
+		action secrets.PlaceholderAction
+		verb   string
+	}{
+		{secrets.PlaceholderSuppress, "suppressed"},
+		{secrets.PlaceholderDowngrade, "downgraded to info"},
+		{secrets.PlaceholderKeep, "kept"},
+	}
+	for _, a := range actions {
+		kinds := stats.Placeholders[a.action]
+		if len(kinds) == 0 {
+			continue
+		}
+		total := 0
+		counts := make([]string, 0, len(kinds))
+		for _, kind := range slices.Sorted(maps.Keys(kinds)) {
+			total += kinds[kind]
+			counts = append(counts, fmt.Sprintf("%s %d", kind, kinds[kind]))
+		}
+		fmt.Printf("[secrets] %d placeholder match(es) %s: %s\n", total, a.verb, strings.Join(counts, ", "))
+	}
+	if stats.TestDirs > 0 {
+		fmt.Printf("[secrets] %d finding(s) in test directories at lower confidence\n", stats.TestDirs)
+	}
+}
+
 func runServe(args []string) int {
 	serveFS := flag.NewFlagSet("serve", flag.ContinueOnError)
-	var allowedPaths string
+	var allowedPaths, metricsAddr, webAddr, webDir string
+	var readOnly bool
+	metricsLabelFlag := labelList{}
 	serveFS.StringVar(&allowedPaths, "allowed-paths", "", "comma-separated list of allowed workspace paths")
+	serveFS.StringVar(&webAddr, "web", "", "serve a web UI for the findings of --dir at http://ADDR/ instead of MCP (e.g., :8080; binds to localhost when no host is given)")
+	serveFS.StringVar(&webDir, "dir", ".", "directory shown by the web UI")
+	serveFS.BoolVar(&readOnly, "read-only", false, "turn off the web UI's baseline and rescan actions")
+	serveFS.StringVar(&metricsAddr, "metrics-addr", "", "serve the metrics of the latest scan of each repository at http://ADDR/metrics (e.g., :9464)")
+	serveFS.Var(metricsLabelFlag, "metrics-label", "add name=value to every metric (repeatable; repo= overrides the git remote)")
 
 	if err := serveFS.Parse(args); err != nil {
 		return 2
 	}
+	if webAddr != "" {
+		return runServeWeb(webAddr, webDir, readOnly)
+	}
 
 	var paths []string
 	if allowedPaths != "" {
@@ -445,7 +1054,22 @@ func runServe(args []string) int {
 		}
 	}
 
-	srv := server.New(version, paths)
+	var srvOpts []server.ServerOption
+	if offlineMode {
+		srvOpts = append(srvOpts, server.WithOffline())
+	}
+	if metricsAddr != "" {
+		reg := metrics.NewRegistry(buildInfo())
+		addr, err := serveMetrics(metricsAddr, reg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		// stdout carries the MCP protocol, so the address goes to stderr.
+		fmt.Fprintf(os.Stderr, "nox: serving metrics at http://%s/metrics\n", addr)
+		srvOpts = append(srvOpts, server.WithMetrics(reg, metricsLabelFlag))
+	}
+	srv := server.New(version, paths, srvOpts...)
 	if err := srv.Serve(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: MCP server failed: %v\n", err)
 		return 2
@@ -453,11 +1077,159 @@ func runServe(args []string) int {
 	return 0
 }
 
+// scanDir scans the directory target in place with the public scanner API
+// and returns the result in the form of the core packages, which the
+// report writers take. As with nox.RunScanContext, a cancelled scan returns
+// its partial result together with the error. In offline mode the scan
+// makes no network requests.
+func scanDir(ctx context.Context, target string, opts ...noxapi.Option) (*nox.ScanResult, error) {
+	if offlineMode {
+		opts = append(opts, noxapi.WithOffline())
+	}
+	result, err := noxapi.New(opts...).Scan(ctx, noxapi.Dir(target))
+	if result == nil {
+		return nil, err
+	}
+	return result.Core(), err
+}
+
+// dryRun prints what a scan of target with opts would cover: the files
+// it would analyze, with their analyzers, then the skipped paths with the
+// reason and the totals, or all of it as JSON.
+func dryRun(ctx context.Context, target, displayTarget string, jsonOut, quiet bool, opts ...noxapi.Option) int {
+	plan, err := noxapi.New(opts...).DryRun(ctx, noxapi.Dir(target))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: dry run failed: %v\n", err)
+		return 2
+	}
+	if jsonOut {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding dry run: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	if !quiet {
+		fmt.Printf("nox %s — dry run of %s; nothing is analyzed\n", version, displayTarget)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tTYPE\tSIZE\tANALYZERS")
+	for _, f := range plan.Files {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", f.Path, f.Type, f.Size, strings.Join(f.Analyzers, ","))
+	}
+	w.Flush()
+	for _, s := range plan.Skipped {
+		p := s.Path
+		if s.Dir {
+			p += "/"
+		}
+		fmt.Printf("[skipped] %s: %s\n", p, s.Reason)
+	}
+
+	t := plan.Totals
+	fmt.Printf("\n%d files (%d bytes) would be scanned, %d paths skipped\n", t.Files, t.Bytes, t.Skipped)
+	for _, name := range slices.Sorted(maps.Keys(t.Analyzers)) {
+		fmt.Printf("  %-10s %d files\n", name, t.Analyzers[name])
+	}
+	return 0
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// reportJob is a report file and the function that writes its content.
+type reportJob struct {
+	path  string
+	write func(io.Writer) error
+}
+
+// generated adapts a reporter that returns the whole report to a
+// reportJob write function.
+func generated(generate func() ([]byte, error)) func(io.Writer) error {
+	return func(w io.Writer) error {
+		data, err := generate()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
+
+// writeReports writes the reports of jobs concurrently, each encrypted to
+// recipients when there are any. Errors are printed in job order; it
+// reports whether every report was written.
+func writeReports(jobs []reportJob, recipients []age.Recipient, verbose bool) bool {
+	written := make([]string, len(jobs))
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Go(func() {
+			written[i], errs[i] = report.WriteStream(job.path, job.write, recipients)
+		})
+	}
+	wg.Wait()
+
+	ok := true
+	for i, job := range jobs {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "error: writing %s: %v\n", job.path, errs[i])
+			ok = false
+		} else if verbose {
+			fmt.Printf("[report] wrote %s\n", written[i])
+		}
+	}
+	return ok
+}
+
+// gitlabFormats maps the --format values that write GitLab security
+// reports to their report types. "gitlab" splits the findings between
+// both: secrets into secret detection and the rest into SAST.
+var gitlabFormats = map[string][]gitlab.ReportType{
+	"gitlab":                  {gitlab.SAST, gitlab.SecretDetection},
+	"gitlab-sast":             {gitlab.SAST},
+	"gitlab-secret-detection": {gitlab.SecretDetection},
+}
+
+// gitlabFormat returns the format name of GitLab report type t, as
+// report.FileNames and output.files know it.
+func gitlabFormat(t gitlab.ReportType) string {
+	return "gitlab-" + strings.ReplaceAll(string(t), "_", "-")
+}
+
+// reportFormats returns the report file formats that formats, the --format
+// values, write: the GitLab values expand to their report types, and the
+// AI inventory is always included.
+func reportFormats(formats []string) []string {
+	var out []string
+	for _, f := range formats {
+		if types, ok := gitlabFormats[f]; ok {
+			for _, t := range types {
+				out = append(out, gitlabFormat(t))
+			}
+		} else if _, ok := report.FileNames[f]; ok {
+			out = append(out, f)
+		}
+	}
+	return append(out, "ai-inventory")
+}
+
 // parseFormats splits the comma-separated format flag into individual format
 // strings. "all" expands to all supported formats.
 func parseFormats(flag string) []string {
 	if flag == "all" {
-		return []string{"json", "sarif", "cdx", "spdx"}
+		return []string{"json", "sarif", "cdx", "spdx", "csv"}
 	}
 
 	var formats []string
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nox-hq/nox/core/metrics"
+)
+
+// labelList collects the values of a repeatable --metrics-label flag.
+type labelList map[string]string
+
+func (l labelList) String() string { return "" }
+
+func (l labelList) Set(s string) error {
+	name, value, err := metrics.ParseLabel(s)
+	if err != nil {
+		return err
+	}
+	l[name] = value
+	return nil
+}
+
+// metricsLabels returns labels with repo set to repo unless a
+// --metrics-label already set it.
+func metricsLabels(labels labelList, repo string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	if out["repo"] == "" {
+		out["repo"] = repo
+	}
+	return out
+}
+
+// buildInfo identifies this binary in nox_build_info.
+func buildInfo() metrics.BuildInfo {
+	return metrics.BuildInfo{Version: version, Commit: commit}
+}
+
+// serveMetrics serves reg at /metrics on addr in the background and
+// returns the address it listens on.
+func serveMetrics(addr string, reg *metrics.Registry) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics endpoint: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", reg)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return ln.Addr(), nil
+}
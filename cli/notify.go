@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// notifyCommand returns the command that shows a desktop notification on
+// goos: osascript on macOS, notify-send on Linux and a PowerShell toast on
+// Windows.
+func notifyCommand(goos, title, message string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+		return exec.Command("osascript", "-e", script), nil
+	case "linux":
+		return exec.Command("notify-send", title, message), nil
+	case "windows":
+		quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+		script := strings.Join([]string{
+			"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null",
+			"$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+			"$text = $xml.GetElementsByTagName('text')",
+			"$text.Item(0).AppendChild($xml.CreateTextNode(" + quote(title) + ")) | Out-Null",
+			"$text.Item(1).AppendChild($xml.CreateTextNode(" + quote(message) + ")) | Out-Null",
+			"$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)",
+			"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('nox').Show($toast)",
+		}, "; ")
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+	default:
+		return nil, fmt.Errorf("unsupported platform: %s", goos)
+	}
+}
+
+// sendNotification shows a desktop notification and waits for the
+// notifier to exit.
+func sendNotification(title, message string) error {
+	cmd, err := notifyCommand(runtime.GOOS, title, message)
+	if err != nil {
+		return err
+	}
+	return cmd.Run()
+}
+
+// execHookCommand returns the shell command for a watch --exec hook with
+// the scan delta in its environment.
+func execHookCommand(goos, command string, delta watchDelta, reportPath string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if goos == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"NOX_NEW_FINDINGS="+strconv.Itoa(delta.New),
+		"NOX_RESOLVED="+strconv.Itoa(delta.Resolved),
+		"NOX_REPORT_PATH="+reportPath,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// runExecHook runs a watch --exec hook and waits for it to finish.
+func runExecHook(command string, delta watchDelta, reportPath string) error {
+	return execHookCommand(runtime.GOOS, command, delta, reportPath).Run()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNotifyCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		name string
+		want string // substring of the joined arguments
+	}{
+		{"darwin", "osascript", `display notification "it's \"done\"" with title "nox"`},
+		{"linux", "notify-send", `nox it's "done"`},
+		{"windows", "powershell", `CreateTextNode('it''s "done"')`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			cmd, err := notifyCommand(tt.goos, "nox", `it's "done"`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(cmd.Path, tt.name) && cmd.Args[0] != tt.name {
+				t.Errorf("command = %s, want %s", cmd.Args[0], tt.name)
+			}
+			if args := strings.Join(cmd.Args[1:], " "); !strings.Contains(args, tt.want) {
+				t.Errorf("args = %q, want to contain %q", args, tt.want)
+			}
+		})
+	}
+	if _, err := notifyCommand("plan9", "nox", "x"); err == nil {
+		t.Error("expected error for unsupported platform")
+	}
+}
+
+func TestExecHookCommand_Env(t *testing.T) {
+	cmd := execHookCommand("linux", "true", watchDelta{New: 3, Resolved: 1}, "/tmp/r.json")
+	if cmd.Args[0] != "sh" {
+		t.Errorf("shell = %s, want sh", cmd.Args[0])
+	}
+	for _, kv := range []string{"NOX_NEW_FINDINGS=3", "NOX_RESOLVED=1", "NOX_REPORT_PATH=/tmp/r.json"} {
+		if !slices.Contains(cmd.Env, kv) {
+			t.Errorf("env missing %s", kv)
+		}
+	}
+	if cmd := execHookCommand("windows", "echo", watchDelta{}, ""); cmd.Args[0] != "cmd" {
+		t.Errorf("windows shell = %s, want cmd", cmd.Args[0])
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nox-hq/nox/core/codeowners"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// assignMissingOwners records CODEOWNERS owners on ff, whose paths are
+// relative to root, when none of them has owners yet, as in a report
+// written before the repository had a CODEOWNERS file.
+func assignMissingOwners(ff []findings.Finding, root string) {
+	for i := range ff {
+		if ff[i].Metadata[findings.MetaOwners] != "" {
+			return
+		}
+	}
+	co, err := codeowners.Load(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return
+	}
+	if co != nil {
+		co.Assign(ff, "")
+	}
+}
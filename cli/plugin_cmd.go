@@ -11,15 +11,18 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/nox-hq/nox/core/offline"
+	"github.com/nox-hq/nox/core/rulepack"
 	"github.com/nox-hq/nox/plugin"
 	"github.com/nox-hq/nox/registry"
 	"github.com/nox-hq/nox/registry/oci"
+	"github.com/nox-hq/nox/registry/trust"
 )
 
 // runPlugin dispatches plugin subcommands.
 func runPlugin(args []string) int {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Usage: nox plugin <search|info|install|update|list|remove|call|init|test>")
+		fmt.Fprintln(os.Stderr, "Usage: nox plugin <search|info|install|update|list|remove|call|invoke|init|scaffold|test>")
 		return 2
 	}
 
@@ -38,31 +41,45 @@ func runPlugin(args []string) int {
 		return runPluginRemove(args[1:])
 	case "call":
 		return runPluginCall(args[1:])
+	case "invoke":
+		return runPluginInvoke(args[1:])
 	case "init":
 		return runPluginInit(args[1:])
+	case "scaffold":
+		return runPluginScaffold(args[1:])
 	case "test":
 		return runPluginTest(args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown plugin command: %s\n", args[0])
-		fmt.Fprintln(os.Stderr, "Usage: nox plugin <search|info|install|update|list|remove|call|init|test>")
+		fmt.Fprintln(os.Stderr, "Usage: nox plugin <search|info|install|update|list|remove|call|invoke|init|scaffold|test>")
 		return 2
 	}
 }
 
 // newRegistryClient creates a registry client configured from state sources.
+// In offline mode it only reads cached indexes.
 func newRegistryClient(st *State) *registry.Client {
 	cacheDir := filepath.Join(noxHome(), "cache", "registry")
-	c := registry.NewClient(registry.WithCacheDir(cacheDir))
+	opts := []registry.ClientOption{registry.WithCacheDir(cacheDir)}
+	if offlineMode {
+		opts = append(opts, registry.WithHTTPClient(offline.Client()))
+	}
+	c := registry.NewClient(opts...)
 	for _, s := range st.Sources {
 		_ = c.AddSource(s)
 	}
 	return c
 }
 
-// newOCIStore creates an OCI artifact store using the nox home cache.
-func newOCIStore() *oci.Store {
+// newOCIStore creates an OCI artifact store using the nox home cache. In
+// offline mode downloads fail with offline.ErrOffline.
+func newOCIStore(opts ...oci.StoreOption) *oci.Store {
 	cacheDir := filepath.Join(noxHome(), "cache", "artifacts")
-	return oci.NewStore(oci.WithCacheDir(cacheDir))
+	base := []oci.StoreOption{oci.WithCacheDir(cacheDir)}
+	if offlineMode {
+		base = append(base, oci.WithHTTPClient(offline.Client()))
+	}
+	return oci.NewStore(append(base, opts...)...)
 }
 
 // runPluginSearch searches registries for plugins matching a query.
@@ -213,12 +230,20 @@ func runPluginInfo(args []string) int {
 
 // runPluginInstall installs a plugin from a registry.
 func runPluginInstall(args []string) int {
-	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "Usage: nox plugin install <name[@version]>")
+	fs := flag.NewFlagSet("plugin install", flag.ContinueOnError)
+	var allowUnsigned bool
+	fs.BoolVar(&allowUnsigned, "allow-unsigned", false, "install even if the artifact is unsigned or not signed by a trusted registry key")
+
+	positional, err := parseFlagsAnywhere(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: nox plugin install <name[@version]> [--allow-unsigned]")
 		return 2
 	}
 
-	nameVer := args[0]
+	nameVer := positional[0]
 	name, constraint := parseNameVersion(nameVer)
 
 	statePath := DefaultStatePath()
@@ -240,10 +265,9 @@ func runPluginInstall(args []string) int {
 	}
 
 	client := newRegistryClient(st)
-	store := newOCIStore()
 	ctx := context.Background()
 
-	ve, err := client.Resolve(ctx, name, constraint)
+	ve, src, err := client.ResolveSource(ctx, name, constraint)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: resolving %s@%s: %v\n", name, constraint, err)
 		return 2
@@ -255,6 +279,27 @@ func runPluginInstall(args []string) int {
 		return 0
 	}
 
+	store, err := newVerifyingStore(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if entry, err := client.Lookup(ctx, name); err == nil && entry.IsRulePack() {
+		ip, err := installRulePack(ctx, store, name, ve, src, allowUnsigned)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: installing rule pack %s@%s: %v\n", name, ve.Version, err)
+			return 2
+		}
+		st.AddPlugin(*ip)
+		if err := SaveState(statePath, st); err != nil {
+			fmt.Fprintf(os.Stderr, "error: saving state: %v\n", err)
+			return 2
+		}
+		fmt.Printf("Installed rule pack %s@%s (signature: %s). Enable it in .nox.yaml under scan.rule_packs.\n", name, ve.Version, ip.Signature)
+		return 0
+	}
+
 	artifact, err := store.Fetch(ctx, name, *ve)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: fetching %s@%s: %v\n", name, ve.Version, err)
@@ -274,16 +319,26 @@ func runPluginInstall(args []string) int {
 		}
 	}
 
+	status := signatureStatus(artifact.VerifyResult)
+	if err := requireSignature(status, src, allowUnsigned); err != nil {
+		fmt.Fprintf(os.Stderr, "error: refusing to install %s@%s: %v\n", name, ve.Version, err)
+		return 2
+	}
+
 	now := time.Now()
 	st.AddPlugin(InstalledPlugin{
-		Name:        name,
-		Version:     ve.Version,
-		Digest:      artifact.Digest,
-		BinaryPath:  artifact.BinaryPath,
-		TrustLevel:  trustLevel,
-		RiskClass:   ve.RiskClass,
-		InstalledAt: now,
-		UpdatedAt:   now,
+		Name:         name,
+		Version:      ve.Version,
+		Digest:       artifact.Digest,
+		BinaryPath:   artifact.BinaryPath,
+		BinaryDigest: artifact.BinaryDigest,
+		TrustLevel:   trustLevel,
+		RiskClass:    ve.RiskClass,
+		Registry:     src.Name,
+		Signature:    status,
+		Signer:       artifact.VerifyResult.SignerName,
+		InstalledAt:  now,
+		UpdatedAt:    now,
 	})
 
 	if err := SaveState(statePath, st); err != nil {
@@ -297,6 +352,15 @@ func runPluginInstall(args []string) int {
 
 // runPluginUpdate updates installed plugins to their latest versions.
 func runPluginUpdate(args []string) int {
+	fs := flag.NewFlagSet("plugin update", flag.ContinueOnError)
+	var allowUnsigned bool
+	fs.BoolVar(&allowUnsigned, "allow-unsigned", false, "update even if the new artifact is unsigned or not signed by a trusted registry key")
+
+	positional, err := parseFlagsAnywhere(fs, args)
+	if err != nil {
+		return 2
+	}
+
 	statePath := DefaultStatePath()
 	st, err := LoadState(statePath)
 	if err != nil {
@@ -311,8 +375,8 @@ func runPluginUpdate(args []string) int {
 
 	// Determine which plugins to update.
 	var targets []string
-	if len(args) > 0 {
-		targets = []string{args[0]}
+	if len(positional) > 0 {
+		targets = []string{positional[0]}
 	} else {
 		for _, p := range st.Plugins {
 			targets = append(targets, p.Name)
@@ -325,7 +389,6 @@ func runPluginUpdate(args []string) int {
 	}
 
 	client := newRegistryClient(st)
-	store := newOCIStore()
 	ctx := context.Background()
 
 	updated := 0
@@ -336,7 +399,7 @@ func runPluginUpdate(args []string) int {
 			continue
 		}
 
-		ve, err := client.Resolve(ctx, name, "*")
+		ve, src, err := client.ResolveSource(ctx, name, "*")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "warning: cannot resolve %s: %v\n", name, err)
 			continue
@@ -347,22 +410,51 @@ func runPluginUpdate(args []string) int {
 			continue
 		}
 
+		store, err := newVerifyingStore(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: cannot update %s: %v\n", name, err)
+			continue
+		}
+
+		if ip.IsRulePack() {
+			updatedPack, err := installRulePack(ctx, store, name, ve, src, allowUnsigned)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: cannot fetch %s@%s: %v\n", name, ve.Version, err)
+				continue
+			}
+			updatedPack.InstalledAt = ip.InstalledAt
+			fmt.Printf("Updated %s: %s -> %s\n", name, ip.Version, ve.Version)
+			st.AddPlugin(*updatedPack)
+			updated++
+			continue
+		}
+
 		artifact, err := store.Fetch(ctx, name, *ve)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "warning: cannot fetch %s@%s: %v\n", name, ve.Version, err)
 			continue
 		}
 
+		status := signatureStatus(artifact.VerifyResult)
+		if err := requireSignature(status, src, allowUnsigned); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: not updating %s to %s: %v\n", name, ve.Version, err)
+			continue
+		}
+
 		now := time.Now()
 		st.AddPlugin(InstalledPlugin{
-			Name:        name,
-			Version:     ve.Version,
-			Digest:      artifact.Digest,
-			BinaryPath:  artifact.BinaryPath,
-			TrustLevel:  artifact.VerifyResult.TrustLevel.String(),
-			RiskClass:   ve.RiskClass,
-			InstalledAt: ip.InstalledAt,
-			UpdatedAt:   now,
+			Name:         name,
+			Version:      ve.Version,
+			Digest:       artifact.Digest,
+			BinaryPath:   artifact.BinaryPath,
+			BinaryDigest: artifact.BinaryDigest,
+			TrustLevel:   artifact.VerifyResult.TrustLevel.String(),
+			RiskClass:    ve.RiskClass,
+			Registry:     src.Name,
+			Signature:    status,
+			Signer:       artifact.VerifyResult.SignerName,
+			InstalledAt:  ip.InstalledAt,
+			UpdatedAt:    now,
 		})
 		updated++
 		fmt.Printf("Updated %s: %s -> %s\n", name, ip.Version, ve.Version)
@@ -370,7 +462,7 @@ func runPluginUpdate(args []string) int {
 
 	// GC old artifacts.
 	if updated > 0 {
-		_, _ = store.GC(oci.GCOptions{ReferencedDigests: st.InstalledDigests()})
+		_, _ = newOCIStore().GC(oci.GCOptions{ReferencedDigests: st.InstalledDigests()})
 	}
 
 	if err := SaveState(statePath, st); err != nil {
@@ -400,9 +492,20 @@ func runPluginList(args []string) int {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tVERSION\tTRUST\tINSTALLED")
+	fmt.Fprintln(w, "NAME\tVERSION\tKIND\tTRUST\tSIGNATURE\tINSTALLED")
 	for _, p := range st.Plugins {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.Version, p.TrustLevel, p.InstalledAt.Format("2006-01-02"))
+		kind := string(registry.KindPlugin)
+		if p.IsRulePack() {
+			kind = string(registry.KindRulePack)
+		}
+		sig := p.Signature
+		if sig == "" {
+			sig = sigUnsigned
+		}
+		if p.Signer != "" {
+			sig += " (" + p.Signer + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Name, p.Version, kind, p.TrustLevel, sig, p.InstalledAt.Format("2006-01-02"))
 	}
 	w.Flush()
 	return 0
@@ -430,6 +533,12 @@ func runPluginRemove(args []string) int {
 	}
 
 	ver := ip.Version
+	if ip.IsRulePack() {
+		if err := rulepack.Remove(rulepack.DefaultDir(), name); err != nil {
+			fmt.Fprintf(os.Stderr, "error: removing rule pack: %v\n", err)
+			return 2
+		}
+	}
 	st.RemovePlugin(name)
 
 	// GC unreferenced artifacts.
@@ -448,16 +557,20 @@ func runPluginRemove(args []string) int {
 // runPluginCall invokes a tool on an installed plugin.
 func runPluginCall(args []string) int {
 	fs := flag.NewFlagSet("plugin call", flag.ContinueOnError)
-	var inputFile string
+	var (
+		inputFile     string
+		allowUnsigned bool
+	)
 	fs.StringVar(&inputFile, "input", "", "JSON file with tool input")
+	fs.BoolVar(&allowUnsigned, "allow-unsigned", false, "run the plugin even if it was not verified against a trusted registry key")
 
-	if err := fs.Parse(args); err != nil {
+	remaining, err := parseFlagsAnywhere(fs, args)
+	if err != nil {
 		return 2
 	}
 
-	remaining := fs.Args()
 	if len(remaining) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: nox plugin call <name> <tool> [--input <file.json>] [key=value ...]")
+		fmt.Fprintln(os.Stderr, "Usage: nox plugin call <name> <tool> [--input <file.json>] [--allow-unsigned] [key=value ...]")
 		return 2
 	}
 
@@ -476,6 +589,10 @@ func runPluginCall(args []string) int {
 		fmt.Fprintf(os.Stderr, "error: plugin %q is not installed\n", pluginName)
 		return 2
 	}
+	if ip.IsRulePack() {
+		fmt.Fprintf(os.Stderr, "error: %q is a rule pack; enable it with scan.rule_packs in .nox.yaml\n", pluginName)
+		return 2
+	}
 
 	// Build input map.
 	input := make(map[string]any)
@@ -499,14 +616,17 @@ func runPluginCall(args []string) int {
 		input[parts[0]] = parts[1]
 	}
 
-	// Load policy from .nox.yaml if present.
+	if err := verifyInstalledPlugin(ip, allowUnsigned); err != nil {
+		fmt.Fprintf(os.Stderr, "error: refusing to run %s: %v\n", pluginName, err)
+		return 2
+	}
+
 	cwd, _ := os.Getwd()
-	cfg, err := plugin.LoadConfig(filepath.Join(cwd, ".nox.yaml"))
+	policy, err := loadPluginPolicy(cwd)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: loading config: %v\n", err)
 		return 2
 	}
-	policy := cfg.PluginPolicy.ToPolicy()
 
 	host := plugin.NewHost(plugin.WithPolicy(policy))
 	defer host.Close()
@@ -544,6 +664,70 @@ func runPluginCall(args []string) int {
 	return 0
 }
 
+// loadPluginPolicy returns the plugin policy of the .nox.yaml in dir, or the
+// default policy if there is none. In offline mode plugins that declare
+// remote hosts are refused.
+func loadPluginPolicy(dir string) (plugin.Policy, error) {
+	cfg, err := plugin.LoadConfig(filepath.Join(dir, ".nox.yaml"))
+	if err != nil {
+		return plugin.Policy{}, err
+	}
+	policy := cfg.PluginPolicy.ToPolicy()
+	if offlineMode {
+		policy.AllowedNetworkHosts = []string{"localhost", "127.0.0.1", "::1"}
+		policy.AllowedNetworkCIDRs = nil
+	}
+	return policy, nil
+}
+
+// installRulePack fetches a rule pack, verifying its registry digest, and
+// unpacks its rule files into the local rule pack directory so scans can
+// resolve it offline. It returns the state entry to record.
+func installRulePack(ctx context.Context, store *oci.Store, name string, ve *registry.VersionEntry, src registry.Source, allowUnsigned bool) (*InstalledPlugin, error) {
+	pack, err := store.FetchRulePack(ctx, name, *ve)
+	if err != nil {
+		return nil, err
+	}
+	status := signatureStatus(pack.VerifyResult)
+	if err := requireSignature(status, src, allowUnsigned); err != nil {
+		return nil, err
+	}
+	if _, err := rulepack.Install(rulepack.DefaultDir(), name, ve.Version, pack.Digest, pack.Files); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &InstalledPlugin{
+		Name:        name,
+		Version:     ve.Version,
+		Digest:      pack.Digest,
+		TrustLevel:  pack.VerifyResult.TrustLevel.String(),
+		Kind:        string(registry.KindRulePack),
+		Registry:    src.Name,
+		Signature:   status,
+		Signer:      pack.VerifyResult.SignerName,
+		InstalledAt: now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// parseFlagsAnywhere parses fs from args, allowing flags to follow
+// positional arguments, and returns the positional arguments in order.
+func parseFlagsAnywhere(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 // parseNameVersion splits "name@version" into name and constraint.
 // If no "@" is present, constraint defaults to "*".
 func parseNameVersion(s string) (string, string) {
@@ -552,3 +736,106 @@ func parseNameVersion(s string) (string, string) {
 	}
 	return s, "*"
 }
+
+// Signature statuses recorded in state for installed plugins and rule packs.
+const (
+	sigVerified  = "verified"  // signed by a key trusted for the source registry
+	sigUntrusted = "untrusted" // validly signed, but by a key the registry does not pin
+	sigInvalid   = "invalid"   // signature present but does not verify
+	sigUnsigned  = "unsigned"  // no signature published
+)
+
+// signatureStatus summarises a verification result for state and display.
+func signatureStatus(r trust.VerifyResult) string {
+	switch {
+	case r.TrustLevel == trust.TrustVerified:
+		return sigVerified
+	case r.SignatureValid:
+		return sigUntrusted
+	}
+	for _, v := range r.Violations {
+		if v.Field == "signature" {
+			return sigInvalid
+		}
+	}
+	return sigUnsigned
+}
+
+// requireSignature returns an error explaining why an artifact with the given
+// signature status may not be installed from src, or nil if it may.
+func requireSignature(status string, src registry.Source, allowUnsigned bool) error {
+	if status == sigVerified || allowUnsigned {
+		return nil
+	}
+	var reason string
+	switch status {
+	case sigUntrusted:
+		reason = fmt.Sprintf("artifact is signed by a key not trusted for registry %q", src.Name)
+	case sigInvalid:
+		reason = "artifact signature does not verify"
+	default:
+		reason = "artifact is unsigned"
+	}
+	if len(src.TrustedKeys) == 0 {
+		reason += fmt.Sprintf(" (registry %q has no trusted keys; add one with: nox registry key add %s <key.pem>)", src.Name, src.Name)
+	}
+	return fmt.Errorf("%s; pass --allow-unsigned to proceed anyway", reason)
+}
+
+// sourceKeyring builds a keyring from the public keys pinned for src.
+func sourceKeyring(src registry.Source) (*trust.Keyring, error) {
+	kr := trust.NewKeyring()
+	for _, pem := range src.TrustedKeys {
+		k, err := trust.NewKey(src.Name, []byte(pem))
+		if err != nil {
+			return nil, fmt.Errorf("registry %q: invalid trusted key: %w", src.Name, err)
+		}
+		kr.Add(k)
+	}
+	return kr, nil
+}
+
+// newVerifyingStore creates an OCI store that verifies artifact signatures
+// against the keys pinned for src.
+func newVerifyingStore(src registry.Source) (*oci.Store, error) {
+	kr, err := sourceKeyring(src)
+	if err != nil {
+		return nil, err
+	}
+	return newOCIStore(oci.WithVerifier(trust.NewVerifier(trust.WithKeyring(kr)))), nil
+}
+
+// verifyInstalledPlugin checks, before execution, that ip was verified at
+// install time and that the executable it runs still matches the digest
+// recorded at install time. A missing executable is refused.
+func verifyInstalledPlugin(ip *InstalledPlugin, allowUnsigned bool) error {
+	want := ip.BinaryDigest
+	if want == "" && ip.Digest != "" && ip.BinaryPath == newOCIStore().BlobPath(ip.Digest) {
+		// Raw binaries installed before binary digests were recorded run
+		// the cached blob itself.
+		want = ip.Digest
+	}
+	if want == "" {
+		return fmt.Errorf("no digest recorded for the plugin executable; reinstall with: nox plugin install %s", ip.Name)
+	}
+	data, err := os.ReadFile(ip.BinaryPath)
+	if err != nil {
+		return fmt.Errorf("reading plugin executable: %w; reinstall with: nox plugin install %s", err, ip.Name)
+	}
+	match, err := trust.VerifyDigest(data, want)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return fmt.Errorf("plugin executable %s no longer matches digest %s; reinstall with: nox plugin install %s", ip.BinaryPath, want, ip.Name)
+	}
+
+	status := ip.Signature
+	if status == "" {
+		status = sigUnsigned
+	}
+	if status == sigVerified || allowUnsigned {
+		return nil
+	}
+	return fmt.Errorf("plugin signature is %s; reinstall from a registry with a trusted key or pass --allow-unsigned", status)
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,7 +13,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nox-hq/nox/core/rulepack"
 	"github.com/nox-hq/nox/registry"
+	"github.com/nox-hq/nox/registry/trust"
 )
 
 func testRegistryIndex() registry.Index {
@@ -629,3 +635,230 @@ func TestNewOCIStore(t *testing.T) {
 		t.Fatal("expected non-nil store")
 	}
 }
+
+// serveRulePack serves a one-file rule pack index. When sig is non-nil it is
+// published as a detached signature next to the pack.
+func serveRulePack(t *testing.T, sig []byte) (*httptest.Server, string) {
+	t.Helper()
+	packYAML := []byte("rules:\n  - id: ACME-001\n    version: \"1.0\"\n    description: \"ACME token\"\n    severity: high\n    confidence: high\n    matcher_type: regex\n    pattern: 'acme_[a-z0-9]{16}'\n")
+	sum := sha256.Sum256(packYAML)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/acme.yaml":
+			w.Write(packYAML)
+			return
+		case "/acme.yaml.sig":
+			w.Write(sig)
+			return
+		}
+		artifact := registry.PlatformArtifact{
+			OS: registry.PlatformAny, Arch: registry.PlatformAny,
+			URL: srv.URL + "/acme.yaml", Digest: digest,
+		}
+		if sig != nil {
+			artifact.SignatureURL = srv.URL + "/acme.yaml.sig"
+		}
+		idx := registry.Index{
+			SchemaVersion: "2",
+			Plugins: []registry.PluginEntry{{
+				Name: "acme/secret-rules",
+				Kind: registry.KindRulePack,
+				Versions: []registry.VersionEntry{{
+					Version:    "1.2.0",
+					APIVersion: "v1",
+					Digest:     digest,
+					Artifacts:  []registry.PlatformArtifact{artifact},
+				}},
+			}},
+		}
+		_ = json.NewEncoder(w).Encode(idx)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, digest
+}
+
+func TestRunPluginInstall_RulePack(t *testing.T) {
+	srv, digest := serveRulePack(t, nil)
+	dir := setupPluginTestState(t, srv)
+
+	if code := runPlugin([]string{"install", "acme/secret-rules@1.2.0"}); code != 2 {
+		t.Fatalf("install unsigned rule pack: expected exit 2, got %d", code)
+	}
+	if code := runPlugin([]string{"install", "acme/secret-rules@1.2.0", "--allow-unsigned"}); code != 0 {
+		t.Fatalf("install rule pack: expected exit 0, got %d", code)
+	}
+
+	st, _ := LoadState(filepath.Join(dir, "state.json"))
+	ip := st.FindPlugin("acme/secret-rules")
+	if ip == nil || !ip.IsRulePack() || ip.Digest != digest {
+		t.Fatalf("unexpected state entry: %+v", ip)
+	}
+	if ip.Signature != sigUnsigned || ip.Registry != "test" {
+		t.Fatalf("signature = %q, registry = %q; want unsigned from test", ip.Signature, ip.Registry)
+	}
+	pack, err := rulepack.Resolve(rulepack.DefaultDir(), "acme/secret-rules@1.2.0")
+	if err != nil {
+		t.Fatalf("resolving installed pack: %v", err)
+	}
+	if _, ok := pack.Files["acme.yaml"]; !ok {
+		t.Fatalf("expected acme.yaml in pack, got %v", pack.Files)
+	}
+
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, ".nox.yaml"), []byte("scan:\n  rule_packs:\n    - acme/secret-rules@^1.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(repo)
+	listed, err := collectRules("")
+	if err != nil {
+		t.Fatalf("collecting rules: %v", err)
+	}
+	if r := listed[len(listed)-1]; r.ID != "ACME-001" || r.Source != "plugin:acme/secret-rules@1.2.0" {
+		t.Fatalf("last listed rule = %s from %q; want ACME-001 from plugin:acme/secret-rules@1.2.0", r.ID, r.Source)
+	}
+
+	if code := runPlugin([]string{"call", "acme/secret-rules", "scan"}); code != 2 {
+		t.Fatalf("call on rule pack: expected exit 2, got %d", code)
+	}
+
+	if code := runPlugin([]string{"remove", "acme/secret-rules"}); code != 0 {
+		t.Fatalf("remove rule pack: expected exit 0, got %d", code)
+	}
+	if _, err := rulepack.Resolve(rulepack.DefaultDir(), "acme/secret-rules"); err == nil {
+		t.Fatal("expected rule pack files to be removed")
+	}
+}
+
+func TestRunPluginInstall_SignedRulePack(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packYAML := []byte("rules:\n  - id: ACME-001\n    version: \"1.0\"\n    description: \"ACME token\"\n    severity: high\n    confidence: high\n    matcher_type: regex\n    pattern: 'acme_[a-z0-9]{16}'\n")
+
+	tests := []struct {
+		name     string
+		sig      []byte
+		wantCode int
+	}{
+		{"trusted key", ed25519.Sign(priv, packYAML), 0},
+		{"untrusted key", ed25519.Sign(otherPriv, packYAML), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := serveRulePack(t, tt.sig)
+			dir := setupPluginTestState(t, srv)
+
+			keyFile := filepath.Join(t.TempDir(), "registry.pem")
+			if err := os.WriteFile(keyFile, trust.ExportKeyPEM(pub), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if code := runRegistry([]string{"key", "add", "test", keyFile}); code != 0 {
+				t.Fatalf("registry key add: expected exit 0, got %d", code)
+			}
+
+			if code := runPlugin([]string{"install", "acme/secret-rules"}); code != tt.wantCode {
+				t.Fatalf("install: expected exit %d, got %d", tt.wantCode, code)
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+
+			st, _ := LoadState(filepath.Join(dir, "state.json"))
+			ip := st.FindPlugin("acme/secret-rules")
+			if ip == nil || ip.Signature != sigVerified || ip.Signer != "test" {
+				t.Fatalf("expected verified signature from test, got %+v", ip)
+			}
+		})
+	}
+}
+
+func TestSignatureStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		result trust.VerifyResult
+		want   string
+	}{
+		{"verified", trust.VerifyResult{TrustLevel: trust.TrustVerified, SignatureValid: true}, sigVerified},
+		{"untrusted", trust.VerifyResult{TrustLevel: trust.TrustCommunity, SignatureValid: true}, sigUntrusted},
+		{"invalid", trust.VerifyResult{Violations: []trust.TrustViolation{{Field: "signature", Message: "bad"}}}, sigInvalid},
+		{"unsigned", trust.VerifyResult{}, sigUnsigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signatureStatus(tt.result); got != tt.want {
+				t.Errorf("signatureStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyInstalledPlugin(t *testing.T) {
+	t.Setenv("NOX_HOME", t.TempDir())
+
+	content := []byte("plugin binary")
+	digest := trust.ComputeDigest(content).String()
+	bin := filepath.Join(t.TempDir(), "dast")
+	if err := os.WriteFile(bin, content, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := &InstalledPlugin{Name: "nox/dast", BinaryPath: bin, BinaryDigest: digest}
+	if err := verifyInstalledPlugin(unsigned, false); err == nil {
+		t.Fatal("expected unsigned plugin to be refused")
+	}
+	if err := verifyInstalledPlugin(unsigned, true); err != nil {
+		t.Fatalf("expected --allow-unsigned to permit plugin, got %v", err)
+	}
+
+	// The executable of an extracted artifact is checked, not the archive.
+	extracted := &InstalledPlugin{Name: "nox/dast", Digest: trust.ComputeDigest([]byte("archive")).String(), BinaryPath: bin, BinaryDigest: digest, Signature: sigVerified}
+	if err := verifyInstalledPlugin(extracted, false); err != nil {
+		t.Fatalf("expected verified plugin to pass, got %v", err)
+	}
+	if err := os.WriteFile(bin, []byte("tampered"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyInstalledPlugin(extracted, true); err == nil {
+		t.Fatal("expected modified executable to be refused even with --allow-unsigned")
+	}
+	if err := os.Remove(bin); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyInstalledPlugin(extracted, true); err == nil {
+		t.Fatal("expected missing executable to be refused")
+	}
+
+	// A raw binary installed without a binary digest runs the cached blob,
+	// which is checked against the artifact digest.
+	blob := newOCIStore().BlobPath(digest)
+	if err := os.MkdirAll(filepath.Dir(blob), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blob, content, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := &InstalledPlugin{Name: "nox/dast", Digest: digest, BinaryPath: blob, Signature: sigVerified}
+	if err := verifyInstalledPlugin(legacy, false); err != nil {
+		t.Fatalf("expected verified plugin to pass, got %v", err)
+	}
+	if err := os.WriteFile(blob, []byte("tampered"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyInstalledPlugin(legacy, true); err == nil {
+		t.Fatal("expected modified artifact to be refused even with --allow-unsigned")
+	}
+
+	// Without a digest for the executable there is nothing to check against.
+	legacy.BinaryPath = bin
+	if err := verifyInstalledPlugin(legacy, true); err == nil {
+		t.Fatal("expected a plugin without an executable digest to be refused")
+	}
+}
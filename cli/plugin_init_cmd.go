@@ -29,6 +29,13 @@ type pluginInitData struct {
 	CapabilityDesc   string // e.g. "Static analysis"
 	ReadOnly         string // "true" or "false"
 	SafetyOpts       string // e.g. "sdk.WithRiskClass(sdk.RiskPassive)"
+	ToolName         string // e.g. "scan"
+
+	// Set by nox plugin scaffold only.
+	Capability  string // "analyzer" or "postprocessor"
+	ToolDesc    string // e.g. "Run Sast scan"
+	HandlerName string // e.g. "handleScan"
+	RuleID      string // rule of the sample finding, e.g. "SAST-001"
 }
 
 // runPluginInit scaffolds a new plugin project with track-aware templates.
@@ -142,6 +149,7 @@ func buildInitData(name string, track registry.Track, riskClassOverride string)
 		CapabilityDesc:   trackInfo.DisplayName + " analysis",
 		ReadOnly:         readOnly,
 		SafetyOpts:       safetyOpts,
+		ToolName:         "scan",
 	}
 }
 
@@ -174,25 +182,46 @@ func buildSafetyOpts(track registry.Track, riskClass string) string {
 	return strings.Join(opts, ",\n\t\t")
 }
 
+// templateFile maps an embedded template to its path in a scaffolded project.
+type templateFile struct {
+	tmpl string
+	out  string
+}
+
+// commonTemplates are the build, release, and documentation files shared by
+// every scaffolded project.
+var commonTemplates = []templateFile{
+	{"templates/go.mod.tmpl", "go.mod"},
+	{"templates/Makefile.tmpl", "Makefile"},
+	{"templates/README.md.tmpl", "README.md"},
+	{"templates/Dockerfile.tmpl", "Dockerfile"},
+	{"templates/ci.yml.tmpl", filepath.Join(".github", "workflows", "ci.yml")},
+	{"templates/release.yml.tmpl", filepath.Join(".github", "workflows", "release.yml")},
+}
+
 // scaffoldPlugin writes all template files into the output directory.
 func scaffoldPlugin(outDir string, data pluginInitData) error {
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return fmt.Errorf("creating output directory: %w", err)
-	}
-
-	// File mappings: template name → output path
-	files := []struct {
-		tmpl string
-		out  string
-	}{
+	files := append([]templateFile{
 		{"templates/main.go.tmpl", "main.go"},
 		{"templates/main_test.go.tmpl", "main_test.go"},
-		{"templates/go.mod.tmpl", "go.mod"},
-		{"templates/Makefile.tmpl", "Makefile"},
-		{"templates/README.md.tmpl", "README.md"},
-		{"templates/Dockerfile.tmpl", "Dockerfile"},
-		{"templates/ci.yml.tmpl", filepath.Join(".github", "workflows", "ci.yml")},
-		{"templates/release.yml.tmpl", filepath.Join(".github", "workflows", "release.yml")},
+	}, commonTemplates...)
+	if err := writeTemplates(outDir, files, data); err != nil {
+		return err
+	}
+
+	// Create testdata directory.
+	testdataDir := filepath.Join(outDir, "testdata")
+	if err := os.MkdirAll(testdataDir, 0o755); err != nil {
+		return fmt.Errorf("creating testdata: %w", err)
+	}
+
+	return nil
+}
+
+// writeTemplates renders each template with data into outDir.
+func writeTemplates(outDir string, files []templateFile, data pluginInitData) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
 	}
 
 	for _, f := range files {
@@ -223,11 +252,5 @@ func scaffoldPlugin(outDir string, data pluginInitData) error {
 		out.Close()
 	}
 
-	// Create testdata directory.
-	testdataDir := filepath.Join(outDir, "testdata")
-	if err := os.MkdirAll(testdataDir, 0o755); err != nil {
-		return fmt.Errorf("creating testdata: %w", err)
-	}
-
 	return nil
 }
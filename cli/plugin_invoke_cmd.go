@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/plugin"
+)
+
+// invokeReport is the --json output of nox plugin invoke.
+type invokeReport struct {
+	Plugin   string             `json:"plugin"`
+	Version  string             `json:"version"`
+	Tool     string             `json:"tool"`
+	Findings []findings.Finding `json:"findings"`
+}
+
+// runPluginInvoke runs an uninstalled plugin against a directory. --dev
+// names a plugin binary, or a Go project directory that is built first. A
+// "scan" tool is invoked with the directory as workspace; a "postprocess"
+// tool receives the findings of a nox scan of the directory as
+// input["findings"].
+func runPluginInvoke(args []string) int {
+	fs := flag.NewFlagSet("plugin invoke", flag.ContinueOnError)
+	var (
+		devPath    string
+		toolName   string
+		inputFile  string
+		jsonOutput bool
+	)
+	fs.StringVar(&devPath, "dev", "", "plugin binary or Go project directory to run without installing")
+	fs.StringVar(&toolName, "tool", "", "tool to invoke (default: scan, or postprocess if the plugin has no scan tool)")
+	fs.StringVar(&inputFile, "input", "", "JSON file with additional tool input")
+	fs.BoolVar(&jsonOutput, "json", false, "print findings as JSON")
+
+	remaining, err := parseFlagsAnywhere(fs, args)
+	if err != nil {
+		return 2
+	}
+	if devPath == "" || len(remaining) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: nox plugin invoke --dev <binary|dir> [--tool <name>] [--input <file.json>] [--json] [path]")
+		return 2
+	}
+
+	target := "."
+	if len(remaining) == 1 {
+		target = remaining[0]
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "error: %s is not a directory\n", target)
+		return 2
+	}
+
+	input := make(map[string]any)
+	if inputFile != "" {
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: reading input file: %v\n", err)
+			return 2
+		}
+		if err := json.Unmarshal(data, &input); err != nil {
+			fmt.Fprintf(os.Stderr, "error: parsing input file: %v\n", err)
+			return 2
+		}
+	}
+
+	binary, cleanup, err := resolveDevPlugin(devPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	defer cleanup()
+
+	cwd, _ := os.Getwd()
+	policy, err := loadPluginPolicy(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: loading config: %v\n", err)
+		return 2
+	}
+
+	host := plugin.NewHost(plugin.WithPolicy(policy))
+	defer host.Close()
+
+	ctx := context.Background()
+	if err := host.RegisterBinary(ctx, binary, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "error: registering plugin: %v\n", err)
+		return 2
+	}
+	info := host.Plugins()[0]
+
+	toolName, err = selectDevTool(info, toolName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if toolName == "postprocess" {
+		result, err := scanDir(ctx, target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: scanning %s: %v\n", target, err)
+			return 2
+		}
+		scanned, err := findingsInput(result.Findings.ActiveFindings())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding findings: %v\n", err)
+			return 2
+		}
+		input["findings"] = scanned
+	}
+
+	resp, err := host.InvokeTool(ctx, info.Name+"."+toolName, input, target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invoking tool: %v\n", err)
+		return 2
+	}
+
+	for _, d := range host.Diagnostics() {
+		fmt.Fprintf(os.Stderr, "[%s] %s: %s\n", d.Severity, d.Source, d.Message)
+	}
+
+	report := invokeReport{
+		Plugin:   info.Name,
+		Version:  info.Version,
+		Tool:     toolName,
+		Findings: make([]findings.Finding, 0, len(resp.GetFindings())),
+	}
+	for _, pf := range resp.GetFindings() {
+		if !plugin.KnownProtoSeverity(pf.GetSeverity()) {
+			fmt.Fprintf(os.Stderr, "[warn] finding %s has unknown severity %s; reporting it as info\n", pf.GetRuleId(), pf.GetSeverity())
+		}
+		report.Findings = append(report.Findings, plugin.ProtoFindingToGo(pf))
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding response: %v\n", err)
+			return 2
+		}
+	} else {
+		for _, f := range report.Findings {
+			fmt.Printf("  [%s] %s:%d — %s (%s)\n", f.Severity, f.Location.FilePath, f.Location.StartLine, f.Message, f.RuleID)
+		}
+		fmt.Printf("%s %s: %s returned %d finding(s)\n", info.Name, info.Version, toolName, len(report.Findings))
+	}
+
+	if len(report.Findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// resolveDevPlugin returns the plugin binary for path. A directory is built
+// with go build into a temporary directory, which cleanup removes.
+func resolveDevPlugin(path string) (binary string, cleanup func(), err error) {
+	cleanup = func() {}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", cleanup, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		abs, err := filepath.Abs(path)
+		return abs, cleanup, err
+	}
+	if _, err := os.Stat(filepath.Join(path, "go.mod")); err != nil {
+		return "", cleanup, fmt.Errorf("%s is not a Go plugin project (no go.mod)", path)
+	}
+
+	tmp, err := os.MkdirTemp("", "nox-plugin-dev-")
+	if err != nil {
+		return "", cleanup, err
+	}
+	cleanup = func() { _ = os.RemoveAll(tmp) }
+
+	binary = filepath.Join(tmp, "plugin")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	cmd := exec.Command("go", "build", "-o", binary, ".")
+	cmd.Dir = path
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		return "", func() {}, fmt.Errorf("building plugin in %s: %w", path, err)
+	}
+	return binary, cleanup, nil
+}
+
+// selectDevTool returns the tool of info to invoke: tool if set, otherwise
+// "scan", or "postprocess" if the plugin has no scan tool.
+func selectDevTool(info plugin.PluginInfo, tool string) (string, error) {
+	var names []string
+	has := make(map[string]bool)
+	for _, c := range info.Capabilities {
+		for _, t := range c.Tools {
+			names = append(names, t.Name)
+			has[t.Name] = true
+		}
+	}
+
+	switch {
+	case tool != "" && has[tool]:
+		return tool, nil
+	case tool != "":
+		return "", fmt.Errorf("plugin %s has no tool %q (tools: %s)", info.Name, tool, strings.Join(names, ", "))
+	case has["scan"]:
+		return "scan", nil
+	case has["postprocess"]:
+		return "postprocess", nil
+	default:
+		return "", fmt.Errorf("plugin %s has neither a scan nor a postprocess tool; choose one with --tool (tools: %s)", info.Name, strings.Join(names, ", "))
+	}
+}
+
+// findingsInput converts findings to the generic JSON values a tool input
+// carries.
+func findingsInput(fs []findings.Finding) ([]any, error) {
+	data, err := json.Marshal(fs)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, 0, len(fs))
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/plugin"
+)
+
+func TestRunPluginInvoke_Usage(t *testing.T) {
+	if code := runPluginInvoke(nil); code != 2 {
+		t.Errorf("missing --dev: expected exit code 2, got %d", code)
+	}
+	if code := runPluginInvoke([]string{"--dev", "x", "a", "b"}); code != 2 {
+		t.Errorf("two paths: expected exit code 2, got %d", code)
+	}
+	if code := runPluginInvoke([]string{"--dev", t.TempDir(), filepath.Join(t.TempDir(), "missing")}); code != 2 {
+		t.Errorf("missing target: expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunPluginInvoke_NotAGoProject(t *testing.T) {
+	code := runPluginInvoke([]string{"--dev", t.TempDir(), t.TempDir()})
+	if code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestSelectDevTool(t *testing.T) {
+	t.Parallel()
+
+	info := func(tools ...string) plugin.PluginInfo {
+		c := plugin.CapabilityInfo{Name: "c"}
+		for _, name := range tools {
+			c.Tools = append(c.Tools, plugin.ToolInfo{Name: name})
+		}
+		return plugin.PluginInfo{Name: "p", Capabilities: []plugin.CapabilityInfo{c}}
+	}
+
+	tests := []struct {
+		name    string
+		info    plugin.PluginInfo
+		tool    string
+		want    string
+		wantErr bool
+	}{
+		{"scan preferred", info("postprocess", "scan"), "", "scan", false},
+		{"postprocess fallback", info("postprocess"), "", "postprocess", false},
+		{"explicit tool", info("scan", "lint"), "lint", "lint", false},
+		{"unknown tool", info("scan"), "lint", "", true},
+		{"no default tool", info("lint"), "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectDevTool(tt.info, tt.tool)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("tool = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindingsInput(t *testing.T) {
+	t.Parallel()
+
+	in, err := findingsInput([]findings.Finding{{
+		RuleID:   "SEC-001",
+		Severity: findings.SeverityHigh,
+		Location: findings.Location{FilePath: "a.env", StartLine: 3},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := in[0].(map[string]any)
+	if !ok || f["RuleID"] != "SEC-001" || f["Severity"] != "high" {
+		t.Fatalf("unexpected input: %#v", in)
+	}
+	if loc, _ := f["Location"].(map[string]any); loc["StartLine"] != float64(3) {
+		t.Errorf("StartLine = %#v, want 3", loc["StartLine"])
+	}
+}
+
+// TestRunPluginInvoke_DevScaffold builds a scaffolded analyzer against this
+// module and runs it through nox plugin invoke --dev.
+func TestRunPluginInvoke_DevScaffold(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a plugin binary")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "my-analyzer")
+	if code := runPluginScaffold([]string{"--name", "my-analyzer", "--output", dir}); code != 0 {
+		t.Fatalf("scaffold: exit code %d", code)
+	}
+	edit := exec.Command("go", "mod", "edit", "-replace", "github.com/nox-hq/nox="+root)
+	edit.Dir = dir
+	if out, err := edit.CombinedOutput(); err != nil {
+		t.Fatalf("go mod edit: %v\n%s", err, out)
+	}
+	sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), sum, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	code, out := captureRulesOutput(t, func() int {
+		return runPluginInvoke([]string{"--dev", dir, "--json", filepath.Join(dir, "testdata")})
+	})
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d\n%s", code, out)
+	}
+	var report invokeReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("parsing output: %v\n%s", err, out)
+	}
+	if report.Plugin != "my-analyzer" || report.Tool != "scan" || len(report.Findings) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if f := report.Findings[0]; f.RuleID != "MY-ANALYZER-001" || !strings.HasSuffix(f.Location.FilePath, "sample.txt") {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/nox-hq/nox/registry"
+)
+
+// scaffoldCapabilities maps the capabilities nox plugin scaffold generates
+// to the track of the generated plugin and the tool it exposes.
+var scaffoldCapabilities = map[string]struct {
+	track registry.Track
+	tool  string
+}{
+	"analyzer":      {registry.TrackCoreAnalysis, "scan"},
+	"postprocessor": {registry.TrackPolicyGovernance, "postprocess"},
+}
+
+// runPluginScaffold generates a ready-to-build plugin project with a stub
+// tool, a registry manifest, and a Makefile whose dev target runs the plugin
+// through nox plugin invoke --dev.
+func runPluginScaffold(args []string) int {
+	fs := flag.NewFlagSet("plugin scaffold", flag.ContinueOnError)
+	var (
+		name       string
+		lang       string
+		capability string
+		outDir     string
+	)
+	fs.StringVar(&name, "name", "", "plugin name (e.g. my-analyzer or nox/my-analyzer)")
+	fs.StringVar(&lang, "lang", "go", "implementation language (go)")
+	fs.StringVar(&capability, "capability", "analyzer", "plugin capability: analyzer or postprocessor")
+	fs.StringVar(&outDir, "output", "", "output directory (default: the plugin's short name)")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if name == "" {
+		fmt.Fprintln(os.Stderr, "Usage: nox plugin scaffold --name <name> [--lang go] [--capability analyzer|postprocessor] [--output <dir>]")
+		return 2
+	}
+	if lang != "go" {
+		fmt.Fprintf(os.Stderr, "error: unsupported language %q (supported: go)\n", lang)
+		return 2
+	}
+	capInfo, ok := scaffoldCapabilities[capability]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown capability %q (expected analyzer or postprocessor)\n", capability)
+		return 2
+	}
+
+	data := buildScaffoldData(name, capability)
+	if outDir == "" {
+		outDir = path.Base(name)
+	}
+
+	files := append([]templateFile{
+		{"templates/scaffold_main.go.tmpl", "main.go"},
+		{"templates/scaffold_main_test.go.tmpl", "main_test.go"},
+		{"templates/plugin.json.tmpl", "plugin.json"},
+		{"templates/sample.txt.tmpl", "testdata/sample.txt"},
+	}, commonTemplates...)
+	if err := writeTemplates(outDir, files, data); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Created %s plugin project in %s/\n", capability, outDir)
+	fmt.Printf("  Track: %s (%s)\n", data.TrackDisplayName, capInfo.track)
+	fmt.Printf("  Tool:  %s\n", data.ToolName)
+	fmt.Println("\nNext steps:")
+	fmt.Printf("  cd %s\n", outDir)
+	fmt.Println("  go mod tidy")
+	fmt.Println("  make test")
+	fmt.Println("  make dev    # nox plugin invoke --dev . testdata")
+	return 0
+}
+
+// buildScaffoldData constructs template data for a plugin with the given
+// capability, which must be a key of scaffoldCapabilities.
+func buildScaffoldData(name, capability string) pluginInitData {
+	capInfo := scaffoldCapabilities[capability]
+	data := buildInitData(name, capInfo.track, "")
+	data.Capability = capability
+	data.ToolName = capInfo.tool
+	data.HandlerName = "handle" + strings.ToUpper(capInfo.tool[:1]) + capInfo.tool[1:]
+	data.ToolDesc = "Run " + data.DisplayName + " " + capInfo.tool
+	data.RuleID = strings.ToUpper(data.CapabilityName) + "-001"
+	return data
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildScaffoldData(t *testing.T) {
+	t.Parallel()
+
+	data := buildScaffoldData("my-analyzer", "analyzer")
+	if data.Track != "core-analysis" {
+		t.Errorf("Track = %q, want core-analysis", data.Track)
+	}
+	if data.ToolName != "scan" || data.HandlerName != "handleScan" {
+		t.Errorf("tool = %q/%q, want scan/handleScan", data.ToolName, data.HandlerName)
+	}
+	if data.RuleID != "MY-ANALYZER-001" {
+		t.Errorf("RuleID = %q, want MY-ANALYZER-001", data.RuleID)
+	}
+
+	data = buildScaffoldData("acme/triage", "postprocessor")
+	if data.Track != "policy-governance" {
+		t.Errorf("Track = %q, want policy-governance", data.Track)
+	}
+	if data.ToolName != "postprocess" || data.HandlerName != "handlePostprocess" {
+		t.Errorf("tool = %q/%q, want postprocess/handlePostprocess", data.ToolName, data.HandlerName)
+	}
+}
+
+func TestRunPluginScaffold_Analyzer(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "my-analyzer")
+
+	code := run([]string{"plugin", "scaffold", "--name", "my-analyzer", "--lang", "go", "--output", outDir})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	for _, f := range []string{"main.go", "main_test.go", "go.mod", "Makefile", "README.md", "plugin.json", filepath.Join("testdata", "sample.txt")} {
+		if info, err := os.Stat(filepath.Join(outDir, f)); err != nil || info.Size() == 0 {
+			t.Errorf("expected %s to exist with content: %v", f, err)
+		}
+	}
+
+	mainGo, _ := os.ReadFile(filepath.Join(outDir, "main.go"))
+	if !strings.Contains(string(mainGo), `HandleTool("scan", handleScan)`) {
+		t.Errorf("main.go should register the scan tool:\n%s", mainGo)
+	}
+	makefile, _ := os.ReadFile(filepath.Join(outDir, "Makefile"))
+	if !strings.Contains(string(makefile), "nox plugin invoke --dev . testdata") {
+		t.Errorf("Makefile should have a dev target:\n%s", makefile)
+	}
+	manifest, _ := os.ReadFile(filepath.Join(outDir, "plugin.json"))
+	if !strings.Contains(string(manifest), `"track": "core-analysis"`) {
+		t.Errorf("plugin.json should declare the track:\n%s", manifest)
+	}
+}
+
+func TestRunPluginScaffold_Postprocessor(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "triage")
+
+	code := runPluginScaffold([]string{"--name", "acme/triage", "--capability", "postprocessor", "--output", outDir})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	mainGo, _ := os.ReadFile(filepath.Join(outDir, "main.go"))
+	if !strings.Contains(string(mainGo), `HandleTool("postprocess", handlePostprocess)`) {
+		t.Errorf("main.go should register the postprocess tool:\n%s", mainGo)
+	}
+	if strings.Contains(string(mainGo), "handleScan") {
+		t.Error("postprocessor main.go should not contain the analyzer stub")
+	}
+}
+
+func TestRunPluginScaffold_InvalidArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing name", nil},
+		{"unsupported lang", []string{"--name", "x", "--lang", "rust"}},
+		{"unknown capability", []string{"--name", "x", "--capability", "exporter"}},
+		{"bad flag", []string{"--bogus"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := runPluginScaffold(tt.args); code != 2 {
+				t.Errorf("expected exit code 2, got %d", code)
+			}
+		})
+	}
+}
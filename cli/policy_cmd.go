@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/policy"
+	"github.com/nox-hq/nox/core/report"
+)
+
+const policyUsage = "Usage: nox policy test --findings <findings.json> [--expression <cel>] [dir]"
+
+func runPolicy(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, policyUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "test":
+		return policyTest(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown policy subcommand: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, policyUsage)
+		return 2
+	}
+}
+
+// policyTest evaluates the policy from dir's .nox.yaml against a saved
+// findings.json without scanning. --expression replaces the configured CEL
+// expression. The exit code is the one nox scan would return.
+func policyTest(args []string) int {
+	fs := flag.NewFlagSet("policy test", flag.ContinueOnError)
+	var findingsPath, expression string
+	fs.StringVar(&findingsPath, "findings", "findings.json", "path to a findings.json report")
+	fs.StringVar(&expression, "expression", "", "CEL expression to evaluate instead of policy.expression")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	cfg, err := nox.LoadScanConfig(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	settings := cfg.Policy
+	if expression != "" {
+		settings.Expression, settings.ExpressionFile = expression, ""
+	}
+	expr, err := nox.LoadPolicyExpression(root, settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if settings.FailOn == "" && settings.BaselineMode == "" && expr == nil {
+		fmt.Fprintln(os.Stderr, "error: no policy configured (set policy.fail_on or policy.expression, or pass --expression)")
+		return 2
+	}
+
+	scope, err := policy.ParseScope(settings.Scope)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: policy.scope: %v\n", err)
+		return 2
+	}
+
+	data, err := report.ReadFile(findingsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: reading findings file: %v\n", err)
+		return 2
+	}
+	var rep report.JSONReport
+	if err := json.Unmarshal(data, &rep); err != nil {
+		fmt.Fprintf(os.Stderr, "error: parsing findings JSON: %v\n", err)
+		return 2
+	}
+	categories, err := nox.ReportCategories(cfg, &rep)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	// findings.json has no package inventory, so the inventory variables
+	// are zero.
+	result := policy.EvaluateInput(policy.Config{
+		FailOn:       cfg.SeverityMapping.Resolve(settings.FailOn),
+		WarnOn:       cfg.SeverityMapping.Resolve(settings.WarnOn),
+		BaselineMode: policy.BaselineMode(settings.BaselineMode),
+		Scope:        scope,
+		Expression:   expr,
+		Categories:   nox.PolicyCategories(cfg, categories),
+		Category:     nox.ReportFindingCategory,
+	}, policy.Input{Findings: rep.Findings})
+
+	for _, w := range result.Warnings {
+		fmt.Printf("[warn] %s\n", w)
+	}
+	fmt.Printf("[policy] %s\n", result.Summary)
+	return result.ExitCode
+}
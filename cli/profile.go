@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// Profile file names written to the --profile directory.
+const (
+	cpuProfileFile  = "cpu.pprof"
+	heapProfileFile = "heap.pprof"
+)
+
+// startProfile starts a CPU profile written to cpu.pprof in dir, creating
+// dir if needed. The returned function stops it and writes a heap profile
+// to heap.pprof; call it when the profiled phase ends.
+func startProfile(dir string) (stop func() error, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	cpu, err := os.Create(filepath.Join(dir, cpuProfileFile))
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		_ = cpu.Close()
+		return nil, err
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		err := cpu.Close()
+		heap, createErr := os.Create(filepath.Join(dir, heapProfileFile))
+		if createErr != nil {
+			return errors.Join(err, createErr)
+		}
+		// The heap profile shows the allocations as of the last GC.
+		runtime.GC()
+		return errors.Join(err, pprof.WriteHeapProfile(heap), heap.Close())
+	}, nil
+}
+
+// printPerformance prints the time each analyzer pass took and the slowest
+// rules of result, for --verbose.
+func printPerformance(result *nox.ScanResult) {
+	if len(result.AnalyzerTimings) > 0 {
+		fmt.Println("[timing] analyzers:")
+		for _, t := range result.AnalyzerTimings {
+			fmt.Printf("  %-10s %10s  (%d findings)\n", t.Analyzer, t.Duration.Round(time.Microsecond), t.Findings)
+		}
+	}
+	if len(result.RuleTimings) > 0 {
+		fmt.Println("[timing] slowest rules:")
+		for _, rt := range rules.SlowestRules(result.RuleTimings, nox.SlowestRulesReported) {
+			fmt.Printf("  %-10s %10s  (%d files, %d matches)\n", rt.RuleID, rt.Duration.Round(time.Microsecond), rt.Files, rt.Matches)
+		}
+	}
+}
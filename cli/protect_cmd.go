@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/findings"
 	"github.com/nox-hq/nox/core/git"
 )
 
@@ -14,11 +22,24 @@ import (
 // hooks managed by nox.
 const hookMarker = "Installed by nox protect"
 
+// preReceiveBypassEnv names the environment variable that lets every push
+// through the pre-receive hook. Its value is the reason, which is logged
+// with the findings let through.
+const preReceiveBypassEnv = "NOX_PRE_RECEIVE_BYPASS"
+
+// preReceiveStdin is where the pre-receive subcommand reads the ref
+// updates from.
+var preReceiveStdin io.Reader = os.Stdin
+
 // runProtect implements the "nox protect" command with install, uninstall, and
-// status subcommands for managing git pre-commit hooks.
+// status subcommands for managing git pre-commit hooks. --hook commit-msg
+// installs the scan as a commit-msg hook instead, which sees the commit
+// message and so honours Nox-Override trailers. --hook pre-receive installs
+// a server-side hook in a bare repository, which runs the pre-receive
+// subcommand.
 func runProtect(args []string) int {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Usage: nox protect <install|uninstall|status> [flags]")
+		fmt.Fprintln(os.Stderr, "Usage: nox protect <install|uninstall|status|pre-receive> [flags]")
 		return 2
 	}
 
@@ -32,9 +53,11 @@ func runProtect(args []string) int {
 		return protectUninstall(remaining)
 	case "status":
 		return protectStatus(remaining)
+	case "pre-receive":
+		return protectPreReceive(remaining)
 	default:
 		fmt.Fprintf(os.Stderr, "unknown protect subcommand: %s\n", subcommand)
-		fmt.Fprintln(os.Stderr, "Usage: nox protect <install|uninstall|status> [flags]")
+		fmt.Fprintln(os.Stderr, "Usage: nox protect <install|uninstall|status|pre-receive> [flags]")
 		return 2
 	}
 }
@@ -43,12 +66,16 @@ func protectInstall(args []string) int {
 	fs := flag.NewFlagSet("protect install", flag.ContinueOnError)
 	var (
 		threshold string
+		hook      string
 		hookPath  string
 		force     bool
+		timeout   time.Duration
 	)
 	fs.StringVar(&threshold, "severity-threshold", "high", "minimum severity to block commit (critical, high, medium, low)")
-	fs.StringVar(&hookPath, "hook-path", "", "path to pre-commit hook file (default: auto-detect)")
+	fs.StringVar(&hook, "hook", "pre-commit", "git hook to install (pre-commit, commit-msg, pre-receive)")
+	fs.StringVar(&hookPath, "hook-path", "", "path to the hook file (default: auto-detect)")
 	fs.BoolVar(&force, "force", false, "overwrite existing hook without prompting")
+	fs.DurationVar(&timeout, "timeout", defaultPreReceiveTimeout, "time budget of each pre-receive scan (pre-receive hook only)")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
@@ -58,6 +85,10 @@ func protectInstall(args []string) int {
 		fmt.Fprintf(os.Stderr, "error: invalid severity threshold: %q (must be critical, high, medium, or low)\n", threshold)
 		return 2
 	}
+	if !isValidHook(hook) {
+		fmt.Fprintf(os.Stderr, "error: invalid hook: %q (must be pre-commit, commit-msg or pre-receive)\n", hook)
+		return 2
+	}
 
 	// Determine working directory.
 	dir := "."
@@ -65,12 +96,7 @@ func protectInstall(args []string) int {
 		dir = fs.Arg(0)
 	}
 
-	if !git.IsGitRepo(dir) {
-		fmt.Fprintln(os.Stderr, "error: not a git repository")
-		return 2
-	}
-
-	repoRoot, err := git.RepoRoot(dir)
+	defaultPath, err := defaultHookPath(dir, hook)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		return 2
@@ -78,7 +104,7 @@ func protectInstall(args []string) int {
 
 	// Resolve hook path.
 	if hookPath == "" {
-		hookPath = filepath.Join(repoRoot, ".git", "hooks", "pre-commit")
+		hookPath = defaultPath
 	}
 
 	// Check for existing hook.
@@ -87,18 +113,26 @@ func protectInstall(args []string) int {
 			// Read existing hook to check if it was installed by nox.
 			existing, readErr := os.ReadFile(hookPath)
 			if readErr == nil && strings.Contains(string(existing), hookMarker) {
-				fmt.Fprintln(os.Stderr, "error: nox pre-commit hook is already installed")
+				fmt.Fprintf(os.Stderr, "error: nox %s hook is already installed\n", hook)
 				fmt.Fprintln(os.Stderr, "  use --force to overwrite")
 				return 2
 			}
-			fmt.Fprintln(os.Stderr, "error: pre-commit hook already exists at "+hookPath)
+			fmt.Fprintf(os.Stderr, "error: %s hook already exists at %s\n", hook, hookPath)
 			fmt.Fprintln(os.Stderr, "  use --force to overwrite")
 			return 2
 		}
 	}
 
-	// Write the hook script.
-	hookContent := generateHookScript(threshold)
+	// Write the hook script. The binary running this command is the
+	// fallback for a hook shell that cannot find nox on its PATH.
+	noxPath, err := os.Executable()
+	if err != nil {
+		noxPath = ""
+	}
+	hookContent := generateHookScript(hook, threshold, noxPath)
+	if hook == "pre-receive" {
+		hookContent = generatePreReceiveScript(threshold, timeout, noxPath)
+	}
 
 	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "error: creating hooks directory: %v\n", err)
@@ -110,44 +144,52 @@ func protectInstall(args []string) int {
 		return 2
 	}
 
-	fmt.Printf("protect: installed pre-commit hook at %s\n", hookPath)
+	fmt.Printf("protect: installed %s hook at %s\n", hook, hookPath)
+	if hook == "pre-receive" {
+		fmt.Printf("protect: pushes will be rejected on new secrets of severity >= %s\n", threshold)
+		fmt.Printf("protect: set %s=<reason> in the server's environment to let pushes through (logged)\n", preReceiveBypassEnv)
+		return 0
+	}
 	fmt.Printf("protect: commits will be blocked on severity >= %s\n", threshold)
+	if hook == "commit-msg" {
+		fmt.Printf("protect: a 'Nox-Override: <finding-id> reason=<text>' trailer lets a finding through\n")
+	}
 	return 0
 }
 
 func protectUninstall(args []string) int {
 	fs := flag.NewFlagSet("protect uninstall", flag.ContinueOnError)
-	var hookPath string
-	fs.StringVar(&hookPath, "hook-path", "", "path to pre-commit hook file (default: auto-detect)")
+	var hook, hookPath string
+	fs.StringVar(&hook, "hook", "pre-commit", "git hook to remove (pre-commit, commit-msg, pre-receive)")
+	fs.StringVar(&hookPath, "hook-path", "", "path to the hook file (default: auto-detect)")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
+	if !isValidHook(hook) {
+		fmt.Fprintf(os.Stderr, "error: invalid hook: %q (must be pre-commit, commit-msg or pre-receive)\n", hook)
+		return 2
+	}
 
 	dir := "."
 	if fs.NArg() > 0 {
 		dir = fs.Arg(0)
 	}
 
-	if !git.IsGitRepo(dir) {
-		fmt.Fprintln(os.Stderr, "error: not a git repository")
-		return 2
-	}
-
-	repoRoot, err := git.RepoRoot(dir)
+	defaultPath, err := defaultHookPath(dir, hook)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		return 2
 	}
 
 	if hookPath == "" {
-		hookPath = filepath.Join(repoRoot, ".git", "hooks", "pre-commit")
+		hookPath = defaultPath
 	}
 
 	// Check if hook exists.
 	content, err := os.ReadFile(hookPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			fmt.Fprintln(os.Stderr, "error: no pre-commit hook found")
+			fmt.Fprintf(os.Stderr, "error: no %s hook found\n", hook)
 			return 2
 		}
 		fmt.Fprintf(os.Stderr, "error: reading hook: %v\n", err)
@@ -156,7 +198,7 @@ func protectUninstall(args []string) int {
 
 	// Verify it was installed by nox.
 	if !strings.Contains(string(content), hookMarker) {
-		fmt.Fprintln(os.Stderr, "error: pre-commit hook was not installed by nox — refusing to remove")
+		fmt.Fprintf(os.Stderr, "error: %s hook was not installed by nox — refusing to remove\n", hook)
 		return 2
 	}
 
@@ -165,36 +207,36 @@ func protectUninstall(args []string) int {
 		return 2
 	}
 
-	fmt.Printf("protect: removed pre-commit hook from %s\n", hookPath)
+	fmt.Printf("protect: removed %s hook from %s\n", hook, hookPath)
 	return 0
 }
 
 func protectStatus(args []string) int {
 	fs := flag.NewFlagSet("protect status", flag.ContinueOnError)
-	var hookPath string
-	fs.StringVar(&hookPath, "hook-path", "", "path to pre-commit hook file (default: auto-detect)")
+	var hook, hookPath string
+	fs.StringVar(&hook, "hook", "pre-commit", "git hook to check (pre-commit, commit-msg, pre-receive)")
+	fs.StringVar(&hookPath, "hook-path", "", "path to the hook file (default: auto-detect)")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
+	if !isValidHook(hook) {
+		fmt.Fprintf(os.Stderr, "error: invalid hook: %q (must be pre-commit, commit-msg or pre-receive)\n", hook)
+		return 2
+	}
 
 	dir := "."
 	if fs.NArg() > 0 {
 		dir = fs.Arg(0)
 	}
 
-	if !git.IsGitRepo(dir) {
-		fmt.Fprintln(os.Stderr, "error: not a git repository")
-		return 2
-	}
-
-	repoRoot, err := git.RepoRoot(dir)
+	defaultPath, err := defaultHookPath(dir, hook)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		return 2
 	}
 
 	if hookPath == "" {
-		hookPath = filepath.Join(repoRoot, ".git", "hooks", "pre-commit")
+		hookPath = defaultPath
 	}
 
 	content, err := os.ReadFile(hookPath)
@@ -210,28 +252,245 @@ func protectStatus(args []string) int {
 	if strings.Contains(string(content), hookMarker) {
 		fmt.Println("protect: installed")
 	} else {
-		fmt.Println("protect: not installed (pre-commit hook exists but was not installed by nox)")
+		fmt.Printf("protect: not installed (%s hook exists but was not installed by nox)\n", hook)
+	}
+	return 0
+}
+
+// defaultPreReceiveTimeout is the default time budget of a pre-receive
+// scan. Git servers usually time hooks out after a minute or more.
+const defaultPreReceiveTimeout = 30 * time.Second
+
+// protectPreReceive implements "nox protect pre-receive", run by the
+// pre-receive hook of a git server. It reads the "<old> <new> <ref>" lines
+// git passes the hook, scans the files the pushed commits introduce for
+// secrets and exits 1, which rejects the push, when it finds new secrets at
+// or above the threshold or, unless --on-timeout is allow, when the scan
+// does not finish in time.
+func protectPreReceive(args []string) int {
+	fs := flag.NewFlagSet("protect pre-receive", flag.ContinueOnError)
+	var (
+		threshold string
+		timeout   time.Duration
+		onTimeout string
+	)
+	fs.StringVar(&threshold, "severity-threshold", "high", "minimum severity to reject a push (critical, high, medium, low)")
+	fs.DurationVar(&timeout, "timeout", defaultPreReceiveTimeout, "time budget of the scan; 0 for none")
+	fs.StringVar(&onTimeout, "on-timeout", "reject", "what to do with a push the scan could not finish in time: reject, allow")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !isValidThreshold(threshold) {
+		fmt.Fprintf(os.Stderr, "error: invalid severity threshold: %q (must be critical, high, medium, or low)\n", threshold)
+		return 2
+	}
+	if onTimeout != "allow" && onTimeout != "reject" {
+		fmt.Fprintf(os.Stderr, "error: invalid --on-timeout: %q (must be allow or reject)\n", onTimeout)
+		return 2
+	}
+
+	// Git runs the hook in the git directory of the receiving repository.
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	updates, err := git.ParseRefUpdates(preReceiveStdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nox: error: %v\n", err)
+		return 2
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	result, err := nox.RunPushScan(ctx, dir, updates)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nox: error: %v\n", err)
+		return 2
+	}
+
+	var blocking []findings.Finding
+	for _, f := range result.Findings.ActiveFindings() {
+		if f.Severity.MeetsThreshold(findings.Severity(threshold)) {
+			blocking = append(blocking, f)
+		}
+	}
+
+	if reason, ok := os.LookupEnv(preReceiveBypassEnv); ok {
+		logPreReceiveBypass(os.Stderr, reason, updates, blocking)
+		return 0
+	}
+	if result.Partial {
+		fmt.Fprintf(os.Stderr, "nox: warning: the scan did not finish within %s; %d secret(s) found so far\n", timeout, len(blocking))
+		if onTimeout == "reject" || len(blocking) > 0 {
+			writePreReceiveRejection(os.Stderr, threshold, blocking)
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, "nox: warning: push accepted without a complete secrets check")
+		slog.Warn("pre-receive scan timed out; push accepted", "timeout", timeout, "refs", refNames(updates))
+		return 0
+	}
+	if len(blocking) > 0 {
+		writePreReceiveRejection(os.Stderr, threshold, blocking)
+		return 1
 	}
 	return 0
 }
 
-// generateHookScript produces the shell script content for the pre-commit hook.
-func generateHookScript(threshold string) string {
+// writePreReceiveRejection explains to the pusher why the push was
+// rejected. Git relays the hook's output to them. With no findings, the
+// push was rejected because the scan did not finish.
+func writePreReceiveRejection(w io.Writer, threshold string, blocking []findings.Finding) {
+	fmt.Fprintln(w)
+	if len(blocking) == 0 {
+		fmt.Fprintln(w, "nox: push rejected: the secrets check did not finish in time")
+		fmt.Fprintln(w, "nox: push fewer commits at a time, or ask an administrator to raise the time budget")
+		fmt.Fprintln(w)
+		return
+	}
+	fmt.Fprintf(w, "nox: push rejected: %d new secret(s) of severity >= %s\n", len(blocking), threshold)
+	fmt.Fprintln(w)
+	for _, f := range blocking {
+		fmt.Fprintf(w, "  %s:%d  %s [%s] %s\n", f.Location.FilePath, f.Location.StartLine, f.RuleID, f.Severity, f.Message)
+		commit := shortSHA(f.Metadata[findings.MetaCommitSHA])
+		if author := f.Metadata["commit_author"]; author != "" {
+			commit += fmt.Sprintf(" by %s: %q", author, f.Metadata["commit_message"])
+		}
+		fmt.Fprintf(w, "    in %s, commit %s\n", f.Metadata["ref"], commit)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "nox: remove the secrets from these commits (for example with 'git rebase -i') and push again")
+	fmt.Fprintln(w, "nox: rotate any secret that was real; it has left your machine")
+	fmt.Fprintln(w, "nox: use '// nox:ignore RULE-ID -- reason' on the line to suppress a false positive")
+	fmt.Fprintln(w)
+}
+
+// logPreReceiveBypass reports loudly, to the pusher and to the server's
+// log, that a push was let through by preReceiveBypassEnv.
+func logPreReceiveBypass(w io.Writer, reason string, updates []git.RefUpdate, blocking []findings.Finding) {
+	banner := strings.Repeat("*", 72)
+	fmt.Fprintln(w, banner)
+	fmt.Fprintf(w, "nox: SECRETS CHECK BYPASSED by %s (reason: %q)\n", preReceiveBypassEnv, reason)
+	fmt.Fprintf(w, "nox: refs: %s\n", strings.Join(refNames(updates), " "))
+	fmt.Fprintf(w, "nox: %d secret(s) that would have rejected the push were let through\n", len(blocking))
+	for _, f := range blocking {
+		fmt.Fprintf(w, "nox:   %s:%d  %s [%s] commit %s\n", f.Location.FilePath, f.Location.StartLine, f.RuleID, f.Severity, shortSHA(f.Metadata[findings.MetaCommitSHA]))
+	}
+	fmt.Fprintln(w, banner)
+
+	ids := make([]string, 0, len(blocking))
+	for _, f := range blocking {
+		ids = append(ids, f.RuleID+":"+f.Location.FilePath+":"+strconv.Itoa(f.Location.StartLine))
+	}
+	slog.Warn("pre-receive secrets check bypassed", "env", preReceiveBypassEnv, "reason", reason,
+		"refs", refNames(updates), "findings", ids)
+}
+
+// refNames returns the names of the updated refs.
+func refNames(updates []git.RefUpdate) []string {
+	names := make([]string, len(updates))
+	for i, u := range updates {
+		names[i] = u.Ref
+	}
+	return names
+}
+
+// shortSHA abbreviates a commit SHA to 12 characters.
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// generateHookScript produces the shell script content for the given hook.
+// The script runs nox from PATH, or noxPath when nox is not on the PATH of
+// the shell git runs hooks with, as happens on Windows when nox.exe was
+// installed outside the Git for Windows environment. Git for Windows runs
+// hooks with its bundled sh whether the commit is made from Git Bash, cmd or
+// PowerShell, so one POSIX script serves every platform; it is written with
+// LF line endings, which that sh requires. noxPath may be empty.
+func generateHookScript(hook, threshold, noxPath string) string {
+	scan := `"$nox" scan --staged --severity-threshold ` + threshold + " --quiet ."
+	hint := ""
+	if hook == "commit-msg" {
+		scan = `"$nox" scan --staged --commit-msg "$1" --severity-threshold ` + threshold + " --quiet ."
+		hint = "\n    echo \"nox: add a 'Nox-Override: <finding-id> reason=<text>' trailer to let one finding through (logged)\""
+	}
 	return fmt.Sprintf(`#!/bin/sh
 # %s - https://github.com/nox-hq/nox
-# To uninstall: nox protect uninstall
+# To uninstall: nox protect uninstall --hook %s
 
-nox scan --staged --severity-threshold %s --quiet .
+nox=nox
+%s%s
 exit_code=$?
 if [ $exit_code -eq 1 ]; then
     echo ""
     echo "nox: commit blocked — secrets or security issues found in staged files" # nox:ignore SEC-163 -- em dash in string not hex
-    echo "nox: use '// nox:ignore RULE-ID -- reason' to suppress false positives"
+    echo "nox: use '// nox:ignore RULE-ID -- reason' to suppress false positives"%s
     echo "nox: use 'git commit --no-verify' to skip this check (not recommended)"
     exit 1
 fi
 exit 0
-`, hookMarker, threshold)
+`, hookMarker, hook, hookLookup(noxPath), scan, hint)
+}
+
+// generatePreReceiveScript produces the pre-receive hook, which hands the
+// ref updates on its standard input to "nox protect pre-receive". noxPath
+// is used as generateHookScript uses it.
+func generatePreReceiveScript(threshold string, timeout time.Duration, noxPath string) string {
+	return fmt.Sprintf(`#!/bin/sh
+# %s - https://github.com/nox-hq/nox
+# To uninstall: nox protect uninstall --hook pre-receive
+# To let pushes through in an emergency, set %s=<reason>
+# in the environment of the git server (logged).
+
+nox=nox
+%sexec "$nox" protect pre-receive --severity-threshold %s --timeout %s --on-timeout reject
+`, hookMarker, preReceiveBypassEnv, hookLookup(noxPath), threshold, timeout)
+}
+
+// hookLookup returns the lines of a hook script that fall back to noxPath
+// when nox is not on the PATH, or nothing when noxPath is empty.
+func hookLookup(noxPath string) string {
+	if noxPath == "" {
+		return ""
+	}
+	return fmt.Sprintf("if ! command -v nox >/dev/null 2>&1; then\n    nox=%s\nfi\n", shellQuote(filepath.ToSlash(noxPath)))
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isValidHook returns true if nox protect can install the given git hook.
+func isValidHook(s string) bool {
+	return s == "pre-commit" || s == "commit-msg" || s == "pre-receive"
+}
+
+// defaultHookPath returns the path of hook in the repository at dir. The
+// pre-receive hook runs on a git server, so it goes in the hooks directory
+// of the git directory, which may be a bare repository.
+func defaultHookPath(dir, hook string) (string, error) {
+	if hook == "pre-receive" {
+		gitDir, err := git.GitDir(dir)
+		if err != nil {
+			return "", errors.New("not a git repository")
+		}
+		return filepath.Join(gitDir, "hooks", hook), nil
+	}
+	if !git.IsGitRepo(dir) {
+		return "", errors.New("not a git repository")
+	}
+	repoRoot, err := git.RepoRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoRoot, ".git", "hooks", hook), nil
 }
 
 // isValidThreshold returns true if the given string is a recognized severity
@@ -8,12 +8,13 @@ import (
 	"text/tabwriter"
 
 	"github.com/nox-hq/nox/registry"
+	"github.com/nox-hq/nox/registry/trust"
 )
 
 // runRegistry dispatches registry subcommands.
 func runRegistry(args []string) int {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Usage: nox registry <add|list|remove>")
+		fmt.Fprintln(os.Stderr, "Usage: nox registry <add|list|remove|key>")
 		return 2
 	}
 
@@ -24,9 +25,11 @@ func runRegistry(args []string) int {
 		return runRegistryList(args[1:])
 	case "remove":
 		return runRegistryRemove(args[1:])
+	case "key":
+		return runRegistryKey(args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown registry command: %s\n", args[0])
-		fmt.Fprintln(os.Stderr, "Usage: nox registry <add|list|remove>")
+		fmt.Fprintln(os.Stderr, "Usage: nox registry <add|list|remove|key>")
 		return 2
 	}
 }
@@ -34,19 +37,24 @@ func runRegistry(args []string) int {
 // runRegistryAdd adds a registry source.
 func runRegistryAdd(args []string) int {
 	fs := flag.NewFlagSet("registry add", flag.ContinueOnError)
-	var name string
+	var name, keyFile string
 	fs.StringVar(&name, "name", "", "registry name (default: derived from URL hostname)")
+	fs.StringVar(&keyFile, "key", "", "PEM file with the registry's Ed25519 signing public key")
 
-	if err := fs.Parse(args); err != nil {
+	positional, err := parseFlagsAnywhere(fs, args)
+	if err != nil {
 		return 2
 	}
 
-	if fs.NArg() < 1 {
-		fmt.Fprintln(os.Stderr, "Usage: nox registry add <url> [--name <name>]")
+	if len(positional) < 1 || len(positional) > 2 {
+		fmt.Fprintln(os.Stderr, "Usage: nox registry add [<name>] <url> [--name <name>] [--key <pubkey.pem>]")
 		return 2
 	}
 
-	rawURL := fs.Arg(0)
+	rawURL := positional[len(positional)-1]
+	if len(positional) == 2 && name == "" {
+		name = positional[0]
+	}
 
 	if name == "" {
 		u, err := url.Parse(rawURL)
@@ -72,7 +80,16 @@ func runRegistryAdd(args []string) int {
 		}
 	}
 
-	st.Sources = append(st.Sources, registry.Source{Name: name, URL: rawURL})
+	src := registry.Source{Name: name, URL: rawURL}
+	if keyFile != "" {
+		pem, _, err := readRegistryKey(name, keyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		src.TrustedKeys = append(src.TrustedKeys, pem)
+	}
+	st.Sources = append(st.Sources, src)
 
 	if err := SaveState(statePath, st); err != nil {
 		fmt.Fprintf(os.Stderr, "error: saving state: %v\n", err)
@@ -98,9 +115,9 @@ func runRegistryList(args []string) int {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tURL")
+	fmt.Fprintln(w, "NAME\tURL\tKEYS")
 	for _, s := range st.Sources {
-		fmt.Fprintf(w, "%s\t%s\n", s.Name, s.URL)
+		fmt.Fprintf(w, "%s\t%s\t%d\n", s.Name, s.URL, len(s.TrustedKeys))
 	}
 	w.Flush()
 	return 0
@@ -143,3 +160,116 @@ func runRegistryRemove(args []string) int {
 	fmt.Printf("Registry %q removed.\n", name)
 	return 0
 }
+
+// runRegistryKey manages the signing keys trusted for a registry. Several
+// keys may be trusted at once, so a registry can rotate keys by adding the
+// new key before removing the old one.
+func runRegistryKey(args []string) int {
+	const usage = "Usage: nox registry key <add <registry> <pubkey.pem>|remove <registry> <fingerprint>|list <registry>>"
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	action, name := args[0], args[1]
+	if (action == "add" || action == "remove") && len(args) < 3 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	statePath := DefaultStatePath()
+	st, err := LoadState(statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: loading state: %v\n", err)
+		return 2
+	}
+
+	var src *registry.Source
+	for i := range st.Sources {
+		if st.Sources[i].Name == name {
+			src = &st.Sources[i]
+			break
+		}
+	}
+	if src == nil {
+		fmt.Fprintf(os.Stderr, "error: registry %q not found\n", name)
+		return 2
+	}
+
+	switch action {
+	case "list":
+		if len(src.TrustedKeys) == 0 {
+			fmt.Printf("No trusted keys for registry %q.\n", name)
+			return 0
+		}
+		for _, pem := range src.TrustedKeys {
+			k, err := trust.NewKey(name, []byte(pem))
+			if err != nil {
+				fmt.Printf("invalid key: %v\n", err)
+				continue
+			}
+			fmt.Println(k.Fingerprint)
+		}
+		return 0
+
+	case "add":
+		pem, key, err := readRegistryKey(name, args[2])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		for _, existing := range src.TrustedKeys {
+			if k, err := trust.NewKey(name, []byte(existing)); err == nil && k.Fingerprint == key.Fingerprint {
+				fmt.Printf("Key %s is already trusted for registry %q.\n", key.Fingerprint, name)
+				return 0
+			}
+		}
+		src.TrustedKeys = append(src.TrustedKeys, pem)
+		if err := SaveState(statePath, st); err != nil {
+			fmt.Fprintf(os.Stderr, "error: saving state: %v\n", err)
+			return 2
+		}
+		fmt.Printf("Trusted key %s added to registry %q.\n", key.Fingerprint, name)
+		return 0
+
+	case "remove":
+		fingerprint := args[2]
+		kept := src.TrustedKeys[:0]
+		found := false
+		for _, pem := range src.TrustedKeys {
+			if k, err := trust.NewKey(name, []byte(pem)); err == nil && k.Fingerprint == fingerprint {
+				found = true
+				continue
+			}
+			kept = append(kept, pem)
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "error: key %s is not trusted for registry %q\n", fingerprint, name)
+			return 2
+		}
+		src.TrustedKeys = kept
+		if err := SaveState(statePath, st); err != nil {
+			fmt.Fprintf(os.Stderr, "error: saving state: %v\n", err)
+			return 2
+		}
+		fmt.Printf("Trusted key %s removed from registry %q.\n", fingerprint, name)
+		return 0
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown registry key command: %s\n", action)
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+}
+
+// readRegistryKey reads and validates a PEM-encoded Ed25519 public key file.
+func readRegistryKey(registryName, path string) (string, trust.Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", trust.Key{}, fmt.Errorf("reading key: %w", err)
+	}
+	key, err := trust.NewKey(registryName, data)
+	if err != nil {
+		return "", trust.Key{}, fmt.Errorf("invalid key %s: %w", path, err)
+	}
+	return string(data), key, nil
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/nox-hq/nox/registry"
+	"github.com/nox-hq/nox/registry/trust"
 )
 
 // setupStateDir creates a temp NOX_HOME and sets the env var.
@@ -53,6 +56,20 @@ func TestRunRegistryAdd_WithName(t *testing.T) {
 	}
 }
 
+func TestRunRegistryAdd_PositionalName(t *testing.T) {
+	setupStateDir(t)
+
+	code := runRegistry([]string{"add", "rules", "https://rules.example.com/index.json"})
+	if code != 0 {
+		t.Fatalf("registry add <name> <url>: expected exit 0, got %d", code)
+	}
+
+	st, _ := LoadState(DefaultStatePath())
+	if st.Sources[0].Name != "rules" || st.Sources[0].URL != "https://rules.example.com/index.json" {
+		t.Errorf("source = %+v", st.Sources[0])
+	}
+}
+
 func TestRunRegistryAdd_DuplicateName(t *testing.T) {
 	dir := setupStateDir(t)
 
@@ -214,3 +231,95 @@ func TestRunRegistryAdd_CorruptState(t *testing.T) {
 		t.Fatalf("corrupt state: expected exit 2, got %d", code)
 	}
 }
+
+// writeTestKey writes a fresh Ed25519 public key PEM and returns its path
+// and fingerprint.
+func writeTestKey(t *testing.T) (string, string) {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, trust.ExportKeyPEM(pub), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, trust.KeyFingerprint(pub)
+}
+
+func TestRunRegistryAdd_WithKey(t *testing.T) {
+	setupStateDir(t)
+	keyFile, _ := writeTestKey(t)
+
+	code := runRegistry([]string{"add", "acme", "https://acme.example.com/index.json", "--key", keyFile})
+	if code != 0 {
+		t.Fatalf("registry add --key: expected exit 0, got %d", code)
+	}
+
+	st, _ := LoadState(DefaultStatePath())
+	if len(st.Sources[0].TrustedKeys) != 1 {
+		t.Fatalf("expected 1 trusted key, got %d", len(st.Sources[0].TrustedKeys))
+	}
+}
+
+func TestRunRegistryAdd_InvalidKey(t *testing.T) {
+	setupStateDir(t)
+	keyFile := filepath.Join(t.TempDir(), "bad.pem")
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code := runRegistry([]string{"add", "acme", "https://acme.example.com/index.json", "--key", keyFile})
+	if code != 2 {
+		t.Fatalf("registry add with invalid key: expected exit 2, got %d", code)
+	}
+}
+
+func TestRunRegistryKey_Rotation(t *testing.T) {
+	setupStateDir(t)
+	oldKey, oldFP := writeTestKey(t)
+	newKey, newFP := writeTestKey(t)
+
+	if code := runRegistry([]string{"add", "acme", "https://acme.example.com/index.json", "--key", oldKey}); code != 0 {
+		t.Fatalf("registry add: expected exit 0, got %d", code)
+	}
+	if code := runRegistry([]string{"key", "add", "acme", newKey}); code != 0 {
+		t.Fatalf("key add: expected exit 0, got %d", code)
+	}
+	// Adding the same key again is a no-op.
+	if code := runRegistry([]string{"key", "add", "acme", newKey}); code != 0 {
+		t.Fatalf("key add duplicate: expected exit 0, got %d", code)
+	}
+	if code := runRegistry([]string{"key", "list", "acme"}); code != 0 {
+		t.Fatalf("key list: expected exit 0, got %d", code)
+	}
+
+	st, _ := LoadState(DefaultStatePath())
+	kr, err := sourceKeyring(st.Sources[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.Find(oldFP) == nil || kr.Find(newFP) == nil {
+		t.Fatalf("expected both keys trusted during rotation, got %+v", kr.Keys)
+	}
+
+	if code := runRegistry([]string{"key", "remove", "acme", oldFP}); code != 0 {
+		t.Fatalf("key remove: expected exit 0, got %d", code)
+	}
+	if code := runRegistry([]string{"key", "remove", "acme", oldFP}); code != 2 {
+		t.Fatalf("key remove unknown: expected exit 2, got %d", code)
+	}
+
+	st, _ = LoadState(DefaultStatePath())
+	kr, _ = sourceKeyring(st.Sources[0])
+	if kr.Find(oldFP) != nil || kr.Find(newFP) == nil {
+		t.Fatalf("expected only the new key after rotation, got %+v", kr.Keys)
+	}
+}
+
+func TestRunRegistryKey_UnknownRegistry(t *testing.T) {
+	setupStateDir(t)
+	if code := runRegistry([]string{"key", "list", "missing"}); code != 2 {
+		t.Fatalf("key list unknown registry: expected exit 2, got %d", code)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/offline"
+)
+
+// defaultMaxCloneSize is the default --max-clone-size: the most a remote
+// repository's fetched objects may take before the clone is refused.
+const defaultMaxCloneSize = "500MB"
+
+// remoteTarget returns the repository target names when it is a git URL or
+// owner/repo shorthand rather than a local path. A path that exists always
+// wins over the shorthand.
+func remoteTarget(target string) (git.Remote, bool) {
+	if _, err := os.Stat(target); err == nil {
+		return git.Remote{}, false
+	}
+	return git.ParseRemote(target)
+}
+
+// cloneForScan clones r into a new temporary directory. It returns the
+// checkout, the commit SHA, and an empty config file for the scan to use in
+// place of the repository's own .nox.yaml, whose paths an untrusted
+// repository could point anywhere. The caller must call cleanup, which
+// removes the directory, whether or not the clone succeeded.
+func cloneForScan(ctx context.Context, r git.Remote, token string, maxSize int64) (dir, sha, config string, cleanup func(), err error) {
+	cleanup = func() {}
+	if offlineMode && !strings.HasPrefix(r.URL, "file://") {
+		return "", "", "", cleanup, fmt.Errorf("cloning %s: %w", r, offline.ErrOffline)
+	}
+	tmp, err := os.MkdirTemp("", "nox-remote-")
+	if err != nil {
+		return "", "", "", cleanup, err
+	}
+	cleanup = func() { _ = os.RemoveAll(tmp) }
+
+	dir, sha, err = git.Clone(ctx, r, tmp, git.CloneOptions{Token: token, MaxSize: maxSize})
+	if errors.Is(err, git.ErrCloneTooLarge) {
+		return "", "", "", cleanup, fmt.Errorf("%w; raise --max-clone-size to scan it", err)
+	}
+	if err != nil {
+		return "", "", "", cleanup, err
+	}
+	config = filepath.Join(tmp, "nox.yaml")
+	if err := os.WriteFile(config, nil, 0o600); err != nil {
+		return "", "", "", cleanup, err
+	}
+	return dir, sha, config, cleanup, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nox-hq/nox/core/report"
+)
+
+const reportUsage = "Usage: nox report decrypt [--identity <file>] [--output <file>] <report.age>"
+
+func runReport(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, reportUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "decrypt":
+		return reportDecrypt(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown report subcommand: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, reportUsage)
+		return 2
+	}
+}
+
+// reportDecrypt decrypts a report written with --encrypt-report and prints
+// it, or writes it to --output.
+func reportDecrypt(args []string) int {
+	fs := flag.NewFlagSet("report decrypt", flag.ContinueOnError)
+	var identity, output string
+	fs.StringVar(&identity, "identity", "", "age identity file or SSH private key (default $"+report.IdentityEnv+", else ~/.ssh/id_ed25519)")
+	fs.StringVar(&identity, "i", "", "age identity file or SSH private key (shorthand)")
+	fs.StringVar(&output, "output", "", "write the decrypted report to this file instead of stdout")
+	fs.StringVar(&output, "o", "", "write the decrypted report to this file instead of stdout (shorthand)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, reportUsage)
+		return 2
+	}
+	path := fs.Arg(0)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if !report.IsEncrypted(data) {
+		fmt.Fprintf(os.Stderr, "error: %s is not an encrypted report\n", path)
+		return 2
+	}
+	ids, err := report.LoadIdentities(identity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	plain, err := report.Decrypt(data, ids...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: decrypting %s: %v\n", path, err)
+		return 2
+	}
+
+	if output == "" {
+		os.Stdout.Write(plain)
+		return 0
+	}
+	if err := os.WriteFile(output, plain, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	return 0
+}
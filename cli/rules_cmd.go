@@ -0,0 +1,596 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/catalog"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rulepack"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// Rule sources reported by "nox rules list".
+const (
+	ruleSourceBuiltin = "builtin"
+	ruleSourceCustom  = "custom"
+	// ruleSourcePlugin marks the rules of a rule pack, which is installed
+	// with "nox plugin install" and reported as plugin:<name>@<version>.
+	ruleSourcePlugin = "plugin"
+)
+
+// listedRule is a single row of "nox rules list" output.
+type listedRule struct {
+	ID          string `json:"id"`
+	Category    string `json:"category"`
+	Severity    string `json:"severity"`
+	Confidence  string `json:"confidence"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+
+	rule *rules.Rule
+}
+
+// runRules dispatches rules subcommands.
+func runRules(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: nox rules <list|test|export|selftest> [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return runRulesList(args[1:])
+	case "test":
+		return runRulesTest(args[1:])
+	case "export":
+		return runRulesExport(args[1:])
+	case "selftest":
+		return runRulesSelftest(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown rules subcommand: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: nox rules <list|test|export|selftest> [flags]")
+		return 2
+	}
+}
+
+// runRulesList prints the built-in and custom rules.
+func runRulesList(args []string) int {
+	fs := flag.NewFlagSet("rules list", flag.ContinueOnError)
+	var (
+		categoryFlag  string
+		jsonFlag      bool
+		analyzersFlag bool
+		rulesPath     string
+	)
+	fs.BoolVar(&analyzersFlag, "analyzers", false, "list the analyzers that --only-analyzer and --disable-analyzer accept instead of rules")
+	fs.StringVar(&categoryFlag, "category", "", "only list rules in this category (secrets, data, iac, container, ai, deps, custom)")
+	fs.BoolVar(&jsonFlag, "json", false, "output as JSON")
+	fs.StringVar(&rulesPath, "rules", "", "path to custom rules YAML file or directory (default: scan.rules_dir from .nox.yaml)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if analyzersFlag {
+		return listAnalyzers(jsonFlag)
+	}
+
+	all, err := collectRules(rulesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	var listed []listedRule
+	for _, r := range all {
+		if categoryFlag != "" && !strings.EqualFold(r.Category, categoryFlag) {
+			continue
+		}
+		listed = append(listed, r)
+	}
+
+	if jsonFlag {
+		if listed == nil {
+			listed = []listedRule{}
+		}
+		data, err := json.MarshalIndent(listed, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding rules: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCATEGORY\tSEVERITY\tCONFIDENCE\tSOURCE\tDESCRIPTION")
+	for _, r := range listed {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Category, r.Severity, r.Confidence, r.Source, r.Description)
+	}
+	w.Flush()
+	return 0
+}
+
+// listAnalyzers prints the built-in analyzers.
+func listAnalyzers(jsonOut bool) int {
+	if jsonOut {
+		data, err := json.MarshalIndent(nox.Analyzers, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding analyzers: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION")
+	for _, a := range nox.Analyzers {
+		fmt.Fprintf(w, "%s\t%s\n", a.Name, a.Description)
+	}
+	w.Flush()
+	return 0
+}
+
+// runRulesTest runs a single rule against a file or inline text and prints
+// every match with its span and captured groups.
+func runRulesTest(args []string) int {
+	fs := flag.NewFlagSet("rules test", flag.ContinueOnError)
+	var (
+		ruleID    string
+		inputPath string
+		textFlag  string
+		rulesPath string
+		jsonFlag  bool
+	)
+	fs.StringVar(&ruleID, "rule", "", "rule ID to run (required)")
+	fs.StringVar(&inputPath, "input", "", "file to run the rule against")
+	fs.StringVar(&textFlag, "text", "", "inline text to run the rule against")
+	fs.StringVar(&rulesPath, "rules", "", "path to custom rules YAML file or directory")
+	fs.BoolVar(&jsonFlag, "json", false, "output matches as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if ruleID == "" || (inputPath == "") == (textFlag == "") {
+		fmt.Fprintln(os.Stderr, "Usage: nox rules test --rule <id> (--input <file> | --text <text>) [--rules <path>]")
+		return 2
+	}
+
+	all, err := collectRules(rulesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	var rule *rules.Rule
+	for _, r := range all {
+		if r.ID == ruleID {
+			rule = r.rule
+			break
+		}
+	}
+	if rule == nil {
+		fmt.Fprintf(os.Stderr, "error: rule %s not found\n", ruleID)
+		return 2
+	}
+
+	content := []byte(textFlag)
+	if inputPath != "" {
+		content, err = os.ReadFile(inputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: reading input: %v\n", err)
+			return 2
+		}
+	}
+
+	details, err := rules.MatchDetails(rule, content)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if jsonFlag {
+		if details == nil {
+			details = []rules.MatchDetail{}
+		}
+		data, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding matches: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	fmt.Printf("%s (%s): %d match(es)\n", rule.ID, rule.MatcherType, len(details))
+	if !rules.KeywordsPresent(rule, content) {
+		words := append(slices.Clone(rule.Keywords), rule.ContextKeywords...)
+		fmt.Printf("note: the input fails the rule's keyword pre-filter (%s); scans would skip it\n", strings.Join(words, ", "))
+	}
+	for _, d := range details {
+		if rule.MatcherType == "regex" {
+			fmt.Printf("  %d:%d [%d-%d] %q\n", d.Line, d.Column, d.Start, d.End, d.Text)
+		} else {
+			fmt.Printf("  %d:%d %q\n", d.Line, d.Column, d.Text)
+		}
+		for _, g := range d.Groups {
+			label := fmt.Sprintf("group %d", g.Index)
+			if g.Name != "" {
+				label += fmt.Sprintf(" (%s)", g.Name)
+			}
+			fmt.Printf("    %s [%d-%d] %q\n", label, g.Start, g.End, g.Text)
+		}
+	}
+	return 0
+}
+
+// selftestReport is the JSON output of "nox rules selftest".
+type selftestReport struct {
+	Rules           int             `json:"rules"`
+	Examples        int             `json:"examples"`
+	Failures        []selftestIssue `json:"failures"`
+	MissingExamples []string        `json:"missing_examples"`
+}
+
+// selftestIssue is a single failed example in selftestReport.
+type selftestIssue struct {
+	RuleID    string `json:"rule_id"`
+	Example   string `json:"example,omitempty"`
+	WantMatch bool   `json:"want_match"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runRulesSelftest checks every rule against its examples.match and
+// examples.nomatch and lints every regex pattern. It exits 1 when an example
+// is not handled as declared, when a pattern does not compile or exceeds
+// --lint-budget on adversarial input, or with --require-examples when a
+// critical or high severity rule has no positive example.
+func runRulesSelftest(args []string) int {
+	fs := flag.NewFlagSet("rules selftest", flag.ContinueOnError)
+	var (
+		rulesPath       string
+		jsonFlag        bool
+		customOnly      bool
+		requireExamples bool
+		lintBudget      time.Duration
+	)
+	fs.StringVar(&rulesPath, "rules", "", "path to custom rules YAML file or directory (default: scan.rules_dir from .nox.yaml)")
+	fs.BoolVar(&jsonFlag, "json", false, "output as JSON")
+	fs.BoolVar(&customOnly, "custom", false, "only check custom and rule pack rules")
+	fs.BoolVar(&requireExamples, "require-examples", false, "fail when a critical or high severity rule has no examples.match")
+	fs.DurationVar(&lintBudget, "lint-budget", rules.DefaultLintBudget, "fail a regex rule whose pattern takes longer than this on adversarial input (0 only checks that patterns compile)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	all, err := collectRules(rulesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	rs := rules.NewRuleSet()
+	report := selftestReport{Failures: []selftestIssue{}, MissingExamples: []string{}}
+	for _, r := range all {
+		if customOnly && r.Source == ruleSourceBuiltin {
+			continue
+		}
+		rs.Add(r.rule)
+		report.Rules++
+		report.Examples += len(r.rule.Examples.Match) + len(r.rule.Examples.NoMatch)
+	}
+	issues := append(rules.CheckExamples(rs), rules.CheckKeywords(rs)...)
+	for _, issue := range issues {
+		si := selftestIssue{RuleID: issue.RuleID, Example: issue.Example, WantMatch: issue.WantMatch}
+		if issue.Err != nil {
+			si.Error = issue.Err.Error()
+		}
+		report.Failures = append(report.Failures, si)
+	}
+	lintIssues := rules.CheckPatterns(rs)
+	if lintBudget > 0 {
+		lintIssues = rules.LintRules(rs, lintBudget)
+	}
+	for _, issue := range lintIssues {
+		report.Failures = append(report.Failures, selftestIssue{RuleID: issue.RuleID, Error: strings.TrimPrefix(issue.String(), issue.RuleID+": ")})
+	}
+	for _, r := range rules.WithoutExamples(rs) {
+		if r.Severity == findings.SeverityCritical || r.Severity == findings.SeverityHigh {
+			report.MissingExamples = append(report.MissingExamples, r.ID)
+		}
+	}
+
+	failed := len(report.Failures) > 0 || (requireExamples && len(report.MissingExamples) > 0)
+	if jsonFlag {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding report: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("FAIL %s\n", issue)
+		}
+		for _, issue := range lintIssues {
+			fmt.Printf("FAIL %s\n", issue)
+		}
+		if requireExamples {
+			for _, id := range report.MissingExamples {
+				fmt.Printf("FAIL %s: no examples.match\n", id)
+			}
+		}
+		fmt.Printf("%d rules, %d examples, %d failed; %d critical or high rules without examples.match\n",
+			report.Rules, report.Examples, len(report.Failures), len(report.MissingExamples))
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// exportedRule is a single rule in "nox rules export" output.
+type exportedRule struct {
+	ID          string   `json:"id"`
+	Category    string   `json:"category"`
+	Severity    string   `json:"severity"`
+	Confidence  string   `json:"confidence"`
+	CWE         string   `json:"cwe"`
+	Description string   `json:"description"`
+	Remediation string   `json:"remediation"`
+	References  []string `json:"references"`
+	Origin      string   `json:"origin"`
+}
+
+// runRulesExport writes the rules that are active for the current
+// directory, after the root .nox.yaml's scan.rules.disable,
+// scan.rules.severity_override and scan.categories are applied, as CSV,
+// JSON or Markdown. Rules are ordered by category, then ID.
+func runRulesExport(args []string) int {
+	fs := flag.NewFlagSet("rules export", flag.ContinueOnError)
+	var formatFlag, rulesPath string
+	fs.StringVar(&formatFlag, "format", "json", "output format (csv, json, markdown)")
+	fs.StringVar(&rulesPath, "rules", "", "path to custom rules YAML file or directory (default: scan.rules_dir from .nox.yaml)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	switch formatFlag {
+	case "csv", "json", "markdown":
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown export format %q (want csv, json or markdown)\n", formatFlag)
+		return 2
+	}
+
+	exported, err := effectiveRules(rulesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	switch formatFlag {
+	case "csv":
+		err = writeRulesCSV(os.Stdout, exported)
+	case "markdown":
+		err = writeRulesMarkdown(os.Stdout, exported)
+	default:
+		var data []byte
+		data, err = json.MarshalIndent(exported, "", "  ")
+		if err == nil {
+			_, err = fmt.Println(string(data))
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing rules: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// effectiveRules returns the rules collectRules finds, minus those the root
+// .nox.yaml disables or whose category it leaves out, with its severity
+// overrides applied, sorted by category and then ID.
+func effectiveRules(rulesPath string) ([]exportedRule, error) {
+	all, err := collectRules(rulesPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := nox.LoadScanConfig(".")
+	if err != nil {
+		return nil, fmt.Errorf("loading .nox.yaml: %w", err)
+	}
+	categories, err := nox.ConfigCategories(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// The rules files that were loaded declare the categories of their
+	// rules.
+	for _, r := range all {
+		if !categories.Valid(r.Category) {
+			if err := categories.Register(rules.CategoryDecl{Name: r.Category}.Info()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	enabled, err := nox.NewCategoryFilter(cfg.Scan.Categories, categories)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []exportedRule{}
+	for _, r := range all {
+		if slices.Contains(cfg.Scan.Rules.Disable, r.ID) || !enabled(r.Category) {
+			continue
+		}
+		severity := r.Severity
+		if sev, ok := cfg.Scan.Rules.SeverityOverride[r.ID]; ok {
+			severity = sev
+		}
+		refs := r.rule.References
+		if refs == nil {
+			refs = []string{}
+		}
+		out = append(out, exportedRule{
+			ID:          r.ID,
+			Category:    r.Category,
+			Severity:    severity,
+			Confidence:  r.Confidence,
+			CWE:         r.rule.Metadata["cwe"],
+			Description: r.Description,
+			Remediation: r.rule.Remediation,
+			References:  refs,
+			Origin:      r.Source,
+		})
+	}
+
+	categoryRank := func(c string) int {
+		if i := slices.Index(rules.Categories, c); i >= 0 {
+			return i
+		}
+		return len(rules.Categories)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ci, cj := categoryRank(out[i].Category), categoryRank(out[j].Category)
+		if ci != cj {
+			return ci < cj
+		}
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// writeRulesCSV writes rules as CSV with a header row. References are
+// joined with "; ".
+func writeRulesCSV(w io.Writer, rs []exportedRule) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "category", "severity", "confidence", "cwe", "description", "remediation", "references", "origin"})
+	for _, r := range rs {
+		_ = cw.Write([]string{r.ID, r.Category, r.Severity, r.Confidence, r.CWE, r.Description, r.Remediation, strings.Join(r.References, "; "), r.Origin})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeRulesMarkdown writes rules as a Markdown document with one section
+// and table per category and a table of contents linking to them.
+func writeRulesMarkdown(w io.Writer, rs []exportedRule) error {
+	var categories []string
+	count := make(map[string]int)
+	for _, r := range rs {
+		if count[r.Category] == 0 {
+			categories = append(categories, r.Category)
+		}
+		count[r.Category]++
+	}
+
+	var b strings.Builder
+	b.WriteString("# Nox Rules\n\n")
+	fmt.Fprintf(&b, "%d active rules.\n\n", len(rs))
+	for _, c := range categories {
+		fmt.Fprintf(&b, "- [%s](#%s) (%d)\n", c, c, count[c])
+	}
+	for _, c := range categories {
+		fmt.Fprintf(&b, "\n## %s\n\n", c)
+		b.WriteString("| ID | Severity | Confidence | CWE | Description | Remediation | References | Origin |\n")
+		b.WriteString("|----|----------|------------|-----|-------------|-------------|------------|--------|\n")
+		for _, r := range rs {
+			if r.Category != c {
+				continue
+			}
+			refs := make([]string, len(r.References))
+			for i, ref := range r.References {
+				refs[i] = "<" + ref + ">"
+			}
+			fmt.Fprintf(&b, "| <a id=\"%s\"></a>`%s` | %s | %s | %s | %s | %s | %s | %s |\n",
+				strings.ToLower(r.ID), r.ID, r.Severity, r.Confidence, markdownCell(r.CWE),
+				markdownCell(r.Description), markdownCell(r.Remediation), strings.Join(refs, "<br>"), markdownCell(r.Origin))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes s for use in a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ").Replace(s)
+}
+
+// collectRules returns the built-in rules, then the custom rules from
+// rulesPath (or scan.rules_dir in .nox.yaml when rulesPath is empty), then
+// the rules of installed packs listed in scan.rule_packs, sorted by ID
+// within each source.
+func collectRules(rulesPath string) ([]listedRule, error) {
+	var out []listedRule
+	for _, crs := range catalog.BuiltinRuleSets() {
+		for _, r := range crs.Rules.Rules() {
+			out = append(out, newListedRule(r, ruleSourceBuiltin))
+		}
+	}
+	sortListed(out)
+
+	cfg, err := nox.LoadScanConfig(".")
+	if err != nil {
+		return nil, fmt.Errorf("loading .nox.yaml: %w", err)
+	}
+	if rulesPath == "" {
+		rulesPath = cfg.Scan.RulesDir
+	}
+	if rulesPath != "" {
+		custom, err := nox.LoadCustomRules(rulesPath)
+		if err != nil {
+			return nil, err
+		}
+		start := len(out)
+		for _, r := range custom.Rules() {
+			out = append(out, newListedRule(r, ruleSourceCustom))
+		}
+		sortListed(out[start:])
+	}
+
+	for _, ref := range cfg.Scan.RulePacks {
+		pack, err := rulepack.Resolve(rulepack.DefaultDir(), ref)
+		if err != nil {
+			return nil, err
+		}
+		packRules, err := pack.Load()
+		if err != nil {
+			return nil, err
+		}
+		start := len(out)
+		source := ruleSourcePlugin + ":" + pack.Name + "@" + pack.Version
+		for _, r := range packRules.Rules() {
+			out = append(out, newListedRule(r, source))
+		}
+		sortListed(out[start:])
+	}
+	return out, nil
+}
+
+func sortListed(rs []listedRule) {
+	sort.Slice(rs, func(i, j int) bool { return rs[i].ID < rs[j].ID })
+}
+
+func newListedRule(r *rules.Rule, source string) listedRule {
+	category := r.Category
+	if category == "" {
+		category = rules.CategoryCustom
+	}
+	return listedRule{
+		ID:          r.ID,
+		Category:    category,
+		Severity:    string(r.Severity),
+		Confidence:  string(r.Confidence),
+		Source:      source,
+		Description: r.Description,
+		rule:        r,
+	}
+}
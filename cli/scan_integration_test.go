@@ -144,8 +144,23 @@ func TestRunScan_WithDependencies(t *testing.T) {
 		t.Fatalf("writing package.json: %v", err)
 	}
 
+	// A lockfile in sync with package.json, so no LOCK-* findings.
+	packageLock := `{
+  "name": "test-app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "test-app", "version": "1.0.0"},
+    "node_modules/express": {"version": "4.18.2"},
+    "node_modules/lodash": {"version": "4.17.21"}
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "package-lock.json"), []byte(packageLock), 0o644); err != nil {
+		t.Fatalf("writing package-lock.json: %v", err)
+	}
+
 	outDir := filepath.Join(dir, "output")
-	code := run([]string{"--quiet", "--format", "cdx", "--output", outDir, "scan", dir})
+	code := run([]string{"--quiet", "--format", "cdx", "--output", outDir, "scan", "--no-osv", dir})
 
 	if code != 0 {
 		t.Fatalf("expected exit code 0, got %d", code)
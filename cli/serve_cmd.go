@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/offline"
+	"github.com/nox-hq/nox/server"
+)
+
+// runServeWeb implements "nox serve --web": it serves the web UI for dir
+// at addr until interrupted.
+func runServeWeb(addr, dir string, readOnly bool) int {
+	addr, loopback, err := webListenAddr(addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: --web: %v\n", err)
+		return 2
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "error: --dir %s is not a directory\n", dir)
+		return 2
+	}
+	if !loopback {
+		fmt.Fprintf(os.Stderr, "warning: %s is not a loopback address; anyone who can reach it can read the findings and the files they are in\n", addr)
+		if !readOnly {
+			fmt.Fprintln(os.Stderr, "warning: baseline and rescan actions are enabled; add --read-only to share the UI")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report := nox.LatestFindingsReport(dir)
+	if report != "" {
+		fmt.Printf("[serve] reading %s\n", report)
+	} else {
+		fmt.Printf("nox %s — scanning %s\n", version, dir)
+	}
+	ui := server.NewWebUI(server.WebConfig{
+		Dir:     dir,
+		Version: version,
+		Report:  report,
+		Scan: func(ctx context.Context) (*nox.ScanResult, error) {
+			return scanDir(ctx, dir)
+		},
+		ReadOnly:     readOnly,
+		LoopbackOnly: loopback,
+	})
+	if err := ui.Load(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: web UI: %v\n", err)
+		return 2
+	}
+	srv := &http.Server{Handler: ui.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf("[serve] web UI at http://%s/ (Ctrl-C to stop)\n", ln.Addr())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "error: web UI: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// webListenAddr returns the address for the web UI to listen on, binding
+// to 127.0.0.1 when addr has no host, and whether it is a loopback address.
+func webListenAddr(addr string) (string, bool, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", false, err
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), offline.IsLoopback(host), nil
+}
@@ -25,3 +25,35 @@ func TestRunServe_ViaRunCommand(t *testing.T) {
 	// We can't actually start the server in tests, but we can verify dispatch.
 	_ = run([]string{"serve", "--unknown"})
 }
+
+func TestWebListenAddr(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     string
+		loopback bool
+	}{
+		{":8080", "127.0.0.1:8080", true},
+		{"localhost:8080", "localhost:8080", true},
+		{"[::1]:8080", "[::1]:8080", true},
+		{"0.0.0.0:8080", "0.0.0.0:8080", false},
+		{"192.168.1.5:80", "192.168.1.5:80", false},
+	}
+	for _, tt := range tests {
+		got, loopback, err := webListenAddr(tt.in)
+		if err != nil || got != tt.want || loopback != tt.loopback {
+			t.Errorf("webListenAddr(%q) = %q, %v, %v; want %q, %v", tt.in, got, loopback, err, tt.want, tt.loopback)
+		}
+	}
+	if _, _, err := webListenAddr("8080"); err == nil {
+		t.Error("expected an error for an address without a port")
+	}
+}
+
+func TestRunServe_WebErrors(t *testing.T) {
+	if code := run([]string{"serve", "--web", "8080"}); code != 2 {
+		t.Errorf("invalid --web address: exit %d, want 2", code)
+	}
+	if code := run([]string{"serve", "--web", ":0", "--dir", t.TempDir() + "/missing"}); code != 2 {
+		t.Errorf("missing --dir: exit %d, want 2", code)
+	}
+}
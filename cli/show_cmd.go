@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -40,19 +42,32 @@ func runShow(args []string) int {
 	fs := flag.NewFlagSet("show", flag.ContinueOnError)
 
 	var (
-		severity    string
-		rulePattern string
-		filePattern string
-		input       string
-		jsonOutput  bool
-		contextN    int
+		severity      string
+		minConfidence string
+		rulePattern   string
+		filePattern   string
+		pathGlob      string
+		owner         string
+		sortKey       string
+		input         string
+		jsonOutput    bool
+		tableOutput   bool
+		rescan        bool
+		contextN      int
 	)
 
-	fs.StringVar(&severity, "severity", "", "filter by severity: critical,high,medium,low,info (comma-separated)")
-	fs.StringVar(&rulePattern, "rule", "", "filter by rule pattern (e.g., AI-*, SEC-001)")
+	fs.StringVar(&severity, "severity", "", "filter by severity: critical,high,medium,low,info or severity_mapping labels (comma-separated)")
+	fs.StringVar(&minConfidence, "min-confidence", "", "drop findings below this confidence: high, medium, low")
+	fs.StringVar(&rulePattern, "rule", "", "filter by rule pattern, comma-separated (e.g., AI-*, SEC-001)")
 	fs.StringVar(&filePattern, "file", "", "filter by file pattern (e.g., src/)")
-	fs.StringVar(&input, "input", "", "path to findings.json (default: run scan)")
+	fs.StringVar(&pathGlob, "path-glob", "", "filter by path glob, ** matches directories (e.g., src/**/*.go)")
+	fs.StringVar(&owner, "owner", "", "filter by CODEOWNERS owner, comma-separated (e.g., @org/payments, unowned)")
+	fs.StringVar(&sortKey, "sort", "", "sort by: "+strings.Join(detail.SortKeys, ", ")+" (default: report order)")
+	fs.StringVar(&input, "input", "", "path to findings.json (default: existing report, else run scan)")
+	fs.StringVar(&input, "report", "", "path to a findings.json report written under a custom name (same as --input)")
 	fs.BoolVar(&jsonOutput, "json", false, "output JSON instead of TUI")
+	fs.BoolVar(&tableOutput, "table", false, "output an aligned table instead of TUI")
+	fs.BoolVar(&rescan, "rescan", false, "scan even if a findings.json report exists")
 	fs.IntVar(&contextN, "context", 5, "number of source context lines")
 
 	if err := fs.Parse(flagArgs); err != nil {
@@ -61,6 +76,40 @@ func runShow(args []string) int {
 	// Merge any remaining positional args from flag parse with pre-extracted ones.
 	positionalArgs = append(positionalArgs, fs.Args()...)
 
+	if jsonOutput && tableOutput {
+		fmt.Fprintln(os.Stderr, "error: --json and --table are mutually exclusive")
+		return 2
+	}
+	if minConfidence != "" && !detail.ValidConfidence(findings.Confidence(minConfidence)) {
+		fmt.Fprintf(os.Stderr, "error: invalid --min-confidence %q (want high, medium or low)\n", minConfidence)
+		return 2
+	}
+	if err := detail.Sort(nil, sortKey); err != nil {
+		fmt.Fprintf(os.Stderr, "error: --sort: %v\n", err)
+		return 2
+	}
+
+	// Progress messages go to stderr in the non-interactive modes so that
+	// stdout carries only the JSON or table.
+	interactive := !jsonOutput && !tableOutput && interactiveTerminal()
+	status := os.Stdout
+	if !interactive {
+		status = os.Stderr
+	}
+
+	// Determine target path.
+	target := "."
+	if len(positionalArgs) > 0 {
+		target = positionalArgs[0]
+	}
+	found := false
+	if input == "" && !rescan {
+		input = nox.LatestFindingsReport(target)
+		if found = input != ""; found {
+			fmt.Fprintf(status, "[show] reading %s (use --rescan to scan again)\n", input)
+		}
+	}
+
 	// Load or generate findings.
 	var store *detail.Store
 	var basePath string
@@ -73,58 +122,83 @@ func runShow(args []string) int {
 			return 2
 		}
 		basePath = store.BasePath()
-	} else {
-		// Determine target path.
-		target := "."
-		if len(positionalArgs) > 0 {
-			target = positionalArgs[0]
+		if found {
+			// Paths in a report found for the target are relative to the
+			// target, not to the report directory.
+			basePath = target
 		}
-
-		fmt.Printf("nox — scanning %s\n", target)
-		result, err := nox.RunScan(target)
+	} else {
+		fmt.Fprintf(status, "nox — scanning %s\n", target)
+		result, err := scanDir(context.Background(), target)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
 			return 2
 		}
 
 		findingCount := len(result.Findings.Findings())
-		fmt.Printf("[results] %d findings\n", findingCount)
+		fmt.Fprintf(status, "[results] %d findings\n", findingCount)
 
 		if findingCount == 0 {
-			fmt.Println("[show] no findings to display")
+			fmt.Fprintln(status, "[show] no findings to display")
 			return 0
 		}
 
+		result.Findings.ApplySeverityMapping(result.SeverityMapping)
 		store = detail.LoadFromSet(result.Findings, target)
 		basePath = target
 	}
 
 	// Build filter.
 	filter := detail.Filter{
-		RulePattern: rulePattern,
-		FilePattern: filePattern,
+		RulePattern:   rulePattern,
+		FilePattern:   filePattern,
+		PathGlob:      pathGlob,
+		MinConfidence: findings.Confidence(minConfidence),
+		Owners:        splitList(owner),
+	}
+	if len(filter.Owners) > 0 {
+		assignMissingOwners(store.All(), basePath)
 	}
 	if severity != "" {
+		// Severities may be given as severity_mapping labels too.
+		var mapping findings.SeverityMapping
+		if cfg, err := nox.LoadScanConfig(target); err == nil {
+			mapping = cfg.SeverityMapping
+		}
 		for _, s := range strings.Split(severity, ",") {
 			s = strings.TrimSpace(s)
-			if s != "" {
-				filter.Severities = append(filter.Severities, findings.Severity(s))
+			if s == "" {
+				continue
+			}
+			matched := mapping.Matching(s)
+			if len(matched) == 0 {
+				fmt.Fprintf(os.Stderr, "error: --severity: unknown severity %q (want critical, high, medium, low, info or a severity_mapping label)\n", s)
+				return 2
 			}
+			filter.Severities = append(filter.Severities, matched...)
 		}
 	}
 
 	filtered := store.Filter(filter)
+	_ = detail.Sort(filtered, sortKey) // key validated above
+
+	// Non-interactive: table output.
+	if tableOutput {
+		showTable(os.Stdout, filtered, useColor())
+		return 0
+	}
 
 	// Build catalog.
 	cat := catalog.Catalog()
 
 	// Non-interactive: JSON output.
-	if jsonOutput || !isTerminal() {
+	if !interactive {
 		return showJSON(filtered, basePath, store.All(), cat, contextN)
 	}
 
 	// Interactive: TUI.
 	filteredStore := detail.LoadFromSet(toFindingSet(filtered), basePath)
+	configureTUIColor()
 	m := tui.New(filteredStore, cat, contextN)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
@@ -134,6 +208,55 @@ func runShow(args []string) int {
 	return 0
 }
 
+// showTable writes findings as a column-aligned table with one finding per
+// line. Severities are coloured only when color is set.
+func showTable(w io.Writer, ff []findings.Finding, color bool) {
+	headers := []string{"SEVERITY", "CONFIDENCE", "RULE", "LOCATION", "MESSAGE"}
+	rows := make([][]string, 0, len(ff))
+	for _, f := range ff {
+		loc := f.Location.FilePath
+		if f.Location.StartLine > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, f.Location.StartLine)
+		}
+		confidence := string(f.Confidence)
+		if confidence == "" {
+			confidence = "-"
+		}
+		message := strings.Join(strings.Fields(f.Message), " ")
+		rows = append(rows, []string{f.DisplaySeverity(), confidence, f.RuleID, loc, message})
+	}
+
+	widths := make([]int, len(headers)-1)
+	for i := range widths {
+		widths[i] = len(headers[i])
+		for _, r := range rows {
+			widths[i] = max(widths[i], len(r[i]))
+		}
+	}
+
+	writeRow := func(cells []string, sevColor string) {
+		var b strings.Builder
+		for i, c := range cells[:len(widths)] {
+			padded := fmt.Sprintf("%-*s  ", widths[i], c)
+			if i == 0 && sevColor != "" {
+				padded = sevColor + c + ansiReset + padded[len(c):]
+			}
+			b.WriteString(padded)
+		}
+		b.WriteString(cells[len(widths)])
+		fmt.Fprintln(w, b.String())
+	}
+
+	writeRow(headers, "")
+	for i, r := range rows {
+		sevColor := ""
+		if color {
+			sevColor = severityColors[ff[i].Severity]
+		}
+		writeRow(r, sevColor)
+	}
+}
+
 func showJSON(ff []findings.Finding, basePath string, allFindings []findings.Finding, cat map[string]catalog.RuleMeta, contextLines int) int {
 	var details []*detail.FindingDetail
 	for _, f := range ff {
@@ -163,7 +286,7 @@ func toFindingSet(ff []findings.Finding) *findings.FindingSet {
 func isBoolFlag(name string) bool {
 	name = strings.TrimLeft(name, "-")
 	switch name {
-	case "json":
+	case "json", "table", "rescan":
 		return true
 	default:
 		return false
@@ -6,19 +6,27 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/nox-hq/nox/core/atomicfile"
 	"github.com/nox-hq/nox/registry"
 )
 
 // InstalledPlugin records metadata for a locally installed plugin.
 type InstalledPlugin struct {
-	Name        string    `json:"name"`
-	Version     string    `json:"version"`
-	Digest      string    `json:"digest"`
-	BinaryPath  string    `json:"binary_path"`
-	TrustLevel  string    `json:"trust_level"`
-	RiskClass   string    `json:"risk_class"`
-	InstalledAt time.Time `json:"installed_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Digest     string `json:"digest"`
+	BinaryPath string `json:"binary_path"`
+	// BinaryDigest is the digest of the file at BinaryPath, checked before
+	// the plugin is run. For tar.gz artifacts it differs from Digest.
+	BinaryDigest string    `json:"binary_digest,omitempty"`
+	TrustLevel   string    `json:"trust_level"`
+	RiskClass    string    `json:"risk_class"`
+	Kind         string    `json:"kind,omitempty"` // "rules" for rule packs; empty for plugins
+	Registry     string    `json:"registry,omitempty"`
+	Signature    string    `json:"signature,omitempty"` // verified, untrusted, invalid, or unsigned
+	Signer       string    `json:"signer,omitempty"`    // name of the trusted key that verified the signature
+	InstalledAt  time.Time `json:"installed_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // State persists registry sources and installed plugins across CLI invocations.
@@ -59,6 +67,12 @@ func (s *State) RemovePlugin(name string) bool {
 	return false
 }
 
+// IsRulePack reports whether the installed entry is a rule pack rather than
+// a plugin binary.
+func (p *InstalledPlugin) IsRulePack() bool {
+	return p.Kind == string(registry.KindRulePack)
+}
+
 // InstalledDigests returns the digests of all installed plugins.
 func (s *State) InstalledDigests() []string {
 	digests := make([]string, len(s.Plugins))
@@ -97,16 +111,7 @@ func SaveState(path string, s *State) error {
 		return err
 	}
 
-	tmp := path + ".tmp"
-	if err := os.WriteFile(tmp, data, 0o644); err != nil {
-		return err
-	}
-
-	if err := os.Rename(tmp, path); err != nil {
-		_ = os.Remove(tmp)
-		return err
-	}
-	return nil
+	return atomicfile.WriteFile(path, data, 0o644)
 }
 
 // DefaultStatePath returns the default state file path, respecting NOX_HOME.
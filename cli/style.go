@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// Every escape sequence nox writes to the terminal comes from this file,
+// so that --color never, NO_COLOR and redirected output are honoured by
+// all commands alike.
+
+// ANSI escape sequences used to colour terminal output.
+const ansiReset = "\033[0m"
+
+var severityColors = map[findings.Severity]string{
+	findings.SeverityCritical: "\033[1;31m",
+	findings.SeverityHigh:     "\033[31m",
+	findings.SeverityMedium:   "\033[33m",
+	findings.SeverityLow:      "\033[36m",
+	findings.SeverityInfo:     "\033[2m",
+}
+
+// colorSeverity returns text in the colour of severity sev when output is
+// coloured (see useColor), and text unchanged otherwise.
+func colorSeverity(sev findings.Severity, text string) string {
+	if c := severityColors[sev]; c != "" && useColor() {
+		return c + text + ansiReset
+	}
+	return text
+}
+
+// clearScreen clears the terminal before a redraw. It does nothing unless
+// nox may redraw the terminal, and with --color never, which promises
+// output without escape sequences.
+func clearScreen() {
+	if interactiveTerminal() && colorMode != "never" {
+		fmt.Print("\033[2J\033[H")
+	}
+}
+
+// configureTUIColor makes the interactive TUI follow useColor: without
+// colour, its styles render as plain text.
+func configureTUIColor() {
+	if !useColor() {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
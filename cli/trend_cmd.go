@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/trend"
+)
+
+// trendSeverities are the severity columns of the trend table and CSV.
+var trendSeverities = []findings.Severity{
+	findings.SeverityCritical, findings.SeverityHigh, findings.SeverityMedium,
+	findings.SeverityLow, findings.SeverityInfo,
+}
+
+// runTrend implements the "nox trend" command, which summarizes the scan
+// history that scans record when history.enabled is set.
+func runTrend(args []string) int {
+	fs := flag.NewFlagSet("trend", flag.ContinueOnError)
+	var since, format string
+	fs.StringVar(&since, "since", "90d", "summarize the scans of this period, such as 30d or 2w; empty for the whole history")
+	fs.StringVar(&format, "format", "table", "output format: table, json, csv")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if format != "table" && format != "json" && format != "csv" {
+		fmt.Fprintf(os.Stderr, "error: unknown --format %q (want table, json or csv)\n", format)
+		return 2
+	}
+
+	target := "."
+	if fs.NArg() > 0 {
+		target = fs.Arg(0)
+	}
+	cfg, err := nox.LoadRootConfig(target, configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: loading config: %v\n", err)
+		return 2
+	}
+	s, err := nox.ScanTrend(target, cfg, since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if len(s.Scans) == 0 && format == "table" {
+		fmt.Fprintf(os.Stderr, "[trend] no scans recorded in %s", nox.HistoryPath(target, cfg))
+		if !cfg.History.Enabled {
+			fmt.Fprint(os.Stderr, "; set history.enabled: true in .nox.yaml to record them")
+		}
+		fmt.Fprintln(os.Stderr)
+		return 0
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: encoding trend: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+	case "csv":
+		err = writeTrendCSV(os.Stdout, s)
+	default:
+		err = writeTrendTable(os.Stdout, s)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// writeTrendTable writes the totals of s, a table of its scans and its
+// largest regressions.
+func writeTrendTable(w io.Writer, s *trend.Summary) error {
+	first, last := s.Scans[0], s.Scans[len(s.Scans)-1]
+	fmt.Fprintf(w, "Scans:     %d, %s to %s\n", len(s.Scans), first.Time.Format(time.DateOnly), last.Time.Format(time.DateOnly))
+	fmt.Fprintf(w, "Findings:  %d -> %d (%+d); %d new, %d resolved\n", first.Total, last.Total, s.Change, s.New, s.Resolved)
+	if s.Resolved > 0 {
+		fmt.Fprintf(w, "Mean time to resolution: %s\n", formatAge(time.Duration(s.MeanTimeToResolution*float64(time.Second))))
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCOMMIT\tTOTAL\tCRITICAL\tHIGH\tMEDIUM\tLOW\tINFO\tNEW\tRESOLVED")
+	for _, r := range s.Scans {
+		commit := r.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if commit == "" {
+			commit = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d", r.Time.Format("2006-01-02 15:04"), commit, r.Total)
+		for _, sev := range trendSeverities {
+			fmt.Fprintf(tw, "\t%d", r.BySeverity[string(sev)])
+		}
+		fmt.Fprintf(tw, "\t%d\t%d\n", r.New, r.Resolved)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(s.Regressions) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nLargest regressions by rule:")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tNEW\tRESOLVED\tNET")
+	for _, c := range s.Regressions {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%+d\n", c.RuleID, c.New, c.Resolved, c.Net)
+	}
+	return tw.Flush()
+}
+
+// writeTrendCSV writes one row per scan of s.
+func writeTrendCSV(w io.Writer, s *trend.Summary) error {
+	cw := csv.NewWriter(w)
+	header := []string{"time", "commit", "duration_ms", "total"}
+	for _, sev := range trendSeverities {
+		header = append(header, string(sev))
+	}
+	header = append(header, "new", "resolved", "carried")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range s.Scans {
+		row := []string{r.Time.Format(time.RFC3339), r.Commit, strconv.FormatInt(r.DurationMS, 10), strconv.Itoa(r.Total)}
+		for _, sev := range trendSeverities {
+			row = append(row, strconv.Itoa(r.BySeverity[string(sev)]))
+		}
+		row = append(row, strconv.Itoa(r.New), strconv.Itoa(r.Resolved), strconv.Itoa(r.Carried))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatAge formats d in days, or in hours when shorter than a day.
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%.1fh", d.Hours())
+	}
+	return fmt.Sprintf("%.1fd", d.Hours()/24)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nox-hq/nox/core/trend"
+)
+
+func writeTrendHistory(t *testing.T, dir string) {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, r := range []trend.Record{
+		{Time: now.AddDate(0, 0, -10), Commit: "0123456789abcdef0123", Total: 4, First: true, BySeverity: map[string]int{"high": 3, "low": 1}},
+		{Time: now.AddDate(0, 0, -3), Total: 6, New: 3, Resolved: 1, Carried: 3,
+			BySeverity:     map[string]int{"critical": 1, "high": 4, "low": 1},
+			NewByRule:      map[string]int{"SEC-001": 1, "IAC-010": 2},
+			ResolvedByRule: map[string]int{"SEC-001": 1},
+			ResolvedAgeS:   2 * 86400},
+	} {
+		if err := trend.Append(filepath.Join(dir, ".nox", trend.DefaultFile), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunTrend_Table(t *testing.T) {
+	dir := t.TempDir()
+	writeTrendHistory(t, dir)
+	code, out := captureRulesOutput(t, func() int { return runTrend([]string{dir}) })
+	if code != 0 {
+		t.Fatalf("exit code = %d, output:\n%s", code, out)
+	}
+	for _, want := range []string{
+		"Findings:  4 -> 6 (+2); 3 new, 1 resolved",
+		"Mean time to resolution: 2.0d",
+		"0123456789ab",
+		"IAC-010  2    0         +2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "SEC-001") {
+		t.Errorf("a rule with as many resolved as new findings is not a regression:\n%s", out)
+	}
+}
+
+func TestRunTrend_JSONAndCSV(t *testing.T) {
+	dir := t.TempDir()
+	writeTrendHistory(t, dir)
+
+	code, out := captureRulesOutput(t, func() int { return runTrend([]string{"--since", "7d", "--format", "json", dir}) })
+	var s trend.Summary
+	if code != 0 || json.Unmarshal([]byte(out), &s) != nil {
+		t.Fatalf("exit code %d, output:\n%s", code, out)
+	}
+	if len(s.Scans) != 1 || s.Scans[0].Total != 6 {
+		t.Errorf("--since 7d summary = %+v, want the last scan only", s)
+	}
+
+	code, out = captureRulesOutput(t, func() int { return runTrend([]string{"--format", "csv", dir}) })
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if code != 0 || len(lines) != 3 || lines[0] != "time,commit,duration_ms,total,critical,high,medium,low,info,new,resolved,carried" {
+		t.Fatalf("exit code %d, CSV:\n%s", code, out)
+	}
+	if !strings.HasSuffix(lines[2], ",6,1,4,0,1,0,3,1,3") {
+		t.Errorf("CSV row = %q", lines[2])
+	}
+}
+
+func TestRunTrend_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if code := runTrend([]string{"--format", "xml", dir}); code != 2 {
+		t.Errorf("unknown format: exit code = %d, want 2", code)
+	}
+	if code := runTrend([]string{"--since", "soon", dir}); code != 2 {
+		t.Errorf("invalid --since: exit code = %d, want 2", code)
+	}
+	if code := runTrend([]string{dir}); code != 0 {
+		t.Errorf("no history: exit code = %d, want 0", code)
+	}
+}
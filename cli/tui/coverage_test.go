@@ -975,7 +975,7 @@ func TestRenderFindingLineSelected(t *testing.T) {
 		Message:  "AWS Access Key",
 	}
 
-	line := renderFindingLine(f, true)
+	line := renderFindingLine(f, true, false)
 	if !strings.Contains(line, "SEC-001") {
 		t.Error("finding line should contain rule ID")
 	}
@@ -992,7 +992,7 @@ func TestRenderFindingLineNotSelected(t *testing.T) {
 		Message:  "AWS Access Key",
 	}
 
-	line := renderFindingLine(f, false)
+	line := renderFindingLine(f, false, false)
 	if !strings.Contains(line, "SEC-001") {
 		t.Error("finding line should contain rule ID")
 	}
@@ -1006,7 +1006,7 @@ func TestRenderFindingLineNoStartLine(t *testing.T) {
 		Message:  "Test message",
 	}
 
-	line := renderFindingLine(f, false)
+	line := renderFindingLine(f, false, false)
 	if !strings.Contains(line, "somefile.txt") {
 		t.Error("finding line should contain file path")
 	}
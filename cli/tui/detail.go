@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/nox-hq/nox/core/annotate"
 	coredetail "github.com/nox-hq/nox/core/detail"
+	"github.com/nox-hq/nox/core/findings"
 )
 
 // renderDetail renders the detail view for a single finding.
@@ -19,7 +21,7 @@ func renderDetail(m *Model) string {
 	var b strings.Builder
 
 	// Header.
-	sevBadge := severityStyle(f.Severity).Render(strings.ToUpper(string(f.Severity)))
+	sevBadge := severityStyle(f.Severity).Render(strings.ToUpper(f.DisplaySeverity()))
 	b.WriteString(fmt.Sprintf(" %s · %s · %s\n",
 		ruleIDStyle.Render(f.RuleID),
 		f.Message,
@@ -32,7 +34,11 @@ func renderDetail(m *Model) string {
 	if f.Location.StartLine > 0 {
 		fileLoc = fmt.Sprintf("%s:%d", f.Location.FilePath, f.Location.StartLine)
 	}
-	b.WriteString(" " + fileStyle.Render(fileLoc) + "\n\n")
+	b.WriteString(" " + fileStyle.Render(fileLoc) + "\n")
+	if blame := annotate.BlameSummary(&f); blame != "" {
+		b.WriteString(" " + subtleStyle.Render(strings.ReplaceAll(blame, "`", "")) + "\n")
+	}
+	b.WriteString("\n")
 
 	// Source context.
 	if d.Source != nil && len(d.Source.Lines) > 0 {
@@ -88,6 +94,30 @@ func renderDetail(m *Model) string {
 		b.WriteString("\n")
 	}
 
+	// Other locations of the same secret value.
+	if group := f.Metadata[findings.MetaSecretGroup]; group != "" {
+		var others []string
+		all := m.store.All()
+		for i := range all {
+			g := &all[i]
+			if g.Metadata[findings.MetaSecretGroup] != group || g.Location == f.Location {
+				continue
+			}
+			loc := g.Location.FilePath
+			if g.Location.StartLine > 0 {
+				loc = fmt.Sprintf("%s:%d", loc, g.Location.StartLine)
+			}
+			others = append(others, loc)
+		}
+		if len(others) > 0 {
+			b.WriteString(" " + remediationHeaderStyle.Render("Same credential") + "\n")
+			for _, loc := range others {
+				b.WriteString("   " + fileStyle.Render(loc) + "\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+
 	// Metadata.
 	if len(f.Metadata) > 0 {
 		b.WriteString(" " + remediationHeaderStyle.Render("Metadata") + "\n")
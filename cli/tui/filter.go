@@ -6,15 +6,6 @@ import (
 	"github.com/nox-hq/nox/core/findings"
 )
 
-// severityOrder defines the cycle order for the severity filter toggle.
-var severityOrder = []findings.Severity{
-	findings.SeverityCritical,
-	findings.SeverityHigh,
-	findings.SeverityMedium,
-	findings.SeverityLow,
-	findings.SeverityInfo,
-}
-
 // filterState tracks the active filter configuration.
 type filterState struct {
 	severityIdx int    // -1 = all, 0..4 = specific severity
@@ -29,7 +20,7 @@ func newFilterState() filterState {
 // cycleSeverity advances the severity filter to the next level.
 func (f *filterState) cycleSeverity() {
 	f.severityIdx++
-	if f.severityIdx >= len(severityOrder) {
+	if f.severityIdx >= len(findings.Severities) {
 		f.severityIdx = -1
 	}
 }
@@ -39,14 +30,14 @@ func (f *filterState) activeSeverity() string {
 	if f.severityIdx < 0 {
 		return "all"
 	}
-	return string(severityOrder[f.severityIdx])
+	return string(findings.Severities[f.severityIdx])
 }
 
 // matchesFinding returns true if the finding passes all active filters.
 func (f *filterState) matchesFinding(finding findings.Finding) bool {
 	// Severity filter.
 	if f.severityIdx >= 0 {
-		if finding.Severity != severityOrder[f.severityIdx] {
+		if finding.Severity != findings.Severities[f.severityIdx] {
 			return false
 		}
 	}
@@ -9,6 +9,7 @@ type keyMap struct {
 	Back     key.Binding
 	Search   key.Binding
 	Severity key.Binding
+	Group    key.Binding
 	NextItem key.Binding
 	PrevItem key.Binding
 	Quit     key.Binding
@@ -39,6 +40,10 @@ var keys = keyMap{
 		key.WithKeys("s"),
 		key.WithHelp("s", "severity"),
 	),
+	Group: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group secrets"),
+	),
 	NextItem: key.NewBinding(
 		key.WithKeys("n"),
 		key.WithHelp("n", "next"),
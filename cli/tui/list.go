@@ -27,6 +27,9 @@ func renderList(m *Model) string {
 	if m.filter.search != "" {
 		filterLine += subtleStyle.Render("  Search: ") + "[" + m.filter.search + "]"
 	}
+	if m.grouped {
+		filterLine += subtleStyle.Render("  View: ") + "[grouped]"
+	}
 	b.WriteString(filterLine)
 	b.WriteString("\n\n")
 
@@ -54,7 +57,7 @@ func renderList(m *Model) string {
 
 		for i := start; i < end; i++ {
 			f := m.filtered[i]
-			line := renderFindingLine(f, i == m.cursor)
+			line := renderFindingLine(f, i == m.cursor, m.grouped)
 			b.WriteString(line)
 			b.WriteString("\n")
 		}
@@ -69,14 +72,16 @@ func renderList(m *Model) string {
 
 	// Help.
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render(" ↑↓ navigate  enter detail  / search  s severity  q quit"))
+	b.WriteString(helpStyle.Render(" ↑↓ navigate  enter detail  / search  s severity  g group  q quit"))
 	b.WriteString("\n")
 
 	return b.String()
 }
 
-// renderFindingLine renders a single finding line in the list.
-func renderFindingLine(f findings.Finding, selected bool) string {
+// renderFindingLine renders a single finding line in the list. In the
+// grouped view, a finding standing for a secret group names the number of
+// locations.
+func renderFindingLine(f findings.Finding, selected, grouped bool) string {
 	badge := severityBadge(f.Severity)
 	ruleID := ruleIDStyle.Render(fmt.Sprintf("%-7s", f.RuleID))
 
@@ -87,6 +92,9 @@ func renderFindingLine(f findings.Finding, selected bool) string {
 	file := fileStyle.Render(fmt.Sprintf("%-30s", fileLoc))
 
 	line := fmt.Sprintf(" %s  %s  %s  %s", badge, ruleID, file, f.Message)
+	if n := findings.SecretGroupSize(&f); grouped && n > 1 {
+		line += subtleStyle.Render(fmt.Sprintf("  (1 credential, %d locations)", n))
+	}
 
 	if selected {
 		return selectedStyle.Render("▸") + line
@@ -24,6 +24,7 @@ type Model struct {
 	catalog      map[string]catalog.RuleMeta
 	filter       filterState
 	filtered     []findings.Finding
+	grouped      bool
 	cursor       int
 	width        int
 	height       int
@@ -115,6 +116,10 @@ func (m *Model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case matchesBinding(msg, keys.Severity):
 		m.filter.cycleSeverity()
 		m.applyFilter()
+
+	case matchesBinding(msg, keys.Group):
+		m.grouped = !m.grouped
+		m.applyFilter()
 	}
 	return m, nil
 }
@@ -159,8 +164,13 @@ func (m *Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// applyFilter recomputes the visible findings. In the grouped view,
+// findings that expose the same secret value show as one row.
 func (m *Model) applyFilter() {
 	m.filtered = m.filter.filterFindings(m.store.All())
+	if m.grouped {
+		m.filtered = findings.CollapseSecretGroups(m.filtered)
+	}
 	if m.cursor >= len(m.filtered) {
 		m.cursor = len(m.filtered) - 1
 	}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -105,6 +106,45 @@ func TestModelSeverityFilter(t *testing.T) {
 	}
 }
 
+func TestModelGroupedView(t *testing.T) {
+	fs := findings.NewFindingSet()
+	for _, path := range []string{"a.env", "b.env", "c.env"} {
+		fs.Add(findings.Finding{
+			RuleID: "SEC-001", Severity: findings.SeverityHigh,
+			Location: findings.Location{FilePath: path, StartLine: 1},
+			Message:  "AWS Access Key ID detected",
+			Metadata: map[string]string{findings.MetaSecretGroup: "0123abcd"},
+		})
+	}
+	fs.Add(findings.Finding{
+		RuleID: "SEC-002", Severity: findings.SeverityHigh,
+		Location: findings.Location{FilePath: "d.env", StartLine: 2},
+		Message:  "AWS Secret Access Key detected",
+	})
+	fs.NumberSecretGroups()
+	m := New(detail.LoadFromSet(fs, "."), catalog.Catalog(), 5)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if !m.grouped || len(m.filtered) != 2 {
+		t.Fatalf("grouped view: grouped %v, %d rows, want 2", m.grouped, len(m.filtered))
+	}
+	if view := m.View(); !strings.Contains(view, "1 credential, 3 locations") {
+		t.Errorf("grouped list does not name the locations:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	view := m.View()
+	if !strings.Contains(view, "Same credential") || !strings.Contains(view, "b.env:1") || !strings.Contains(view, "c.env:1") {
+		t.Errorf("detail does not list the other locations:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if m.grouped || len(m.filtered) != 4 {
+		t.Errorf("ungrouped view: grouped %v, %d rows, want 4", m.grouped, len(m.filtered))
+	}
+}
+
 func TestModelSearch(t *testing.T) {
 	store := testStore()
 	cat := catalog.Catalog()
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/atomicfile"
+	"github.com/nox-hq/nox/core/ci"
+)
+
+const (
+	// updateCheckInterval is how often the passive update check asks
+	// GitHub for the latest release.
+	updateCheckInterval = 24 * time.Hour
+	// updateCheckTimeout bounds the passive check's request.
+	updateCheckTimeout = 3 * time.Second
+)
+
+// updateCheckState records the last passive update check in
+// $NOX_HOME/update-check.json.
+type updateCheckState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"`
+}
+
+// updateCheck is a passive update check started alongside a scan.
+type updateCheck struct {
+	latest string      // latest release known from the last check
+	done   chan string // receives the latest release once a refresh ends
+}
+
+func updateCheckPath() string {
+	return filepath.Join(noxHome(), "update-check.json")
+}
+
+// updateCheckEnabled reports whether the passive update check may run: it
+// is off for development builds, in CI, offline, with NOX_NO_UPDATE_CHECK
+// set, and with update_check: false in the config. CI is any provider
+// ci.Detect knows and any environment with CI set, as most CI systems do.
+func updateCheckEnabled(cfg *nox.ScanConfig) bool {
+	if !isReleaseVersion(version) || offlineMode || os.Getenv("NOX_NO_UPDATE_CHECK") != "" {
+		return false
+	}
+	if cfg != nil && cfg.UpdateCheck != nil && !*cfg.UpdateCheck {
+		return false
+	}
+	if v := os.Getenv("CI"); v != "" && v != "false" && v != "0" {
+		return false
+	}
+	return ci.Detect() == nil
+}
+
+// startUpdateCheck returns the passive update check, or nil when it is
+// disabled. When the last check is older than updateCheckInterval, it asks
+// GitHub for the latest release in the background; the scan never waits
+// for it.
+func startUpdateCheck(cfg *nox.ScanConfig) *updateCheck {
+	if !updateCheckEnabled(cfg) {
+		return nil
+	}
+	st := loadUpdateCheckState(updateCheckPath())
+	c := &updateCheck{latest: st.Latest, done: make(chan string, 1)}
+	if time.Since(st.CheckedAt) < updateCheckInterval {
+		return c
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+		latest := st.Latest
+		if rel, err := fetchLatestRelease(ctx, &http.Client{Timeout: updateCheckTimeout}); err == nil {
+			latest = rel.TagName
+		}
+		// Failed checks count too, so that an unreachable API is asked at
+		// most once a day.
+		_ = saveUpdateCheckState(updateCheckPath(), updateCheckState{CheckedAt: time.Now().UTC(), Latest: latest})
+		c.done <- latest
+	}()
+	return c
+}
+
+// notice returns a one-line notice when a newer release is known, or "".
+// It uses the background refresh only if it has already finished.
+func (c *updateCheck) notice() string {
+	if c == nil {
+		return ""
+	}
+	select {
+	case latest := <-c.done:
+		c.latest = latest
+	default:
+	}
+	latest := strings.TrimPrefix(c.latest, "v")
+	if !isNewerVersion(latest, version) {
+		return ""
+	}
+	return fmt.Sprintf("nox %s is available (current: %s); run nox upgrade to update", latest, version)
+}
+
+// recordUpdateCheck saves the latest release found by nox upgrade, so that
+// the passive check does not ask again the same day.
+func recordUpdateCheck(latest string) {
+	_ = saveUpdateCheckState(updateCheckPath(), updateCheckState{CheckedAt: time.Now().UTC(), Latest: latest})
+}
+
+func loadUpdateCheckState(path string) updateCheckState {
+	var st updateCheckState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st
+	}
+	_ = json.Unmarshal(data, &st)
+	return st
+}
+
+// saveUpdateCheckState writes st to path atomically (temp file + rename).
+func saveUpdateCheckState(path string, st updateCheckState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0o600)
+}
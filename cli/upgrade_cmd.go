@@ -0,0 +1,361 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/atomicfile"
+	"github.com/nox-hq/nox/registry"
+)
+
+// releasesURL is the GitHub API endpoint for the latest nox release.
+var releasesURL = "https://api.github.com/repos/nox-hq/nox/releases/latest"
+
+// releasePublicKey is the base64-encoded Ed25519 key that signs
+// checksums.txt, set at build time with -X main.releasePublicKey. The
+// official release config sets no key and publishes no checksums.txt.sig,
+// so official builds verify checksums only.
+var releasePublicKey = ""
+
+// maxReleaseAssetSize caps the size of a downloaded release asset.
+const maxReleaseAssetSize = 256 << 20
+
+// githubRelease is the part of a GitHub release that nox upgrade uses.
+type githubRelease struct {
+	TagName string         `json:"tag_name"`
+	HTMLURL string         `json:"html_url"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// asset returns the release asset with the given name, or nil.
+func (r *githubRelease) asset(name string) *releaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+func runUpgrade(args []string) int {
+	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	var checkOnly, force bool
+	fs.BoolVar(&checkOnly, "check", false, "only report whether a newer version is available")
+	fs.BoolVar(&force, "force", false, "install the latest release even if this build is current or a development build")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: nox upgrade [--check] [--force]")
+		return 2
+	}
+	if offlineMode {
+		fmt.Fprintln(os.Stderr, "error: nox upgrade needs network access, which --offline disables")
+		return 2
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: locating the nox executable: %v\n", err)
+		return 2
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	client := &http.Client{}
+	rel, err := fetchLatestRelease(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	recordUpdateCheck(rel.TagName)
+
+	latest := strings.TrimPrefix(rel.TagName, "v")
+	newer := isNewerVersion(latest, version)
+	switch {
+	case checkOnly && newer:
+		fmt.Printf("nox %s is available (current: %s): %s\n", latest, version, rel.HTMLURL)
+		return 0
+	case checkOnly:
+		fmt.Printf("nox %s is up to date (latest release: %s)\n", version, latest)
+		return 0
+	case !force && !isReleaseVersion(version):
+		fmt.Fprintf(os.Stderr, "error: nox %s is a development build; pass --force to replace it with release %s\n", version, latest)
+		return 2
+	case !force && !newer:
+		fmt.Printf("nox %s is up to date (latest release: %s)\n", version, latest)
+		return 0
+	}
+
+	if manager, hint := packageManager(exe); manager != "" {
+		fmt.Printf("nox %s is available, but %s is managed by %s. Upgrade it with:\n\n  %s\n", latest, exe, manager, hint)
+		return 0
+	}
+
+	name := releaseArchiveName(rel.TagName, runtime.GOOS, runtime.GOARCH)
+	binary, signed, err := downloadRelease(ctx, client, rel, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := replaceExecutable(exe, binary); err != nil {
+		fmt.Fprintf(os.Stderr, "error: replacing %s: %v\n", exe, err)
+		if errors.Is(err, os.ErrPermission) {
+			fmt.Fprintln(os.Stderr, "hint: re-run with permission to write to its directory, e.g. with sudo")
+		}
+		return 2
+	}
+	verified := "checksum verified"
+	if signed {
+		verified = "checksum and signature verified"
+	}
+	fmt.Printf("Upgraded nox %s to %s (%s, %s)\n", version, latest, name, verified)
+	if !signed {
+		fmt.Fprintln(os.Stderr, "note: the release signature was not checked; the checksum comes from the same release, so it catches a corrupted download but not a tampered release")
+	}
+	return 0
+}
+
+// fetchLatestRelease queries the GitHub API for the latest release.
+func fetchLatestRelease(ctx context.Context, client *http.Client) (*githubRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "nox/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("checking for the latest release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("checking for the latest release: %s", resp.Status)
+	}
+	var rel githubRelease
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&rel); err != nil {
+		return nil, fmt.Errorf("decoding the latest release: %w", err)
+	}
+	if rel.TagName == "" {
+		return nil, errors.New("the latest release has no tag")
+	}
+	return &rel, nil
+}
+
+// isReleaseVersion reports whether v is a release version rather than a
+// development build.
+func isReleaseVersion(v string) bool {
+	_, err := registry.ParseVersion(v)
+	return err == nil
+}
+
+// isNewerVersion reports whether latest is a later release than current.
+// It is false when either is not a release version.
+func isNewerVersion(latest, current string) bool {
+	l, err := registry.ParseVersion(latest)
+	if err != nil {
+		return false
+	}
+	c, err := registry.ParseVersion(current)
+	if err != nil {
+		return false
+	}
+	return c.LessThan(l)
+}
+
+// releaseArchiveName is the name of the release archive for a platform, as
+// written by GoReleaser.
+func releaseArchiveName(tag, goos, goarch string) string {
+	return fmt.Sprintf("nox_%s_%s_%s.tar.gz", strings.TrimPrefix(tag, "v"), goos, goarch)
+}
+
+// packageManager returns the package manager that installed the
+// executable at exe, and the command that upgrades it, or "" when nox was
+// installed from a release archive.
+func packageManager(exe string) (name, hint string) {
+	p := filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(p, "/Cellar/") || strings.Contains(p, "/homebrew/") || strings.Contains(p, "/.linuxbrew/"):
+		return "Homebrew", "brew upgrade nox"
+	case strings.HasPrefix(p, "/nix/store/"):
+		return "Nix", "nix profile upgrade nox (or update the nox package in your Nix configuration)"
+	case strings.HasPrefix(p, "/snap/"):
+		return "snap", "sudo snap refresh nox"
+	}
+	return "", ""
+}
+
+// downloadRelease downloads the release archive called name, verifies it
+// against the release's checksums.txt and, when the build has a release
+// key, the signature of checksums.txt, and returns the nox binary it
+// contains. signed reports whether the signature was verified.
+func downloadRelease(ctx context.Context, client *http.Client, rel *githubRelease, name string) (binary []byte, signed bool, err error) {
+	archiveAsset := rel.asset(name)
+	if archiveAsset == nil {
+		return nil, false, fmt.Errorf("release %s has no archive for %s/%s (%s)", rel.TagName, runtime.GOOS, runtime.GOARCH, name)
+	}
+	sumsAsset := rel.asset("checksums.txt")
+	if sumsAsset == nil {
+		return nil, false, fmt.Errorf("release %s has no checksums.txt; refusing to install an unverified binary", rel.TagName)
+	}
+
+	sums, err := downloadAsset(ctx, client, sumsAsset.URL)
+	if err != nil {
+		return nil, false, err
+	}
+	if releasePublicKey != "" {
+		sigAsset := rel.asset("checksums.txt.sig")
+		if sigAsset == nil {
+			return nil, false, fmt.Errorf("release %s has no checksums.txt.sig; refusing to install an unsigned release", rel.TagName)
+		}
+		sig, err := downloadAsset(ctx, client, sigAsset.URL)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := verifyChecksumsSignature(sums, sig, releasePublicKey); err != nil {
+			return nil, false, err
+		}
+		signed = true
+	}
+
+	archive, err := downloadAsset(ctx, client, archiveAsset.URL)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := verifyChecksum(name, archive, sums); err != nil {
+		return nil, false, err
+	}
+	binary, err = extractBinary(archive, "nox")
+	if err != nil {
+		return nil, false, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return binary, signed, nil
+}
+
+// downloadAsset fetches a release asset into memory.
+func downloadAsset(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "nox/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", path.Base(url), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", path.Base(url), resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReleaseAssetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", path.Base(url), err)
+	}
+	if len(data) > maxReleaseAssetSize {
+		return nil, fmt.Errorf("downloading %s: larger than %d MiB", path.Base(url), maxReleaseAssetSize>>20)
+	}
+	return data, nil
+}
+
+// verifyChecksum checks data against the SHA-256 listed for name in sums,
+// a checksums.txt in sha256sum format.
+func verifyChecksum(name string, data, sums []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		want, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return fmt.Errorf("checksums.txt: invalid checksum for %s", name)
+		}
+		got := sha256.Sum256(data)
+		if !bytes.Equal(got[:], want) {
+			return fmt.Errorf("checksum mismatch for %s: got %x, want %x", name, got, want)
+		}
+		return nil
+	}
+	return fmt.Errorf("checksums.txt has no entry for %s", name)
+}
+
+// verifyChecksumsSignature checks the Ed25519 signature sig, raw or
+// base64-encoded, of checksums.txt against the base64-encoded key.
+func verifyChecksumsSignature(sums, sig []byte, key string) error {
+	pub, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("this build has an invalid release signing key")
+	}
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return errors.New("checksums.txt.sig is not an Ed25519 signature")
+		}
+		sig = decoded
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(ed25519.PublicKey(pub), sums, sig) {
+		return errors.New("the signature of checksums.txt does not verify; refusing to install")
+	}
+	return nil
+}
+
+// extractBinary returns the contents of the regular file called name in
+// the gzip-compressed tar archive.
+func extractBinary(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("archive has no %s binary", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == name {
+			return io.ReadAll(io.LimitReader(tr, maxReleaseAssetSize))
+		}
+	}
+}
+
+// replaceExecutable atomically replaces the file at exe with data: it is
+// written to a temporary file in the same directory, which is renamed over
+// exe, so an interrupted upgrade leaves the old binary in place.
+func replaceExecutable(exe string, data []byte) error {
+	mode := os.FileMode(0o755)
+	if info, err := os.Stat(exe); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return atomicfile.WriteFile(exe, data, mode)
+}
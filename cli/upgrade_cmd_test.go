@@ -0,0 +1,292 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+)
+
+// fakeRelease serves a GitHub release with an archive for this platform
+// holding binary, and its checksums.txt signed with key when key is set.
+func fakeRelease(t *testing.T, tag string, binary []byte, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range map[string][]byte{"LICENSE": []byte("license"), "nox": binary} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archiveName := releaseArchiveName(tag, runtime.GOOS, runtime.GOARCH)
+	assets := map[string][]byte{archiveName: buf.Bytes()}
+	assets["checksums.txt"] = []byte(fmt.Sprintf("%x  other.tar.gz\n%x  %s\n", sha256.Sum256(nil), sha256.Sum256(buf.Bytes()), archiveName))
+	if key != nil {
+		assets["checksums.txt.sig"] = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, assets["checksums.txt"])))
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest" {
+			rel := githubRelease{TagName: tag, HTMLURL: "https://example.com/releases/" + tag}
+			for name := range assets {
+				rel.Assets = append(rel.Assets, releaseAsset{Name: name, URL: srv.URL + "/download/" + name})
+			}
+			_ = json.NewEncoder(w).Encode(rel)
+			return
+		}
+		data, ok := assets[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	releasesURL = srv.URL + "/latest"
+	t.Cleanup(func() { releasesURL = "https://api.github.com/repos/nox-hq/nox/releases/latest" })
+	return srv
+}
+
+func setVersion(t *testing.T, v string) {
+	t.Helper()
+	old := version
+	version = v
+	t.Cleanup(func() { version = old })
+}
+
+func TestDownloadRelease(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeRelease(t, "v1.2.0", []byte("new binary"), priv)
+	ctx := context.Background()
+	rel, err := fetchLatestRelease(ctx, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := releaseArchiveName(rel.TagName, runtime.GOOS, runtime.GOARCH)
+
+	binary, signed, err := downloadRelease(ctx, http.DefaultClient, rel, name)
+	if err != nil || signed || string(binary) != "new binary" {
+		t.Fatalf("without a release key: %q, signed %v, %v", binary, signed, err)
+	}
+
+	old := releasePublicKey
+	t.Cleanup(func() { releasePublicKey = old })
+	releasePublicKey = base64.StdEncoding.EncodeToString(pub)
+	if _, signed, err := downloadRelease(ctx, http.DefaultClient, rel, name); err != nil || !signed {
+		t.Fatalf("with the release key: signed %v, %v", signed, err)
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	releasePublicKey = base64.StdEncoding.EncodeToString(other)
+	if _, _, err := downloadRelease(ctx, http.DefaultClient, rel, name); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected a signature error with another key, got %v", err)
+	}
+
+	if _, _, err := downloadRelease(ctx, http.DefaultClient, rel, "nox_1.2.0_plan9_mips.tar.gz"); err == nil {
+		t.Error("expected an error for a platform without an archive")
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("archive")
+	sums := []byte(fmt.Sprintf("%x  nox.tar.gz\n", sha256.Sum256(data)))
+	if err := verifyChecksum("nox.tar.gz", data, sums); err != nil {
+		t.Errorf("matching checksum: %v", err)
+	}
+	if err := verifyChecksum("nox.tar.gz", []byte("tampered"), sums); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+	if err := verifyChecksum("other.tar.gz", data, sums); err == nil {
+		t.Error("expected an error for a missing entry")
+	}
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "nox")
+	if err := os.WriteFile(exe, []byte("old"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceExecutable(exe, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("replaced executable = %q, %v", data, err)
+	}
+	if info, _ := os.Stat(exe); runtime.GOOS != "windows" && info.Mode().Perm() != 0o750 {
+		t.Errorf("mode = %v, want 0750", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestPackageManager(t *testing.T) {
+	for exe, want := range map[string]string{
+		"/opt/homebrew/Cellar/nox/1.0.0/bin/nox": "Homebrew",
+		"/home/linuxbrew/.linuxbrew/bin/nox":     "Homebrew",
+		"/nix/store/abc-nox-1.0.0/bin/nox":       "Nix",
+		"/usr/local/bin/nox":                     "",
+	} {
+		if got, _ := packageManager(exe); got != want {
+			t.Errorf("packageManager(%q) = %q, want %q", exe, got, want)
+		}
+	}
+}
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"1.2.0", "1.2.0", false},
+		{"1.2.0", "1.2.0-rc.1", true},
+		{"1.1.0", "1.2.0", false},
+		{"1.2.0", "dev", false},
+	}
+	for _, tt := range tests {
+		if got := isNewerVersion(tt.latest, tt.current); got != tt.want {
+			t.Errorf("isNewerVersion(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestRunUpgrade_Check(t *testing.T) {
+	t.Setenv("NOX_HOME", t.TempDir())
+	fakeRelease(t, "v1.2.0", []byte("new binary"), nil)
+
+	setVersion(t, "1.1.0")
+	code, out := captureRulesOutput(t, func() int { return runUpgrade([]string{"--check"}) })
+	if code != 0 || !strings.Contains(out, "nox 1.2.0 is available (current: 1.1.0)") {
+		t.Errorf("older version: exit %d, output %q", code, out)
+	}
+	if st := loadUpdateCheckState(updateCheckPath()); st.Latest != "v1.2.0" {
+		t.Errorf("update check state not recorded: %+v", st)
+	}
+
+	setVersion(t, "1.2.0")
+	code, out = captureRulesOutput(t, func() int { return runUpgrade(nil) })
+	if code != 0 || !strings.Contains(out, "up to date") {
+		t.Errorf("current version: exit %d, output %q", code, out)
+	}
+
+	setVersion(t, "dev")
+	if code := runUpgrade(nil); code != 2 {
+		t.Errorf("development build without --force: exit %d, want 2", code)
+	}
+}
+
+func TestRunUpgrade_Offline(t *testing.T) {
+	offlineMode = true
+	t.Cleanup(func() { offlineMode = false })
+	if code := runUpgrade(nil); code != 2 {
+		t.Errorf("offline: exit %d, want 2", code)
+	}
+}
+
+func clearCIEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "JENKINS_URL"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestUpdateCheck(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv("NOX_HOME", t.TempDir())
+	t.Setenv("NOX_NO_UPDATE_CHECK", "")
+	fakeRelease(t, "v1.2.0", nil, nil)
+	setVersion(t, "1.1.0")
+
+	c := startUpdateCheck(&nox.ScanConfig{})
+	if c == nil {
+		t.Fatal("expected the update check to run")
+	}
+	select {
+	case latest := <-c.done:
+		c.done <- latest
+	case <-time.After(5 * time.Second):
+		t.Fatal("update check did not finish")
+	}
+	if got := c.notice(); !strings.Contains(got, "nox 1.2.0 is available (current: 1.1.0)") {
+		t.Errorf("notice = %q", got)
+	}
+
+	// The next scan the same day uses the recorded result.
+	releasesURL = "http://127.0.0.1:0/unreachable"
+	c = startUpdateCheck(&nox.ScanConfig{})
+	if got := c.notice(); !strings.Contains(got, "1.2.0") {
+		t.Errorf("cached notice = %q", got)
+	}
+
+	setVersion(t, "1.2.0")
+	if got := startUpdateCheck(&nox.ScanConfig{}).notice(); got != "" {
+		t.Errorf("notice for the latest version = %q", got)
+	}
+}
+
+func TestUpdateCheckEnabled(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv("NOX_NO_UPDATE_CHECK", "")
+	setVersion(t, "1.1.0")
+	off := false
+
+	if !updateCheckEnabled(&nox.ScanConfig{}) {
+		t.Error("expected the update check to be enabled")
+	}
+	if updateCheckEnabled(&nox.ScanConfig{UpdateCheck: &off}) {
+		t.Error("update_check: false should disable the check")
+	}
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("NOX_NO_UPDATE_CHECK", "1")
+		if updateCheckEnabled(nil) {
+			t.Error("NOX_NO_UPDATE_CHECK should disable the check")
+		}
+	})
+	t.Run("generic ci", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		if updateCheckEnabled(nil) {
+			t.Error("the check should not run with CI set")
+		}
+	})
+	t.Run("ci", func(t *testing.T) {
+		t.Setenv("GITHUB_ACTIONS", "true")
+		if updateCheckEnabled(nil) {
+			t.Error("the check should not run in CI")
+		}
+	})
+	setVersion(t, "dev")
+	if updateCheckEnabled(nil) {
+		t.Error("development builds should not check for updates")
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -12,18 +14,32 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	noxapi "github.com/nox-hq/nox"
 	nox "github.com/nox-hq/nox/core"
 	"github.com/nox-hq/nox/core/badge"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/metrics"
+	"github.com/nox-hq/nox/core/report"
 )
 
 func runWatch(args []string) int {
 	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
 	var (
-		debounce time.Duration
-		jsonFlag bool
+		debounce    time.Duration
+		jsonFlag    bool
+		notify      bool
+		execCmd     string
+		metricsAddr string
+		lspFlag     bool
 	)
+	metricsLabelFlag := labelList{}
 	fs.DurationVar(&debounce, "debounce", 500*time.Millisecond, "debounce interval for file changes")
 	fs.BoolVar(&jsonFlag, "json", false, "output as JSON")
+	fs.BoolVar(&notify, "notify", false, "send a desktop notification when findings appear or resolve")
+	fs.StringVar(&execCmd, "exec", "", "shell command to run after each re-scan, with NOX_NEW_FINDINGS, NOX_RESOLVED and NOX_REPORT_PATH set")
+	fs.StringVar(&metricsAddr, "metrics-addr", "", "serve the metrics of the latest scan at http://ADDR/metrics (e.g., :9464)")
+	fs.Var(metricsLabelFlag, "metrics-label", "add name=value to every metric (repeatable; repo= overrides the git remote)")
+	fs.BoolVar(&lspFlag, "lsp", false, "serve findings as Language Server Protocol diagnostics over stdio, for editors")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
@@ -32,6 +48,34 @@ func runWatch(args []string) int {
 	if fs.NArg() > 0 {
 		target = fs.Arg(0)
 	}
+	if lspFlag {
+		if jsonFlag || notify || execCmd != "" || metricsAddr != "" {
+			fmt.Fprintln(os.Stderr, "error: --lsp cannot be combined with --json, --notify, --exec or --metrics-addr")
+			return 2
+		}
+		return runWatchLSP(target, fs.NArg() == 0, debounce, os.Stdin, os.Stdout)
+	}
+
+	session := &watchSession{target: target, jsonOutput: jsonFlag, notify: notify, execCmd: execCmd}
+	if execCmd != "" {
+		dir, err := os.MkdirTemp("", "nox-watch-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: creating report directory: %v\n", err)
+			return 2
+		}
+		defer os.RemoveAll(dir)
+		session.reportPath = filepath.Join(dir, "findings.json")
+	}
+	if metricsAddr != "" {
+		session.metrics = metrics.NewRegistry(buildInfo())
+		session.metricsLabels = metricsLabels(metricsLabelFlag, metrics.RepoLabel(target))
+		addr, err := serveMetrics(metricsAddr, session.metrics)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		fmt.Printf("watch: serving metrics at http://%s/metrics\n", addr)
+	}
 
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -52,7 +96,7 @@ func runWatch(args []string) int {
 
 	// Initial scan.
 	fmt.Printf("watch: scanning %s (debounce: %s)\n", target, debounce)
-	printScanResults(target, jsonFlag)
+	session.scan(false)
 
 	// Debounced event loop.
 	var mu sync.Mutex
@@ -65,9 +109,9 @@ func runWatch(args []string) int {
 			timer.Stop()
 		}
 		timer = time.AfterFunc(debounce, func() {
-			fmt.Print("\033[2J\033[H") // clear terminal
+			clearScreen()
 			fmt.Printf("watch: re-scanning %s\n", target)
-			printScanResults(target, jsonFlag)
+			session.scan(true)
 		})
 	}
 
@@ -79,6 +123,7 @@ func runWatch(args []string) int {
 			}
 			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Remove) {
 				// Add new directories if created.
+				slog.Debug("file changed", "path", event.Name, "op", event.Op.String())
 				if event.Has(fsnotify.Create) {
 					info, err := os.Stat(event.Name)
 					if err == nil && info.IsDir() {
@@ -91,7 +136,7 @@ func runWatch(args []string) int {
 			if !ok {
 				return 0
 			}
-			fmt.Fprintf(os.Stderr, "watch error: %v\n", err)
+			slog.Error("watch error", "target", target, "error", err)
 		case <-sigCh:
 			fmt.Println("\nwatch: stopped")
 			return 0
@@ -99,13 +144,101 @@ func runWatch(args []string) int {
 	}
 }
 
-func printScanResults(target string, jsonOutput bool) {
-	result, err := nox.RunScan(target)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: scan failed: %v\n", err)
+// watchSession runs the scans of a watch and reports how the active
+// findings change from one scan to the next.
+type watchSession struct {
+	target     string
+	jsonOutput bool
+	notify     bool
+	execCmd    string
+	reportPath string
+
+	metrics       *metrics.Registry // nil unless --metrics-addr is set
+	metricsLabels map[string]string
+
+	mu   sync.Mutex      // serialises scans so deltas are computed in order
+	seen map[string]bool // fingerprints of the previous scan
+}
+
+// watchDelta counts the findings that appeared and resolved between scans.
+type watchDelta struct {
+	New      int
+	Resolved int
+}
+
+// scan runs one scan and prints its results. For a re-scan (incremental
+// is true) the change from the previous scan is sent as a notification,
+// if enabled and anything changed, and passed to the exec hook.
+func (w *watchSession) scan(incremental bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := printScanResults(w.target, w.jsonOutput)
+	if result == nil {
+		return
+	}
+	if w.metrics != nil {
+		w.metrics.Set(metrics.Snapshot{Labels: w.metricsLabels, Result: result, Time: time.Now()})
+	}
+	active := result.Findings.ActiveFindings()
+	current := make(map[string]bool, len(active))
+	for _, f := range active {
+		current[f.Fingerprint] = true
+	}
+	delta := diffFingerprints(w.seen, current)
+	w.seen = current
+	if !incremental {
 		return
 	}
 
+	if delta.New > 0 || delta.Resolved > 0 {
+		fmt.Printf("[watch] %d new, %d resolved\n", delta.New, delta.Resolved)
+		if w.notify {
+			if err := sendNotification("nox", fmt.Sprintf("%d new, %d resolved finding(s) in %s", delta.New, delta.Resolved, w.target)); err != nil {
+				slog.Warn("desktop notification failed", "error", err)
+			}
+		}
+	}
+
+	if w.execCmd != "" {
+		if err := report.NewJSONReporter(version).WriteToFile(result.Findings, w.reportPath); err != nil {
+			slog.Warn("writing watch report failed", "path", w.reportPath, "error", err)
+		}
+		if err := runExecHook(w.execCmd, delta, w.reportPath); err != nil {
+			slog.Warn("exec hook failed", "command", w.execCmd, "error", err)
+		}
+	}
+}
+
+// diffFingerprints compares the fingerprints of two scans. A nil prev (no
+// earlier scan) yields an empty delta.
+func diffFingerprints(prev, current map[string]bool) watchDelta {
+	var d watchDelta
+	if prev == nil {
+		return d
+	}
+	for fp := range current {
+		if !prev[fp] {
+			d.New++
+		}
+	}
+	for fp := range prev {
+		if !current[fp] {
+			d.Resolved++
+		}
+	}
+	return d
+}
+
+func printScanResults(target string, jsonOutput bool) *nox.ScanResult {
+	start := time.Now()
+	result, err := scanDir(context.Background(), target, noxapi.WithConfigFile(configPath))
+	if err != nil {
+		slog.Error("scan failed", "target", target, "duration", time.Since(start), "error", err)
+		return nil
+	}
+	slog.Info("scan completed", "target", target, "findings", len(result.Findings.Findings()), "duration", time.Since(start))
+
 	ff := result.Findings.ActiveFindings()
 	suppressed := len(result.Findings.Findings()) - len(ff)
 	counts := badge.CountBySeverity(ff)
@@ -116,8 +249,10 @@ func printScanResults(target string, jsonOutput bool) {
 	}
 	if len(counts) > 0 {
 		parts := make([]string, 0, len(counts))
-		for sev, count := range counts {
-			parts = append(parts, fmt.Sprintf("%d %s", count, string(sev)))
+		for _, sev := range findings.Severities {
+			if count := counts[sev]; count > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", count, string(sev)))
+			}
 		}
 		fmt.Printf(" — %s", strings.Join(parts, ", "))
 	}
@@ -126,6 +261,7 @@ func printScanResults(target string, jsonOutput bool) {
 	if result.PolicyResult != nil {
 		fmt.Printf("[policy] %s\n", result.PolicyResult.Summary)
 	}
+	return result
 }
 
 func addDirsRecursive(watcher *fsnotify.Watcher, root string) error {
@@ -0,0 +1,608 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fsnotify/fsnotify"
+	noxapi "github.com/nox-hq/nox"
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// LSP diagnostic severities.
+const (
+	lspError       = 1
+	lspWarning     = 2
+	lspInformation = 3
+	lspHint        = 4
+)
+
+// JSON-RPC error codes used by the language server.
+const (
+	lspMethodNotFound       = -32601
+	lspInvalidParams        = -32602
+	lspServerNotInitialized = -32002
+)
+
+// lspServer is the language server of nox watch --lsp. It speaks the
+// diagnostics subset of the Language Server Protocol over stdio: it
+// publishes the findings of each scan of the workspace as diagnostics and,
+// for the documents an editor has open, the findings in the unsaved buffer
+// instead.
+type lspServer struct {
+	target   string // workspace root
+	debounce time.Duration
+	// opts are the options of workspace scans; buffer scans add the fast
+	// path to them.
+	opts []noxapi.Option
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	scanMu sync.Mutex // serialises scans
+
+	mu          sync.Mutex
+	initialized bool
+	shutdown    bool
+	open        map[string]string          // buffer text of open documents, by path
+	disk        map[string][]lspDiagnostic // diagnostics of the last workspace scan, by path
+	timers      map[string]*time.Timer     // pending buffer scans, by path
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextDocument struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+// lspRequest is a request or notification from the client.
+type lspRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// runWatchLSP serves diagnostics for target over in and out until the
+// client sends exit or closes in. When rootFromClient is set the workspace
+// root is taken from the initialize request instead.
+func runWatchLSP(target string, rootFromClient bool, debounce time.Duration, in io.Reader, out io.Writer) int {
+	s := &lspServer{
+		target:   target,
+		debounce: debounce,
+		out:      out,
+		open:     make(map[string]string),
+		disk:     make(map[string][]lspDiagnostic),
+		timers:   make(map[string]*time.Timer),
+	}
+	r := bufio.NewReader(in)
+	var stopWatch func()
+	defer func() {
+		if stopWatch != nil {
+			stopWatch()
+		}
+	}()
+	for {
+		body, err := readLSPMessage(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				slog.Error("lsp: reading message", "error", err)
+				return 1
+			}
+			return 0
+		}
+		var req lspRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			slog.Warn("lsp: malformed message", "error", err)
+			continue
+		}
+
+		switch req.Method {
+		case "initialize":
+			var params struct {
+				RootURI string `json:"rootUri"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			if rootFromClient && params.RootURI != "" {
+				if root, ok := uriToPath(params.RootURI); ok {
+					s.target = root
+				}
+			}
+			if err := s.configure(); err != nil {
+				s.respondError(req.ID, lspInvalidParams, err.Error())
+				continue
+			}
+			s.mu.Lock()
+			s.initialized = true
+			s.mu.Unlock()
+			s.respond(req.ID, map[string]any{
+				"capabilities": map[string]any{
+					// Full document sync: didChange carries the whole text.
+					"textDocumentSync": map[string]any{"openClose": true, "change": 1, "save": true},
+				},
+				"serverInfo": map[string]string{"name": "nox", "version": version},
+			})
+		case "initialized":
+			if stopWatch, err = s.watch(); err != nil {
+				slog.Error("lsp: watching workspace", "target", s.target, "error", err)
+			}
+			go s.scanWorkspace()
+		case "shutdown":
+			s.mu.Lock()
+			s.shutdown = true
+			s.mu.Unlock()
+			s.respond(req.ID, nil)
+		case "exit":
+			s.mu.Lock()
+			shutdown := s.shutdown
+			s.mu.Unlock()
+			if !shutdown {
+				return 1
+			}
+			return 0
+		case "textDocument/didOpen", "textDocument/didChange", "textDocument/didClose":
+			s.didChangeDocument(req.Method, req.Params)
+		default:
+			// Requests need an answer; other notifications, such as
+			// didSave, are left to the file watcher or ignored.
+			if len(req.ID) > 0 {
+				s.mu.Lock()
+				ready := s.initialized
+				s.mu.Unlock()
+				if !ready {
+					s.respondError(req.ID, lspServerNotInitialized, "server not initialized")
+				} else {
+					s.respondError(req.ID, lspMethodNotFound, "method not supported: "+req.Method)
+				}
+			}
+		}
+	}
+}
+
+// configure sets the options of the scans of the workspace. A relative
+// scan.rules_dir is resolved against the workspace, so that buffer scans,
+// which run in a temporary directory, load the same custom rules.
+func (s *lspServer) configure() error {
+	cfg, err := nox.LoadRootConfig(s.target, configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	s.opts = []noxapi.Option{noxapi.WithConfigFile(configPath)}
+	if dir := cfg.Scan.RulesDir; dir != "" {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(s.target, dir)
+		}
+		s.opts = append(s.opts, noxapi.WithRules(dir))
+	}
+	return nil
+}
+
+// watch re-scans the workspace when a file in it changes, after the
+// debounce interval.
+func (s *lspServer) watch() (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := addDirsRecursive(watcher, s.target); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go func() {
+		var timer *time.Timer
+		for event := range watcher.Events {
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = addDirsRecursive(watcher, event.Name)
+				}
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(s.debounce, s.scanWorkspace)
+		}
+	}()
+	return func() { watcher.Close() }, nil
+}
+
+// scanWorkspace scans the workspace and publishes the diagnostics of each
+// file that has findings or had them in the previous scan. Open documents
+// keep the diagnostics of their buffer.
+func (s *lspServer) scanWorkspace() {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	start := time.Now()
+	result, err := scanDir(context.Background(), s.target, s.opts...)
+	if err != nil {
+		slog.Error("lsp: scan failed", "target", s.target, "duration", time.Since(start), "error", err)
+		return
+	}
+	slog.Info("lsp: scan completed", "target", s.target, "findings", len(result.Findings.Findings()), "duration", time.Since(start))
+
+	byPath := make(map[string][]findings.Finding)
+	for _, f := range result.Findings.ActiveFindings() {
+		if f.Location.FilePath != "" {
+			byPath[f.Location.FilePath] = append(byPath[f.Location.FilePath], f)
+		}
+	}
+	disk := make(map[string][]lspDiagnostic, len(byPath))
+	for p, fs := range byPath {
+		content, _ := os.ReadFile(filepath.Join(s.target, filepath.FromSlash(p)))
+		disk[p] = lspDiagnostics(fs, content)
+	}
+
+	s.mu.Lock()
+	prev := s.disk
+	s.disk = disk
+	var publish []string
+	for p := range disk {
+		publish = append(publish, p)
+	}
+	for p := range prev {
+		if _, ok := disk[p]; !ok {
+			publish = append(publish, p)
+		}
+	}
+	sort.Strings(publish)
+	publish = slices.DeleteFunc(publish, func(p string) bool {
+		_, open := s.open[p]
+		return open
+	})
+	s.mu.Unlock()
+	for _, p := range publish {
+		s.publish(p, disk[p])
+	}
+}
+
+// didChangeDocument tracks the buffer of an open document and schedules a
+// scan of it. Closing a document restores the diagnostics of the file on
+// disk.
+func (s *lspServer) didChangeDocument(method string, raw json.RawMessage) {
+	var params struct {
+		TextDocument   lspTextDocument `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		slog.Warn("lsp: malformed notification", "method", method, "error", err)
+		return
+	}
+	rel, ok := s.relPath(params.TextDocument.URI)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch method {
+	case "textDocument/didOpen":
+		s.open[rel] = params.TextDocument.Text
+	case "textDocument/didChange":
+		if len(params.ContentChanges) == 0 {
+			return
+		}
+		s.open[rel] = params.ContentChanges[len(params.ContentChanges)-1].Text
+	case "textDocument/didClose":
+		delete(s.open, rel)
+		if t := s.timers[rel]; t != nil {
+			t.Stop()
+			delete(s.timers, rel)
+		}
+		go s.publish(rel, s.disk[rel])
+		return
+	}
+
+	if t := s.timers[rel]; t != nil {
+		t.Stop()
+	}
+	delay := s.debounce
+	if method == "textDocument/didOpen" {
+		delay = 0
+	}
+	s.timers[rel] = time.AfterFunc(delay, func() { s.scanBuffer(rel) })
+}
+
+// scanBuffer scans the buffer of the open document at rel and publishes
+// its diagnostics. The buffer is written to a temporary directory together
+// with the .nox.yaml files that apply to it, and scanned on the fast path,
+// so that only the content analyzers with rules for the file run.
+func (s *lspServer) scanBuffer(rel string) {
+	s.mu.Lock()
+	text, ok := s.open[rel]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	s.scanMu.Lock()
+	diags, err := s.bufferDiagnostics(rel, text)
+	s.scanMu.Unlock()
+	if err != nil {
+		slog.Error("lsp: buffer scan failed", "path", rel, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	current, stillOpen := s.open[rel]
+	s.mu.Unlock()
+	// A newer buffer has its own scan pending.
+	if stillOpen && current == text {
+		s.publish(rel, diags)
+	}
+}
+
+func (s *lspServer) bufferDiagnostics(rel, text string) ([]lspDiagnostic, error) {
+	tmp, err := os.MkdirTemp("", "nox-lsp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	files := map[string][]byte{rel: []byte(text)}
+	for _, dir := range append([]string{"."}, bufferAncestors(rel)...) {
+		cfgPath := path.Join(dir, nox.ConfigFileName)
+		if data, err := os.ReadFile(filepath.Join(s.target, filepath.FromSlash(cfgPath))); err == nil {
+			files[cfgPath] = data
+		}
+	}
+	for p, data := range files {
+		dest := filepath.Join(tmp, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := append(append([]noxapi.Option{}, s.opts...), noxapi.WithFast(), noxapi.WithoutOSV())
+	result, err := scanDir(context.Background(), tmp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var fs []findings.Finding
+	for _, f := range result.Findings.ActiveFindings() {
+		if f.Location.FilePath == rel {
+			fs = append(fs, f)
+		}
+	}
+	return lspDiagnostics(fs, []byte(text)), nil
+}
+
+// bufferAncestors returns the directories above the file at rel, outermost
+// first.
+func bufferAncestors(rel string) []string {
+	var dirs []string
+	for d := path.Dir(rel); d != "." && d != "/"; d = path.Dir(d) {
+		dirs = append([]string{d}, dirs...)
+	}
+	return dirs
+}
+
+// relPath returns the slash-separated path of a file URI relative to the
+// workspace root, or false for URIs outside it.
+func (s *lspServer) relPath(uri string) (string, bool) {
+	p, ok := uriToPath(uri)
+	if !ok {
+		return "", false
+	}
+	root, err := filepath.Abs(s.target)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// pathURI returns the file URI of the file at rel in the workspace.
+func (s *lspServer) pathURI(rel string) string {
+	abs, err := filepath.Abs(filepath.Join(s.target, filepath.FromSlash(rel)))
+	if err != nil {
+		abs = filepath.Join(s.target, filepath.FromSlash(rel))
+	}
+	p := filepath.ToSlash(abs)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive letter
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// uriToPath returns the local path of a file URI.
+func uriToPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	p := u.Path
+	if runtime.GOOS == "windows" {
+		p = strings.TrimPrefix(p, "/")
+	}
+	return filepath.FromSlash(p), true
+}
+
+// lspDiagnostics converts findings in a file with content to diagnostics.
+// Finding columns are 1-based byte offsets; LSP characters count UTF-16
+// code units.
+func lspDiagnostics(fs []findings.Finding, content []byte) []lspDiagnostic {
+	lines := strings.Split(string(content), "\n")
+	position := func(line, col int) lspPosition {
+		if line < 1 {
+			line = 1
+		}
+		pos := lspPosition{Line: line - 1}
+		if line <= len(lines) && col > 1 {
+			text := strings.TrimSuffix(lines[line-1], "\r")
+			pos.Character = utf16Len(text[:min(col-1, len(text))])
+		}
+		return pos
+	}
+	endOfLine := func(line int) lspPosition {
+		if line < 1 || line > len(lines) {
+			return lspPosition{Line: max(line, 1)}
+		}
+		return lspPosition{Line: line - 1, Character: utf16Len(strings.TrimSuffix(lines[line-1], "\r"))}
+	}
+
+	diags := make([]lspDiagnostic, 0, len(fs))
+	for _, f := range fs {
+		loc := f.Location
+		endLine := loc.EndLine
+		if endLine < loc.StartLine {
+			endLine = loc.StartLine
+		}
+		end := endOfLine(endLine)
+		if loc.EndColumn > 0 {
+			end = position(endLine, loc.EndColumn)
+		}
+		diags = append(diags, lspDiagnostic{
+			Range:    lspRange{Start: position(loc.StartLine, loc.StartColumn), End: end},
+			Severity: lspSeverity(f.Severity),
+			Code:     f.RuleID,
+			Source:   "nox",
+			Message:  f.Message,
+		})
+	}
+	return diags
+}
+
+// lspSeverity maps a finding severity to a DiagnosticSeverity: critical
+// and high findings are errors, medium ones warnings, low ones information
+// and info ones hints.
+func lspSeverity(s findings.Severity) int {
+	switch s {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		return lspError
+	case findings.SeverityMedium:
+		return lspWarning
+	case findings.SeverityLow:
+		return lspInformation
+	default:
+		return lspHint
+	}
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// publish sends the diagnostics of the file at rel.
+func (s *lspServer) publish(rel string, diags []lspDiagnostic) {
+	if diags == nil {
+		diags = []lspDiagnostic{}
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]any{
+		"uri":         s.pathURI(rel),
+		"diagnostics": diags,
+	})
+}
+
+func (s *lspServer) respond(id json.RawMessage, result any) {
+	s.write(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (s *lspServer) respondError(id json.RawMessage, code int, message string) {
+	s.write(map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": code, "message": message}})
+}
+
+func (s *lspServer) notify(method string, params any) {
+	s.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// write sends msg with the Content-Length header of the base protocol.
+func (s *lspServer) write(msg any) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("lsp: encoding message", "error", err)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		slog.Error("lsp: writing message", "error", err)
+	}
+}
+
+// readLSPMessage reads the body of the next base protocol message from r.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("reading header: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("message without Content-Length")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+	return body, nil
+}
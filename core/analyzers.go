@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// AnalyzerInfo describes a built-in analyzer that can be turned off with
+// ScanOptions.DisabledAnalyzers, ScanOptions.OnlyAnalyzers or
+// scan.analyzers in .nox.yaml.
+type AnalyzerInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Analyzers lists the built-in analyzers in the order they run.
+var Analyzers = []AnalyzerInfo{
+	{"secrets", "Hardcoded credentials, API keys and private keys"},
+	{"dotenv", "Committed .env files and secrets in their values"},
+	{"data", "Personal and regulated data (PII, PCI, PHI)"},
+	{"iac", "Infrastructure-as-code misconfigurations"},
+	{"ai", "AI application security and the AI component inventory"},
+	{"deps", "Lockfile inventory, OSV vulnerabilities and supply-chain checks"},
+	{"container", "Dockerfile base images and image secrets"},
+}
+
+// AnalyzerNames returns the names of the built-in analyzers.
+func AnalyzerNames() []string {
+	names := make([]string, len(Analyzers))
+	for i, a := range Analyzers {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// AnalyzersConfig is the scan.analyzers map in .nox.yaml. An analyzer set
+// to false does not run; analyzers that are not listed do.
+type AnalyzersConfig map[string]bool
+
+// resolveDisabledAnalyzers returns the analyzers that do not run, in
+// Analyzers order. When only or disabled is set, both replace cfg: only
+// keeps the listed analyzers and disabled turns off the listed ones.
+// Unknown analyzer names are an error.
+func resolveDisabledAnalyzers(cfg AnalyzersConfig, only, disabled []string) ([]string, error) {
+	names := AnalyzerNames()
+	check := func(name string) error {
+		if !slices.Contains(names, name) {
+			return fmt.Errorf("invalid analyzer %q (want one of %s)", name, strings.Join(names, ", "))
+		}
+		return nil
+	}
+	if len(only) == 0 && len(disabled) == 0 {
+		for name, on := range cfg {
+			if err := check(name); err != nil {
+				return nil, fmt.Errorf("scan.analyzers: %w", err)
+			}
+			if !on {
+				disabled = append(disabled, name)
+			}
+		}
+	}
+	for _, name := range slices.Concat(only, disabled) {
+		if err := check(name); err != nil {
+			return nil, err
+		}
+	}
+	var out []string
+	for _, name := range names {
+		if len(only) > 0 && !slices.Contains(only, name) || slices.Contains(disabled, name) {
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
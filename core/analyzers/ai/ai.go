@@ -6,9 +6,14 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
@@ -75,18 +80,44 @@ func (inv *Inventory) WriteFile(path string) error {
 // extracts an inventory of AI components.
 type Analyzer struct {
 	engine *rules.Engine
+	lazy   bool
+	// fileErrs records the files that cannot be read or scanned; nil
+	// fails the scan on them instead.
+	fileErrs *discovery.FileErrors
+}
+
+// AnalyzerOption configures the Analyzer.
+type AnalyzerOption func(*Analyzer)
+
+// WithLazyCompile compiles rule patterns on first use instead of up front.
+// See rules.NewLazyEngine.
+func WithLazyCompile() AnalyzerOption {
+	return func(a *Analyzer) { a.lazy = true }
+}
+
+// WithFileErrors records the artifacts that cannot be read or scanned in
+// e and skips them, instead of failing the scan.
+func WithFileErrors(e *discovery.FileErrors) AnalyzerOption {
+	return func(a *Analyzer) { a.fileErrs = e }
 }
 
 // NewAnalyzer creates an Analyzer with built-in AI security rules.
-func NewAnalyzer() *Analyzer {
+func NewAnalyzer(opts ...AnalyzerOption) *Analyzer {
 	rs := rules.NewRuleSet()
 	aiRules := builtinAIRules()
 	for _, r := range aiRules {
 		rs.Add(r)
 	}
-	return &Analyzer{
-		engine: rules.NewEngine(rs),
+	a := &Analyzer{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.lazy {
+		a.engine = rules.NewLazyEngine(rs)
+	} else {
+		a.engine = rules.NewEngine(rs)
 	}
+	return a
 }
 
 // Rules returns the analyzer's RuleSet for catalog aggregation.
@@ -98,27 +129,62 @@ func (a *Analyzer) ScanFile(path string, content []byte) ([]findings.Finding, er
 	return a.engine.ScanFile(path, content)
 }
 
+// SetFileTimeout bounds the time spent matching rules against a single file.
+// Zero means no limit.
+func (a *Analyzer) SetFileTimeout(d time.Duration) { a.engine.SetFileTimeout(d) }
+
+// RuleTimings returns the cumulative matching time per rule, slowest first.
+func (a *Analyzer) RuleTimings() []rules.RuleTiming { return a.engine.RuleTimings() }
+
+// LineNotes returns the lines that were capped or on which a rule exceeded
+// the per-line matching budget.
+func (a *Analyzer) LineNotes() []rules.LineNote { return a.engine.LineNotes() }
+
 // ScanArtifacts reads each artifact file from disk, scans it for AI security
 // issues, and collects findings. It also builds an AI component inventory from
 // artifacts classified as AIComponent.
 func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*findings.FindingSet, *Inventory, error) {
+	return a.ScanArtifactsContext(context.Background(), artifacts)
+}
+
+// ScanArtifactsContext is like ScanArtifacts but stops once ctx is done,
+// returning the findings and inventory gathered so far together with
+// ctx.Err(). Files that exceed the per-file timeout keep their partial
+// findings and are skipped.
+func (a *Analyzer) ScanArtifactsContext(ctx context.Context, artifacts []discovery.Artifact) (*findings.FindingSet, *Inventory, error) {
 	fs := findings.NewFindingSet()
 	inv := NewInventory()
 
 	for _, artifact := range artifacts {
-		content, err := os.ReadFile(artifact.AbsPath)
+		if err := ctx.Err(); err != nil {
+			fs.Deduplicate()
+			return fs, inv, err
+		}
+
+		content, err := discovery.ReadContent(artifact)
 		if err != nil {
+			if a.fileErrs.Skip(artifact.Path, err) {
+				continue
+			}
 			return nil, nil, fmt.Errorf("reading artifact %s: %w", artifact.Path, err)
 		}
 
 		// Scan for AI security rule violations.
-		results, err := a.ScanFile(artifact.Path, content)
-		if err != nil {
-			return nil, nil, fmt.Errorf("scanning artifact %s: %w", artifact.Path, err)
-		}
+		results, err := a.engine.ScanFileContext(ctx, artifact.Path, content)
 		for i := range results {
 			fs.Add(results[i])
 		}
+		switch {
+		case errors.Is(err, rules.ErrFileTimeout):
+			slog.Warn("file scan timed out", "analyzer", "ai", "path", artifact.Path)
+		case ctx.Err() != nil:
+			fs.Deduplicate()
+			return fs, inv, ctx.Err()
+		case err != nil:
+			if !a.fileErrs.Skip(artifact.Path, err) {
+				return nil, nil, fmt.Errorf("scanning artifact %s: %w", artifact.Path, err)
+			}
+		}
 
 		// Extract inventory entries from AI component artifacts.
 		if artifact.Type == discovery.AIComponent {
@@ -198,7 +264,7 @@ func extractMCPComponents(path string, content []byte) []Component {
 	}
 
 	var components []Component
-	for serverName := range config.MCPServers {
+	for _, serverName := range sortedServerNames(config.MCPServers) {
 		components = append(components, Component{
 			Name:    serverName,
 			Type:    "mcp_server",
@@ -209,6 +275,17 @@ func extractMCPComponents(path string, content []byte) []Component {
 	return components
 }
 
+// sortedServerNames returns the keys of an mcpServers object in sorted
+// order, so that inventory entries do not depend on map iteration order.
+func sortedServerNames(servers map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // classifyByPath returns a component type based on path segments.
 func classifyByPath(path string) string {
 	if containsSegment(path, "agents") {
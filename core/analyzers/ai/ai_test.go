@@ -9,6 +9,7 @@ import (
 
 	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
 )
 
 // ---------------------------------------------------------------------------
@@ -749,6 +750,33 @@ func TestDetect_LLMOutputInFilePath(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// AI-039: Webhook over plain HTTP
+// ---------------------------------------------------------------------------
+
+func TestDetect_InsecureWebhook(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{`webhook_url = "http://hooks.example.com/agent"`, true},
+		{`callback: 'http://10.0.0.5/done'`, true},
+		{`webhook = "http://localhost:8080/hook"`, false},
+		{`webhook = "http://127.0.0.1:8080/hook"`, false},
+		{`webhook = "https://hooks.example.com/agent"`, false},
+	}
+	for _, tt := range tests {
+		a := NewAnalyzer()
+		results, err := a.ScanFile("agent.py", []byte(tt.content))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := findingWithRule(results, "AI-039") != nil; got != tt.want {
+			t.Errorf("AI-039 on %q: got %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Rule count and compilation
 // ---------------------------------------------------------------------------
@@ -764,6 +792,12 @@ func TestAllAIRules_Compile(t *testing.T) {
 	for _, r := range builtinAIRules() {
 		if r.Pattern == "" {
 			t.Errorf("rule %s has empty pattern", r.ID)
+			continue
+		}
+		if r.MatcherType == "regex" {
+			if _, err := rules.CompilePattern(r.Pattern); err != nil {
+				t.Errorf("rule %s: %v", r.ID, err)
+			}
 		}
 	}
 }
@@ -428,7 +428,7 @@ func builtinAIRules() []*rules.Rule {
 		},
 		{
 			id: "AI-037", severity: findings.SeverityMedium, confidence: findings.ConfidenceMedium,
-			pattern:     `(?i)(system|assistant)\s*[:=]\s*["'][^"']{2000,}`,
+			pattern:     `(?i)(system|assistant)\s*[:=]\s*["'][^"']{1000}[^"']{1000,}`,
 			description: "Excessively long system prompt may cause inconsistency",
 			cwe:         "CWE-754", keywords: []string{"system", "prompt"},
 			tags:        []string{"ai", "reliability", "prompt-engineering"},
@@ -446,7 +446,7 @@ func builtinAIRules() []*rules.Rule {
 		},
 		{
 			id: "AI-039", severity: findings.SeverityMedium, confidence: findings.ConfidenceMedium,
-			pattern:     `(?i)(webhook|callback|url)\s*[:=]\s*["']http://(?!localhost|127\.0\.0\.1)`,
+			pattern:     `(?i)(webhook|callback|url)\s*[:=]\s*["']http://(?:[^l1"'\s]|l(?:[^o"'\s]|o[^c"'\s])|1(?:[^2"'\s]|2[^7"'\s]))`,
 			description: "AI webhook uses insecure HTTP",
 			cwe:         "CWE-295", keywords: []string{"webhook", "http://"},
 			tags:        []string{"ai", "transport-security", "webhook"},
@@ -566,6 +566,7 @@ func builtinAIRules() []*rules.Rule {
 			Pattern:      defs[i].pattern,
 			FilePatterns: defs[i].filePatterns,
 			Keywords:     defs[i].keywords,
+			Category:     rules.CategoryAI,
 			Tags:         defs[i].tags,
 			Metadata:     map[string]string{"cwe": defs[i].cwe},
 			Remediation:  defs[i].remediation,
@@ -45,12 +45,12 @@ func extractMCPToolPermissions(path string, content []byte) []ToolPermissionSet
 	}
 
 	var sets []ToolPermissionSet
-	for serverName, raw := range config.MCPServers {
+	for _, serverName := range sortedServerNames(config.MCPServers) {
 		var serverConfig struct {
 			Command string   `json:"command"`
 			Args    []string `json:"args"`
 		}
-		_ = json.Unmarshal(raw, &serverConfig)
+		_ = json.Unmarshal(config.MCPServers[serverName], &serverConfig)
 
 		set := ToolPermissionSet{
 			Agent:  "mcp_client",
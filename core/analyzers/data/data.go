@@ -6,8 +6,11 @@
 package data
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"time"
 
 	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
@@ -17,20 +20,46 @@ import (
 // Analyzer wraps a rules.Engine pre-loaded with data sensitivity detection rules.
 type Analyzer struct {
 	engine *rules.Engine
+	lazy   bool
+	// fileErrs records the files that cannot be read or scanned; nil
+	// fails the scan on them instead.
+	fileErrs *discovery.FileErrors
+}
+
+// AnalyzerOption configures the Analyzer.
+type AnalyzerOption func(*Analyzer)
+
+// WithLazyCompile compiles rule patterns on first use instead of up front.
+// See rules.NewLazyEngine.
+func WithLazyCompile() AnalyzerOption {
+	return func(a *Analyzer) { a.lazy = true }
+}
+
+// WithFileErrors records the artifacts that cannot be read or scanned in
+// e and skips them, instead of failing the scan.
+func WithFileErrors(e *discovery.FileErrors) AnalyzerOption {
+	return func(a *Analyzer) { a.fileErrs = e }
 }
 
 // NewAnalyzer creates an Analyzer with built-in data sensitivity detection
 // rules loaded programmatically. The rules use regex matching and apply to all
 // file types.
-func NewAnalyzer() *Analyzer {
+func NewAnalyzer(opts ...AnalyzerOption) *Analyzer {
 	rs := rules.NewRuleSet()
 	builtins := builtinDataRules()
 	for _, r := range builtins {
 		rs.Add(r)
 	}
-	return &Analyzer{
-		engine: rules.NewEngine(rs),
+	a := &Analyzer{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.lazy {
+		a.engine = rules.NewLazyEngine(rs)
+	} else {
+		a.engine = rules.NewEngine(rs)
 	}
+	return a
 }
 
 // Rules returns the analyzer's RuleSet for catalog aggregation.
@@ -42,26 +71,59 @@ func (a *Analyzer) ScanFile(path string, content []byte) ([]findings.Finding, er
 	return a.engine.ScanFile(path, content)
 }
 
+// SetFileTimeout bounds the time spent matching rules against a single file.
+// Zero means no limit.
+func (a *Analyzer) SetFileTimeout(d time.Duration) { a.engine.SetFileTimeout(d) }
+
+// RuleTimings returns the cumulative matching time per rule, slowest first.
+func (a *Analyzer) RuleTimings() []rules.RuleTiming { return a.engine.RuleTimings() }
+
+// LineNotes returns the lines that were capped or on which a rule exceeded
+// the per-line matching budget.
+func (a *Analyzer) LineNotes() []rules.LineNote { return a.engine.LineNotes() }
+
 // ScanArtifacts reads each artifact file from disk, scans it for sensitive
 // data patterns, and collects all findings into a deduplicated FindingSet. If
 // any artifact cannot be read, scanning stops and the error is returned.
 func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*findings.FindingSet, error) {
+	return a.ScanArtifactsContext(context.Background(), artifacts)
+}
+
+// ScanArtifactsContext is like ScanArtifacts but stops once ctx is done,
+// returning the findings gathered so far together with ctx.Err(). Files that
+// exceed the per-file timeout keep their partial findings and are skipped.
+func (a *Analyzer) ScanArtifactsContext(ctx context.Context, artifacts []discovery.Artifact) (*findings.FindingSet, error) {
 	fs := findings.NewFindingSet()
 
 	for _, artifact := range artifacts {
-		content, err := os.ReadFile(artifact.AbsPath)
-		if err != nil {
-			return nil, fmt.Errorf("reading artifact %s: %w", artifact.Path, err)
+		if err := ctx.Err(); err != nil {
+			fs.Deduplicate()
+			return fs, err
 		}
 
-		results, err := a.ScanFile(artifact.Path, content)
+		content, err := discovery.ReadContent(artifact)
 		if err != nil {
-			return nil, fmt.Errorf("scanning artifact %s: %w", artifact.Path, err)
+			if a.fileErrs.Skip(artifact.Path, err) {
+				continue
+			}
+			return nil, fmt.Errorf("reading artifact %s: %w", artifact.Path, err)
 		}
 
+		results, err := a.engine.ScanFileContext(ctx, artifact.Path, content)
 		for i := range results {
 			fs.Add(results[i])
 		}
+		switch {
+		case errors.Is(err, rules.ErrFileTimeout):
+			slog.Warn("file scan timed out", "analyzer", "data", "path", artifact.Path)
+		case ctx.Err() != nil:
+			fs.Deduplicate()
+			return fs, ctx.Err()
+		case err != nil:
+			if !a.fileErrs.Skip(artifact.Path, err) {
+				return nil, fmt.Errorf("scanning artifact %s: %w", artifact.Path, err)
+			}
+		}
 	}
 
 	fs.Deduplicate()
@@ -8,6 +8,7 @@ import (
 
 	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
 )
 
 // ---------------------------------------------------------------------------
@@ -52,6 +53,12 @@ func TestAllRules_Compile(t *testing.T) {
 	}
 }
 
+func TestAllRules_KeywordsCanMatch(t *testing.T) {
+	for _, issue := range rules.CheckKeywords(NewAnalyzer().Rules()) {
+		t.Error(issue)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Positive and negative match tests for each DATA-* rule
 // ---------------------------------------------------------------------------
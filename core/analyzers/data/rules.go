@@ -15,8 +15,11 @@ type dataRule struct {
 	description string
 	cwe         string
 	keywords    []string
-	remediation string
-	references  []string
+	// contextKeywords are words the file must mention for the rule to
+	// run, such as the field name of a value with no distinctive format.
+	contextKeywords []string
+	remediation     string
+	references      []string
 }
 
 // builtinDataRules returns all built-in data sensitivity detection rules.
@@ -85,7 +88,7 @@ func builtinDataRules() []*rules.Rule {
 			id: "DATA-008", severity: findings.SeverityHigh, confidence: findings.ConfidenceMedium,
 			pattern:     `\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z]\s?\d{2}\s?\d{2}\s?\d{2}\s?[A-D]\b`,
 			description: "UK National Insurance Number",
-			cwe:         "CWE-359", keywords: []string{"nino", "national_insurance", "ni_number"},
+			cwe:         "CWE-359", contextKeywords: []string{"nino", "national_insurance", "ni_number"},
 			remediation: "Remove or externalize PII data. Never hard-code sensitive personal information in source code or configuration files. Use environment variables, encrypted vaults, or database references.",
 			references:  []string{"https://cwe.mitre.org/data/definitions/359.html"},
 		},
@@ -126,18 +129,20 @@ func builtinDataRules() []*rules.Rule {
 	out := make([]*rules.Rule, len(defs))
 	for i := range defs {
 		out[i] = &rules.Rule{
-			ID:          defs[i].id,
-			Version:     "1.0",
-			Description: defs[i].description,
-			Severity:    defs[i].severity,
-			Confidence:  defs[i].confidence,
-			MatcherType: "regex",
-			Pattern:     defs[i].pattern,
-			Keywords:    defs[i].keywords,
-			Tags:        []string{"data-sensitivity", "pii"},
-			Metadata:    map[string]string{"cwe": defs[i].cwe},
-			Remediation: defs[i].remediation,
-			References:  defs[i].references,
+			ID:              defs[i].id,
+			Version:         "1.0",
+			Description:     defs[i].description,
+			Severity:        defs[i].severity,
+			Confidence:      defs[i].confidence,
+			MatcherType:     "regex",
+			Pattern:         defs[i].pattern,
+			Keywords:        defs[i].keywords,
+			ContextKeywords: defs[i].contextKeywords,
+			Category:        rules.CategoryData,
+			Tags:            []string{"data-sensitivity", "pii"},
+			Metadata:        map[string]string{"cwe": defs[i].cwe},
+			Remediation:     defs[i].remediation,
+			References:      defs[i].references,
 		}
 	}
 	return out
@@ -0,0 +1,61 @@
+package deps
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// usesPattern matches the value of a workflow step's "uses:" key.
+var usesPattern = regexp.MustCompile(`^\s*(?:-\s+)?uses:\s*["']?([^\s"'#]+)`)
+
+// isWorkflowFile reports whether path is a GitHub Actions workflow
+// (.github/workflows/*.yml) or the metadata file of a composite action
+// (action.yml).
+func isWorkflowFile(p string) bool {
+	p = filepath.ToSlash(p)
+	base := path.Base(p)
+	if base == "action.yml" || base == "action.yaml" {
+		return true
+	}
+	ext := path.Ext(base)
+	if ext != ".yml" && ext != ".yaml" {
+		return false
+	}
+	dir := path.Dir(p)
+	return dir == ".github/workflows" || strings.HasSuffix(dir, "/.github/workflows")
+}
+
+// ParseWorkflow extracts the actions and container images a GitHub Actions
+// workflow uses. "uses: owner/repo[/path]@ref" produces a Package with
+// Ecosystem "github-actions" and "uses: docker://image" one with Ecosystem
+// "docker". Local actions ("./path") and references without a ref are
+// skipped.
+func ParseWorkflow(content []byte) []Package {
+	var pkgs []Package
+	for _, line := range strings.Split(string(content), "\n") {
+		m := usesPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ref := m[1]
+		switch {
+		case strings.HasPrefix(ref, "./"), strings.HasPrefix(ref, "../"):
+			continue
+		case strings.HasPrefix(ref, "docker://"):
+			name, version := parseImageRef(strings.TrimPrefix(ref, "docker://"))
+			if name == "" || strings.Contains(name, "$") {
+				continue
+			}
+			pkgs = append(pkgs, Package{Name: name, Version: version, Ecosystem: "docker"})
+		default:
+			name, version, ok := strings.Cut(ref, "@")
+			if !ok || version == "" || !strings.Contains(name, "/") || strings.Contains(ref, "$") {
+				continue
+			}
+			pkgs = append(pkgs, Package{Name: name, Version: version, Ecosystem: "github-actions"})
+		}
+	}
+	return pkgs
+}
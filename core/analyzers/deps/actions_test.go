@@ -0,0 +1,91 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+func TestIsWorkflowFile(t *testing.T) {
+	tests := map[string]bool{
+		".github/workflows/ci.yml":          true,
+		"sub/.github/workflows/test.yaml":   true,
+		"action.yml":                        true,
+		".github/actions/setup/action.yaml": true,
+		".github/workflows/README.md":       false,
+		"workflows/ci.yml":                  false,
+		"docker-compose.yml":                false,
+	}
+	for path, want := range tests {
+		if got := isWorkflowFile(path); got != want {
+			t.Errorf("isWorkflowFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestParseWorkflow(t *testing.T) {
+	content := []byte(`on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1
+      - uses: "actions/setup-go@v5"
+      - name: CodeQL
+        uses: github/codeql-action/init@v3
+      - uses: ./.github/actions/local
+      - uses: docker://alpine:3.19
+      - uses: ${{ matrix.action }}@v1
+      - uses: actions/cache
+`)
+	got := ParseWorkflow(content)
+	want := []Package{
+		{Name: "actions/checkout", Version: "b4ffde65f46336ab88eb53be808477a3936bae11", Ecosystem: "github-actions"},
+		{Name: "actions/setup-go", Version: "v5", Ecosystem: "github-actions"},
+		{Name: "github/codeql-action/init", Version: "v3", Ecosystem: "github-actions"},
+		{Name: "alpine", Version: "3.19", Ecosystem: "docker"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseWorkflow = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("package %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScanArtifacts_WorkflowComponents(t *testing.T) {
+	dir := t.TempDir()
+	wf := filepath.Join(dir, "ci.yml")
+	workflow := "steps:\n  - uses: actions/checkout@v4\n  - uses: actions/checkout@v4\n"
+	if err := os.WriteFile(wf, []byte(workflow), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	artifacts := append(writeDockerfile(t, "FROM node:22\n"),
+		discovery.Artifact{Path: ".github/workflows/ci.yml", AbsPath: wf, Type: discovery.Config})
+
+	inv, _, err := NewAnalyzer(WithOSVDisabled()).ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(inv.ByEcosystem("github-actions")); got != 1 {
+		t.Errorf("expected 1 action, got %d", got)
+	}
+	if got := len(inv.ByEcosystem("docker")); got != 1 {
+		t.Errorf("expected 1 base image, got %d", got)
+	}
+
+	inv, fs, err := NewAnalyzer(WithOSVDisabled(), WithCIComponentsDisabled()).ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(inv.Packages()); n != 0 {
+		t.Errorf("expected no packages with CI components disabled, got %d", n)
+	}
+	if len(fs.Findings()) == 0 {
+		t.Error("expected container findings to be reported with CI components disabled")
+	}
+}
@@ -0,0 +1,206 @@
+package deps
+
+// This file implements checks on the release a container base image is
+// built on: end-of-life detection driven by embedded data, and the runtime
+// package to query OSV with for images that pin an exact version.
+
+import (
+	_ "embed"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// baseImageEOLJSON is the end-of-life database for official base images.
+// It is generated from endoflife.date by scripts/update-base-image-eol.py.
+//
+//go:embed data/base_image_eol.json
+var baseImageEOLJSON []byte
+
+// eolCycle is a release cycle of a product, such as Debian 10 or Node.js 18.
+type eolCycle struct {
+	Cycle    string `json:"cycle"`
+	Codename string `json:"codename,omitempty"`
+	EOL      string `json:"eol,omitempty"`
+}
+
+// eolProduct is a product tracked in the end-of-life database. Images lists
+// the Docker Hub official images built from it and Variant the tag prefix
+// other images use for it, as in "golang:1.22-alpine3.20".
+type eolProduct struct {
+	Name    string     `json:"name"`
+	Images  []string   `json:"images"`
+	Variant string     `json:"variant,omitempty"`
+	Cycles  []eolCycle `json:"cycles"`
+}
+
+// eolDatabase is the decoded form of data/base_image_eol.json.
+type eolDatabase struct {
+	Source   string                `json:"source"`
+	Updated  string                `json:"updated"`
+	Products map[string]eolProduct `json:"products"`
+}
+
+var (
+	eolOnce     sync.Once
+	eolDB       eolDatabase
+	eolProducts []string // product keys in sorted order
+)
+
+// loadEOL decodes the embedded end-of-life database. It is called exactly
+// once via sync.Once.
+func loadEOL() {
+	if err := json.Unmarshal(baseImageEOLJSON, &eolDB); err != nil {
+		return
+	}
+	for key := range eolDB.Products {
+		eolProducts = append(eolProducts, key)
+	}
+	sort.Strings(eolProducts)
+}
+
+// baseImageRelease is a product release a base image is built on.
+type baseImageRelease struct {
+	Product string // display name, e.g. "Alpine Linux"
+	Cycle   string
+	EOL     time.Time // zero when the end-of-life date is not known
+}
+
+// imageNameTag returns the repository name and tag of a base image package.
+// Images pinned with both a tag and a digest ("node:18@sha256:...") keep
+// the tag in the name, so it is split off here.
+func imageNameTag(img Package) (name, tag string) {
+	name, tag = img.Name, img.Version
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	return name, tag
+}
+
+// officialImage returns the Docker Hub official image name of ref, or ""
+// if ref names an image in another namespace or registry.
+func officialImage(name string) string {
+	name = strings.ToLower(name)
+	for _, prefix := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	name = strings.TrimPrefix(name, "library/")
+	if strings.Contains(name, "/") {
+		return ""
+	}
+	return name
+}
+
+// matchCycle returns the cycle of p that version names, either as a
+// version within the cycle ("3.16.2" is in "3.16") or by codename.
+func matchCycle(p eolProduct, version string) (eolCycle, bool) {
+	var best eolCycle
+	found := false
+	for _, c := range p.Cycles {
+		if version == c.Cycle || strings.HasPrefix(version, c.Cycle+".") || (c.Codename != "" && version == c.Codename) {
+			if !found || len(c.Cycle) > len(best.Cycle) {
+				best, found = c, true
+			}
+		}
+	}
+	return best, found
+}
+
+// baseImageReleases returns the releases an official image is built on,
+// derived from its tag: "golang:1.22-alpine3.16" is Go 1.22 on Alpine Linux
+// 3.16 and "node:14-buster" is Node.js 14 on Debian 10. Images outside the
+// Docker Hub official library and tags without a recognisable version,
+// such as "latest" or "lts", yield nothing.
+func baseImageReleases(img Package) []baseImageRelease {
+	eolOnce.Do(loadEOL)
+
+	name, tag := imageNameTag(img)
+	repo := officialImage(name)
+	if repo == "" || tag == "" {
+		return nil
+	}
+
+	var releases []baseImageRelease
+	seen := make(map[string]bool)
+	add := func(key string, p eolProduct, c eolCycle) {
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		r := baseImageRelease{Product: p.Name, Cycle: c.Cycle}
+		if t, err := time.Parse("2006-01-02", c.EOL); err == nil {
+			r.EOL = t
+		}
+		releases = append(releases, r)
+	}
+
+	segments := strings.Split(strings.ToLower(tag), "-")
+	for _, key := range eolProducts {
+		p := eolDB.Products[key]
+		for _, image := range p.Images {
+			if image != repo {
+				continue
+			}
+			if c, ok := matchCycle(p, segments[0]); ok {
+				add(key, p, c)
+			}
+		}
+	}
+	for _, seg := range segments[1:] {
+		for _, key := range eolProducts {
+			p := eolDB.Products[key]
+			if p.Variant != "" && strings.HasPrefix(seg, p.Variant) {
+				if c, ok := matchCycle(p, strings.TrimPrefix(seg, p.Variant)); ok {
+					add(key, p, c)
+				}
+				continue
+			}
+			for _, c := range p.Cycles {
+				if c.Codename != "" && seg == c.Codename {
+					add(key, p, c)
+				}
+			}
+		}
+	}
+	return releases
+}
+
+// timeNow returns the current time. It is a variable so tests can override it.
+var timeNow = time.Now
+
+// endOfLifeReleases returns the releases of img whose end of life is on or
+// before now.
+func endOfLifeReleases(img Package, now time.Time) []baseImageRelease {
+	var eol []baseImageRelease
+	for _, r := range baseImageReleases(img) {
+		if !r.EOL.IsZero() && !now.Before(r.EOL) {
+			eol = append(eol, r)
+		}
+	}
+	return eol
+}
+
+// exactGoVersion matches a Go release with a patch version, e.g. "1.22.3".
+var exactGoVersion = regexp.MustCompile(`^1\.\d+\.\d+$`)
+
+// baseImageRuntime returns the package OSV tracks for the runtime shipped
+// in an official image whose tag pins an exact release. OSV has no
+// ecosystem for container images, and OS packages of distro images cannot
+// be known from the tag, so only the Go toolchain is covered: its
+// vulnerabilities are recorded against the "stdlib" package of the Go
+// ecosystem. Floating tags such as "golang:1.22" are skipped because the
+// patch release they resolve to changes over time.
+func baseImageRuntime(img Package) (Package, bool) {
+	name, tag := imageNameTag(img)
+	if officialImage(name) != "golang" {
+		return Package{}, false
+	}
+	version, _, _ := strings.Cut(tag, "-")
+	if !exactGoVersion.MatchString(version) {
+		return Package{}, false
+	}
+	return Package{Name: "stdlib", Version: version, Ecosystem: "go"}, true
+}
@@ -0,0 +1,156 @@
+package deps
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+func TestBaseImageReleases(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"alpine:3.16", []string{"Alpine Linux 3.16"}},
+		{"alpine:3.16.2", []string{"Alpine Linux 3.16"}},
+		{"golang:1.22-alpine3.16", []string{"Go 1.22", "Alpine Linux 3.16"}},
+		{"node:14-buster", []string{"Node.js 14", "Debian 10"}},
+		{"node:18-alpine", []string{"Node.js 18"}},
+		{"python:3.10-slim-bullseye", []string{"Python 3.10", "Debian 11"}},
+		{"debian:buster-slim", []string{"Debian 10"}},
+		{"ubuntu:bionic-20230530", []string{"Ubuntu 18.04"}},
+		{"docker.io/library/ubuntu:20.04", []string{"Ubuntu 20.04"}},
+		{"node:18@sha256:abc", []string{"Node.js 18"}},
+		{"node:lts", nil},
+		{"alpine:latest", nil},
+		{"bitnami/node:14", nil},
+		{"ghcr.io/org/alpine:3.16", nil},
+	}
+	for _, tt := range tests {
+		name, version := parseImageRef(tt.ref)
+		var got []string
+		for _, r := range baseImageReleases(Package{Name: name, Version: version, Ecosystem: "docker"}) {
+			got = append(got, r.Product+" "+r.Cycle)
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("baseImageReleases(%q) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestEndOfLifeReleases(t *testing.T) {
+	img := Package{Name: "node", Version: "20-alpine3.21", Ecosystem: "docker"}
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	got := endOfLifeReleases(img, now)
+	if len(got) != 1 || got[0].Product != "Node.js" || got[0].Cycle != "20" {
+		t.Fatalf("expected only Node.js 20 to be end-of-life, got %+v", got)
+	}
+	if got := endOfLifeReleases(img, time.Date(2026, 4, 29, 0, 0, 0, 0, time.UTC)); len(got) != 0 {
+		t.Errorf("expected no end-of-life releases before the date, got %+v", got)
+	}
+}
+
+func TestBaseImageRuntime(t *testing.T) {
+	tests := []struct {
+		ref     string
+		version string
+	}{
+		{"golang:1.22.3-alpine", "1.22.3"},
+		{"golang:1.22.3", "1.22.3"},
+		{"golang:1.22", ""},
+		{"golang:latest", ""},
+		{"node:20.11.1", ""},
+	}
+	for _, tt := range tests {
+		name, version := parseImageRef(tt.ref)
+		pkg, ok := baseImageRuntime(Package{Name: name, Version: version, Ecosystem: "docker"})
+		if ok != (tt.version != "") || pkg.Version != tt.version {
+			t.Errorf("baseImageRuntime(%q) = %+v, %v", tt.ref, pkg, ok)
+		}
+		if ok && (pkg.Name != "stdlib" || pkg.Ecosystem != "go") {
+			t.Errorf("baseImageRuntime(%q) = %+v, want Go stdlib", tt.ref, pkg)
+		}
+	}
+}
+
+func writeDockerfile(t *testing.T, content string) []discovery.Artifact {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return []discovery.Artifact{{Path: "Dockerfile", AbsPath: path, Type: discovery.Container}}
+}
+
+func TestScanArtifacts_EndOfLifeBaseImage(t *testing.T) {
+	orig := timeNow
+	timeNow = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = orig }()
+
+	artifacts := writeDockerfile(t, "FROM golang:1.22-alpine3.16 AS build\nFROM node:22-bookworm\n")
+	_, fs, err := NewAnalyzer(WithOSVDisabled()).ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range fs.Findings() {
+		if f.RuleID != "CONT-006" {
+			continue
+		}
+		if f.Location.StartLine != 1 {
+			t.Errorf("CONT-006 at line %d, want 1", f.Location.StartLine)
+		}
+		got = append(got, f.Metadata["product"]+" "+f.Metadata["cycle"]+" "+f.Metadata["eol"])
+	}
+	if strings.Join(got, ", ") != "Alpine Linux 3.16 2024-05-23" {
+		t.Errorf("CONT-006 findings = %v", got)
+	}
+}
+
+func TestScanArtifacts_BaseImageOSV(t *testing.T) {
+	var queried []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req osvBatchRequest
+		decodeJSON(t, r, &req)
+		results := make([]osvBatchResult, len(req.Queries))
+		for i, q := range req.Queries {
+			queried = append(queried, q.Package.Ecosystem+"/"+q.Package.Name+"@"+q.Version)
+			if q.Package.Name == "stdlib" {
+				results[i] = osvBatchResult{Vulns: []osvVuln{{ID: "GO-2024-2887", Summary: "Unexpected behavior from Is methods for IPv4-mapped IPv6 addresses in net/netip"}}}
+			}
+		}
+		encodeJSON(t, w, osvBatchResponse{Results: results})
+	}))
+	defer srv.Close()
+
+	artifacts := writeDockerfile(t, "FROM golang:1.22.3-alpine AS build\nFROM alpine:3.20\n")
+	_, fs, err := NewAnalyzer(WithOSVBaseURL(srv.URL), WithHTTPClient(srv.Client())).ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(queried, ",") != "Go/stdlib@1.22.3" {
+		t.Errorf("OSV queries = %v, want only the Go stdlib of the golang image", queried)
+	}
+	var vulns int
+	for _, f := range fs.Findings() {
+		if f.RuleID != "VULN-001" {
+			continue
+		}
+		vulns++
+		if f.Location.FilePath != "Dockerfile" || f.Location.StartLine != 1 || f.Metadata["image"] != "golang" {
+			t.Errorf("unexpected VULN-001 finding: %+v", f)
+		}
+	}
+	if vulns != 1 {
+		t.Errorf("expected 1 VULN-001 finding, got %d", vulns)
+	}
+}
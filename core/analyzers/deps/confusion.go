@@ -0,0 +1,287 @@
+// Package deps — dependency confusion and near-miss typosquat heuristics.
+//
+// This file implements the SUPPLY-* checks over the package inventory:
+// internal package names resolved from a public registry (SUPPLY-001),
+// public packages with few releases that shadow an internal name
+// (SUPPLY-002), and names one edit away from a widely used package
+// (SUPPLY-003).
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// shadowMaxVersions is the largest number of published versions at which a
+// public package with an internal name is reported by SUPPLY-002. Squatted
+// names are usually a single placeholder release.
+const shadowMaxVersions = 3
+
+// nearMissMinLength is the shortest package name checked by SUPPLY-003.
+// Short names are one edit away from many legitimate packages.
+const nearMissMinLength = 5
+
+// Default public registry endpoints queried by SUPPLY-002.
+const (
+	defaultNPMRegistryURL = "https://registry.npmjs.org"
+	defaultPyPIURL        = "https://pypi.org"
+)
+
+// pypiSimpleURL is recorded as the source of poetry.lock packages that have
+// no explicit source, which poetry installs from PyPI.
+const pypiSimpleURL = "https://pypi.org/simple"
+
+// publicRegistryHosts are the hosts of the public registries per ecosystem.
+var publicRegistryHosts = map[string][]string{
+	"npm":  {"registry.npmjs.org", "registry.yarnpkg.com", "registry.npmjs.com"},
+	"pypi": {"pypi.org", "files.pythonhosted.org", "pypi.python.org"},
+}
+
+// topPkgs holds the lazy-loaded top package names per ecosystem, keyed by
+// their normalizePackageName form.
+var (
+	topOnce sync.Once
+	topPkgs map[string]map[string]string // ecosystem → normalized name → name
+)
+
+// WithInternalPrefixes sets the package name prefixes (e.g. "@acme/",
+// "acme-") that identify internal packages for the dependency confusion
+// checks.
+func WithInternalPrefixes(prefixes ...string) AnalyzerOption {
+	return func(a *Analyzer) { a.internalPrefixes = prefixes }
+}
+
+// WithRegistryBaseURLs overrides the npm and PyPI registry URLs queried for
+// published versions. An empty URL keeps the default.
+func WithRegistryBaseURLs(npm, pypi string) AnalyzerOption {
+	return func(a *Analyzer) {
+		if npm != "" {
+			a.npmRegistryURL = npm
+		}
+		if pypi != "" {
+			a.pypiURL = pypi
+		}
+	}
+}
+
+func loadTop() {
+	topPkgs = make(map[string]map[string]string)
+	files := map[string]string{
+		"npm":  "data/top_npm.txt",
+		"pypi": "data/top_pypi.txt",
+	}
+	for eco, path := range files {
+		data, err := dataFS.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		names := make(map[string]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			names[normalizePackageName(line, eco)] = line
+		}
+		topPkgs[eco] = names
+	}
+}
+
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePackageName lowercases name and, for PyPI, folds runs of "-",
+// "_" and "." into "-" as pip does (PEP 503), so that spellings of the same
+// project compare equal.
+func normalizePackageName(name, ecosystem string) string {
+	name = strings.ToLower(name)
+	if ecosystem == "pypi" {
+		name = pypiSeparators.ReplaceAllString(name, "-")
+	}
+	return name
+}
+
+// DetectNearMiss reports whether name is one edit away from a package in
+// the embedded list of the most downloaded packages of its ecosystem, and
+// returns that package, the first in sort order if there are several.
+// Packages on the list and names shorter than nearMissMinLength are never
+// reported. Only npm and pypi are checked.
+func DetectNearMiss(name, ecosystem string) (string, bool) {
+	topOnce.Do(loadTop)
+	names, ok := topPkgs[ecosystem]
+	if !ok {
+		return "", false
+	}
+	norm := normalizePackageName(name, ecosystem)
+	if _, top := names[norm]; top || len(norm) < nearMissMinLength {
+		return "", false
+	}
+	match := ""
+	for topNorm, topName := range names {
+		if len(topNorm) < nearMissMinLength || abs(len(topNorm)-len(norm)) > 1 {
+			continue
+		}
+		if LevenshteinDistance(norm, topNorm) == 1 && (match == "" || topName < match) {
+			match = topName
+		}
+	}
+	return match, match != ""
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// internalPrefix returns the configured prefix that name starts with.
+func (a *Analyzer) internalPrefix(name, ecosystem string) (string, bool) {
+	norm := normalizePackageName(name, ecosystem)
+	for _, p := range a.internalPrefixes {
+		if p != "" && strings.HasPrefix(norm, normalizePackageName(p, ecosystem)) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// resolvedFromPublicRegistry reports whether a lockfile records pkg as
+// downloaded from the public registry of its ecosystem. Packages whose
+// lockfile does not record a source are not reported.
+func resolvedFromPublicRegistry(pkg Package) bool {
+	if pkg.Resolved == "" {
+		return false
+	}
+	u, err := url.Parse(pkg.Resolved)
+	if err != nil {
+		return false
+	}
+	for _, host := range publicRegistryHosts[pkg.Ecosystem] {
+		if strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// supplyChainFindings runs the SUPPLY-* checks over pkgs. paths holds the
+// lockfile of each package. Packages already reported as typosquats by
+// VULN-002 are not reported again by SUPPLY-003. Public registries are
+// queried for SUPPLY-002 only when network lookups are enabled; lookup
+// failures are logged and skipped.
+func (a *Analyzer) supplyChainFindings(ctx context.Context, pkgs []Package, paths []string, typosquats map[int]bool) []findings.Finding {
+	var out []findings.Finding
+	add := func(ruleID string, i int, msg string, extra map[string]string) {
+		pkg := pkgs[i]
+		md := map[string]string{
+			"package":   pkg.Name,
+			"version":   pkg.Version,
+			"ecosystem": pkg.Ecosystem,
+		}
+		for k, v := range extra {
+			md[k] = v
+		}
+		out = append(out, findings.Finding{
+			RuleID:     ruleID,
+			Severity:   findings.SeverityMedium,
+			Confidence: findings.ConfidenceMedium,
+			Location:   findings.Location{FilePath: paths[i], StartLine: 1},
+			Message:    msg,
+			Metadata:   md,
+		})
+	}
+
+	queried := make(map[string]bool)
+	for i, pkg := range pkgs {
+		if pkg.Ecosystem != "npm" && pkg.Ecosystem != "pypi" {
+			continue
+		}
+		prefix, internal := a.internalPrefix(pkg.Name, pkg.Ecosystem)
+		switch {
+		case internal && resolvedFromPublicRegistry(pkg):
+			// SUPPLY-001: internal name installed from the public registry.
+			add("SUPPLY-001", i, fmt.Sprintf("Internal package %s@%s (prefix %s) is resolved from the public %s registry: %s", pkg.Name, pkg.Version, prefix, pkg.Ecosystem, pkg.Resolved),
+				map[string]string{"internal_prefix": prefix, "resolved": pkg.Resolved})
+		case internal && a.osvEnabled:
+			// SUPPLY-002: a public package with the same name and few
+			// releases is waiting for a misconfigured install.
+			key := pkg.Ecosystem + "|" + normalizePackageName(pkg.Name, pkg.Ecosystem)
+			if queried[key] {
+				continue
+			}
+			queried[key] = true
+			n, exists, err := a.publishedVersions(ctx, pkg)
+			if err != nil {
+				slog.Warn("registry lookup failed", "package", pkg.Name, "ecosystem", pkg.Ecosystem, "error", err)
+				continue
+			}
+			if exists && n <= shadowMaxVersions {
+				add("SUPPLY-002", i, fmt.Sprintf("Internal package name %s (prefix %s) is published on the public %s registry with only %d version(s)", pkg.Name, prefix, pkg.Ecosystem, n),
+					map[string]string{"internal_prefix": prefix, "public_versions": fmt.Sprint(n)})
+			}
+		case !internal && !typosquats[i]:
+			// SUPPLY-003: one edit away from a widely used package.
+			if top, ok := DetectNearMiss(pkg.Name, pkg.Ecosystem); ok {
+				add("SUPPLY-003", i, fmt.Sprintf("Package %s is one character away from the widely used package %s", pkg.Name, top),
+					map[string]string{"similar_package": top})
+			}
+		}
+	}
+	return out
+}
+
+// publishedVersions returns the number of versions of pkg published on the
+// public registry of its ecosystem, and false if the registry does not
+// know the package.
+func (a *Analyzer) publishedVersions(ctx context.Context, pkg Package) (int, bool, error) {
+	var endpoint string
+	switch pkg.Ecosystem {
+	case "npm":
+		// Scoped names keep the "@" but escape the "/".
+		endpoint = strings.TrimSuffix(a.npmRegistryURL, "/") + "/" + strings.Replace(pkg.Name, "/", "%2f", 1)
+	case "pypi":
+		endpoint = strings.TrimSuffix(a.pypiURL, "/") + "/pypi/" + url.PathEscape(pkg.Name) + "/json"
+	default:
+		return 0, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	if pkg.Ecosystem == "npm" {
+		// The abbreviated metadata document lists versions without
+		// their full manifests.
+		req.Header.Set("Accept", "application/vnd.npm.install-v1+json")
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+
+	var doc struct {
+		Versions map[string]json.RawMessage `json:"versions"` // npm
+		Releases map[string]json.RawMessage `json:"releases"` // PyPI
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&doc); err != nil {
+		return 0, false, fmt.Errorf("decoding %s: %w", endpoint, err)
+	}
+	return len(doc.Versions) + len(doc.Releases), true, nil
+}
@@ -0,0 +1,176 @@
+package deps
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+func TestDetectNearMiss(t *testing.T) {
+	tests := []struct {
+		name, ecosystem string
+		want            string
+		ok              bool
+	}{
+		{"@babel/cor", "npm", "@babel/core", true},
+		{"ansble", "pypi", "ansible", true},
+		{"Ansible_Core", "pypi", "", false}, // ansible-core after PEP 503 normalization
+		{"@babel/core", "npm", "", false},   // on the list
+		{"chalc", "npm", "chalk", true},
+		{"vue", "npm", "", false}, // too short
+		{"completely-unrelated", "npm", "", false},
+		{"ansble", "cargo", "", false}, // unsupported ecosystem
+	}
+	for _, tt := range tests {
+		t.Run(tt.ecosystem+"/"+tt.name, func(t *testing.T) {
+			got, ok := DetectNearMiss(tt.name, tt.ecosystem)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("DetectNearMiss(%q, %q) = %q, %v, want %q, %v", tt.name, tt.ecosystem, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	content := []byte(`[[package]]
+name = "requests"
+version = "2.31.0"
+
+[package.dependencies]
+idna = ">=2.5"
+
+[[package]]
+name = "acme-utils"
+version = "0.4.2"
+
+[package.source]
+type = "legacy"
+url = "https://pypi.acme.internal/simple"
+reference = "acme"
+
+[metadata]
+lock-version = "2.0"
+`)
+	pkgs, err := parsePoetryLock(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Package{
+		{Name: "requests", Version: "2.31.0", Ecosystem: "pypi", Resolved: "https://pypi.org/simple"},
+		{Name: "acme-utils", Version: "0.4.2", Ecosystem: "pypi", Resolved: "https://pypi.acme.internal/simple"},
+	}
+	if len(pkgs) != len(want) {
+		t.Fatalf("expected %d packages, got %d: %+v", len(want), len(pkgs), pkgs)
+	}
+	for i := range want {
+		if pkgs[i] != want[i] {
+			t.Errorf("package %d = %+v, want %+v", i, pkgs[i], want[i])
+		}
+	}
+}
+
+// confusionFindings scans testdata/confusion and returns "RULE:package" for
+// each SUPPLY-* finding.
+func confusionFindings(t *testing.T, opts ...AnalyzerOption) []string {
+	t.Helper()
+	artifacts, err := discovery.NewWalker(filepath.Join("testdata", "confusion")).Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]AnalyzerOption{WithInternalPrefixes("@acme/", "acme-")}, opts...)
+	_, fs, err := NewAnalyzer(opts...).ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatalf("ScanArtifacts returned error: %v", err)
+	}
+	var got []string
+	for _, f := range fs.Findings() {
+		if strings.HasPrefix(f.RuleID, "SUPPLY-") {
+			got = append(got, f.RuleID+":"+f.Metadata["package"])
+		}
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestScanArtifacts_DependencyConfusionOffline(t *testing.T) {
+	got := confusionFindings(t, WithOSVDisabled())
+	want := []string{
+		"SUPPLY-001:@acme/ui",
+		"SUPPLY-001:acme-billing",
+		"SUPPLY-003:@babel/cor",
+		"SUPPLY-003:ansble",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
+
+func TestScanArtifacts_DependencyConfusionRegistryLookup(t *testing.T) {
+	var lookups []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.EscapedPath()
+		if path == "/v1/querybatch" {
+			var req osvBatchRequest
+			decodeJSON(t, r, &req)
+			encodeJSON(t, w, osvBatchResponse{Results: make([]osvBatchResult, len(req.Queries))})
+			return
+		}
+		lookups = append(lookups, path)
+		switch path {
+		case "/@acme%2fauth":
+			if got := r.Header.Get("Accept"); got != "application/vnd.npm.install-v1+json" {
+				t.Errorf("Accept = %q", got)
+			}
+			encodeJSON(t, w, map[string]any{"versions": map[string]any{"99.0.0": map[string]any{}}})
+		case "/pypi/acme_utils/json":
+			encodeJSON(t, w, map[string]any{"releases": map[string]any{"0.0.1": []any{}, "99.0.0": []any{}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	got := confusionFindings(t,
+		WithOSVBaseURL(srv.URL),
+		WithHTTPClient(srv.Client()),
+		WithRegistryBaseURLs(srv.URL, srv.URL),
+	)
+	want := []string{
+		"SUPPLY-001:@acme/ui",
+		"SUPPLY-001:acme-billing",
+		"SUPPLY-002:@acme/auth",
+		"SUPPLY-002:acme_utils",
+		"SUPPLY-003:@babel/cor",
+		"SUPPLY-003:ansble",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	// Packages resolved from the public registry are not looked up.
+	sort.Strings(lookups)
+	wantLookups := []string{"/@acme%2fauth", "/@acme%2flogger", "/pypi/acme_utils/json"}
+	if strings.Join(lookups, " ") != strings.Join(wantLookups, " ") {
+		t.Errorf("registry lookups = %v, want %v", lookups, wantLookups)
+	}
+}
+
+func TestScanArtifacts_NoInternalPrefixes(t *testing.T) {
+	artifacts, err := discovery.NewWalker(filepath.Join("testdata", "confusion")).Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, fs, err := NewAnalyzer(WithOSVDisabled()).ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatalf("ScanArtifacts returned error: %v", err)
+	}
+	for _, f := range fs.Findings() {
+		if f.RuleID == "SUPPLY-001" || f.RuleID == "SUPPLY-002" {
+			t.Errorf("unexpected %s without internal prefixes: %s", f.RuleID, f.Message)
+		}
+	}
+}
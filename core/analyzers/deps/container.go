@@ -1,77 +1,54 @@
 package deps
 
 import (
-	"bufio"
-	"bytes"
-	"path/filepath"
-	"regexp"
 	"strings"
-)
 
-// reFromInstruction matches a Dockerfile FROM instruction and captures the
-// image reference. It handles optional --platform flags and multi-stage build
-// aliases.
-//
-// Examples matched:
-//
-//	FROM ubuntu:22.04
-//	FROM --platform=linux/amd64 python:3.11-slim AS builder
-//	FROM registry.example.com/myimage:v1.2
-//	FROM node@sha256:abc123def456
-var reFromInstruction = regexp.MustCompile(
-	`(?i)^\s*FROM\s+(?:--platform=\S+\s+)?(\S+)(?:\s+AS\s+\S+)?\s*$`,
+	"github.com/nox-hq/nox/core/dockerfile"
 )
 
 // ParseDockerfile extracts base image references from Dockerfile content.
-// Each FROM line produces a Package with Ecosystem "docker". Special images
-// like "scratch" and variable references (e.g., ${BASE_IMAGE}) are skipped.
+// Each FROM line produces a Package with Ecosystem "docker". ARG references
+// are expanded with the defaults of global ARG instructions. The special
+// "scratch" image, references to earlier build stages and ARG references
+// without a default (e.g., ${BASE_IMAGE}) are skipped.
 func ParseDockerfile(content []byte) ([]Package, error) {
-	var pkgs []Package
-
-	scanner := bufio.NewScanner(bytes.NewReader(content))
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Skip blank lines and comments.
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			continue
-		}
-
-		matches := reFromInstruction.FindStringSubmatch(trimmed)
-		if matches == nil {
-			continue
-		}
-
-		imageRef := matches[1]
-
-		// Skip the special "scratch" base image.
-		if strings.EqualFold(imageRef, "scratch") {
-			continue
-		}
-
-		// Skip variable references like ${BASE_IMAGE} or $BASE_IMAGE.
-		if strings.Contains(imageRef, "$") {
-			continue
-		}
-
-		name, version := parseImageRef(imageRef)
-		if name == "" {
-			continue
-		}
+	stages, err := dockerfileBaseImages(content)
+	if err != nil {
+		return nil, err
+	}
 
+	pkgs := make([]Package, 0, len(stages))
+	for _, s := range stages {
+		name, version := parseImageRef(s.Image)
 		pkgs = append(pkgs, Package{
 			Name:      name,
 			Version:   version,
 			Ecosystem: "docker",
 		})
 	}
+	return pkgs, nil
+}
 
-	if err := scanner.Err(); err != nil {
+// dockerfileBaseImages returns the stages of a Dockerfile that are built
+// from an external image. Base image rules apply to every such stage,
+// builder stages included, since each one is pulled at build time.
+func dockerfileBaseImages(content []byte) ([]*dockerfile.Stage, error) {
+	df, err := dockerfile.Parse(content)
+	if err != nil {
 		return nil, err
 	}
 
-	return pkgs, nil
+	var stages []*dockerfile.Stage
+	for _, s := range df.Stages {
+		if s.Image == "" || s.Base >= 0 || strings.EqualFold(s.Image, "scratch") {
+			continue
+		}
+		if name, _ := parseImageRef(s.Image); name == "" {
+			continue
+		}
+		stages = append(stages, s)
+	}
+	return stages, nil
 }
 
 // parseImageRef splits a Docker image reference into name and version.
@@ -112,21 +89,7 @@ func parseImageRef(ref string) (name, version string) {
 // patterns: "Dockerfile", "Dockerfile.*" (e.g., Dockerfile.production),
 // or "*.dockerfile".
 func isDockerfile(filename string) bool {
-	base := filepath.Base(filename)
-
-	if base == "Dockerfile" {
-		return true
-	}
-
-	if strings.HasPrefix(base, "Dockerfile.") {
-		return true
-	}
-
-	if strings.HasSuffix(strings.ToLower(base), ".dockerfile") {
-		return true
-	}
-
-	return false
+	return dockerfile.IsDockerfile(filename)
 }
 
 // imageIsPinnedToDigest reports whether the image reference includes a
@@ -143,40 +106,14 @@ func imageUsesLatestTag(version string) bool {
 }
 
 // dockerfileFromLines returns the 1-based line numbers of FROM instructions
-// in the given Dockerfile content that would produce packages (i.e., excluding
-// scratch and variable references). The returned slice aligns with the output
-// of ParseDockerfile so fromLines[i] is the line for packages[i].
+// in the given Dockerfile content that would produce packages. The returned
+// slice aligns with the output of ParseDockerfile so fromLines[i] is the
+// line for packages[i].
 func dockerfileFromLines(content []byte) []int {
-	var lines []int
-	lineNum := 0
-
-	scanner := bufio.NewScanner(bytes.NewReader(content))
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			continue
-		}
-
-		matches := reFromInstruction.FindStringSubmatch(trimmed)
-		if matches == nil {
-			continue
-		}
-
-		imageRef := matches[1]
-
-		// Mirror the skip logic from ParseDockerfile.
-		if strings.EqualFold(imageRef, "scratch") {
-			continue
-		}
-		if strings.Contains(imageRef, "$") {
-			continue
-		}
-
-		lines = append(lines, lineNum)
+	stages, _ := dockerfileBaseImages(content)
+	lines := make([]int, 0, len(stages))
+	for _, s := range stages {
+		lines = append(lines, s.Line)
 	}
-
 	return lines
 }
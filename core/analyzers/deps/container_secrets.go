@@ -0,0 +1,123 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/dockerfile"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// credentialFiles are base names of files that hold credentials and must
+// not be copied into an image. .npmrc is only a credential file when it
+// contains an auth token, which is checked in the build context.
+var credentialFiles = []string{
+	".env",
+	".env.*",
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	".npmrc",
+}
+
+// credentialTemplateSuffixes mark .env files that are committed templates
+// rather than real configuration.
+var credentialTemplateSuffixes = []string{".example", ".sample", ".template", ".dist"}
+
+// dockerfileSecretFindings returns CONT-003, CONT-004 and CONT-005 findings
+// for ENV and ARG instructions that set credential-named variables to
+// literal values and for COPY or ADD instructions that copy credential
+// files from the build context. contextDir is the directory of the
+// Dockerfile, which is taken as the build context. ARGs without a default
+// and values that reference other variables are not reported, so the
+// BuildKit pattern of declaring ARG NPM_TOKEN and mounting the secret is
+// left alone.
+func dockerfileSecretFindings(filePath, contextDir string, content []byte) []findings.Finding {
+	df, err := dockerfile.Parse(content)
+	if err != nil {
+		return nil
+	}
+
+	var out []findings.Finding
+	add := func(ruleID string, severity findings.Severity, confidence findings.Confidence, line int, msg string, meta map[string]string) {
+		out = append(out, findings.Finding{
+			RuleID:     ruleID,
+			Severity:   severity,
+			Confidence: confidence,
+			Location: findings.Location{
+				FilePath:  filePath,
+				StartLine: line,
+			},
+			Message:  msg,
+			Metadata: meta,
+		})
+	}
+
+	instructions := df.Global
+	for _, s := range df.Stages {
+		instructions = append(instructions, s.Instructions...)
+	}
+	for _, ins := range instructions {
+		switch ins.Cmd {
+		case "ENV", "ARG":
+			for _, v := range ins.Vars() {
+				if !v.HasValue || v.Value == "" || strings.Contains(v.Value, "$") || !rules.IsSecretName(v.Name) {
+					continue
+				}
+				if ins.Cmd == "ENV" {
+					add("CONT-003", findings.SeverityHigh, findings.ConfidenceMedium, ins.Line,
+						fmt.Sprintf("ENV %s sets a credential that is stored in the image", v.Name),
+						map[string]string{"variable": v.Name})
+				} else {
+					add("CONT-004", findings.SeverityHigh, findings.ConfidenceMedium, ins.Line,
+						fmt.Sprintf("ARG %s has a credential as its default value", v.Name),
+						map[string]string{"variable": v.Name})
+				}
+			}
+		case "COPY", "ADD":
+			if _, ok := ins.Flag("from"); ok {
+				continue
+			}
+			for _, src := range ins.Sources() {
+				if isCredentialFile(contextDir, src) {
+					add("CONT-005", findings.SeverityHigh, findings.ConfidenceHigh, ins.Line,
+						fmt.Sprintf("%s copies credential file %s into the image", ins.Cmd, src),
+						map[string]string{"source": src})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// isCredentialFile reports whether the COPY source src names a credential
+// file. .npmrc sources are read from contextDir and only count when they
+// contain an auth token (_auth or _authToken).
+func isCredentialFile(contextDir, src string) bool {
+	base := strings.ToLower(path.Base(src))
+	for _, suffix := range credentialTemplateSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return false
+		}
+	}
+	matched := false
+	for _, pattern := range credentialFiles {
+		if ok, _ := path.Match(pattern, base); ok {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if base != ".npmrc" {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(contextDir, filepath.FromSlash(src)))
+	return err == nil && strings.Contains(string(data), "_auth")
+}
@@ -0,0 +1,88 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+func TestDockerfileSecretFindings(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".npmrc"), []byte("//registry.npmjs.org/:_authToken=npm_abc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "public"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "public", ".npmrc"), []byte("registry=https://registry.npmjs.org/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte(`ARG GITHUB_TOKEN=ghp_0123456789abcdef
+ARG NPM_TOKEN
+FROM node:20 AS build
+ARG NPM_TOKEN
+ARG DB_PASSWORD=""
+ENV API_KEY=sk_live_abc123 NODE_ENV=production
+ENV dbPassword hunter2
+ENV SECRET_FROM_ARG=${NPM_TOKEN}
+ENV KEYCLOAK_URL=https://auth.example.com PRIVATE_REGISTRY=registry.example.com
+COPY .npmrc public/.npmrc package.json ./
+RUN --mount=type=secret,id=npm_token npm ci
+
+FROM node:20-slim
+COPY --chown=node [".env", "/app/.env"]
+COPY .env.example /app/
+ADD config/id_rsa /root/.ssh/id_rsa
+COPY --from=build /root/.ssh/id_ed25519 /tmp/
+`)
+
+	got := make(map[string][]int)
+	for _, f := range dockerfileSecretFindings("Dockerfile", dir, content) {
+		got[f.RuleID] = append(got[f.RuleID], f.Location.StartLine)
+	}
+
+	want := map[string][]int{
+		"CONT-003": {6, 7},
+		"CONT-004": {1},
+		"CONT-005": {10, 14, 16},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("findings = %v, want %v", got, want)
+	}
+	for id, lines := range want {
+		if !slices.Equal(got[id], lines) {
+			t.Errorf("%s lines = %v, want %v", id, got[id], lines)
+		}
+	}
+}
+
+func TestScanArtifacts_DockerfileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Dockerfile")
+	if err := os.WriteFile(path, []byte("FROM alpine@sha256:abc\nENV AWS_SECRET_ACCESS_KEY=wJalrXUtnFEMI\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	analyzer := NewAnalyzer(WithOSVDisabled())
+	_, fs, err := analyzer.ScanArtifacts([]discovery.Artifact{{Path: "Dockerfile", AbsPath: path, Type: discovery.Container}})
+	if err != nil {
+		t.Fatalf("ScanArtifacts returned error: %v", err)
+	}
+
+	var found bool
+	for _, f := range fs.Findings() {
+		if f.RuleID == "CONT-003" {
+			found = true
+			if f.Location.StartLine != 2 || f.Metadata["variable"] != "AWS_SECRET_ACCESS_KEY" {
+				t.Errorf("unexpected CONT-003 finding: %+v", f)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected CONT-003 finding")
+	}
+}
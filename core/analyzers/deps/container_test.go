@@ -166,9 +166,10 @@ func TestParseDockerfile_SkipScratchCaseInsensitive(t *testing.T) {
 }
 
 func TestParseDockerfile_SkipVariable(t *testing.T) {
-	content := []byte(`ARG BASE_IMAGE=ubuntu:22.04
+	content := []byte(`ARG BASE_IMAGE
 FROM ${BASE_IMAGE}
 FROM $BASE_IMAGE
+FROM ${OTHER_IMAGE}
 `)
 
 	pkgs, err := ParseDockerfile(content)
@@ -177,7 +178,55 @@ FROM $BASE_IMAGE
 	}
 
 	if len(pkgs) != 0 {
-		t.Fatalf("expected 0 packages (variables skipped), got %d: %+v", len(pkgs), pkgs)
+		t.Fatalf("expected 0 packages (ARGs without defaults skipped), got %d: %+v", len(pkgs), pkgs)
+	}
+}
+
+func TestParseDockerfile_ResolvesArgDefaults(t *testing.T) {
+	content := []byte(`ARG BASE_IMAGE=ubuntu:22.04
+ARG NODE_VERSION="18"
+FROM ${BASE_IMAGE}
+FROM node:$NODE_VERSION-alpine
+FROM python:${PY_VERSION:-3.12}
+ARG LATE=alpine:3.18
+FROM $LATE
+`)
+
+	pkgs, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("ParseDockerfile returned error: %v", err)
+	}
+
+	// LATE is declared after the first FROM, so FROM lines cannot see it.
+	expected := []Package{
+		{Name: "ubuntu", Version: "22.04", Ecosystem: "docker"},
+		{Name: "node", Version: "18-alpine", Ecosystem: "docker"},
+		{Name: "python", Version: "3.12", Ecosystem: "docker"},
+	}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("expected %d packages, got %d: %+v", len(expected), len(pkgs), pkgs)
+	}
+	for i, exp := range expected {
+		if pkgs[i] != exp {
+			t.Errorf("package[%d]: got %+v, want %+v", i, pkgs[i], exp)
+		}
+	}
+}
+
+func TestParseDockerfile_SkipStageReference(t *testing.T) {
+	content := []byte(`FROM node:18 AS base
+FROM base AS deps
+RUN npm ci
+FROM Deps
+`)
+
+	pkgs, err := ParseDockerfile(content)
+	if err != nil {
+		t.Fatalf("ParseDockerfile returned error: %v", err)
+	}
+
+	if len(pkgs) != 1 || pkgs[0].Name != "node" {
+		t.Fatalf("expected only the node base image, got %+v", pkgs)
 	}
 }
 
@@ -832,9 +881,18 @@ func TestContainerRulesRegistered(t *testing.T) {
 		t.Errorf("CONT-002 severity: got %q, want %q", cont002.Severity, "high")
 	}
 
+	for _, id := range []string{"CONT-003", "CONT-004", "CONT-005"} {
+		if r, ok := rs.ByID(id); !ok || r.Severity != "high" {
+			t.Errorf("expected %s registered with severity high", id)
+		}
+	}
+
 	// Verify tags.
 	containerRules := rs.ByTag("container")
-	if len(containerRules) != 2 {
-		t.Errorf("expected 2 container rules, got %d", len(containerRules))
+	if len(containerRules) != 8 {
+		t.Errorf("expected 8 container rules, got %d", len(containerRules))
+	}
+	if secretRules := rs.ByTag("secrets"); len(secretRules) != 3 {
+		t.Errorf("expected 3 container secret rules, got %d", len(secretRules))
 	}
 }
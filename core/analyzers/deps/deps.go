@@ -10,15 +10,16 @@ package deps
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/memguard"
 	"github.com/nox-hq/nox/core/rules"
 )
 
@@ -28,6 +29,9 @@ type Package struct {
 	Version   string
 	Ecosystem string // "npm", "go", "pypi", "rubygems", "cargo", "maven", "gradle", "nuget"
 	License   string // SPDX identifier (e.g., "MIT", "Apache-2.0", "GPL-3.0")
+	// Resolved is the URL the lockfile records the package as downloaded
+	// from, if any (package-lock.json "resolved", poetry.lock source).
+	Resolved string
 }
 
 // Vulnerability describes a known security issue for a package.
@@ -120,10 +124,16 @@ func (pi *PackageInventory) AllVulnerabilities() map[int][]Vulnerability {
 	return out
 }
 
-// LicensePolicy defines which dependency licenses are allowed or denied.
+// LicensePolicy defines which dependency licenses are allowed, denied or
+// flagged for review; see CheckLicensePolicy.
 type LicensePolicy struct {
 	Deny  []string
 	Allow []string
+	Warn  []string
+	// HistoryPath is the file recording the license of each package, which
+	// is compared with the next scan to report changes (LIC-003). Empty
+	// disables change detection.
+	HistoryPath string
 }
 
 // AnalyzerOption configures the dependency Analyzer.
@@ -135,6 +145,42 @@ func WithOSVDisabled() AnalyzerOption {
 	return func(a *Analyzer) { a.osvEnabled = false }
 }
 
+// WithCIComponentsDisabled keeps container base images and GitHub Actions
+// out of the package inventory, so SBOMs list only lockfile dependencies.
+// Container findings are still reported.
+func WithCIComponentsDisabled() AnalyzerOption {
+	return func(a *Analyzer) { a.ciComponents = false }
+}
+
+// WithContainerDisabled skips Dockerfiles, so there are no container
+// findings and no base images in the package inventory.
+func WithContainerDisabled() AnalyzerOption {
+	return func(a *Analyzer) { a.containerEnabled = false }
+}
+
+// WithInstallScriptBase compares npm lockfiles with their content at the
+// git revision base, to report packages with install scripts that were
+// added or upgraded since (DEP-005). read returns the content of a
+// lockfile, by its artifact path, at base, or an error wrapping
+// os.ErrNotExist when it did not exist there. Without it, the default,
+// DEP-005 is not checked.
+func WithInstallScriptBase(base string, read func(path string) ([]byte, error)) AnalyzerOption {
+	return func(a *Analyzer) { a.scriptBase, a.readBaseLockfile = base, read }
+}
+
+// WithMemoryGuard sets the guard that throttles the analyzer's workers,
+// which read the package.json of installed npm packages, when the process
+// crosses its memory budget.
+func WithMemoryGuard(g *memguard.Guard) AnalyzerOption {
+	return func(a *Analyzer) { a.memGuard = g }
+}
+
+// WithFileErrors records the lockfiles that cannot be read in e and skips
+// them, instead of failing the scan.
+func WithFileErrors(e *discovery.FileErrors) AnalyzerOption {
+	return func(a *Analyzer) { a.fileErrs = e }
+}
+
 // WithHTTPClient sets a custom HTTP client for OSV API requests.
 func WithHTTPClient(c *http.Client) AnalyzerOption {
 	return func(a *Analyzer) { a.httpClient = c }
@@ -159,15 +205,43 @@ type Analyzer struct {
 	OSVBaseURL    string
 	httpClient    *http.Client
 	osvEnabled    bool
+	ciComponents  bool
 	licensePolicy *LicensePolicy
+	// containerEnabled runs the Dockerfile checks.
+	containerEnabled bool
+	// internalPrefixes and the registry URLs drive the SUPPLY-* checks in
+	// confusion.go.
+	internalPrefixes []string
+	npmRegistryURL   string
+	pypiURL          string
+	// scriptBase and readBaseLockfile drive the DEP-005 comparison in
+	// scripts.go.
+	scriptBase       string
+	readBaseLockfile func(path string) ([]byte, error)
+	// memGuard throttles the install script workers; nil never does.
+	memGuard *memguard.Guard
+	// fileErrs records the lockfiles that cannot be read; nil fails the
+	// scan on them instead.
+	fileErrs *discovery.FileErrors
+	// verifyPins and the lookup settings drive the SUPPLY-004 and
+	// SUPPLY-005 checks in pins.go.
+	verifyPins   bool
+	githubToken  string
+	githubAPIURL string
+	registryURL  string
 }
 
 // NewAnalyzer returns an Analyzer with the default OSV API endpoint.
 func NewAnalyzer(opts ...AnalyzerOption) *Analyzer {
 	a := &Analyzer{
-		OSVBaseURL: "https://api.osv.dev",
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-		osvEnabled: true,
+		OSVBaseURL:       "https://api.osv.dev",
+		httpClient:       &http.Client{Timeout: 30 * time.Second},
+		osvEnabled:       true,
+		ciComponents:     true,
+		containerEnabled: true,
+		npmRegistryURL:   defaultNPMRegistryURL,
+		pypiURL:          defaultPyPIURL,
+		githubAPIURL:     defaultGitHubAPIURL,
 	}
 	for _, opt := range opts {
 		opt(a)
@@ -180,6 +254,7 @@ func (a *Analyzer) Rules() *rules.RuleSet {
 	rs := rules.NewRuleSet()
 	rs.Add(&rules.Rule{
 		ID:          "VULN-001",
+		Category:    rules.CategoryDeps,
 		Version:     "1.0",
 		Description: "Known vulnerability in dependency",
 		Severity:    findings.SeverityHigh,
@@ -191,6 +266,7 @@ func (a *Analyzer) Rules() *rules.RuleSet {
 	})
 	rs.Add(&rules.Rule{
 		ID:          "VULN-002",
+		Category:    rules.CategoryDeps,
 		Version:     "1.0",
 		Description: "Typosquatting: package name suspiciously similar to popular package",
 		Severity:    findings.SeverityCritical,
@@ -202,6 +278,7 @@ func (a *Analyzer) Rules() *rules.RuleSet {
 	})
 	rs.Add(&rules.Rule{
 		ID:          "VULN-003",
+		Category:    rules.CategoryDeps,
 		Version:     "1.0",
 		Description: "Known malicious package detected",
 		Severity:    findings.SeverityCritical,
@@ -211,8 +288,81 @@ func (a *Analyzer) Rules() *rules.RuleSet {
 		References:  []string{"https://osv.dev"},
 		Metadata:    map[string]string{"cwe": "CWE-506"},
 	})
+	rs.Add(&rules.Rule{
+		ID:          "SUPPLY-001",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Internal package resolved from a public registry",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"dependency", "dependency-confusion", "supply-chain"},
+		Remediation: "A package whose name matches dependencies.internal_prefixes was installed from the public registry, so anyone who publishes that name can run code in your builds. Pin the scope or package to the internal registry (e.g., @acme:registry in .npmrc, a poetry source with priority = \"explicit\", or --index-url instead of --extra-index-url), regenerate the lockfile, and check the installed package.",
+		References:  []string{"https://medium.com/@alex.birsan/dependency-confusion-4a5d60fec610", "https://docs.npmjs.com/cli/configuring-npm/npmrc"},
+		Metadata:    map[string]string{"cwe": "CWE-427"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "SUPPLY-002",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Public package with few releases shadows an internal package name",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"dependency", "dependency-confusion", "supply-chain"},
+		Remediation: "Someone has published a package with the name of an internal package, usually as a placeholder or attack release. Make sure installs of internal names can only reach the internal registry, and claim the name or scope on the public registry so that it cannot be squatted.",
+		References:  []string{"https://medium.com/@alex.birsan/dependency-confusion-4a5d60fec610"},
+		Metadata:    map[string]string{"cwe": "CWE-427"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "SUPPLY-003",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Package name is one character away from a widely used package",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"dependency", "typosquatting", "supply-chain"},
+		Remediation: "Check that the dependency is the package you meant to install. Typosquats copy the name of a popular package with one character changed and run malicious install scripts.",
+		References:  []string{"https://snyk.io/blog/typosquatting-attacks/"},
+		Metadata:    map[string]string{"cwe": "CWE-1357"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "SUPPLY-004",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Pinned image digest or action commit does not exist",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"supply-chain", "pinning"},
+		Remediation: "The registry or GitHub no longer has the pinned digest or commit, so builds fail or, for an action, the SHA may only have existed in a fork or a force-pushed branch. Re-pin to the digest or commit of a release you have reviewed, e.g. with docker buildx imagetools inspect <image>:<tag> or git ls-remote https://github.com/<owner>/<repo> refs/tags/<tag>.",
+		References:  []string{"https://docs.github.com/en/actions/security-for-github-actions/security-guides/security-hardening-for-github-actions#using-third-party-actions", "https://docs.docker.com/reference/cli/docker/image/pull/#pull-an-image-by-digest-immutable-identifier"},
+		Metadata:    map[string]string{"cwe": "CWE-829"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "SUPPLY-005",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Pinned digest or commit does not match the tag it is annotated with",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"supply-chain", "pinning"},
+		Remediation: "The tag next to the pin (node:20@sha256:... or uses: owner/repo@<sha> # v4.1.0) now points elsewhere, or no longer exists, so reviewers read a version the build does not run. Check what the pinned digest or commit is, then update the pin and its annotation together, as Dependabot and Renovate do.",
+		References:  []string{"https://docs.github.com/en/actions/security-for-github-actions/security-guides/security-hardening-for-github-actions#using-third-party-actions"},
+		Metadata:    map[string]string{"cwe": "CWE-829"},
+	})
 	rs.Add(&rules.Rule{
 		ID:          "LIC-001",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Dependency license could not be determined",
+		Severity:    findings.SeverityLow,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"dependency", "license", "compliance"},
+		Remediation: "Check the license of this dependency by hand. Installing dependencies (for example node_modules) before the scan lets nox read licenses from their manifests.",
+		References:  []string{"https://spdx.org/licenses/"},
+		Metadata:    map[string]string{"cwe": "CWE-1357"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "LIC-002",
+		Category:    rules.CategoryDeps,
 		Version:     "1.0",
 		Description: "Dependency uses a restricted license",
 		Severity:    findings.SeverityHigh,
@@ -222,8 +372,117 @@ func (a *Analyzer) Rules() *rules.RuleSet {
 		References:  []string{"https://spdx.org/licenses/"},
 		Metadata:    map[string]string{"cwe": "CWE-1357"},
 	})
+	rs.Add(&rules.Rule{
+		ID:          "LIC-003",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Dependency license changed since the last scan",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"dependency", "license", "compliance"},
+		Remediation: "Review the new license terms of this dependency, or pin the last version released under the previous license.",
+		References:  []string{"https://spdx.org/licenses/"},
+		Metadata:    map[string]string{"cwe": "CWE-1357"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "LOCK-001",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Lockfile version does not match the dependency manifest",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"dependency", "lockfile", "sca"},
+		Remediation: "Regenerate the lockfile so it matches the manifest (e.g., npm install, go mod tidy) and commit both files together. Vulnerability results are based on the locked versions.",
+		References:  []string{"https://docs.npmjs.com/cli/configuring-npm/package-lock-json", "https://go.dev/ref/mod#go-sum-files"},
+		Metadata:    map[string]string{"cwe": "CWE-1104"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "LOCK-002",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "Dependency manifest has no lockfile",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"dependency", "lockfile", "sca"},
+		Remediation: "Generate and commit a lockfile (package-lock.json, go.sum) so builds install reviewed versions and dependency scanning sees what is installed.",
+		References:  []string{"https://docs.npmjs.com/cli/configuring-npm/package-lock-json", "https://go.dev/ref/mod#go-sum-files"},
+		Metadata:    map[string]string{"cwe": "CWE-1104"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "LOCK-003",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "go.sum has entries that are not in the module graph",
+		Severity:    findings.SeverityLow,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"dependency", "lockfile", "sca"},
+		Remediation: "Run go mod tidy to drop checksums for module versions the build no longer uses.",
+		References:  []string{"https://go.dev/ref/mod#go-mod-tidy"},
+		Metadata:    map[string]string{"cwe": "CWE-1104"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "DEP-001",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "npm install script pipes a download into a shell",
+		Severity:    findings.SeverityCritical,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"dependency", "supply-chain", "install-script", "npm"},
+		Remediation: "Remove the package and check the machines that installed it. Install with --ignore-scripts until the package is reviewed, and report it to the npm registry if it is malicious.",
+		References:  []string{"https://docs.npmjs.com/cli/using-npm/scripts#life-cycle-scripts", "https://cwe.mitre.org/data/definitions/494.html"},
+		Metadata:    map[string]string{"cwe": "CWE-494"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "DEP-002",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "npm install script decodes and runs a base64 payload",
+		Severity:    findings.SeverityCritical,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"dependency", "supply-chain", "install-script", "npm"},
+		Remediation: "Remove the package and check the machines that installed it. Obfuscated install scripts have no legitimate use; report the package to the npm registry.",
+		References:  []string{"https://docs.npmjs.com/cli/using-npm/scripts#life-cycle-scripts", "https://cwe.mitre.org/data/definitions/506.html"},
+		Metadata:    map[string]string{"cwe": "CWE-506"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "DEP-003",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "npm install script accesses ~/.ssh",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"dependency", "supply-chain", "install-script", "npm"},
+		Remediation: "Remove the package, check ~/.ssh/authorized_keys for added keys and rotate the SSH keys of the machines that installed it.",
+		References:  []string{"https://docs.npmjs.com/cli/using-npm/scripts#life-cycle-scripts", "https://cwe.mitre.org/data/definitions/506.html"},
+		Metadata:    map[string]string{"cwe": "CWE-506"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "DEP-004",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "npm install script sends the environment to a remote host",
+		Severity:    findings.SeverityCritical,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"dependency", "supply-chain", "install-script", "npm"},
+		Remediation: "Remove the package and rotate every credential held in the environment of the machines and CI jobs that installed it.",
+		References:  []string{"https://docs.npmjs.com/cli/using-npm/scripts#life-cycle-scripts", "https://cwe.mitre.org/data/definitions/200.html"},
+		Metadata:    map[string]string{"cwe": "CWE-200"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "DEP-005",
+		Category:    rules.CategoryDeps,
+		Version:     "1.0",
+		Description: "npm dependency with an install script added or upgraded",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"dependency", "supply-chain", "install-script", "npm"},
+		Remediation: "Review the package's install scripts and the files they run before merging the lockfile change, or install with --ignore-scripts.",
+		References:  []string{"https://docs.npmjs.com/cli/using-npm/scripts#life-cycle-scripts", "https://cwe.mitre.org/data/definitions/829.html"},
+		Metadata:    map[string]string{"cwe": "CWE-829"},
+	})
 	rs.Add(&rules.Rule{
 		ID:          "CONT-001",
+		Category:    rules.CategoryContainer,
 		Version:     "1.0",
 		Description: "Container base image not pinned to specific digest",
 		Severity:    findings.SeverityMedium,
@@ -235,6 +494,7 @@ func (a *Analyzer) Rules() *rules.RuleSet {
 	})
 	rs.Add(&rules.Rule{
 		ID:          "CONT-002",
+		Category:    rules.CategoryContainer,
 		Version:     "1.0",
 		Description: "Container base image uses 'latest' tag or no tag",
 		Severity:    findings.SeverityHigh,
@@ -244,6 +504,78 @@ func (a *Analyzer) Rules() *rules.RuleSet {
 		References:  []string{"https://docs.docker.com/develop/develop-images/dockerfile_best-practices/"},
 		Metadata:    map[string]string{"cwe": "CWE-829"},
 	})
+	rs.Add(&rules.Rule{
+		ID:          "CONT-003",
+		Category:    rules.CategoryContainer,
+		Version:     "1.0",
+		Description: "Dockerfile ENV sets a credential in the image",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"container", "secrets"},
+		Remediation: "Do not set credentials with ENV; the value is stored in the image config and visible to anyone who can pull it. Pass build-time secrets with RUN --mount=type=secret and inject runtime secrets when the container starts.",
+		References:  []string{"https://docs.docker.com/build/building/secrets/"},
+		Metadata:    map[string]string{"cwe": "CWE-798"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "CONT-004",
+		Category:    rules.CategoryContainer,
+		Version:     "1.0",
+		Description: "Dockerfile ARG has a credential as its default value",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"container", "secrets"},
+		Remediation: "Remove the default value and pass the secret with RUN --mount=type=secret instead of a build argument. ARG values are recorded in the image history.",
+		References:  []string{"https://docs.docker.com/build/building/secrets/"},
+		Metadata:    map[string]string{"cwe": "CWE-798"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "CONT-005",
+		Category:    rules.CategoryContainer,
+		Version:     "1.0",
+		Description: "Dockerfile copies a credential file into the image",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"container", "secrets"},
+		Remediation: "Do not COPY credential files into the image; deleting them in a later layer does not remove them. Mount them for the build step that needs them with RUN --mount=type=secret, and add them to .dockerignore.",
+		References:  []string{"https://docs.docker.com/build/building/secrets/"},
+		Metadata:    map[string]string{"cwe": "CWE-538"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "CONT-006",
+		Category:    rules.CategoryContainer,
+		Version:     "1.0",
+		Description: "Container base image is end-of-life",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"container", "supply-chain", "eol"},
+		Remediation: "Move to a supported release of the base image (e.g., FROM node:22-alpine3.21 instead of node:14-alpine3.16). End-of-life releases no longer receive security fixes.",
+		References:  []string{"https://endoflife.date"},
+		Metadata:    map[string]string{"cwe": "CWE-1104"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "CONT-007",
+		Category:    rules.CategoryContainer,
+		Version:     "1.0",
+		Description: "Dockerfile copies the whole build context without a .dockerignore",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceHigh,
+		Tags:        []string{"container", "build-context"},
+		Remediation: "Add a .dockerignore next to the Dockerfile that excludes .git, .env files, keys and node_modules, or copy only the files the image needs instead of the whole context.",
+		References:  []string{"https://docs.docker.com/build/concepts/context/#dockerignore-files"},
+		Metadata:    map[string]string{"cwe": "CWE-538"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          "CONT-008",
+		Category:    rules.CategoryContainer,
+		Version:     "1.0",
+		Description: "Build context includes sensitive files that .dockerignore does not exclude",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceMedium,
+		Tags:        []string{"container", "build-context"},
+		Remediation: "Add the listed paths to .dockerignore (e.g., .git, **/.env, **/*.pem, **/node_modules), and check that no later ! exception re-includes them.",
+		References:  []string{"https://docs.docker.com/build/concepts/context/#dockerignore-files"},
+		Metadata:    map[string]string{"cwe": "CWE-538"},
+	})
 	return rs
 }
 
@@ -258,17 +590,26 @@ var supportedLockfiles = map[string]func([]byte) ([]Package, error){
 	"pom.xml":            parsePomXML,
 	"build.gradle":       parseBuildGradle,
 	"build.gradle.kts":   parseBuildGradle,
+	"gradle.lockfile":    parseGradleLockfile,
 	"packages.lock.json": parseNuGetPackagesLock,
+	"poetry.lock":        parsePoetryLock,
 	"composer.lock":      parseComposerLock,
 	"bom.json":           parseCycloneDXContent,
 	"sbom.json":          parseSPDXContent,
 }
 
 // ParseLockfile detects the lockfile format from its filename and delegates
-// to the appropriate parser. It returns an error if the filename is not
+// to the appropriate parser. pom.xml files are resolved against their parent
+// POMs on disk. It returns an error if the filename is not
 // recognised as a supported lockfile type.
 func (a *Analyzer) ParseLockfile(path string, content []byte) ([]Package, error) {
 	base := filepath.Base(path)
+	switch _, gradleLock := gradleLockModule(path); {
+	case base == "pom.xml":
+		return parsePomXMLFile(path, content)
+	case gradleLock:
+		return parseGradleLockfile(content)
+	}
 	parser, ok := supportedLockfiles[base]
 	if !ok {
 		return nil, fmt.Errorf("unsupported lockfile type: %s", base)
@@ -280,6 +621,14 @@ func (a *Analyzer) ParseLockfile(path string, content []byte) ([]Package, error)
 // parses each one, queries OSV for known vulnerabilities, and returns a
 // PackageInventory plus a FindingSet with vulnerability findings.
 func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInventory, *findings.FindingSet, error) {
+	return a.ScanArtifactsContext(context.Background(), artifacts)
+}
+
+// ScanArtifactsContext is like ScanArtifacts but honours ctx: lockfile
+// parsing stops once ctx is done and the OSV query is cancelled with it. On
+// cancellation the inventory and findings gathered so far are returned
+// together with ctx.Err().
+func (a *Analyzer) ScanArtifactsContext(ctx context.Context, artifacts []discovery.Artifact) (*PackageInventory, *findings.FindingSet, error) {
 	inventory := &PackageInventory{}
 	fs := findings.NewFindingSet()
 
@@ -289,13 +638,47 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 	}
 	var sources []pkgSource
 
+	// Runtime packages of base images, queried against OSV together with
+	// the lockfile packages.
+	type baseImageQuery struct {
+		pkg    Package
+		image  Package
+		pkgIdx int // inventory index of the image, or -1
+		path   string
+		line   int
+	}
+	var baseQueries []baseImageQuery
+
+	// Gradle modules with a dependency lock file are inventoried from it,
+	// since it holds the resolved versions, and their build files are
+	// skipped. Pre-6.0 modules have one lock file per configuration, so
+	// their packages are registered once per module.
+	lockedGradle := make(map[string]bool)
+	for _, art := range artifacts {
+		if dir, ok := gradleLockModule(art.Path); ok && art.Type == discovery.Lockfile {
+			lockedGradle[dir] = true
+		}
+	}
+	seenGradle := make(map[string]bool)
+
 	for _, art := range artifacts {
 		if art.Type != discovery.Lockfile {
 			continue
 		}
+		if err := ctx.Err(); err != nil {
+			return inventory, fs, err
+		}
+		if base := filepath.Base(art.Path); (base == "build.gradle" || base == "build.gradle.kts") &&
+			lockedGradle[filepath.ToSlash(filepath.Dir(art.Path))] {
+			continue
+		}
+		module, gradleLock := gradleLockModule(art.Path)
 
 		content, err := os.ReadFile(art.AbsPath)
 		if err != nil {
+			if a.fileErrs.Skip(art.Path, err) {
+				continue
+			}
 			return nil, nil, fmt.Errorf("reading lockfile %s: %w", art.Path, err)
 		}
 
@@ -307,13 +690,37 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 		}
 
 		for _, p := range pkgs {
+			if gradleLock {
+				key := module + "|" + p.Name + "@" + p.Version
+				if seenGradle[key] {
+					continue
+				}
+				seenGradle[key] = true
+			}
 			inventory.Add(p)
 			sources = append(sources, pkgSource{lockfilePath: art.Path})
 		}
 	}
 
+	// Compare manifests with their lockfiles so that drift, which makes the
+	// vulnerability results describe versions that will not be installed,
+	// is reported.
+	for _, f := range lockfileDrift(artifacts) {
+		fs.Add(f)
+	}
+
+	// Read the lifecycle scripts of installed npm packages, which run with
+	// the user's permissions on npm install.
+	for _, f := range checkInstallScripts(ctx, artifacts, a.scriptBase, a.readBaseLockfile, a.memGuard) {
+		fs.Add(f)
+	}
+
 	// Scan Dockerfiles for base image references and container findings.
-	for _, art := range artifacts {
+	dockerfiles := artifacts
+	if !a.containerEnabled {
+		dockerfiles = nil
+	}
+	for _, art := range dockerfiles {
 		if art.Type != discovery.Container && !isDockerfile(art.Path) {
 			continue
 		}
@@ -331,12 +738,23 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 			continue
 		}
 
+		for _, f := range dockerfileSecretFindings(art.Path, filepath.Dir(art.AbsPath), content) {
+			fs.Add(f)
+		}
+		for _, f := range dockerignoreFindings(art.Path, filepath.Dir(art.AbsPath), content) {
+			fs.Add(f)
+		}
+
 		// Determine line numbers for each FROM instruction for precise locations.
 		fromLines := dockerfileFromLines(content)
 
 		for i, img := range images {
-			inventory.Add(img)
-			sources = append(sources, pkgSource{lockfilePath: art.Path})
+			pkgIdx := -1
+			if a.ciComponents {
+				pkgIdx = len(sources)
+				inventory.Add(img)
+				sources = append(sources, pkgSource{lockfilePath: art.Path})
+			}
 
 			line := 1
 			if i < len(fromLines) {
@@ -380,6 +798,57 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 					},
 				})
 			}
+
+			// CONT-006: image built on an end-of-life release.
+			for _, rel := range endOfLifeReleases(img, timeNow()) {
+				eol := rel.EOL.Format("2006-01-02")
+				fs.Add(findings.Finding{
+					RuleID:     "CONT-006",
+					Severity:   findings.SeverityHigh,
+					Confidence: findings.ConfidenceHigh,
+					Location: findings.Location{
+						FilePath:  art.Path,
+						StartLine: line,
+					},
+					Message: fmt.Sprintf("Container base image %s:%s is built on %s %s, end-of-life since %s", img.Name, img.Version, rel.Product, rel.Cycle, eol),
+					Metadata: map[string]string{
+						"image":     img.Name,
+						"version":   img.Version,
+						"ecosystem": "docker",
+						"product":   rel.Product,
+						"cycle":     rel.Cycle,
+						"eol":       eol,
+					},
+				})
+			}
+
+			if pkg, ok := baseImageRuntime(img); ok {
+				baseQueries = append(baseQueries, baseImageQuery{pkg: pkg, image: img, pkgIdx: pkgIdx, path: art.Path, line: line})
+			}
+		}
+	}
+
+	// Register the actions and container images that workflows use, once
+	// per name and version.
+	if a.ciComponents {
+		seen := make(map[string]bool)
+		for _, art := range artifacts {
+			if !isWorkflowFile(art.Path) {
+				continue
+			}
+			content, err := os.ReadFile(art.AbsPath)
+			if err != nil {
+				continue // best-effort: skip unreadable files
+			}
+			for _, p := range ParseWorkflow(content) {
+				key := p.Ecosystem + "|" + p.Name + "@" + p.Version
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				inventory.Add(p)
+				sources = append(sources, pkgSource{lockfilePath: art.Path})
+			}
 		}
 	}
 
@@ -395,22 +864,41 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 
 	// Evaluate license policy if configured.
 	if a.licensePolicy != nil {
-		licFindings := CheckLicenses(inventory, a.licensePolicy.Deny, a.licensePolicy.Allow)
+		paths := make([]string, len(sources))
+		for i, src := range sources {
+			paths[i] = src.lockfilePath
+		}
+		licFindings := CheckLicensePolicy(inventory, *a.licensePolicy, paths)
+		if path := a.licensePolicy.HistoryPath; path != "" {
+			previous, err := loadLicenseHistory(path)
+			if err != nil {
+				slog.Warn("starting a new license history", "error", err)
+				previous = make(map[string]string)
+			}
+			licFindings = append(licFindings, CheckLicenseChanges(inventory, previous, paths)...)
+			if err := saveLicenseHistory(path, previous, inventory); err != nil {
+				slog.Warn("could not save license history", "path", path, "error", err)
+			}
+		}
 		for i := range licFindings {
 			fs.Add(licFindings[i])
 		}
 	}
 
-	// Malicious package detection: check for known malicious packages and
-	// typosquatting before making any network calls. This runs entirely
-	// offline using embedded data.
+	// Malicious package detection: check for known malicious packages,
+	// typosquatting and dependency confusion before the OSV query. Only
+	// SUPPLY-002 uses the network, to look up internal package names on
+	// the public registries; the rest runs offline using embedded data.
 	{
 		pkgs := inventory.Packages()
+		paths := make([]string, len(pkgs))
+		typosquats := make(map[int]bool)
 		for i, pkg := range pkgs {
 			lockfilePath := ""
 			if i < len(sources) {
 				lockfilePath = sources[i].lockfilePath
 			}
+			paths[i] = lockfilePath
 
 			// VULN-003: known malicious package.
 			if IsKnownMalicious(pkg.Name, pkg.Ecosystem) {
@@ -433,6 +921,7 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 
 			// VULN-002: typosquatting detection.
 			if popularName, typosquat := DetectTyposquatting(pkg.Name, pkg.Ecosystem, 2); typosquat {
+				typosquats[i] = true
 				fs.Add(findings.Finding{
 					RuleID:     "VULN-002",
 					Severity:   findings.SeverityCritical,
@@ -451,21 +940,93 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 				})
 			}
 		}
+
+		// SUPPLY-001 to SUPPLY-003: dependency confusion and near-miss
+		// typosquats.
+		for _, f := range a.supplyChainFindings(ctx, pkgs, paths, typosquats) {
+			fs.Add(f)
+		}
+	}
+
+	// SUPPLY-004 and SUPPLY-005: look up pinned digests and commits.
+	if a.verifyPins {
+		for _, f := range a.pinFindings(ctx, artifacts) {
+			fs.Add(f)
+		}
+		if err := ctx.Err(); err != nil {
+			return inventory, fs, err
+		}
 	}
 
 	// Query OSV for vulnerabilities if enabled.
 	if a.osvEnabled {
 		pkgs := inventory.Packages()
-		if len(pkgs) > 0 {
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+
+		// OSV has no ecosystem for container images, so base images are
+		// queried through the runtime package they ship instead. Action
+		// refs are branch or tag names rather than versions and are not
+		// queried. queryIdx maps each query to its inventory index.
+		var queries []Package
+		var queryIdx []int
+		for i, p := range pkgs {
+			if p.Ecosystem == "docker" || p.Ecosystem == "github-actions" {
+				continue
+			}
+			queries = append(queries, p)
+			queryIdx = append(queryIdx, i)
+		}
+		for _, q := range baseQueries {
+			queries = append(queries, q.pkg)
+		}
+
+		if len(queries) > 0 {
+			osvCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 			defer cancel()
 
-			vulnMap, err := queryOSV(ctx, a.httpClient, a.OSVBaseURL, pkgs)
+			osvStart := time.Now()
+			vulnMap, err := queryOSV(osvCtx, a.httpClient, a.OSVBaseURL, queries)
 			if err != nil {
+				if ctx.Err() != nil {
+					return inventory, fs, ctx.Err()
+				}
+				slog.Warn("OSV query failed", "packages", len(queries), "error", err)
 				return nil, nil, fmt.Errorf("querying OSV: %w", err)
 			}
+			slog.Debug("OSV query finished", "packages", len(queries), "vulnerable", len(vulnMap), "duration", time.Since(osvStart))
+
+			for qi, osvVulns := range vulnMap {
+				if qi >= len(queryIdx) {
+					q := baseQueries[qi-len(queryIdx)]
+					var imageVulns []Vulnerability
+					for _, ov := range osvVulns {
+						imageVulns = append(imageVulns, Vulnerability{
+							ID:       ov.ID,
+							Summary:  ov.Summary,
+							Severity: mapOSVSeverity(ov.Severity),
+							Aliases:  ov.Aliases,
+							Details:  ov.Details,
+						})
+						meta := vulnMetadata(ov, q.pkg)
+						meta["image"] = q.image.Name
+						fs.Add(findings.Finding{
+							RuleID:     "VULN-001",
+							Severity:   mapOSVSeverity(ov.Severity),
+							Confidence: findings.ConfidenceHigh,
+							Location: findings.Location{
+								FilePath:  q.path,
+								StartLine: q.line,
+							},
+							Message:  fmt.Sprintf("Known vulnerability %s in base image %s:%s (%s@%s): %s", ov.ID, q.image.Name, q.image.Version, q.pkg.Name, q.pkg.Version, ov.Summary),
+							Metadata: meta,
+						})
+					}
+					if q.pkgIdx >= 0 {
+						inventory.SetVulnerabilities(q.pkgIdx, imageVulns)
+					}
+					continue
+				}
 
-			for pkgIdx, osvVulns := range vulnMap {
+				pkgIdx := queryIdx[qi]
 				pkg := pkgs[pkgIdx]
 				var domainVulns []Vulnerability
 
@@ -484,7 +1045,6 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 						lockfilePath = sources[pkgIdx].lockfilePath
 					}
 
-					aliases := strings.Join(ov.Aliases, ",")
 					fs.Add(findings.Finding{
 						RuleID:     "VULN-001",
 						Severity:   sev,
@@ -493,14 +1053,8 @@ func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*PackageInvent
 							FilePath:  lockfilePath,
 							StartLine: 1,
 						},
-						Message: fmt.Sprintf("Known vulnerability %s in %s@%s: %s", ov.ID, pkg.Name, pkg.Version, ov.Summary),
-						Metadata: map[string]string{
-							"vuln_id":   ov.ID,
-							"package":   pkg.Name,
-							"version":   pkg.Version,
-							"ecosystem": pkg.Ecosystem,
-							"aliases":   aliases,
-						},
+						Message:  fmt.Sprintf("Known vulnerability %s in %s@%s: %s", ov.ID, pkg.Name, pkg.Version, ov.Summary),
+						Metadata: vulnMetadata(ov, pkg),
 					})
 				}
 
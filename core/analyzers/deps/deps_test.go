@@ -654,6 +654,184 @@ func TestParseBuildGradle_EmptyInput(t *testing.T) {
 	}
 }
 
+func TestParseCargoLock_Sources(t *testing.T) {
+	content := []byte(`version = 3
+
+[[package]]
+name = "private"
+version = "0.1.0"
+source = "git+https://github.com/example/private?rev=abc#abc"
+
+[[package]]
+name = "sparse"
+version = "1.0.0"
+source = "sparse+https://index.crates.io/"
+
+[[package]]
+name = "mirror"
+version = "2.0.0"
+source = "registry+https://mirror.example.com/index"
+
+[[patch.unused]]
+name = "unused"
+version = "9.9.9"
+`)
+
+	pkgs, err := parseCargoLock(content)
+	if err != nil {
+		t.Fatalf("parseCargoLock returned error: %v", err)
+	}
+	want := []Package{{Name: "sparse", Version: "1.0.0", Ecosystem: "cargo"}}
+	if len(pkgs) != 1 || pkgs[0] != want[0] {
+		t.Errorf("got %+v, want %+v", pkgs, want)
+	}
+}
+
+func TestParsePomXML_PropertiesAndManagedVersions(t *testing.T) {
+	content := []byte(`<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>2.0.0</version>
+  <properties>
+    <jackson.version>2.16.0</jackson.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.slf4j</groupId>
+        <artifactId>slf4j-api</artifactId>
+        <version>2.0.9</version>
+      </dependency>
+      <dependency>
+        <groupId>org.unused</groupId>
+        <artifactId>unused</artifactId>
+        <version>1.0</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+      <version>${jackson.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>${project.groupId}</groupId>
+      <artifactId>core</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.range</groupId>
+      <artifactId>ranged</artifactId>
+      <version>[1.0,2.0)</version>
+    </dependency>
+  </dependencies>
+</project>`)
+
+	pkgs, err := parsePomXML(content)
+	if err != nil {
+		t.Fatalf("parsePomXML returned error: %v", err)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+
+	expected := []Package{
+		{Name: "com.example:core", Version: "2.0.0", Ecosystem: "maven"},
+		{Name: "com.fasterxml.jackson.core:jackson-databind", Version: "2.16.0", Ecosystem: "maven"},
+		{Name: "org.slf4j:slf4j-api", Version: "2.0.9", Ecosystem: "maven"},
+	}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("expected %d packages, got %d: %+v", len(expected), len(pkgs), pkgs)
+	}
+	for i, exp := range expected {
+		if pkgs[i] != exp {
+			t.Errorf("package[%d]: got %+v, want %+v", i, pkgs[i], exp)
+		}
+	}
+}
+
+func TestParsePomXMLFile_Parent(t *testing.T) {
+	path := filepath.Join("testdata", "jvm-rust", "api", "pom.xml")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := parsePomXMLFile(path, content)
+	if err != nil {
+		t.Fatalf("parsePomXMLFile returned error: %v", err)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+
+	expected := []Package{
+		{Name: "com.example:model", Version: "1.4.0", Ecosystem: "maven"},
+		{Name: "org.apache.commons:commons-text", Version: "1.9", Ecosystem: "maven"},
+		{Name: "org.slf4j:slf4j-api", Version: "1.7.36", Ecosystem: "maven"},
+	}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("expected %d packages, got %d: %+v", len(expected), len(pkgs), pkgs)
+	}
+	for i, exp := range expected {
+		if pkgs[i] != exp {
+			t.Errorf("package[%d]: got %+v, want %+v", i, pkgs[i], exp)
+		}
+	}
+
+	// Without the parent the managed versions are unknown.
+	if pkgs, _ := parsePomXML(content); len(pkgs) != 1 || pkgs[0].Name != "com.example:model" {
+		t.Errorf("expected only the dependency with a version without the parent, got %+v", pkgs)
+	}
+}
+
+func TestParseGradleLockfile(t *testing.T) {
+	content := []byte(`# This is a Gradle generated file for dependency locking.
+org.apache.logging.log4j:log4j-core:2.14.1=compileClasspath,runtimeClasspath
+com.google.guava:guava:29.0-jre
+com.google.guava:guava:29.0-jre=testRuntimeClasspath
+empty=annotationProcessor
+`)
+
+	pkgs, err := parseGradleLockfile(content)
+	if err != nil {
+		t.Fatalf("parseGradleLockfile returned error: %v", err)
+	}
+	expected := []Package{
+		{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1", Ecosystem: "gradle"},
+		{Name: "com.google.guava:guava", Version: "29.0-jre", Ecosystem: "gradle"},
+	}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("expected %d packages, got %d: %+v", len(expected), len(pkgs), pkgs)
+	}
+	for i, exp := range expected {
+		if pkgs[i] != exp {
+			t.Errorf("package[%d]: got %+v, want %+v", i, pkgs[i], exp)
+		}
+	}
+}
+
+func TestGradleLockModule(t *testing.T) {
+	tests := []struct {
+		path, module string
+		ok           bool
+	}{
+		{"gradle.lockfile", ".", true},
+		{"services/worker/gradle.lockfile", "services/worker", true},
+		{"gradle/dependency-locks/compileClasspath.lockfile", ".", true},
+		{"legacy/gradle/dependency-locks/runtimeClasspath.lockfile", "legacy", true},
+		{"mygradle/dependency-locks/x.lockfile", "", false},
+		{"build.gradle", "", false},
+	}
+	for _, tt := range tests {
+		module, ok := gradleLockModule(tt.path)
+		if module != tt.module || ok != tt.ok {
+			t.Errorf("gradleLockModule(%q) = %q, %v; want %q, %v", tt.path, module, ok, tt.module, tt.ok)
+		}
+	}
+}
+
 func TestParseNuGetPackagesLock(t *testing.T) {
 	content := []byte(`{
   "version": 1,
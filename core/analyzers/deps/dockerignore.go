@@ -0,0 +1,291 @@
+package deps
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nox-hq/nox/core/dockerfile"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// sensitiveContextDirs are directory names that should not be sent with
+// the build context: version control history and credential stores, and
+// installed dependencies that the build should install itself.
+var sensitiveContextDirs = map[string]int{
+	".git":         1,
+	".ssh":         1,
+	".aws":         1,
+	"node_modules": 2,
+}
+
+// sensitiveContextFiles are base names of key and certificate files, in
+// addition to credentialFiles, that should not be sent with the build
+// context.
+var sensitiveContextFiles = []string{"*.pem", "*.key", "*.p12", "*.pfx"}
+
+// maxListedContextPaths caps the offending paths named in a finding.
+const maxListedContextPaths = 5
+
+// dockerignoreFindings returns a CONT-007 finding when the Dockerfile
+// copies the whole build context without a .dockerignore, and a CONT-008
+// finding when its .dockerignore leaves credential files, .git or
+// node_modules in the context. contextDir is the directory of the
+// Dockerfile, which is taken as the build context. Only the first COPY or
+// ADD of the context is reported.
+func dockerignoreFindings(filePath, contextDir string, content []byte) []findings.Finding {
+	df, err := dockerfile.Parse(content)
+	if err != nil {
+		return nil
+	}
+	var broad *dockerfile.Instruction
+stages:
+	for _, s := range df.Stages {
+		for i := range s.Instructions {
+			if copiesContext(s.Instructions[i]) {
+				broad = &s.Instructions[i]
+				break stages
+			}
+		}
+	}
+	if broad == nil {
+		return nil
+	}
+
+	ignore, ignorePath, err := loadDockerignore(contextDir, filepath.Base(filePath))
+	if err != nil {
+		return nil
+	}
+	exposed := exposedContextPaths(contextDir, ignore)
+
+	meta := map[string]string{"source": strings.Join(broad.Sources(), " ")}
+	if len(exposed) > 0 {
+		meta["paths"] = strings.Join(exposed, ",")
+	}
+	f := findings.Finding{
+		RuleID:     "CONT-008",
+		Severity:   findings.SeverityMedium,
+		Confidence: findings.ConfidenceMedium,
+		Location: findings.Location{
+			FilePath:  filePath,
+			StartLine: broad.Line,
+		},
+		Metadata: meta,
+	}
+	if ignorePath == "" {
+		f.RuleID = "CONT-007"
+		f.Confidence = findings.ConfidenceHigh
+		f.Message = fmt.Sprintf("%s copies the whole build context and there is no .dockerignore", broad.Cmd)
+		if len(exposed) > 0 {
+			f.Message += "; the context includes " + listPaths(exposed)
+		}
+		return []findings.Finding{f}
+	}
+	if len(exposed) == 0 {
+		return nil
+	}
+	meta["dockerignore"] = filepath.ToSlash(filepath.Base(ignorePath))
+	f.Message = fmt.Sprintf("%s copies the whole build context, and %s does not exclude %s",
+		broad.Cmd, meta["dockerignore"], listPaths(exposed))
+	return []findings.Finding{f}
+}
+
+// copiesContext reports whether ins is a COPY or ADD of the whole build
+// context, such as "COPY . ." or "COPY ./* /app/".
+func copiesContext(ins dockerfile.Instruction) bool {
+	if ins.Cmd != "COPY" && ins.Cmd != "ADD" {
+		return false
+	}
+	if _, ok := ins.Flag("from"); ok {
+		return false
+	}
+	for _, src := range ins.Sources() {
+		switch path.Clean(src) {
+		case ".", "*", "/", "/*":
+			return true
+		}
+	}
+	return false
+}
+
+// listPaths names the first offending paths and how many more there are.
+func listPaths(paths []string) string {
+	if len(paths) <= maxListedContextPaths {
+		return strings.Join(paths, ", ")
+	}
+	return strings.Join(paths[:maxListedContextPaths], ", ") + " and " +
+		strconv.Itoa(len(paths)-maxListedContextPaths) + " more"
+}
+
+// exposedContextPaths returns the sensitive files and directories under
+// contextDir that ignore does not exclude, as slash-separated paths
+// relative to contextDir. Credential files come first, then .git and
+// credential directories, then node_modules.
+func exposedContextPaths(contextDir string, ignore *dockerignore) []string {
+	type exposed struct {
+		path string
+		rank int
+	}
+	var found []exposed
+	_ = filepath.WalkDir(contextDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == contextDir {
+			return nil
+		}
+		rel, err := filepath.Rel(contextDir, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		excluded := ignore.Excludes(rel)
+		if d.IsDir() {
+			if rank, ok := sensitiveContextDirs[d.Name()]; ok {
+				if !excluded {
+					found = append(found, exposed{rel + "/", rank})
+				}
+				return filepath.SkipDir
+			}
+			// Docker still walks an excluded directory when a later
+			// exception may re-include something below it.
+			if excluded && !ignore.hasExceptions {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !excluded && isSensitiveContextFile(contextDir, rel) {
+			found = append(found, exposed{rel, 0})
+		}
+		return nil
+	})
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].rank != found[j].rank {
+			return found[i].rank < found[j].rank
+		}
+		return found[i].path < found[j].path
+	})
+	paths := make([]string, len(found))
+	for i, e := range found {
+		paths[i] = e.path
+	}
+	return paths
+}
+
+// isSensitiveContextFile reports whether the context file rel holds
+// credentials or key material.
+func isSensitiveContextFile(contextDir, rel string) bool {
+	if isCredentialFile(contextDir, rel) {
+		return true
+	}
+	base := strings.ToLower(path.Base(rel))
+	for _, pattern := range sensitiveContextFiles {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// dockerignore holds the patterns of a .dockerignore file.
+type dockerignore struct {
+	patterns      []ignorePattern
+	hasExceptions bool
+}
+
+type ignorePattern struct {
+	segments  []string
+	exception bool
+}
+
+// loadDockerignore reads the ignore file for the Dockerfile named
+// dockerfileName in contextDir. Like BuildKit, it prefers
+// <Dockerfile>.dockerignore over .dockerignore. It returns an empty path
+// when there is neither.
+func loadDockerignore(contextDir, dockerfileName string) (*dockerignore, string, error) {
+	for _, name := range []string{dockerfileName + ".dockerignore", ".dockerignore"} {
+		p := filepath.Join(contextDir, name)
+		data, err := os.ReadFile(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return parseDockerignore(data), p, nil
+	}
+	return &dockerignore{}, "", nil
+}
+
+// parseDockerignore parses .dockerignore content. Patterns are relative
+// to the root of the build context, with or without a leading "/", and
+// are cleaned like paths, so "dir/" is "dir". A "!" prefix makes a
+// pattern an exception that re-includes what it matches.
+func parseDockerignore(data []byte) *dockerignore {
+	d := &dockerignore{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exception := strings.HasPrefix(line, "!")
+		if exception {
+			line = strings.TrimSpace(line[1:])
+		}
+		line = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(line)), "/")
+		if line == "" {
+			continue
+		}
+		d.patterns = append(d.patterns, ignorePattern{
+			segments:  strings.Split(line, "/"),
+			exception: exception,
+		})
+		d.hasExceptions = d.hasExceptions || exception
+	}
+	return d
+}
+
+// Excludes reports whether the context path rel, slash-separated, is left
+// out of the build context. The last pattern that matches rel or one of
+// its parent directories decides.
+func (d *dockerignore) Excludes(rel string) bool {
+	name := strings.Split(rel, "/")
+	excluded := false
+	for _, p := range d.patterns {
+		for k := len(name); k > 0; k-- {
+			if matchIgnoreSegments(p.segments, name[:k]) {
+				excluded = !p.exception
+				break
+			}
+		}
+	}
+	return excluded
+}
+
+// matchIgnoreSegments matches path segments against pattern segments,
+// where "**" matches any number of segments, including none.
+func matchIgnoreSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchIgnoreSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,355 @@
+package deps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// npmLockfiles are the lockfile names that pin an npm project. Only
+// package-lock.json and npm-shrinkwrap.json are compared against
+// package.json; the others only satisfy the missing lockfile check.
+var npmLockfiles = []string{"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml"}
+
+// lockfileDrift compares dependency manifests with their lockfiles and
+// returns LOCK-001 findings for dependencies whose locked version does not
+// satisfy the manifest, LOCK-002 findings for manifests without a lockfile
+// and LOCK-003 findings for go.sum entries that the module graph no longer
+// uses. npm projects (package.json) and Go modules (go.mod) are checked.
+// Unreadable or malformed files are skipped.
+func lockfileDrift(artifacts []discovery.Artifact) []findings.Finding {
+	byPath := make(map[string]discovery.Artifact, len(artifacts))
+	for _, a := range artifacts {
+		byPath[a.Path] = a
+	}
+	sibling := func(manifest discovery.Artifact, name string) (discovery.Artifact, bool) {
+		a, ok := byPath[path.Join(path.Dir(manifest.Path), name)]
+		return a, ok
+	}
+
+	var out []findings.Finding
+	for _, art := range artifacts {
+		if strings.Contains("/"+art.Path, "/node_modules/") || strings.Contains("/"+art.Path, "/vendor/") {
+			continue
+		}
+		switch path.Base(art.Path) {
+		case "package.json":
+			out = append(out, npmDrift(art, byPath, sibling)...)
+		case "go.mod":
+			goSum, ok := sibling(art, "go.sum")
+			out = append(out, goModDrift(art, goSum, ok)...)
+		}
+	}
+	return out
+}
+
+// packageJSON is the subset of package.json needed for drift detection.
+type packageJSON struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// npmLock is the subset of package-lock.json needed for drift detection.
+// Lockfile v2/v3 use "packages"; v1 only has "dependencies".
+type npmLock struct {
+	Packages map[string]struct {
+		Version string `json:"version"`
+	} `json:"packages"`
+	Dependencies map[string]struct {
+		Version string `json:"version"`
+	} `json:"dependencies"`
+}
+
+func npmDrift(manifest discovery.Artifact, byPath map[string]discovery.Artifact, sibling func(discovery.Artifact, string) (discovery.Artifact, bool)) []findings.Finding {
+	content, err := os.ReadFile(manifest.AbsPath)
+	if err != nil {
+		return nil
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil
+	}
+	declared := make(map[string]string)
+	for _, deps := range []map[string]string{pkg.OptionalDependencies, pkg.DevDependencies, pkg.Dependencies} {
+		for name, constraint := range deps {
+			declared[name] = constraint
+		}
+	}
+	if len(declared) == 0 {
+		return nil
+	}
+
+	var lockArt discovery.Artifact
+	found := false
+	for _, name := range npmLockfiles {
+		if lockArt, found = sibling(manifest, name); found {
+			break
+		}
+	}
+	if !found {
+		// Workspace packages are locked by the lockfile of an ancestor.
+		for dir := path.Dir(manifest.Path); dir != "." && dir != "/"; {
+			dir = path.Dir(dir)
+			for _, name := range npmLockfiles {
+				if _, ok := byPath[path.Join(dir, name)]; ok {
+					return nil
+				}
+			}
+		}
+		return []findings.Finding{missingLockfileFinding(manifest.Path, "npm", "package-lock.json")}
+	}
+	switch path.Base(lockArt.Path) {
+	case "package-lock.json", "npm-shrinkwrap.json":
+	default:
+		return nil
+	}
+
+	lockContent, err := os.ReadFile(lockArt.AbsPath)
+	if err != nil {
+		return nil
+	}
+	var lock npmLock
+	if err := json.Unmarshal(lockContent, &lock); err != nil {
+		return nil
+	}
+	locked := func(name string) (string, bool) {
+		if p, ok := lock.Packages["node_modules/"+name]; ok {
+			return p.Version, true
+		}
+		if lock.Packages == nil {
+			if d, ok := lock.Dependencies[name]; ok {
+				return d.Version, true
+			}
+		}
+		return "", false
+	}
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []findings.Finding
+	for _, name := range names {
+		constraint := declared[name]
+		version, ok := locked(name)
+		if ok {
+			satisfied, known := npmRangeSatisfied(constraint, version)
+			if !known || satisfied {
+				continue
+			}
+		}
+		out = append(out, driftFinding(manifest.Path, lineOf(content, `"`+name+`"`), lockArt.Path, "npm", name, constraint, version))
+	}
+	return out
+}
+
+// goModFile is the subset of go.mod needed for drift detection.
+type goModFile struct {
+	goVersion string
+	requires  []goRequire
+	replaced  map[string]bool
+}
+
+type goRequire struct {
+	mod, version string
+	line         int
+}
+
+func goModDrift(goMod, goSum discovery.Artifact, hasSum bool) []findings.Finding {
+	content, err := os.ReadFile(goMod.AbsPath)
+	if err != nil {
+		return nil
+	}
+	mf := parseGoMod(content)
+	if len(mf.requires) == 0 {
+		return nil
+	}
+	if !hasSum {
+		return []findings.Finding{missingLockfileFinding(goMod.Path, "go", "go.sum")}
+	}
+
+	sumContent, err := os.ReadFile(goSum.AbsPath)
+	if err != nil {
+		return nil
+	}
+	type modVersion struct{ mod, version string }
+	sums := make(map[modVersion]bool) // any entry, including /go.mod hashes
+	zips := make(map[modVersion]int)  // module content hashes, by go.sum line
+	scanner := bufio.NewScanner(bytes.NewReader(sumContent))
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		version, goModOnly := strings.CutSuffix(fields[1], "/go.mod")
+		key := modVersion{fields[0], version}
+		sums[key] = true
+		if !goModOnly {
+			zips[key] = n
+		}
+	}
+
+	var out []findings.Finding
+	selected := make(map[string]string, len(mf.requires))
+	for _, r := range mf.requires {
+		selected[r.mod] = r.version
+		if mf.replaced[r.mod] || sums[modVersion{r.mod, r.version}] {
+			continue
+		}
+		out = append(out, driftFinding(goMod.Path, r.line, goSum.Path, "go", r.mod, r.version, ""))
+	}
+
+	// Since Go 1.17 go.mod records the selected version of every module
+	// that provides a package to the build, and only that version's content
+	// is downloaded. A content hash for another version of a required
+	// module is left over from an earlier module graph.
+	if !goVersionAtLeast(mf.goVersion, 1, 17) {
+		return out
+	}
+	var stale []modVersion
+	for key := range zips {
+		if v, ok := selected[key.mod]; ok && v != key.version && !mf.replaced[key.mod] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return zips[stale[i]] < zips[stale[j]] })
+	for _, key := range stale {
+		out = append(out, findings.Finding{
+			RuleID:     "LOCK-003",
+			Severity:   findings.SeverityLow,
+			Confidence: findings.ConfidenceMedium,
+			Location: findings.Location{
+				FilePath:  goSum.Path,
+				StartLine: zips[key],
+			},
+			Message: fmt.Sprintf("go.sum has a stale entry for %s@%s; %s selects %s", key.mod, key.version, goMod.Path, selected[key.mod]),
+			Metadata: map[string]string{
+				"package":          key.mod,
+				"version":          key.version,
+				"selected_version": selected[key.mod],
+				"ecosystem":        "go",
+				"manifest":         goMod.Path,
+			},
+		})
+	}
+	return out
+}
+
+// parseGoMod extracts the go directive, require directives and replaced
+// module paths from go.mod content.
+func parseGoMod(content []byte) goModFile {
+	mf := goModFile{replaced: make(map[string]bool)}
+	block := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		switch fields[0] {
+		case "go":
+			if len(fields) > 1 {
+				mf.goVersion = fields[1]
+			}
+		case "require":
+			if len(fields) >= 3 {
+				mf.requires = append(mf.requires, goRequire{mod: fields[1], version: fields[2], line: n})
+			}
+		case "replace":
+			if len(fields) >= 2 {
+				mf.replaced[fields[1]] = true
+			}
+		}
+	}
+	return mf
+}
+
+// goVersionAtLeast reports whether a go directive version such as "1.21.3"
+// is at least major.minor.
+func goVersionAtLeast(v string, major, minor int) bool {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	gotMajor, err1 := strconv.Atoi(parts[0])
+	gotMinor, err2 := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
+
+func driftFinding(manifestPath string, line int, lockPath, ecosystem, name, constraint, locked string) findings.Finding {
+	msg := fmt.Sprintf("%s requires %s %s but %s locks %s", manifestPath, name, constraint, lockPath, locked)
+	if locked == "" {
+		msg = fmt.Sprintf("%s requires %s %s but %s has no entry for it", manifestPath, name, constraint, lockPath)
+	}
+	return findings.Finding{
+		RuleID:     "LOCK-001",
+		Severity:   findings.SeverityMedium,
+		Confidence: findings.ConfidenceHigh,
+		Location: findings.Location{
+			FilePath:  manifestPath,
+			StartLine: line,
+		},
+		Message: msg,
+		Metadata: map[string]string{
+			"package":        name,
+			"constraint":     constraint,
+			"locked_version": locked,
+			"ecosystem":      ecosystem,
+			"lockfile":       lockPath,
+		},
+	}
+}
+
+func missingLockfileFinding(manifestPath, ecosystem, lockfile string) findings.Finding {
+	return findings.Finding{
+		RuleID:     "LOCK-002",
+		Severity:   findings.SeverityMedium,
+		Confidence: findings.ConfidenceHigh,
+		Location: findings.Location{
+			FilePath:  manifestPath,
+			StartLine: 1,
+		},
+		Message: fmt.Sprintf("%s has dependencies but no %s next to it", manifestPath, lockfile),
+		Metadata: map[string]string{
+			"ecosystem": ecosystem,
+			"lockfile":  lockfile,
+		},
+	}
+}
+
+// lineOf returns the 1-based line of the first occurrence of substr in
+// content, or 1 if it does not occur.
+func lineOf(content []byte, substr string) int {
+	i := bytes.Index(content, []byte(substr))
+	if i < 0 {
+		return 1
+	}
+	return bytes.Count(content[:i], []byte("\n")) + 1
+}
@@ -0,0 +1,172 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// writeArtifacts writes files (slash-separated path -> content) under a
+// temporary directory and returns them as artifacts.
+func writeArtifacts(t *testing.T, files map[string]string) []discovery.Artifact {
+	t.Helper()
+	dir := t.TempDir()
+	var artifacts []discovery.Artifact
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, discovery.Artifact{Path: rel, AbsPath: p})
+	}
+	return artifacts
+}
+
+func findingsByRule(fs []findings.Finding) map[string][]findings.Finding {
+	out := make(map[string][]findings.Finding)
+	for _, f := range fs {
+		out[f.RuleID] = append(out[f.RuleID], f)
+	}
+	return out
+}
+
+func TestLockfileDrift_Npm(t *testing.T) {
+	artifacts := writeArtifacts(t, map[string]string{
+		"package.json": `{
+  "name": "app",
+  "dependencies": {
+    "express": "^4.18.0",
+    "lodash": "^4.17.21",
+    "left-pad": "1.3.0",
+    "local-lib": "file:../lib"
+  },
+  "devDependencies": {
+    "jest": "^29.0.0"
+  }
+}`,
+		"package-lock.json": `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app"},
+    "node_modules/express": {"version": "4.18.2"},
+    "node_modules/lodash": {"version": "4.17.15"},
+    "node_modules/local-lib": {"version": "0.0.1"},
+    "node_modules/jest": {"version": "29.7.0"}
+  }
+}`,
+		// Workspace package locked by the root lockfile.
+		"packages/ui/package.json": `{"dependencies": {"react": "^18.0.0"}}`,
+		// No lockfile anywhere above it.
+		"tools/package.json": `{}`,
+		// Dependencies of installed packages are not manifests of the project.
+		"node_modules/express/package.json": `{"dependencies": {"debug": "2.6.9"}}`,
+	})
+
+	got := findingsByRule(lockfileDrift(artifacts))
+	if len(got) != 1 || len(got["LOCK-001"]) != 2 {
+		t.Fatalf("expected 2 LOCK-001 findings, got %+v", got)
+	}
+	leftPad, lodash := got["LOCK-001"][0], got["LOCK-001"][1]
+	if lodash.Metadata["package"] != "lodash" || lodash.Metadata["constraint"] != "^4.17.21" || lodash.Metadata["locked_version"] != "4.17.15" {
+		t.Errorf("unexpected lodash finding: %+v", lodash)
+	}
+	if lodash.Location.FilePath != "package.json" || lodash.Location.StartLine != 5 || lodash.Severity != findings.SeverityMedium {
+		t.Errorf("unexpected lodash location or severity: %+v", lodash)
+	}
+	if leftPad.Metadata["package"] != "left-pad" || leftPad.Metadata["locked_version"] != "" {
+		t.Errorf("expected left-pad to be reported as missing from the lockfile, got %+v", leftPad)
+	}
+}
+
+func TestLockfileDrift_MissingLockfile(t *testing.T) {
+	artifacts := writeArtifacts(t, map[string]string{
+		"web/package.json":  `{"dependencies": {"react": "^18.0.0"}}`,
+		"svc/go.mod":        "module example.com/svc\n\ngo 1.22\n\nrequire golang.org/x/text v0.14.0\n",
+		"lib/go.mod":        "module example.com/lib\n\ngo 1.22\n",
+		"yarn/package.json": `{"dependencies": {"react": "^18.0.0"}}`,
+		"yarn/yarn.lock":    "",
+	})
+
+	got := findingsByRule(lockfileDrift(artifacts))
+	if len(got) != 1 || len(got["LOCK-002"]) != 2 {
+		t.Fatalf("expected 2 LOCK-002 findings, got %+v", got)
+	}
+	files := map[string]string{}
+	for _, f := range got["LOCK-002"] {
+		files[f.Location.FilePath] = f.Metadata["lockfile"]
+	}
+	if files["web/package.json"] != "package-lock.json" || files["svc/go.mod"] != "go.sum" {
+		t.Errorf("unexpected LOCK-002 findings: %v", files)
+	}
+}
+
+func TestLockfileDrift_Go(t *testing.T) {
+	artifacts := writeArtifacts(t, map[string]string{
+		"go.mod": `module example.com/app
+
+go 1.22
+
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/text v0.14.0 // indirect
+	example.com/forked v1.0.0
+)
+
+require gopkg.in/yaml.v3 v3.0.1
+
+replace example.com/forked => ../forked
+`,
+		"go.sum": `github.com/google/uuid v1.5.0 h1:old=
+github.com/google/uuid v1.5.0/go.mod h1:old=
+github.com/google/uuid v1.6.0 h1:new=
+github.com/google/uuid v1.6.0/go.mod h1:new=
+github.com/stretchr/testify v1.9.0 h1:test=
+github.com/stretchr/testify v1.9.0/go.mod h1:test=
+golang.org/x/text v0.13.0/go.mod h1:older=
+golang.org/x/text v0.14.0/go.mod h1:text=
+`,
+	})
+
+	got := findingsByRule(lockfileDrift(artifacts))
+	if len(got["LOCK-001"]) != 1 || len(got["LOCK-003"]) != 1 || len(got) != 2 {
+		t.Fatalf("expected one LOCK-001 and one LOCK-003 finding, got %+v", got)
+	}
+	missing := got["LOCK-001"][0]
+	if missing.Metadata["package"] != "gopkg.in/yaml.v3" || missing.Location.FilePath != "go.mod" || missing.Location.StartLine != 11 {
+		t.Errorf("expected missing go.sum line for yaml.v3, got %+v", missing)
+	}
+	stale := got["LOCK-003"][0]
+	if stale.Metadata["package"] != "github.com/google/uuid" || stale.Metadata["version"] != "v1.5.0" || stale.Location.FilePath != "go.sum" || stale.Location.StartLine != 1 {
+		t.Errorf("expected stale uuid v1.5.0 entry, got %+v", stale)
+	}
+}
+
+func TestScanArtifacts_LockfileDrift(t *testing.T) {
+	artifacts := writeArtifacts(t, map[string]string{
+		"package.json":      `{"dependencies": {"lodash": "^4.17.21"}}`,
+		"package-lock.json": `{"packages": {"node_modules/lodash": {"version": "4.17.20"}}}`,
+	})
+	for i := range artifacts {
+		if artifacts[i].Path == "package-lock.json" {
+			artifacts[i].Type = discovery.Lockfile
+		}
+	}
+
+	_, fs, err := NewAnalyzer(WithOSVDisabled()).ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatalf("ScanArtifacts returned error: %v", err)
+	}
+	var found bool
+	for _, f := range fs.Findings() {
+		found = found || f.RuleID == "LOCK-001"
+	}
+	if !found {
+		t.Error("expected a LOCK-001 finding")
+	}
+}
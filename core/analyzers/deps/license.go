@@ -5,8 +5,9 @@
 // detection is best-effort: parse failures are silently ignored so that a
 // missing or malformed manifest never causes the scan to fail.
 //
-// CheckLicenses evaluates packages against a deny/allow license policy and
-// returns findings for violations.
+// CheckLicensePolicy evaluates packages against a deny/allow/warn license
+// policy and returns findings for violations and unknown licenses;
+// CheckLicenseChanges reports licenses that changed since the last scan.
 package deps
 
 import (
@@ -65,75 +66,137 @@ func DetectLicenses(basePath string, inventory *PackageInventory) {
 	}
 }
 
-// CheckLicenses evaluates packages against a license policy and returns
-// findings for violations. If deny is specified, any package whose license
-// matches (case-insensitive prefix) any entry in deny produces a finding.
-// If allow is specified, any package whose license does NOT match any entry
-// in allow produces a finding. Packages without detected licenses are
-// skipped to avoid false positives.
-func CheckLicenses(inventory *PackageInventory, deny, allow []string) []findings.Finding {
-	if len(deny) == 0 && len(allow) == 0 {
+// License policy rule IDs.
+const (
+	licUnknownRuleID = "LIC-001"
+	licDeniedRuleID  = "LIC-002"
+	licChangedRuleID = "LIC-003"
+)
+
+// CheckLicensePolicy evaluates packages against policy and returns
+// findings. License strings are read as SPDX expressions, and a package
+// passes when any one of its licensing options passes: every license in
+// the option is in the allow list (if there is one) and none is in the deny
+// list. Packages with no passing option are reported as LIC-002. Packages
+// whose only passing options include a license from the warn list are
+// reported as LIC-002 at medium severity. Packages without a detected
+// license are reported as LIC-001. paths[i], when present, is the lockfile
+// of the i-th package.
+func CheckLicensePolicy(inventory *PackageInventory, policy LicensePolicy, paths []string) []findings.Finding {
+	if len(policy.Deny) == 0 && len(policy.Allow) == 0 && len(policy.Warn) == 0 {
 		return nil
 	}
 
-	pkgs := inventory.Packages()
 	var result []findings.Finding
-
-	for _, pkg := range pkgs {
-		if pkg.License == "" {
-			continue
-		}
-
-		if len(deny) > 0 && matchesLicenseList(pkg.License, deny) {
-			result = append(result, findings.Finding{
-				RuleID:     "LIC-001",
-				Severity:   findings.SeverityHigh,
+	for i, pkg := range inventory.Packages() {
+		path := ""
+		if i < len(paths) {
+			path = paths[i]
+		}
+		finding := func(ruleID string, sev findings.Severity, verdict, msg string) findings.Finding {
+			return findings.Finding{
+				RuleID:     ruleID,
+				Severity:   sev,
 				Confidence: findings.ConfidenceHigh,
 				Location: findings.Location{
-					FilePath:  "",
+					FilePath:  path,
 					StartLine: 1,
 				},
-				Message: fmt.Sprintf("Dependency %s@%s uses denied license %q", pkg.Name, pkg.Version, pkg.License),
+				Message: msg,
 				Metadata: map[string]string{
-					"package":   pkg.Name,
-					"version":   pkg.Version,
-					"ecosystem": pkg.Ecosystem,
-					"license":   pkg.License,
+					"package":        pkg.Name,
+					"version":        pkg.Version,
+					"ecosystem":      pkg.Ecosystem,
+					"license":        pkg.License,
+					"license_policy": verdict,
 				},
-			})
+			}
 		}
 
-		if len(allow) > 0 && !matchesLicenseList(pkg.License, allow) {
-			result = append(result, findings.Finding{
-				RuleID:     "LIC-001",
-				Severity:   findings.SeverityHigh,
-				Confidence: findings.ConfidenceHigh,
-				Location: findings.Location{
-					FilePath:  "",
-					StartLine: 1,
-				},
-				Message: fmt.Sprintf("Dependency %s@%s uses license %q which is not in the allow list", pkg.Name, pkg.Version, pkg.License),
-				Metadata: map[string]string{
-					"package":   pkg.Name,
-					"version":   pkg.Version,
-					"ecosystem": pkg.Ecosystem,
-					"license":   pkg.License,
-				},
-			})
+		if !licenseKnown(pkg.License) {
+			f := finding(licUnknownRuleID, findings.SeverityLow, "unknown",
+				fmt.Sprintf("Dependency %s@%s has no detected license", pkg.Name, pkg.Version))
+			f.Confidence = findings.ConfidenceMedium
+			result = append(result, f)
+			continue
+		}
+
+		verdict := evaluateLicense(pkg.License, policy)
+		switch verdict {
+		case "deny":
+			result = append(result, finding(licDeniedRuleID, findings.SeverityHigh, verdict,
+				fmt.Sprintf("Dependency %s@%s uses denied license %q", pkg.Name, pkg.Version, pkg.License)))
+		case "allow":
+			result = append(result, finding(licDeniedRuleID, findings.SeverityHigh, verdict,
+				fmt.Sprintf("Dependency %s@%s uses license %q which is not in the allow list", pkg.Name, pkg.Version, pkg.License)))
+		case "warn":
+			result = append(result, finding(licDeniedRuleID, findings.SeverityMedium, verdict,
+				fmt.Sprintf("Dependency %s@%s uses license %q which the license policy flags for review", pkg.Name, pkg.Version, pkg.License)))
 		}
 	}
+	return result
+}
 
+// CheckLicenses evaluates packages against deny and allow lists, like
+// CheckLicensePolicy, but skips packages without a detected license.
+func CheckLicenses(inventory *PackageInventory, deny, allow []string) []findings.Finding {
+	var result []findings.Finding
+	for _, f := range CheckLicensePolicy(inventory, LicensePolicy{Deny: deny, Allow: allow}, nil) {
+		if f.RuleID != licUnknownRuleID {
+			result = append(result, f)
+		}
+	}
 	return result
 }
 
+// evaluateLicense returns "" when one of the licensing options of the
+// expression license passes policy, "warn" when the passing options all
+// include a license from the warn list, and otherwise "deny" when every
+// option includes a denied license or "allow" when some option is only
+// missing from the allow list.
+func evaluateLicense(license string, policy LicensePolicy) string {
+	verdict := "deny"
+	for _, option := range licenseOptions(license) {
+		denied, allowed, warned := false, true, false
+		for _, l := range option {
+			denied = denied || matchesLicenseList(l, policy.Deny)
+			allowed = allowed && (len(policy.Allow) == 0 || matchesLicenseList(l, policy.Allow))
+			warned = warned || matchesLicenseList(l, policy.Warn)
+		}
+		switch {
+		case denied:
+		case !allowed:
+			if verdict == "deny" {
+				verdict = "allow"
+			}
+		case warned:
+			verdict = "warn"
+		default:
+			return ""
+		}
+	}
+	return verdict
+}
+
+// licenseKnown reports whether license names a license rather than being
+// empty or an SPDX placeholder for an unknown one.
+func licenseKnown(license string) bool {
+	switch strings.ToUpper(strings.TrimSpace(license)) {
+	case "", "NOASSERTION", "UNKNOWN":
+		return false
+	}
+	return true
+}
+
 // matchesLicenseList checks if the given license matches any entry in the list
 // using case-insensitive prefix matching. For example, "GPL" matches
-// "GPL-2.0", "GPL-3.0-only", etc.
+// "GPL-2.0", "GPL-3.0-only", etc. A trailing "*" on an entry is ignored, so
+// "BSD-*" matches "BSD-3-Clause".
 func matchesLicenseList(license string, list []string) bool {
 	lower := strings.ToLower(license)
 	for _, entry := range list {
-		entryLower := strings.ToLower(entry)
-		if strings.HasPrefix(lower, entryLower) {
+		entryLower := strings.ToLower(strings.TrimSuffix(entry, "*"))
+		if entryLower != "" && strings.HasPrefix(lower, entryLower) {
 			return true
 		}
 	}
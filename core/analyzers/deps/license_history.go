@@ -0,0 +1,106 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/atomicfile"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// licenseHistory is the content of the license history file: the license
+// last detected for each package, keyed by licenseKey. The version is not
+// part of the key, so an upgrade that relicenses a package is reported.
+type licenseHistory struct {
+	Licenses map[string]string `json:"licenses"`
+}
+
+// licenseKey identifies a package in the license history.
+func licenseKey(p Package) string {
+	return p.Ecosystem + "/" + p.Name
+}
+
+// loadLicenseHistory reads the license history at path. A missing file
+// yields an empty history.
+func loadLicenseHistory(path string) (map[string]string, error) {
+	var h licenseHistory
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading license history %s: %w", path, err)
+	default:
+		if err := json.Unmarshal(data, &h); err != nil {
+			return nil, fmt.Errorf("parsing license history %s: %w", path, err)
+		}
+	}
+	if h.Licenses == nil {
+		h.Licenses = make(map[string]string)
+	}
+	return h.Licenses, nil
+}
+
+// saveLicenseHistory writes the licenses detected in inventory to path,
+// replacing the file atomically. Packages whose license is unknown in this
+// scan keep their entry from previous, so that a scan without manifests
+// does not hide a later change.
+func saveLicenseHistory(path string, previous map[string]string, inventory *PackageInventory) error {
+	h := licenseHistory{Licenses: make(map[string]string)}
+	for _, pkg := range inventory.Packages() {
+		key := licenseKey(pkg)
+		if licenseKnown(pkg.License) {
+			h.Licenses[key] = pkg.License
+		} else if old, ok := previous[key]; ok {
+			h.Licenses[key] = old
+		}
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// CheckLicenseChanges returns a LIC-003 finding for each package whose
+// detected license differs from its license in previous, which maps
+// "ecosystem/name" to the license of an earlier scan. Packages without a
+// detected license in either scan are skipped. paths[i], when present, is
+// the lockfile of the i-th package.
+func CheckLicenseChanges(inventory *PackageInventory, previous map[string]string, paths []string) []findings.Finding {
+	var result []findings.Finding
+	for i, pkg := range inventory.Packages() {
+		old, ok := previous[licenseKey(pkg)]
+		if !ok || !licenseKnown(pkg.License) || strings.EqualFold(strings.TrimSpace(old), strings.TrimSpace(pkg.License)) {
+			continue
+		}
+		path := ""
+		if i < len(paths) {
+			path = paths[i]
+		}
+		result = append(result, findings.Finding{
+			RuleID:     licChangedRuleID,
+			Severity:   findings.SeverityMedium,
+			Confidence: findings.ConfidenceHigh,
+			Location: findings.Location{
+				FilePath:  path,
+				StartLine: 1,
+			},
+			Message: fmt.Sprintf("Dependency %s@%s changed license from %q to %q since the last scan", pkg.Name, pkg.Version, old, pkg.License),
+			Metadata: map[string]string{
+				"package":          pkg.Name,
+				"version":          pkg.Version,
+				"ecosystem":        pkg.Ecosystem,
+				"license":          pkg.License,
+				"previous_license": old,
+			},
+		})
+	}
+	return result
+}
@@ -21,8 +21,8 @@ func TestCheckLicenses_DenyList_Match(t *testing.T) {
 	if len(fs) != 1 {
 		t.Fatalf("expected 1 finding, got %d", len(fs))
 	}
-	if fs[0].RuleID != "LIC-001" {
-		t.Errorf("expected rule ID LIC-001, got %s", fs[0].RuleID)
+	if fs[0].RuleID != "LIC-002" {
+		t.Errorf("expected rule ID LIC-002, got %s", fs[0].RuleID)
 	}
 	if fs[0].Severity != findings.SeverityHigh {
 		t.Errorf("expected severity high, got %s", fs[0].Severity)
@@ -211,6 +211,114 @@ func TestCheckLicenses_AllowList_PrefixMatching(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// CheckLicensePolicy
+// ---------------------------------------------------------------------------
+
+func TestCheckLicensePolicy(t *testing.T) {
+	inv := &PackageInventory{}
+	inv.Add(Package{Name: "dual", Version: "1.0.0", Ecosystem: "cargo", License: "MIT OR GPL-3.0"})
+	inv.Add(Package{Name: "legacy-dual", Version: "1.0.0", Ecosystem: "cargo", License: "MIT/Apache-2.0"})
+	inv.Add(Package{Name: "gpl-only", Version: "1.0.0", Ecosystem: "npm", License: "GPL-3.0-only"})
+	inv.Add(Package{Name: "combined", Version: "1.0.0", Ecosystem: "npm", License: "(MIT AND GPL-3.0) OR ISC"})
+	inv.Add(Package{Name: "mpl", Version: "1.0.0", Ecosystem: "npm", License: "MPL-2.0"})
+	inv.Add(Package{Name: "mpl-or-mit", Version: "1.0.0", Ecosystem: "npm", License: "MPL-2.0 OR MIT"})
+	inv.Add(Package{Name: "unknown", Version: "1.0.0", Ecosystem: "npm"})
+	inv.Add(Package{Name: "noassertion", Version: "1.0.0", Ecosystem: "npm", License: "NOASSERTION"})
+
+	policy := LicensePolicy{
+		Deny:  []string{"GPL-3.0"},
+		Allow: []string{"MIT", "Apache-2.0", "GPL-3.0", "MPL-2.0"},
+		Warn:  []string{"MPL-2.0"},
+	}
+	paths := []string{"Cargo.lock", "Cargo.lock", "package-lock.json"}
+	got := make(map[string]findings.Finding)
+	for _, f := range CheckLicensePolicy(inv, policy, paths) {
+		got[f.Metadata["package"]] = f
+	}
+
+	want := map[string]struct {
+		rule, verdict string
+		severity      findings.Severity
+	}{
+		"gpl-only":    {"LIC-002", "deny", findings.SeverityHigh},
+		"combined":    {"LIC-002", "allow", findings.SeverityHigh},
+		"mpl":         {"LIC-002", "warn", findings.SeverityMedium},
+		"unknown":     {"LIC-001", "unknown", findings.SeverityLow},
+		"noassertion": {"LIC-001", "unknown", findings.SeverityLow},
+	}
+	if len(got) != len(want) {
+		t.Errorf("got findings for %d packages, want %d: %v", len(got), len(want), got)
+	}
+	for name, w := range want {
+		f, ok := got[name]
+		if !ok {
+			t.Errorf("no finding for %s", name)
+			continue
+		}
+		if f.RuleID != w.rule || f.Metadata["license_policy"] != w.verdict || f.Severity != w.severity {
+			t.Errorf("%s: got %s/%s/%s, want %s/%s/%s", name, f.RuleID, f.Metadata["license_policy"], f.Severity, w.rule, w.verdict, w.severity)
+		}
+	}
+	if f := got["gpl-only"]; f.Location.FilePath != "package-lock.json" {
+		t.Errorf("gpl-only location = %q, want package-lock.json", f.Location.FilePath)
+	}
+}
+
+func TestCheckLicensePolicy_EmptyPolicy(t *testing.T) {
+	inv := &PackageInventory{}
+	inv.Add(Package{Name: "unknown", Version: "1.0.0", Ecosystem: "npm"})
+	if fs := CheckLicensePolicy(inv, LicensePolicy{}, nil); len(fs) != 0 {
+		t.Fatalf("expected 0 findings with empty policy, got %d", len(fs))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// License history
+// ---------------------------------------------------------------------------
+
+func TestLicenseHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".nox", "license-history.json")
+	previous, err := loadLicenseHistory(path)
+	if err != nil || len(previous) != 0 {
+		t.Fatalf("loading a missing history = %v, %v", previous, err)
+	}
+
+	first := &PackageInventory{}
+	first.Add(Package{Name: "relicensed", Version: "1.0.0", Ecosystem: "npm", License: "Apache-2.0"})
+	first.Add(Package{Name: "stable", Version: "1.0.0", Ecosystem: "npm", License: "MIT"})
+	first.Add(Package{Name: "sometimes", Version: "1.0.0", Ecosystem: "npm", License: "ISC"})
+	if fs := CheckLicenseChanges(first, previous, nil); len(fs) != 0 {
+		t.Fatalf("first scan reported %d changes", len(fs))
+	}
+	if err := saveLicenseHistory(path, previous, first); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &PackageInventory{}
+	second.Add(Package{Name: "relicensed", Version: "2.0.0", Ecosystem: "npm", License: "BUSL-1.1"})
+	second.Add(Package{Name: "stable", Version: "1.1.0", Ecosystem: "npm", License: "mit"})
+	second.Add(Package{Name: "sometimes", Version: "1.0.0", Ecosystem: "npm"})
+	previous, err = loadLicenseHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := CheckLicenseChanges(second, previous, []string{"package-lock.json"})
+	if len(fs) != 1 {
+		t.Fatalf("expected 1 change, got %d: %v", len(fs), fs)
+	}
+	if f := fs[0]; f.RuleID != "LIC-003" || f.Metadata["previous_license"] != "Apache-2.0" || f.Location.FilePath != "package-lock.json" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if err := saveLicenseHistory(path, previous, second); err != nil {
+		t.Fatal(err)
+	}
+	previous, _ = loadLicenseHistory(path)
+	if previous["npm/sometimes"] != "ISC" {
+		t.Errorf("a package without a detected license should keep its entry, got %v", previous)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // matchesLicenseList unit tests
 // ---------------------------------------------------------------------------
@@ -228,6 +336,8 @@ func TestMatchesLicenseList(t *testing.T) {
 		{"gpl-3.0", []string{"GPL"}, true},
 		{"MIT", []string{"MIT", "Apache-2.0"}, true},
 		{"ISC", []string{"MIT", "Apache-2.0"}, false},
+		{"BSD-3-Clause", []string{"BSD-*"}, true},
+		{"MIT", []string{""}, false},
 	}
 
 	for _, tt := range tests {
@@ -809,16 +919,21 @@ func TestWithLicensePolicy(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// LIC-001 rule registration
+// LIC-* rule registration
 // ---------------------------------------------------------------------------
 
-func TestRules_ContainsLIC001(t *testing.T) {
+func TestRules_ContainsLIC002(t *testing.T) {
 	a := NewAnalyzer(WithOSVDisabled())
 	rs := a.Rules()
 
-	rule, ok := rs.ByID("LIC-001")
+	for _, id := range []string{"LIC-001", "LIC-003"} {
+		if _, ok := rs.ByID(id); !ok {
+			t.Errorf("expected %s rule to be registered", id)
+		}
+	}
+	rule, ok := rs.ByID("LIC-002")
 	if !ok {
-		t.Fatal("expected LIC-001 rule to be registered")
+		t.Fatal("expected LIC-002 rule to be registered")
 	}
 	if rule.Description != "Dependency uses a restricted license" {
 		t.Errorf("unexpected description: %s", rule.Description)
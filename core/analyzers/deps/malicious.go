@@ -13,7 +13,7 @@ import (
 	"sync"
 )
 
-//go:embed data/popular_npm.txt data/popular_pypi.txt data/known_malicious.json
+//go:embed data/popular_npm.txt data/popular_pypi.txt data/known_malicious.json data/top_npm.txt data/top_pypi.txt
 var dataFS embed.FS
 
 // popularPackages holds lazy-loaded popular package names per ecosystem.
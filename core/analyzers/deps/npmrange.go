@@ -0,0 +1,257 @@
+package deps
+
+import (
+	"strconv"
+	"strings"
+)
+
+// npmVersion is a parsed semantic version. parts is the number of numeric
+// components given (0-3); wildcards such as "1.x" stop the count.
+type npmVersion struct {
+	major, minor, patch int
+	pre                 string
+	parts               int
+}
+
+// npmComparator is a single bound such as ">=1.2.3".
+type npmComparator struct {
+	op string
+	v  npmVersion
+}
+
+// npmRangeSatisfied reports whether version satisfies the npm range
+// constraint. known is false for specs that are not version ranges, such
+// as dist-tags, git URLs, file paths and workspace or alias protocols.
+// Prerelease versions are compared by precedence without npm's rule that
+// excludes them from ranges naming a different release.
+func npmRangeSatisfied(constraint, version string) (satisfied, known bool) {
+	v, ok := parseNpmVersion(strings.TrimPrefix(strings.TrimSpace(version), "v"))
+	if !ok || v.parts < 3 {
+		return false, false
+	}
+	for _, set := range strings.Split(constraint, "||") {
+		comparators, ok := parseNpmRangeSet(set)
+		if !ok {
+			return false, false
+		}
+		match := true
+		for _, c := range comparators {
+			if !c.matches(v) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// parseNpmRangeSet desugars one ||-separated part of a range into plain
+// comparators.
+func parseNpmRangeSet(set string) ([]npmComparator, bool) {
+	fields := strings.Fields(set)
+	if len(fields) == 3 && fields[1] == "-" {
+		lo, ok1 := parseNpmVersion(fields[0])
+		hi, ok2 := parseNpmVersion(fields[2])
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		out := []npmComparator{{">=", lo.floor()}}
+		if hi.parts == 3 {
+			return append(out, npmComparator{"<=", hi}), true
+		}
+		if hi.parts > 0 {
+			out = append(out, npmComparator{"<", hi.next()})
+		}
+		return out, true
+	}
+
+	var out []npmComparator
+	// Operators may be separated from their version: ">= 1.2.3".
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if strings.Trim(f, "<>=~^") == "" && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		cs, ok := desugarNpmComparator(f)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, cs...)
+	}
+	return out, true
+}
+
+func desugarNpmComparator(s string) ([]npmComparator, bool) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~>", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, s[len(prefix):]
+			break
+		}
+	}
+	v, ok := parseNpmVersion(strings.TrimPrefix(s, "v"))
+	if !ok {
+		return nil, false
+	}
+	if v.parts == 0 {
+		switch op {
+		case "<", ">":
+			// "<*" and ">*" match nothing.
+			return []npmComparator{{"<", npmVersion{}}}, true
+		}
+		return nil, true
+	}
+
+	lo := v.floor()
+	switch op {
+	case ">=":
+		return []npmComparator{{">=", lo}}, true
+	case ">":
+		if v.parts == 3 {
+			return []npmComparator{{">", v}}, true
+		}
+		return []npmComparator{{">=", v.next()}}, true
+	case "<=":
+		if v.parts == 3 {
+			return []npmComparator{{"<=", v}}, true
+		}
+		return []npmComparator{{"<", v.next()}}, true
+	case "<":
+		return []npmComparator{{"<", lo}}, true
+	case "~", "~>":
+		hi := npmVersion{major: v.major, minor: v.minor + 1, parts: 3}
+		if v.parts == 1 {
+			hi = npmVersion{major: v.major + 1, parts: 3}
+		}
+		return []npmComparator{{">=", lo}, {"<", hi}}, true
+	case "^":
+		var hi npmVersion
+		switch {
+		case v.major > 0 || v.parts == 1:
+			hi = npmVersion{major: v.major + 1, parts: 3}
+		case v.minor > 0 || v.parts == 2:
+			hi = npmVersion{minor: v.minor + 1, parts: 3}
+		default:
+			hi = npmVersion{patch: v.patch + 1, parts: 3}
+		}
+		return []npmComparator{{">=", lo}, {"<", hi}}, true
+	default:
+		if v.parts == 3 {
+			return []npmComparator{{"=", v}}, true
+		}
+		return []npmComparator{{">=", lo}, {"<", v.next()}}, true
+	}
+}
+
+// parseNpmVersion parses a full or partial version such as "1.2.3-rc.1",
+// "1.2", "1.x" or "*".
+func parseNpmVersion(s string) (npmVersion, bool) {
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, _ := strings.Cut(s, "-")
+	var v npmVersion
+	v.pre = pre
+	if core == "" {
+		return v, false
+	}
+	nums := strings.Split(core, ".")
+	if len(nums) > 3 {
+		return v, false
+	}
+	for i, n := range nums {
+		if n == "x" || n == "X" || n == "*" {
+			break
+		}
+		val, err := strconv.Atoi(n)
+		if err != nil || val < 0 {
+			return v, false
+		}
+		switch i {
+		case 0:
+			v.major = val
+		case 1:
+			v.minor = val
+		case 2:
+			v.patch = val
+		}
+		v.parts++
+	}
+	if v.parts < 3 {
+		v.pre = ""
+	}
+	return v, true
+}
+
+// floor returns the lowest version matching a partial version.
+func (v npmVersion) floor() npmVersion {
+	v.parts = 3
+	return v
+}
+
+// next returns the first version above every version matching a partial
+// version: 1.2 -> 1.3.0, 1 -> 2.0.0.
+func (v npmVersion) next() npmVersion {
+	switch v.parts {
+	case 1:
+		return npmVersion{major: v.major + 1, parts: 3}
+	case 2:
+		return npmVersion{major: v.major, minor: v.minor + 1, parts: 3}
+	}
+	return npmVersion{major: v.major, minor: v.minor, patch: v.patch + 1, parts: 3}
+}
+
+func (c npmComparator) matches(v npmVersion) bool {
+	cmp := compareNpmVersions(v, c.v)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp == 0
+	}
+}
+
+// compareNpmVersions orders versions by semver precedence.
+func compareNpmVersions(a, b npmVersion) int {
+	for _, d := range []int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			return d
+		}
+	}
+	switch {
+	case a.pre == b.pre:
+		return 0
+	case a.pre == "":
+		return 1
+	case b.pre == "":
+		return -1
+	}
+	ap, bp := strings.Split(a.pre, "."), strings.Split(b.pre, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return an - bn
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(ap) - len(bp)
+}
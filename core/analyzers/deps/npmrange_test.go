@@ -0,0 +1,46 @@
+package deps
+
+import "testing"
+
+func TestNpmRangeSatisfied(t *testing.T) {
+	tests := []struct {
+		constraint, version string
+		satisfied, known    bool
+	}{
+		{"^4.18.0", "4.18.2", true, true},
+		{"^4.18.0", "5.0.0", false, true},
+		{"^4.18.0", "4.17.9", false, true},
+		{"^0.2.3", "0.2.9", true, true},
+		{"^0.2.3", "0.3.0", false, true},
+		{"^0.0.3", "0.0.4", false, true},
+		{"~1.2.3", "1.2.9", true, true},
+		{"~1.2.3", "1.3.0", false, true},
+		{"~1", "1.9.0", true, true},
+		{"1.2.3", "1.2.3", true, true},
+		{"=1.2.3", "1.2.4", false, true},
+		{"1.2", "1.2.7", true, true},
+		{"1.x", "2.0.0", false, true},
+		{"*", "9.9.9", true, true},
+		{"", "1.0.0", true, true},
+		{">=1.2.0 <2", "1.9.9", true, true},
+		{">= 1.2.0 < 2", "2.0.0", false, true},
+		{">1.2", "1.2.9", false, true},
+		{"<=1.2", "1.2.9", true, true},
+		{"1.2.3 - 2.3", "2.3.9", true, true},
+		{"1.2.3 - 2.3.4", "2.3.5", false, true},
+		{"^1.0.0 || ^2.0.0", "2.5.0", true, true},
+		{"^1.0.0 || ^2.0.0", "3.0.0", false, true},
+		{"^1.0.0", "1.1.0-beta.1", true, true},
+		{"latest", "1.0.0", false, false},
+		{"github:user/repo", "1.0.0", false, false},
+		{"file:../lib", "1.0.0", false, false},
+		{"npm:other@^1.0.0", "1.0.0", false, false},
+		{"^1.0.0", "", false, false},
+	}
+	for _, tt := range tests {
+		satisfied, known := npmRangeSatisfied(tt.constraint, tt.version)
+		if satisfied != tt.satisfied || known != tt.known {
+			t.Errorf("npmRangeSatisfied(%q, %q) = %v, %v; want %v, %v", tt.constraint, tt.version, satisfied, known, tt.satisfied, tt.known)
+		}
+	}
+}
@@ -49,6 +49,64 @@ type osvVuln struct {
 	Severity []osvSeverity `json:"severity"`
 	Aliases  []string      `json:"aliases"`
 	Details  string        `json:"details"`
+	Affected []osvAffected `json:"affected"`
+}
+
+// osvAffected lists the affected version ranges of one package.
+type osvAffected struct {
+	Package osvPackage `json:"package"`
+	Ranges  []struct {
+		Events []struct {
+			Fixed string `json:"fixed"`
+		} `json:"events"`
+	} `json:"ranges"`
+}
+
+// fixedVersion returns the first version that v lists as fixed for the
+// package name, or "" when it lists none.
+func (v osvVuln) fixedVersion(name string) string {
+	for _, a := range v.Affected {
+		if a.Package.Name != "" && !strings.EqualFold(a.Package.Name, name) {
+			continue
+		}
+		for _, r := range a.Ranges {
+			for _, e := range r.Events {
+				if e.Fixed != "" {
+					return e.Fixed
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// cveID returns the CVE ID of v, its own ID or its first CVE alias, or ""
+// when it has none.
+func (v osvVuln) cveID() string {
+	for _, id := range append([]string{v.ID}, v.Aliases...) {
+		if strings.HasPrefix(id, "CVE-") {
+			return id
+		}
+	}
+	return ""
+}
+
+// vulnMetadata returns the metadata of a VULN-001 finding for v in pkg.
+func vulnMetadata(v osvVuln, pkg Package) map[string]string {
+	meta := map[string]string{
+		findings.MetaVulnID:    v.ID,
+		findings.MetaPackage:   pkg.Name,
+		findings.MetaVersion:   pkg.Version,
+		findings.MetaEcosystem: pkg.Ecosystem,
+		"aliases":              strings.Join(v.Aliases, ","),
+	}
+	if cve := v.cveID(); cve != "" {
+		meta[findings.MetaCVE] = cve
+	}
+	if fixed := v.fixedVersion(pkg.Name); fixed != "" {
+		meta[findings.MetaFixedVersion] = fixed
+	}
+	return meta
 }
 
 // osvSeverity holds a CVSS or other severity score.
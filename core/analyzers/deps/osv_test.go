@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -459,6 +460,92 @@ func TestScanArtifacts_WithOSV(t *testing.T) {
 	}
 }
 
+// TestScanArtifacts_JVMAndRustFixtures scans the multi-module fixture in
+// testdata/jvm-rust against a recorded OSV batch response.
+func TestScanArtifacts_JVMAndRustFixtures(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "osv-querybatch-jvm-rust.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recorded struct {
+		osvBatchRequest
+		osvBatchResponse
+	}
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	replay := make(map[osvQuery]osvBatchResult)
+	for i, q := range recorded.Queries {
+		replay[q] = recorded.Results[i]
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req osvBatchRequest
+		decodeJSON(t, r, &req)
+		results := make([]osvBatchResult, len(req.Queries))
+		for i, q := range req.Queries {
+			res, ok := replay[q]
+			if !ok {
+				t.Errorf("query not in the recording: %+v", q)
+			}
+			results[i] = res
+		}
+		encodeJSON(t, w, osvBatchResponse{Results: results})
+	}))
+	defer srv.Close()
+
+	artifacts, err := discovery.NewWalker(filepath.Join("testdata", "jvm-rust")).Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer := NewAnalyzer(WithOSVBaseURL(srv.URL), WithHTTPClient(srv.Client()))
+	inventory, fs, err := analyzer.ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatalf("ScanArtifacts returned error: %v", err)
+	}
+
+	var got []string
+	for _, p := range inventory.Packages() {
+		got = append(got, p.Ecosystem+":"+p.Name+"@"+p.Version)
+	}
+	sort.Strings(got)
+	want := []string{
+		"cargo:demo@0.1.0",
+		"cargo:libc@0.2.150",
+		"cargo:time@0.1.43",
+		"gradle:com.google.guava:failureaccess@1.0.1",
+		"gradle:com.google.guava:guava@29.0-jre",
+		"gradle:org.apache.logging.log4j:log4j-api@2.14.1",
+		"gradle:org.apache.logging.log4j:log4j-core@2.14.1",
+		"maven:com.example:model@1.4.0",
+		"maven:org.apache.commons:commons-text@1.9",
+		"maven:org.slf4j:slf4j-api@1.7.36",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("inventory =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	vulnPaths := make(map[string]string)
+	for _, f := range fs.Findings() {
+		if f.RuleID == "VULN-001" {
+			vulnPaths[f.Metadata["vuln_id"]] = filepath.ToSlash(f.Location.FilePath)
+		}
+	}
+	wantPaths := map[string]string{
+		"RUSTSEC-2020-0071":   "Cargo.lock",
+		"GHSA-599f-7c49-w659": "api/pom.xml",
+		"GHSA-jfh8-c2jp-5v3q": "worker/gradle.lockfile",
+		"GHSA-5mg8-w23w-74h3": "legacy/gradle/dependency-locks/compileClasspath.lockfile",
+	}
+	if len(vulnPaths) != len(wantPaths) {
+		t.Errorf("vulnerabilities = %v, want %v", vulnPaths, wantPaths)
+	}
+	for id, path := range wantPaths {
+		if vulnPaths[id] != path {
+			t.Errorf("%s reported in %q, want %q", id, vulnPaths[id], path)
+		}
+	}
+}
+
 func TestScanArtifacts_OSVDisabled(t *testing.T) {
 	// Start a server that should never be called.
 	var called atomic.Bool
@@ -583,6 +670,34 @@ func TestScanArtifacts_VulnerabilityMetadata(t *testing.T) {
 	}
 }
 
+func TestVulnMetadata(t *testing.T) {
+	var v osvVuln
+	err := json.Unmarshal([]byte(`{
+  "id": "GHSA-35jh-r3h4-6jhm",
+  "aliases": ["CVE-2021-23337"],
+  "affected": [
+    {"package": {"name": "lodash-es", "ecosystem": "npm"}, "ranges": [{"events": [{"introduced": "0"}, {"fixed": "4.17.22"}]}]},
+    {"package": {"name": "lodash", "ecosystem": "npm"}, "ranges": [{"events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]}
+  ]
+}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := vulnMetadata(v, Package{Name: "lodash", Version: "4.17.20", Ecosystem: "npm"})
+	if meta[findings.MetaCVE] != "CVE-2021-23337" || meta[findings.MetaFixedVersion] != "4.17.21" || meta[findings.MetaVulnID] != "GHSA-35jh-r3h4-6jhm" {
+		t.Errorf("metadata = %v", meta)
+	}
+
+	// Without a CVE alias or a fixed version, the keys are left out.
+	meta = vulnMetadata(osvVuln{ID: "PYSEC-2023-001"}, Package{Name: "django", Version: "4.2.1", Ecosystem: "pypi"})
+	if _, ok := meta[findings.MetaCVE]; ok {
+		t.Errorf("unexpected cve in %v", meta)
+	}
+	if _, ok := meta[findings.MetaFixedVersion]; ok {
+		t.Errorf("unexpected fixed_version in %v", meta)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Rules tests
 // ---------------------------------------------------------------------------
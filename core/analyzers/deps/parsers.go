@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -68,7 +71,9 @@ func parseGoSum(content []byte) ([]Package, error) {
 // package uses the empty string "" as its key.
 type packageLockJSON struct {
 	Packages map[string]struct {
-		Version string `json:"version"`
+		Version          string `json:"version"`
+		Resolved         string `json:"resolved"`
+		HasInstallScript bool   `json:"hasInstallScript"`
 	} `json:"packages"`
 }
 
@@ -100,6 +105,7 @@ func parsePackageLockJSON(content []byte) ([]Package, error) {
 			Name:      name,
 			Version:   info.Version,
 			Ecosystem: "npm",
+			Resolved:  info.Resolved,
 		})
 	}
 
@@ -290,43 +296,49 @@ func parseGemfileLock(content []byte) ([]Package, error) {
 //	[[package]]
 //	name = "serde"
 //	version = "1.0.193"
+//	source = "registry+https://github.com/rust-lang/crates.io-index"
+//
+// Crates from git repositories and other registries are skipped, since they
+// are not on crates.io. Workspace members have no source and are kept.
 func parseCargoLock(content []byte) ([]Package, error) {
 	var pkgs []Package
-	var name, version string
+	var name, version, source string
+	inPackage := false
+
+	emit := func() {
+		if inPackage && name != "" && version != "" && isCratesIOSource(source) {
+			pkgs = append(pkgs, Package{
+				Name:      name,
+				Version:   version,
+				Ecosystem: "cargo",
+			})
+		}
+		name, version, source = "", "", ""
+	}
 
 	scanner := bufio.NewScanner(bytes.NewReader(content))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
-		if line == "[[package]]" {
-			// Emit previous package if complete.
-			if name != "" && version != "" {
-				pkgs = append(pkgs, Package{
-					Name:      name,
-					Version:   version,
-					Ecosystem: "cargo",
-				})
-			}
-			name = ""
-			version = ""
+		if strings.HasPrefix(line, "[") {
+			// Emit the previous package on any new table header.
+			emit()
+			inPackage = line == "[[package]]"
 			continue
 		}
 
-		if strings.HasPrefix(line, "name = ") {
+		switch {
+		case strings.HasPrefix(line, "name = "):
 			name = unquoteTOML(strings.TrimPrefix(line, "name = "))
-		} else if strings.HasPrefix(line, "version = ") {
+		case strings.HasPrefix(line, "version = "):
 			version = unquoteTOML(strings.TrimPrefix(line, "version = "))
+		case strings.HasPrefix(line, "source = "):
+			source = unquoteTOML(strings.TrimPrefix(line, "source = "))
 		}
 	}
 
 	// Emit the last package.
-	if name != "" && version != "" {
-		pkgs = append(pkgs, Package{
-			Name:      name,
-			Version:   version,
-			Ecosystem: "cargo",
-		})
-	}
+	emit()
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("scanning Cargo.lock: %w", err)
@@ -335,6 +347,77 @@ func parseCargoLock(content []byte) ([]Package, error) {
 	return pkgs, nil
 }
 
+// parsePoetryLock extracts packages from a Python poetry.lock file. Poetry
+// records a [package.source] table only for packages installed from a
+// source other than PyPI, so packages without one are recorded as resolved
+// from PyPI.
+func parsePoetryLock(content []byte) ([]Package, error) {
+	var pkgs []Package
+	var cur *Package
+	// table is the TOML table of the current line: "package" for the
+	// [[package]] entry itself, "package.source" for its source.
+	table := ""
+
+	emit := func() {
+		if cur != nil && cur.Name != "" && cur.Version != "" {
+			if cur.Resolved == "" {
+				cur.Resolved = pypiSimpleURL
+			}
+			pkgs = append(pkgs, *cur)
+		}
+		cur = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line, "[]")
+			switch {
+			case line == "[[package]]":
+				emit()
+				cur = &Package{Ecosystem: "pypi"}
+			case !strings.HasPrefix(table, "package."):
+				emit()
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		switch {
+		case table == "package" && key == "name":
+			cur.Name = unquoteTOML(value)
+		case table == "package" && key == "version":
+			cur.Version = unquoteTOML(value)
+		case table == "package.source" && key == "url":
+			cur.Resolved = unquoteTOML(value)
+		}
+	}
+	emit()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning poetry.lock: %w", err)
+	}
+	return pkgs, nil
+}
+
+// isCratesIOSource reports whether a Cargo.lock source refers to crates.io,
+// through either the git or the sparse index, or is empty.
+func isCratesIOSource(source string) bool {
+	switch source {
+	case "", "registry+https://github.com/rust-lang/crates.io-index", "sparse+https://index.crates.io/":
+		return true
+	}
+	return false
+}
+
 // unquoteTOML strips surrounding double quotes from a TOML value.
 func unquoteTOML(s string) string {
 	s = strings.TrimSpace(s)
@@ -344,70 +427,181 @@ func unquoteTOML(s string) string {
 	return s
 }
 
+// pomDependency is a <dependency> element of a Maven pom.xml file.
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+// pomProperty is a single element of a pom.xml <properties> block.
+type pomProperty struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
 // pomXML is the minimal structure needed to extract dependencies from a Maven
 // pom.xml file.
 type pomXML struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupID      string `xml:"groupId"`
+		ArtifactID   string `xml:"artifactId"`
+		Version      string `xml:"version"`
+		RelativePath string `xml:"relativePath"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []pomProperty `xml:",any"`
+	} `xml:"properties"`
 	Dependencies struct {
-		Dependency []struct {
-			GroupID    string `xml:"groupId"`
-			ArtifactID string `xml:"artifactId"`
-			Version    string `xml:"version"`
-		} `xml:"dependency"`
+		Dependency []pomDependency `xml:"dependency"`
 	} `xml:"dependencies"`
 	DependencyManagement struct {
 		Dependencies struct {
-			Dependency []struct {
-				GroupID    string `xml:"groupId"`
-				ArtifactID string `xml:"artifactId"`
-				Version    string `xml:"version"`
-			} `xml:"dependency"`
+			Dependency []pomDependency `xml:"dependency"`
 		} `xml:"dependencies"`
 	} `xml:"dependencyManagement"`
 }
 
-// parsePomXML extracts dependencies from a Maven pom.xml file.
-// Dependencies are named as "groupId:artifactId".
+// maxPomParents bounds how many parent POMs are followed.
+const maxPomParents = 5
+
+// parsePomXML extracts the direct dependencies of a Maven pom.xml file.
+// Dependencies are named as "groupId:artifactId". Versions may come from the
+// file's <dependencyManagement> and may reference its literal <properties>
+// and ${project.version}; dependencies whose version cannot be resolved,
+// and version ranges, are skipped.
 func parsePomXML(content []byte) ([]Package, error) {
 	var pom pomXML
 	if err := xml.Unmarshal(content, &pom); err != nil {
 		return nil, fmt.Errorf("parsing pom.xml: %w", err)
 	}
+	return resolvePom(&pom, nil), nil
+}
 
-	type key struct{ name, ver string }
-	seen := make(map[key]struct{})
-	var pkgs []Package
+// parsePomXMLFile is like parsePomXML but also reads the parent POMs of the
+// module at path (<relativePath>, by default ../pom.xml) for properties and
+// managed versions, so each module of a multi-module project lists the
+// dependencies it declares.
+func parsePomXMLFile(path string, content []byte) ([]Package, error) {
+	var pom pomXML
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return nil, fmt.Errorf("parsing pom.xml: %w", err)
+	}
 
-	addDeps := func(deps []struct {
-		GroupID    string `xml:"groupId"`
-		ArtifactID string `xml:"artifactId"`
-		Version    string `xml:"version"`
-	}) {
-		for _, d := range deps {
-			if d.GroupID == "" || d.ArtifactID == "" || d.Version == "" {
-				continue
-			}
-			// Skip Maven property references like ${project.version}.
-			if strings.HasPrefix(d.Version, "${") {
-				continue
-			}
-			name := d.GroupID + ":" + d.ArtifactID
-			k := key{name, d.Version}
-			if _, exists := seen[k]; exists {
-				continue
+	var parents []*pomXML
+	child, dir := &pom, filepath.Dir(path)
+	for range maxPomParents {
+		if child.Parent.ArtifactID == "" {
+			break
+		}
+		rel := child.Parent.RelativePath
+		if rel == "" {
+			rel = "../pom.xml"
+		}
+		parentPath := filepath.Join(dir, filepath.FromSlash(rel))
+		if !strings.HasSuffix(parentPath, ".xml") {
+			parentPath = filepath.Join(parentPath, "pom.xml")
+		}
+		data, err := os.ReadFile(parentPath)
+		if err != nil {
+			break
+		}
+		var parent pomXML
+		if xml.Unmarshal(data, &parent) != nil || parent.ArtifactID != child.Parent.ArtifactID {
+			break
+		}
+		parents = append(parents, &parent)
+		child, dir = &parent, filepath.Dir(parentPath)
+	}
+	return resolvePom(&pom, parents), nil
+}
+
+// resolvePom returns the direct dependencies of pom with their versions
+// resolved against the properties and dependency management of pom and its
+// parents, nearest first.
+func resolvePom(pom *pomXML, parents []*pomXML) []Package {
+	props := make(map[string]string)
+	managed := make(map[string]string)
+	// Apply the farthest parent first so nearer POMs override it.
+	chain := slices.Clone(parents)
+	slices.Reverse(chain)
+	chain = append(chain, pom)
+	for _, p := range chain {
+		for _, e := range p.Properties.Entries {
+			props[e.XMLName.Local] = strings.TrimSpace(e.Value)
+		}
+		for _, d := range p.DependencyManagement.Dependencies.Dependency {
+			if d.Version != "" {
+				managed[d.GroupID+":"+d.ArtifactID] = d.Version
 			}
-			seen[k] = struct{}{}
-			pkgs = append(pkgs, Package{
-				Name:      name,
-				Version:   d.Version,
-				Ecosystem: "maven",
-			})
 		}
 	}
+	groupID, version := pom.GroupID, pom.Version
+	if groupID == "" {
+		groupID = pom.Parent.GroupID
+	}
+	if version == "" {
+		version = pom.Parent.Version
+	}
+	props["project.groupId"], props["project.version"] = groupID, version
+	props["project.parent.version"] = pom.Parent.Version
 
-	addDeps(pom.Dependencies.Dependency)
-	addDeps(pom.DependencyManagement.Dependencies.Dependency)
+	type key struct{ name, ver string }
+	seen := make(map[key]struct{})
+	var pkgs []Package
+	for _, d := range pom.Dependencies.Dependency {
+		group := expandPomProperties(d.GroupID, props)
+		artifact := expandPomProperties(d.ArtifactID, props)
+		ver := d.Version
+		if ver == "" {
+			ver = managed[d.GroupID+":"+d.ArtifactID]
+		}
+		ver = expandPomProperties(ver, props)
+		// Skip unresolved property references and version ranges.
+		if group == "" || artifact == "" || ver == "" || strings.Contains(group+artifact+ver, "${") || strings.ContainsAny(ver[:1], "[(") {
+			continue
+		}
+		name := group + ":" + artifact
+		k := key{name, ver}
+		if _, exists := seen[k]; exists {
+			continue
+		}
+		seen[k] = struct{}{}
+		pkgs = append(pkgs, Package{
+			Name:      name,
+			Version:   ver,
+			Ecosystem: "maven",
+		})
+	}
+	return pkgs
+}
 
-	return pkgs, nil
+// maxPomExpansions bounds property expansion so self-referencing properties
+// terminate.
+const maxPomExpansions = 10
+
+// expandPomProperties replaces ${name} references in s with literal values
+// from props. Expansion stops at the first reference to an unknown property.
+func expandPomProperties(s string, props map[string]string) string {
+	for range maxPomExpansions {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			return s
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return s
+		}
+		v, ok := props[s[start+2:start+end]]
+		if !ok {
+			return s
+		}
+		s = s[:start] + v + s[start+end+1:]
+	}
+	return s
 }
 
 // reGradleDep matches Gradle dependency declarations such as:
@@ -462,6 +656,64 @@ func parseBuildGradle(content []byte) ([]Package, error) {
 	return pkgs, nil
 }
 
+// parseGradleLockfile extracts the resolved dependencies of a Gradle
+// dependency lock file. gradle.lockfile lists one module per line followed
+// by the configurations that resolve it:
+//
+//	org.apache.logging.log4j:log4j-core:2.14.1=compileClasspath,runtimeClasspath
+//	empty=annotationProcessor
+//
+// The per-configuration files written by Gradle before 6.0
+// (gradle/dependency-locks/<configuration>.lockfile) use the same lines
+// without the configuration list.
+func parseGradleLockfile(content []byte) ([]Package, error) {
+	var pkgs []Package
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		coord, _, _ := strings.Cut(line, "=")
+		parts := strings.Split(coord, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			continue
+		}
+		name := parts[0] + ":" + parts[1]
+		if seen[name+"@"+parts[2]] {
+			continue
+		}
+		seen[name+"@"+parts[2]] = true
+		pkgs = append(pkgs, Package{
+			Name:      name,
+			Version:   parts[2],
+			Ecosystem: "gradle",
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning gradle.lockfile: %w", err)
+	}
+
+	return pkgs, nil
+}
+
+// gradleLockModule reports whether path is a Gradle dependency lock file and
+// returns the directory of the module it locks.
+func gradleLockModule(path string) (string, bool) {
+	p := filepath.ToSlash(path)
+	dir, base := filepath.ToSlash(filepath.Dir(p)), filepath.Base(p)
+	switch {
+	case base == "gradle.lockfile":
+		return dir, true
+	case strings.HasSuffix(base, ".lockfile") && (dir == "gradle/dependency-locks" || strings.HasSuffix(dir, "/gradle/dependency-locks")):
+		return filepath.ToSlash(filepath.Dir(filepath.Dir(dir))), true
+	}
+	return "", false
+}
+
 // nugetPackagesLock is the structure of a NuGet packages.lock.json file.
 // The top-level keys are target framework monikers, each containing a
 // dependencies map of package name -> info.
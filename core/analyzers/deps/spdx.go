@@ -0,0 +1,132 @@
+package deps
+
+import "strings"
+
+// maxLicenseOptions bounds the number of licensing options an expression
+// expands to, so that a pathological expression cannot blow up.
+const maxLicenseOptions = 64
+
+// licenseOptions expands an SPDX license expression into the licensing
+// options it offers: a package may be used under any one option, and an
+// option requires every license in it. "MIT OR (Apache-2.0 AND BSD-3-Clause)"
+// yields [[MIT] [Apache-2.0 BSD-3-Clause]]. A "WITH" exception stays part of
+// its license, and the legacy "MIT/Apache-2.0" form is read as OR.
+// Operators are matched case-insensitively. A string that does not parse as
+// an expression, such as "BSD License", is returned as a single license.
+func licenseOptions(expr string) [][]string {
+	p := &spdxParser{tokens: tokenizeSPDX(expr)}
+	opts, ok := p.parseOr()
+	if !ok || p.pos != len(p.tokens) {
+		return [][]string{{strings.TrimSpace(expr)}}
+	}
+	return opts
+}
+
+// tokenizeSPDX splits an expression into parentheses, "/" and words.
+func tokenizeSPDX(expr string) []string {
+	var tokens []string
+	word := func(start, end int) {
+		if start < end {
+			tokens = append(tokens, expr[start:end])
+		}
+	}
+	start := 0
+	for i, c := range expr {
+		switch c {
+		case '(', ')', '/':
+			word(start, i)
+			tokens = append(tokens, string(c))
+			start = i + 1
+		case ' ', '\t', '\n', '\r':
+			word(start, i)
+			start = i + 1
+		}
+	}
+	word(start, len(expr))
+	return tokens
+}
+
+type spdxParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *spdxParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// parseOr parses and-expressions joined by OR or "/".
+func (p *spdxParser) parseOr() ([][]string, bool) {
+	opts, ok := p.parseAnd()
+	for ok && (strings.EqualFold(p.peek(), "OR") || p.peek() == "/") {
+		p.pos++
+		var more [][]string
+		if more, ok = p.parseAnd(); ok {
+			opts = append(opts, more...)
+			if len(opts) > maxLicenseOptions {
+				return nil, false
+			}
+		}
+	}
+	return opts, ok
+}
+
+// parseAnd parses terms joined by AND. The options of the result combine
+// one option of each term.
+func (p *spdxParser) parseAnd() ([][]string, bool) {
+	opts, ok := p.parseTerm()
+	for ok && strings.EqualFold(p.peek(), "AND") {
+		p.pos++
+		var right [][]string
+		if right, ok = p.parseTerm(); !ok {
+			break
+		}
+		if len(opts)*len(right) > maxLicenseOptions {
+			return nil, false
+		}
+		var combined [][]string
+		for _, l := range opts {
+			for _, r := range right {
+				combined = append(combined, append(append([]string{}, l...), r...))
+			}
+		}
+		opts = combined
+	}
+	return opts, ok
+}
+
+// parseTerm parses a parenthesized expression or a license with an
+// optional WITH exception.
+func (p *spdxParser) parseTerm() ([][]string, bool) {
+	tok := p.peek()
+	switch {
+	case tok == "(":
+		p.pos++
+		opts, ok := p.parseOr()
+		if !ok || p.peek() != ")" {
+			return nil, false
+		}
+		p.pos++
+		return opts, true
+	case tok == "", tok == ")", tok == "/", isSPDXOperator(tok):
+		return nil, false
+	}
+	p.pos++
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.pos++
+		exception := p.peek()
+		if exception == "" || exception == "(" || exception == ")" || exception == "/" || isSPDXOperator(exception) {
+			return nil, false
+		}
+		p.pos++
+		tok += " WITH " + exception
+	}
+	return [][]string{{tok}}, true
+}
+
+func isSPDXOperator(tok string) bool {
+	return strings.EqualFold(tok, "AND") || strings.EqualFold(tok, "OR") || strings.EqualFold(tok, "WITH")
+}
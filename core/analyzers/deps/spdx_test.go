@@ -0,0 +1,41 @@
+package deps
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLicenseOptions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want [][]string
+	}{
+		{"MIT", [][]string{{"MIT"}}},
+		{"MIT OR Apache-2.0", [][]string{{"MIT"}, {"Apache-2.0"}}},
+		{"MIT/Apache-2.0", [][]string{{"MIT"}, {"Apache-2.0"}}},
+		{"mit or apache-2.0", [][]string{{"mit"}, {"apache-2.0"}}},
+		{"MIT AND (Apache-2.0 OR BSD-3-Clause)", [][]string{{"MIT", "Apache-2.0"}, {"MIT", "BSD-3-Clause"}}},
+		{"(MIT OR ISC) AND Zlib OR Unlicense", [][]string{{"MIT", "Zlib"}, {"ISC", "Zlib"}, {"Unlicense"}}},
+		{"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT", [][]string{{"GPL-2.0-only WITH Classpath-exception-2.0"}, {"MIT"}}},
+		{"BSD License", [][]string{{"BSD License"}}},
+		{"(MIT OR", [][]string{{"(MIT OR"}}},
+		{"MIT WITH", [][]string{{"MIT WITH"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := licenseOptions(tt.expr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("licenseOptions(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLicenseOptions_Bounded(t *testing.T) {
+	expr := "(A OR B)"
+	for i := 0; i < 8; i++ {
+		expr += " AND (A OR B)"
+	}
+	if got := licenseOptions(expr); len(got) != 1 || got[0][0] != expr {
+		t.Errorf("an expression with too many options should be kept whole, got %d options", len(got))
+	}
+}
@@ -0,0 +1,265 @@
+// Package dotenv analyzes dotenv files such as .env, .env.production and
+// config.env. The secret rules are applied to each value with its variable
+// name as context, env files committed to git are reported, and example
+// files are checked for values that look real rather than like
+// placeholders.
+package dotenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/nox-hq/nox/core/analyzers/secrets"
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+const (
+	// committedRuleID flags env files that git tracks.
+	committedRuleID = "SEC-956"
+	// exampleRuleID flags example env files with real-looking values.
+	exampleRuleID = "SEC-957"
+)
+
+// exampleSuffixes and examplePrefixes mark env files that are committed
+// templates rather than real configuration, such as .env.example and
+// example.env.
+var (
+	exampleSuffixes = []string{".example", ".sample", ".template", ".dist", ".defaults"}
+	examplePrefixes = []string{"example.", "sample.", "template."}
+)
+
+// IsEnvFile reports whether path names a dotenv file: .env, .env.<name> or
+// <name>.env, example files included. .envrc, which direnv runs as a shell
+// script, is not one.
+func IsEnvFile(path string) bool {
+	name := strings.ToLower(filepath.Base(filepath.FromSlash(path)))
+	return name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env") || IsExampleFile(path) && strings.Contains(name, ".env")
+}
+
+// IsExampleFile reports whether path names an example env file, such as
+// .env.example, .env.template or example.env.
+func IsExampleFile(path string) bool {
+	name := strings.ToLower(filepath.Base(filepath.FromSlash(path)))
+	if !strings.Contains(name, ".env") {
+		return false
+	}
+	for _, s := range exampleSuffixes {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	for _, p := range examplePrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyzer scans dotenv files with the rules of a secrets analyzer.
+type Analyzer struct {
+	secrets  *secrets.Analyzer
+	tracked  map[string]bool
+	fileErrs *discovery.FileErrors
+}
+
+// NewAnalyzer returns an Analyzer that applies the rules of sa, with its
+// entropy and pairing settings, to env file values.
+func NewAnalyzer(sa *secrets.Analyzer) *Analyzer {
+	return &Analyzer{secrets: sa}
+}
+
+// SetTrackedFiles sets the slash-separated paths, relative to the scan
+// root, of the files git tracks. Env files among them are reported as
+// committed (SEC-956).
+func (a *Analyzer) SetTrackedFiles(paths []string) {
+	a.tracked = make(map[string]bool, len(paths))
+	for _, p := range paths {
+		a.tracked[p] = true
+	}
+}
+
+// SetFileErrors records the env files that cannot be read or scanned in e
+// and skips them, instead of failing the scan.
+func (a *Analyzer) SetFileErrors(e *discovery.FileErrors) { a.fileErrs = e }
+
+// Rules returns the rules of the dotenv analyzer. The secret rules it also
+// applies belong to the secrets analyzer.
+func (a *Analyzer) Rules() *rules.RuleSet {
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{
+		ID:          committedRuleID,
+		Version:     "1.0",
+		Description: "Environment file committed to version control",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceHigh,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "dotenv", "filename"},
+		Metadata:    map[string]string{"cwe": "CWE-538"},
+		Remediation: "Remove the file from the repository and its history, add it to .gitignore, rotate the credentials it held, and commit a .env.example with placeholder values instead.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/538.html"},
+	})
+	rs.Add(&rules.Rule{
+		ID:          exampleRuleID,
+		Version:     "1.0",
+		Description: "Example environment file contains a real-looking value",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceMedium,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "dotenv"},
+		Metadata:    map[string]string{"cwe": "CWE-798"},
+		Remediation: "Replace the value with an empty string or a placeholder such as <your-api-key>, and rotate the credential if it was real.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/798.html"},
+	})
+	return rs
+}
+
+// ScanArtifactsContext scans the env files among artifacts and ignores the
+// rest. It stops once ctx is done, returning the findings gathered so far
+// together with ctx.Err().
+func (a *Analyzer) ScanArtifactsContext(ctx context.Context, artifacts []discovery.Artifact) (*findings.FindingSet, error) {
+	fs := findings.NewFindingSet()
+	for _, artifact := range artifacts {
+		if !IsEnvFile(artifact.Path) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			fs.Deduplicate()
+			return fs, err
+		}
+		content, err := discovery.ReadContent(artifact)
+		if err != nil {
+			if a.fileErrs.Skip(artifact.Path, err) {
+				continue
+			}
+			return nil, fmt.Errorf("reading artifact %s: %w", artifact.Path, err)
+		}
+		results, err := a.ScanFile(ctx, artifact.Path, content)
+		for i := range results {
+			fs.Add(results[i])
+		}
+		switch {
+		case errors.Is(err, rules.ErrFileTimeout):
+			slog.Warn("file scan timed out", "analyzer", "dotenv", "path", artifact.Path)
+		case ctx.Err() != nil:
+			fs.Deduplicate()
+			return fs, ctx.Err()
+		case err != nil:
+			if !a.fileErrs.Skip(artifact.Path, err) {
+				return nil, fmt.Errorf("scanning artifact %s: %w", artifact.Path, err)
+			}
+		}
+	}
+	fs.Deduplicate()
+	return fs, nil
+}
+
+// ScanFile scans the env file at path. Example files are only checked for
+// real-looking values. In other files, every single-line assignment is
+// rewritten as KEY="value" before the secret rules run, so that keyword
+// rules such as SEC-080 match unquoted values, exported variables and
+// values followed by a comment. Findings name the variable in their
+// key_path metadata.
+func (a *Analyzer) ScanFile(ctx context.Context, path string, content []byte) ([]findings.Finding, error) {
+	entries := Parse(content)
+	if IsExampleFile(path) {
+		return a.exampleFindings(path, entries), nil
+	}
+
+	var out []findings.Finding
+	if a.tracked[filepath.ToSlash(path)] {
+		out = append(out, a.finding(committedRuleID, path, 1, fmt.Sprintf("%s is committed to version control", filepath.Base(path)), nil))
+	}
+
+	lines := strings.Split(string(content), "\n")
+	keys := make(map[int]string)
+	for _, e := range entries {
+		for l := e.Line; l <= e.EndLine; l++ {
+			keys[l] = e.Key
+		}
+		if e.Line != e.EndLine || strings.ContainsAny(e.Value, "\r\n") {
+			continue
+		}
+		lines[e.Line-1] = e.Key + `="` + e.Value + `"`
+	}
+	results, err := a.secrets.ScanSensitiveContext(ctx, path, []byte(strings.Join(lines, "\n")))
+	for i := range results {
+		if key, ok := keys[results[i].Location.StartLine]; ok {
+			results[i].Metadata = maps.Clone(results[i].Metadata)
+			if results[i].Metadata == nil {
+				results[i].Metadata = make(map[string]string)
+			}
+			results[i].Metadata[findings.MetaKeyPath] = key
+		}
+	}
+	return append(out, results...), err
+}
+
+// exampleFindings returns a SEC-957 finding for each value of an example
+// file that looks like a real secret rather than a placeholder.
+func (a *Analyzer) exampleFindings(path string, entries []Entry) []findings.Finding {
+	var out []findings.Finding
+	for _, e := range entries {
+		if secrets.IsPlaceholder(e.Value) || !looksReal(e.Value) {
+			continue
+		}
+		out = append(out, a.finding(exampleRuleID, path, e.Line,
+			fmt.Sprintf("Example env file sets %s to a real-looking value", e.Key),
+			map[string]string{findings.MetaKeyPath: e.Key}))
+	}
+	return out
+}
+
+func (a *Analyzer) finding(ruleID, path string, line int, msg string, meta map[string]string) findings.Finding {
+	r, _ := a.Rules().ByID(ruleID)
+	loc := findings.Location{FilePath: path, StartLine: line, EndLine: line}
+	if meta == nil {
+		meta = maps.Clone(r.Metadata)
+	} else {
+		meta["cwe"] = r.Metadata["cwe"]
+	}
+	return findings.Finding{
+		ID:          fmt.Sprintf("%s:%s:%d", r.ID, path, line),
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		Location:    loc,
+		Message:     msg,
+		Metadata:    meta,
+		Fingerprint: findings.ComputeFingerprint(r.ID, loc, msg),
+	}
+}
+
+// minRealLength and minRealEntropy bound the values of example files that
+// are reported as real: long, random-looking tokens of letters and digits.
+const (
+	minRealLength  = 16
+	minRealEntropy = 3.5
+)
+
+// looksReal reports whether v looks like a real secret: a long, high
+// entropy token mixing letters and digits, or a URL with a password.
+func looksReal(v string) bool {
+	if strings.Contains(v, "://") {
+		u, err := url.Parse(v)
+		if err != nil || u.User == nil {
+			return false
+		}
+		pass, ok := u.User.Password()
+		return ok && !secrets.IsPlaceholder(pass)
+	}
+	if len(v) < minRealLength || strings.ContainsFunc(v, unicode.IsSpace) {
+		return false
+	}
+	return strings.ContainsFunc(v, unicode.IsLetter) && strings.ContainsFunc(v, unicode.IsDigit) &&
+		rules.ShannonEntropy(v) >= minRealEntropy
+}
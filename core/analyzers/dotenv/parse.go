@@ -0,0 +1,124 @@
+package dotenv
+
+import (
+	"strings"
+)
+
+// Entry is one KEY=VALUE assignment of a dotenv file.
+type Entry struct {
+	Key   string
+	Value string
+	// Line and EndLine are the 1-based lines the assignment spans; they
+	// differ for quoted values that continue over several lines.
+	Line    int
+	EndLine int
+	// Quote is the quote character around the value (' " or `), or 0 for
+	// an unquoted value.
+	Quote byte
+}
+
+// Parse returns the assignments of a dotenv file. It accepts an optional
+// "export " prefix, single-, double- and backtick-quoted values that may
+// span lines, backslash escapes in double-quoted values, and trailing
+// " # comments" after unquoted values. Lines that are not assignments are
+// skipped.
+func Parse(content []byte) []Entry {
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	var entries []Entry
+	for i := 0; i < len(lines); i++ {
+		key, rest, ok := splitAssignment(lines[i])
+		if !ok {
+			continue
+		}
+		e := Entry{Key: key, Line: i + 1, EndLine: i + 1}
+		if rest != "" && strings.ContainsRune(`'"`+"`", rune(rest[0])) {
+			value, end, closed := readQuoted(lines, i, rest)
+			if closed {
+				e.Value, e.Quote, e.EndLine = value, rest[0], end+1
+				entries = append(entries, e)
+				i = end
+				continue
+			}
+			// An unterminated quote is taken literally, up to the end of
+			// the line.
+		}
+		e.Value = unquotedValue(rest)
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// splitAssignment splits "[export ]KEY=rest" into the key and the text
+// after "=", without leading blanks.
+func splitAssignment(line string) (key, rest string, ok bool) {
+	line = strings.TrimLeft(line, " \t")
+	if trimmed, found := strings.CutPrefix(line, "export"); found && trimmed != "" && (trimmed[0] == ' ' || trimmed[0] == '\t') {
+		line = strings.TrimLeft(trimmed, " \t")
+	}
+	key, rest, found := strings.Cut(line, "=")
+	key = strings.TrimRight(key, " \t")
+	if !found || !validKey(key) {
+		return "", "", false
+	}
+	return key, strings.TrimLeft(rest, " \t"), true
+}
+
+func validKey(key string) bool {
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		return false
+	}
+	for _, c := range key {
+		if !(c == '_' || c == '.' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// readQuoted reads the quoted value that starts rest, the text after "=" on
+// lines[start]. It returns the value, the index of the line holding the
+// closing quote, and whether the quote was closed.
+func readQuoted(lines []string, start int, rest string) (string, int, bool) {
+	quote := rest[0]
+	var b strings.Builder
+	text := rest[1:]
+	for i := start; i < len(lines); i++ {
+		if i > start {
+			b.WriteByte('\n')
+			text = lines[i]
+		}
+		for j := 0; j < len(text); j++ {
+			c := text[j]
+			switch {
+			case c == quote:
+				return b.String(), i, true
+			case c == '\\' && quote == '"' && j+1 < len(text):
+				j++
+				switch text[j] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(text[j])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+	}
+	return "", start, false
+}
+
+// unquotedValue trims rest and drops a trailing " # comment".
+func unquotedValue(rest string) string {
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '#' && (i == 0 || rest[i-1] == ' ' || rest[i-1] == '\t') {
+			rest = rest[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(rest)
+}
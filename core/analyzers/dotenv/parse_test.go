@@ -0,0 +1,65 @@
+package dotenv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Entry
+	}{
+		{
+			name:    "unquoted with comment",
+			content: "# database\nDB_HOST=localhost # local only\n\nDB_PORT = 5432\n",
+			want: []Entry{
+				{Key: "DB_HOST", Value: "localhost", Line: 2, EndLine: 2},
+				{Key: "DB_PORT", Value: "5432", Line: 4, EndLine: 4},
+			},
+		},
+		{
+			name:    "export prefix",
+			content: "export API_TOKEN=abc#123\n",
+			want:    []Entry{{Key: "API_TOKEN", Value: "abc#123", Line: 1, EndLine: 1}},
+		},
+		{
+			name:    "quotes and escapes",
+			content: "A='single # kept'\nB=\"tab\\there \\\"q\\\"\" # comment\nC=`tick`\n",
+			want: []Entry{
+				{Key: "A", Value: "single # kept", Line: 1, EndLine: 1, Quote: '\''},
+				{Key: "B", Value: "tab\there \"q\"", Line: 2, EndLine: 2, Quote: '"'},
+				{Key: "C", Value: "tick", Line: 3, EndLine: 3, Quote: '`'},
+			},
+		},
+		{
+			name:    "multi-line value",
+			content: "KEY=\"line one\nline two\"\nNEXT=1\n",
+			want: []Entry{
+				{Key: "KEY", Value: "line one\nline two", Line: 1, EndLine: 2, Quote: '"'},
+				{Key: "NEXT", Value: "1", Line: 3, EndLine: 3},
+			},
+		},
+		{
+			name:    "unterminated quote",
+			content: "KEY=\"open\nNEXT=1\n",
+			want: []Entry{
+				{Key: "KEY", Value: "\"open", Line: 1, EndLine: 1},
+				{Key: "NEXT", Value: "1", Line: 2, EndLine: 2},
+			},
+		},
+		{
+			name:    "not assignments",
+			content: "just text\n1KEY=x\nexport\n=value\r\nOK=yes\r\n",
+			want:    []Entry{{Key: "OK", Value: "yes", Line: 5, EndLine: 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse([]byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package iac
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// Ansible vault rules that are not pattern matches. ANS-001 and ANS-005
+// check parsed YAML variables and run in ScanFile and ScanArtifacts;
+// ANS-002 needs the list of committed files and runs only in
+// ScanArtifacts. ANS-003 and ANS-004 are regex rules run by the engine.
+const (
+	ansPlaintextVar     = "ANS-001"
+	ansVaultPassFile    = "ANS-002"
+	ansVaultUnencrypted = "ANS-005"
+)
+
+// vaultHeader starts every ansible-vault encrypted value.
+const vaultHeader = "$ANSIBLE_VAULT;"
+
+// ansCredentialName matches variable names that suggest a credential.
+var ansCredentialName = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|access_?key|credential)`)
+
+// ansConnectionPassword matches the connection and become password
+// variables, which ANS-003 reports wherever they are set.
+var ansConnectionPassword = regexp.MustCompile(`(?i)^ansible_(?:become_pass(?:word)?|ssh_pass(?:word)?|password|sudo_pass)$`)
+
+// ansPathSuffix matches credential-named variables that hold the path to a
+// credential rather than the credential itself.
+var ansPathSuffix = regexp.MustCompile(`(?i)_(?:file|path|dir)$`)
+
+// vaultPasswordFileRe matches the vault_password_file setting of an
+// ansible.cfg.
+var vaultPasswordFileRe = regexp.MustCompile(`(?m)^[ \t]*vault_password_file[ \t]*[=:][ \t]*([^\s#;]+)`)
+
+// ansibleRules returns the metadata of the ANS-* rules checked in Go.
+func ansibleRules() []*rules.Rule {
+	defs := []struct {
+		id          string
+		severity    findings.Severity
+		confidence  findings.Confidence
+		description string
+		cwe         string
+		patterns    []string
+		remediation string
+		references  []string
+	}{
+		{
+			ansPlaintextVar, findings.SeverityHigh, findings.ConfidenceMedium, "Ansible group_vars or host_vars credential in plaintext", "CWE-798",
+			[]string{"group_vars/*", "host_vars/*", "*/group_vars/*", "*/host_vars/*"},
+			"Encrypt the value with 'ansible-vault encrypt_string' and commit the !vault block, move it to a vault-encrypted vars file, or read it at runtime with a lookup plugin such as '{{ lookup(\"community.hashi_vault.hashi_vault\", \"secret/db:password\") }}'.",
+			[]string{"https://cwe.mitre.org/data/definitions/798.html", "https://docs.ansible.com/ansible/latest/vault_guide/vault_encrypting_content.html", "https://docs.ansible.com/ansible/latest/plugins/lookup.html"},
+		},
+		{
+			ansVaultPassFile, findings.SeverityCritical, findings.ConfidenceHigh, "Ansible vault password file committed to the repository", "CWE-522",
+			[]string{"ansible.cfg", ".ansible.cfg"},
+			"Remove the password file from the repository, add it to .gitignore and rotate the vault password with 'ansible-vault rekey', since anyone with the repository can decrypt every vault. Keep the password outside the repository or point vault_password_file at an executable client script that fetches it from a secret manager.",
+			[]string{"https://cwe.mitre.org/data/definitions/522.html", "https://docs.ansible.com/ansible/latest/vault_guide/vault_managing_passwords.html"},
+		},
+		{
+			ansVaultUnencrypted, findings.SeverityHigh, findings.ConfidenceHigh, "Ansible !vault value that is empty or not encrypted", "CWE-311",
+			[]string{"*.yml", "*.yaml"},
+			"Replace the value with the output of 'ansible-vault encrypt_string', which starts with $ANSIBLE_VAULT; and carries the whole ciphertext. A !vault tag on plaintext or a truncated ciphertext fails at runtime and may expose the value.",
+			[]string{"https://cwe.mitre.org/data/definitions/311.html", "https://docs.ansible.com/ansible/latest/vault_guide/vault_encrypting_content.html"},
+		},
+	}
+
+	out := make([]*rules.Rule, len(defs))
+	for i, d := range defs {
+		out[i] = &rules.Rule{
+			ID:           d.id,
+			Version:      "1.0",
+			Description:  d.description,
+			Severity:     d.severity,
+			Confidence:   d.confidence,
+			FilePatterns: d.patterns,
+			Category:     rules.CategoryIaC,
+			Tags:         []string{"iac", "ansible", "secrets", "vault"},
+			Metadata:     map[string]string{"cwe": d.cwe},
+			Remediation:  d.remediation,
+			References:   d.references,
+		}
+	}
+	return out
+}
+
+// scanAnsible runs ANS-001 on the variable files under group_vars and
+// host_vars and ANS-005 on YAML files with !vault values.
+func scanAnsible(filePath string, content []byte, rs []*rules.Rule) []findings.Finding {
+	vars := isAnsibleVarsFile(filePath)
+	hasVault := bytes.Contains(content, []byte("!vault"))
+	if !vars && !hasVault {
+		return nil
+	}
+	if !vars && !isYAMLFile(filePath) {
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*rules.Rule, len(rs))
+	for _, r := range rs {
+		byID[r.ID] = r
+	}
+	var out []findings.Finding
+	report := func(ruleID string, at *yaml.Node, message string) {
+		if r := byID[ruleID]; r != nil {
+			out = append(out, ansibleFinding(r, filePath, at.Line, at.Column, message))
+		}
+	}
+
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, val := n.Content[i].Value, n.Content[i+1]
+				if val.Kind != yaml.ScalarNode {
+					walk(val)
+					continue
+				}
+				if val.Tag == "!vault" {
+					if problem := vaultProblem(val.Value); problem != "" {
+						report(ansVaultUnencrypted, val, fmt.Sprintf("!vault value of %s %s", key, problem))
+					}
+					continue
+				}
+				if vars && isPlaintextCredential(key, val) {
+					report(ansPlaintextVar, val, fmt.Sprintf("Ansible variable %s has a plaintext value", key))
+				}
+			}
+		case yaml.SequenceNode, yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c)
+			}
+		}
+	}
+	walk(doc.Content[0])
+	return out
+}
+
+// isAnsibleVarsFile reports whether filePath is a variable file of an
+// inventory: a YAML or extensionless file under a group_vars or host_vars
+// directory.
+func isAnsibleVarsFile(filePath string) bool {
+	dirs := strings.Split(path.Dir(strings.ReplaceAll(filePath, "\\", "/")), "/")
+	inVars := false
+	for _, d := range dirs {
+		if d == "group_vars" || d == "host_vars" {
+			inVars = true
+		}
+	}
+	if !inVars {
+		return false
+	}
+	switch path.Ext(filePath) {
+	case "", ".yml", ".yaml", ".json":
+		return true
+	}
+	return false
+}
+
+// isYAMLFile reports whether filePath has a YAML extension.
+func isYAMLFile(filePath string) bool {
+	ext := strings.ToLower(path.Ext(filePath))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// isPlaintextCredential reports whether a variable named key with the
+// scalar val holds a credential in plaintext. Templated values, which
+// include lookup plugins and references to vaulted variables, are not
+// plaintext, and neither are numbers, booleans and empty values.
+func isPlaintextCredential(key string, val *yaml.Node) bool {
+	if !ansCredentialName.MatchString(key) || ansConnectionPassword.MatchString(key) || ansPathSuffix.MatchString(key) {
+		return false
+	}
+	if val.ShortTag() != "!!str" {
+		return false
+	}
+	v := strings.TrimSpace(val.Value)
+	return v != "" && !strings.Contains(v, "{{") && !strings.HasPrefix(v, vaultHeader)
+}
+
+// vaultProblem describes what is wrong with the value of a !vault tag, or
+// returns "" when it is a well-formed vault ciphertext: a header line
+// followed by the hex encoding of the salt, HMAC and ciphertext, each
+// itself hex encoded on its own line.
+func vaultProblem(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "is empty"
+	}
+	header, body, _ := strings.Cut(value, "\n")
+	if !strings.HasPrefix(header, vaultHeader) {
+		return "is not vault-encrypted"
+	}
+	inner, err := hex.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return "is not a valid vault ciphertext"
+	}
+	parts := strings.Split(string(inner), "\n")
+	// The salt and HMAC are 32 bytes and AES blocks 16 bytes, hex encoded.
+	if len(parts) != 3 || len(parts[0]) != 64 || len(parts[1]) != 64 || len(parts[2]) < 32 {
+		return "is truncated"
+	}
+	return ""
+}
+
+// scanVaultPasswordFiles runs ANS-002: it reports each ansible.cfg whose
+// vault_password_file names a file that is among artifacts. Executable
+// files are vault password client scripts, which fetch the password from
+// elsewhere, and are not reported.
+func scanVaultPasswordFiles(artifacts []discovery.Artifact, rs []*rules.Rule) []findings.Finding {
+	var rule *rules.Rule
+	for _, r := range rs {
+		if r.ID == ansVaultPassFile {
+			rule = r
+		}
+	}
+	if rule == nil {
+		return nil
+	}
+	byPath := make(map[string]discovery.Artifact, len(artifacts))
+	for _, a := range artifacts {
+		byPath[a.Path] = a
+	}
+
+	var out []findings.Finding
+	for _, cfg := range artifacts {
+		if name := path.Base(cfg.Path); name != "ansible.cfg" && name != ".ansible.cfg" {
+			continue
+		}
+		content, err := os.ReadFile(cfg.AbsPath)
+		if err != nil {
+			continue
+		}
+		for _, m := range vaultPasswordFileRe.FindAllSubmatchIndex(content, -1) {
+			ref := string(content[m[2]:m[3]])
+			if path.IsAbs(ref) || strings.ContainsAny(ref, "~${") {
+				continue
+			}
+			target, ok := byPath[path.Join(path.Dir(cfg.Path), ref)]
+			if !ok {
+				continue
+			}
+			if info, err := os.Stat(target.AbsPath); err != nil || info.Mode()&0o111 != 0 {
+				continue
+			}
+			line := bytes.Count(content[:m[0]], []byte("\n")) + 1
+			col := m[2] - bytes.LastIndexByte(content[:m[2]], '\n')
+			out = append(out, ansibleFinding(rule, cfg.Path, line, col,
+				fmt.Sprintf("vault_password_file points at %s, which is committed", target.Path)))
+		}
+	}
+	return out
+}
+
+func ansibleFinding(r *rules.Rule, filePath string, line, col int, message string) findings.Finding {
+	loc := findings.Location{FilePath: filePath, StartLine: line, EndLine: line, StartColumn: col}
+	return findings.Finding{
+		ID:          fmt.Sprintf("%s:%s:%d", r.ID, filePath, line),
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		Location:    loc,
+		Message:     message,
+		Metadata:    maps.Clone(r.Metadata),
+		Fingerprint: findings.ComputeFingerprint(r.ID, loc, message),
+	}
+}
@@ -0,0 +1,197 @@
+package iac
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+// ansFindings returns "RULE:line" for each ANS-* finding of a scan, sorted.
+func ansFindings(t *testing.T, path, content string) []string {
+	t.Helper()
+	results, err := NewAnalyzer().ScanFile(path, []byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, f := range results {
+		if strings.HasPrefix(f.RuleID, "ANS-") {
+			got = append(got, fmt.Sprintf("%s:%d", f.RuleID, f.Location.StartLine))
+		}
+	}
+	sort.Strings(got)
+	return got
+}
+
+// vaultValue returns a !vault block value, indented for a top-level key,
+// with a ciphertext of n bytes.
+func vaultValue(n int) string {
+	inner := strings.Repeat("ab", 32) + "\n" + strings.Repeat("cd", 32) + "\n" + strings.Repeat("ef", n)
+	body := hex.EncodeToString([]byte(inner))
+	var b strings.Builder
+	b.WriteString("!vault |\n  $ANSIBLE_VAULT;1.1;AES256\n")
+	for len(body) > 80 {
+		b.WriteString("  " + body[:80] + "\n")
+		body = body[80:]
+	}
+	b.WriteString("  " + body + "\n")
+	return b.String()
+}
+
+func TestAnsible_PlaintextGroupVars(t *testing.T) {
+	content := `db_password: hunter2
+api_token: "{{ lookup('community.hashi_vault.hashi_vault', 'secret/api:token') }}"
+vault_db_password: ` + vaultValue(16) + `token_ttl: 3600
+ssl_private_key_file: /etc/ssl/private/app.key
+admin_password: ""
+users:
+  - name: deploy
+    password: s3cret-pass
+ansible_become_pass: letmein
+`
+	got := ansFindings(t, "inventories/prod/group_vars/all.yml", content)
+	want := []string{"ANS-001:1", "ANS-001:15", "ANS-003:16"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	if got := ansFindings(t, "host_vars/web1", "db_password: hunter2\n"); strings.Join(got, " ") != "ANS-001:1" {
+		t.Errorf("extensionless host_vars file: findings = %v", got)
+	}
+	if got := ansFindings(t, "roles/app/defaults/main.yml", "db_password: hunter2\n"); len(got) != 0 {
+		t.Errorf("ANS-001 outside group_vars and host_vars: findings = %v", got)
+	}
+	if got := ansFindings(t, "group_vars/all.yml", "$ANSIBLE_VAULT;1.1;AES256\n6162636465\n"); len(got) != 0 {
+		t.Errorf("encrypted vars file: findings = %v", got)
+	}
+}
+
+func TestAnsible_VaultValues(t *testing.T) {
+	content := `empty: !vault ""
+plain: !vault |
+  hunter2
+short: !vault |
+  $ANSIBLE_VAULT;1.1;AES256
+  61626364
+truncated: ` + vaultValue(4) + `valid: ` + vaultValue(16)
+	got := ansFindings(t, "playbook.yml", content)
+	want := []string{"ANS-005:1", "ANS-005:2", "ANS-005:4", "ANS-005:7"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
+
+func TestAnsible_InventoryPasswords(t *testing.T) {
+	content := `[web]
+web1 ansible_host=10.0.0.1 ansible_become_pass=Sup3rSecret
+web2 ansible_host=10.0.0.2 ansible_ssh_pass="{{ vault_ssh_pass }}"
+
+[db:vars]
+ansible_password='hunter2'
+ansible_become_password=
+`
+	got := ansFindings(t, "inventory/hosts", content)
+	want := []string{"ANS-003:2", "ANS-003:6"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	yml := `all:
+  vars:
+    ansible_become_pass: !vault |
+      $ANSIBLE_VAULT;1.1;AES256
+    ansible_ssh_pass:
+      - unused
+`
+	for _, f := range ansFindings(t, "inventory.yml", yml) {
+		if strings.HasPrefix(f, "ANS-003") {
+			t.Errorf("vaulted or empty password reported: %s", f)
+		}
+	}
+}
+
+func TestAnsible_CommandPasswords(t *testing.T) {
+	content := `- hosts: db
+  tasks:
+    - name: Create user
+      shell: mysql -u root -pSecret123 -e "CREATE USER app"
+    - name: Dump
+      ansible.builtin.command: pg_dump --password={{ db_password }} app
+    - name: Copy keys
+      command: sshpass -p hunter2 ssh-copy-id app@db
+    - name: Curl
+      shell: curl -u "app:x" https://example.com/?password=abc123
+    - name: Prompt
+      command: mysql -u root -p
+    - name: Make dirs
+      shell: mkdir -p /opt/app
+    - name: Port
+      command: ssh -p 2222 db uptime
+`
+	got := ansFindings(t, "site.yml", content)
+	want := []string{"ANS-004:10", "ANS-004:4", "ANS-004:6", "ANS-004:8"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
+
+func TestScanArtifacts_VaultPasswordFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		"ansible.cfg":              {"[defaults]\ninventory = hosts\nvault_password_file = .vault_pass\n", 0o644},
+		".vault_pass":              {"hunter2\n", 0o600},
+		"ops/ansible.cfg":          {"[defaults]\nvault_password_file=./vault-client.py\n", 0o644},
+		"ops/vault-client.py":      {"#!/usr/bin/env python3\n", 0o755},
+		"staging/ansible.cfg":      {"[defaults]\nvault_password_file = ~/.vault_pass\n", 0o644},
+		"missing/ansible.cfg":      {"[defaults]\nvault_password_file = .vault_pass\n", 0o644},
+		"missing/group_vars/a.yml": {"x: 1\n", 0o644},
+	}
+	var artifacts []discovery.Artifact
+	for name, f := range files {
+		abs := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(f.content), f.mode); err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, discovery.Artifact{Path: name, AbsPath: abs, Type: discovery.Config})
+	}
+
+	fs, err := NewAnalyzer().ScanArtifacts(artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range fs.Findings() {
+		if f.RuleID == ansVaultPassFile {
+			got = append(got, fmt.Sprintf("%s:%d:%d", f.Location.FilePath, f.Location.StartLine, f.Location.StartColumn))
+		}
+	}
+	if want := "ansible.cfg:3:23"; strings.Join(got, " ") != want {
+		t.Errorf("findings = %v, want %s", got, want)
+	}
+}
+
+func TestAnsible_RulesInCatalog(t *testing.T) {
+	rs := NewAnalyzer().Rules()
+	for _, id := range []string{"ANS-001", "ANS-002", "ANS-003", "ANS-004", "ANS-005"} {
+		r, ok := rs.ByID(id)
+		if !ok {
+			t.Errorf("rule %s missing from Rules()", id)
+			continue
+		}
+		if r.Remediation == "" || r.Metadata["cwe"] == "" {
+			t.Errorf("rule %s lacks remediation or CWE", id)
+		}
+	}
+}
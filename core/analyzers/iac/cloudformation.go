@@ -0,0 +1,441 @@
+package iac
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// CloudFormation rules (CFN-001 to CFN-007) check the resources of parsed
+// CloudFormation and SAM templates. They are not run by the rules engine;
+// ScanFile and ScanArtifacts run them on files that parse as a template.
+const (
+	cfnS3Encryption    = "CFN-001"
+	cfnS3PublicAccess  = "CFN-002"
+	cfnOpenIngress     = "CFN-003"
+	cfnIAMWildcard     = "CFN-004"
+	cfnLambdaSecretEnv = "CFN-005"
+	cfnRDSUnencrypted  = "CFN-006"
+	cfnEBSUnencrypted  = "CFN-007"
+)
+
+// cfnFilePatterns are the file names CloudFormation templates are read from.
+var cfnFilePatterns = []string{"*.yaml", "*.yml", "*.json", "*.template"}
+
+// cfnSensitivePorts are the ports CFN-003 reports when open to the internet:
+// remote administration, databases and caches.
+var cfnSensitivePorts = []int{20, 21, 22, 23, 445, 1433, 1521, 2375, 2376, 3306, 3389, 5432, 5601, 5900, 6379, 9200, 9300, 11211, 27017}
+
+// cfnSecretVarName matches environment variable names that suggest a secret.
+var cfnSecretVarName = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|access_?key|credential)`)
+
+// cloudFormationRules returns the metadata of the CFN-* rules.
+func cloudFormationRules() []*rules.Rule {
+	defs := []struct {
+		id          string
+		severity    findings.Severity
+		description string
+		cwe         string
+		tags        []string
+		remediation string
+		references  []string
+	}{
+		{
+			cfnS3Encryption, findings.SeverityMedium, "CloudFormation S3 bucket without default encryption", "CWE-311",
+			[]string{"s3", "encryption"},
+			"Add BucketEncryption with a ServerSideEncryptionConfiguration rule to the AWS::S3::Bucket, e.g. BucketEncryption: {ServerSideEncryptionConfiguration: [{ServerSideEncryptionByDefault: {SSEAlgorithm: aws:kms}}]}.",
+			[]string{"https://cwe.mitre.org/data/definitions/311.html", "https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-properties-s3-bucket-bucketencryption.html"},
+		},
+		{
+			cfnS3PublicAccess, findings.SeverityHigh, "CloudFormation S3 bucket without a complete public access block", "CWE-732",
+			[]string{"s3", "public-access"},
+			"Add PublicAccessBlockConfiguration to the AWS::S3::Bucket with BlockPublicAcls, BlockPublicPolicy, IgnorePublicAcls and RestrictPublicBuckets all set to true.",
+			[]string{"https://cwe.mitre.org/data/definitions/732.html", "https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-properties-s3-bucket-publicaccessblockconfiguration.html"},
+		},
+		{
+			cfnOpenIngress, findings.SeverityHigh, "CloudFormation security group allows internet ingress to a sensitive port", "CWE-284",
+			[]string{"network", "security-group"},
+			"Restrict CidrIp/CidrIpv6 in SecurityGroupIngress to known ranges instead of 0.0.0.0/0 or ::/0, or reference a SourceSecurityGroupId. Reach administrative ports through SSM Session Manager or a bastion.",
+			[]string{"https://cwe.mitre.org/data/definitions/284.html", "https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-properties-ec2-security-group-rule-1.html"},
+		},
+		{
+			cfnIAMWildcard, findings.SeverityHigh, "CloudFormation IAM policy allows every action on every resource", "CWE-250",
+			[]string{"iam", "least-privilege"},
+			"Replace Action: \"*\" and Resource: \"*\" in the PolicyDocument Statement with the specific actions and resource ARNs the principal needs, e.g. Action: [s3:GetObject] and Resource: !Sub arn:aws:s3:::${Bucket}/*.",
+			[]string{"https://cwe.mitre.org/data/definitions/250.html", "https://docs.aws.amazon.com/IAM/latest/UserGuide/best-practices.html#grant-least-privilege"},
+		},
+		{
+			cfnLambdaSecretEnv, findings.SeverityHigh, "CloudFormation Lambda function has a plaintext secret in its environment variables", "CWE-798",
+			[]string{"lambda", "serverless", "secrets"},
+			"Do not put secret values in Environment.Variables. Store them in Secrets Manager or SSM Parameter Store and pass a dynamic reference such as '{{resolve:secretsmanager:MySecret}}', or pass the secret ARN and read it at runtime.",
+			[]string{"https://cwe.mitre.org/data/definitions/798.html", "https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/dynamic-references.html"},
+		},
+		{
+			cfnRDSUnencrypted, findings.SeverityHigh, "CloudFormation RDS storage is not encrypted", "CWE-311",
+			[]string{"database", "rds", "encryption"},
+			"Set StorageEncrypted: true on the AWS::RDS::DBInstance or AWS::RDS::DBCluster, optionally with a KmsKeyId. Encryption cannot be enabled on an existing instance without a snapshot restore.",
+			[]string{"https://cwe.mitre.org/data/definitions/311.html", "https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-resource-rds-dbinstance.html#cfn-rds-dbinstance-storageencrypted"},
+		},
+		{
+			cfnEBSUnencrypted, findings.SeverityMedium, "CloudFormation EBS volume is not encrypted", "CWE-311",
+			[]string{"ebs", "encryption"},
+			"Set Encrypted: true on the AWS::EC2::Volume, or on the Ebs block of each BlockDeviceMappings entry of the instance or launch template.",
+			[]string{"https://cwe.mitre.org/data/definitions/311.html", "https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-resource-ec2-volume.html#cfn-ec2-volume-encrypted"},
+		},
+	}
+
+	out := make([]*rules.Rule, len(defs))
+	for i, d := range defs {
+		out[i] = &rules.Rule{
+			ID:           d.id,
+			Version:      "1.0",
+			Description:  d.description,
+			Severity:     d.severity,
+			Confidence:   findings.ConfidenceHigh,
+			FilePatterns: cfnFilePatterns,
+			Category:     rules.CategoryIaC,
+			Tags:         append([]string{"iac", "cloudformation", "aws"}, d.tags...),
+			Metadata:     map[string]string{"cwe": d.cwe},
+			Remediation:  d.remediation,
+			References:   d.references,
+		}
+	}
+	return out
+}
+
+// cfnTemplate is a parsed CloudFormation or SAM template.
+type cfnTemplate struct {
+	path  string
+	root  *yaml.Node
+	rules map[string]*rules.Rule
+	out   []findings.Finding
+	// resource is the logical ID of the resource being checked, recorded in
+	// the metadata of its findings.
+	resource string
+}
+
+// scanCloudFormation runs the CFN-* checks on content if it is a
+// CloudFormation or SAM template, in JSON or YAML. Findings are located at
+// the offending property, or at the resource when a property is missing.
+func scanCloudFormation(filePath string, content []byte, rs []*rules.Rule) []findings.Finding {
+	if !isCFNCandidate(filePath, content) {
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	resources := mapValue(root, "Resources")
+	if resources == nil || resources.Kind != yaml.MappingNode || !hasAWSResource(resources) {
+		return nil
+	}
+
+	t := &cfnTemplate{path: filePath, root: root, rules: make(map[string]*rules.Rule, len(rs))}
+	for _, r := range rs {
+		t.rules[r.ID] = r
+	}
+	// SAM applies Globals.Function to every AWS::Serverless::Function.
+	if globals := mapValue(mapValue(root, "Globals"), "Function"); globals != nil {
+		t.resource = "Globals.Function"
+		t.checkLambdaEnv("Globals.Function", globals)
+	}
+
+	for i := 0; i+1 < len(resources.Content); i += 2 {
+		name, res := resources.Content[i].Value, resources.Content[i+1]
+		keyNode := resources.Content[i]
+		t.resource = name
+		props := mapValue(res, "Properties")
+		switch scalar(mapValue(res, "Type")) {
+		case "AWS::S3::Bucket":
+			t.checkS3Bucket(name, keyNode, props)
+		case "AWS::EC2::SecurityGroup":
+			for _, rule := range items(mapValue(props, "SecurityGroupIngress")) {
+				t.checkIngress(name, rule)
+			}
+		case "AWS::EC2::SecurityGroupIngress":
+			t.checkIngress(name, props)
+		case "AWS::IAM::Policy", "AWS::IAM::ManagedPolicy":
+			t.checkPolicyDocument(name, mapValue(props, "PolicyDocument"))
+		case "AWS::IAM::Role", "AWS::IAM::User", "AWS::IAM::Group":
+			for _, p := range items(mapValue(props, "Policies")) {
+				t.checkPolicyDocument(name, mapValue(p, "PolicyDocument"))
+			}
+		case "AWS::Lambda::Function", "AWS::Serverless::Function":
+			t.checkLambdaEnv(name, props)
+		case "AWS::RDS::DBInstance":
+			// Instances of an Aurora cluster inherit the cluster's
+			// encryption setting.
+			if mapValue(props, "DBClusterIdentifier") == nil {
+				t.checkEncrypted(cfnRDSUnencrypted, keyNode, props, "StorageEncrypted", "RDS instance "+name)
+			}
+		case "AWS::RDS::DBCluster":
+			t.checkEncrypted(cfnRDSUnencrypted, keyNode, props, "StorageEncrypted", "RDS cluster "+name)
+		case "AWS::EC2::Volume":
+			t.checkEncrypted(cfnEBSUnencrypted, keyNode, props, "Encrypted", "EBS volume "+name)
+		case "AWS::EC2::Instance":
+			t.checkBlockDevices(name, mapValue(props, "BlockDeviceMappings"))
+		case "AWS::EC2::LaunchTemplate":
+			t.checkBlockDevices(name, mapValue(mapValue(props, "LaunchTemplateData"), "BlockDeviceMappings"))
+		}
+	}
+	return t.out
+}
+
+// isCFNCandidate is a cheap check that filePath may hold a template before
+// it is parsed.
+func isCFNCandidate(filePath string, content []byte) bool {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(filePath, "\\", "/")))
+	ext := path.Ext(base)
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" && ext != ".template" {
+		return false
+	}
+	return bytes.Contains(content, []byte("AWS::")) && bytes.Contains(content, []byte("Resources"))
+}
+
+// hasAWSResource reports whether a Resources mapping declares at least one
+// resource with an AWS:: type.
+func hasAWSResource(resources *yaml.Node) bool {
+	for i := 1; i < len(resources.Content); i += 2 {
+		if strings.HasPrefix(scalar(mapValue(resources.Content[i], "Type")), "AWS::") {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *cfnTemplate) checkS3Bucket(name string, keyNode, props *yaml.Node) {
+	if mapValue(props, "BucketEncryption") == nil {
+		t.report(cfnS3Encryption, keyNode, fmt.Sprintf("S3 bucket %s has no BucketEncryption", name))
+	}
+	block := mapValue(props, "PublicAccessBlockConfiguration")
+	if block == nil {
+		t.report(cfnS3PublicAccess, keyNode, fmt.Sprintf("S3 bucket %s has no PublicAccessBlockConfiguration", name))
+		return
+	}
+	for _, setting := range []string{"BlockPublicAcls", "BlockPublicPolicy", "IgnorePublicAcls", "RestrictPublicBuckets"} {
+		v := mapValue(block, setting)
+		if v == nil {
+			t.report(cfnS3PublicAccess, block, fmt.Sprintf("S3 bucket %s does not set %s in PublicAccessBlockConfiguration", name, setting))
+			continue
+		}
+		if on, known := boolValue(v); known && !on {
+			t.report(cfnS3PublicAccess, v, fmt.Sprintf("S3 bucket %s sets %s to false", name, setting))
+		}
+	}
+}
+
+// checkIngress reports an ingress rule open to 0.0.0.0/0 or ::/0 whose
+// port range includes a sensitive port.
+func (t *cfnTemplate) checkIngress(name string, rule *yaml.Node) {
+	if rule == nil {
+		return
+	}
+	cidr := mapValue(rule, "CidrIp")
+	if cidr == nil {
+		cidr = mapValue(rule, "CidrIpv6")
+	}
+	if v := scalar(cidr); v != "0.0.0.0/0" && v != "::/0" {
+		return
+	}
+	protocol := scalar(mapValue(rule, "IpProtocol"))
+	from, fromOK := intValue(mapValue(rule, "FromPort"))
+	to, toOK := intValue(mapValue(rule, "ToPort"))
+	var port int
+	switch {
+	case protocol == "-1" || protocol == "all":
+		port = -1
+	case fromOK && toOK:
+		port = sensitivePortIn(from, to)
+	case fromOK:
+		port = sensitivePortIn(from, from)
+	}
+	switch {
+	case port == -1:
+		t.report(cfnOpenIngress, cidr, fmt.Sprintf("security group %s allows all traffic from %s", name, scalar(cidr)))
+	case port > 0:
+		t.report(cfnOpenIngress, cidr, fmt.Sprintf("security group %s allows port %d from %s", name, port, scalar(cidr)))
+	}
+}
+
+// sensitivePortIn returns the first sensitive port in [from, to], -1 if
+// the range covers every port, or 0 if it has none.
+func sensitivePortIn(from, to int) int {
+	if from <= 0 && to >= 65535 {
+		return -1
+	}
+	for _, p := range cfnSensitivePorts {
+		if p >= from && p <= to {
+			return p
+		}
+	}
+	return 0
+}
+
+// checkPolicyDocument reports Allow statements whose Action and Resource
+// are both "*".
+func (t *cfnTemplate) checkPolicyDocument(name string, doc *yaml.Node) {
+	for _, stmt := range items(mapValue(doc, "Statement")) {
+		if effect := scalar(mapValue(stmt, "Effect")); effect != "" && effect != "Allow" {
+			continue
+		}
+		action := mapValue(stmt, "Action")
+		if hasWildcard(action, "*", "*:*") && hasWildcard(mapValue(stmt, "Resource"), "*") {
+			t.report(cfnIAMWildcard, action, fmt.Sprintf("IAM policy in %s allows Action \"*\" on Resource \"*\"", name))
+		}
+	}
+}
+
+func hasWildcard(n *yaml.Node, wildcards ...string) bool {
+	if n == nil {
+		return false
+	}
+	values := []*yaml.Node{n}
+	if n.Kind == yaml.SequenceNode {
+		values = n.Content
+	}
+	for _, v := range values {
+		for _, w := range wildcards {
+			if scalar(v) == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkLambdaEnv reports environment variables whose name suggests a
+// secret and whose value is a literal rather than an intrinsic function or
+// dynamic reference.
+func (t *cfnTemplate) checkLambdaEnv(name string, props *yaml.Node) {
+	vars := mapValue(mapValue(props, "Environment"), "Variables")
+	if vars == nil || vars.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		key, val := vars.Content[i], vars.Content[i+1]
+		if !cfnSecretVarName.MatchString(key.Value) || !isLiteral(val) {
+			continue
+		}
+		v := val.Value
+		if v == "" || strings.Contains(v, "{{resolve:") || strings.HasPrefix(v, "arn:") {
+			continue
+		}
+		t.report(cfnLambdaSecretEnv, val, fmt.Sprintf("Lambda function %s sets %s to a plaintext value", name, key.Value))
+	}
+}
+
+// checkEncrypted reports a resource whose encryption property is missing
+// or false. Values set by an intrinsic function are not reported.
+func (t *cfnTemplate) checkEncrypted(ruleID string, at, props *yaml.Node, property, subject string) {
+	v := mapValue(props, property)
+	if v == nil {
+		t.report(ruleID, at, fmt.Sprintf("%s does not set %s", subject, property))
+		return
+	}
+	if on, known := boolValue(v); known && !on {
+		t.report(ruleID, v, fmt.Sprintf("%s sets %s to false", subject, property))
+	}
+}
+
+// checkBlockDevices reports EBS block device mappings that are not
+// encrypted.
+func (t *cfnTemplate) checkBlockDevices(name string, mappings *yaml.Node) {
+	for _, m := range items(mappings) {
+		ebs := mapValue(m, "Ebs")
+		if ebs == nil {
+			continue
+		}
+		subject := "EBS block device of " + name
+		if device := scalar(mapValue(m, "DeviceName")); device != "" {
+			subject = fmt.Sprintf("EBS block device %s of %s", device, name)
+		}
+		t.checkEncrypted(cfnEBSUnencrypted, ebs, ebs, "Encrypted", subject)
+	}
+}
+
+func (t *cfnTemplate) report(ruleID string, at *yaml.Node, message string) {
+	r := t.rules[ruleID]
+	if r == nil {
+		return
+	}
+	loc := findings.Location{FilePath: t.path, StartLine: at.Line, EndLine: at.Line, StartColumn: at.Column}
+	meta := maps.Clone(r.Metadata)
+	if t.resource != "" {
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[findings.MetaResource] = t.resource
+	}
+	t.out = append(t.out, findings.Finding{
+		ID:          fmt.Sprintf("%s:%s:%d", r.ID, t.path, at.Line),
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		Location:    loc,
+		Message:     message,
+		Metadata:    meta,
+		Fingerprint: findings.ComputeFingerprint(r.ID, loc, message),
+	})
+}
+
+// mapValue returns the value of key in the mapping n, or nil.
+func mapValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// items returns the elements of a sequence node, or nil.
+func items(n *yaml.Node) []*yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return n.Content
+}
+
+// isLiteral reports whether n is a plain scalar rather than an intrinsic
+// function, either as a short-form tag (!Ref, !Sub) or a JSON-style
+// {"Ref": ...} / {"Fn::...": ...} mapping.
+func isLiteral(n *yaml.Node) bool {
+	return n != nil && n.Kind == yaml.ScalarNode && (n.Tag == "" || strings.HasPrefix(n.Tag, "!!"))
+}
+
+// scalar returns the value of a literal scalar node, or "".
+func scalar(n *yaml.Node) string {
+	if !isLiteral(n) {
+		return ""
+	}
+	return n.Value
+}
+
+// boolValue returns the boolean value of a literal scalar. known is false
+// for intrinsic functions and other values that are not a boolean.
+func boolValue(n *yaml.Node) (value, known bool) {
+	b, err := strconv.ParseBool(scalar(n))
+	if err != nil {
+		return false, false
+	}
+	return b, true
+}
+
+// intValue returns the integer value of a literal scalar.
+func intValue(n *yaml.Node) (int, bool) {
+	v, err := strconv.Atoi(scalar(n))
+	return v, err == nil
+}
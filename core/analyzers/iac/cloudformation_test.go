@@ -0,0 +1,216 @@
+package iac
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// cfnFindings returns "RULE:line" for each CFN-* finding of a scan.
+func cfnFindings(t *testing.T, path, content string) []string {
+	t.Helper()
+	results, err := NewAnalyzer().ScanFile(path, []byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, f := range results {
+		if strings.HasPrefix(f.RuleID, "CFN-") {
+			got = append(got, fmt.Sprintf("%s:%d", f.RuleID, f.Location.StartLine))
+		}
+	}
+	return got
+}
+
+func TestCloudFormation_YAMLTemplate(t *testing.T) {
+	content := `AWSTemplateFormatVersion: "2010-09-09"
+Transform: AWS::Serverless-2016-10-31
+Globals:
+  Function:
+    Environment:
+      Variables:
+        API_KEY: sk-live-1234567890
+Resources:
+  Logs:
+    Type: AWS::S3::Bucket
+  Data:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub "${AWS::StackName}-data"
+      BucketEncryption:
+        ServerSideEncryptionConfiguration:
+          - ServerSideEncryptionByDefault:
+              SSEAlgorithm: aws:kms
+      PublicAccessBlockConfiguration:
+        BlockPublicAcls: true
+        BlockPublicPolicy: false
+        IgnorePublicAcls: true
+        RestrictPublicBuckets: !Ref Restrict
+  WebSG:
+    Type: AWS::EC2::SecurityGroup
+    Properties:
+      GroupDescription: web
+      SecurityGroupIngress:
+        - IpProtocol: tcp
+          FromPort: 443
+          ToPort: 443
+          CidrIp: 0.0.0.0/0
+        - IpProtocol: tcp
+          FromPort: 22
+          ToPort: 22
+          CidrIp: 0.0.0.0/0
+  AdminPolicy:
+    Type: AWS::IAM::ManagedPolicy
+    Properties:
+      PolicyDocument:
+        Statement:
+          - Effect: Allow
+            Action: "*"
+            Resource: "*"
+  Handler:
+    Type: AWS::Serverless::Function
+    Properties:
+      Environment:
+        Variables:
+          DB_PASSWORD: hunter2
+          SECRET_ARN: !Ref DbSecret
+          API_TOKEN: "{{resolve:secretsmanager:api-token}}"
+          LOG_LEVEL: debug
+  Db:
+    Type: AWS::RDS::DBInstance
+    Properties:
+      StorageEncrypted: false
+  Disk:
+    Type: AWS::EC2::Volume
+    Properties:
+      Encrypted: !Ref EncryptDisks
+`
+	got := cfnFindings(t, "template.yaml", content)
+	want := []string{
+		"CFN-005:7",  // Globals API_KEY
+		"CFN-001:9",  // Logs: no encryption
+		"CFN-002:9",  // Logs: no public access block
+		"CFN-002:21", // Data: BlockPublicPolicy false
+		"CFN-003:36", // WebSG: port 22
+		"CFN-004:43", // AdminPolicy
+		"CFN-005:50", // Handler DB_PASSWORD
+		"CFN-006:57", // Db: StorageEncrypted false
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
+
+func TestCloudFormation_JSONTemplate(t *testing.T) {
+	content := `{
+  "Resources": {
+    "Ingress": {
+      "Type": "AWS::EC2::SecurityGroupIngress",
+      "Properties": {
+        "GroupId": {"Ref": "SG"},
+        "IpProtocol": "tcp",
+        "FromPort": 3000,
+        "ToPort": 4000,
+        "CidrIpv6": "::/0"
+      }
+    },
+    "Role": {
+      "Type": "AWS::IAM::Role",
+      "Properties": {
+        "Policies": [{
+          "PolicyName": "admin",
+          "PolicyDocument": {
+            "Statement": [{"Effect": "Allow", "Action": ["*"], "Resource": "*"}]
+          }
+        }]
+      }
+    },
+    "Server": {
+      "Type": "AWS::EC2::Instance",
+      "Properties": {
+        "BlockDeviceMappings": [
+          {"DeviceName": "/dev/xvda", "Ebs": {"VolumeSize": 20}},
+          {"DeviceName": "/dev/xvdb", "Ebs": {"Encrypted": true}}
+        ]
+      }
+    },
+    "Cluster": {
+      "Type": "AWS::RDS::DBCluster",
+      "Properties": {"Engine": "aurora-postgresql", "StorageEncrypted": true}
+    },
+    "Member": {
+      "Type": "AWS::RDS::DBInstance",
+      "Properties": {"DBClusterIdentifier": {"Ref": "Cluster"}}
+    }
+  }
+}
+`
+	got := cfnFindings(t, "stack.template", content)
+	want := []string{
+		"CFN-003:10", // Ingress: 3306 in 3000-4000 from ::/0
+		"CFN-004:19", // Role inline policy
+		"CFN-007:28", // Server /dev/xvda
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
+
+func TestCloudFormation_ResourceMetadata(t *testing.T) {
+	content := `Transform: AWS::Serverless-2016-10-31
+Globals:
+  Function:
+    Environment:
+      Variables:
+        API_KEY: sk-live-1234567890
+Resources:
+  Logs:
+    Type: AWS::S3::Bucket
+`
+	results, err := NewAnalyzer().ScanFile("template.yaml", []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, f := range results {
+		if strings.HasPrefix(f.RuleID, "CFN-") {
+			got[f.RuleID] = f.Metadata[findings.MetaResource]
+		}
+	}
+	want := map[string]string{"CFN-001": "Logs", "CFN-002": "Logs", "CFN-005": "Globals.Function"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("resources = %v, want %v", got, want)
+	}
+}
+
+func TestCloudFormation_IgnoresOtherYAML(t *testing.T) {
+	content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: aws
+data:
+  note: "Resources of type AWS::S3::Bucket are created elsewhere"
+`
+	if got := cfnFindings(t, "configmap.yaml", content); len(got) != 0 {
+		t.Errorf("expected no CFN findings, got %v", got)
+	}
+	if got := cfnFindings(t, "main.tf", `resource "aws_s3_bucket" "b" {} # AWS::S3::Bucket Resources`); len(got) != 0 {
+		t.Errorf("expected no CFN findings in Terraform, got %v", got)
+	}
+}
+
+func TestCloudFormation_RulesInCatalog(t *testing.T) {
+	rs := NewAnalyzer().Rules()
+	for _, id := range []string{cfnS3Encryption, cfnS3PublicAccess, cfnOpenIngress, cfnIAMWildcard, cfnLambdaSecretEnv, cfnRDSUnencrypted, cfnEBSUnencrypted} {
+		r, ok := rs.ByID(id)
+		if !ok {
+			t.Errorf("rule %s missing from Rules()", id)
+			continue
+		}
+		if r.Remediation == "" || r.Metadata["cwe"] == "" {
+			t.Errorf("rule %s lacks remediation or CWE", id)
+		}
+	}
+}
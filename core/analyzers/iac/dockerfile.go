@@ -0,0 +1,74 @@
+package iac
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/nox-hq/nox/core/dockerfile"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// runtimeDockerfileRules are Dockerfile rules about the container that runs
+// in production: its user, healthcheck, exposed ports and package caches.
+// In a multi-stage build only the final stage and the stages it is built
+// FROM end up in the image, so findings in builder stages are dropped.
+// Supply-chain rules such as IAC-002 still apply to every stage.
+var runtimeDockerfileRules = map[string]bool{
+	"IAC-001": true,
+	"IAC-121": true,
+	"IAC-122": true,
+	"IAC-126": true,
+	"IAC-127": true,
+	"IAC-128": true,
+	"IAC-129": true,
+	"IAC-341": true,
+	"IAC-342": true,
+}
+
+// scopeDockerfile adjusts the engine findings for a Dockerfile to its build
+// stages. Runtime rules are kept only for the stages that make up the final
+// image, IAC-001 only for the USER instruction that is still in effect, and
+// IAC-002 is dropped for FROM lines that name an earlier stage.
+func scopeDockerfile(path string, content []byte, results []findings.Finding) []findings.Finding {
+	if !dockerfile.IsDockerfile(path) {
+		return results
+	}
+	df, err := dockerfile.Parse(content)
+	if err != nil || df.Final() == nil {
+		return results
+	}
+
+	// The last USER instruction of the runtime stages sets the user the
+	// container runs as; earlier ones only affect the build.
+	runtime := df.Runtime()
+	userLine := 0
+	for _, s := range runtime {
+		for _, ins := range s.Instructions {
+			if ins.Cmd == "USER" {
+				userLine = ins.Line
+			}
+		}
+	}
+
+	lines := bytes.Split(content, []byte("\n"))
+	kept := results[:0]
+	for _, f := range results {
+		// Patterns starting with ^\s* can match from a preceding blank
+		// line, so skip to the line holding the instruction.
+		line := f.Location.StartLine
+		for line > 0 && line < len(lines) && len(bytes.TrimSpace(lines[line-1])) == 0 {
+			line++
+		}
+		stage := df.StageAt(line)
+		switch {
+		case f.RuleID == "IAC-002" && stage != nil && stage.Line == line && stage.Base >= 0:
+			continue
+		case f.RuleID == "IAC-001" && line != userLine:
+			continue
+		case runtimeDockerfileRules[f.RuleID] && !slices.Contains(runtime, stage):
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
@@ -6,6 +6,7 @@ import "github.com/nox-hq/nox/core/rules"
 func builtinIaCRules() []rules.Rule {
 	all := builtinBaseIaCRules()
 	all = append(all, builtinAnsibleRules()...)
+	all = append(all, builtinAnsibleVaultRules()...)
 	all = append(all, builtinKustomizeRules()...)
 	all = append(all, builtinServerlessRules()...)
 	all = append(all, builtinExpandedIaCRules()...)
@@ -0,0 +1,63 @@
+package iac
+
+import (
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// builtinAnsibleVaultRules returns the Ansible credential rules matched by
+// pattern (ANS-003 and ANS-004). The other ANS-* rules are checked in Go;
+// see ansibleRules.
+func builtinAnsibleVaultRules() []rules.Rule {
+	defs := []iacRule{
+		{
+			// Values that are templated, !vault tagged or vault-encrypted
+			// do not match: the first character of the value may not be
+			// {, ! or $.
+			id: "ANS-003", severity: findings.SeverityCritical, confidence: findings.ConfidenceHigh,
+			pattern:      `(?i)\bansible_(?:become_pass(?:word)?|ssh_pass(?:word)?|password|sudo_pass)[ \t]*[=:][ \t]*['"]?[^\s'"{!$]`,
+			description:  "Ansible inventory sets a connection or become password in plaintext",
+			cwe:          "CWE-798",
+			keywords:     []string{"ansible_become_pass", "ansible_ssh_pass", "ansible_password", "ansible_sudo_pass"},
+			filePatterns: []string{"hosts", "hosts.*", "inventory", "inventory.*", "*.ini", "*.yml", "*.yaml", "group_vars/*", "host_vars/*", "*/group_vars/*", "*/host_vars/*"},
+			tags:         []string{"iac", "ansible", "secrets", "vault"},
+			remediation:  "Move the password to a vault-encrypted group_vars or host_vars file and set the variable from it, e.g. ansible_become_pass: '{{ vault_become_pass }}', or read it with a lookup plugin. Prefer SSH keys to ansible_ssh_pass, and --ask-become-pass for interactive runs.",
+			references:   []string{"https://cwe.mitre.org/data/definitions/798.html", "https://docs.ansible.com/ansible/latest/vault_guide/vault_encrypting_content.html", "https://docs.ansible.com/ansible/latest/plugins/lookup.html"},
+		},
+		{
+			// Templated passwords are reported too: a password on the
+			// command line is visible in the process list and in the task
+			// output.
+			id: "ANS-004", severity: findings.SeverityHigh, confidence: findings.ConfidenceMedium,
+			pattern:      `(?i)\b(?:shell|command|cmd)[ \t]*:[^\n]*?(?:--pass(?:word|wd)?[ \t=]+['"]?[^\s'"-]|\bsshpass[ \t]+-p[ \t]*[^\s-]|\bmysql\w*[ \t][^\n]*?[ \t]-p['"]?[^\s'"]|\b\w*(?:password|passwd)=['"]?[^\s'"&])`,
+			description:  "Ansible command or shell task passes a password on the command line",
+			cwe:          "CWE-214",
+			keywords:     []string{"shell", "command", "cmd"},
+			filePatterns: []string{"*.yml", "*.yaml"},
+			tags:         []string{"iac", "ansible", "secrets", "vault"},
+			remediation:  "Use a dedicated module that takes the password as a parameter, such as community.mysql.mysql_user or community.postgresql.postgresql_query, or pass it through the task's environment or stdin. Keep the password in a vaulted variable or read it with a lookup plugin, and set no_log: true on the task.",
+			references:   []string{"https://cwe.mitre.org/data/definitions/214.html", "https://docs.ansible.com/ansible/latest/vault_guide/index.html", "https://docs.ansible.com/ansible/latest/plugins/lookup.html"},
+		},
+	}
+
+	out := make([]rules.Rule, len(defs))
+	for i := range defs {
+		out[i] = rules.Rule{
+			ID:           defs[i].id,
+			Version:      "1.0",
+			Description:  defs[i].description,
+			Severity:     defs[i].severity,
+			Confidence:   defs[i].confidence,
+			MatcherType:  "regex",
+			Pattern:      defs[i].pattern,
+			FilePatterns: defs[i].filePatterns,
+			Keywords:     defs[i].keywords,
+			Category:     rules.CategoryIaC,
+			Tags:         defs[i].tags,
+			Metadata:     map[string]string{"cwe": defs[i].cwe},
+			Remediation:  defs[i].remediation,
+			References:   defs[i].references,
+		}
+	}
+	return out
+}
@@ -2654,6 +2654,7 @@ func builtinExpandedIaCRules() []rules.Rule {
 			Pattern:      defs[i].pattern,
 			FilePatterns: defs[i].filePatterns,
 			Keywords:     defs[i].keywords,
+			Category:     rules.CategoryIaC,
 			Tags:         defs[i].tags,
 			Metadata:     map[string]string{"cwe": defs[i].cwe},
 			Remediation:  defs[i].remediation,
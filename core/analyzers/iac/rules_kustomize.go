@@ -189,6 +189,7 @@ func builtinKustomizeRules() []rules.Rule {
 			Pattern:      defs[i].pattern,
 			FilePatterns: defs[i].filePatterns,
 			Keywords:     defs[i].keywords,
+			Category:     rules.CategoryIaC,
 			Tags:         defs[i].tags,
 			Metadata:     map[string]string{"cwe": defs[i].cwe},
 			Remediation:  defs[i].remediation,
@@ -41,12 +41,19 @@ type tfResourceChange struct {
 // ScanTerraformPlan parses a terraform plan JSON file and checks planned
 // resource values against IaC security rules.
 func ScanTerraformPlan(planPath string) (*findings.FindingSet, error) {
+	return ScanTerraformPlanAs(planPath, planPath)
+}
+
+// ScanTerraformPlanAs is ScanTerraformPlan with findings located at
+// reportPath, such as the plan's path relative to the scan target, instead
+// of planPath.
+func ScanTerraformPlanAs(planPath, reportPath string) (*findings.FindingSet, error) {
 	data, err := os.ReadFile(planPath)
 	if err != nil {
 		return nil, fmt.Errorf("reading terraform plan %s: %w", planPath, err)
 	}
 
-	return scanTerraformPlanContent(data, planPath)
+	return scanTerraformPlanContent(data, reportPath)
 }
 
 // scanTerraformPlanContent parses and scans terraform plan JSON content.
@@ -90,7 +97,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 					Confidence: findings.ConfidenceMedium,
 					Location:   findings.Location{FilePath: path, StartLine: 1},
 					Message:    fmt.Sprintf("Planned resource %s allows public access (0.0.0.0/0)", address),
-					Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+					Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 				})
 			}
 		}
@@ -104,7 +111,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 			Confidence: findings.ConfidenceLow,
 			Location:   findings.Location{FilePath: path, StartLine: 1},
 			Message:    fmt.Sprintf("Planned resource %s has encryption disabled", address),
-			Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+			Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 		})
 	}
 
@@ -116,7 +123,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 			Confidence: findings.ConfidenceHigh,
 			Location:   findings.Location{FilePath: path, StartLine: 1},
 			Message:    fmt.Sprintf("Planned resource %s is publicly accessible", address),
-			Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+			Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 		})
 	}
 
@@ -128,7 +135,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 			Confidence: findings.ConfidenceHigh,
 			Location:   findings.Location{FilePath: path, StartLine: 1},
 			Message:    fmt.Sprintf("Planned resource %s has storage encryption disabled", address),
-			Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+			Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 		})
 	}
 
@@ -141,7 +148,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 				Confidence: findings.ConfidenceMedium,
 				Location:   findings.Location{FilePath: path, StartLine: 1},
 				Message:    fmt.Sprintf("Planned resource %s uses HTTP instead of HTTPS", address),
-				Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+				Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 			})
 		}
 	}
@@ -155,7 +162,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 				Confidence: findings.ConfidenceHigh,
 				Location:   findings.Location{FilePath: path, StartLine: 1},
 				Message:    fmt.Sprintf("Planned resource %s has public ACL: %s", address, acl),
-				Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+				Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 			})
 		}
 	}
@@ -168,7 +175,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 			Confidence: findings.ConfidenceMedium,
 			Location:   findings.Location{FilePath: path, StartLine: 1},
 			Message:    fmt.Sprintf("Planned resource %s allows SSH access (port 22)", address),
-			Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+			Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 		})
 	}
 
@@ -180,7 +187,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 			Confidence: findings.ConfidenceHigh,
 			Location:   findings.Location{FilePath: path, StartLine: 1},
 			Message:    fmt.Sprintf("Planned resource %s allows HTTP traffic", address),
-			Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+			Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 		})
 	}
 
@@ -192,7 +199,7 @@ func checkTfResourceValues(fs *findings.FindingSet, address, resourceType string
 			Confidence: findings.ConfidenceHigh,
 			Location:   findings.Location{FilePath: path, StartLine: 1},
 			Message:    fmt.Sprintf("Planned resource %s runs as privileged", address),
-			Metadata:   map[string]string{"resource": address, "source": "terraform-plan"},
+			Metadata:   map[string]string{findings.MetaResource: address, "source": "terraform-plan"},
 		})
 	}
 }
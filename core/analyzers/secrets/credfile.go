@@ -0,0 +1,193 @@
+package secrets
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// Cloud CLI credential files are flagged by the file as a whole rather
+// than by a token format: a copy of ~/.aws/credentials, a kubeconfig, a
+// .netrc or gcloud application default credentials grants whatever the
+// account behind it can do. They are not run by the rules engine. A file is
+// recognised by its name or by its content, and one finding is reported
+// per file, at the line of the credential.
+const (
+	awsCredentialsRuleID = "SEC-962"
+	kubeconfigRuleID     = "SEC-963"
+	netrcRuleID          = "SEC-964"
+	gcloudADCRuleID      = "SEC-965"
+)
+
+// credentialFile describes one kind of CLI credential file.
+type credentialFile struct {
+	rule *rules.Rule
+	// names are file name patterns, compared case-insensitively. A pattern
+	// with a slash is matched against as many trailing path elements.
+	names []string
+	// format matches any file of the kind, with or without a credential.
+	format *regexp.Regexp
+	// credential matches a credential in the file; its first group is the
+	// value.
+	credential *regexp.Regexp
+	// requires must also match for content alone to identify the file.
+	requires []*regexp.Regexp
+}
+
+var credentialFiles = []credentialFile{
+	{
+		rule: credentialFileRule(awsCredentialsRuleID,
+			"AWS CLI shared credentials file committed",
+			"Deactivate and delete the access key in IAM (aws iam update-access-key --status Inactive, then aws iam delete-access-key), create a new key for the profile, and remove the file from the repository and its history. Prefer IAM Identity Center (aws sso login) or role credentials over long-lived keys.",
+			"https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-files.html"),
+		names:      []string{".aws/credentials", "aws-credentials", "aws_credentials", "aws-credentials.ini", "aws_credentials.ini"},
+		format:     regexp.MustCompile(`(?m)^[ \t]*\[[\w .-]+\][ \t]*$`),
+		credential: regexp.MustCompile(`(?mi)^[ \t]*aws_secret_access_key[ \t]*=[ \t]*(\S+)`),
+		requires:   []*regexp.Regexp{regexp.MustCompile(`(?mi)^[ \t]*aws_access_key_id[ \t]*=`)},
+	},
+	{
+		rule: credentialFileRule(kubeconfigRuleID,
+			"Kubernetes kubeconfig with embedded user credentials committed",
+			"Revoke the embedded credential: delete the service account token or its Secret, remove the RBAC bindings of a client certificate (certificates cannot be revoked before they expire) and reissue it, and change static passwords. Remove the file from the repository and its history, and authenticate with an exec plugin such as aws eks get-token or gke-gcloud-auth-plugin instead.",
+			"https://kubernetes.io/docs/concepts/configuration/organize-cluster-access-kubeconfig/"),
+		names:      []string{".kube/config", "kubeconfig", "kubeconfig.yaml", "kubeconfig.yml", "*.kubeconfig", "*-kubeconfig", "*_kubeconfig", "*-kubeconfig.yaml", "*-kubeconfig.yml"},
+		format:     regexp.MustCompile(`(?m)^kind:[ \t]*["']?Config["']?[ \t]*$`),
+		credential: regexp.MustCompile(`(?m)^[ \t]+(?:token|client-key-data|password|id-token|refresh-token):[ \t]*(\S+)`),
+		requires:   []*regexp.Regexp{regexp.MustCompile(`(?m)^users:`)},
+	},
+	{
+		rule: credentialFileRule(netrcRuleID,
+			".netrc file with a password committed",
+			"Change the password or revoke the token of each machine entry, and remove the file from the repository and its history. Keep .netrc in the home directory with mode 600, or pass credentials to the tool from a secret store.",
+			"https://everything.curl.dev/usingcurl/netrc"),
+		names:      []string{".netrc", "_netrc", "netrc", "*.netrc"},
+		format:     regexp.MustCompile(`(?m)^[ \t]*(?:machine|default)\b`),
+		credential: regexp.MustCompile(`(?m)^[ \t]*(?:machine[ \t]+\S+|default)(?:\s+(?:login|account|port)\s+\S+)*\s+password\s+(\S+)`),
+	},
+	{
+		rule: credentialFileRule(gcloudADCRuleID,
+			"gcloud application default credentials with a refresh token committed",
+			"Revoke the refresh token with gcloud auth application-default revoke, or remove the Google Cloud SDK's access to the account at https://myaccount.google.com/permissions. Remove the file from the repository and its history, and run gcloud auth application-default login on each machine or use workload identity instead.",
+			"https://cloud.google.com/docs/authentication/application-default-credentials"),
+		names:      []string{"application_default_credentials.json", "legacy_credentials/*/adc.json", "gcloud/credentials.db", "gcloud/access_tokens.db"},
+		format:     regexp.MustCompile(`"type"\s*:`),
+		credential: regexp.MustCompile(`"refresh_token"\s*:\s*"([^"]+)"`),
+		requires:   []*regexp.Regexp{regexp.MustCompile(`"type"\s*:\s*"authorized_user"`)},
+	},
+}
+
+func credentialFileRule(id, description, remediation, reference string) *rules.Rule {
+	return &rules.Rule{
+		ID:          id,
+		Version:     "1.0",
+		Description: description,
+		Severity:    findings.SeverityCritical,
+		Confidence:  findings.ConfidenceHigh,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "credential-file"},
+		Metadata:    map[string]string{"cwe": "CWE-798"},
+		Remediation: remediation,
+		References:  []string{"https://cwe.mitre.org/data/definitions/798.html", reference},
+	}
+}
+
+// credentialFileRules returns the rules of the credential file kinds.
+func credentialFileRules() []*rules.Rule {
+	out := make([]*rules.Rule, len(credentialFiles))
+	for i, c := range credentialFiles {
+		out[i] = c.rule
+	}
+	return out
+}
+
+// matchesCredentialName reports whether p matches one of patterns. Patterns
+// without a slash are matched against the base name and the others against
+// the same number of trailing path elements.
+func matchesCredentialName(p string, patterns []string) bool {
+	elems := strings.Split(strings.ToLower(findings.NormalizePath(p)), "/")
+	for _, pat := range patterns {
+		pat = strings.ToLower(pat)
+		n := strings.Count(pat, "/") + 1
+		if n > len(elems) {
+			continue
+		}
+		if ok, _ := path.Match(pat, strings.Join(elems[len(elems)-n:], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// credentialLine returns the line of the first credential in content that
+// is not a placeholder, or 0.
+func (c credentialFile) credentialLine(content []byte, lineStarts []int) int {
+	for _, m := range c.credential.FindAllSubmatchIndex(content, -1) {
+		v := strings.Trim(string(content[m[2]:m[3]]), `"'`)
+		if !IsPlaceholder(v) {
+			line, _ := lineColumn(lineStarts, m[2])
+			return line
+		}
+	}
+	return 0
+}
+
+// credentialFileFindings returns a finding for each kind of credential file
+// that the file at path is, by name or by content. A file recognised by
+// content holds a credential and is reported with high confidence. A file
+// recognised only by name is reported with medium confidence when its
+// content is not in the expected format, such as an encrypted copy or a
+// gcloud credential database, and not at all when it is in the format but
+// holds no credential, such as a kubeconfig that uses an exec plugin.
+func credentialFileFindings(path string, content []byte) []findings.Finding {
+	var out []findings.Finding
+	var lineStarts []int
+	for _, c := range credentialFiles {
+		byName := matchesCredentialName(path, c.names)
+		if !byName && !c.format.Match(content) {
+			continue
+		}
+		if lineStarts == nil {
+			lineStarts = lineOffsets(content)
+		}
+		line := c.credentialLine(content, lineStarts)
+		inFormat := c.format.Match(content)
+		hasCredential := line > 0 && inFormat
+		byContent := hasCredential
+		for _, re := range c.requires {
+			byContent = byContent && re.Match(content)
+		}
+
+		confidence := findings.ConfidenceHigh
+		var detectedBy string
+		switch {
+		case byName && hasCredential:
+			detectedBy = "filename,content"
+		case byContent:
+			detectedBy = "content"
+		case byName && !inFormat:
+			confidence = findings.ConfidenceMedium
+			detectedBy = "filename"
+			line = 1
+		default:
+			continue
+		}
+
+		r := c.rule
+		loc := findings.Location{FilePath: path, StartLine: line, EndLine: line}
+		out = append(out, findings.Finding{
+			ID:          fmt.Sprintf("%s:%s:%d", r.ID, path, line),
+			RuleID:      r.ID,
+			Severity:    r.Severity,
+			Confidence:  confidence,
+			Location:    loc,
+			Message:     r.Description,
+			Metadata:    map[string]string{"cwe": r.Metadata["cwe"], "detected_by": detectedBy},
+			Fingerprint: findings.ComputeFingerprint(r.ID, loc, path),
+		})
+	}
+	return out
+}
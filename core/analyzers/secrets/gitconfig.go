@@ -0,0 +1,170 @@
+package secrets
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// Git config files are parsed rather than matched by the rules engine, so
+// that a remote URL is told apart from other URLs and the credential helper
+// setting is read from its section. The files are .git/config, which the
+// scan reads from the git directory of the target, and committed copies of
+// a .gitconfig.
+const (
+	gitRemoteCredentialRuleID = "SEC-966"
+	gitCredentialStoreRuleID  = "SEC-967"
+)
+
+// gitConfigNames are the file name patterns of git config files, as
+// matched by matchesCredentialName.
+var gitConfigNames = []string{".git/config", ".gitconfig"}
+
+func gitRemoteCredentialRule() *rules.Rule {
+	return &rules.Rule{
+		ID:          gitRemoteCredentialRuleID,
+		Version:     "1.0",
+		Description: "Git remote URL with embedded credentials",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceHigh,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "git"},
+		Metadata:    map[string]string{"cwe": "CWE-522"},
+		Remediation: "Revoke the token or change the password, then remove it from the URL with git remote set-url <name> <url without user:token@>. Let a credential helper supply it instead: the OS keychain (osxkeychain, manager, libsecret), or gh auth setup-git for GitHub. Where a token is needed in CI, use a short-lived, repository-scoped one such as a GitHub App installation token or a fine-grained PAT with read-only contents access, passed through the environment rather than stored in .git/config.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/522.html", "https://git-scm.com/docs/gitcredentials"},
+	}
+}
+
+func gitCredentialStoreRule() *rules.Rule {
+	return &rules.Rule{
+		ID:          gitCredentialStoreRuleID,
+		Version:     "1.0",
+		Description: "Git credential helper \"store\" saves credentials in plaintext",
+		Severity:    findings.SeverityMedium,
+		Confidence:  findings.ConfidenceHigh,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "git"},
+		Metadata:    map[string]string{"cwe": "CWE-256"},
+		Remediation: "Use a helper that encrypts stored credentials, such as osxkeychain, manager or libsecret, or cache with a timeout. Delete the ~/.git-credentials file (or the --file of the helper) and revoke the credentials it held.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/256.html", "https://git-scm.com/docs/git-credential-store"},
+	}
+}
+
+// gitConfigRules returns the rules of git config files.
+func gitConfigRules() []*rules.Rule {
+	return []*rules.Rule{gitRemoteCredentialRule(), gitCredentialStoreRule()}
+}
+
+var (
+	gitSection  = regexp.MustCompile(`^\s*\[\s*([A-Za-z0-9.-]+)(?:\s+"((?:[^"\\]|\\.)*)")?\s*\]`)
+	gitVariable = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9-]*)\s*=\s*(.*?)\s*$`)
+)
+
+// gitTokenPrefixes are prefixes of tokens that hosts accept as the user
+// name of a URL with no password.
+var gitTokenPrefixes = []string{"ghp_", "gho_", "ghu_", "ghs_", "github_pat_", "glpat-"}
+
+// gitConfigFindings returns a SEC-966 finding for each URL in the git
+// config file at path that carries a password or a token, in a remote's
+// url or pushurl or in a [url "..."] section, and a SEC-967 finding for a
+// credential helper set to store. Other files get none.
+func gitConfigFindings(path string, content []byte) []findings.Finding {
+	if !matchesCredentialName(path, gitConfigNames) {
+		return nil
+	}
+	var out []findings.Finding
+	var section, subsection string
+	for i, line := range strings.Split(string(content), "\n") {
+		lineNo := i + 1
+		if m := gitSection.FindStringSubmatch(line); m != nil {
+			section, subsection = strings.ToLower(m[1]), m[2]
+			if section == "url" && urlHasCredential(subsection) {
+				out = append(out, gitConfigFinding(gitRemoteCredentialRule(), path, lineNo,
+					fmt.Sprintf("Git URL rewrite embeds credentials for %s", urlHost(subsection))))
+			}
+			line = line[len(m[0]):]
+		}
+		m := gitVariable.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		key, value := strings.ToLower(m[1]), strings.Trim(m[2], `"`)
+		switch {
+		case section == "remote" && (key == "url" || key == "pushurl") && urlHasCredential(value):
+			out = append(out, gitConfigFinding(gitRemoteCredentialRule(), path, lineNo,
+				fmt.Sprintf("Git remote %q URL embeds credentials for %s", subsection, urlHost(value))))
+		case section == "credential" && key == "helper" && (value == "store" || strings.HasPrefix(value, "store ")):
+			out = append(out, gitConfigFinding(gitCredentialStoreRule(), path, lineNo, gitCredentialStoreRule().Description))
+		}
+	}
+	return out
+}
+
+func gitConfigFinding(r *rules.Rule, path string, line int, message string) findings.Finding {
+	loc := findings.Location{FilePath: path, StartLine: line, EndLine: line}
+	return findings.Finding{
+		ID:          fmt.Sprintf("%s:%s:%d", r.ID, path, line),
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		Location:    loc,
+		Message:     message,
+		Metadata:    map[string]string{"cwe": r.Metadata["cwe"]},
+		Fingerprint: findings.ComputeFingerprint(r.ID, loc, path),
+	}
+}
+
+// urlHasCredential reports whether s is a URL whose user info holds a
+// password, or a user name that is a token, that is not a placeholder.
+func urlHasCredential(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return false
+	}
+	if pw, ok := u.User.Password(); ok {
+		return pw != "" && !IsPlaceholder(pw)
+	}
+	name := u.User.Username()
+	for _, p := range gitTokenPrefixes {
+		if strings.HasPrefix(name, p) && !IsPlaceholder(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlHost returns the host of the URL s, which the message of a finding
+// names instead of the URL and its credential.
+func urlHost(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "the remote"
+}
+
+// addGitConfigFindings adds the findings of the git config file at path to
+// results, dropping the generic SEC-085 findings of URLs with a password on
+// the lines that SEC-966 reports.
+func addGitConfigFindings(path string, content []byte, results []findings.Finding) []findings.Finding {
+	gc := gitConfigFindings(path, content)
+	if len(gc) == 0 {
+		return results
+	}
+	remote := make(map[int]bool, len(gc))
+	for _, f := range gc {
+		if f.RuleID == gitRemoteCredentialRuleID {
+			remote[f.Location.StartLine] = true
+		}
+	}
+	out := results[:0]
+	for _, f := range results {
+		if f.RuleID != "SEC-085" || !remote[f.Location.StartLine] {
+			out = append(out, f)
+		}
+	}
+	return append(out, gc...)
+}
@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// secretGroupIDLength is the number of HMAC bytes kept in a group ID.
+const secretGroupIDLength = 8
+
+// newGroupKey returns the random key an Analyzer salts secret values with.
+// It lives only as long as the Analyzer, so group IDs cannot be compared
+// across scans.
+func newGroupKey() []byte {
+	key := make([]byte, sha256.Size)
+	_, _ = rand.Read(key)
+	return key
+}
+
+// groupSecrets records a salted hash of each finding's matched value as
+// findings.MetaSecretGroup, so that findings exposing the same credential
+// share a group ID. Values are compared after trimming surrounding spaces
+// and quotes. The value itself is never stored. Findings whose value cannot
+// be re-matched, such as those from file names, get no group.
+func (a *Analyzer) groupSecrets(content []byte, results []findings.Finding) []findings.Finding {
+	var lineStarts []int
+	for i := range results {
+		f := &results[i]
+		rule, ok := a.engine.Rules().ByID(f.RuleID)
+		if !ok {
+			continue
+		}
+		if lineStarts == nil {
+			lineStarts = lineOffsets(content)
+		}
+		start, end, ok := matchedSpan(content, lineStarts, f.Location, rule.Pattern)
+		if !ok {
+			continue
+		}
+		value := normalizeSecretValue(string(content[start:end]))
+		if value == "" {
+			continue
+		}
+		f.Metadata = maps.Clone(f.Metadata)
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[findings.MetaSecretGroup] = a.secretGroupID(value)
+	}
+	return results
+}
+
+// secretGroupID returns the group ID of a normalized secret value.
+func (a *Analyzer) secretGroupID(value string) string {
+	mac := hmac.New(sha256.New, a.groupKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:secretGroupIDLength])
+}
+
+// normalizeSecretValue trims the spaces and quotes around a matched value.
+func normalizeSecretValue(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "\"'`")
+}
@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// DefaultTestDirs are the names of directories that hold tests and
+// fixtures. Secrets findings in files under them get lower confidence.
+var DefaultTestDirs = []string{"testdata", "test", "tests", "__tests__", "spec", "fixtures", "__fixtures__", "__mocks__"}
+
+// FilterStats counts the matches the analyzer's placeholder and test
+// directory heuristics acted on, so that they can be tuned.
+type FilterStats struct {
+	// Placeholders counts matches whose value is a placeholder, by the
+	// action taken and then by PlaceholderKind. Matches of rules with the
+	// "placeholders" flag are always suppressed.
+	Placeholders map[PlaceholderAction]map[string]int
+	// TestDirs counts findings in test directories whose confidence was
+	// lowered.
+	TestDirs int
+}
+
+// filterStats accumulates FilterStats across files.
+type filterStats struct {
+	mu    sync.Mutex
+	stats FilterStats
+}
+
+func (s *filterStats) countPlaceholder(action PlaceholderAction, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats.Placeholders == nil {
+		s.stats.Placeholders = make(map[PlaceholderAction]map[string]int)
+	}
+	if s.stats.Placeholders[action] == nil {
+		s.stats.Placeholders[action] = make(map[string]int)
+	}
+	s.stats.Placeholders[action][kind]++
+}
+
+func (s *filterStats) countTestDir() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.TestDirs++
+}
+
+// FilterStats returns the counts of the matches the heuristics acted on
+// since the analyzer was created.
+func (a *Analyzer) FilterStats() FilterStats {
+	a.stats.mu.Lock()
+	defer a.stats.mu.Unlock()
+	out := FilterStats{TestDirs: a.stats.stats.TestDirs}
+	if len(a.stats.stats.Placeholders) > 0 {
+		out.Placeholders = make(map[PlaceholderAction]map[string]int, len(a.stats.stats.Placeholders))
+		for action, kinds := range a.stats.stats.Placeholders {
+			out.Placeholders[action] = maps.Clone(kinds)
+		}
+	}
+	return out
+}
+
+// SetPlaceholderAction sets what happens to matches whose value is a
+// placeholder. The default is PlaceholderKeep.
+func (a *Analyzer) SetPlaceholderAction(action PlaceholderAction) { a.placeholderAction = action }
+
+// SetTestDirs replaces DefaultTestDirs as the directory names whose
+// findings get lower confidence. An empty list turns the heuristic off.
+func (a *Analyzer) SetTestDirs(dirs []string) { a.testDirs = dirs }
+
+// inTestDir reports whether a directory of path is one of the analyzer's
+// test directories.
+func (a *Analyzer) inTestDir(path string) bool {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+	for _, d := range dirs {
+		if slices.Contains(a.testDirs, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerTestConfidence lowers the confidence of findings in files under a
+// test directory by one level, from high to medium and from medium to
+// low, since such files mostly hold fixtures rather than live secrets.
+func (a *Analyzer) lowerTestConfidence(path string, results []findings.Finding) []findings.Finding {
+	if len(results) == 0 || !a.inTestDir(path) {
+		return results
+	}
+	for i := range results {
+		switch results[i].Confidence {
+		case findings.ConfidenceHigh:
+			results[i].Confidence = findings.ConfidenceMedium
+		case findings.ConfidenceMedium:
+			results[i].Confidence = findings.ConfidenceLow
+		default:
+			continue
+		}
+		a.stats.countTestDir()
+	}
+	return results
+}
@@ -0,0 +1,175 @@
+package secrets
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// importPrefixes start a line that imports or includes another module in a
+// common language. Secrets do not appear in these lines, but module paths
+// and imported names are often long enough to look like keys.
+var importPrefixes = []string{
+	"import ",   // Go, Java, Kotlin, Python, JS/TS
+	"from ",     // Python
+	"export ",   // JS/TS re-exports, checked for " from " below
+	"} from ",   // JS/TS multi-line import
+	"#include ", // C, C++
+	"#import ",  // Objective-C
+	"@import ",  // CSS, Sass
+	"using ",    // C#
+	"use ",      // Rust, PHP, Perl
+	"require ",  // Ruby
+	"require_relative ",
+}
+
+// filterIdentifierMatches drops findings of medium and low confidence regex
+// rules whose match is identifier-shaped code rather than a secret: part of
+// a longer identifier, the name of a called function, or on an import line.
+// Rules with the "raw" metadata flag are kept as matched. A match counts as
+// identifier-shaped when the matched secret, the longest capture group or
+// else the whole match, has only word characters, dashes and dots. Block
+// rules match multi-line spans and are kept as well.
+func (a *Analyzer) filterIdentifierMatches(content []byte, results []findings.Finding) []findings.Finding {
+	var (
+		lineStarts []int
+		imports    map[int]bool
+	)
+	out := results[:0]
+	for _, f := range results {
+		rule, ok := a.engine.Rules().ByID(f.RuleID)
+		if !ok || rule.MatcherType != "regex" || rule.Block != nil || rule.Confidence == findings.ConfidenceHigh || rule.Metadata["raw"] == "true" {
+			out = append(out, f)
+			continue
+		}
+		if lineStarts == nil {
+			lineStarts = lineOffsets(content)
+			imports = importLines(content)
+		}
+		if imports[f.Location.StartLine] || identifierMatch(content, lineStarts, f.Location, rule.Pattern) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// identifierMatch re-runs pattern at the finding location and reports
+// whether the matched secret is embedded in a longer identifier or
+// immediately followed by a call.
+func identifierMatch(content []byte, lineStarts []int, loc findings.Location, pattern string) bool {
+	start, end, ok := matchedSpan(content, lineStarts, loc, pattern)
+	if !ok || !identifierShaped(content[start:end]) {
+		return false
+	}
+	if start > 0 && isWordByte(content[start-1]) {
+		return true
+	}
+	return end < len(content) && (isWordByte(content[end]) || content[end] == '(')
+}
+
+// matchedSpan re-runs pattern at the finding location and returns the byte
+// range of the matched secret: the longest capture group, or else the
+// whole match.
+func matchedSpan(content []byte, lineStarts []int, loc findings.Location, pattern string) (start, end int, ok bool) {
+	if loc.StartLine < 1 || loc.StartLine > len(lineStarts) || loc.StartColumn < 1 {
+		return 0, 0, false
+	}
+	offset := lineStarts[loc.StartLine-1] + loc.StartColumn - 1
+	if offset >= len(content) {
+		return 0, 0, false
+	}
+	re, err := rules.CompilePattern(pattern)
+	if err != nil {
+		return 0, 0, false
+	}
+	m := re.FindSubmatchIndex(content[offset:])
+	if m == nil || m[0] != 0 {
+		return 0, 0, false
+	}
+	start, end = m[0], m[1]
+	if len(m) > 2 {
+		start, end = 0, 0
+		for i := 2; i < len(m); i += 2 {
+			if m[i] >= 0 && m[i+1]-m[i] > end-start {
+				start, end = m[i], m[i+1]
+			}
+		}
+	}
+	return offset + start, offset + end, true
+}
+
+func identifierShaped(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if !isWordByte(c) && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// lineOffsets returns the byte offset of the start of each line.
+func lineOffsets(content []byte) []int {
+	starts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// importLines returns the 1-based numbers of lines that import or include
+// other modules, including the lines of Go import blocks.
+func importLines(content []byte) map[int]bool {
+	lines := make(map[int]bool)
+	inBlock := false
+	for i, raw := range bytes.Split(content, []byte("\n")) {
+		line := strings.TrimSpace(string(raw))
+		n := i + 1
+		if inBlock {
+			lines[n] = true
+			if strings.HasPrefix(line, ")") {
+				inBlock = false
+			}
+			continue
+		}
+		if line == "import (" {
+			lines[n] = true
+			inBlock = true
+			continue
+		}
+		if isImportLine(line) {
+			lines[n] = true
+		}
+	}
+	return lines
+}
+
+func isImportLine(line string) bool {
+	if strings.Contains(line, `require("`) || strings.Contains(line, `require('`) {
+		return true // CommonJS
+	}
+	for _, p := range importPrefixes {
+		if !strings.HasPrefix(line, p) {
+			continue
+		}
+		switch p {
+		case "from ":
+			return strings.Contains(line, " import ")
+		case "export ":
+			return strings.Contains(line, " from ")
+		}
+		return true
+	}
+	return false
+}
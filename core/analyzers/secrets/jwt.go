@@ -0,0 +1,175 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// jwtRuleID is the rule that flags JSON Web Tokens. Its findings are
+// classified by the claims of the token's payload.
+const jwtRuleID = "SEC-084"
+
+// Metadata keys set on JWT findings whose payload decodes.
+const (
+	MetaJWTStatus  = "jwt_status" // "live", "expired" or "test"
+	MetaJWTExpires = "jwt_expires"
+	MetaJWTIssuer  = "jwt_issuer"
+	MetaJWTScope   = "jwt_scope"
+)
+
+var timeNow = time.Now
+
+// testIssuers are iss claims, compared case-insensitively, of tokens made
+// for tests and documentation.
+var testIssuers = []string{"test", "testing", "example", "localhost", "dummy", "fake", "mock"}
+
+// testIssuerDomains are domains reserved for documentation and testing
+// (RFC 2606 and RFC 6761). An issuer on them or their subdomains is a test
+// issuer.
+var testIssuerDomains = []string{"example.com", "example.org", "example.net", "example", "test", "invalid", "localhost"}
+
+// jwtClaims are the payload claims used to classify a token.
+type jwtClaims struct {
+	Exp   json.Number `json:"exp"`
+	Iss   string      `json:"iss"`
+	Scope any         `json:"scope"`
+	Scp   any         `json:"scp"`
+}
+
+// classifyJWTs adjusts JWT findings by the claims of the matched token's
+// payload, which is decoded without verifying the signature. Tokens from a
+// test issuer drop to info severity and expired tokens to low severity;
+// tokens that do not expire or expire in the future keep the rule's
+// severity. Findings whose payload does not decode are kept unchanged.
+func (a *Analyzer) classifyJWTs(content []byte, results []findings.Finding) []findings.Finding {
+	rule, ok := a.engine.Rules().ByID(jwtRuleID)
+	if !ok {
+		return results
+	}
+	var lineStarts []int
+	for i := range results {
+		f := &results[i]
+		if f.RuleID != jwtRuleID {
+			continue
+		}
+		if lineStarts == nil {
+			lineStarts = lineOffsets(content)
+		}
+		start, end, ok := matchedSpan(content, lineStarts, f.Location, rule.Pattern)
+		if !ok {
+			continue
+		}
+		claims, ok := decodeJWTClaims(content[start:end])
+		if !ok {
+			continue
+		}
+		classifyJWT(f, claims, timeNow())
+	}
+	return results
+}
+
+// decodeJWTClaims decodes the payload, the second segment, of token.
+func decodeJWTClaims(token []byte) (jwtClaims, bool) {
+	var claims jwtClaims
+	parts := bytes.Split(token, []byte("."))
+	if len(parts) < 2 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(string(parts[1]), "="))
+	if err != nil {
+		return claims, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return claims, false
+	}
+	return claims, true
+}
+
+// classifyJWT sets the severity, message and metadata of f from claims.
+func classifyJWT(f *findings.Finding, claims jwtClaims, now time.Time) {
+	if f.Metadata == nil {
+		f.Metadata = make(map[string]string)
+	}
+	if claims.Iss != "" {
+		f.Metadata[MetaJWTIssuer] = claims.Iss
+	}
+	if scope := claimScope(claims); scope != "" {
+		f.Metadata[MetaJWTScope] = scope
+	}
+
+	var exp time.Time
+	if secs, err := claims.Exp.Float64(); err == nil && claims.Exp != "" {
+		exp = time.Unix(int64(secs), 0).UTC()
+		f.Metadata[MetaJWTExpires] = exp.Format(time.RFC3339)
+	}
+
+	switch {
+	case isTestIssuer(claims.Iss):
+		f.Severity = findings.SeverityInfo
+		f.Metadata[MetaJWTStatus] = "test"
+		f.Message = fmt.Sprintf("%s (test issuer %q)", f.Message, claims.Iss)
+	case !exp.IsZero() && exp.Before(now):
+		f.Severity = findings.SeverityLow
+		f.Metadata[MetaJWTStatus] = "expired"
+		f.Message = fmt.Sprintf("%s (expired at %s)", f.Message, exp.Format("2006-01-02"))
+	default:
+		f.Metadata[MetaJWTStatus] = "live"
+	}
+}
+
+// claimScope returns the scope or scp claim, either a space-separated
+// string or a list of strings, as a space-separated string.
+func claimScope(claims jwtClaims) string {
+	for _, v := range []any{claims.Scope, claims.Scp} {
+		switch s := v.(type) {
+		case string:
+			if s != "" {
+				return s
+			}
+		case []any:
+			var scopes []string
+			for _, e := range s {
+				if str, ok := e.(string); ok {
+					scopes = append(scopes, str)
+				}
+			}
+			if len(scopes) > 0 {
+				return strings.Join(scopes, " ")
+			}
+		}
+	}
+	return ""
+}
+
+// isTestIssuer reports whether iss, a name or URL, is a well-known test
+// issuer or a host on a domain reserved for documentation and testing.
+func isTestIssuer(iss string) bool {
+	iss = strings.ToLower(strings.TrimSpace(iss))
+	if iss == "" {
+		return false
+	}
+	for _, t := range testIssuers {
+		if iss == t {
+			return true
+		}
+	}
+	host := iss
+	if u, err := url.Parse(iss); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	for _, d := range testIssuerDomains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// testJWT builds a token with the given JSON payload and a dummy signature.
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		enc.EncodeToString([]byte(payload)) + "." + "c2lnbmF0dXJlLXZhbHVl"
+}
+
+func scanJWT(t *testing.T, token string) []findings.Finding {
+	t.Helper()
+	results, err := NewAnalyzer().ScanFile("fixtures/auth.txt", []byte("token: "+token+"\n"))
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	var jwts []findings.Finding
+	for _, f := range results {
+		if f.RuleID == jwtRuleID {
+			jwts = append(jwts, f)
+		}
+	}
+	return jwts
+}
+
+func TestScanFile_JWTClassification(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		severity findings.Severity
+		status   string
+		message  string
+	}{
+		{"no exp", `{"sub":"1234567890"}`, findings.SeverityHigh, "live", ""},
+		{"future exp", `{"sub":"1","exp":4102444800}`, findings.SeverityHigh, "live", ""},
+		{"expired", `{"sub":"1","exp":1577836800}`, findings.SeverityLow, "expired", "expired at 2020-01-01"},
+		{"test issuer", `{"iss":"test","exp":4102444800}`, findings.SeverityInfo, "test", `test issuer "test"`},
+		{"example.com issuer", `{"iss":"https://auth.example.com/","exp":1}`, findings.SeverityInfo, "test", "test issuer"},
+		{"real issuer", `{"iss":"https://accounts.google.com"}`, findings.SeverityHigh, "live", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwts := scanJWT(t, testJWT(tt.payload))
+			if len(jwts) != 1 {
+				t.Fatalf("expected 1 %s finding, got %d", jwtRuleID, len(jwts))
+			}
+			f := jwts[0]
+			if f.Severity != tt.severity {
+				t.Errorf("severity = %s, want %s", f.Severity, tt.severity)
+			}
+			if f.Metadata[MetaJWTStatus] != tt.status {
+				t.Errorf("status = %q, want %q", f.Metadata[MetaJWTStatus], tt.status)
+			}
+			if tt.message != "" && !strings.Contains(f.Message, tt.message) {
+				t.Errorf("message = %q, want it to contain %q", f.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestScanFile_JWTMalformedPayload(t *testing.T) {
+	// The payload decodes to bytes that are not JSON.
+	token := "eyJhbGciOiJIUzI1NiJ9" + ".eyJub3QganNvbg.c2lnbmF0dXJlLXZhbHVl"
+	jwts := scanJWT(t, token)
+	if len(jwts) != 1 {
+		t.Fatalf("expected 1 %s finding, got %d", jwtRuleID, len(jwts))
+	}
+	if jwts[0].Severity != findings.SeverityHigh || jwts[0].Metadata[MetaJWTStatus] != "" {
+		t.Errorf("malformed payload should keep the rule's severity: %+v", jwts[0])
+	}
+}
+
+func TestScanFile_JWTUnsigned(t *testing.T) {
+	enc := base64.RawURLEncoding
+	token := enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(`{"sub":"admin"}`)) + "."
+	if jwts := scanJWT(t, token); len(jwts) != 1 {
+		t.Fatalf("expected unsigned token to be flagged, got %d findings", len(jwts))
+	}
+}
+
+func TestClassifyJWT_ScopeAndExpiry(t *testing.T) {
+	claims, ok := decodeJWTClaims([]byte(testJWT(`{"exp":1700000000,"scp":["read","write"]}`)))
+	if !ok {
+		t.Fatal("expected payload to decode")
+	}
+	f := findings.Finding{Severity: findings.SeverityHigh, Message: "JWT token detected"}
+	classifyJWT(&f, claims, time.Unix(1600000000, 0))
+	if f.Severity != findings.SeverityHigh {
+		t.Errorf("severity = %s, want high before exp", f.Severity)
+	}
+	if f.Metadata[MetaJWTScope] != "read write" {
+		t.Errorf("scope = %q", f.Metadata[MetaJWTScope])
+	}
+	if f.Metadata[MetaJWTExpires] != "2023-11-14T22:13:20Z" {
+		t.Errorf("expires = %q", f.Metadata[MetaJWTExpires])
+	}
+}
+
+func TestIsTestIssuer(t *testing.T) {
+	for iss, want := range map[string]bool{
+		"test":                       true,
+		"Example":                    true,
+		"https://idp.example.org":    true,
+		"http://localhost:8080/auth": true,
+		"issuer.test":                true,
+		"https://login.microsoftonline.com/tenant": false,
+		"acme":        false,
+		"":            false,
+		"contest.com": false,
+	} {
+		if got := isTestIssuer(iss); got != want {
+			t.Errorf("isTestIssuer(%q) = %v, want %v", iss, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// keyFileRuleID flags private key and keystore files by name. It is not run
+// by the rules engine because these files are often binary.
+const keyFileRuleID = "SEC-951"
+
+// DefaultKeyFilePatterns are file name patterns for private SSH keys and
+// PKCS#12 and Java keystores.
+var DefaultKeyFilePatterns = []string{
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	"*.p12",
+	"*.pfx",
+	"*.keystore",
+	"*.jks",
+}
+
+func keyFileRule() *rules.Rule {
+	return &rules.Rule{
+		ID:          keyFileRuleID,
+		Version:     "1.0",
+		Description: "Private key or keystore file committed",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceMedium,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "filename"},
+		Metadata:    map[string]string{"cwe": "CWE-321"},
+		Remediation: "Remove the key file from the repository and its history, add it to .gitignore, and regenerate the key pair or keystore.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/321.html"},
+	}
+}
+
+// keyFileFinding returns a SEC-951 finding if the name of path matches a
+// key file pattern.
+func (a *Analyzer) keyFileFinding(path string) (findings.Finding, bool) {
+	if !discovery.MatchesName(path, a.keyFilePatterns) {
+		return findings.Finding{}, false
+	}
+	r := keyFileRule()
+	loc := findings.Location{FilePath: path, StartLine: 1, EndLine: 1}
+	return findings.Finding{
+		ID:          fmt.Sprintf("%s:%s:1", r.ID, path),
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		Location:    loc,
+		Message:     r.Description,
+		Metadata:    r.Metadata,
+		Fingerprint: findings.ComputeFingerprint(r.ID, loc, path),
+	}, true
+}
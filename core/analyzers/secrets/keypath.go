@@ -0,0 +1,391 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// keyPathSpan maps the byte range [start, end) of a value in a structured
+// file to the path of its key.
+type keyPathSpan struct {
+	start, end int
+	path       string
+}
+
+// bareKey matches keys that need no quoting in a dotted key path.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// appendKey appends key to a dot/bracket key path: "a.b" for bare keys,
+// `a["b.c"]` for keys with other characters.
+func appendKey(path, key string) string {
+	switch {
+	case !bareKey.MatchString(key):
+		return path + "[" + strconv.Quote(key) + "]"
+	case path == "":
+		return key
+	default:
+		return path + "." + key
+	}
+}
+
+// appendIndex appends an array index to a key path.
+func appendIndex(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// addKeyPaths records the key path of the value each finding was matched
+// in, for findings in JSON, YAML and TOML files. The path is stored as
+// findings.MetaKeyPath and appended to the message. Findings in other files,
+// or in files that do not parse, are returned unchanged.
+func addKeyPaths(path string, content []byte, results []findings.Finding) []findings.Finding {
+	if len(results) == 0 {
+		return results
+	}
+	var index func([]byte) []keyPathSpan
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		index = jsonKeyPaths
+	case ".yaml", ".yml":
+		index = yamlKeyPaths
+	case ".toml":
+		index = tomlKeyPaths
+	default:
+		return results
+	}
+	spans := index(content)
+	if len(spans) == 0 {
+		return results
+	}
+
+	lineStarts := lineOffsets(content)
+	for i := range results {
+		loc := results[i].Location
+		if loc.StartLine < 1 || loc.StartLine > len(lineStarts) || loc.StartColumn < 1 {
+			continue
+		}
+		keyPath := lookupKeyPath(spans, lineStarts[loc.StartLine-1]+loc.StartColumn-1)
+		if keyPath == "" {
+			continue
+		}
+		f := &results[i]
+		f.Message = fmt.Sprintf("%s (key %s)", f.Message, keyPath)
+		// Findings of one rule share the rule's metadata map.
+		f.Metadata = maps.Clone(f.Metadata)
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[findings.MetaKeyPath] = keyPath
+	}
+	return results
+}
+
+// lookupKeyPath returns the path of the smallest span containing offset.
+func lookupKeyPath(spans []keyPathSpan, offset int) string {
+	best := -1
+	for i, s := range spans {
+		if offset >= s.start && offset < s.end && (best < 0 || s.end-s.start < spans[best].end-spans[best].start) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return spans[best].path
+}
+
+// jsonFrame is an open object or array while walking JSON tokens.
+type jsonFrame struct {
+	array     bool
+	index     int
+	key       string
+	expectKey bool
+}
+
+// jsonKeyPaths indexes the scalar values of a JSON document. A document
+// that stops parsing keeps the spans read up to the error. Duplicate keys
+// each get a span.
+func jsonKeyPaths(content []byte) []keyPathSpan {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var (
+		stack []jsonFrame
+		spans []keyPathSpan
+	)
+	current := func() string {
+		p := ""
+		for _, f := range stack {
+			if f.array {
+				p = appendIndex(p, f.index)
+			} else {
+				p = appendKey(p, f.key)
+			}
+		}
+		return p
+	}
+	// next moves the innermost container past the value just read.
+	next := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := &stack[len(stack)-1]
+		if top.array {
+			top.index++
+		} else {
+			top.expectKey = true
+		}
+	}
+
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return spans
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				stack = append(stack, jsonFrame{expectKey: true})
+			case '[':
+				stack = append(stack, jsonFrame{array: true})
+			default:
+				stack = stack[:len(stack)-1]
+				next()
+			}
+		default:
+			if n := len(stack); n > 0 && !stack[n-1].array && stack[n-1].expectKey {
+				stack[n-1].key, _ = t.(string)
+				stack[n-1].expectKey = false
+				continue
+			}
+			spans = append(spans, keyPathSpan{start: start, end: int(dec.InputOffset()), path: current()})
+			next()
+		}
+	}
+}
+
+// yamlPos is the start of a node in a YAML document.
+type yamlPos struct {
+	offset int
+	node   *yaml.Node
+	path   string
+	value  bool
+}
+
+// yamlKeyPaths indexes the scalar values of every document in a YAML
+// stream. Aliases are not followed, since the aliased value is indexed where
+// its anchor is defined. Duplicate keys each get a span.
+func yamlKeyPaths(content []byte) []keyPathSpan {
+	lineStarts := lineOffsets(content)
+	offset := func(n *yaml.Node) int {
+		if n.Line < 1 || n.Line > len(lineStarts) {
+			return -1
+		}
+		return runeColumnOffset(content, lineStarts[n.Line-1], n.Column)
+	}
+
+	var positions []yamlPos
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+				positions = append(positions, yamlPos{offset: offset(k), node: k})
+				if k.Value == "<<" && k.Tag == "!!merge" {
+					continue
+				}
+				walk(v, appendKey(path, k.Value))
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, appendIndex(path, i))
+			}
+		case yaml.ScalarNode:
+			positions = append(positions, yamlPos{offset: offset(n), node: n, path: path, value: true})
+		}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		walk(&doc, "")
+	}
+
+	sort.SliceStable(positions, func(i, j int) bool { return positions[i].offset < positions[j].offset })
+	var spans []keyPathSpan
+	for i, p := range positions {
+		if !p.value || p.offset < 0 {
+			continue
+		}
+		// A value runs to the next node. Single-line scalars also end at
+		// the end of their line, which keeps trailing comments out.
+		end := len(content)
+		if i+1 < len(positions) && positions[i+1].offset > p.offset {
+			end = positions[i+1].offset
+		}
+		if p.node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 && !strings.Contains(p.node.Value, "\n") {
+			if nl := bytes.IndexByte(content[p.offset:end], '\n'); nl >= 0 {
+				end = p.offset + nl
+			}
+		}
+		spans = append(spans, keyPathSpan{start: p.offset, end: end, path: p.path})
+	}
+	return spans
+}
+
+// runeColumnOffset converts a 1-based column counted in characters, as
+// reported by the YAML parser, to a byte offset in content.
+func runeColumnOffset(content []byte, lineStart, column int) int {
+	off := lineStart
+	for c := 1; c < column && off < len(content) && content[off] != '\n'; c++ {
+		_, size := utf8.DecodeRune(content[off:])
+		off += size
+	}
+	return off
+}
+
+// tomlKeyPaths indexes the values of a TOML document line by line. Tables
+// and arrays of tables set the path prefix; key/value pairs, including
+// dotted and quoted keys and multi-line strings, get a span from the value
+// to the end of the value. Inline tables are indexed under their key.
+func tomlKeyPaths(content []byte) []keyPathSpan {
+	var (
+		spans      []keyPathSpan
+		table      string
+		arrays     = make(map[string]int)
+		multiline  string // closing delimiter of an open multi-line string
+		openSpan   int
+		lineOffset int
+	)
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		start := lineOffset
+		lineOffset += len(line)
+		if multiline != "" {
+			if strings.Contains(line, multiline) {
+				spans[openSpan].end = start + strings.Index(line, multiline) + len(multiline)
+				multiline = ""
+			} else {
+				spans[openSpan].end = lineOffset
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "[["):
+			name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(stripTOMLComment(trimmed), "[["), "]]"))
+			table = appendIndex(tomlPath("", name), arrays[name])
+			arrays[name]++
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(stripTOMLComment(trimmed), "["), "]"))
+			table = tomlPath("", name)
+			continue
+		}
+
+		eq := tomlKeyEnd(line)
+		if eq < 0 {
+			continue
+		}
+		valueStart := eq + 1
+		for valueStart < len(line) && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+			valueStart++
+		}
+		value := line[valueStart:]
+		spans = append(spans, keyPathSpan{
+			start: start + valueStart,
+			end:   start + len(strings.TrimRight(line, "\r\n")),
+			path:  tomlPath(table, strings.TrimSpace(line[:eq])),
+		})
+		for _, delim := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, delim) && !strings.Contains(value[len(delim):], delim) {
+				multiline, openSpan = delim, len(spans)-1
+				spans[openSpan].end = lineOffset
+			}
+		}
+	}
+	return spans
+}
+
+// tomlKeyEnd returns the index of the '=' that ends the key of a TOML
+// key/value line, skipping '=' inside quoted keys, or -1.
+func tomlKeyEnd(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		case c == '#':
+			return -1
+		}
+	}
+	return -1
+}
+
+// tomlPath appends the dotted, possibly quoted, TOML key to path.
+func tomlPath(path, key string) string {
+	var (
+		part  strings.Builder
+		quote byte
+	)
+	flush := func() {
+		if s := strings.TrimSpace(part.String()); s != "" {
+			path = appendKey(path, s)
+		}
+		part.Reset()
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			flush()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	flush()
+	return path
+}
+
+// stripTOMLComment removes a trailing comment from a table header line.
+func stripTOMLComment(s string) string {
+	if i := strings.LastIndex(s, "#"); i > strings.LastIndex(s, "]") {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// DefaultPairWindow is the default number of lines within which an
+// identifier and its secret are treated as one credential.
+const DefaultPairWindow = 5
+
+// credentialPair describes a credential made of an identifier, such as a
+// client ID, and a secret. The identifier alone is low risk; together they
+// grant access.
+type credentialPair struct {
+	name        string
+	idRules     []string
+	secretRules []string
+}
+
+// pairForSecret returns the credential pair whose secret half ruleID
+// matches.
+func pairForSecret(ruleID string) (credentialPair, bool) {
+	for _, p := range credentialPairs {
+		if slices.Contains(p.secretRules, ruleID) {
+			return p, true
+		}
+	}
+	return credentialPair{}, false
+}
+
+// correlatePairs merges identifier and secret findings of the same
+// credential into a single critical finding. results must come from one
+// file. A secret finding with a matching identifier finding within window
+// lines is raised to critical severity, its message names both halves and
+// the nearest identifier's rule and line are recorded in its metadata; the
+// identifier findings are dropped. A window of zero or less disables
+// correlation.
+func correlatePairs(results []findings.Finding, window int) []findings.Finding {
+	if window <= 0 || len(results) < 2 {
+		return results
+	}
+
+	paired := make(map[int]bool)
+	for i := range results {
+		pair, ok := pairForSecret(results[i].RuleID)
+		if !ok {
+			continue
+		}
+		secret := &results[i]
+		id := -1
+		for j := range results {
+			if !slices.Contains(pair.idRules, results[j].RuleID) || results[j].Location.FilePath != secret.Location.FilePath {
+				continue
+			}
+			dist := lineDistance(results[j].Location.StartLine, secret.Location.StartLine)
+			if dist > window {
+				continue
+			}
+			paired[j] = true
+			if id < 0 || dist < lineDistance(results[id].Location.StartLine, secret.Location.StartLine) {
+				id = j
+			}
+		}
+		if id < 0 {
+			continue
+		}
+
+		idLine := results[id].Location.StartLine
+		secret.Severity = findings.SeverityCritical
+		secret.Message = fmt.Sprintf("%s ID (line %d) and secret (line %d) found together: %s",
+			pair.name, idLine, secret.Location.StartLine, secret.Message)
+		secret.Metadata = maps.Clone(secret.Metadata)
+		if secret.Metadata == nil {
+			secret.Metadata = make(map[string]string)
+		}
+		secret.Metadata["paired_rule_id"] = results[id].RuleID
+		secret.Metadata["paired_line"] = strconv.Itoa(idLine)
+	}
+	if len(paired) == 0 {
+		return results
+	}
+
+	out := results[:0]
+	for i := range results {
+		if !paired[i] {
+			out = append(out, results[i])
+		}
+	}
+	return out
+}
+
+func lineDistance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,214 @@
+package secrets
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// placeholderWords are substrings of values that are placeholders rather
+// than real settings.
+var placeholderWords = []string{
+	"changeme", "change_me", "change-me", "changethis", "your", "example",
+	"placeholder", "replace", "dummy", "sample", "fixme", "todo", "redacted",
+	"insert", "xxx", "***", "...",
+}
+
+// placeholderValues are whole values that are placeholders.
+var placeholderValues = []string{
+	"secret", "password", "token", "key", "none", "null", "nil", "true",
+	"false", "test", "dev", "local",
+}
+
+// Kinds of placeholder values, as returned by PlaceholderKind.
+const (
+	PlaceholderEmpty    = "empty"    // nothing but spaces
+	PlaceholderVariable = "variable" // $VAR or ${VAR}
+	PlaceholderTemplate = "template" // <...>, {{...}}, [...] or an embedded ${...}
+	PlaceholderRepeated = "repeated" // one character or a short run repeated, such as xxxxxx or abab
+	PlaceholderSequence = "sequence" // consecutive characters, such as 123456 or abcdef
+	PlaceholderWord     = "word"     // a placeholder word, such as your-api-key-here
+)
+
+// minPatternLength is the shortest value that is checked for repeated
+// runs and sequences longer than one character.
+const minPatternLength = 6
+
+// IsPlaceholder reports whether v is a placeholder rather than a real
+// value; see PlaceholderKind.
+func IsPlaceholder(v string) bool {
+	return PlaceholderKind(v) != ""
+}
+
+// PlaceholderKind returns the kind of placeholder v is, or "" when v looks
+// like a real value. A value is a placeholder when it is empty, refers to
+// another variable, is wrapped in <>, {{}} or [], holds template syntax,
+// repeats a single character or a short run, is a run of consecutive
+// characters, or contains a placeholder word. Repetitions and sequences
+// also count after a prefix such as sk_live_ or ghp_.
+func PlaceholderKind(v string) string {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "":
+		return PlaceholderEmpty
+	case strings.HasPrefix(v, "$"):
+		return PlaceholderVariable
+	}
+	wrapped := [][2]string{{"<", ">"}, {"{{", "}}"}, {"[", "]"}}
+	for _, w := range wrapped {
+		if strings.HasPrefix(v, w[0]) && strings.HasSuffix(v, w[1]) {
+			return PlaceholderTemplate
+		}
+	}
+	if strings.Contains(v, "${") || strings.Contains(v, "{{") {
+		return PlaceholderTemplate
+	}
+	body := v
+	if i := strings.LastIndexAny(v, "_-:"); i >= 0 && len(v)-i-1 >= minPatternLength {
+		body = v[i+1:]
+	}
+	if strings.Trim(v, v[:1]) == "" || isRepeated(body) {
+		return PlaceholderRepeated
+	}
+	if isSequence(strings.ToLower(body)) {
+		return PlaceholderSequence
+	}
+	lower := strings.ToLower(v)
+	for _, p := range placeholderValues {
+		if lower == p {
+			return PlaceholderWord
+		}
+	}
+	for _, w := range placeholderWords {
+		if strings.Contains(lower, w) {
+			return PlaceholderWord
+		}
+	}
+	return ""
+}
+
+// isRepeated reports whether s, of at least minPatternLength bytes, is a
+// run of up to four bytes repeated at least three times.
+func isRepeated(s string) bool {
+	if len(s) < minPatternLength {
+		return false
+	}
+	for n := 1; n <= 4 && 3*n <= len(s); n++ {
+		if len(s)%n == 0 && strings.Repeat(s[:n], len(s)/n) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isSequence reports whether s, of at least minPatternLength bytes, is a
+// run of consecutive letters or digits, ascending or descending, such as
+// abcdef, 654321 or 1234567890.
+func isSequence(s string) bool {
+	if len(s) < minPatternLength {
+		return false
+	}
+	step := 0
+	for i := 1; i < len(s); i++ {
+		prev, c := s[i-1], s[i]
+		if !isAlnum(prev) || !isAlnum(c) {
+			return false
+		}
+		d := int(c) - int(prev)
+		if prev == '9' && c == '0' {
+			d = 1
+		}
+		if d != 1 && d != -1 || step != 0 && d != step {
+			return false
+		}
+		step = d
+	}
+	return true
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z'
+}
+
+// PlaceholderAction is what the analyzer does with matches whose value is a
+// placeholder.
+type PlaceholderAction string
+
+// Placeholder actions.
+const (
+	// PlaceholderKeep reports placeholder matches unchanged.
+	PlaceholderKeep PlaceholderAction = "keep"
+	// PlaceholderDowngrade reports placeholder matches at info severity.
+	PlaceholderDowngrade PlaceholderAction = "downgrade"
+	// PlaceholderSuppress drops placeholder matches.
+	PlaceholderSuppress PlaceholderAction = "suppress"
+)
+
+// ParsePlaceholderAction parses a placeholder action; "" is
+// PlaceholderKeep.
+func ParsePlaceholderAction(s string) (PlaceholderAction, error) {
+	switch a := PlaceholderAction(strings.ToLower(strings.TrimSpace(s))); a {
+	case "":
+		return PlaceholderKeep, nil
+	case PlaceholderKeep, PlaceholderDowngrade, PlaceholderSuppress:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown placeholder action %q (want suppress, downgrade or keep)", s)
+	}
+}
+
+// MetaPlaceholder is the metadata key holding the placeholder kind of a
+// finding downgraded by PlaceholderDowngrade.
+const MetaPlaceholder = "placeholder"
+
+// filterPlaceholders drops findings of rules with the "placeholders"
+// metadata flag whose matched value, the longest capture group, is a
+// placeholder such as {{token}}, <YOUR_KEY> or xxxxxxxx. These rules match
+// values that need no vendor prefix, so templates and documentation would
+// otherwise trigger them. The matches of other rules whose value is a
+// placeholder are handled by the analyzer's PlaceholderAction. Every
+// placeholder match is counted in the analyzer's FilterStats.
+func (a *Analyzer) filterPlaceholders(content []byte, results []findings.Finding) []findings.Finding {
+	var lineStarts []int
+	out := results[:0]
+	for _, f := range results {
+		rule, ok := a.engine.Rules().ByID(f.RuleID)
+		if !ok {
+			out = append(out, f)
+			continue
+		}
+		if lineStarts == nil {
+			lineStarts = lineOffsets(content)
+		}
+		start, end, ok := matchedSpan(content, lineStarts, f.Location, rule.Pattern)
+		if !ok {
+			out = append(out, f)
+			continue
+		}
+		kind := PlaceholderKind(string(content[start:end]))
+		if kind == "" {
+			out = append(out, f)
+			continue
+		}
+		action := a.placeholderAction
+		if rule.Metadata["placeholders"] == "true" {
+			action = PlaceholderSuppress
+		}
+		a.stats.countPlaceholder(action, kind)
+		switch action {
+		case PlaceholderSuppress:
+			continue
+		case PlaceholderDowngrade:
+			f.Severity = findings.SeverityInfo
+			f.Metadata = maps.Clone(f.Metadata)
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			f.Metadata[MetaPlaceholder] = kind
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,279 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// postmanRuleID flags concrete credentials in Postman collections. It is
+// not run by the rules engine: the collection is parsed so that auth
+// settings, headers and query parameters are told apart from the rest of
+// the request, and each finding points at the value itself.
+const postmanRuleID = "SEC-961"
+
+func postmanRule() *rules.Rule {
+	return &rules.Rule{
+		ID:          postmanRuleID,
+		Version:     "1.0",
+		Description: "Postman collection contains a hardcoded credential",
+		Severity:    findings.SeverityHigh,
+		Confidence:  findings.ConfidenceHigh,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "postman"},
+		Metadata:    map[string]string{"cwe": "CWE-798"},
+		Remediation: "Replace the value with a variable such as {{token}}, set it in a Postman environment that is not exported with the collection (or as a secret-type variable), and rotate the exposed credential.",
+		References:  []string{"https://cwe.mitre.org/data/definitions/798.html", "https://learning.postman.com/docs/sending-requests/variables/variables/"},
+	}
+}
+
+// postmanAuthFields are the fields of Postman auth settings that hold a
+// credential, by auth type. Usernames, key names and algorithms are not
+// listed.
+var postmanAuthFields = map[string][]string{
+	"apikey": {"value"},
+	"awsv4":  {"secretKey", "sessionToken"},
+	"basic":  {"password"},
+	"bearer": {"token"},
+	"digest": {"password"},
+	"hawk":   {"authKey"},
+	"jwt":    {"secret", "privateKey"},
+	"ntlm":   {"password"},
+	"oauth1": {"consumerSecret", "tokenSecret", "privateKey"},
+	"oauth2": {"accessToken", "refreshToken", "clientSecret", "password"},
+}
+
+// postmanSecretNames are header and query parameter names, in lower case,
+// whose values are credentials.
+var postmanSecretNames = map[string]bool{
+	"authorization": true, "proxy-authorization": true, "x-api-key": true,
+	"api-key": true, "apikey": true, "api_key": true, "x-auth-token": true,
+	"x-access-token": true, "private-token": true, "x-goog-api-key": true,
+	"access_token": true, "token": true, "client_secret": true, "password": true,
+}
+
+// isPostmanCollection reports whether content is a Postman collection
+// export.
+func isPostmanCollection(path string, content []byte) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(path), ".postman_collection.json") ||
+		bytes.Contains(content, []byte(`"_postman_id"`)) ||
+		bytes.Contains(content, []byte("schema.getpostman.com"))
+}
+
+// postmanCredential is a credential found in a collection: the key path of
+// its value and what it is.
+type postmanCredential struct {
+	path, what string
+}
+
+// postmanFindings returns a SEC-961 finding for each auth setting, header
+// and query parameter of the collection in content that holds a concrete
+// value rather than a {{variable}} or placeholder. Findings point at the
+// line and column of the value.
+func postmanFindings(path string, content []byte) []findings.Finding {
+	if !isPostmanCollection(path, content) {
+		return nil
+	}
+	var doc any
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil
+	}
+	var creds []postmanCredential
+	walkPostman(doc, "", &creds)
+	if len(creds) == 0 {
+		return nil
+	}
+
+	offsets := make(map[string]int)
+	for _, s := range jsonKeyPaths(content) {
+		if _, ok := offsets[s.path]; !ok {
+			offsets[s.path] = valueStart(content, s.start)
+		}
+	}
+	lineStarts := lineOffsets(content)
+	r := postmanRule()
+	var out []findings.Finding
+	for _, c := range creds {
+		offset, ok := offsets[c.path]
+		if !ok {
+			continue
+		}
+		line, col := lineColumn(lineStarts, offset)
+		loc := findings.Location{FilePath: path, StartLine: line, EndLine: line, StartColumn: col, EndColumn: col}
+		msg := "Postman collection contains a hardcoded " + c.what
+		out = append(out, findings.Finding{
+			ID:          fmt.Sprintf("%s:%s:%d", r.ID, path, line),
+			RuleID:      r.ID,
+			Severity:    r.Severity,
+			Confidence:  r.Confidence,
+			Location:    loc,
+			Message:     msg,
+			Metadata:    r.Metadata,
+			Fingerprint: findings.ComputeFingerprint(r.ID, loc, c.path),
+		})
+	}
+	return out
+}
+
+// walkPostman collects the credentials in the auth, header and query
+// blocks under v, whose key path is path. Collections nest folders and
+// requests arbitrarily, so every object is visited.
+func walkPostman(v any, path string, creds *[]postmanCredential) {
+	switch t := v.(type) {
+	case []any:
+		for i, c := range t {
+			walkPostman(c, appendIndex(path, i), creds)
+		}
+	case map[string]any:
+		for key, c := range t {
+			p := appendKey(path, key)
+			switch key {
+			case "auth":
+				if auth, ok := c.(map[string]any); ok {
+					postmanAuth(auth, p, creds)
+					continue
+				}
+			case "header", "query":
+				if list, ok := c.([]any); ok {
+					postmanPairs(list, p, key, creds)
+					continue
+				}
+			}
+			walkPostman(c, p, creds)
+		}
+	}
+}
+
+// postmanAuth collects the credential fields of an auth block. Collection
+// format v2.1 stores the fields of a type as a list of key/value pairs,
+// v2.0 as an object.
+func postmanAuth(auth map[string]any, path string, creds *[]postmanCredential) {
+	typ, _ := auth["type"].(string)
+	fields := postmanAuthFields[typ]
+	if len(fields) == 0 {
+		return
+	}
+	typPath := appendKey(path, typ)
+	isField := func(name string) bool {
+		for _, f := range fields {
+			if f == name {
+				return true
+			}
+		}
+		return false
+	}
+	switch settings := auth[typ].(type) {
+	case []any:
+		for i, item := range settings {
+			kv, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := kv["key"].(string)
+			value, _ := kv["value"].(string)
+			if isField(name) && isCredential(value) {
+				*creds = append(*creds, postmanCredential{appendKey(appendIndex(typPath, i), "value"), typ + " auth " + name})
+			}
+		}
+	case map[string]any:
+		for _, name := range fields {
+			if value, _ := settings[name].(string); isCredential(value) {
+				*creds = append(*creds, postmanCredential{appendKey(typPath, name), typ + " auth " + name})
+			}
+		}
+	}
+}
+
+// postmanPairs collects the header or query parameter values whose name is
+// a credential name.
+func postmanPairs(list []any, path, kind string, creds *[]postmanCredential) {
+	for i, item := range list {
+		kv, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := kv["key"].(string)
+		value, _ := kv["value"].(string)
+		if !postmanSecretNames[strings.ToLower(name)] {
+			continue
+		}
+		if scheme, rest, ok := strings.Cut(value, " "); ok && !strings.Contains(scheme, "{{") {
+			// "Bearer abc": the scheme is not part of the credential.
+			value = rest
+		}
+		if isCredential(value) {
+			what := name + " header"
+			if kind == "query" {
+				what = name + " query parameter"
+			}
+			*creds = append(*creds, postmanCredential{appendKey(appendIndex(path, i), "value"), what})
+		}
+	}
+}
+
+// isCredential reports whether v is a concrete value rather than a
+// placeholder or a value built from {{variables}}.
+func isCredential(v string) bool {
+	v = strings.TrimSpace(v)
+	return !IsPlaceholder(v) && !strings.Contains(v, "{{")
+}
+
+// valueStart skips the separators that precede a JSON value at offset.
+func valueStart(content []byte, offset int) int {
+	for offset < len(content) && strings.IndexByte(" \t\r\n:,", content[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// lineColumn converts a byte offset to a 1-based line and column.
+func lineColumn(lineStarts []int, offset int) (line, col int) {
+	line = 1
+	for line < len(lineStarts) && lineStarts[line] <= offset {
+		line++
+	}
+	return line, offset - lineStarts[line-1] + 1
+}
+
+// addPostmanFindings merges the SEC-961 findings for the collection at path
+// into results. A Postman finding replaces the medium and low confidence
+// findings on its line, such as the entropy rules', and is left out where a
+// high confidence rule already flagged the line.
+func addPostmanFindings(path string, content []byte, results []findings.Finding) []findings.Finding {
+	pm := postmanFindings(path, content)
+	if len(pm) == 0 {
+		return results
+	}
+	high := make(map[int]bool, len(results))
+	for _, f := range results {
+		if f.Confidence == findings.ConfidenceHigh {
+			high[f.Location.StartLine] = true
+		}
+	}
+	replaced := make(map[int]bool, len(pm))
+	for _, f := range pm {
+		if !high[f.Location.StartLine] {
+			replaced[f.Location.StartLine] = true
+		}
+	}
+	out := results[:0]
+	for _, f := range results {
+		if !replaced[f.Location.StartLine] {
+			out = append(out, f)
+		}
+	}
+	for _, f := range pm {
+		if replaced[f.Location.StartLine] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+)
+
+// postmanCollection is a v2.1 export with a bearer token, an API key
+// header and query parameter, and templated or placeholder values that
+// must not be reported.
+var postmanCollection = `{
+  "info": {
+    "_postman_id": "0f3c1a2b-4d5e-6f70-8192-a3b4c5d6e7f8",
+    "name": "Items API",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "auth": {
+    "type": "bearer",
+    "bearer": [
+      {
+        "key": "token",
+        "value": "` + httpCredential + `",
+        "type": "string"
+      }
+    ]
+  },
+  "item": [
+    {
+      "name": "Items",
+      "item": [
+        {
+          "name": "List items",
+          "request": {
+            "method": "GET",
+            "header": [
+              {"key": "Accept", "value": "application/json"},
+              {"key": "X-API-Key", "value": "` + httpCredential + `"},
+              {"key": "Authorization", "value": "Bearer {{token}}"}
+            ],
+            "url": {
+              "raw": "{{baseUrl}}/items",
+              "query": [
+                {"key": "limit", "value": "10"},
+                {"key": "access_token", "value": "` + httpCredential + `"},
+                {"key": "api_key", "value": "<YOUR_KEY>"}
+              ]
+            }
+          }
+        },
+        {
+          "name": "Create item",
+          "request": {
+            "method": "POST",
+            "auth": {
+              "type": "apikey",
+              "apikey": [
+                {"key": "key", "value": "X-API-Key"},
+                {"key": "value", "value": "xxxxxxxxxxxxxxxx"}
+              ]
+            }
+          }
+        }
+      ]
+    }
+  ]
+}
+`
+
+func TestPostmanFindings(t *testing.T) {
+	a := NewAnalyzer()
+	results, err := a.ScanFile("api.postman_collection.json", []byte(postmanCollection))
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+
+	lines := strings.Split(postmanCollection, "\n")
+	got := make(map[int]string)
+	for _, f := range results {
+		if f.RuleID != postmanRuleID {
+			continue
+		}
+		got[f.Location.StartLine] = f.Message
+		// The column points at the opening quote of the value.
+		line := lines[f.Location.StartLine-1]
+		if !strings.HasPrefix(line[f.Location.StartColumn-1:], `"`+httpCredential+`"`) {
+			t.Errorf("finding at %d:%d does not point at the value: %q", f.Location.StartLine, f.Location.StartColumn, line)
+		}
+	}
+
+	want := map[int]string{
+		12: "bearer auth token",
+		27: "X-API-Key header",
+		34: "access_token query parameter",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d %s findings, got %v", len(want), postmanRuleID, got)
+	}
+	for line, what := range want {
+		if !strings.Contains(got[line], what) {
+			t.Errorf("line %d: message = %q, want it to mention %q", line, got[line], what)
+		}
+	}
+	// The entropy rules also match the header and query values; the
+	// Postman finding replaces them.
+	for _, f := range results {
+		if f.RuleID != postmanRuleID && got[f.Location.StartLine] != "" {
+			t.Errorf("%s finding on line %d was not replaced", f.RuleID, f.Location.StartLine)
+		}
+	}
+	if !a.Rules().HasID(postmanRuleID) {
+		t.Errorf("expected %s in the analyzer rule set", postmanRuleID)
+	}
+}
+
+func TestPostmanFindings_V20AuthObject(t *testing.T) {
+	content := `{
+  "info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"},
+  "item": [{
+    "request": {
+      "auth": {
+        "type": "basic",
+        "basic": {
+          "username": "admin",
+          "password": "S3cr3tPassw0rd!x"
+        }
+      }
+    }
+  }]
+}
+`
+	got := postmanFindings("collection.json", []byte(content))
+	if len(got) != 1 || got[0].Location.StartLine != 9 {
+		t.Fatalf("expected one finding on line 9, got %+v", got)
+	}
+}
+
+func TestPostmanFindings_NotACollection(t *testing.T) {
+	content := `{"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "` + httpCredential + `"}]}}`
+	if got := postmanFindings("package.json", []byte(content)); len(got) != 0 {
+		t.Errorf("expected no findings outside a Postman collection, got %+v", got)
+	}
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"bytes"
+	"maps"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// sharedPrefixRuleID flags sk_live_ and sk_test_ keys, which both Stripe
+// and Clerk issue. Its findings name the provider only when the code
+// around the key does.
+const sharedPrefixRuleID = "SEC-030"
+
+// providerWindow is the number of lines on either side of a key searched
+// for the name of its provider.
+const providerWindow = 3
+
+// sharedPrefixProviders are the providers that issue keys matched by
+// sharedPrefixRuleID, with the lower-case word that names each in code.
+var sharedPrefixProviders = []struct{ name, word string }{
+	{"Stripe", "stripe"},
+	{"Clerk", "clerk"},
+}
+
+// disambiguateProviders names the provider of sharedPrefixRuleID findings
+// from the lines around the key: the nearest line that mentions exactly
+// one of Stripe and Clerk decides. Restricted keys (rk_) are Stripe's
+// only. Keys with no such line keep the rule's "Stripe or Clerk" message
+// and no provider.
+func (a *Analyzer) disambiguateProviders(content []byte, results []findings.Finding) []findings.Finding {
+	rule, ok := a.engine.Rules().ByID(sharedPrefixRuleID)
+	if !ok {
+		return results
+	}
+	var lineStarts []int
+	var lines [][]byte
+	for i := range results {
+		f := &results[i]
+		if f.RuleID != sharedPrefixRuleID {
+			continue
+		}
+		if lineStarts == nil {
+			lineStarts = lineOffsets(content)
+			lines = bytes.Split(bytes.ToLower(content), []byte("\n"))
+		}
+		start, _, ok := matchedSpan(content, lineStarts, f.Location, rule.Pattern)
+		if !ok {
+			continue
+		}
+		provider := "Stripe"
+		if !bytes.HasPrefix(content[start:], []byte("rk_")) {
+			provider = nearestProvider(lines, f.Location.StartLine)
+		}
+		if provider == "" {
+			continue
+		}
+		f.Metadata = maps.Clone(f.Metadata)
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[findings.MetaSecretProvider] = provider
+		f.Message = provider + " secret key detected"
+	}
+	return results
+}
+
+// nearestProvider returns the provider named on the line of lines, in
+// lower case, nearest to line (1-based) within providerWindow lines, or ""
+// when none is named or the nearest such line names both.
+func nearestProvider(lines [][]byte, line int) string {
+	for d := 0; d <= providerWindow; d++ {
+		for _, n := range []int{line - d, line + d} {
+			if n < 1 || n > len(lines) {
+				continue
+			}
+			var named []string
+			for _, p := range sharedPrefixProviders {
+				if bytes.Contains(lines[n-1], []byte(p.word)) {
+					named = append(named, p.name)
+				}
+			}
+			switch len(named) {
+			case 1:
+				return named[0]
+			case 2:
+				return ""
+			}
+		}
+	}
+	return ""
+}
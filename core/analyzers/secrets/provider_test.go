@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+const sharedPrefixKey = "sk_live_" + "aB3dE5gH7jK9mN2pQ4sT6vW8"
+
+// keyFindings drops the findings of the entropy rules, which flag any
+// high-entropy value whatever its format.
+func keyFindings(results []findings.Finding) []findings.Finding {
+	var out []findings.Finding
+	for _, f := range results {
+		if f.Metadata["entropy_threshold"] == "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestScanFile_SharedPrefixKeyProvider(t *testing.T) {
+	tests := []struct {
+		name, content, provider, message string
+	}{
+		{"bare", "key = \"" + sharedPrefixKey + "\"\n", "", "Stripe or Clerk secret key detected"},
+		{"stripe variable", "STRIPE_KEY=" + sharedPrefixKey + "\n", "Stripe", "Stripe secret key detected"},
+		{"clerk comment", "# Clerk backend API\n\nkey = " + sharedPrefixKey + "\n", "Clerk", "Clerk secret key detected"},
+		{"nearest line decides", "import stripe\n\n\n\n# clerk\nkey = " + sharedPrefixKey + "\n", "Clerk", "Clerk secret key detected"},
+		{"both on one line", "stripe_or_clerk = " + sharedPrefixKey + "\n", "", "Stripe or Clerk secret key detected"},
+		{"restricted key", "key = rk_live_" + "aB3dE5gH7jK9mN2pQ4sT6vW8\n", "Stripe", "Stripe secret key detected"},
+	}
+	a := NewAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := a.ScanFile("config.yaml", []byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			results = keyFindings(results)
+			if len(results) != 1 {
+				t.Fatalf("got %d findings, want 1: %+v", len(results), results)
+			}
+			f := results[0]
+			if f.RuleID != sharedPrefixRuleID {
+				t.Errorf("rule = %s, want %s", f.RuleID, sharedPrefixRuleID)
+			}
+			if f.Metadata[findings.MetaSecretProvider] != tt.provider {
+				t.Errorf("provider = %q, want %q", f.Metadata[findings.MetaSecretProvider], tt.provider)
+			}
+			if f.Message != tt.message {
+				t.Errorf("message = %q, want %q", f.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestScanArtifacts_SharedPrefixKeyReportedOnce(t *testing.T) {
+	dir := t.TempDir()
+	content := "// Stripe\nconst stripeKey = \"" + sharedPrefixKey + "\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "billing.js"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := NewAnalyzer().ScanArtifacts([]discovery.Artifact{
+		{Path: "billing.js", AbsPath: filepath.Join(dir, "billing.js"), Type: discovery.Source},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keyFindings(fs.Findings()); len(got) != 1 || got[0].RuleID != sharedPrefixRuleID {
+		t.Errorf("findings = %+v, want one %s finding", got, sharedPrefixRuleID)
+	}
+}
+
+func TestScanFile_LobKeyNeedsContext(t *testing.T) {
+	key := "live_" + "abcdef1234567890abcdef1234567890"
+	a := NewAnalyzer()
+	for content, want := range map[string]int{
+		"LOB_API_KEY=" + key + "\n":                         1,
+		"# lob fixtures\nchecksum = test_" + key[5:] + "\n": 0,
+	} {
+		results, err := a.ScanFile("config.yaml", []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, f := range results {
+			if f.RuleID == "SEC-070" {
+				n++
+			}
+		}
+		if n != want {
+			t.Errorf("%q: %d SEC-070 findings, want %d", content, n, want)
+		}
+	}
+}
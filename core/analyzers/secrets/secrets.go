@@ -5,9 +5,13 @@
 package secrets
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
@@ -16,19 +20,59 @@ import (
 
 // Analyzer wraps a rules.Engine pre-loaded with secret detection rules.
 type Analyzer struct {
-	engine *rules.Engine
+	engine          *rules.Engine
+	keyFilePatterns []string
+	pairWindow      int
+	lazy            bool
+	groupKey        []byte
+
+	placeholderAction PlaceholderAction
+	testDirs          []string
+	stats             filterStats
+	// fileErrs records the files that cannot be read or scanned; nil
+	// fails the scan on them instead.
+	fileErrs *discovery.FileErrors
+}
+
+// AnalyzerOption configures the Analyzer.
+type AnalyzerOption func(*Analyzer)
+
+// WithLazyCompile compiles rule patterns on first use instead of up front.
+// See rules.NewLazyEngine.
+func WithLazyCompile() AnalyzerOption {
+	return func(a *Analyzer) { a.lazy = true }
+}
+
+// WithFileErrors records the artifacts that cannot be read or scanned in
+// e and skips them, instead of failing the scan.
+func WithFileErrors(e *discovery.FileErrors) AnalyzerOption {
+	return func(a *Analyzer) { a.fileErrs = e }
 }
 
 // NewAnalyzer creates an Analyzer with built-in secret detection rules loaded
 // programmatically. The rules use regex matching and apply to all file types.
-func NewAnalyzer() *Analyzer {
+func NewAnalyzer(opts ...AnalyzerOption) *Analyzer {
 	rs := rules.NewRuleSet()
 	for _, r := range builtinSecretRules() {
 		rs.Add(r)
 	}
-	return &Analyzer{
-		engine: rules.NewEngine(rs),
+	a := &Analyzer{
+		keyFilePatterns: DefaultKeyFilePatterns,
+		pairWindow:      DefaultPairWindow,
+		groupKey:        newGroupKey(),
+
+		placeholderAction: PlaceholderKeep,
+		testDirs:          DefaultTestDirs,
+	}
+	for _, opt := range opts {
+		opt(a)
 	}
+	if a.lazy {
+		a.engine = rules.NewLazyEngine(rs)
+	} else {
+		a.engine = rules.NewEngine(rs)
+	}
+	return a
 }
 
 // EntropyOverrides holds optional overrides for entropy-based rule thresholds.
@@ -80,43 +124,161 @@ func (a *Analyzer) ApplyEntropyOverrides(o EntropyOverrides) {
 	}
 }
 
-// Rules returns the analyzer's RuleSet for catalog aggregation.
-func (a *Analyzer) Rules() *rules.RuleSet { return a.engine.Rules() }
+// Rules returns the analyzer's RuleSet for catalog aggregation. It includes
+// the file name rule for private key files, the Postman collection rule,
+// the git config rules and the cloud CLI credential file rules, which the
+// engine does not run.
+func (a *Analyzer) Rules() *rules.RuleSet {
+	rs := rules.NewRuleSet()
+	for _, r := range a.engine.Rules().Rules() {
+		rs.Add(r)
+	}
+	rs.Add(keyFileRule())
+	rs.Add(postmanRule())
+	for _, r := range gitConfigRules() {
+		rs.Add(r)
+	}
+	for _, r := range credentialFileRules() {
+		rs.Add(r)
+	}
+	return rs
+}
+
+// AddKeyFilePatterns extends the file name patterns that identify private
+// key and keystore files.
+func (a *Analyzer) AddKeyFilePatterns(patterns ...string) {
+	a.keyFilePatterns = append(slices.Clone(a.keyFilePatterns), patterns...)
+}
+
+// SetPairWindow sets the number of lines within which a client ID or access
+// key ID and its secret are merged into one critical finding. Zero disables
+// the correlation.
+func (a *Analyzer) SetPairWindow(lines int) { a.pairWindow = lines }
 
 // ScanFile delegates to the underlying rules engine to scan the given file
-// content and returns any secret-related findings.
+// content and returns any secret-related findings. Identifier-shaped matches
+// of medium and low confidence rules are dropped, placeholder values are
+// handled by the analyzer's PlaceholderAction, findings in test directories
+// get lower confidence, JWT findings are classified by the token's claims,
+// and Stripe or Clerk keys name the provider mentioned near them. Findings in JSON, YAML
+// and TOML files name the key path of the matched value. Findings of the same
+// value share a secret group ID.
 func (a *Analyzer) ScanFile(path string, content []byte) ([]findings.Finding, error) {
-	return a.engine.ScanFile(path, content)
+	// The filters below re-run patterns at the finding locations, so they
+	// must see the content the engine matched.
+	content = rules.NormalizeLineEndings(content)
+	results, err := a.engine.ScanFile(path, content)
+	results = a.filterIdentifierMatches(content, results)
+	results = a.filterPlaceholders(content, results)
+	results = a.classifyJWTs(content, results)
+	results = a.disambiguateProviders(content, results)
+	results = correlatePairs(results, a.pairWindow)
+	results = addPostmanFindings(path, content, results)
+	results = addGitConfigFindings(path, content, results)
+	results = append(results, credentialFileFindings(path, content)...)
+	results = addKeyPaths(path, content, results)
+	results = a.lowerTestConfidence(path, results)
+	return a.groupSecrets(content, results), err
 }
 
+// SetFileTimeout bounds the time spent matching rules against a single file.
+// Zero means no limit.
+func (a *Analyzer) SetFileTimeout(d time.Duration) { a.engine.SetFileTimeout(d) }
+
+// RuleTimings returns the cumulative matching time per rule, slowest first.
+func (a *Analyzer) RuleTimings() []rules.RuleTiming { return a.engine.RuleTimings() }
+
+// LineNotes returns the lines that were capped or on which a rule exceeded
+// the per-line matching budget.
+func (a *Analyzer) LineNotes() []rules.LineNote { return a.engine.LineNotes() }
+
 // ScanArtifacts reads each artifact file from disk, scans it for secrets, and
 // collects all findings into a deduplicated FindingSet. If any artifact cannot
 // be read, scanning stops and the error is returned.
 func (a *Analyzer) ScanArtifacts(artifacts []discovery.Artifact) (*findings.FindingSet, error) {
+	return a.ScanArtifactsContext(context.Background(), artifacts)
+}
+
+// ScanArtifactsContext is like ScanArtifacts but stops once ctx is done,
+// returning the findings gathered so far together with ctx.Err(). Files that
+// exceed the per-file timeout keep their partial findings and are skipped.
+func (a *Analyzer) ScanArtifactsContext(ctx context.Context, artifacts []discovery.Artifact) (*findings.FindingSet, error) {
 	fs := findings.NewFindingSet()
 
 	for _, artifact := range artifacts {
-		content, err := os.ReadFile(artifact.AbsPath)
-		if err != nil {
-			return nil, fmt.Errorf("reading artifact %s: %w", artifact.Path, err)
+		if err := ctx.Err(); err != nil {
+			fs.Deduplicate()
+			return fs, err
 		}
 
-		results, err := a.ScanFile(artifact.Path, content)
+		// Key files are flagged by name since binary keystores cannot be
+		// matched by content.
+		if f, ok := a.keyFileFinding(artifact.Path); ok {
+			fs.Add(f)
+		}
+
+		content, err := discovery.ReadContent(artifact)
 		if err != nil {
-			return nil, fmt.Errorf("scanning artifact %s: %w", artifact.Path, err)
+			if a.fileErrs.Skip(artifact.Path, err) {
+				continue
+			}
+			return nil, fmt.Errorf("reading artifact %s: %w", artifact.Path, err)
 		}
 
+		results, err := a.scanContent(ctx, artifact.Path, content, artifact.Sensitive)
 		for i := range results {
 			fs.Add(results[i])
 		}
-
-		// Scan decoded base64/hex content for encoded secrets.
-		decodedResults := DecodeAndScan(content, artifact.Path, a.engine)
-		for i := range decodedResults {
-			fs.Add(decodedResults[i])
+		switch {
+		case errors.Is(err, rules.ErrFileTimeout):
+			slog.Warn("file scan timed out", "analyzer", "secrets", "path", artifact.Path)
+		case ctx.Err() != nil:
+			fs.Deduplicate()
+			return fs, ctx.Err()
+		case err != nil:
+			if !a.fileErrs.Skip(artifact.Path, err) {
+				return nil, fmt.Errorf("scanning artifact %s: %w", artifact.Path, err)
+			}
 		}
 	}
 
 	fs.Deduplicate()
 	return fs, nil
 }
+
+// ScanSensitiveContext scans content as the file at path with every rule,
+// whatever the file's extension, as ScanArtifactsContext does for
+// sensitive artifacts. It is used by analyzers that rewrite a file before
+// secret detection, such as the dotenv analyzer.
+func (a *Analyzer) ScanSensitiveContext(ctx context.Context, path string, content []byte) ([]findings.Finding, error) {
+	return a.scanContent(ctx, path, content, true)
+}
+
+// scanContent runs the rules against content and then against its decoded
+// base64 and hex strings. Decoding is skipped when matching fails or times
+// out.
+func (a *Analyzer) scanContent(ctx context.Context, path string, content []byte, allRules bool) ([]findings.Finding, error) {
+	content = rules.NormalizeLineEndings(content)
+	scan := a.engine.ScanFileContext
+	if allRules {
+		scan = a.engine.ScanFileAllRulesContext
+	}
+	results, err := scan(ctx, path, content)
+	results = a.filterIdentifierMatches(content, results)
+	results = a.filterPlaceholders(content, results)
+	results = a.classifyJWTs(content, results)
+	results = a.disambiguateProviders(content, results)
+	results = correlatePairs(results, a.pairWindow)
+	results = addPostmanFindings(path, content, results)
+	results = addGitConfigFindings(path, content, results)
+	results = append(results, credentialFileFindings(path, content)...)
+	results = addKeyPaths(path, content, results)
+	results = a.lowerTestConfidence(path, results)
+	results = a.groupSecrets(content, results)
+	if err != nil {
+		return results, err
+	}
+
+	// Scan decoded base64/hex content for encoded secrets.
+	return append(results, addKeyPaths(path, content, DecodeAndScan(content, path, a.engine))...), nil
+}
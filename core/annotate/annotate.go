@@ -1,14 +1,22 @@
-// Package annotate builds GitHub PR review payloads from security findings.
-// The payload construction lives here so both CLI and MCP can use it;
-// the actual GitHub API call remains in the CLI layer.
+// Package annotate builds GitHub PR review and check run payloads from
+// security findings. The payload construction lives here so both CLI and MCP
+// can use it; the actual GitHub API calls remain in the CLI layer.
 package annotate
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/nox-hq/nox/core/codeowners"
 	"github.com/nox-hq/nox/core/findings"
 )
 
+// ReviewMarker starts the body of every review BuildReviewPayload builds,
+// so that a later run can find the review and update it in place.
+const ReviewMarker = "<!-- nox-annotate -->"
+
 // ReviewComment is a single line-level comment on a PR.
 type ReviewComment struct {
 	Path string `json:"path"`
@@ -25,34 +33,116 @@ type ReviewPayload struct {
 }
 
 // BuildReviewPayload constructs a GitHub PR review payload from findings.
-func BuildReviewPayload(ff []findings.Finding) *ReviewPayload {
+// The review body is ReviewMarker and their Summary with opts, grouped by
+// rule; MaxBytes defaults to MaxCommentBytes. Findings outside the changed
+// files get no inline comment and are listed in the body instead. At most
+// opts.MaxFindings findings, the most severe, get an inline comment, which
+// starts with the FindingMarker of the finding.
+func BuildReviewPayload(ff []findings.Finding, opts SummaryOptions) *ReviewPayload {
 	if len(ff) == 0 {
 		return nil
 	}
+	if opts.MaxFindings <= 0 {
+		opts.MaxFindings = DefaultMaxFindings
+	}
 
-	var comments []ReviewComment
+	var inline []*findings.Finding
 	for i := range ff {
-		badge := SeverityBadge(ff[i].Severity)
-		body := fmt.Sprintf("%s **%s** `%s`\n\n%s", badge, ff[i].Severity, ff[i].RuleID, ff[i].Message)
+		if !ff[i].OutsideChangedFiles() {
+			inline = append(inline, &ff[i])
+		}
+	}
+	sort.SliceStable(inline, func(i, j int) bool {
+		return findings.CompareSeverity(inline[i].Severity, inline[j].Severity) < 0
+	})
+	omitted := 0
+	if len(inline) > opts.MaxFindings {
+		omitted = len(inline) - opts.MaxFindings
+		inline = inline[:opts.MaxFindings]
+	}
+
+	var comments []ReviewComment
+	for _, f := range inline {
+		badge := SeverityBadge(f.Severity)
+		body := fmt.Sprintf("%s\n%s **%s** `%s`\n\n%s", FindingMarker(f), badge, f.DisplaySeverity(), f.RuleID, f.Message)
+		if line := BlameSummary(f); line != "" {
+			body += "\n\n" + line
+		}
+		if line := OwnerSummary(f); line != "" {
+			body += "\n\n" + line
+		}
+		if u := f.Metadata[findings.MetaReferenceURL]; u != "" {
+			body += "\n\nReference: " + u
+		}
 
 		c := ReviewComment{
-			Path: ff[i].Location.FilePath,
+			Path: f.Location.FilePath,
 			Body: body,
 			Side: "RIGHT",
 		}
-		if ff[i].Location.StartLine > 0 {
-			c.Line = ff[i].Location.StartLine
+		if f.Location.StartLine > 0 {
+			c.Line = f.Location.StartLine
 		}
 		comments = append(comments, c)
 	}
 
+	var notice string
+	if omitted > 0 {
+		notice = fmt.Sprintf("\n_%d more finding(s) in the changed files got no inline comment; see the full report artifact._\n", omitted)
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = MaxCommentBytes
+	}
+	opts.MaxBytes -= len(ReviewMarker) + 1 + len(notice)
+	opts.GroupByRule = true
 	return &ReviewPayload{
 		Event:    "COMMENT",
-		Body:     fmt.Sprintf("Nox found **%d finding(s)** in this PR.", len(ff)),
+		Body:     ReviewMarker + "\n" + Summary(ff, opts) + notice,
 		Comments: comments,
 	}
 }
 
+// FindingMarker returns the hidden marker that identifies the inline
+// comment of f, so that a later run does not comment on f again.
+func FindingMarker(f *findings.Finding) string {
+	fp := f.Fingerprint
+	if fp == "" {
+		fp = findings.ComputeFingerprint(f.RuleID, f.Location, f.Message)
+	}
+	return "<!-- nox:finding:" + fp + " -->"
+}
+
+// BlameSummary describes the commit a finding was attributed to by git
+// blame, or returns "" if the finding has no blame metadata.
+func BlameSummary(f *findings.Finding) string {
+	sha := f.Metadata[findings.MetaBlameCommit]
+	switch sha {
+	case "":
+		return ""
+	case findings.BlameUnknown:
+		return "Introduced by: unknown"
+	}
+	date := f.Metadata[findings.MetaBlameDate]
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		date = t.Format("2006-01-02")
+	}
+	return fmt.Sprintf("Introduced by %s <%s> in `%.12s` on %s",
+		f.Metadata[findings.MetaBlameAuthor], f.Metadata[findings.MetaBlameEmail], sha, date)
+}
+
+// OwnerSummary names the CODEOWNERS owners of a finding's file, or returns
+// "" if ownership was not resolved.
+func OwnerSummary(f *findings.Finding) string {
+	owners := codeowners.FindingOwners(f)
+	switch {
+	case len(owners) == 0:
+		return ""
+	case len(owners) == 1 && owners[0] == codeowners.Unowned:
+		return "Owners: none (no CODEOWNERS rule matches this file)"
+	}
+	return "Owners: " + strings.Join(owners, " ")
+}
+
 // SeverityBadge returns a GitHub-flavored emoji badge for the given severity.
 func SeverityBadge(sev findings.Severity) string {
 	switch sev {
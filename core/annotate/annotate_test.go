@@ -1,13 +1,14 @@
 package annotate
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/nox-hq/nox/core/findings"
 )
 
 func TestBuildReviewPayload_Empty(t *testing.T) {
-	result := BuildReviewPayload(nil)
+	result := BuildReviewPayload(nil, SummaryOptions{})
 	if result != nil {
 		t.Fatal("expected nil for empty findings")
 	}
@@ -26,7 +27,7 @@ func TestBuildReviewPayload_SingleFinding(t *testing.T) {
 		},
 	}
 
-	payload := BuildReviewPayload(ff)
+	payload := BuildReviewPayload(ff, SummaryOptions{})
 	if payload == nil {
 		t.Fatal("expected non-nil payload")
 	}
@@ -56,7 +57,7 @@ func TestBuildReviewPayload_MultipleFindings(t *testing.T) {
 		{RuleID: "AI-001", Severity: findings.SeverityMedium, Message: "three", Location: findings.Location{FilePath: "c.py"}},
 	}
 
-	payload := BuildReviewPayload(ff)
+	payload := BuildReviewPayload(ff, SummaryOptions{})
 	if payload == nil {
 		t.Fatal("expected non-nil payload")
 	}
@@ -83,7 +84,7 @@ func TestBuildReviewPayload_SeverityBadges(t *testing.T) {
 	}
 	for _, tt := range tests {
 		ff := []findings.Finding{{RuleID: "X", Severity: tt.severity, Message: "m", Location: findings.Location{FilePath: "f"}}}
-		payload := BuildReviewPayload(ff)
+		payload := BuildReviewPayload(ff, SummaryOptions{})
 		if payload == nil {
 			t.Fatal("expected non-nil payload")
 		}
@@ -93,3 +94,44 @@ func TestBuildReviewPayload_SeverityBadges(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildReviewPayload_Blame(t *testing.T) {
+	ff := []findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "secret detected",
+			Location: findings.Location{FilePath: "config.env", StartLine: 1},
+			Metadata: map[string]string{
+				findings.MetaBlameAuthor: "Dev",
+				findings.MetaBlameEmail:  "dev@example.com",
+				findings.MetaBlameCommit: "0123456789abcdef0123456789abcdef01234567",
+				findings.MetaBlameDate:   "2026-03-04T05:06:07Z",
+			}},
+		{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "secret detected",
+			Location: findings.Location{FilePath: "shallow.env", StartLine: 1},
+			Metadata: map[string]string{findings.MetaBlameCommit: findings.BlameUnknown}},
+		{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "secret detected",
+			Location: findings.Location{FilePath: "plain.env", StartLine: 1}},
+	}
+
+	payload := BuildReviewPayload(ff, SummaryOptions{})
+	want := []string{
+		"\n\nIntroduced by Dev <dev@example.com> in `0123456789ab` on 2026-03-04",
+		"\n\nIntroduced by: unknown",
+		"secret detected",
+	}
+	for i, suffix := range want {
+		if !strings.HasSuffix(payload.Comments[i].Body, suffix) {
+			t.Errorf("comment %d body = %q, want suffix %q", i, payload.Comments[i].Body, suffix)
+		}
+	}
+}
+
+func TestBuildReviewPayload_ReferenceURL(t *testing.T) {
+	ff := []findings.Finding{{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "secret detected",
+		Location: findings.Location{FilePath: "config.env", StartLine: 1},
+		Metadata: map[string]string{findings.MetaReferenceURL: "https://wiki.corp/security/nox/SEC-001"}}}
+
+	payload := BuildReviewPayload(ff, SummaryOptions{})
+	if want := "\n\nReference: https://wiki.corp/security/nox/SEC-001"; !strings.HasSuffix(payload.Comments[0].Body, want) {
+		t.Errorf("comment body = %q, want suffix %q", payload.Comments[0].Body, want)
+	}
+}
@@ -0,0 +1,166 @@
+package annotate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/policy"
+)
+
+// MaxCheckAnnotations is the number of annotations the GitHub Checks API
+// accepts in a single create or update request.
+const MaxCheckAnnotations = 50
+
+// CheckRunName is the name under which nox check runs appear on a commit.
+const CheckRunName = "nox"
+
+// maxTopRules bounds the rule table in the check run summary.
+const maxTopRules = 10
+
+// CheckAnnotation is a single inline annotation in a check run.
+type CheckAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+// CheckOutput is the output section of a check run.
+type CheckOutput struct {
+	Title       string            `json:"title"`
+	Summary     string            `json:"summary"`
+	Annotations []CheckAnnotation `json:"annotations,omitempty"`
+}
+
+// CheckRunPayload is a GitHub check run create or update request body.
+type CheckRunPayload struct {
+	Name       string      `json:"name,omitempty"`
+	HeadSHA    string      `json:"head_sha,omitempty"`
+	Status     string      `json:"status,omitempty"`
+	Conclusion string      `json:"conclusion,omitempty"`
+	Output     CheckOutput `json:"output"`
+}
+
+// BuildCheckRun constructs the request bodies for a check run on headSHA.
+// The first payload creates the run; each following payload updates it with
+// the next batch of at most MaxCheckAnnotations annotations. The last
+// payload completes the run with a conclusion from evaluating ff against
+// cfg (any new finding fails when cfg.FailOn is empty). Findings outside
+// the changed files are counted in the summary but not annotated.
+func BuildCheckRun(ff []findings.Finding, headSHA string, cfg policy.Config) []CheckRunPayload {
+	result := policy.Evaluate(cfg, ff)
+	conclusion := "success"
+	if !result.Pass {
+		conclusion = "failure"
+	}
+
+	output := CheckOutput{
+		Title:   checkRunTitle(ff),
+		Summary: checkRunSummary(ff, result),
+	}
+
+	annotations := make([]CheckAnnotation, 0, len(ff))
+	for i := range ff {
+		if !ff[i].OutsideChangedFiles() {
+			annotations = append(annotations, checkAnnotation(&ff[i]))
+		}
+	}
+
+	var payloads []CheckRunPayload
+	for start := 0; start == 0 || start < len(annotations); start += MaxCheckAnnotations {
+		end := min(start+MaxCheckAnnotations, len(annotations))
+		p := CheckRunPayload{Status: "in_progress", Output: output}
+		p.Output.Annotations = annotations[start:end]
+		if start == 0 {
+			p.Name = CheckRunName
+			p.HeadSHA = headSHA
+		}
+		payloads = append(payloads, p)
+	}
+
+	last := &payloads[len(payloads)-1]
+	last.Status = "completed"
+	last.Conclusion = conclusion
+	return payloads
+}
+
+func checkRunTitle(ff []findings.Finding) string {
+	if len(ff) == 0 {
+		return "No findings"
+	}
+	return fmt.Sprintf("%d finding(s)", len(ff))
+}
+
+// checkRunSummary renders the markdown summary: policy outcome, counts by
+// severity, and the most frequent rules.
+func checkRunSummary(ff []findings.Finding, result *policy.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Nox found **%d finding(s)**. %s\n", len(ff), result.Summary)
+	if len(ff) == 0 {
+		return b.String()
+	}
+
+	ruleCounts := make(map[string]int)
+	ruleSeverity := make(map[string]string)
+	for i := range ff {
+		ruleCounts[ff[i].RuleID]++
+		ruleSeverity[ff[i].RuleID] = ff[i].DisplaySeverity()
+	}
+
+	writeSeverityTable(&b, ff)
+
+	rules := make([]string, 0, len(ruleCounts))
+	for id := range ruleCounts {
+		rules = append(rules, id)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if ruleCounts[rules[i]] != ruleCounts[rules[j]] {
+			return ruleCounts[rules[i]] > ruleCounts[rules[j]]
+		}
+		return rules[i] < rules[j]
+	})
+	if len(rules) > maxTopRules {
+		rules = rules[:maxTopRules]
+	}
+
+	b.WriteString("\n**Top rules**\n\n| Rule | Severity | Findings |\n|------|----------|----------|\n")
+	for _, id := range rules {
+		fmt.Fprintf(&b, "| `%s` | %s | %d |\n", id, ruleSeverity[id], ruleCounts[id])
+	}
+	return b.String()
+}
+
+func checkAnnotation(f *findings.Finding) CheckAnnotation {
+	start := f.Location.StartLine
+	if start < 1 {
+		start = 1
+	}
+	end := f.Location.EndLine
+	if end < start {
+		end = start
+	}
+	return CheckAnnotation{
+		Path:            f.Location.FilePath,
+		StartLine:       start,
+		EndLine:         end,
+		AnnotationLevel: annotationLevel(f.Severity),
+		Title:           fmt.Sprintf("%s (%s)", f.RuleID, f.DisplaySeverity()),
+		Message:         f.Message,
+	}
+}
+
+// annotationLevel maps a severity to a check run annotation level.
+func annotationLevel(sev findings.Severity) string {
+	switch sev {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		return "failure"
+	case findings.SeverityMedium:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
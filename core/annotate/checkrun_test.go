@@ -0,0 +1,100 @@
+package annotate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/policy"
+)
+
+func TestBuildCheckRun_Batches(t *testing.T) {
+	var ff []findings.Finding
+	for i := 0; i < 120; i++ {
+		ff = append(ff, findings.Finding{
+			RuleID:   fmt.Sprintf("SEC-%03d", i%3),
+			Severity: findings.SeverityMedium,
+			Message:  "m",
+			Location: findings.Location{FilePath: "a.go", StartLine: i + 1},
+		})
+	}
+
+	payloads := BuildCheckRun(ff, "abc123", policy.Config{FailOn: findings.SeverityHigh})
+	if len(payloads) != 3 {
+		t.Fatalf("expected 3 payloads for 120 annotations, got %d", len(payloads))
+	}
+	total := 0
+	for i, p := range payloads {
+		if len(p.Output.Annotations) > MaxCheckAnnotations {
+			t.Errorf("payload %d has %d annotations", i, len(p.Output.Annotations))
+		}
+		total += len(p.Output.Annotations)
+	}
+	if total != 120 {
+		t.Errorf("expected 120 annotations in total, got %d", total)
+	}
+
+	if payloads[0].Name != CheckRunName || payloads[0].HeadSHA != "abc123" || payloads[0].Status != "in_progress" {
+		t.Errorf("unexpected create payload: %+v", payloads[0])
+	}
+	last := payloads[2]
+	if last.Status != "completed" || last.Conclusion != "success" {
+		t.Errorf("expected completed/success below fail-on threshold, got %s/%s", last.Status, last.Conclusion)
+	}
+	if !strings.Contains(last.Output.Summary, "`SEC-000`") {
+		t.Errorf("expected top rules in summary, got:\n%s", last.Output.Summary)
+	}
+}
+
+func TestBuildCheckRun_Conclusion(t *testing.T) {
+	ff := []findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityCritical, Message: "secret", Location: findings.Location{FilePath: "config.env"}},
+	}
+
+	tests := []struct {
+		failOn findings.Severity
+		want   string
+	}{
+		{"", "failure"},
+		{findings.SeverityHigh, "failure"},
+	}
+	for _, tt := range tests {
+		payloads := BuildCheckRun(ff, "sha", policy.Config{FailOn: tt.failOn})
+		if len(payloads) != 1 {
+			t.Fatalf("expected single payload, got %d", len(payloads))
+		}
+		if got := payloads[0].Conclusion; got != tt.want {
+			t.Errorf("failOn %q: conclusion = %q, want %q", tt.failOn, got, tt.want)
+		}
+	}
+
+	// Lines default to 1 so the Checks API accepts file-level findings.
+	a := BuildCheckRun(ff, "sha", policy.Config{})[0].Output.Annotations[0]
+	if a.StartLine != 1 || a.EndLine != 1 || a.AnnotationLevel != "failure" {
+		t.Errorf("unexpected annotation: %+v", a)
+	}
+
+	empty := BuildCheckRun(nil, "sha", policy.Config{})
+	if len(empty) != 1 || empty[0].Conclusion != "success" || len(empty[0].Output.Annotations) != 0 {
+		t.Errorf("expected a single successful payload for no findings, got %+v", empty)
+	}
+}
+
+func TestBuildCheckRun_ScopeChanged(t *testing.T) {
+	in, out := true, false
+	ff := []findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityCritical, Location: findings.Location{FilePath: "old.go", StartLine: 1}, InChangedFiles: &out},
+		{RuleID: "SEC-002", Severity: findings.SeverityLow, Location: findings.Location{FilePath: "new.go", StartLine: 2}, InChangedFiles: &in},
+	}
+	p := BuildCheckRun(ff, "sha", policy.Config{FailOn: findings.SeverityHigh, Scope: policy.ScopeChanged})[0]
+	if p.Conclusion != "success" {
+		t.Errorf("conclusion = %q, want success", p.Conclusion)
+	}
+	if len(p.Output.Annotations) != 1 || p.Output.Annotations[0].Path != "new.go" {
+		t.Errorf("annotations = %+v, want only new.go", p.Output.Annotations)
+	}
+	if !strings.Contains(p.Output.Summary, "2 finding(s)") {
+		t.Errorf("summary does not count every finding:\n%s", p.Output.Summary)
+	}
+}
@@ -0,0 +1,360 @@
+package annotate
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/nox-hq/nox/core/codeowners"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/policy"
+)
+
+// MaxStepSummaryBytes is the largest job summary GitHub Actions accepts for
+// a single step.
+const MaxStepSummaryBytes = 1 << 20
+
+// MaxCommentBytes is the largest body GitHub accepts for a PR comment or
+// review.
+const MaxCommentBytes = 65536
+
+// maxTopFindings bounds the findings table of a summary.
+const maxTopFindings = 10
+
+// DefaultMaxFindings is the number of findings a PR review lists when
+// SummaryOptions.MaxFindings is zero.
+const DefaultMaxFindings = 100
+
+// ruleGroupsFooterBytes is kept free for the footer of the rule sections.
+const ruleGroupsFooterBytes = 256
+
+// SummaryOptions controls the markdown summary rendered by Summary.
+type SummaryOptions struct {
+	// RepoURL is the web URL of the repository, such as
+	// https://github.com/org/repo. Together with Commit it turns finding
+	// locations into links to the affected lines.
+	RepoURL string
+	// Commit is the commit the findings were reported on.
+	Commit string
+	// Policy, when set, is shown as the outcome of the scan.
+	Policy *policy.Result
+	// MaxBytes caps the size of the summary. Zero means
+	// MaxStepSummaryBytes.
+	MaxBytes int
+	// GroupByRule replaces the top findings table with a collapsible
+	// section per rule that lists the locations of its findings. Each
+	// section has an anchor derived from the rule ID, so links into a
+	// comment that is updated in place keep working.
+	GroupByRule bool
+	// MaxFindings caps the locations the rule sections list. Zero means
+	// DefaultMaxFindings.
+	MaxFindings int
+}
+
+// Summary renders ff as a markdown summary for a GitHub job summary or PR
+// comment: the policy outcome, active findings by severity, the baseline
+// delta, and the most severe findings with links to their lines. A summary
+// over opts.MaxBytes is cut at a line boundary and ends with a truncation
+// notice; rule sections, with opts.GroupByRule, are left out whole instead.
+func Summary(ff []findings.Finding, opts SummaryOptions) string {
+	var active []findings.Finding
+	statuses := make(map[findings.Status]int)
+	for i := range ff {
+		s := ff[i].Status
+		if s == "" {
+			s = findings.StatusNew
+		}
+		statuses[s]++
+		if s.IsActive() {
+			active = append(active, ff[i])
+		}
+	}
+
+	limit := opts.MaxBytes
+	if limit <= 0 {
+		limit = MaxStepSummaryBytes
+	}
+
+	var b strings.Builder
+	b.WriteString("## Nox scan results\n\n")
+	if opts.Policy != nil {
+		outcome := ":white_check_mark: **Passed**"
+		if !opts.Policy.Pass {
+			outcome = ":x: **Failed**"
+		}
+		fmt.Fprintf(&b, "%s — %s\n\n", outcome, opts.Policy.Summary)
+	}
+	fmt.Fprintf(&b, "Nox found **%d active finding(s)**.\n", len(active))
+
+	if len(ff) > len(active) {
+		parts := []string{fmt.Sprintf("%d new", statuses[findings.StatusNew])}
+		for _, s := range []findings.Status{
+			findings.StatusBaselined, findings.StatusSuppressed,
+			findings.StatusVEXNotAffected, findings.StatusVEXUnderInvestigation, findings.StatusVEXFixed,
+		} {
+			if statuses[s] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", statuses[s], strings.ReplaceAll(string(s), "_", " ")))
+			}
+		}
+		fmt.Fprintf(&b, "\n**Baseline:** %s\n", strings.Join(parts, ", "))
+	}
+
+	if len(active) > 0 {
+		writeSeverityTable(&b, active)
+		writeOwnerTable(&b, active)
+		var outside strings.Builder
+		writeOutsideChanged(&outside, active, opts)
+		if opts.GroupByRule {
+			writeRuleGroups(&b, active, opts, limit-outside.Len())
+		} else {
+			writeTopFindings(&b, active, opts)
+		}
+		b.WriteString(outside.String())
+	}
+	return truncateSummary(b.String(), limit)
+}
+
+// writeSeverityTable writes a table of the number of findings per severity,
+// named by their custom severity labels if the report has them.
+func writeSeverityTable(b *strings.Builder, ff []findings.Finding) {
+	counts := make(map[findings.Severity]int)
+	labels := make(map[findings.Severity]string)
+	for i := range ff {
+		counts[ff[i].Severity]++
+		labels[ff[i].Severity] = ff[i].DisplaySeverity()
+	}
+	b.WriteString("\n| Severity | Count |\n|----------|-------|\n")
+	for _, sev := range findings.Severities {
+		if counts[sev] > 0 {
+			fmt.Fprintf(b, "| %s %s | %d |\n", SeverityBadge(sev), labels[sev], counts[sev])
+		}
+	}
+}
+
+// writeOwnerTable writes the number of findings per CODEOWNERS owner and
+// the most severe of them, busiest owner first and unowned findings last.
+// A finding with several owners counts for each. Nothing is written when
+// ownership was not resolved.
+func writeOwnerTable(b *strings.Builder, ff []findings.Finding) {
+	counts := make(map[string]int)
+	worst := make(map[string]*findings.Finding)
+	for i := range ff {
+		for _, o := range codeowners.FindingOwners(&ff[i]) {
+			counts[o]++
+			if w := worst[o]; w == nil || ff[i].Severity.Rank() < w.Severity.Rank() {
+				worst[o] = &ff[i]
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	owners := make([]string, 0, len(counts))
+	for o := range counts {
+		owners = append(owners, o)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if (owners[i] == codeowners.Unowned) != (owners[j] == codeowners.Unowned) {
+			return owners[j] == codeowners.Unowned
+		}
+		if counts[owners[i]] != counts[owners[j]] {
+			return counts[owners[i]] > counts[owners[j]]
+		}
+		return owners[i] < owners[j]
+	})
+
+	b.WriteString("\n**Findings by owner**\n\n| Owner | Count | Most severe |\n|-------|-------|-------------|\n")
+	for _, o := range owners {
+		w := worst[o]
+		fmt.Fprintf(b, "| %s | %d | %s %s |\n", tableCell(o), counts[o], SeverityBadge(w.Severity), w.DisplaySeverity())
+	}
+}
+
+// writeTopFindings writes the maxTopFindings most severe findings of ff,
+// keeping the order of ff among findings of equal severity. Findings that
+// expose the same secret value share one row, which names the number of
+// locations.
+func writeTopFindings(b *strings.Builder, ff []findings.Finding, opts SummaryOptions) {
+	top := make([]findings.Finding, len(ff))
+	copy(top, ff)
+	sort.SliceStable(top, func(i, j int) bool {
+		return findings.CompareSeverity(top[i].Severity, top[j].Severity) < 0
+	})
+	top = findings.CollapseSecretGroups(top)
+	rows := len(top)
+	if len(top) > maxTopFindings {
+		top = top[:maxTopFindings]
+	}
+
+	b.WriteString("\n**Top findings**\n\n| Severity | Rule | Location | Message |\n|----------|------|----------|---------|\n")
+	for i := range top {
+		f := &top[i]
+		msg := tableCell(f.Message)
+		if n := findings.SecretGroupSize(f); n > 1 {
+			msg = fmt.Sprintf("1 credential exposed in %d locations: %s", n, msg)
+		}
+		fmt.Fprintf(b, "| %s %s | `%s` | %s | %s |\n",
+			SeverityBadge(f.Severity), f.DisplaySeverity(), f.RuleID, locationLink(f, opts), msg)
+	}
+	if more := rows - len(top); more > 0 {
+		fmt.Fprintf(b, "\n_…and %d more finding(s)._\n", more)
+	}
+}
+
+// ruleGroup is the findings of one rule in a summary.
+type ruleGroup struct {
+	ruleID string
+	worst  *findings.Finding
+	ff     []*findings.Finding
+}
+
+// writeRuleGroups writes a collapsible section per rule of ff, most severe
+// rule first, then the rule with the most findings. Each lists the
+// locations of the rule's findings until opts.MaxFindings locations have
+// been listed. A section that would take b past limit bytes is left out
+// whole, so no section is cut off, and a footer counts the findings that
+// were not listed.
+func writeRuleGroups(b *strings.Builder, ff []findings.Finding, opts SummaryOptions, limit int) {
+	maxFindings := opts.MaxFindings
+	if maxFindings <= 0 {
+		maxFindings = DefaultMaxFindings
+	}
+
+	byRule := make(map[string]*ruleGroup)
+	var groups []*ruleGroup
+	for i := range ff {
+		g := byRule[ff[i].RuleID]
+		if g == nil {
+			g = &ruleGroup{ruleID: ff[i].RuleID, worst: &ff[i]}
+			byRule[ff[i].RuleID] = g
+			groups = append(groups, g)
+		}
+		if ff[i].Severity.Rank() < g.worst.Severity.Rank() {
+			g.worst = &ff[i]
+		}
+		g.ff = append(g.ff, &ff[i])
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if c := findings.CompareSeverity(groups[i].worst.Severity, groups[j].worst.Severity); c != 0 {
+			return c < 0
+		}
+		if len(groups[i].ff) != len(groups[j].ff) {
+			return len(groups[i].ff) > len(groups[j].ff)
+		}
+		return groups[i].ruleID < groups[j].ruleID
+	})
+
+	b.WriteString("\n**Findings by rule**\n")
+	listed := 0
+	for _, g := range groups {
+		if listed >= maxFindings {
+			break
+		}
+		var s strings.Builder
+		fmt.Fprintf(&s, "\n<a id=\"%s\"></a>\n<details>\n<summary>%s <b>%s</b> <code>%s</code> — %d finding(s): %s</summary>\n\n",
+			RuleAnchor(g.ruleID), SeverityBadge(g.worst.Severity), html.EscapeString(g.worst.DisplaySeverity()),
+			html.EscapeString(g.ruleID), len(g.ff), html.EscapeString(tableCell(g.ff[0].Message)))
+		n := min(len(g.ff), maxFindings-listed)
+		for _, f := range g.ff[:n] {
+			s.WriteString("- " + locationLink(f, opts))
+			if f.Message != g.ff[0].Message {
+				s.WriteString(": " + tableCell(f.Message))
+			}
+			s.WriteString("\n")
+		}
+		s.WriteString("\n</details>\n")
+		if b.Len()+s.Len() > limit-ruleGroupsFooterBytes {
+			break
+		}
+		b.WriteString(s.String())
+		listed += n
+	}
+	if more := len(ff) - listed; more > 0 {
+		fmt.Fprintf(b, "\n_…and %d more — see the full report artifact._\n", more)
+	}
+}
+
+// RuleAnchor returns the id of the summary section of a rule: the rule ID
+// in lower case with anything but letters and digits replaced by dashes.
+func RuleAnchor(ruleID string) string {
+	return "nox-rule-" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, ruleID)
+}
+
+// writeOutsideChanged lists the findings of ff in files the change does
+// not touch, which get no inline comment, most severe first. It writes
+// nothing when ff was not tagged with the changed files.
+func writeOutsideChanged(b *strings.Builder, ff []findings.Finding, opts SummaryOptions) {
+	var outside []findings.Finding
+	for i := range ff {
+		if ff[i].OutsideChangedFiles() {
+			outside = append(outside, ff[i])
+		}
+	}
+	if len(outside) == 0 {
+		return
+	}
+	sort.SliceStable(outside, func(i, j int) bool {
+		return findings.CompareSeverity(outside[i].Severity, outside[j].Severity) < 0
+	})
+	rows := len(outside)
+	if len(outside) > maxTopFindings {
+		outside = outside[:maxTopFindings]
+	}
+
+	fmt.Fprintf(b, "\n**Outside the changed files** (%d finding(s) in files this change does not touch)\n\n| Severity | Rule | Location | Message |\n|----------|------|----------|---------|\n", rows)
+	for i := range outside {
+		f := &outside[i]
+		fmt.Fprintf(b, "| %s %s | `%s` | %s | %s |\n",
+			SeverityBadge(f.Severity), f.DisplaySeverity(), f.RuleID, locationLink(f, opts), tableCell(f.Message))
+	}
+	if more := rows - len(outside); more > 0 {
+		fmt.Fprintf(b, "\n_…and %d more finding(s)._\n", more)
+	}
+}
+
+// locationLink renders the location of f as path:line, linked to the line
+// on opts.Commit when the repository and commit are known.
+func locationLink(f *findings.Finding, opts SummaryOptions) string {
+	loc := f.Location.FilePath
+	if f.Location.StartLine > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, f.Location.StartLine)
+	}
+	if opts.RepoURL == "" || opts.Commit == "" || f.Location.FilePath == "" {
+		return "`" + loc + "`"
+	}
+	url := fmt.Sprintf("%s/blob/%s/%s", strings.TrimSuffix(opts.RepoURL, "/"), opts.Commit, f.Location.FilePath)
+	if f.Location.StartLine > 0 {
+		url += fmt.Sprintf("#L%d", f.Location.StartLine)
+	}
+	return fmt.Sprintf("[%s](%s)", tableCell(loc), url)
+}
+
+// tableCell escapes s for use in a markdown table cell.
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncateSummary cuts s at the last line boundary that leaves room for a
+// truncation notice within limit bytes.
+func truncateSummary(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	notice := fmt.Sprintf("\n_Summary truncated to %d bytes. See the full report for all findings._\n", limit)
+	cut := strings.LastIndexByte(s[:max(limit-len(notice), 0)], '\n')
+	if cut < 0 {
+		return notice[1:]
+	}
+	return s[:cut+1] + notice
+}
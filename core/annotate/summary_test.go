@@ -0,0 +1,255 @@
+package annotate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/policy"
+)
+
+func TestSummary_Content(t *testing.T) {
+	ff := []findings.Finding{
+		{RuleID: "IAC-001", Severity: findings.SeverityMedium, Message: "root | user", Location: findings.Location{FilePath: "Dockerfile", StartLine: 3}},
+		{RuleID: "SEC-001", Severity: findings.SeverityCritical, Message: "aws key", Location: findings.Location{FilePath: "config.env", StartLine: 5}},
+		{RuleID: "SEC-002", Severity: findings.SeverityHigh, Message: "old key", Location: findings.Location{FilePath: "old.env", StartLine: 1}, Status: findings.StatusBaselined},
+		{RuleID: "SEC-003", Severity: findings.SeverityHigh, Message: "ignored", Location: findings.Location{FilePath: "test.env", StartLine: 2}, Status: findings.StatusSuppressed},
+	}
+	got := Summary(ff, SummaryOptions{
+		RepoURL: "https://github.com/org/repo/",
+		Commit:  "abc123",
+		Policy:  policy.Evaluate(policy.Config{FailOn: findings.SeverityHigh}, ff),
+	})
+
+	for _, want := range []string{
+		":x: **Failed** — policy: fail",
+		"Nox found **2 active finding(s)**.",
+		"**Baseline:** 2 new, 1 baselined, 1 suppressed",
+		"| :red_circle: critical | 1 |\n| :yellow_circle: medium | 1 |\n",
+		"| :red_circle: critical | `SEC-001` | [config.env:5](https://github.com/org/repo/blob/abc123/config.env#L5) | aws key |\n" +
+			"| :yellow_circle: medium | `IAC-001` | [Dockerfile:3](https://github.com/org/repo/blob/abc123/Dockerfile#L3) | root \\| user |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "old.env") || strings.Contains(got, "test.env") {
+		t.Errorf("inactive findings listed:\n%s", got)
+	}
+}
+
+func TestSummary_SeverityLabels(t *testing.T) {
+	ff := []findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityCritical, Message: "aws key", Location: findings.Location{FilePath: "config.env", StartLine: 5},
+			Metadata: map[string]string{findings.MetaSeverityLabel: "P1"}},
+	}
+	got := Summary(ff, SummaryOptions{})
+	for _, want := range []string{"| :red_circle: P1 | 1 |", "| :red_circle: P1 | `SEC-001` |"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSummary_OwnerTable(t *testing.T) {
+	owned := func(sev findings.Severity, owners string) findings.Finding {
+		return findings.Finding{RuleID: "SEC-001", Severity: sev, Message: "key", Location: findings.Location{FilePath: "a.env", StartLine: 1},
+			Metadata: map[string]string{findings.MetaOwners: owners}}
+	}
+	ff := []findings.Finding{
+		owned(findings.SeverityLow, "unowned"),
+		owned(findings.SeverityMedium, "@org/web"),
+		owned(findings.SeverityCritical, "@org/payments @alice"),
+		owned(findings.SeverityLow, "@org/payments"),
+	}
+	got := Summary(ff, SummaryOptions{})
+	want := "| @org/payments | 2 | :red_circle: critical |\n" +
+		"| @alice | 1 | :red_circle: critical |\n" +
+		"| @org/web | 1 | :yellow_circle: medium |\n" +
+		"| unowned | 1 | :large_blue_circle: low |\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("summary missing owner table %q:\n%s", want, got)
+	}
+	if got := Summary(ff[:0], SummaryOptions{}); strings.Contains(got, "Findings by owner") {
+		t.Errorf("owner table without owners:\n%s", got)
+	}
+}
+
+func TestSummary_SecretGroups(t *testing.T) {
+	var ff []findings.Finding
+	for _, path := range []string{"a.env", "b.env", "c.yaml", "d.json", "e.toml"} {
+		ff = append(ff, findings.Finding{RuleID: "SEC-001", Severity: findings.SeverityCritical, Message: "aws key", Location: findings.Location{FilePath: path, StartLine: 1},
+			Metadata: map[string]string{findings.MetaSecretGroup: "0123abcd", findings.MetaSecretGroupSize: "5"}})
+	}
+	ff = append(ff, findings.Finding{RuleID: "SEC-002", Severity: findings.SeverityHigh, Message: "other key", Location: findings.Location{FilePath: "f.env", StartLine: 2}})
+
+	got := Summary(ff, SummaryOptions{})
+	want := "| :red_circle: critical | `SEC-001` | `a.env:1` | 1 credential exposed in 5 locations: aws key |\n" +
+		"| :orange_circle: high | `SEC-002` | `f.env:2` | other key |\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("summary missing grouped rows %q:\n%s", want, got)
+	}
+	if strings.Contains(got, "b.env") || strings.Contains(got, "more finding") {
+		t.Errorf("grouped findings listed separately:\n%s", got)
+	}
+}
+
+func TestSummary_NoLinksWithoutCommit(t *testing.T) {
+	ff := []findings.Finding{{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "key", Location: findings.Location{FilePath: "a.env", StartLine: 1}}}
+	got := Summary(ff, SummaryOptions{RepoURL: "https://github.com/org/repo"})
+	if !strings.Contains(got, "| `a.env:1` |") {
+		t.Errorf("expected an unlinked location:\n%s", got)
+	}
+}
+
+func TestSummary_Truncated(t *testing.T) {
+	var ff []findings.Finding
+	for i := 0; i < 10; i++ {
+		ff = append(ff, findings.Finding{
+			RuleID:   "SEC-001",
+			Severity: findings.SeverityHigh,
+			Message:  strings.Repeat("x", 200),
+			Location: findings.Location{FilePath: fmt.Sprintf("f%d.env", i), StartLine: 1},
+		})
+	}
+	const limit = 1000
+	got := Summary(ff, SummaryOptions{MaxBytes: limit})
+	if len(got) > limit {
+		t.Errorf("summary is %d bytes, want at most %d", len(got), limit)
+	}
+	if !strings.HasSuffix(got, "_Summary truncated to 1000 bytes. See the full report for all findings._\n") {
+		t.Errorf("missing truncation notice:\n%s", got)
+	}
+	if strings.Contains(got, "f9.env") {
+		t.Error("expected the last findings to be cut")
+	}
+}
+
+func TestBuildReviewPayload_BodyIsSummary(t *testing.T) {
+	ff := []findings.Finding{{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "key", Location: findings.Location{FilePath: "a.env", StartLine: 1}}}
+	payload := BuildReviewPayload(ff, SummaryOptions{})
+	want := ReviewMarker + "\n" + Summary(ff, SummaryOptions{MaxBytes: MaxCommentBytes - len(ReviewMarker) - 1, GroupByRule: true})
+	if payload.Body != want {
+		t.Errorf("review body does not match the summary:\n%s", payload.Body)
+	}
+}
+
+func TestBuildReviewPayload_OutsideChangedFiles(t *testing.T) {
+	in, out := true, false
+	ff := []findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "old key", Location: findings.Location{FilePath: "old.env", StartLine: 1}, InChangedFiles: &out},
+		{RuleID: "SEC-002", Severity: findings.SeverityLow, Message: "new key", Location: findings.Location{FilePath: "new.env", StartLine: 3}, InChangedFiles: &in},
+	}
+	payload := BuildReviewPayload(ff, SummaryOptions{})
+	if len(payload.Comments) != 1 || payload.Comments[0].Path != "new.env" {
+		t.Errorf("comments = %+v, want only new.env", payload.Comments)
+	}
+	_, outside, ok := strings.Cut(payload.Body, "**Outside the changed files** (1 finding(s)")
+	if !ok || !strings.Contains(outside, "`old.env:1`") || strings.Contains(outside, "new.env") {
+		t.Errorf("body does not list the finding outside the changed files:\n%s", payload.Body)
+	}
+
+	// Untagged findings are commented inline and not listed.
+	ff[0].InChangedFiles = nil
+	if payload := BuildReviewPayload(ff, SummaryOptions{}); len(payload.Comments) != 2 || strings.Contains(payload.Body, "Outside the changed files") {
+		t.Errorf("untagged findings: %d comments, body:\n%s", len(payload.Comments), payload.Body)
+	}
+}
+
+func TestBuildReviewPayload_GroupsByRule(t *testing.T) {
+	var ff []findings.Finding
+	for i := 0; i < 3; i++ {
+		ff = append(ff, findings.Finding{RuleID: "IAC-001", Severity: findings.SeverityLow, Message: "open bucket", Location: findings.Location{FilePath: fmt.Sprintf("b%d.tf", i), StartLine: 1}})
+	}
+	ff = append(ff, findings.Finding{RuleID: "SEC-001", Severity: findings.SeverityCritical, Message: "key <b>", Location: findings.Location{FilePath: "a.env", StartLine: 2}})
+
+	payload := BuildReviewPayload(ff, SummaryOptions{})
+	if !strings.HasPrefix(payload.Body, ReviewMarker+"\n") {
+		t.Errorf("body does not start with the review marker:\n%s", payload.Body)
+	}
+	sec := strings.Index(payload.Body, `<a id="nox-rule-sec-001"></a>`)
+	iac := strings.Index(payload.Body, `<a id="nox-rule-iac-001"></a>`)
+	if sec < 0 || iac < sec {
+		t.Errorf("want the SEC-001 section before the IAC-001 section:\n%s", payload.Body)
+	}
+	for _, want := range []string{"<code>IAC-001</code> — 3 finding(s): open bucket</summary>", "key &lt;b&gt;</summary>", "- `b2.tf:1`\n"} {
+		if !strings.Contains(payload.Body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, payload.Body)
+		}
+	}
+	if strings.Contains(payload.Body, "**Top findings**") || strings.Contains(payload.Body, "more — see the full report artifact") {
+		t.Errorf("unexpected top findings table or footer:\n%s", payload.Body)
+	}
+	if !strings.HasPrefix(payload.Comments[0].Body, FindingMarker(&ff[3])+"\n") {
+		t.Errorf("first comment is not the critical finding with its marker: %q", payload.Comments[0].Body)
+	}
+}
+
+func TestBuildReviewPayload_MaxFindings(t *testing.T) {
+	var ff []findings.Finding
+	for i := 0; i < 5; i++ {
+		ff = append(ff, findings.Finding{RuleID: fmt.Sprintf("SEC-%03d", i), Severity: findings.SeverityHigh, Message: "key", Location: findings.Location{FilePath: fmt.Sprintf("f%d.env", i), StartLine: 1}})
+	}
+	payload := BuildReviewPayload(ff, SummaryOptions{MaxFindings: 3})
+	if len(payload.Comments) != 3 {
+		t.Errorf("got %d comments, want 3", len(payload.Comments))
+	}
+	for _, want := range []string{"_…and 2 more — see the full report artifact._", "_2 more finding(s) in the changed files got no inline comment"} {
+		if !strings.Contains(payload.Body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, payload.Body)
+		}
+	}
+	if strings.Contains(payload.Body, "f3.env") {
+		t.Errorf("body lists more than 3 findings:\n%s", payload.Body)
+	}
+}
+
+func TestBuildReviewPayload_CommentSizeLimit(t *testing.T) {
+	out := false
+	var ff []findings.Finding
+	for rule := 0; rule < 200; rule++ {
+		for file := 0; file < 60; file++ {
+			f := findings.Finding{
+				RuleID:   fmt.Sprintf("RULE-%03d", rule),
+				Severity: findings.Severities[rule%len(findings.Severities)],
+				Message:  strings.Repeat("long message | ", 40),
+				Location: findings.Location{FilePath: fmt.Sprintf("dir/sub/file%02d.go", file), StartLine: rule + 1},
+			}
+			if file%7 == 0 {
+				f.InChangedFiles = &out
+			}
+			ff = append(ff, f)
+		}
+	}
+	opts := SummaryOptions{RepoURL: "https://github.com/org/repo", Commit: strings.Repeat("a", 40)}
+	for _, maxFindings := range []int{0, 1000, len(ff)} {
+		opts.MaxFindings = maxFindings
+		payload := BuildReviewPayload(ff, opts)
+		if len(payload.Body) > MaxCommentBytes {
+			t.Errorf("max %d: body is %d bytes, want at most %d", maxFindings, len(payload.Body), MaxCommentBytes)
+		}
+		if open, closed := strings.Count(payload.Body, "<details>"), strings.Count(payload.Body, "</details>"); open == 0 || open != closed {
+			t.Errorf("max %d: %d <details> and %d </details>", maxFindings, open, closed)
+		}
+		if !strings.Contains(payload.Body, "more — see the full report artifact._") {
+			t.Errorf("max %d: body has no footer", maxFindings)
+		}
+		if !strings.Contains(payload.Body, "**Outside the changed files**") {
+			t.Errorf("max %d: body lost the findings outside the changed files", maxFindings)
+		}
+		if want := min(max(maxFindings, DefaultMaxFindings), countInlineFindings(ff)); len(payload.Comments) != want {
+			t.Errorf("max %d: %d comments, want %d", maxFindings, len(payload.Comments), want)
+		}
+	}
+}
+
+func countInlineFindings(ff []findings.Finding) int {
+	n := 0
+	for i := range ff {
+		if !ff[i].OutsideChangedFiles() {
+			n++
+		}
+	}
+	return n
+}
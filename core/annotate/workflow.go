@@ -0,0 +1,76 @@
+package annotate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// MaxWorkflowAnnotations is the number of annotations of each level (error,
+// warning, notice) GitHub Actions displays for a single step. Commands past
+// the limit are accepted but not shown.
+const MaxWorkflowAnnotations = 10
+
+// WorkflowCommands renders ff as GitHub Actions workflow commands that
+// annotate the affected files:
+//
+//	::error file=config.env,line=3,endLine=3,title=SEC-003::GitHub token detected
+//
+// Critical and high findings become errors, medium findings warnings and
+// the rest notices. At most MaxWorkflowAnnotations commands are produced per
+// level; if any findings are left out, a plain summary line saying how many
+// is appended.
+func WorkflowCommands(ff []findings.Finding) []string {
+	var lines []string
+	emitted := make(map[string]int)
+	skipped := 0
+	for i := range ff {
+		level := workflowLevel(ff[i].Severity)
+		if emitted[level] >= MaxWorkflowAnnotations {
+			skipped++
+			continue
+		}
+		emitted[level]++
+		lines = append(lines, workflowCommand(level, &ff[i]))
+	}
+	if skipped > 0 {
+		lines = append(lines, fmt.Sprintf("nox: %d more finding(s) not annotated; GitHub shows at most %d errors, %d warnings and %d notices per step",
+			skipped, MaxWorkflowAnnotations, MaxWorkflowAnnotations, MaxWorkflowAnnotations))
+	}
+	return lines
+}
+
+func workflowCommand(level string, f *findings.Finding) string {
+	props := []string{"file=" + escapeProperty(f.Location.FilePath)}
+	if start := f.Location.StartLine; start > 0 {
+		end := max(f.Location.EndLine, start)
+		props = append(props, fmt.Sprintf("line=%d", start), fmt.Sprintf("endLine=%d", end))
+	}
+	props = append(props, "title="+escapeProperty(f.RuleID))
+	return fmt.Sprintf("::%s %s::%s", level, strings.Join(props, ","), escapeData(f.Message))
+}
+
+// workflowLevel maps a severity to a workflow command name.
+func workflowLevel(sev findings.Severity) string {
+	switch sev {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		return "error"
+	case findings.SeverityMedium:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// escapeData escapes a workflow command message as the GitHub Actions
+// runner does.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value, which
+// additionally cannot contain the ":" and "," delimiters.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,67 @@
+package annotate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+func TestWorkflowCommands_Format(t *testing.T) {
+	ff := []findings.Finding{
+		{RuleID: "SEC-003", Severity: findings.SeverityHigh, Message: "GitHub token detected",
+			Location: findings.Location{FilePath: "config.env", StartLine: 3, EndLine: 3}},
+		{RuleID: "IAC-001", Severity: findings.SeverityMedium, Message: "100% public\r\nsecond line",
+			Location: findings.Location{FilePath: "infra/a,b:c.tf", StartLine: 7, EndLine: 9}},
+		{RuleID: "VULN-001", Severity: findings.SeverityLow, Message: "no line",
+			Location: findings.Location{FilePath: "go.mod"}},
+		{RuleID: "SEC-002", Severity: findings.SeverityCritical, Message: "end before start",
+			Location: findings.Location{FilePath: "x.env", StartLine: 4}},
+	}
+
+	want := []string{
+		"::error file=config.env,line=3,endLine=3,title=SEC-003::GitHub token detected",
+		"::warning file=infra/a%2Cb%3Ac.tf,line=7,endLine=9,title=IAC-001::100%25 public%0D%0Asecond line",
+		"::notice file=go.mod,title=VULN-001::no line",
+		"::error file=x.env,line=4,endLine=4,title=SEC-002::end before start",
+	}
+	got := WorkflowCommands(ff)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("WorkflowCommands() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWorkflowCommands_LimitPerLevel(t *testing.T) {
+	var ff []findings.Finding
+	for i := range 15 {
+		ff = append(ff, findings.Finding{
+			RuleID: "SEC-001", Severity: findings.SeverityHigh, Message: "m",
+			Location: findings.Location{FilePath: "a.env", StartLine: i + 1},
+		})
+	}
+	for i := range 3 {
+		ff = append(ff, findings.Finding{
+			RuleID: "IAC-001", Severity: findings.SeverityMedium, Message: "m",
+			Location: findings.Location{FilePath: "main.tf", StartLine: i + 1},
+		})
+	}
+
+	got := WorkflowCommands(ff)
+	counts := make(map[string]int)
+	for _, line := range got[:len(got)-1] {
+		level, _, _ := strings.Cut(strings.TrimPrefix(line, "::"), " ")
+		counts[level]++
+	}
+	if counts["error"] != MaxWorkflowAnnotations || counts["warning"] != 3 {
+		t.Errorf("annotations per level = %v", counts)
+	}
+	if last := got[len(got)-1]; last != fmt.Sprintf("nox: 5 more finding(s) not annotated; GitHub shows at most %d errors, %d warnings and %d notices per step",
+		MaxWorkflowAnnotations, MaxWorkflowAnnotations, MaxWorkflowAnnotations) {
+		t.Errorf("overflow summary = %q", last)
+	}
+
+	if got := WorkflowCommands(ff[:2]); len(got) != 2 {
+		t.Errorf("expected no overflow summary below the limit, got %v", got)
+	}
+}
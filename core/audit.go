@@ -0,0 +1,397 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nox-hq/nox/core/baseline"
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/override"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// Defaults for the audit: thresholds in .nox.yaml.
+const (
+	defaultAuditBaselineGrowth  = 10
+	defaultAuditExcludeFraction = 0.5
+)
+
+// auditStateFile records the baseline size seen by the last scan so that
+// the next scan can tell how much the baseline grew. It lives next to the
+// default baseline in the .nox directory.
+const auditStateFile = "audit-state.json"
+
+// AuditSettings configures the self-audit, which reports config and
+// baseline changes that weaken the scan as informational AUDIT-* findings.
+type AuditSettings struct {
+	// Disabled turns the self-audit off. A disabled audit is itself
+	// reported once per scan (AUDIT-005).
+	Disabled bool `yaml:"disabled,omitempty"`
+	// BaselineGrowth is the number of entries the baseline may gain
+	// between two scans before AUDIT-001 is reported (default 10).
+	BaselineGrowth int `yaml:"baseline_growth,omitempty"`
+	// ExcludeFraction is the fraction of the repository's files that the
+	// scan.exclude patterns of one config may cover before AUDIT-002 is
+	// reported (default 0.5).
+	ExcludeFraction float64 `yaml:"exclude_fraction,omitempty"`
+}
+
+// ruleCategory is a named group of built-in rules, such as all secrets
+// rules. Disabling every rule of a category is reported by AUDIT-003.
+type ruleCategory struct {
+	name  string
+	rules *rules.RuleSet
+}
+
+// auditState is the content of the audit state file.
+type auditState struct {
+	BaselineEntries int `json:"baseline_entries"`
+}
+
+// auditRules returns the metadata of the AUDIT-* findings produced by the
+// self-audit.
+func auditRules() *rules.RuleSet {
+	rs := rules.NewRuleSet()
+	for _, r := range []struct{ id, desc, remediation string }{
+		{"AUDIT-001", "Baseline grew sharply since the last scan", "Review the new baseline entries; baselining findings hides them from CI."},
+		{"AUDIT-002", "scan.exclude patterns cover a large part of the repository", "Narrow the exclude patterns so that the files that ship are scanned."},
+		{"AUDIT-003", "Every rule of a category is disabled", "Re-enable the rules, or disable only the individual rules that do not apply."},
+		{"AUDIT-004", "Policy never fails the scan", "Set policy.fail_on to a severity so that findings can fail CI."},
+		{"AUDIT-005", "Self-audit is disabled", "Remove audit.disabled from .nox.yaml so that config changes that weaken the scan are reported."},
+		{"AUDIT-006", "Finding was let through by a Nox-Override commit trailer", "Fix the finding, or baseline it once the override has been reviewed."},
+		{"AUDIT-007", "Inline suppression has expired", "Fix the findings the nox:ignore comment covered, or extend its until= date after review."},
+		{"AUDIT-008", "Inline suppression has no reason", "Add a reason after \"--\" in the nox:ignore comment."},
+	} {
+		rs.Add(&rules.Rule{
+			ID:          r.id,
+			Version:     "1.0",
+			Description: r.desc,
+			Severity:    findings.SeverityInfo,
+			Confidence:  findings.ConfidenceHigh,
+			Category:    rules.CategoryAudit,
+			Tags:        []string{"audit", "config"},
+			Remediation: r.remediation,
+		})
+	}
+	return rs
+}
+
+// auditScan checks the scan's own configuration and baseline for settings
+// that weaken it and returns AUDIT-* findings located at the offending
+// config file. The findings are added after suppressions and baseline
+// matching so that they cannot be hidden by the settings they report.
+// fs holds the scan's findings, which are checked against the override log.
+func auditScan(target string, cfg *ScanConfig, tree *ConfigTree, baselinePath string, categories []ruleCategory, fs *findings.FindingSet) []findings.Finding {
+	audit := cfg.Audit
+	if audit.Disabled {
+		return []findings.Finding{auditFinding(target, ConfigFileName, "audit", "AUDIT-005",
+			fmt.Sprintf("self-audit is disabled by audit.disabled in %s", ConfigFileName),
+			map[string]string{"setting": "audit.disabled"})}
+	}
+	growth := audit.BaselineGrowth
+	if growth <= 0 {
+		growth = defaultAuditBaselineGrowth
+	}
+	fraction := audit.ExcludeFraction
+	if fraction <= 0 {
+		fraction = defaultAuditExcludeFraction
+	}
+
+	var out []findings.Finding
+	out = append(out, auditBaselineGrowth(target, baselinePath, growth)...)
+	out = append(out, auditExcludes(target, cfg, tree, fraction)...)
+	out = append(out, auditDisabledCategories(target, tree, categories)...)
+	out = append(out, auditOverrides(target, fs)...)
+
+	if failOn := cfg.Policy.FailOn; failOn != "" {
+		switch findings.Severity(failOn) {
+		case findings.SeverityCritical, findings.SeverityHigh, findings.SeverityMedium, findings.SeverityLow, findings.SeverityInfo:
+		default:
+			out = append(out, auditFinding(target, ConfigFileName, "fail_on", "AUDIT-004",
+				fmt.Sprintf("policy.fail_on: %s in %s means no finding can fail the scan", failOn, ConfigFileName),
+				map[string]string{"setting": "policy.fail_on", "value": failOn}))
+		}
+	}
+	return out
+}
+
+// auditBaselineGrowth compares the size of the baseline with the size
+// recorded by the previous scan and records the current size. Nothing is
+// recorded while the baseline file does not exist.
+func auditBaselineGrowth(target, baselinePath string, growth int) []findings.Finding {
+	if _, err := os.Stat(baselinePath); err != nil {
+		return nil
+	}
+	b, err := baseline.Load(baselinePath)
+	if err != nil {
+		return nil
+	}
+	current := b.Len()
+
+	statePath := filepath.Join(filepath.Dir(baseline.DefaultPath(target)), auditStateFile)
+	var prev *auditState
+	if data, err := os.ReadFile(statePath); err == nil {
+		var s auditState
+		if json.Unmarshal(data, &s) == nil {
+			prev = &s
+		}
+	}
+	if data, err := json.Marshal(auditState{BaselineEntries: current}); err == nil {
+		if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err == nil {
+			err = os.WriteFile(statePath, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			slog.Debug("could not record audit state", "path", statePath, "error", err)
+		}
+	}
+
+	if prev == nil || current-prev.BaselineEntries <= growth {
+		return nil
+	}
+	rel := relToTarget(target, baselinePath)
+	return []findings.Finding{auditFinding(target, rel, "", "AUDIT-001",
+		fmt.Sprintf("baseline %s grew from %d to %d entries since the last scan (threshold %d)", rel, prev.BaselineEntries, current, growth),
+		map[string]string{
+			"setting":          "baseline",
+			"previous_entries": fmt.Sprint(prev.BaselineEntries),
+			"entries":          fmt.Sprint(current),
+		})}
+}
+
+// auditExcludes reports each config whose scan.exclude patterns cover more
+// than fraction of the files discovery finds when only .gitignore applies.
+func auditExcludes(target string, cfg *ScanConfig, tree *ConfigTree, fraction float64) []findings.Finding {
+	configs := map[string]*ScanConfig{".": cfg}
+	for dir, nested := range tree.nested {
+		configs[dir] = nested
+	}
+	hasExcludes := false
+	for _, c := range configs {
+		hasExcludes = hasExcludes || len(c.Scan.Exclude) > 0
+	}
+	if !hasExcludes {
+		return nil
+	}
+
+	all, err := discovery.NewWalker(target).Walk()
+	if err != nil || len(all) == 0 {
+		return nil
+	}
+	excluded := make(map[string]int)
+	for _, a := range all {
+		if dir := excludingConfig(a.Path, cfg, tree); dir != "" {
+			excluded[dir]++
+		}
+	}
+
+	dirs := make([]string, 0, len(excluded))
+	for dir := range excluded {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var out []findings.Finding
+	for _, dir := range dirs {
+		share := float64(excluded[dir]) / float64(len(all))
+		if share <= fraction {
+			continue
+		}
+		file := ConfigFileName
+		if dir != "." {
+			file = dir + "/" + ConfigFileName
+		}
+		patterns := strings.Join(configs[dir].Scan.Exclude, ", ")
+		out = append(out, auditFinding(target, file, "exclude", "AUDIT-002",
+			fmt.Sprintf("scan.exclude [%s] in %s excludes %d of %d files (%.0f%%, threshold %.0f%%)",
+				patterns, file, excluded[dir], len(all), share*100, fraction*100),
+			map[string]string{
+				"setting":        "scan.exclude",
+				"value":          patterns,
+				"excluded_files": fmt.Sprint(excluded[dir]),
+				"total_files":    fmt.Sprint(len(all)),
+			}))
+	}
+	return out
+}
+
+// excludingConfig returns the directory of the config whose scan.exclude
+// patterns exclude file, "." for the root config, or "" if none does.
+// Like discovery, a pattern matching a parent directory excludes the file.
+func excludingConfig(file string, cfg *ScanConfig, tree *ConfigTree) string {
+	if ignoredWithParents(file, cfg.Scan.Exclude) {
+		return "."
+	}
+	for _, dir := range ancestors(path.Dir(file)) {
+		nested, ok := tree.nested[dir]
+		if ok && ignoredWithParents(strings.TrimPrefix(file, dir+"/"), nested.Scan.Exclude) {
+			return dir
+		}
+	}
+	return ""
+}
+
+func ignoredWithParents(file string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	for _, dir := range ancestors(path.Dir(file)) {
+		if discovery.IsIgnored(dir, patterns) {
+			return true
+		}
+	}
+	return discovery.IsIgnored(file, patterns)
+}
+
+// auditDisabledCategories reports configs whose scan.rules.disable list,
+// merged with their parents', covers every rule of a category. A nested
+// config is reported only if its parent does not already disable the
+// category.
+func auditDisabledCategories(target string, tree *ConfigTree, categories []ruleCategory) []findings.Finding {
+	dirs := []string{"."}
+	for dir := range tree.nested {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs[1:])
+
+	allDisabled := func(dir string, c ruleCategory) bool {
+		disabled := tree.effectiveDir(dir).Scan.Rules.Disable
+		if len(disabled) == 0 || len(c.rules.Rules()) == 0 {
+			return false
+		}
+		set := make(map[string]bool, len(disabled))
+		for _, id := range disabled {
+			set[id] = true
+		}
+		for _, r := range c.rules.Rules() {
+			if !set[r.ID] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var out []findings.Finding
+	for _, dir := range dirs {
+		for _, c := range categories {
+			if !allDisabled(dir, c) || (dir != "." && allDisabled(path.Dir(dir), c)) {
+				continue
+			}
+			file := ConfigFileName
+			if dir != "." {
+				file = dir + "/" + ConfigFileName
+			}
+			out = append(out, auditFinding(target, file, "disable", "AUDIT-003",
+				fmt.Sprintf("scan.rules.disable in %s disables all %d %s rules", file, len(c.rules.Rules()), c.name),
+				map[string]string{"setting": "scan.rules.disable", "category": c.name}))
+		}
+	}
+	return out
+}
+
+// auditOverrides reports the findings of fs that the override log says
+// were let through by a Nox-Override trailer and that are not baselined.
+// The AUDIT-006 finding is located at the overridden finding.
+func auditOverrides(target string, fs *findings.FindingSet) []findings.Finding {
+	logPath := override.DefaultLogPath(target)
+	entries, err := override.Load(logPath)
+	if err != nil {
+		slog.Debug("could not read override log", "path", logPath, "error", err)
+	}
+	if len(entries) == 0 || fs == nil {
+		return nil
+	}
+	items := fs.Findings()
+	byFingerprint := make(map[string]*findings.Finding, len(items))
+	for i := range items {
+		byFingerprint[items[i].Fingerprint] = &items[i]
+	}
+
+	var out []findings.Finding
+	reported := make(map[string]bool)
+	for _, e := range entries {
+		f, ok := byFingerprint[e.Fingerprint]
+		if !ok || f.Status == findings.StatusBaselined || reported[e.Fingerprint] {
+			continue
+		}
+		reported[e.Fingerprint] = true
+		out = append(out, findings.Finding{
+			RuleID:     "AUDIT-006",
+			Severity:   findings.SeverityInfo,
+			Confidence: findings.ConfidenceHigh,
+			Location:   f.Location,
+			Message: fmt.Sprintf("%s was let through by a Nox-Override trailer on %s (reason: %s) and is not baselined",
+				e.FindingID, e.Time, e.Reason),
+			Metadata: map[string]string{
+				"setting":      "Nox-Override",
+				"finding_id":   e.FindingID,
+				"fingerprint":  e.Fingerprint,
+				"reason":       e.Reason,
+				"overridden":   e.Time,
+				"override_log": relToTarget(target, logPath),
+			},
+		})
+	}
+	return out
+}
+
+// auditFinding builds an AUDIT-* finding at the line of key in file, a
+// path relative to target. An empty key or a key that cannot be found
+// points at line 1.
+func auditFinding(target, file, key, ruleID, message string, metadata map[string]string) findings.Finding {
+	line := 1
+	if key != "" {
+		line = yamlKeyLine(filepath.Join(target, filepath.FromSlash(file)), key)
+	}
+	metadata["config_file"] = file
+	return findings.Finding{
+		RuleID:     ruleID,
+		Severity:   findings.SeverityInfo,
+		Confidence: findings.ConfidenceHigh,
+		Location: findings.Location{
+			FilePath:  file,
+			StartLine: line,
+		},
+		Message:  message,
+		Metadata: metadata,
+	}
+}
+
+// yamlKeyLine returns the 1-based line of the first "key:" in the YAML
+// file at path, or 1 if it is not found.
+func yamlKeyLine(path, key string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 1
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		if strings.HasPrefix(strings.TrimSpace(scanner.Text()), key+":") {
+			return n
+		}
+	}
+	return 1
+}
+
+// relToTarget returns p relative to target with forward slashes, or p
+// unchanged if it lies outside target.
+func relToTarget(target, p string) string {
+	absTarget, err1 := filepath.Abs(target)
+	absPath, err2 := filepath.Abs(p)
+	if err1 != nil || err2 != nil {
+		return filepath.ToSlash(p)
+	}
+	rel, err := filepath.Rel(absTarget, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -76,13 +76,42 @@ func CountBySeverity(ff []findings.Finding) map[findings.Severity]int {
 
 // SecurityScore computes a weighted score from finding severity counts.
 func SecurityScore(counts map[findings.Severity]int) int {
+	return WeightedScore(counts, nil)
+}
+
+// WeightedScore computes a score like SecurityScore, with the weights in
+// weights used in place of SeverityWeight for the severities they list.
+func WeightedScore(counts, weights map[findings.Severity]int) int {
 	score := 0
 	for sev, n := range counts {
-		score += SeverityWeight[sev] * n
+		w, ok := weights[sev]
+		if !ok {
+			w = SeverityWeight[sev]
+		}
+		score += w * n
 	}
 	return score
 }
 
+// CategoryWeightedScore computes a score like WeightedScore, multiplying
+// the weight of each finding by the weight of its category, as returned
+// by categoryWeight, and rounding the total. A nil categoryWeight weighs
+// every category 1.
+func CategoryWeightedScore(ff []findings.Finding, weights map[findings.Severity]int, categoryWeight func(*findings.Finding) float64) int {
+	if categoryWeight == nil {
+		return WeightedScore(CountBySeverity(ff), weights)
+	}
+	score := 0.0
+	for i := range ff {
+		w, ok := weights[ff[i].Severity]
+		if !ok {
+			w = SeverityWeight[ff[i].Severity]
+		}
+		score += float64(w) * categoryWeight(&ff[i])
+	}
+	return int(math.Round(score))
+}
+
 // GradeFromScore returns the letter grade for a given score.
 func GradeFromScore(score int) Grade {
 	for _, t := range gradeThresholds {
@@ -95,8 +124,20 @@ func GradeFromScore(score int) Grade {
 
 // GenerateFromFindings creates a badge result from a set of findings.
 func GenerateFromFindings(ff []findings.Finding, label string) *Result {
-	counts := CountBySeverity(ff)
-	score := SecurityScore(counts)
+	return GenerateWeighted(ff, label, nil)
+}
+
+// GenerateWeighted creates a badge result like GenerateFromFindings, scoring
+// findings with custom severity weights as described by WeightedScore.
+func GenerateWeighted(ff []findings.Finding, label string, weights map[findings.Severity]int) *Result {
+	return GenerateCategoryWeighted(ff, label, weights, nil)
+}
+
+// GenerateCategoryWeighted creates a badge result like GenerateWeighted,
+// weighing findings by their category as described by
+// CategoryWeightedScore.
+func GenerateCategoryWeighted(ff []findings.Finding, label string, weights map[findings.Severity]int, categoryWeight func(*findings.Finding) float64) *Result {
+	score := CategoryWeightedScore(ff, weights, categoryWeight)
 	grade := GradeFromScore(score)
 
 	return &Result{
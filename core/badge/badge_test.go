@@ -78,6 +78,42 @@ func TestGenerateFromFindings_WithFindings(t *testing.T) {
 	}
 }
 
+func TestGenerateWeighted(t *testing.T) {
+	ff := []findings.Finding{
+		{Severity: findings.SeverityCritical},
+		{Severity: findings.SeverityHigh},
+	}
+	// Critical is reweighted; high keeps its default weight of 5.
+	result := GenerateWeighted(ff, "nox", map[findings.Severity]int{findings.SeverityCritical: 50})
+	if result.Score != 55 || result.Grade != "F" {
+		t.Errorf("expected score 55 and grade F, got %d and %s", result.Score, result.Grade)
+	}
+	if got := GenerateWeighted(ff, "nox", nil); got.Score != GenerateFromFindings(ff, "nox").Score {
+		t.Errorf("nil weights scored %d, want the default score", got.Score)
+	}
+}
+
+func TestGenerateCategoryWeighted(t *testing.T) {
+	ff := []findings.Finding{
+		{RuleID: "LIC-X", Severity: findings.SeverityHigh},
+		{RuleID: "SEC-001", Severity: findings.SeverityMedium},
+		{RuleID: "LIC-Y", Severity: findings.SeverityMedium},
+	}
+	// Findings of the license category weigh half as much; 2.5 + 2 + 1.
+	weight := func(f *findings.Finding) float64 {
+		if strings.HasPrefix(f.RuleID, "LIC-") {
+			return 0.5
+		}
+		return 1
+	}
+	if got := GenerateCategoryWeighted(ff, "nox", nil, weight); got.Score != 6 || got.Grade != "C" {
+		t.Errorf("expected score 6 and grade C, got %d and %s", got.Score, got.Grade)
+	}
+	if got := GenerateCategoryWeighted(ff, "nox", nil, nil); got.Score != 9 {
+		t.Errorf("nil category weights scored %d, want 9", got.Score)
+	}
+}
+
 func TestGenerateSVG_Structure(t *testing.T) {
 	svg := GenerateSVG("nox", "A", "#4c1")
 	if !strings.HasPrefix(svg, "<svg") {
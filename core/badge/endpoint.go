@@ -0,0 +1,36 @@
+package badge
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Endpoint is a badge in the shields.io endpoint schema. Serve or commit
+// it as JSON and point https://img.shields.io/endpoint?url=... at it to
+// render a badge that updates with the file.
+type Endpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// NewEndpoint converts a badge result to the shields.io endpoint schema.
+// Shields expects hex colors without the leading '#'.
+func NewEndpoint(r *Result) Endpoint {
+	return Endpoint{
+		SchemaVersion: 1,
+		Label:         r.Label,
+		Message:       r.Value,
+		Color:         strings.TrimPrefix(r.Color, "#"),
+	}
+}
+
+// MarshalEndpoint returns the indented endpoint JSON for a badge result.
+func MarshalEndpoint(r *Result) ([]byte, error) {
+	data, err := json.MarshalIndent(NewEndpoint(r), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
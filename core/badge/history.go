@@ -0,0 +1,142 @@
+package badge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/nox-hq/nox/core/atomicfile"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// MaxHistoryEntries caps the number of entries kept in a history file so
+// that it stays small enough to commit.
+const MaxHistoryEntries = 365
+
+// HistoryEntry is one line of a badge history file. It holds only the
+// date, grade and counts so the file is safe to commit.
+type HistoryEntry struct {
+	Date     string `json:"date"`
+	Grade    string `json:"grade"`
+	Score    int    `json:"score"`
+	Critical int    `json:"critical"`
+	High     int    `json:"high"`
+	Medium   int    `json:"medium"`
+	Low      int    `json:"low"`
+	Total    int    `json:"total"`
+}
+
+// NewHistoryEntry records the grade and severity counts of findings for
+// date, formatted as YYYY-MM-DD.
+func NewHistoryEntry(date string, ff []findings.Finding) HistoryEntry {
+	counts := CountBySeverity(ff)
+	score := SecurityScore(counts)
+	return HistoryEntry{
+		Date:     date,
+		Grade:    GradeFromScore(score).Letter,
+		Score:    score,
+		Critical: counts[findings.SeverityCritical],
+		High:     counts[findings.SeverityHigh],
+		Medium:   counts[findings.SeverityMedium],
+		Low:      counts[findings.SeverityLow],
+		Total:    len(ff),
+	}
+}
+
+// ReadHistory reads a JSON Lines history file. A missing file yields no
+// entries; malformed lines are skipped.
+func ReadHistory(path string) ([]HistoryEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// AppendHistory appends entry to the history file at path, keeping at most
+// limit of the newest entries. The file is replaced atomically so that an
+// interrupted run cannot leave a truncated history behind.
+func AppendHistory(path string, entry HistoryEntry, limit int) error {
+	entries, err := ReadHistory(path)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, entry)
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	return atomicfile.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// sparkTicks are the bar glyphs used by Sparkline, lowest first.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a row of bars scaled between their minimum
+// and maximum. Equal values render as the lowest bar.
+func Sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = (v - lo) * (len(sparkTicks) - 1) / (hi - lo)
+		}
+		b.WriteRune(sparkTicks[i])
+	}
+	return b.String()
+}
+
+// Trend summarises the last n history entries as a sparkline of scores
+// (higher bars are worse) followed by the first and last score and grade.
+func Trend(entries []HistoryEntry, n int) string {
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	if len(entries) == 0 {
+		return "no badge history"
+	}
+	scores := make([]int, len(entries))
+	for i, e := range entries {
+		scores[i] = e.Score
+	}
+	first, last := entries[0], entries[len(entries)-1]
+	return fmt.Sprintf("%s score %d → %d, grade %s → %s (%d runs, %s – %s)",
+		Sparkline(scores), first.Score, last.Score, first.Grade, last.Grade,
+		len(entries), first.Date, last.Date)
+}
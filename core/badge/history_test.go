@@ -0,0 +1,109 @@
+package badge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+func TestNewEndpoint(t *testing.T) {
+	r := GenerateFromFindings([]findings.Finding{{Severity: findings.SeverityHigh}}, "nox")
+	data, err := MarshalEndpoint(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]any{"schemaVersion": float64(1), "label": "nox", "message": "C", "color": "dfb317"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestNewHistoryEntry(t *testing.T) {
+	ff := []findings.Finding{
+		{Severity: findings.SeverityCritical},
+		{Severity: findings.SeverityLow},
+		{Severity: findings.SeverityInfo},
+	}
+	e := NewHistoryEntry("2026-01-02", ff)
+	if e.Date != "2026-01-02" || e.Grade != "C" || e.Score != 11 || e.Critical != 1 || e.Low != 1 || e.Total != 3 {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestAppendHistory_Caps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "badge.history.jsonl")
+	for i := range 5 {
+		if err := AppendHistory(path, HistoryEntry{Date: "2026-01-0" + string(rune('1'+i)), Score: i}, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := ReadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Score != 2 || entries[2].Score != 4 {
+		t.Errorf("entries = %+v, want the last 3", entries)
+	}
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("file has %d lines, want 3", n)
+	}
+}
+
+func TestReadHistory(t *testing.T) {
+	entries, err := ReadHistory(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || entries != nil {
+		t.Errorf("missing file: entries=%v err=%v", entries, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "h.jsonl")
+	content := `{"date":"2026-01-01","grade":"A","score":0}` + "\nnot json\n\n" + `{"date":"2026-01-02","grade":"B","score":2}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ReadHistory(path)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("entries=%v err=%v, want 2 entries", entries, err)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []int
+		want   string
+	}{
+		{nil, ""},
+		{[]int{3, 3}, "▁▁"},
+		{[]int{0, 7, 14}, "▁▄█"},
+		{[]int{10, 0}, "█▁"},
+	}
+	for _, tt := range tests {
+		if got := Sparkline(tt.values); got != tt.want {
+			t.Errorf("Sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestTrend(t *testing.T) {
+	if got := Trend(nil, 30); got != "no badge history" {
+		t.Errorf("Trend(nil) = %q", got)
+	}
+	var entries []HistoryEntry
+	for i := range 40 {
+		entries = append(entries, HistoryEntry{Date: "d", Grade: "A", Score: i})
+	}
+	got := Trend(entries, 30)
+	if !strings.Contains(got, "score 10 → 39") || !strings.Contains(got, "30 runs") {
+		t.Errorf("Trend = %q, want the last 30 entries", got)
+	}
+}
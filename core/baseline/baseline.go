@@ -8,9 +8,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/nox-hq/nox/core/atomicfile"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
 )
 
 const schemaVersion = "1.0.0"
@@ -72,39 +75,9 @@ func (b *Baseline) Save(path string) error {
 		return fmt.Errorf("creating baseline directory: %w", err)
 	}
 
-	tmp, err := os.CreateTemp(dir, ".baseline-*.tmp")
-	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
-	}
-	tmpName := tmp.Name()
-
-	if _, err := tmp.Write(data); err != nil {
-		closeErr := tmp.Close()
-		removeErr := os.Remove(tmpName)
-		if closeErr != nil {
-			return fmt.Errorf("writing temp file: %w (close error: %v)", err, closeErr)
-		}
-		if removeErr != nil && !os.IsNotExist(removeErr) {
-			return fmt.Errorf("writing temp file: %w (remove error: %v)", err, removeErr)
-		}
-		return fmt.Errorf("writing temp file: %w", err)
-	}
-	if err := tmp.Close(); err != nil {
-		removeErr := os.Remove(tmpName)
-		if removeErr != nil && !os.IsNotExist(removeErr) {
-			return fmt.Errorf("closing temp file: %w (remove error: %v)", err, removeErr)
-		}
-		return fmt.Errorf("closing temp file: %w", err)
-	}
-
-	if err := os.Rename(tmpName, path); err != nil {
-		removeErr := os.Remove(tmpName)
-		if removeErr != nil && !os.IsNotExist(removeErr) {
-			return fmt.Errorf("renaming baseline file: %w (remove error: %v)", err, removeErr)
-		}
-		return fmt.Errorf("renaming baseline file: %w", err)
+	if err := atomicfile.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing baseline file: %w", err)
 	}
-
 	return nil
 }
 
@@ -160,6 +133,172 @@ func (b *Baseline) Prune(current []findings.Finding) int {
 	return removed
 }
 
+// Remove deletes the entry with the given fingerprint and reports whether
+// one was found.
+func (b *Baseline) Remove(fingerprint string) bool {
+	for i := range b.Entries {
+		if b.Entries[i].Fingerprint == fingerprint {
+			b.Entries = append(b.Entries[:i], b.Entries[i+1:]...)
+			b.buildIndex()
+			return true
+		}
+	}
+	return false
+}
+
+// SetReason replaces the reason of the entry with the given fingerprint and
+// reports whether one was found.
+func (b *Baseline) SetReason(fingerprint, reason string) bool {
+	e, ok := b.index[fingerprint]
+	if ok {
+		e.Reason = reason
+	}
+	return ok
+}
+
+// Migrate rewrites entries that were recorded under another path for a
+// file that has current findings, and returns the number rewritten. This
+// covers baselines written on Windows with backslashes, from a scan of an
+// absolute path, or from a scan rooted in a parent or child directory: an
+// entry's path migrates to a finding's path when one ends with the other
+// at a path separator. Such an entry's fingerprint, computed over the old
+// path, matches no finding, so entries and unmatched findings of the same
+// rule and file are paired in order. Entries of a removed built-in rule
+// are paired the same way with the findings of the rule that replaced it,
+// and take its ID. Pairing is best-effort and skipped when it is
+// ambiguous: when an entry's path fits several files or the entries and
+// findings of a file differ in number.
+func (b *Baseline) Migrate(current []findings.Finding) int {
+	type key struct{ rule, stored, path string }
+
+	live := make(map[string]bool, len(current))
+	for i := range current {
+		live[current[i].Fingerprint] = true
+	}
+	// Paths of the findings no entry matches, by rule.
+	pending := make(map[string][]*findings.Finding)
+	for i := range current {
+		if _, ok := b.index[current[i].Fingerprint]; !ok {
+			pending[current[i].RuleID] = append(pending[current[i].RuleID], &current[i])
+		}
+	}
+
+	entries := make(map[key][]int)
+	var keys []key
+	for i := range b.Entries {
+		e := &b.Entries[i]
+		if live[e.Fingerprint] {
+			continue
+		}
+		rule := rules.CanonicalID(e.RuleID)
+		renamed := rule != e.RuleID
+		target := ""
+		for _, f := range pending[rule] {
+			p := f.Location.FilePath
+			if (p == e.FilePath && !renamed) || p == target || !samePath(e.FilePath, p) {
+				continue
+			}
+			if target != "" {
+				target = ""
+				break
+			}
+			target = p
+		}
+		if target == "" {
+			continue
+		}
+		k := key{rule, e.FilePath, target}
+		if _, ok := entries[k]; !ok {
+			keys = append(keys, k)
+		}
+		entries[k] = append(entries[k], i)
+	}
+
+	migrated := 0
+	claimed := make(map[*findings.Finding]bool)
+	for _, k := range keys {
+		var ff []*findings.Finding
+		for _, f := range pending[k.rule] {
+			if f.Location.FilePath == k.path && !claimed[f] {
+				ff = append(ff, f)
+			}
+		}
+		if len(ff) != len(entries[k]) {
+			continue
+		}
+		for j, i := range entries[k] {
+			claimed[ff[j]] = true
+			b.Entries[i].Fingerprint = ff[j].Fingerprint
+			b.Entries[i].RuleID = ff[j].RuleID
+			b.Entries[i].FilePath = ff[j].Location.FilePath
+			migrated++
+		}
+	}
+	if migrated > 0 {
+		b.buildIndex()
+	}
+	return migrated
+}
+
+// samePath reports whether stored and current, once normalized, are equal
+// or one ends with the other at a path separator.
+func samePath(stored, current string) bool {
+	s := findings.NormalizePath(stored)
+	c := findings.NormalizePath(current)
+	return s == c || strings.HasSuffix(s, "/"+c) || strings.HasSuffix(c, "/"+s)
+}
+
+// Correlation pairs a baseline entry with the current finding that has its
+// fingerprint. Finding is nil when the entry is stale: the finding it
+// baselined no longer exists.
+type Correlation struct {
+	Entry   Entry
+	Finding *findings.Finding
+}
+
+// Correlate matches every entry against current, in baseline order.
+// Expired entries are matched too, so they can be reviewed.
+func (b *Baseline) Correlate(current []findings.Finding) []Correlation {
+	byFingerprint := make(map[string]*findings.Finding, len(current))
+	for i := range current {
+		byFingerprint[current[i].Fingerprint] = &current[i]
+	}
+	out := make([]Correlation, 0, len(b.Entries))
+	for _, e := range b.Entries {
+		out = append(out, Correlation{Entry: e, Finding: byFingerprint[e.Fingerprint]})
+	}
+	return out
+}
+
+// Backup copies the baseline file at path to path + ".bak" and returns the
+// backup path. It returns "" and no error when there is no file to back up.
+func Backup(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("reading baseline %s: %w", path, err)
+	}
+	backup := path + ".bak"
+	if err := os.WriteFile(backup, data, 0o644); err != nil {
+		return "", fmt.Errorf("writing baseline backup: %w", err)
+	}
+	return backup, nil
+}
+
+// RemovedRules returns the number of entries that name a removed built-in
+// rule, such as those Migrate could not pair with a finding.
+func (b *Baseline) RemovedRules() int {
+	n := 0
+	for i := range b.Entries {
+		if _, ok := rules.RenamedTo(b.Entries[i].RuleID); ok {
+			n++
+		}
+	}
+	return n
+}
+
 // Len returns the number of entries in the baseline.
 func (b *Baseline) Len() int {
 	return len(b.Entries)
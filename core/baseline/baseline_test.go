@@ -117,6 +117,50 @@ func TestPrune(t *testing.T) {
 	}
 }
 
+func TestCorrelateAndRemove(t *testing.T) {
+	bl := &Baseline{}
+	bl.Add(&Entry{Fingerprint: "fp1", RuleID: "SEC-001"})
+	bl.Add(&Entry{Fingerprint: "fp2", RuleID: "SEC-002"})
+
+	current := []findings.Finding{{Fingerprint: "fp2", Message: "still here"}}
+	got := bl.Correlate(current)
+	if len(got) != 2 || got[0].Finding != nil || got[1].Finding == nil || got[1].Finding.Message != "still here" {
+		t.Fatalf("unexpected correlation %+v", got)
+	}
+
+	if !bl.SetReason("fp2", "test fixture") || bl.Entries[1].Reason != "test fixture" {
+		t.Fatal("expected the fp2 reason to be updated")
+	}
+	if !bl.Remove("fp1") || bl.Remove("fp1") {
+		t.Fatal("expected fp1 to be removed exactly once")
+	}
+	if bl.Len() != 1 || bl.Match(&findings.Finding{Fingerprint: "fp2"}) == nil {
+		t.Fatal("expected fp2 to remain matchable after removal")
+	}
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+
+	backup, err := Backup(path)
+	if err != nil || backup != "" {
+		t.Fatalf("expected no backup of a missing file, got %q, %v", backup, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"entries":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backup, err = Backup(path)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	data, err := os.ReadFile(backup)
+	if err != nil || string(data) != `{"entries":[]}` {
+		t.Fatalf("backup content = %q, %v", data, err)
+	}
+}
+
 func TestSave_Atomic(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "sub", "baseline.json")
@@ -274,3 +318,95 @@ func TestBuildIndex_RebuildsCorrectly(t *testing.T) {
 		t.Fatal("expected match for fp2 after rebuild")
 	}
 }
+
+func TestMigrate(t *testing.T) {
+	current := []findings.Finding{
+		{RuleID: "SEC-001", Fingerprint: "new-1", Location: findings.Location{FilePath: "sub/config.go", StartLine: 3}},
+		{RuleID: "SEC-001", Fingerprint: "new-2", Location: findings.Location{FilePath: "sub/config.go", StartLine: 9}},
+		{RuleID: "SEC-002", Fingerprint: "new-3", Location: findings.Location{FilePath: "app/main.go", StartLine: 1}},
+	}
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    int
+	}{
+		{"windows checkout", []Entry{
+			{Fingerprint: "old-1", RuleID: "SEC-001", FilePath: `sub\config.go`},
+			{Fingerprint: "old-2", RuleID: "SEC-001", FilePath: `sub\config.go`},
+		}, 2},
+		{"absolute scan target", []Entry{
+			{Fingerprint: "old-3", RuleID: "SEC-002", FilePath: "/home/me/repo/app/main.go"},
+		}, 1},
+		{"scan of a subdirectory", []Entry{
+			{Fingerprint: "old-3", RuleID: "SEC-002", FilePath: "main.go"},
+		}, 1},
+		{"entry count differs from finding count", []Entry{
+			{Fingerprint: "old-1", RuleID: "SEC-001", FilePath: "/builds/repo/sub/config.go"},
+		}, 0},
+		{"different rule", []Entry{
+			{Fingerprint: "old-3", RuleID: "SEC-009", FilePath: "/home/me/repo/app/main.go"},
+		}, 0},
+		{"same path is a moved finding, not a migration", []Entry{
+			{Fingerprint: "old-3", RuleID: "SEC-002", FilePath: "app/main.go"},
+		}, 0},
+		{"suffix must start at a separator", []Entry{
+			{Fingerprint: "old-3", RuleID: "SEC-002", FilePath: "n.go"},
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bl := &Baseline{}
+			for i := range tt.entries {
+				bl.Add(&tt.entries[i])
+			}
+			if got := bl.Migrate(current); got != tt.want {
+				t.Fatalf("Migrate = %d, want %d", got, tt.want)
+			}
+			matched := 0
+			for i := range current {
+				if e := bl.Match(&current[i]); e != nil {
+					matched++
+					if e.FilePath != current[i].Location.FilePath {
+						t.Errorf("migrated entry path = %q, want %q", e.FilePath, current[i].Location.FilePath)
+					}
+				}
+			}
+			if matched != tt.want {
+				t.Errorf("%d findings match after migration, want %d", matched, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrate_RemovedRule(t *testing.T) {
+	current := []findings.Finding{
+		{RuleID: "SEC-030", Fingerprint: "new", Location: findings.Location{FilePath: "app/billing.go", StartLine: 4}},
+	}
+	bl := &Baseline{}
+	bl.Add(&Entry{Fingerprint: "old", RuleID: "SEC-548", FilePath: "app/billing.go", Reason: "test key"})
+	bl.Add(&Entry{Fingerprint: "gone", RuleID: "SEC-371", FilePath: "app/auth.go"})
+
+	if got := bl.Migrate(current); got != 1 {
+		t.Fatalf("Migrate = %d, want 1", got)
+	}
+	e := bl.Match(&current[0])
+	if e == nil || e.RuleID != "SEC-030" || e.Reason != "test key" {
+		t.Fatalf("expected the SEC-548 entry to baseline the SEC-030 finding, got %+v", e)
+	}
+	if got := bl.RemovedRules(); got != 1 {
+		t.Errorf("RemovedRules = %d, want 1 for the unpaired SEC-371 entry", got)
+	}
+}
+
+func TestMigrate_AmbiguousSuffix(t *testing.T) {
+	current := []findings.Finding{
+		{RuleID: "SEC-001", Fingerprint: "a", Location: findings.Location{FilePath: "svc-a/config.go"}},
+		{RuleID: "SEC-001", Fingerprint: "b", Location: findings.Location{FilePath: "svc-b/config.go"}},
+	}
+	bl := &Baseline{}
+	bl.Add(&Entry{Fingerprint: "old", RuleID: "SEC-001", FilePath: "config.go"})
+	if got := bl.Migrate(current); got != 0 {
+		t.Errorf("Migrate = %d, want 0 for a path that fits two files", got)
+	}
+}
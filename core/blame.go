@@ -0,0 +1,133 @@
+package core
+
+import (
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// attributeSecrets records who last changed the lines of each secrets
+// finding in a file git tracks, running one git blame per file over all of
+// that file's finding ranges. A finding spanning several lines is
+// attributed to the most recent of their commits.
+func attributeSecrets(target string, fs *findings.FindingSet, rs *rules.RuleSet) {
+	if !git.IsGitRepo(target) {
+		return
+	}
+	tracked, err := git.TrackedFiles(target)
+	if err != nil {
+		slog.Debug("skipping blame attribution", "error", err)
+		return
+	}
+	trackedSet := make(map[string]bool, len(tracked))
+	for _, p := range tracked {
+		trackedSet[p] = true
+	}
+
+	items := fs.Findings()
+	byFile := make(map[string][]int)
+	for i := range items {
+		f := &items[i]
+		if f.Location.StartLine <= 0 || rs.CategoryOf(f) != rules.CategorySecrets {
+			continue
+		}
+		if p := filepath.ToSlash(f.Location.FilePath); trackedSet[p] {
+			byFile[p] = append(byFile[p], i)
+		}
+	}
+	if len(byFile) == 0 {
+		return
+	}
+
+	shallow := git.IsShallow(target)
+	files := make([]string, 0, len(byFile))
+	for p := range byFile {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+
+	for _, p := range files {
+		idx := byFile[p]
+		ranges := make([]git.LineRange, 0, len(idx))
+		for _, i := range idx {
+			loc := items[i].Location
+			ranges = append(ranges, git.LineRange{Start: loc.StartLine, End: max(loc.EndLine, loc.StartLine)})
+		}
+		blame, err := git.Blame(target, p, mergeLineRanges(ranges))
+		if err != nil {
+			slog.Debug("skipping blame attribution", "path", p, "error", err)
+			continue
+		}
+		for _, i := range idx {
+			setBlameMetadata(&items[i], blame, shallow)
+		}
+	}
+}
+
+// setBlameMetadata attributes f to the most recent commit among its lines.
+// The author is unknown when a line is not committed yet, or when a line is
+// blamed on the boundary of a shallow clone and no other line has a later
+// commit: the real commit is then older than the clone's history.
+func setBlameMetadata(f *findings.Finding, blame map[int]git.BlameLine, shallow bool) {
+	var (
+		latest, boundary      git.BlameLine
+		found, uncommitted    bool
+		hasBoundary, anyBlame bool
+	)
+	for n := f.Location.StartLine; n <= max(f.Location.EndLine, f.Location.StartLine); n++ {
+		b, ok := blame[n]
+		if !ok {
+			continue
+		}
+		anyBlame = true
+		switch {
+		case !b.Committed():
+			uncommitted = true
+		case shallow && b.Boundary:
+			if !hasBoundary || b.Commit.Date.After(boundary.Commit.Date) {
+				boundary, hasBoundary = b, true
+			}
+		case !found || b.Commit.Date.After(latest.Commit.Date):
+			latest, found = b, true
+		}
+	}
+	if !anyBlame {
+		return
+	}
+	// Findings of one rule may share a metadata map.
+	f.Metadata = maps.Clone(f.Metadata)
+	if f.Metadata == nil {
+		f.Metadata = make(map[string]string)
+	}
+	if uncommitted || !found || (hasBoundary && !latest.Commit.Date.After(boundary.Commit.Date)) {
+		for _, k := range []string{findings.MetaBlameAuthor, findings.MetaBlameEmail, findings.MetaBlameCommit, findings.MetaBlameDate} {
+			f.Metadata[k] = findings.BlameUnknown
+		}
+		return
+	}
+	f.Metadata[findings.MetaBlameAuthor] = latest.Commit.Author
+	f.Metadata[findings.MetaBlameEmail] = latest.Commit.Email
+	f.Metadata[findings.MetaBlameCommit] = latest.Commit.SHA
+	f.Metadata[findings.MetaBlameDate] = latest.Commit.Date.Format(time.RFC3339)
+}
+
+// mergeLineRanges sorts ranges and merges overlapping or adjacent ones, as
+// git blame rejects overlapping -L ranges in older versions.
+func mergeLineRanges(ranges []git.LineRange) []git.LineRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	var out []git.LineRange
+	for _, r := range ranges {
+		if n := len(out); n > 0 && r.Start <= out[n-1].End+1 {
+			out[n-1].End = max(out[n-1].End, r.End)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/nox-hq/nox/core/analyzers/ai"
 	"github.com/nox-hq/nox/core/analyzers/data"
 	"github.com/nox-hq/nox/core/analyzers/deps"
+	"github.com/nox-hq/nox/core/analyzers/dotenv"
 	"github.com/nox-hq/nox/core/analyzers/iac"
 	"github.com/nox-hq/nox/core/analyzers/secrets"
 	"github.com/nox-hq/nox/core/compliance"
@@ -19,6 +20,7 @@ type RuleMeta struct {
 	Description          string                        `json:"description"`
 	Severity             string                        `json:"severity"`
 	Confidence           string                        `json:"confidence"`
+	Category             string                        `json:"category"`
 	CWE                  string                        `json:"cwe,omitempty"`
 	Tags                 []string                      `json:"tags,omitempty"`
 	Remediation          string                        `json:"remediation,omitempty"`
@@ -49,6 +51,7 @@ func Catalog() map[string]RuleMeta {
 func allRuleSets() []*rules.RuleSet {
 	return []*rules.RuleSet{
 		secrets.NewAnalyzer().Rules(),
+		dotenv.NewAnalyzer(nil).Rules(),
 		data.NewAnalyzer().Rules(),
 		ai.NewAnalyzer().Rules(),
 		iac.NewAnalyzer().Rules(),
@@ -56,12 +59,42 @@ func allRuleSets() []*rules.RuleSet {
 	}
 }
 
+// CategorizedRuleSet pairs a built-in RuleSet with the category of the
+// analyzer it belongs to.
+type CategorizedRuleSet struct {
+	Category string
+	Rules    *rules.RuleSet
+}
+
+// BuiltinRuleSets returns the built-in rules grouped by their Category:
+// secrets, data, ai, iac, deps, and container (the base image rules of the
+// dependency analyzer).
+func BuiltinRuleSets() []CategorizedRuleSet {
+	byCategory := make(map[string]*rules.RuleSet)
+	for _, rs := range allRuleSets() {
+		for _, r := range rs.Rules() {
+			if byCategory[r.Category] == nil {
+				byCategory[r.Category] = rules.NewRuleSet()
+			}
+			byCategory[r.Category].Add(r)
+		}
+	}
+	var out []CategorizedRuleSet
+	for _, c := range rules.Categories {
+		if rs := byCategory[c]; rs != nil {
+			out = append(out, CategorizedRuleSet{Category: c, Rules: rs})
+		}
+	}
+	return out
+}
+
 func metaFromRule(r *rules.Rule) RuleMeta {
 	return RuleMeta{
 		ID:          r.ID,
 		Description: r.Description,
 		Severity:    string(r.Severity),
 		Confidence:  string(r.Confidence),
+		Category:    r.Category,
 		CWE:         r.Metadata["cwe"],
 		Tags:        r.Tags,
 		Remediation: r.Remediation,
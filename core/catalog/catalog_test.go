@@ -2,15 +2,19 @@ package catalog
 
 import (
 	"testing"
+
+	"github.com/nox-hq/nox/core/rules"
 )
 
 func TestCatalogContainsAllRules(t *testing.T) {
 	cat := Catalog()
 
-	// We expect 1360 built-in rules across all analyzers (SEC + DATA + AI + IAC + VULN).
-	// SEC: 938, DATA: 12, AI: 50, IAC: 500, VULN: 3, CON: 2, LIC: 1
-	if got := len(cat); got != 1506 {
-		t.Errorf("Catalog() returned %d rules, want 1322", got)
+	// We expect 1546 built-in rules across all analyzers. Dotenv rules use
+	// the SEC prefix.
+	// SEC: 945, IAC: 500, AI: 50, DATA: 12, CONT: 8, CFN: 7, DEP: 5, ANS: 5,
+	// SUPPLY: 5, VULN: 3, LOCK: 3, LIC: 3
+	if got := len(cat); got != 1546 {
+		t.Errorf("Catalog() returned %d rules, want 1546", got)
 	}
 }
 
@@ -61,3 +65,96 @@ func TestCatalogLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestCatalog_EveryRuleHasCategory(t *testing.T) {
+	for id, meta := range Catalog() {
+		if !rules.ValidCategory(meta.Category) || meta.Category == rules.CategoryCustom {
+			t.Errorf("rule %s has category %q", id, meta.Category)
+		}
+	}
+}
+
+func TestBuiltinRuleSets_CoversCatalog(t *testing.T) {
+	cat := Catalog()
+	seen := make(map[string]string)
+	for _, crs := range BuiltinRuleSets() {
+		for _, r := range crs.Rules.Rules() {
+			seen[r.ID] = crs.Category
+		}
+	}
+	if len(seen) != len(cat) {
+		t.Fatalf("expected %d categorized rules, got %d", len(cat), len(seen))
+	}
+	if seen["CONT-001"] != "container" {
+		t.Errorf("expected CONT-001 in container category, got %q", seen["CONT-001"])
+	}
+	if seen["VULN-001"] != "deps" {
+		t.Errorf("expected VULN-001 in deps category, got %q", seen["VULN-001"])
+	}
+}
+
+func TestRenamedRules(t *testing.T) {
+	cat := Catalog()
+	for from, to := range rules.RenamedRules() {
+		if _, ok := cat[from]; ok {
+			t.Errorf("removed rule %s is still in the catalog", from)
+		}
+		if _, ok := cat[to]; !ok {
+			t.Errorf("rule %s, which replaced %s, is not in the catalog", to, from)
+		}
+	}
+}
+
+// uncompilableIaCRules are IaC rules whose patterns use lookaround, which
+// RE2 does not support, so they never match. They need rewriting as
+// RE2 patterns; remove each ID from the list when its rule is fixed.
+var uncompilableIaCRules = map[string]bool{
+	"IAC-051": true, "IAC-058": true, "IAC-059": true, "IAC-066": true, "IAC-074": true, "IAC-075": true,
+	"IAC-079": true, "IAC-080": true, "IAC-082": true, "IAC-084": true, "IAC-086": true, "IAC-092": true,
+	"IAC-094": true, "IAC-095": true, "IAC-096": true, "IAC-097": true, "IAC-098": true, "IAC-099": true,
+	"IAC-100": true, "IAC-101": true, "IAC-102": true, "IAC-104": true, "IAC-108": true, "IAC-113": true,
+	"IAC-119": true, "IAC-121": true, "IAC-122": true, "IAC-123": true, "IAC-124": true, "IAC-125": true,
+	"IAC-126": true, "IAC-127": true, "IAC-129": true, "IAC-132": true, "IAC-133": true, "IAC-134": true,
+	"IAC-135": true, "IAC-137": true, "IAC-138": true, "IAC-139": true, "IAC-140": true, "IAC-142": true,
+	"IAC-145": true, "IAC-146": true, "IAC-147": true, "IAC-148": true, "IAC-149": true, "IAC-153": true,
+	"IAC-155": true, "IAC-159": true, "IAC-162": true, "IAC-163": true, "IAC-164": true, "IAC-167": true,
+	"IAC-168": true, "IAC-169": true, "IAC-170": true, "IAC-171": true, "IAC-173": true, "IAC-176": true,
+	"IAC-179": true, "IAC-180": true, "IAC-182": true, "IAC-183": true, "IAC-200": true,
+}
+
+// TestBuiltinRuleSets_Lint compiles every built-in pattern and times the
+// patterns with the heaviest alternation against the adversarial lint
+// inputs. Timing the whole table takes too long for a unit test; nox rules
+// selftest does that.
+func TestBuiltinRuleSets_Lint(t *testing.T) {
+	slow := map[string]bool{"SEC-183": true, "SEC-252": true, "SEC-254": true, "AI-037": true}
+	all, timed := rules.NewRuleSet(), rules.NewRuleSet()
+	for _, crs := range BuiltinRuleSets() {
+		for _, r := range crs.Rules.Rules() {
+			all.Add(r)
+			if slow[r.ID] {
+				timed.Add(r)
+			}
+		}
+	}
+
+	failed := make(map[string]bool)
+	for _, issue := range rules.CheckPatterns(all) {
+		failed[issue.RuleID] = true
+		if !uncompilableIaCRules[issue.RuleID] {
+			t.Errorf("%s", issue)
+		}
+	}
+	for id := range uncompilableIaCRules {
+		if !failed[id] {
+			t.Errorf("rule %s compiles now; remove it from uncompilableIaCRules", id)
+		}
+	}
+
+	if got := len(timed.Rules()); got != len(slow) {
+		t.Fatalf("expected %d timed rules, got %d", len(slow), got)
+	}
+	for _, issue := range rules.LintRules(timed, rules.DefaultLintBudget) {
+		t.Errorf("%s", issue)
+	}
+}
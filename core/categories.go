@@ -0,0 +1,153 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/nox-hq/nox/core/catalog"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/policy"
+	"github.com/nox-hq/nox/core/report"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// NewCategoryFilter returns a function reporting whether rules of a
+// category take part in the scan under c: the category must be listed in
+// c.Only, when that is set, and must not be listed in c.Skip. Category
+// names that are not in categories are an error.
+func NewCategoryFilter(c CategoriesConfig, categories *rules.CategoryRegistry) (func(category string) bool, error) {
+	for _, name := range slices.Concat(c.Only, c.Skip) {
+		if !categories.Valid(name) {
+			return nil, fmt.Errorf("invalid rule category %q (want one of %s)", name, categories)
+		}
+	}
+	return func(category string) bool {
+		if len(c.Only) > 0 && !slices.Contains(c.Only, category) {
+			return false
+		}
+		return !slices.Contains(c.Skip, category)
+	}, nil
+}
+
+// ConfigCategories returns the built-in categories and those declared
+// under categories in cfg.
+func ConfigCategories(cfg *ScanConfig) (*rules.CategoryRegistry, error) {
+	categories := rules.NewCategoryRegistry()
+	for _, d := range cfg.Categories {
+		if err := categories.Register(d.Info()); err != nil {
+			return nil, fmt.Errorf("categories: %w", err)
+		}
+	}
+	return categories, nil
+}
+
+// scanCategoryRegistry returns the categories of a scan: those of
+// ConfigCategories and those declared by the rules files of sources.
+func scanCategoryRegistry(cfg *ScanConfig, sources []customRuleSource) (*rules.CategoryRegistry, error) {
+	categories, err := ConfigCategories(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range sources {
+		for _, info := range src.rules.DeclaredCategories() {
+			if err := categories.Register(info); err != nil {
+				return nil, fmt.Errorf("%s categories: %w", src.label, err)
+			}
+		}
+	}
+	return categories, nil
+}
+
+// ReportCategories returns the categories of the scan that wrote rep, for
+// the commands that read a findings.json: those of ConfigCategories and
+// those listed in the summary of rep. A category of rep that conflicts
+// with the config is left out with a warning.
+func ReportCategories(cfg *ScanConfig, rep *report.JSONReport) (*rules.CategoryRegistry, error) {
+	categories, err := ConfigCategories(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if rep.Summary == nil {
+		return categories, nil
+	}
+	for _, info := range rep.Summary.Categories {
+		if rules.ValidCategory(info.Name) {
+			continue
+		}
+		if err := categories.Register(info); err != nil {
+			slog.Warn("ignoring a category of the findings file", "category", info.Name, "error", err)
+		}
+	}
+	return categories, nil
+}
+
+// builtinRuleCategories maps the ID of each built-in rule to its category.
+var builtinRuleCategories = sync.OnceValue(func() map[string]string {
+	m := make(map[string]string)
+	for id, meta := range catalog.Catalog() {
+		m[id] = meta.Category
+	}
+	for _, r := range auditRules().Rules() {
+		m[r.ID] = r.Category
+	}
+	return m
+})
+
+// ReportFindingCategory returns the category of a finding read from a
+// findings.json: the category in its metadata under findings.MetaCategory,
+// which the scan records for the findings of rules that are not built in,
+// or that of its built-in rule, or "custom".
+func ReportFindingCategory(f *findings.Finding) string {
+	if c := f.Metadata[findings.MetaCategory]; c != "" {
+		return c
+	}
+	if c, ok := builtinRuleCategories()[f.RuleID]; ok && c != "" {
+		return c
+	}
+	return rules.CategoryCustom
+}
+
+// markCustomCategories records the category of the findings of the rules
+// of rs, rules that are not built in, in their metadata under
+// findings.MetaCategory.
+func markCustomCategories(fs *findings.FindingSet, rs *rules.RuleSet) {
+	items := fs.Findings()
+	for i := range items {
+		f := &items[i]
+		r, ok := rs.ByID(f.RuleID)
+		if !ok {
+			continue
+		}
+		// Findings may share their metadata with the rule.
+		f.Metadata = maps.Clone(f.Metadata)
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[findings.MetaCategory] = r.Category
+	}
+}
+
+// PolicyCategories returns the thresholds of policy.categories in cfg,
+// with severity labels resolved. Categories that are not in categories
+// are left out with a warning listing those that are.
+func PolicyCategories(cfg *ScanConfig, categories *rules.CategoryRegistry) map[string]policy.Thresholds {
+	if len(cfg.Policy.Categories) == 0 {
+		return nil
+	}
+	out := make(map[string]policy.Thresholds, len(cfg.Policy.Categories))
+	for _, name := range slices.Sorted(maps.Keys(cfg.Policy.Categories)) {
+		if !categories.Valid(name) {
+			slog.Warn("ignoring policy.categories entry for an unknown category", "category", name, "registered", categories.String())
+			continue
+		}
+		t := cfg.Policy.Categories[name]
+		out[name] = policy.Thresholds{
+			FailOn: cfg.SeverityMapping.Resolve(t.FailOn),
+			WarnOn: cfg.SeverityMapping.Resolve(t.WarnOn),
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/git"
+)
+
+// changedFilesFrom returns the slash-separated paths, relative to target,
+// of the files a change touches. from is either a file listing the paths
+// one per line, such as the output of git diff --name-only, or a git diff
+// revision spec such as origin/main...HEAD.
+func changedFilesFrom(target, from string) ([]string, error) {
+	if info, err := os.Stat(from); err == nil && !info.IsDir() {
+		return readChangedFiles(from)
+	}
+	if !git.IsGitRepo(target) {
+		return nil, fmt.Errorf("changed files from %s: no such file, and %s is not a git repository to diff", from, target)
+	}
+	changed, err := git.DiffNames(target, from)
+	if err != nil {
+		return nil, fmt.Errorf("changed files from %s: %w", from, err)
+	}
+	return changed, nil
+}
+
+// readChangedFiles reads a list of changed files, one path per line.
+// Blank lines and lines starting with # are skipped.
+func readChangedFiles(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading changed files: %w", err)
+	}
+	defer f.Close()
+
+	var changed []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		changed = append(changed, path.Clean(filepath.ToSlash(line)))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading changed files: %w", err)
+	}
+	return changed, nil
+}
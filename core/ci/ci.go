@@ -0,0 +1,111 @@
+// Package ci detects the continuous integration system nox is running in
+// from the environment variables each provider sets, so the CLI can pick
+// non-interactive defaults and reports can record which pipeline produced
+// them.
+package ci
+
+import (
+	"os"
+	"strings"
+)
+
+// Provider identifies a CI system.
+type Provider string
+
+// Supported CI providers.
+const (
+	GitHubActions Provider = "github-actions"
+	GitLabCI      Provider = "gitlab-ci"
+	Buildkite     Provider = "buildkite"
+	CircleCI      Provider = "circleci"
+	Jenkins       Provider = "jenkins"
+)
+
+// displayNames maps each provider to the name shown in verbose output.
+var displayNames = map[Provider]string{
+	GitHubActions: "GitHub Actions",
+	GitLabCI:      "GitLab CI",
+	Buildkite:     "Buildkite",
+	CircleCI:      "CircleCI",
+	Jenkins:       "Jenkins",
+}
+
+// Env describes the CI run nox is part of. Fields the provider does not
+// expose are empty.
+type Env struct {
+	Provider    Provider `json:"provider"`
+	PipelineURL string   `json:"pipeline_url,omitempty"`
+	Commit      string   `json:"commit,omitempty"`
+	Branch      string   `json:"branch,omitempty"`
+}
+
+// Name returns the human-readable provider name, such as "GitHub Actions".
+func (e *Env) Name() string {
+	return displayNames[e.Provider]
+}
+
+// SupportsAnnotations reports whether the provider turns workflow commands
+// printed to stdout into file annotations.
+func (e *Env) SupportsAnnotations() bool {
+	return e.Provider == GitHubActions
+}
+
+// Detect returns the CI environment from the process environment, or nil
+// when nox is not running in a supported CI system.
+func Detect() *Env {
+	return DetectFrom(os.Getenv)
+}
+
+// DetectFrom is Detect with a custom variable lookup.
+func DetectFrom(getenv func(string) string) *Env {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		env := &Env{
+			Provider: GitHubActions,
+			Commit:   getenv("GITHUB_SHA"),
+			Branch:   firstNonEmpty(getenv("GITHUB_HEAD_REF"), getenv("GITHUB_REF_NAME")),
+		}
+		if server, repo, run := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+			env.PipelineURL = strings.TrimSuffix(server, "/") + "/" + repo + "/actions/runs/" + run
+		}
+		return env
+	case getenv("GITLAB_CI") == "true":
+		return &Env{
+			Provider:    GitLabCI,
+			PipelineURL: getenv("CI_PIPELINE_URL"),
+			Commit:      getenv("CI_COMMIT_SHA"),
+			Branch:      firstNonEmpty(getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"), getenv("CI_COMMIT_REF_NAME")),
+		}
+	case getenv("BUILDKITE") == "true":
+		return &Env{
+			Provider:    Buildkite,
+			PipelineURL: getenv("BUILDKITE_BUILD_URL"),
+			Commit:      getenv("BUILDKITE_COMMIT"),
+			Branch:      getenv("BUILDKITE_BRANCH"),
+		}
+	case getenv("CIRCLECI") == "true":
+		return &Env{
+			Provider:    CircleCI,
+			PipelineURL: getenv("CIRCLE_BUILD_URL"),
+			Commit:      getenv("CIRCLE_SHA1"),
+			Branch:      getenv("CIRCLE_BRANCH"),
+		}
+	case getenv("JENKINS_URL") != "":
+		return &Env{
+			Provider:    Jenkins,
+			PipelineURL: getenv("BUILD_URL"),
+			Commit:      getenv("GIT_COMMIT"),
+			Branch:      firstNonEmpty(getenv("BRANCH_NAME"), strings.TrimPrefix(getenv("GIT_BRANCH"), "origin/")),
+		}
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
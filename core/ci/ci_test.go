@@ -0,0 +1,57 @@
+package ci
+
+import "testing"
+
+func TestDetectFrom(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want *Env
+	}{
+		{"none", map[string]string{"CI": "true"}, nil},
+		{"github actions", map[string]string{
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_SERVER_URL": "https://github.com",
+			"GITHUB_REPOSITORY": "acme/app",
+			"GITHUB_RUN_ID":     "42",
+			"GITHUB_SHA":        "abc123",
+			"GITHUB_REF_NAME":   "17/merge",
+			"GITHUB_HEAD_REF":   "feature",
+		}, &Env{Provider: GitHubActions, PipelineURL: "https://github.com/acme/app/actions/runs/42", Commit: "abc123", Branch: "feature"}},
+		{"gitlab", map[string]string{
+			"GITLAB_CI":          "true",
+			"CI_PIPELINE_URL":    "https://gitlab.com/acme/app/-/pipelines/7",
+			"CI_COMMIT_SHA":      "def456",
+			"CI_COMMIT_REF_NAME": "main",
+		}, &Env{Provider: GitLabCI, PipelineURL: "https://gitlab.com/acme/app/-/pipelines/7", Commit: "def456", Branch: "main"}},
+		{"buildkite", map[string]string{"BUILDKITE": "true", "BUILDKITE_BRANCH": "main"}, &Env{Provider: Buildkite, Branch: "main"}},
+		{"circleci", map[string]string{"CIRCLECI": "true", "CIRCLE_SHA1": "fff"}, &Env{Provider: CircleCI, Commit: "fff"}},
+		{"jenkins", map[string]string{
+			"JENKINS_URL": "https://ci.example.com/",
+			"BUILD_URL":   "https://ci.example.com/job/app/3/",
+			"GIT_BRANCH":  "origin/release",
+		}, &Env{Provider: Jenkins, PipelineURL: "https://ci.example.com/job/app/3/", Branch: "release"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectFrom(func(k string) string { return tt.vars[k] })
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil || *got != *tt.want:
+				t.Errorf("DetectFrom() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnv_SupportsAnnotations(t *testing.T) {
+	if !(&Env{Provider: GitHubActions}).SupportsAnnotations() {
+		t.Error("expected GitHub Actions to support annotations")
+	}
+	if (&Env{Provider: GitLabCI}).SupportsAnnotations() {
+		t.Error("expected GitLab CI not to support annotations")
+	}
+	if name := (&Env{Provider: CircleCI}).Name(); name != "CircleCI" {
+		t.Errorf("Name() = %q", name)
+	}
+}
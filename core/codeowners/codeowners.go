@@ -0,0 +1,288 @@
+// Package codeowners resolves the owners of repository paths from a GitHub
+// or GitLab CODEOWNERS file, so that findings can name the team or user
+// responsible for fixing them.
+package codeowners
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// Unowned is the owner bucket of paths no CODEOWNERS rule assigns.
+const Unowned = "unowned"
+
+// Locations are the paths, relative to the repository root, where GitHub
+// and GitLab look for a CODEOWNERS file, in the order they are tried.
+var Locations = []string{
+	".github/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// rule assigns owners to the paths its pattern matches.
+type rule struct {
+	pattern  []string // slash-separated segments; "**" matches any number
+	dirOnly  bool     // the pattern ended in "/": only paths below it match
+	children bool     // the pattern ended in "/*": only direct children match
+	owners   []string
+}
+
+// section is a GitLab CODEOWNERS section. GitHub files have a single
+// unnamed section.
+type section struct {
+	name  string
+	rules []rule
+}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	// Path is the file the rules were read from.
+	Path     string
+	sections []section
+}
+
+// Load reads the first CODEOWNERS file found in root at one of Locations.
+// It returns nil and no error when root has none.
+func Load(root string) (*File, error) {
+	for _, loc := range Locations {
+		p := filepath.Join(root, filepath.FromSlash(loc))
+		data, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", p, err)
+		}
+		f := Parse(data)
+		f.Path = p
+		return f, nil
+	}
+	return nil, nil
+}
+
+// Parse parses CODEOWNERS content. Blank lines and comments are skipped,
+// as are lines without a pattern. GitLab section headers such as
+// "[Frontend] @web-team" or "^[Docs][2] @docs" start a section whose
+// default owners apply to its rules that list none.
+func Parse(data []byte) *File {
+	f := &File{sections: []section{{}}}
+	var defaults []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, owners, ok := parseSectionHeader(line); ok {
+			f.sections = append(f.sections, section{name: name})
+			defaults = owners
+			continue
+		}
+		fields := splitFields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+		owners := fields[1:]
+		if len(owners) == 0 {
+			owners = defaults
+		}
+		cur := &f.sections[len(f.sections)-1]
+		cur.rules = append(cur.rules, newRule(fields[0], owners))
+	}
+	return f
+}
+
+// parseSectionHeader parses a GitLab section header: an optional "^" for
+// an optional section, the name in brackets, an optional approval count in
+// brackets, and default owners.
+func parseSectionHeader(line string) (name string, owners []string, ok bool) {
+	rest := strings.TrimPrefix(line, "^")
+	if !strings.HasPrefix(rest, "[") {
+		return "", nil, false
+	}
+	end := strings.Index(rest, "]")
+	if end < 0 {
+		return "", nil, false
+	}
+	name = rest[1:end]
+	rest = rest[end+1:]
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "]"); end >= 0 {
+			rest = rest[end+1:]
+		}
+	}
+	return name, splitFields(rest), true
+}
+
+// splitFields splits a line at unescaped whitespace, stopping at a comment.
+// A backslash escapes the next character, so "\ " is a space in a pattern
+// and "\#" a literal "#".
+func splitFields(line string) []string {
+	var (
+		fields []string
+		cur    strings.Builder
+		inWord bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			cur.WriteByte(line[i])
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			i = len(line)
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
+
+// newRule compiles a gitignore-style pattern. A pattern with a leading or
+// inner "/" is anchored at the repository root; any other pattern matches
+// at any depth.
+func newRule(pattern string, owners []string) rule {
+	r := rule{owners: owners}
+	if strings.HasSuffix(pattern, "/") {
+		r.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	r.pattern = strings.Split(pattern, "/")
+	if !anchored {
+		r.pattern = append([]string{"**"}, r.pattern...)
+	}
+	r.children = len(r.pattern) > 1 && r.pattern[len(r.pattern)-1] == "*"
+	return r
+}
+
+// matches reports whether r matches the file at p, a slash-separated path
+// relative to the repository root. A pattern that matches a directory
+// matches everything below it.
+func (r rule) matches(p string) bool {
+	name := strings.Split(strings.Trim(p, "/"), "/")
+	for k := 1; k <= len(name); k++ {
+		if k < len(name) && r.children {
+			continue
+		}
+		if k == len(name) && r.dirOnly {
+			break
+		}
+		if matchSegments(r.pattern, name[:k]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Owners returns the owners of p, a path relative to the repository root.
+// Within a section the last matching rule wins; the owners of all sections
+// are combined in file order without duplicates. A matching rule with no
+// owners leaves the path unowned in its section. It returns nil for an
+// unowned path.
+func (f *File) Owners(p string) []string {
+	if f == nil {
+		return nil
+	}
+	p = filepath.ToSlash(p)
+	var owners []string
+	seen := make(map[string]bool)
+	for _, s := range f.sections {
+		for i := len(s.rules) - 1; i >= 0; i-- {
+			if !s.rules[i].matches(p) {
+				continue
+			}
+			for _, o := range s.rules[i].owners {
+				if !seen[strings.ToLower(o)] {
+					seen[strings.ToLower(o)] = true
+					owners = append(owners, o)
+				}
+			}
+			break
+		}
+	}
+	return owners
+}
+
+// Assign sets the owners metadata of each finding in fs from f. prefix is
+// the path of the scanned directory relative to the repository root, or ""
+// when the repository root was scanned.
+func (f *File) Assign(fs []findings.Finding, prefix string) {
+	for i := range fs {
+		p := fs[i].Location.FilePath
+		if p == "" {
+			continue
+		}
+		if prefix != "" {
+			p = path.Join(filepath.ToSlash(prefix), filepath.ToSlash(p))
+		}
+		owners := strings.Join(f.Owners(p), " ")
+		if owners == "" {
+			owners = Unowned
+		}
+		if fs[i].Metadata == nil {
+			fs[i].Metadata = make(map[string]string)
+		}
+		fs[i].Metadata[findings.MetaOwners] = owners
+	}
+}
+
+// FindingOwners returns the owners recorded in f's metadata: [Unowned] for
+// an unowned finding and nil when ownership was not resolved.
+func FindingOwners(f *findings.Finding) []string {
+	return strings.Fields(f.Metadata[findings.MetaOwners])
+}
+
+// HasOwner reports whether f is owned by owner, compared
+// case-insensitively. Unowned matches unowned findings.
+func HasOwner(f *findings.Finding, owner string) bool {
+	for _, o := range FindingOwners(f) {
+		if strings.EqualFold(o, owner) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+const githubFile = `# Default owners
+*                       @org/security
+
+*.js                    @org/frontend
+/docs/                  @org/docs
+apps/                   @org/apps
+/build/logs/            @org/infra
+docs/*                  @org/writers
+/services/payments/**   @org/payments @alice
+/scripts/deploy.sh      dev@example.com
+/vendor/
+`
+
+func TestOwners_GitHub(t *testing.T) {
+	f := Parse([]byte(githubFile))
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@org/security"}},
+		{"web/app.js", []string{"@org/frontend"}},
+		// docs/* is later than /docs/ and wins for direct children only.
+		{"docs/index.md", []string{"@org/writers"}},
+		{"docs/guide/setup.md", []string{"@org/docs"}},
+		{"src/apps/api/main.go", []string{"@org/apps"}},
+		{"build/logs/out.txt", []string{"@org/infra"}},
+		{"src/build/logs/out.txt", []string{"@org/security"}},
+		{"services/payments/api/charge.go", []string{"@org/payments", "@alice"}},
+		{"services/payments.go", []string{"@org/security"}},
+		{"scripts/deploy.sh", []string{"dev@example.com"}},
+		// A rule without owners un-assigns the path.
+		{"vendor/lib/lib.go", nil},
+	}
+	for _, tt := range tests {
+		if got := f.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOwners_GitLabSections(t *testing.T) {
+	f := Parse([]byte(`* @default-owner
+
+[Frontend] @web-team
+*.css
+/ui/admin/ @admin-team
+
+^[Docs][2] @docs-team
+*.md
+README.md @readme-owner
+`))
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@default-owner"}},
+		{"ui/site.css", []string{"@default-owner", "@web-team"}},
+		{"ui/admin/page.css", []string{"@default-owner", "@admin-team"}},
+		{"guide.md", []string{"@default-owner", "@docs-team"}},
+		{"README.md", []string{"@default-owner", "@readme-owner"}},
+	}
+	for _, tt := range tests {
+		if got := f.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParse_EscapesAndComments(t *testing.T) {
+	f := Parse([]byte("\\#notes.txt @hash # trailing comment\nmy\\ file.txt @space\n!negated @nobody\n"))
+	if got := f.Owners("#notes.txt"); !reflect.DeepEqual(got, []string{"@hash"}) {
+		t.Errorf("escaped # = %v", got)
+	}
+	if got := f.Owners("my file.txt"); !reflect.DeepEqual(got, []string{"@space"}) {
+		t.Errorf("escaped space = %v", got)
+	}
+	if got := f.Owners("negated"); got != nil {
+		t.Errorf("negation should be ignored, got %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	f, err := Load(dir)
+	if err != nil || f != nil {
+		t.Fatalf("Load without CODEOWNERS = %v, %v; want nil, nil", f, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @root\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @github\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Owners("a.go"); !reflect.DeepEqual(got, []string{"@github"}) {
+		t.Errorf(".github/CODEOWNERS should take precedence, got %v", got)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	f := Parse([]byte("/app/api/ @org/api\n"))
+	ff := []findings.Finding{
+		{Location: findings.Location{FilePath: "api/handler.go"}},
+		{Location: findings.Location{FilePath: "web/index.js"}},
+		{},
+	}
+	f.Assign(ff, "app")
+
+	if got := ff[0].Metadata[findings.MetaOwners]; got != "@org/api" {
+		t.Errorf("owned finding: owners = %q", got)
+	}
+	if got := ff[1].Metadata[findings.MetaOwners]; got != Unowned {
+		t.Errorf("unowned finding: owners = %q", got)
+	}
+	if ff[2].Metadata != nil {
+		t.Errorf("finding without a path should be left alone: %v", ff[2].Metadata)
+	}
+	if !HasOwner(&ff[0], "@ORG/api") || !HasOwner(&ff[1], Unowned) || HasOwner(&ff[0], "@org/web") {
+		t.Error("HasOwner mismatch")
+	}
+}
@@ -3,7 +3,10 @@
 // OWASP Top 10, OWASP LLM Top 10, OWASP Agentic). This enables compliance-filtered scan output.
 package compliance
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 // Framework identifies a compliance framework.
 type Framework string
@@ -109,12 +112,16 @@ func GenerateReport(fw Framework, triggeredRuleIDs []string) *FrameworkReport {
 
 	var violations []ControlViolation
 	for ctrlID, ruleIDs := range controlRules {
+		sort.Strings(ruleIDs)
 		violations = append(violations, ControlViolation{
 			ControlID: ctrlID,
 			Title:     controlTitle[ctrlID],
 			RuleIDs:   ruleIDs,
 		})
 	}
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].ControlID < violations[j].ControlID
+	})
 
 	// Count total unique controls for this framework.
 	allControls := make(map[string]struct{})
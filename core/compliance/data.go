@@ -1491,7 +1491,13 @@ func complianceData() map[string][]FrameworkControl {
 		// =================================================================
 		// License Rules (LIC-*)
 		// =================================================================
-		"LIC-001": { // Copyleft or restricted license
+		"LIC-001": { // Unknown license
+			{SOC2, "SOC2 CC9.2", "Risk assessment and management"},
+		},
+		"LIC-002": { // Copyleft or restricted license
+			{SOC2, "SOC2 CC9.2", "Risk assessment and management"},
+		},
+		"LIC-003": { // License changed since the last scan
 			{SOC2, "SOC2 CC9.2", "Risk assessment and management"},
 		},
 
@@ -1724,11 +1730,6 @@ func complianceData() map[string][]FrameworkControl {
 			{OWASPTop, "OWASP A02:2021", "Cryptographic Failures"},
 			{PCIDSS, "PCI-DSS 6.5.3", "Insecure cryptographic storage"},
 		},
-		"SEC-103": { // Clerk Secret Key
-			{NIST80053, "NIST IA-5", "Authenticator management"},
-			{OWASPTop, "OWASP A02:2021", "Cryptographic Failures"},
-			{PCIDSS, "PCI-DSS 6.5.3", "Insecure cryptographic storage"},
-		},
 		"SEC-104": { // Supabase Service Role Key
 			{NIST80053, "NIST IA-5", "Authenticator management"},
 			{OWASPTop, "OWASP A02:2021", "Cryptographic Failures"},
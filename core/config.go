@@ -3,120 +3,316 @@ package core
 import (
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/policy"
+	"github.com/nox-hq/nox/core/rules"
 )
 
-// LicensePolicy defines which dependency licenses are allowed or denied.
+// LicensePolicy defines which dependency licenses are allowed, denied or
+// flagged for review. Entries are SPDX license IDs matched as
+// case-insensitive prefixes. Package licenses are read as SPDX expressions,
+// and a dual-licensed package passes if any of its options does.
 // If Deny is specified, any package with a matching license produces a finding.
 // If Allow is specified, any package with a license NOT in the list produces a finding.
 type LicensePolicy struct {
-	Deny  []string `yaml:"deny"`  // License IDs to deny (e.g., ["GPL-3.0", "AGPL-3.0"])
-	Allow []string `yaml:"allow"` // License IDs to allow (e.g., ["MIT", "Apache-2.0", "BSD-*"])
+	Deny  []string `yaml:"deny,omitempty"`  // License IDs to deny (e.g., ["GPL-3.0", "AGPL-3.0"])
+	Allow []string `yaml:"allow,omitempty"` // License IDs to allow (e.g., ["MIT", "Apache-2.0", "BSD-*"])
+	Warn  []string `yaml:"warn,omitempty"`  // License IDs reported at medium severity (e.g., ["MPL-2.0"])
+	// HistoryPath is the file recording each package's license, used to
+	// report license changes (default .nox/license-history.json).
+	HistoryPath string `yaml:"history_path,omitempty"`
+}
+
+// configured reports whether p has any license list.
+func (p LicensePolicy) configured() bool {
+	return len(p.Deny) > 0 || len(p.Allow) > 0 || len(p.Warn) > 0
+}
+
+// licenseHistoryFile records the license of each dependency. It lives next
+// to the default baseline in the .nox directory.
+const licenseHistoryFile = "license-history.json"
+
+// licensePolicy returns the licenses section, or the license section when
+// licenses has no lists.
+func (c *ScanConfig) licensePolicy() LicensePolicy {
+	if c.Licenses.configured() {
+		return c.Licenses
+	}
+	return c.License
 }
 
 // ScanConfig holds project-level configuration loaded from .nox.yaml.
 type ScanConfig struct {
-	Scan       ScanSettings       `yaml:"scan"`
-	Output     OutputSettings     `yaml:"output"`
-	Explain    ExplainSettings    `yaml:"explain"`
-	Policy     PolicySettings     `yaml:"policy"`
-	License    LicensePolicy      `yaml:"license"`
-	Compliance ComplianceSettings `yaml:"compliance"`
+	Scan     ScanSettings    `yaml:"scan,omitempty"`
+	Output   OutputSettings  `yaml:"output,omitempty"`
+	Explain  ExplainSettings `yaml:"explain,omitempty"`
+	Policy   PolicySettings  `yaml:"policy,omitempty"`
+	Licenses LicensePolicy   `yaml:"licenses,omitempty"`
+	// License is the former name of Licenses, used when Licenses has no
+	// lists.
+	License      LicensePolicy      `yaml:"license,omitempty"`
+	Compliance   ComplianceSettings `yaml:"compliance,omitempty"`
+	Audit        AuditSettings      `yaml:"audit,omitempty"`
+	SBOM         SBOMSettings       `yaml:"sbom,omitempty"`
+	SARIF        SARIFSettings      `yaml:"sarif,omitempty"`
+	Dependencies DependencySettings `yaml:"dependencies,omitempty"`
+	// SeverityMapping renames severities in reports, e.g. critical to P1,
+	// and may give each a score and a badge weight. Threshold flags accept
+	// both the nox names and the labels.
+	SeverityMapping findings.SeverityMapping `yaml:"severity_mapping,omitempty"`
+	// SLA sets the maximum age of open findings per severity.
+	SLA SLASettings `yaml:"sla,omitempty"`
+	// History records a summary of each scan for nox trend.
+	History HistorySettings `yaml:"history,omitempty"`
+	// UpdateCheck set to false turns off the daily check for a newer nox
+	// release after scans.
+	UpdateCheck *bool `yaml:"update_check,omitempty"`
+	// Suppressions configures the handling of nox:ignore comments.
+	Suppressions SuppressionSettings `yaml:"suppressions,omitempty"`
+	// References adds a documentation URL, such as an internal runbook, to
+	// the references of every rule.
+	References ReferenceSettings `yaml:"references,omitempty"`
+	// Categories declares finding categories that are not built in, such
+	// as those of the findings of a plugin, in the form of the categories
+	// key of a rules file.
+	Categories []rules.CategoryDecl `yaml:"categories,omitempty"`
+}
+
+// HistorySettings controls the scan history that nox trend summarizes.
+type HistorySettings struct {
+	// Enabled appends a record of each full scan to the history file.
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Path is the history file (default .nox/history.jsonl).
+	Path string `yaml:"path,omitempty"`
 }
 
 // PolicySettings controls pass/fail thresholds and baseline behavior.
 type PolicySettings struct {
-	FailOn       string `yaml:"fail_on"`
-	WarnOn       string `yaml:"warn_on"`
-	BaselineMode string `yaml:"baseline_mode"`
-	BaselinePath string `yaml:"baseline_path"`
-	VEXPath      string `yaml:"vex_path"`
+	FailOn       string `yaml:"fail_on,omitempty"`
+	WarnOn       string `yaml:"warn_on,omitempty"`
+	BaselineMode string `yaml:"baseline_mode,omitempty"`
+	BaselinePath string `yaml:"baseline_path,omitempty"`
+	VEXPath      string `yaml:"vex_path,omitempty"`
+	// Scope is "changed" to let only findings in the files a change
+	// touches, as listed by nox scan --changed-files-from, fail the
+	// policy, or "all" (the default).
+	Scope string `yaml:"scope,omitempty"`
+	// Expression is a CEL expression that decides whether the scan passes;
+	// see policy.Expression. ExpressionFile reads it from a file relative
+	// to the scan root instead.
+	Expression     string `yaml:"expression,omitempty"`
+	ExpressionFile string `yaml:"expression_file,omitempty"`
+	// Categories sets the thresholds of the findings of a category, built
+	// in or declared, in place of FailOn and WarnOn.
+	Categories map[string]CategoryPolicy `yaml:"categories,omitempty"`
+}
+
+// CategoryPolicy holds the policy thresholds of one finding category.
+type CategoryPolicy struct {
+	FailOn string `yaml:"fail_on,omitempty"`
+	WarnOn string `yaml:"warn_on,omitempty"`
 }
 
 // ComplianceSettings controls compliance framework filtering.
 type ComplianceSettings struct {
-	Framework string `yaml:"framework"`
+	Framework string `yaml:"framework,omitempty"`
 }
 
 // ArtifactTypeExclusion defines exclusions by artifact type.
 type ArtifactTypeExclusion struct {
-	ArtifactTypes []string `yaml:"artifact_types"` // e.g., ["lockfile", "container"]
-	Paths         []string `yaml:"paths"`          // optional: limit to specific paths
+	ArtifactTypes []string `yaml:"artifact_types,omitempty"` // e.g., ["lockfile", "container"]
+	Paths         []string `yaml:"paths,omitempty"`          // optional: limit to specific paths
 }
 
 // AnalyzerRuleConfig defines rules that apply to specific analyzers and paths.
 type AnalyzerRuleConfig struct {
-	Analyzer string   `yaml:"analyzer"` // analyzer name (deps, secrets, iac, ai, data)
-	Rules    []string `yaml:"rules"`    // rule IDs or wildcards (e.g., ["VULN-*", "SEC-001"])
-	Paths    []string `yaml:"paths"`    // glob patterns to match
-	Action   string   `yaml:"action"`   // "disable" or "skip_analyzer"
+	Analyzer string   `yaml:"analyzer,omitempty"` // analyzer name (deps, secrets, iac, ai, data)
+	Rules    []string `yaml:"rules,omitempty"`    // rule IDs or wildcards (e.g., ["VULN-*", "SEC-001"])
+	Paths    []string `yaml:"paths,omitempty"`    // glob patterns to match
+	Action   string   `yaml:"action,omitempty"`   // "disable" or "skip_analyzer"
 }
 
 // ConditionalSeverity defines severity overrides based on path patterns.
 type ConditionalSeverity struct {
-	Rules    []string `yaml:"rules"`    // rule IDs or wildcards
-	Paths    []string `yaml:"paths"`    // glob patterns
-	Severity string   `yaml:"severity"` // critical, high, medium, low, info
+	Rules    []string `yaml:"rules,omitempty"`    // rule IDs or wildcards
+	Paths    []string `yaml:"paths,omitempty"`    // glob patterns
+	Severity string   `yaml:"severity,omitempty"` // critical, high, medium, low, info
 }
 
 // ScanSettings controls which files are scanned and how rules behave.
 type ScanSettings struct {
-	Exclude              []string                `yaml:"exclude"`
-	ExcludeArtifactTypes []ArtifactTypeExclusion `yaml:"exclude_artifact_types"`
-	Include              []string                `yaml:"include"`
-	RulesDir             string                  `yaml:"rules_dir"`
-	Rules                RulesConfig             `yaml:"rules"`
-	AnalyzerRules        []AnalyzerRuleConfig    `yaml:"analyzer_rules"`
-	ConditionalSeverity  []ConditionalSeverity   `yaml:"conditional_severity"`
-	OSV                  OSVConfig               `yaml:"osv"`
-	Entropy              EntropyConfig           `yaml:"entropy"`
+	Exclude              []string                `yaml:"exclude,omitempty"`
+	ExcludeArtifactTypes []ArtifactTypeExclusion `yaml:"exclude_artifact_types,omitempty"`
+	Include              []string                `yaml:"include,omitempty"`
+	RulesDir             string                  `yaml:"rules_dir,omitempty"`
+	RulePacks            []string                `yaml:"rule_packs,omitempty"` // installed rule packs as name[@constraint]
+	Rules                RulesConfig             `yaml:"rules,omitempty"`
+	AnalyzerRules        []AnalyzerRuleConfig    `yaml:"analyzer_rules,omitempty"`
+	ConditionalSeverity  []ConditionalSeverity   `yaml:"conditional_severity,omitempty"`
+	OSV                  OSVConfig               `yaml:"osv,omitempty"`
+	Entropy              EntropyConfig           `yaml:"entropy,omitempty"`
+	Secrets              SecretsConfig           `yaml:"secrets,omitempty"`
+	Categories           CategoriesConfig        `yaml:"categories,omitempty"`
+	Analyzers            AnalyzersConfig         `yaml:"analyzers,omitempty"`
+	FileTimeout          string                  `yaml:"file_timeout,omitempty"` // per-file matching budget (e.g., "10s")
+	// MaxFileSize is the size, such as "10MB", above which the content
+	// analyzers scan only the start of a file; lockfiles and dependency
+	// manifests are read whole. Empty keeps the default of 10MB and "0"
+	// scans every file whole.
+	MaxFileSize string `yaml:"max_file_size,omitempty"`
+	// MemoryBudget is the memory, such as "2GB", above which the workers
+	// that read installed npm packages run one at a time. The content
+	// analyzers read one file at a time and are bounded by MaxFileSize
+	// instead. Empty keeps the default of 2GB and "0" never throttles.
+	MemoryBudget string `yaml:"memory_budget,omitempty"`
+	// Submodules scans the content of checked-out git submodules and lists
+	// them in SBOMs. nil keeps the default of true.
+	Submodules *bool `yaml:"submodules,omitempty"`
 }
 
 // EntropyConfig allows overriding entropy-based secret detection thresholds
 // from .nox.yaml. Zero values mean "use the rule defaults".
 type EntropyConfig struct {
 	// Threshold overrides the default entropy threshold for SEC-161.
-	Threshold float64 `yaml:"threshold"`
+	Threshold float64 `yaml:"threshold,omitempty"`
 	// HexThreshold overrides the entropy threshold for SEC-163 (hex detection).
-	HexThreshold float64 `yaml:"hex_threshold"`
+	HexThreshold float64 `yaml:"hex_threshold,omitempty"`
 	// Base64Threshold overrides the entropy threshold for SEC-162 (base64 detection).
-	Base64Threshold float64 `yaml:"base64_threshold"`
+	Base64Threshold float64 `yaml:"base64_threshold,omitempty"`
 	// RequireContext when true forces SEC-162/SEC-163 to only fire when a
 	// secret-suggestive keyword appears on the same line. Default is true
 	// (set in rule metadata); setting this to false disables that check.
-	RequireContext *bool `yaml:"require_context"`
+	RequireContext *bool `yaml:"require_context,omitempty"`
+}
+
+// SecretsConfig extends the file name patterns used by secret detection.
+// Patterns are matched case-insensitively against file base names and are
+// added to the built-in lists. It also sets how identifiers and secrets
+// are paired and whether findings are attributed with git blame.
+type SecretsConfig struct {
+	// SensitiveFiles marks matching files as likely to hold secrets so that
+	// every secret rule applies to them whatever their extension.
+	SensitiveFiles []string `yaml:"sensitive_files,omitempty"`
+	// KeyFiles flags matching files as committed private keys (SEC-951).
+	KeyFiles []string `yaml:"key_files,omitempty"`
+	// PairWindow is the number of lines within which a client ID or access
+	// key ID and its secret are reported as one critical finding. nil keeps
+	// the default of 5; 0 disables the correlation.
+	PairWindow *int `yaml:"pair_window,omitempty"`
+	// Blame attributes secrets findings to the author and commit that last
+	// changed their lines, like nox scan --blame.
+	Blame bool `yaml:"blame,omitempty"`
+	// PlaceholderAction is what happens to matches whose value is a
+	// placeholder, such as your-api-key-here, ${TOKEN} or a key ending in
+	// EXAMPLE: "keep" (the default) reports them unchanged, "downgrade"
+	// reports them at info severity and "suppress" drops them.
+	PlaceholderAction string `yaml:"placeholder_action,omitempty"`
+	// TestDirs are the directory names whose secrets findings get one
+	// level lower confidence. nil keeps secrets.DefaultTestDirs; an empty
+	// list turns the heuristic off.
+	TestDirs []string `yaml:"test_dirs,omitempty"`
+	// GitMetadata scans .git/config, .git/packed-refs and the git hooks of
+	// the scan root for secrets, although discovery skips .git. Default is
+	// true; set to false to leave them out.
+	GitMetadata *bool `yaml:"git_metadata,omitempty"`
+}
+
+// CategoriesConfig limits a scan to rule categories (secrets, data, ai,
+// iac, deps, container, audit, custom). Only keeps findings from the listed
+// categories and Skip drops them; both may be combined.
+type CategoriesConfig struct {
+	Only []string `yaml:"only,omitempty"`
+	Skip []string `yaml:"skip,omitempty"`
 }
 
 // OSVConfig controls OSV.dev vulnerability enrichment for dependency scanning.
 type OSVConfig struct {
-	Disabled bool `yaml:"disabled"`
+	Disabled bool `yaml:"disabled,omitempty"`
 }
 
-// RulesConfig allows disabling rules or overriding their severity.
+// RulesConfig allows disabling rules or overriding their severity. In a
+// nested .nox.yaml, Enable re-enables rules disabled by a parent config and
+// Inherit set to false discards the parent's rule settings.
 type RulesConfig struct {
-	Disable          []string          `yaml:"disable"`
-	SeverityOverride map[string]string `yaml:"severity_override"`
+	Disable          []string          `yaml:"disable,omitempty"`
+	Enable           []string          `yaml:"enable,omitempty"`
+	SeverityOverride map[string]string `yaml:"severity_override,omitempty"`
+	Inherit          *bool             `yaml:"inherit,omitempty"`
+	// References maps rule IDs to a reference URL template used in place
+	// of references.url_template. It is read from the root config only.
+	References map[string]string `yaml:"references,omitempty"`
 }
 
 // OutputSettings controls default output format and directory.
 type OutputSettings struct {
-	Format    string `yaml:"format"`
-	Directory string `yaml:"directory"`
+	Format    string `yaml:"format,omitempty"`
+	Directory string `yaml:"directory,omitempty"`
+	// Timestamped writes each scan's reports to a new <timestamp>/
+	// directory under Directory (default nox-reports) with a "latest"
+	// symlink to the newest run.
+	Timestamped bool `yaml:"timestamped,omitempty"`
+	// Keep is the number of timestamped runs to keep (default 10).
+	Keep int `yaml:"keep,omitempty"`
+	// EncryptRecipients are age recipients ("age1…" or "ssh-ed25519
+	// AAAA…") that reports are encrypted to. Encrypted reports are written
+	// with a .age suffix, e.g. findings.json.age.
+	EncryptRecipients []string `yaml:"encrypt_recipients,omitempty"`
+	// Files names the report file of a format ("json", "sarif", "cdx",
+	// ...) in place of its default name, relative to Directory. Names may
+	// use the variables {target}, {format}, {date}, {commit} and {ext}.
+	Files map[string]string `yaml:"files,omitempty"`
+}
+
+// SBOMSettings controls what the SBOM reports list.
+type SBOMSettings struct {
+	// IncludeCI lists Dockerfile base images and GitHub Actions as SBOM
+	// components. Default is true; set to false to list only packages from
+	// lockfiles and manifests.
+	IncludeCI *bool `yaml:"include_ci,omitempty"`
+}
+
+// SARIFSettings controls what the SARIF report lists.
+type SARIFSettings struct {
+	// IncludeSuppressed lists suppressed, baselined and VEX-excluded
+	// findings as results with a suppressions array, so code scanning
+	// keeps their alerts closed instead of closing and reopening them when
+	// a baseline changes. Default is true; set to false to leave them out.
+	IncludeSuppressed *bool `yaml:"include_suppressed,omitempty"`
+}
+
+// DependencySettings configures the dependency confusion checks.
+type DependencySettings struct {
+	// InternalPrefixes are the npm and PyPI package name prefixes of
+	// internal packages (e.g., "@acme/", "acme-"). Internal packages
+	// resolved from the public registry are reported as SUPPLY-001, and
+	// public packages with few releases that use an internal name as
+	// SUPPLY-002.
+	InternalPrefixes []string `yaml:"internal_prefixes,omitempty"`
+	// InstallScriptBase is a git revision, such as origin/main, whose
+	// package-lock.json files are compared with the scanned ones to report
+	// npm packages with install scripts that were added or upgraded since
+	// (DEP-005). Empty, the default, disables the comparison.
+	InstallScriptBase string `yaml:"install_script_base,omitempty"`
 }
 
 // ExplainSettings controls defaults for the explain command.
 type ExplainSettings struct {
-	APIKeyEnv string `yaml:"api_key_env"` // env var name to read API key from (default: OPENAI_API_KEY)
-	Model     string `yaml:"model"`       // LLM model name (default: gpt-4o)
-	BaseURL   string `yaml:"base_url"`    // custom OpenAI-compatible API base URL
-	Timeout   string `yaml:"timeout"`     // per-request timeout (e.g., "2m", "30s")
-	BatchSize int    `yaml:"batch_size"`  // findings per LLM request (default: 10)
-	Output    string `yaml:"output"`      // output file path (default: explanations.json)
-	Enrich    string `yaml:"enrich"`      // comma-separated enrichment tool names
-	PluginDir string `yaml:"plugin_dir"`  // directory containing plugin binaries
+	APIKeyEnv string `yaml:"api_key_env,omitempty"` // env var name to read API key from (default: OPENAI_API_KEY)
+	Model     string `yaml:"model,omitempty"`       // LLM model name (default: gpt-4o)
+	BaseURL   string `yaml:"base_url,omitempty"`    // custom OpenAI-compatible API base URL
+	Timeout   string `yaml:"timeout,omitempty"`     // per-request timeout (e.g., "2m", "30s")
+	BatchSize int    `yaml:"batch_size,omitempty"`  // findings per LLM request (default: 10)
+	Output    string `yaml:"output,omitempty"`      // output file path (default: explanations.json)
+	Enrich    string `yaml:"enrich,omitempty"`      // comma-separated enrichment tool names
+	PluginDir string `yaml:"plugin_dir,omitempty"`  // directory containing plugin binaries
 }
 
 // LoadScanConfig reads .nox.yaml from root and returns the parsed config.
@@ -136,6 +332,137 @@ func LoadScanConfig(root string) (*ScanConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parsing %s: %w", path, err)
 	}
+	if err := cfg.SeverityMapping.Validate(); err != nil {
+		return nil, fmt.Errorf("parsing %s: severity_mapping: %w", path, err)
+	}
+	if err := validateReferences(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	renameRemovedRules(&cfg, path)
+
+	return &cfg, nil
+}
+
+// renameRemovedRules rewrites the IDs of removed built-in rules in the
+// scan.rules settings of cfg, read from path, to the IDs of the rules that
+// replaced them, with a warning for each. A setting already given for the
+// replacement wins over one for a removed rule.
+func renameRemovedRules(cfg *ScanConfig, path string) {
+	r := &cfg.Scan.Rules
+	warn := func(setting, id, to string) {
+		slog.Warn("config names a removed rule; applying the setting to the rule that replaced it",
+			"path", path, "setting", "scan.rules."+setting, "rule_id", id, "replaced_by", to)
+	}
+	renameList := func(setting string, ids []string) []string {
+		out := ids[:0]
+		for _, id := range ids {
+			if to, ok := rules.RenamedTo(id); ok {
+				warn(setting, id, to)
+				id = to
+			}
+			if !slices.Contains(out, id) {
+				out = append(out, id)
+			}
+		}
+		return out
+	}
+	renameMap := func(setting string, m map[string]string) {
+		for _, id := range slices.Sorted(maps.Keys(m)) {
+			to, ok := rules.RenamedTo(id)
+			if !ok {
+				continue
+			}
+			warn(setting, id, to)
+			if _, set := m[to]; !set {
+				m[to] = m[id]
+			}
+			delete(m, id)
+		}
+	}
+	r.Disable = renameList("disable", r.Disable)
+	r.Enable = renameList("enable", r.Enable)
+	renameMap("severity_override", r.SeverityOverride)
+	renameMap("references", r.References)
+}
+
+// LoadScanConfigFile reads the config file at path, given with nox --config
+// in place of the .nox.yaml in the scan root. Unlike LoadScanConfig, a
+// missing file is an error. Relative paths in the config are resolved
+// against the directory of the file rather than the scan root.
+func LoadScanConfigFile(path string) (*ScanConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
 
+	var cfg ScanConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if err := cfg.SeverityMapping.Validate(); err != nil {
+		return nil, fmt.Errorf("parsing config %s: severity_mapping: %w", path, err)
+	}
+	if err := validateReferences(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	renameRemovedRules(&cfg, path)
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("resolving config %s: %w", path, err)
+	}
+	for _, p := range []*string{
+		&cfg.Scan.RulesDir,
+		&cfg.Output.Directory,
+		&cfg.Policy.BaselinePath,
+		&cfg.Policy.VEXPath,
+		&cfg.Policy.ExpressionFile,
+		&cfg.SLA.HistoryPath,
+		&cfg.History.Path,
+		&cfg.Explain.Output,
+		&cfg.Explain.PluginDir,
+	} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
 	return &cfg, nil
 }
+
+// LoadRootConfig returns the config file at configPath if one is given and
+// the .nox.yaml in root otherwise.
+func LoadRootConfig(root, configPath string) (*ScanConfig, error) {
+	if configPath != "" {
+		return LoadScanConfigFile(configPath)
+	}
+	return LoadScanConfig(root)
+}
+
+// LoadPolicyExpression compiles the CEL policy expression configured in p,
+// reading policy.expression_file relative to root. It returns nil if no
+// expression is configured.
+func LoadPolicyExpression(root string, p PolicySettings) (*policy.Expression, error) {
+	source := p.Expression
+	if p.ExpressionFile != "" {
+		if source != "" {
+			return nil, errors.New("policy.expression and policy.expression_file are mutually exclusive")
+		}
+		path := p.ExpressionFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading policy.expression_file: %w", err)
+		}
+		source = string(data)
+	}
+	if source == "" {
+		return nil, nil
+	}
+	expr, err := policy.CompileExpression(source)
+	if err != nil {
+		return nil, fmt.Errorf("policy.expression: %w", err)
+	}
+	return expr, nil
+}
@@ -1,8 +1,10 @@
 package core
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -129,6 +131,35 @@ func TestLoadScanConfig_Partial(t *testing.T) {
 	}
 }
 
+func TestLoadScanConfig_RemovedRuleIDs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := `scan:
+  rules:
+    disable: ["SEC-438", "SEC-548", "SEC-002"]
+    severity_override:
+      SEC-371: low
+      SEC-084: info
+      SEC-262: medium
+`
+	if err := os.WriteFile(filepath.Join(dir, ".nox.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadScanConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Scan.Rules.Disable; !slices.Equal(got, []string{"SEC-030", "SEC-002"}) {
+		t.Errorf("disable = %v, want [SEC-030 SEC-002]", got)
+	}
+	want := map[string]string{"SEC-084": "info", "SEC-070": "medium"}
+	if got := cfg.Scan.Rules.SeverityOverride; !maps.Equal(got, want) {
+		t.Errorf("severity_override = %v, want %v", got, want)
+	}
+}
+
 func TestLoadScanConfig_ExplainSettings(t *testing.T) {
 	t.Parallel()
 
@@ -396,3 +427,88 @@ func TestLoadScanConfig_ConditionalSeverity(t *testing.T) {
 		t.Errorf("rule[0] = %q, want %q", cfg.Scan.ConditionalSeverity[1].Rules[0], "VULN-*")
 	}
 }
+
+func TestLoadScanConfigFile_ResolvesRelativePaths(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "configs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := `scan:
+  rules_dir: rules
+output:
+  directory: ../reports
+policy:
+  baseline_path: baselines/ci.json
+  vex_path: /etc/nox/vex.json
+  expression_file: policy.cel
+`
+	path := filepath.Join(dir, "ci.nox.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadScanConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadScanConfigFile() error: %v", err)
+	}
+	for name, tc := range map[string]struct{ got, want string }{
+		"rules_dir":       {cfg.Scan.RulesDir, filepath.Join(dir, "rules")},
+		"directory":       {cfg.Output.Directory, filepath.Join(filepath.Dir(dir), "reports")},
+		"baseline_path":   {cfg.Policy.BaselinePath, filepath.Join(dir, "baselines", "ci.json")},
+		"vex_path":        {cfg.Policy.VEXPath, "/etc/nox/vex.json"},
+		"expression_file": {cfg.Policy.ExpressionFile, filepath.Join(dir, "policy.cel")},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s = %q, want %q", name, tc.got, tc.want)
+		}
+	}
+	if cfg.Explain.Output != "" {
+		t.Errorf("unset paths should stay empty, got explain.output %q", cfg.Explain.Output)
+	}
+}
+
+func TestLoadScanConfigFile_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if _, err := LoadScanConfigFile(filepath.Join(dir, "missing.nox.yaml")); err == nil {
+		t.Error("expected error for a missing config file")
+	}
+	path := filepath.Join(dir, "broken.nox.yaml")
+	if err := os.WriteFile(path, []byte("scan: [[[invalid yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadScanConfigFile(path); err == nil {
+		t.Error("expected error for an invalid config file")
+	}
+}
+
+func TestLoadScanConfig_SeverityMapping(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := "severity_mapping:\n  critical: {label: P1, score: 10, weight: 20}\n  high: {label: P2}\n"
+	if err := os.WriteFile(filepath.Join(dir, ".nox.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadScanConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	critical := cfg.SeverityMapping["critical"]
+	if critical.Label != "P1" || critical.Score == nil || *critical.Score != 10 || critical.Weight == nil || *critical.Weight != 20 {
+		t.Errorf("critical = %+v", critical)
+	}
+	if cfg.SeverityMapping.Resolve("p2") != "high" {
+		t.Errorf("Resolve(p2) = %q", cfg.SeverityMapping.Resolve("p2"))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".nox.yaml"), []byte("severity_mapping:\n  urgent: {label: P0}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadScanConfig(dir); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
@@ -3,10 +3,13 @@ package detail
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/nox-hq/nox/core/codeowners"
 	"github.com/nox-hq/nox/core/findings"
 	"github.com/nox-hq/nox/core/report"
 )
@@ -19,17 +22,30 @@ type Store struct {
 
 // Filter defines criteria for filtering findings.
 type Filter struct {
-	Severities  []findings.Severity
+	Severities []findings.Severity
+	// RulePattern matches rule IDs; several patterns may be given
+	// comma-separated (e.g., "SEC-*,AI-001").
 	RulePattern string
 	FilePattern string
+	// PathGlob matches file paths with "*" within a directory and "**"
+	// across directories. A glob without "/" matches the file name.
+	PathGlob string
+	// MinConfidence drops findings below this confidence. Findings without
+	// a confidence count as low.
+	MinConfidence findings.Confidence
+	// Owners keeps findings owned by any of these CODEOWNERS owners, such
+	// as "@org/payments", compared case-insensitively. "unowned" matches
+	// findings no CODEOWNERS rule assigns.
+	Owners []string
 	// IncludeSuppressed when true includes findings with StatusSuppressed, StatusBaselined,
 	// StatusVEXNotAffected, and StatusVEXFixed. Defaults to false.
 	IncludeSuppressed bool
 }
 
-// LoadFromFile loads findings from a findings.json file.
+// LoadFromFile loads findings from a findings.json file, decrypting it if
+// it is an encrypted report (see report.ReadFile).
 func LoadFromFile(path string) (*Store, error) {
-	data, err := os.ReadFile(path)
+	data, err := report.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading findings file: %w", err)
 	}
@@ -64,12 +80,21 @@ func (s *Store) Filter(f Filter) []findings.Finding {
 		if !matchesSeverity(finding.Severity, f.Severities) {
 			continue
 		}
-		if !matchesPattern(finding.RuleID, f.RulePattern) {
+		if !matchesRule(finding.RuleID, f.RulePattern) {
 			continue
 		}
 		if !matchesPattern(finding.Location.FilePath, f.FilePattern) {
 			continue
 		}
+		if f.PathGlob != "" && !MatchGlob(f.PathGlob, finding.Location.FilePath) {
+			continue
+		}
+		if f.MinConfidence != "" && max(confidenceRank[finding.Confidence], 1) < confidenceRank[f.MinConfidence] {
+			continue
+		}
+		if len(f.Owners) > 0 && !slices.ContainsFunc(f.Owners, func(o string) bool { return codeowners.HasOwner(&finding, o) }) {
+			continue
+		}
 		if !f.IncludeSuppressed && !finding.Status.IsActive() {
 			continue
 		}
@@ -116,6 +141,19 @@ func matchesSeverity(sev findings.Severity, allowed []findings.Severity) bool {
 	return false
 }
 
+// matchesRule matches a rule ID against a comma-separated list of patterns.
+func matchesRule(ruleID, patterns string) bool {
+	if patterns == "" {
+		return true
+	}
+	for _, p := range strings.Split(patterns, ",") {
+		if p = strings.TrimSpace(p); p != "" && matchesPattern(ruleID, p) {
+			return true
+		}
+	}
+	return false
+}
+
 // matchesPattern matches a value against a glob-like pattern.
 // Supports trailing "*" wildcard and prefix matching.
 func matchesPattern(value, pattern string) bool {
@@ -132,3 +170,80 @@ func matchesPattern(value, pattern string) bool {
 	}
 	return strings.Contains(value, pattern)
 }
+
+// MatchGlob reports whether the slash-separated path matches pattern. "*"
+// and the other path.Match metacharacters work within one path segment and a
+// "**" segment matches any number of segments. A pattern without "/" is
+// matched against the last path segment only.
+func MatchGlob(pattern, name string) bool {
+	name = filepath.ToSlash(name)
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// confidenceRank orders confidence levels from least to most certain.
+var confidenceRank = map[findings.Confidence]int{
+	findings.ConfidenceLow:    1,
+	findings.ConfidenceMedium: 2,
+	findings.ConfidenceHigh:   3,
+}
+
+// ValidConfidence reports whether c is a known confidence level.
+func ValidConfidence(c findings.Confidence) bool {
+	return confidenceRank[c] > 0
+}
+
+// SortKeys lists the keys accepted by Sort.
+var SortKeys = []string{"severity", "confidence", "rule", "path"}
+
+// Sort orders ff in place by key: "severity" and "confidence" put the most
+// severe or most certain findings first, "rule" sorts by rule ID and "path"
+// by file and line. Ties keep their order. An empty key leaves ff as is.
+func Sort(ff []findings.Finding, key string) error {
+	var less func(a, b *findings.Finding) bool
+	switch key {
+	case "":
+		return nil
+	case "severity":
+		less = func(a, b *findings.Finding) bool { return findings.CompareSeverity(a.Severity, b.Severity) < 0 }
+	case "confidence":
+		less = func(a, b *findings.Finding) bool { return confidenceRank[a.Confidence] > confidenceRank[b.Confidence] }
+	case "rule":
+		less = func(a, b *findings.Finding) bool { return a.RuleID < b.RuleID }
+	case "path":
+		less = func(a, b *findings.Finding) bool {
+			if a.Location.FilePath != b.Location.FilePath {
+				return a.Location.FilePath < b.Location.FilePath
+			}
+			return a.Location.StartLine < b.Location.StartLine
+		}
+	default:
+		return fmt.Errorf("unknown sort key %q (want one of: %s)", key, strings.Join(SortKeys, ", "))
+	}
+	sort.SliceStable(ff, func(i, j int) bool { return less(&ff[i], &ff[j]) })
+	return nil
+}
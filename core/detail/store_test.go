@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/nox-hq/nox/core/findings"
@@ -95,3 +96,110 @@ func TestMatchesPattern_InvalidGlob(t *testing.T) {
 		t.Fatal("expected invalid glob to return false")
 	}
 }
+
+func TestFilter_ConfidenceAndPathGlob(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{ID: "F-1", RuleID: "SEC-001", Confidence: findings.ConfidenceHigh, Location: findings.Location{FilePath: "src/api/handler.go"}})
+	fs.Add(findings.Finding{ID: "F-2", RuleID: "SEC-002", Confidence: findings.ConfidenceMedium, Location: findings.Location{FilePath: "src/main.go"}})
+	fs.Add(findings.Finding{ID: "F-3", RuleID: "AI-001", Confidence: findings.ConfidenceLow, Location: findings.Location{FilePath: "docs/guide.md"}})
+	fs.Add(findings.Finding{ID: "F-4", RuleID: "IAC-001", Location: findings.Location{FilePath: "deploy/main.tf"}})
+	store := LoadFromSet(fs, "/repo")
+
+	ids := func(ff []findings.Finding) string {
+		var out []string
+		for _, f := range ff {
+			out = append(out, f.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		filter Filter
+		want   string
+	}{
+		{Filter{MinConfidence: findings.ConfidenceMedium}, "F-1,F-2"},
+		{Filter{MinConfidence: findings.ConfidenceLow}, "F-1,F-2,F-3,F-4"},
+		{Filter{PathGlob: "src/**/*.go"}, "F-1,F-2"},
+		{Filter{PathGlob: "src/*.go"}, "F-2"},
+		{Filter{PathGlob: "*.md"}, "F-3"},
+		{Filter{RulePattern: "AI-*, IAC-001"}, "F-3,F-4"},
+		{Filter{RulePattern: "SEC-*", PathGlob: "**/api/**", MinConfidence: findings.ConfidenceHigh}, "F-1"},
+	}
+	for _, tt := range tests {
+		if got := ids(store.Filter(tt.filter)); got != tt.want {
+			t.Errorf("Filter(%+v) = %s, want %s", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestFilter_Owners(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{ID: "F-1", RuleID: "SEC-001", Metadata: map[string]string{findings.MetaOwners: "@org/payments @alice"}})
+	fs.Add(findings.Finding{ID: "F-2", RuleID: "SEC-002", Metadata: map[string]string{findings.MetaOwners: "unowned"}})
+	fs.Add(findings.Finding{ID: "F-3", RuleID: "SEC-003"})
+	store := LoadFromSet(fs, "/repo")
+
+	for _, tt := range []struct {
+		owners []string
+		want   int
+	}{
+		{[]string{"@Org/Payments"}, 1},
+		{[]string{"@alice", "unowned"}, 2},
+		{[]string{"@org/web"}, 0},
+		{nil, 3},
+	} {
+		if got := len(store.Filter(Filter{Owners: tt.owners})); got != tt.want {
+			t.Errorf("Filter(Owners: %v) = %d findings, want %d", tt.owners, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"src/**", "src/a/b.go", true},
+		{"src/**", "lib/a.go", false},
+		{"a/*/c.go", "a/b/c.go", true},
+		{"a/*/c.go", "a/b/x/c.go", false},
+		{"*.env", "config/prod.env", true},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	ff := []findings.Finding{
+		{ID: "F-1", RuleID: "SEC-002", Severity: findings.SeverityLow, Confidence: findings.ConfidenceHigh, Location: findings.Location{FilePath: "b.go", StartLine: 3}},
+		{ID: "F-2", RuleID: "AI-001", Severity: findings.SeverityCritical, Confidence: findings.ConfidenceLow, Location: findings.Location{FilePath: "a.go", StartLine: 9}},
+		{ID: "F-3", RuleID: "SEC-001", Severity: findings.SeverityHigh, Confidence: findings.ConfidenceMedium, Location: findings.Location{FilePath: "a.go", StartLine: 2}},
+	}
+	tests := map[string]string{
+		"severity":   "F-2,F-3,F-1",
+		"confidence": "F-1,F-3,F-2",
+		"rule":       "F-2,F-3,F-1",
+		"path":       "F-3,F-2,F-1",
+	}
+	for key, want := range tests {
+		sorted := append([]findings.Finding(nil), ff...)
+		if err := Sort(sorted, key); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range sorted {
+			got = append(got, f.ID)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("Sort(%s) = %v, want %s", key, got, want)
+		}
+	}
+	if err := Sort(ff, "age"); err == nil {
+		t.Error("expected an error for an unknown sort key")
+	}
+}
@@ -5,16 +5,27 @@ package diff
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/codeowners"
+	"github.com/nox-hq/nox/core/findings"
 	"github.com/nox-hq/nox/core/git"
 )
 
 // Options configures a diff scan.
 type Options struct {
-	Base      string // base git ref (default: "main")
-	Head      string // head git ref (default: "HEAD")
-	RulesPath string // optional custom rules path
+	Base       string // base git ref (default: "main")
+	Head       string // head git ref (default: "HEAD")
+	RulesPath  string // optional custom rules path
+	ConfigPath string // optional config file replacing the target's .nox.yaml
+	// NoRenames turns off git's rename detection, so every finding in a
+	// renamed file is reported as new.
+	NoRenames bool
+	// Offline skips every network lookup, as nox.ScanOptions.Offline does.
+	Offline bool
 }
 
 // Finding is a finding scoped to a changed file.
@@ -23,19 +34,33 @@ type Finding struct {
 	Severity string `json:"severity"`
 	File     string `json:"file"`
 	Line     int    `json:"line"`
+	EndLine  int    `json:"end_line,omitempty"`
 	Message  string `json:"message"`
+	// Owners are the CODEOWNERS owners of the file, or ["unowned"] when
+	// the repository has a CODEOWNERS file that assigns it to no one.
+	Owners []string `json:"owners,omitempty"`
 }
 
 // Result holds the output of a diff scan.
 type Result struct {
-	Findings     []Finding `json:"findings"`
+	// Findings are the findings in changed files that were not in the
+	// base version of the file.
+	Findings []Finding `json:"findings"`
+	// Unchanged are the findings in changed files that were already in
+	// the base version, possibly under an old name or on another line.
+	Unchanged    []Finding `json:"unchanged,omitempty"`
 	ChangedFiles []string  `json:"changed_files"`
-	Base         string    `json:"base"`
-	Head         string    `json:"head"`
+	// Renames maps the new path of each renamed file to its old path.
+	Renames map[string]string `json:"renames,omitempty"`
+	Base    string            `json:"base"`
+	Head    string            `json:"head"`
 }
 
 // Run performs a diff scan on the target directory, scanning only files
-// changed between the Base and Head refs.
+// changed between the Base and Head refs. A finding in a changed file is
+// unchanged when the version of the file at the merge base of Base and
+// Head, under its old name if it was renamed, has a finding of the same
+// rule on lines with the same content, ignoring whitespace.
 func Run(target string, opts Options) (*Result, error) {
 	if opts.Base == "" {
 		opts.Base = "main"
@@ -53,46 +78,172 @@ func Run(target string, opts Options) (*Result, error) {
 		return nil, fmt.Errorf("resolving repo root: %w", err)
 	}
 
-	changed, err := git.ChangedFiles(repoRoot, opts.Base, opts.Head)
+	changes, err := git.DiffFiles(repoRoot, opts.Base, opts.Head, !opts.NoRenames)
 	if err != nil {
 		return nil, fmt.Errorf("getting changed files: %w", err)
 	}
 
 	result := &Result{
-		ChangedFiles: changed,
-		Base:         opts.Base,
-		Head:         opts.Head,
+		Base: opts.Base,
+		Head: opts.Head,
+	}
+	for _, c := range changes {
+		result.ChangedFiles = append(result.ChangedFiles, c.Path)
+		if c.Status == "R" {
+			if result.Renames == nil {
+				result.Renames = make(map[string]string)
+			}
+			result.Renames[c.Path] = c.OldPath
+		}
 	}
 
-	if len(changed) == 0 {
+	if len(changes) == 0 {
 		return result, nil
 	}
 
-	changedSet := make(map[string]struct{}, len(changed))
-	for _, f := range changed {
-		changedSet[f] = struct{}{}
+	changedSet := make(map[string]struct{}, len(changes))
+	for _, c := range changes {
+		changedSet[c.Path] = struct{}{}
 	}
 
 	scanOpts := nox.ScanOptions{
 		CustomRulesPath: opts.RulesPath,
+		ConfigPath:      opts.ConfigPath,
+		Offline:         opts.Offline,
 	}
 	scanResult, err := nox.RunScanWithOptions(target, scanOpts)
 	if err != nil {
 		return nil, fmt.Errorf("scan failed: %w", err)
 	}
 
-	active := scanResult.Findings.ActiveFindings()
-	for i := range active {
-		if _, ok := changedSet[active[i].Location.FilePath]; ok {
-			result.Findings = append(result.Findings, Finding{
-				RuleID:   active[i].RuleID,
-				Severity: string(active[i].Severity),
-				File:     active[i].Location.FilePath,
-				Line:     active[i].Location.StartLine,
-				Message:  active[i].Message,
-			})
+	var changed []findings.Finding
+	for _, f := range scanResult.Findings.ActiveFindings() {
+		if _, ok := changedSet[f.Location.FilePath]; ok {
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) == 0 {
+		return result, nil
+	}
+
+	existing, err := baseFindings(repoRoot, target, changes, opts, scanOpts)
+	if err != nil {
+		return nil, err
+	}
+	headLines := newLineReader(target)
+	for _, f := range changed {
+		df := Finding{
+			RuleID:   f.RuleID,
+			Severity: string(f.Severity),
+			File:     f.Location.FilePath,
+			Line:     f.Location.StartLine,
+			EndLine:  f.Location.EndLine,
+			Message:  f.Message,
+			Owners:   codeowners.FindingOwners(&f),
+		}
+		if k := matchKey(f.RuleID, f.Location.FilePath, headLines.text(f.Location)); existing[k] > 0 {
+			existing[k]--
+			result.Unchanged = append(result.Unchanged, df)
+		} else {
+			result.Findings = append(result.Findings, df)
 		}
 	}
 
 	return result, nil
 }
+
+// baseFindings scans the versions at the merge base of the files that
+// changes modified or renamed, and returns their active findings counted
+// by match key, under the paths the files have at the head ref.
+func baseFindings(repoRoot, target string, changes []git.FileChange, opts Options, scanOpts nox.ScanOptions) (map[string]int, error) {
+	mergeBase, err := git.MergeBase(repoRoot, opts.Base, opts.Head)
+	if err != nil {
+		return nil, fmt.Errorf("resolving merge base: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "nox-diff-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Scan the base versions with the same configuration as the head.
+	if cfgData, err := os.ReadFile(filepath.Join(target, nox.ConfigFileName)); err == nil {
+		if err := os.WriteFile(filepath.Join(tmpDir, nox.ConfigFileName), cfgData, 0o644); err != nil {
+			return nil, fmt.Errorf("writing config: %w", err)
+		}
+	}
+
+	newPath := make(map[string]string)
+	for _, c := range changes {
+		old := c.Path
+		switch c.Status {
+		case "R":
+			old = c.OldPath
+		case "M", "T":
+		default:
+			continue
+		}
+		content, err := git.FileAt(repoRoot, mergeBase, old)
+		if err != nil {
+			return nil, fmt.Errorf("reading base version: %w", err)
+		}
+		dest := filepath.Join(tmpDir, filepath.FromSlash(old))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return nil, fmt.Errorf("creating dir for %s: %w", old, err)
+		}
+		if err := os.WriteFile(dest, content, 0o644); err != nil {
+			return nil, fmt.Errorf("writing base version of %s: %w", old, err)
+		}
+		newPath[old] = c.Path
+	}
+
+	existing := make(map[string]int)
+	if len(newPath) == 0 {
+		return existing, nil
+	}
+	scanResult, err := nox.RunScanWithOptions(tmpDir, scanOpts)
+	if err != nil {
+		return nil, fmt.Errorf("scanning base versions: %w", err)
+	}
+	baseLines := newLineReader(tmpDir)
+	for _, f := range scanResult.Findings.ActiveFindings() {
+		if p, ok := newPath[f.Location.FilePath]; ok {
+			existing[matchKey(f.RuleID, p, baseLines.text(f.Location))]++
+		}
+	}
+	return existing, nil
+}
+
+// matchKey identifies a finding by rule, path and the content of its lines,
+// so that it matches across line moves.
+func matchKey(ruleID, path, content string) string {
+	return ruleID + "\x00" + path + "\x00" + content
+}
+
+// lineReader returns the lines of files under a root, reading each file
+// once.
+type lineReader struct {
+	root  string
+	files map[string][]string
+}
+
+func newLineReader(root string) *lineReader {
+	return &lineReader{root: root, files: make(map[string][]string)}
+}
+
+// text returns the lines of loc with runs of whitespace collapsed to a
+// single space, or "" for a finding on no line or in an unreadable file.
+func (r *lineReader) text(loc findings.Location) string {
+	lines, ok := r.files[loc.FilePath]
+	if !ok {
+		data, _ := os.ReadFile(filepath.Join(r.root, filepath.FromSlash(loc.FilePath)))
+		lines = strings.Split(string(data), "\n")
+		r.files[loc.FilePath] = lines
+	}
+	end := max(loc.EndLine, loc.StartLine)
+	if loc.StartLine < 1 || end > len(lines) {
+		return ""
+	}
+	return strings.Join(strings.Fields(strings.Join(lines[loc.StartLine-1:end], " ")), " ")
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/report"
+)
+
+// ConfigFileName is the name of the project config file. The file at the
+// scan root configures the whole scan; copies in subdirectories override
+// its scoped settings for their subtree.
+const ConfigFileName = ".nox.yaml"
+
+// ConfigTree resolves the effective config for each file under a scan root
+// from the root .nox.yaml and any nested .nox.yaml files below it.
+//
+// Only scan.exclude and scan.rules (disable, enable, severity_override and
+// inherit) are scoped; all other settings come from the root config.
+// Deeper configs are merged over their parents:
+//   - scan.exclude patterns append and are matched relative to the
+//     directory of the config that declares them.
+//   - scan.rules.disable appends to the inherited list and
+//     scan.rules.enable removes rules from it.
+//   - scan.rules.severity_override merges by rule ID; deeper entries win.
+//   - scan.rules.inherit: false replaces the inherited rule settings with
+//     the nested file's own instead of merging them.
+type ConfigTree struct {
+	root   *ScanConfig
+	nested map[string]*ScanConfig // keyed by slash-separated dir relative to root
+	cache  map[string]*ScanConfig
+}
+
+// Hash returns a hash of the root config and every nested config, keyed
+// by directory, so that scans with the same effective settings share it.
+func (t *ConfigTree) Hash() string {
+	return report.HashJSON(struct {
+		Root   *ScanConfig
+		Nested map[string]*ScanConfig
+	}{t.root, t.nested})
+}
+
+// NewConfigTree returns a ConfigTree with root as the config at the scan root.
+func NewConfigTree(root *ScanConfig) *ConfigTree {
+	return &ConfigTree{
+		root:   root,
+		nested: make(map[string]*ScanConfig),
+		cache:  make(map[string]*ScanConfig),
+	}
+}
+
+// Add registers cfg as the nested config for dir, a slash-separated path
+// relative to the scan root.
+func (t *ConfigTree) Add(dir string, cfg *ScanConfig) {
+	t.nested[cleanDir(dir)] = cfg
+	clear(t.cache)
+}
+
+// Nested reports whether any nested configs have been added.
+func (t *ConfigTree) Nested() bool {
+	return len(t.nested) > 0
+}
+
+// Sources returns the config files that apply to file, a slash-separated
+// path relative to the scan root, from the root outward.
+func (t *ConfigTree) Sources(file string) []string {
+	sources := []string{ConfigFileName}
+	for _, dir := range ancestors(path.Dir(cleanDir(file))) {
+		if _, ok := t.nested[dir]; ok {
+			sources = append(sources, dir+"/"+ConfigFileName)
+		}
+	}
+	return sources
+}
+
+// Effective returns the merged config for file, a slash-separated path
+// relative to the scan root. Nested exclude patterns are rewritten relative
+// to the scan root. The returned config must not be modified.
+func (t *ConfigTree) Effective(file string) *ScanConfig {
+	return t.effectiveDir(path.Dir(cleanDir(file)))
+}
+
+func (t *ConfigTree) effectiveDir(dir string) *ScanConfig {
+	if cfg, ok := t.cache[dir]; ok {
+		return cfg
+	}
+
+	var cfg *ScanConfig
+	if dir == "." || dir == "/" {
+		merged := *t.root
+		merged.Scan.Rules = mergeRules(RulesConfig{}, t.root.Scan.Rules)
+		cfg = &merged
+	} else {
+		cfg = t.effectiveDir(path.Dir(dir))
+		if nested, ok := t.nested[dir]; ok {
+			cfg = mergeDirConfig(cfg, dir, nested)
+		}
+	}
+	t.cache[dir] = cfg
+	return cfg
+}
+
+// Excluded reports whether file, a slash-separated path relative to the
+// scan root, matches a scan.exclude pattern of the root config or of a
+// nested config whose subtree contains it.
+func (t *ConfigTree) Excluded(file string) bool {
+	return t.ExcludedBy(file) != ""
+}
+
+// ExcludedBy returns the config file, as listed by Sources, whose
+// scan.exclude patterns exclude file, or "" when file is not excluded.
+func (t *ConfigTree) ExcludedBy(file string) string {
+	file = cleanDir(file)
+	if discovery.IsIgnored(file, t.root.Scan.Exclude) {
+		return ConfigFileName
+	}
+	for _, dir := range ancestors(path.Dir(file)) {
+		nested, ok := t.nested[dir]
+		if !ok {
+			continue
+		}
+		if discovery.IsIgnored(strings.TrimPrefix(file, dir+"/"), nested.Scan.Exclude) {
+			return dir + "/" + ConfigFileName
+		}
+	}
+	return ""
+}
+
+// FilterArtifacts drops artifacts excluded by a nested config.
+func (t *ConfigTree) FilterArtifacts(artifacts []discovery.Artifact) []discovery.Artifact {
+	if !t.Nested() {
+		return artifacts
+	}
+	kept := make([]discovery.Artifact, 0, len(artifacts))
+	for _, a := range artifacts {
+		if !t.Excluded(a.Path) {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// ApplyRules removes findings for rules disabled in the config that applies
+// to their file and applies that config's severity overrides.
+func (t *ConfigTree) ApplyRules(fs *findings.FindingSet) {
+	fs.Retain(func(f *findings.Finding) bool {
+		rules := t.Effective(filepath.ToSlash(f.Location.FilePath)).Scan.Rules
+		if slices.Contains(rules.Disable, f.RuleID) {
+			return false
+		}
+		if sev, ok := rules.SeverityOverride[f.RuleID]; ok {
+			f.Severity = findings.Severity(sev)
+		}
+		return true
+	})
+}
+
+// LoadConfigTree builds the ConfigTree for a scan of root from its root
+// config and the nested .nox.yaml files among artifacts. A nested config
+// inside a directory excluded by a shallower config is ignored.
+func LoadConfigTree(root string, cfg *ScanConfig, artifacts []discovery.Artifact) (*ConfigTree, error) {
+	var dirs []string
+	for _, a := range artifacts {
+		if path.Base(a.Path) == ConfigFileName && path.Dir(a.Path) != "." {
+			dirs = append(dirs, path.Dir(a.Path))
+		}
+	}
+	// Shallower configs first so their excludes can hide deeper ones.
+	sort.Slice(dirs, func(i, j int) bool {
+		di, dj := strings.Count(dirs[i], "/"), strings.Count(dirs[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	tree := NewConfigTree(cfg)
+	for _, dir := range dirs {
+		if tree.Excluded(dir + "/" + ConfigFileName) {
+			continue
+		}
+		nested, err := LoadScanConfig(filepath.Join(root, filepath.FromSlash(dir)))
+		if err != nil {
+			return nil, err
+		}
+		tree.Add(dir, nested)
+	}
+	return tree, nil
+}
+
+// LoadEffectiveConfig returns the config tree for root with the nested
+// configs in every directory between root and file, a path relative to
+// root. It reads only those directories rather than walking the tree.
+func LoadEffectiveConfig(root, file string) (*ConfigTree, error) {
+	file = filepath.ToSlash(filepath.Clean(file))
+	if file == ".." || strings.HasPrefix(file, "../") || filepath.IsAbs(file) {
+		return nil, fmt.Errorf("path %q is outside %s", file, root)
+	}
+
+	cfg, err := LoadScanConfig(root)
+	if err != nil {
+		return nil, err
+	}
+	tree := NewConfigTree(cfg)
+	for _, dir := range ancestors(path.Dir(file)) {
+		dirPath := filepath.Join(root, filepath.FromSlash(dir))
+		if _, err := os.Stat(filepath.Join(dirPath, ConfigFileName)); err != nil {
+			continue
+		}
+		nested, err := LoadScanConfig(dirPath)
+		if err != nil {
+			return nil, err
+		}
+		tree.Add(dir, nested)
+	}
+	return tree, nil
+}
+
+// mergeDirConfig returns parent with the scoped settings of the nested
+// config in dir merged over it.
+func mergeDirConfig(parent *ScanConfig, dir string, nested *ScanConfig) *ScanConfig {
+	merged := *parent
+	merged.Scan.Exclude = slices.Clone(parent.Scan.Exclude)
+	for _, p := range nested.Scan.Exclude {
+		merged.Scan.Exclude = append(merged.Scan.Exclude, rebaseExclude(dir, p))
+	}
+
+	base := parent.Scan.Rules
+	if nested.Scan.Rules.Inherit != nil && !*nested.Scan.Rules.Inherit {
+		base = RulesConfig{}
+	}
+	merged.Scan.Rules = mergeRules(base, nested.Scan.Rules)
+	return &merged
+}
+
+// mergeRules merges the rule settings of a nested config over base. The
+// result has Enable and Inherit cleared since they have been applied.
+func mergeRules(base, nested RulesConfig) RulesConfig {
+	var out RulesConfig
+	for _, id := range base.Disable {
+		if !slices.Contains(nested.Enable, id) {
+			out.Disable = append(out.Disable, id)
+		}
+	}
+	for _, id := range nested.Disable {
+		if !slices.Contains(out.Disable, id) {
+			out.Disable = append(out.Disable, id)
+		}
+	}
+
+	if len(base.SeverityOverride) > 0 || len(nested.SeverityOverride) > 0 {
+		out.SeverityOverride = make(map[string]string, len(base.SeverityOverride)+len(nested.SeverityOverride))
+		for id, sev := range base.SeverityOverride {
+			out.SeverityOverride[id] = sev
+		}
+		for id, sev := range nested.SeverityOverride {
+			out.SeverityOverride[id] = sev
+		}
+	}
+	return out
+}
+
+// rebaseExclude rewrites an exclude pattern declared in the config in dir
+// so that it reads relative to the scan root. It is used for display only;
+// matching uses the original pattern against the path relative to dir.
+func rebaseExclude(dir, pattern string) string {
+	neg := ""
+	if strings.HasPrefix(pattern, "!") {
+		neg, pattern = "!", pattern[1:]
+	}
+	if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		return neg + dir + "/" + strings.TrimPrefix(pattern, "/")
+	}
+	return neg + dir + "/**/" + pattern
+}
+
+// ancestors returns dir and each of its parent directories below the root,
+// shallowest first. It returns nil for the root itself.
+func ancestors(dir string) []string {
+	var dirs []string
+	for d := dir; d != "." && d != "/" && d != ""; d = path.Dir(d) {
+		dirs = append(dirs, d)
+	}
+	slices.Reverse(dirs)
+	return dirs
+}
+
+func cleanDir(p string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "./")
+}
@@ -0,0 +1,108 @@
+package discovery
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// TruncatedFile records a file larger than the scan's file size ceiling,
+// of which the content analyzers scanned only the first Scanned bytes, cut
+// back to a whole line.
+type TruncatedFile struct {
+	// Path is the file path relative to the walker root.
+	Path string `json:"path"`
+	// Size is the size of the file and Scanned the most of it that was
+	// scanned, in bytes.
+	Size    int64 `json:"size"`
+	Scanned int64 `json:"scanned"`
+}
+
+// manifestNames lists the dependency manifests that, like lockfiles, are
+// parsed whole and so are never truncated.
+var manifestNames = map[string]bool{
+	"package.json":        true,
+	"npm-shrinkwrap.json": true,
+	"go.mod":              true,
+	"Cargo.toml":          true,
+	"Gemfile":             true,
+	"Pipfile":             true,
+	"Pipfile.lock":        true,
+	"pyproject.toml":      true,
+	"composer.json":       true,
+}
+
+// NeedsWholeFile reports whether a must be read whole however large it is:
+// lockfiles and dependency manifests, whose parsers cannot work on part of
+// a file.
+func NeedsWholeFile(a Artifact) bool {
+	return a.Type == Lockfile || manifestNames[filepath.Base(a.Path)]
+}
+
+// LimitSize sets the ScanLimit of each artifact larger than maxSize to
+// maxSize, in place, and returns those artifacts as truncated files.
+// Artifacts for which NeedsWholeFile is true are left whole. A maxSize of
+// zero or less leaves every artifact whole.
+func LimitSize(artifacts []Artifact, maxSize int64) []TruncatedFile {
+	if maxSize <= 0 {
+		return nil
+	}
+	var truncated []TruncatedFile
+	for i := range artifacts {
+		a := &artifacts[i]
+		if a.Size <= maxSize || NeedsWholeFile(*a) {
+			continue
+		}
+		a.ScanLimit = maxSize
+		truncated = append(truncated, TruncatedFile{Path: a.Path, Size: a.Size, Scanned: maxSize})
+	}
+	return truncated
+}
+
+// ReadContent returns the content of a for the content analyzers: the
+// whole file or, when a.ScanLimit is set and the file is larger, its first
+// a.ScanLimit bytes cut back to the last whole line. The file is read a
+// line at a time, so no more than a.ScanLimit bytes are held whatever its
+// size.
+func ReadContent(a Artifact) ([]byte, error) {
+	if a.ScanLimit <= 0 || a.Size <= a.ScanLimit {
+		return os.ReadFile(a.AbsPath)
+	}
+	f, err := os.Open(a.AbsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readLines(f, a.ScanLimit)
+}
+
+// readLines reads the whole lines of r that fit in limit bytes. A first
+// line longer than limit is cut at limit, so that a file of one long line
+// is still scanned in part.
+func readLines(r io.Reader, limit int64) ([]byte, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	buf := make([]byte, 0, limit)
+	lineStart := 0
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if room := limit - int64(len(buf)); int64(len(chunk)) > room {
+			if lineStart == 0 {
+				return append(buf, chunk[:room]...), nil
+			}
+			return buf[:lineStart], nil
+		}
+		buf = append(buf, chunk...)
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			// The line goes on in the next chunk.
+		case errors.Is(err, io.EOF):
+			return buf, nil
+		case err != nil:
+			return nil, err
+		default:
+			lineStart = len(buf)
+		}
+	}
+}
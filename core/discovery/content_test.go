@@ -0,0 +1,137 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestReadLines(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		in    string
+		limit int64
+		want  string
+	}{
+		{"cut at last whole line", "aaa\nbbb\nccc\n", 9, "aaa\nbbb\n"},
+		{"line ends at limit", "aaa\nbbb\nccc\n", 8, "aaa\nbbb\n"},
+		{"long first line", "aaaaaaaaaa\nbbb\n", 4, "aaaa"},
+		{"no trailing newline", "aaa\nbbb", 20, "aaa\nbbb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readLines(strings.NewReader(tt.in), tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("readLines(%q, %d) = %q, want %q", tt.in, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLines_LineLongerThanBuffer(t *testing.T) {
+	t.Parallel()
+	// The second line spans several reads of the buffered reader and runs
+	// past the limit, so only the first line is kept.
+	in := "first\n" + strings.Repeat("x", 200<<10) + "\n"
+	got, err := readLines(strings.NewReader(in), 100<<10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "first\n" {
+		t.Errorf("readLines kept %d bytes, want only the first line", len(got))
+	}
+}
+
+func TestLimitSize(t *testing.T) {
+	t.Parallel()
+	artifacts := []Artifact{
+		{Path: "dump.sql", Type: Unknown, Size: 2000},
+		{Path: "small.txt", Type: Unknown, Size: 10},
+		{Path: "package-lock.json", Type: Lockfile, Size: 5000},
+		{Path: "web/package.json", Type: Config, Size: 5000},
+	}
+	truncated := LimitSize(artifacts, 1000)
+	if len(truncated) != 1 || truncated[0] != (TruncatedFile{Path: "dump.sql", Size: 2000, Scanned: 1000}) {
+		t.Errorf("LimitSize truncated %+v, want only dump.sql", truncated)
+	}
+	for _, a := range artifacts {
+		want := int64(0)
+		if a.Path == "dump.sql" {
+			want = 1000
+		}
+		if a.ScanLimit != want {
+			t.Errorf("%s: ScanLimit = %d, want %d", a.Path, a.ScanLimit, want)
+		}
+	}
+
+	if truncated := LimitSize(artifacts[:1], 0); truncated != nil {
+		t.Errorf("LimitSize with no ceiling = %+v, want none", truncated)
+	}
+}
+
+func TestReadContent_BoundedMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("writes a 100MB file")
+	}
+	const size = 100 << 20
+	const limit = 10 << 20
+	// Reading the whole file needs well over this; reading the first limit
+	// bytes a line at a time needs the buffer and little more.
+	const budget = 2 * limit
+
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := bufio.NewWriter(f)
+	line := []byte("INSERT INTO users VALUES (1, 'alice@example.com', 'x');\n")
+	for written := 0; written < size; written += len(line) {
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts := []Artifact{{Path: "dump.sql", AbsPath: path, Size: info.Size()}}
+	LimitSize(artifacts, limit)
+
+	// Collect garbage eagerly so the heap measures what is live.
+	defer debug.SetGCPercent(debug.SetGCPercent(5))
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	content, err := ReadContent(artifacts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.ReadMemStats(&after)
+	if grew := after.TotalAlloc - before.TotalAlloc; grew > budget {
+		t.Errorf("reading a %d byte file with a %d byte limit allocated %d bytes, want at most %d", info.Size(), limit, grew, budget)
+	}
+	if int64(len(content)) > limit || len(content) < limit-len(line) {
+		t.Errorf("ReadContent returned %d bytes, want just under %d", len(content), limit)
+	}
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		t.Error("ReadContent should end at a whole line")
+	}
+}
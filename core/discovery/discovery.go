@@ -7,10 +7,13 @@
 package discovery
 
 import (
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // ArtifactType identifies the category of a discovered file.
@@ -41,6 +44,61 @@ type Artifact struct {
 	Type ArtifactType
 	// Size is the file size in bytes.
 	Size int64
+	// Sensitive is set when the file name matches one of the walker's
+	// SensitivePatterns. Sensitive files are always scanned for secrets.
+	Sensitive bool
+	// ScanLimit caps the bytes of the file ReadContent returns, for files
+	// too large to scan whole. Zero means the whole file; see LimitSize.
+	ScanLimit int64
+}
+
+// FileError records a file or directory that was skipped because it could
+// not be read. Scans continue past such errors unless strict I/O is enabled.
+type FileError struct {
+	// Path is the file path relative to the walker root.
+	Path string `json:"path"`
+	// Message describes the underlying error.
+	Message string `json:"error"`
+}
+
+// FileErrors collects the files the analyzers of a scan skipped because
+// they could not be read or scanned, each once. It is safe for concurrent
+// use.
+type FileErrors struct {
+	mu   sync.Mutex
+	errs []FileError
+	seen map[string]bool
+}
+
+// Skip records that the file at path was skipped because of err and
+// reports true. On a nil FileErrors it records nothing and reports false,
+// so that the caller fails instead, as a scan with strict I/O does.
+func (e *FileErrors) Skip(path string, err error) bool {
+	if e == nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.seen[path] {
+		return true
+	}
+	if e.seen == nil {
+		e.seen = make(map[string]bool)
+	}
+	e.seen[path] = true
+	slog.Warn("skipping unreadable file", "path", path, "error", err)
+	e.errs = append(e.errs, FileError{Path: path, Message: err.Error()})
+	return true
+}
+
+// List returns the recorded files in the order they were skipped.
+func (e *FileErrors) List() []FileError {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.errs)
 }
 
 // Classifier determines the ArtifactType of a file based on its path and
@@ -94,6 +152,7 @@ var lockfileNames = map[string]bool{
 	"pom.xml":            true,
 	"build.gradle":       true,
 	"build.gradle.kts":   true,
+	"gradle.lockfile":    true,
 	"packages.lock.json": true,
 	"composer.lock":      true,
 	"bom.json":           true,
@@ -149,6 +208,11 @@ func (d *DefaultClassifier) Classify(path string, _ os.FileInfo) ArtifactType {
 		return Lockfile
 	}
 
+	// Per-configuration Gradle lock files (gradle/dependency-locks/*.lockfile).
+	if dir := filepath.ToSlash(filepath.Dir(normalised)); ext == ".lockfile" && (dir == "gradle/dependency-locks" || strings.HasSuffix(dir, "/gradle/dependency-locks")) {
+		return Lockfile
+	}
+
 	// Container files by exact name.
 	if containerNames[name] {
 		return Container
@@ -219,6 +283,27 @@ type Walker struct {
 	Registry *ClassifierRegistry
 	// IgnorePatterns holds gitignore-style patterns for skipping files.
 	IgnorePatterns []string
+	// SensitivePatterns holds file name patterns that mark artifacts as
+	// Sensitive. Sensitive files that no classifier recognises are
+	// classified as Config.
+	SensitivePatterns []string
+	// Strict makes Walk fail on the first unreadable file or directory
+	// instead of recording it in Errors and continuing.
+	Strict bool
+	// Errors holds the files and directories skipped during the last Walk.
+	Errors []FileError
+	// Skipped holds the files and directories the last Walk left out
+	// because an ignore pattern matched them or they are not regular
+	// files. The .git directory is not listed.
+	Skipped []Skip
+}
+
+// Skip is a path Walk left out. Pattern is the ignore pattern that matched
+// it, or "" for a symlink or other file that is not a regular file.
+type Skip struct {
+	Path    string
+	Dir     bool
+	Pattern string
 }
 
 // NewWalker creates a Walker rooted at root with the DefaultClassifier
@@ -231,15 +316,20 @@ func NewWalker(root string) *Walker {
 	patterns, _ := LoadGitignore(root)
 
 	return &Walker{
-		Root:           root,
-		Registry:       reg,
-		IgnorePatterns: patterns,
+		Root:              root,
+		Registry:          reg,
+		IgnorePatterns:    patterns,
+		SensitivePatterns: DefaultSensitivePatterns,
 	}
 }
 
 // Walk recursively traverses the Root directory, classifies each regular file,
 // and returns the collected artifacts sorted by relative path. Directories
-// matching ignore patterns or named .git are skipped entirely.
+// matching ignore patterns or named .git are skipped entirely, and so are
+// .git files; the content of checked-out submodules is walked. Symlinks,
+// fifos, sockets, and devices are never followed or opened, so symlink
+// cycles and blocking special files cannot hang the walk. Unreadable entries
+// below the root are recorded in Errors unless Strict is set.
 func (w *Walker) Walk() ([]Artifact, error) {
 	absRoot, err := filepath.Abs(w.Root)
 	if err != nil {
@@ -247,48 +337,70 @@ func (w *Walker) Walk() ([]Artifact, error) {
 	}
 
 	var artifacts []Artifact
+	w.Errors, w.Skipped = nil, nil
 
 	err = filepath.Walk(absRoot, func(path string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-
 		// Compute the path relative to root.
 		rel, err := filepath.Rel(absRoot, path)
 		if err != nil {
 			return err
 		}
 
+		if walkErr != nil {
+			if w.Strict || rel == "." {
+				return walkErr
+			}
+			w.Errors = append(w.Errors, FileError{Path: filepath.ToSlash(rel), Message: walkErr.Error()})
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		// Skip the root itself.
 		if rel == "." {
 			return nil
 		}
 
-		// Always skip .git directories.
-		if info.IsDir() && info.Name() == ".git" {
-			return filepath.SkipDir
+		// Always skip .git directories, and the .git files that point
+		// submodules and worktrees to their git directory.
+		if info.Name() == ".git" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
 		// Check gitignore patterns.
-		if IsIgnored(rel, w.IgnorePatterns) {
+		if pattern := IgnoringPattern(rel, w.IgnorePatterns); pattern != "" {
+			w.Skipped = append(w.Skipped, Skip{Path: filepath.ToSlash(rel), Dir: info.IsDir(), Pattern: pattern})
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		// Only classify regular files.
+		// Only classify regular files. filepath.Walk uses Lstat, so symlinks
+		// and special files land here and are skipped.
 		if !info.Mode().IsRegular() {
+			if !info.IsDir() {
+				w.Skipped = append(w.Skipped, Skip{Path: filepath.ToSlash(rel)})
+			}
 			return nil
 		}
 
 		artifactType := w.Registry.Classify(rel, info)
+		sensitive := MatchesName(rel, w.SensitivePatterns)
+		if sensitive && artifactType == Unknown {
+			artifactType = Config
+		}
 
 		artifacts = append(artifacts, Artifact{
-			Path:    filepath.ToSlash(rel),
-			AbsPath: path,
-			Type:    artifactType,
-			Size:    info.Size(),
+			Path:      filepath.ToSlash(rel),
+			AbsPath:   path,
+			Type:      artifactType,
+			Size:      info.Size(),
+			Sensitive: sensitive,
 		})
 
 		return nil
@@ -23,6 +23,9 @@ func TestDefaultClassifier_Lockfiles(t *testing.T) {
 		"Cargo.lock",
 		"pnpm-lock.yaml",
 		"requirements.txt",
+		"gradle.lockfile",
+		"gradle/dependency-locks/compileClasspath.lockfile",
+		"svc/gradle/dependency-locks/runtimeClasspath.lockfile",
 	}
 
 	for _, name := range lockfiles {
@@ -407,6 +410,29 @@ func TestWalker_SkipsGitDirectory(t *testing.T) {
 	}
 }
 
+func TestWalker_SkipsSubmoduleGitFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "vendor", "lib")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{".git": "gitdir: ../../.git/modules/lib\n", "lib.go": "package lib"} {
+		if err := os.WriteFile(filepath.Join(sub, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifacts, err := NewWalker(root).Walk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Path != "vendor/lib/lib.go" {
+		t.Errorf("artifacts = %+v, want only vendor/lib/lib.go", artifacts)
+	}
+}
+
 func TestWalker_ClassifiesFileTypes(t *testing.T) {
 	t.Parallel()
 
@@ -576,6 +602,70 @@ func TestWalker_NonexistentRoot(t *testing.T) {
 	}
 }
 
+func TestWalker_SkipsSymlinkCycle(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "b"), filepath.Join(root, "a")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(root, "self")); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWalker(root)
+	artifacts, err := w.Walk()
+	if err != nil {
+		t.Fatalf("Walk() returned unexpected error: %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Path != "main.go" {
+		t.Fatalf("expected only main.go, got %+v", artifacts)
+	}
+}
+
+func TestWalker_UnreadableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits are not enforced for root")
+	}
+
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	if err := os.Mkdir(locked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(locked, 0o000); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chmod(locked, 0o755) }()
+
+	w := NewWalker(root)
+	artifacts, err := w.Walk()
+	if err != nil {
+		t.Fatalf("Walk() returned unexpected error: %v", err)
+	}
+	if len(artifacts) != 1 {
+		t.Errorf("expected 1 artifact, got %d", len(artifacts))
+	}
+	if len(w.Errors) != 1 || w.Errors[0].Path != "locked" {
+		t.Fatalf("expected one error for locked, got %+v", w.Errors)
+	}
+
+	w = NewWalker(root)
+	w.Strict = true
+	if _, err := w.Walk(); err == nil {
+		t.Fatal("expected strict Walk() to fail on unreadable directory")
+	}
+}
+
 func TestIsIgnored_RootAnchored(t *testing.T) {
 	t.Parallel()
 
@@ -683,6 +773,61 @@ func TestWalker_GitignoreWithNegation(t *testing.T) {
 	}
 }
 
+func TestIgnoringPattern(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"*.log", "build/", "!important.log", "debug.*"}
+	cases := map[string]string{
+		"error.log":     "*.log",
+		"important.log": "",
+		"debug.log":     "debug.*",
+		"build/out.bin": "build/",
+		"main.go":       "",
+	}
+	for path, want := range cases {
+		if got := IgnoringPattern(path, patterns); got != want {
+			t.Errorf("IgnoringPattern(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWalker_RecordsSkipped(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, f := range []string{"app.log", "main.go", "vendor/lib/lib.go"} {
+		abs := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("main.go", filepath.Join(root, "link.go")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	w := NewWalker(root)
+	w.IgnorePatterns = []string{"*.log", "/vendor"}
+	if _, err := w.Walk(); err != nil {
+		t.Fatalf("Walk() returned unexpected error: %v", err)
+	}
+	want := []Skip{
+		{Path: "app.log", Pattern: "*.log"},
+		{Path: "link.go"},
+		{Path: "vendor", Dir: true, Pattern: "/vendor"},
+	}
+	if len(w.Skipped) != len(want) {
+		t.Fatalf("Skipped = %+v, want %+v", w.Skipped, want)
+	}
+	for i := range want {
+		if w.Skipped[i] != want[i] {
+			t.Errorf("Skipped[%d] = %+v, want %+v", i, w.Skipped[i], want[i])
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Gitignore edge cases: non-ENOENT errors, root-anchored dir patterns
 // ---------------------------------------------------------------------------
@@ -754,3 +899,61 @@ func TestMatchPattern_SlashContainingDirOnly(t *testing.T) {
 		})
 	}
 }
+
+func TestWalker_MarksSensitiveFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, name := range []string{
+		"config/production.env.bak",
+		"settings.py.orig",
+		".env.local.old",
+		"deploy/Credentials.json",
+		"keys/id_rsa",
+		"main.go",
+		"README.md",
+		"vault.kdbx",
+	} {
+		abs := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := NewWalker(root)
+	w.SensitivePatterns = append(w.SensitivePatterns, "*.kdbx")
+	artifacts, err := w.Walk()
+	if err != nil {
+		t.Fatalf("Walk() returned unexpected error: %v", err)
+	}
+
+	want := map[string]struct {
+		sensitive bool
+		typ       ArtifactType
+	}{
+		"config/production.env.bak": {true, Config},
+		"settings.py.orig":          {true, Config},
+		".env.local.old":            {true, Config},
+		"deploy/Credentials.json":   {true, Config},
+		"keys/id_rsa":               {true, Config},
+		"main.go":                   {false, Source},
+		"README.md":                 {false, Unknown},
+		"vault.kdbx":                {true, Config},
+	}
+	for _, a := range artifacts {
+		w, ok := want[a.Path]
+		if !ok {
+			t.Errorf("unexpected artifact %q", a.Path)
+			continue
+		}
+		if a.Sensitive != w.sensitive || a.Type != w.typ {
+			t.Errorf("%s: sensitive=%v type=%q, want sensitive=%v type=%q", a.Path, a.Sensitive, a.Type, w.sensitive, w.typ)
+		}
+	}
+	if len(artifacts) != len(want) {
+		t.Errorf("expected %d artifacts, got %d", len(want), len(artifacts))
+	}
+}
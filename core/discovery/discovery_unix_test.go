@@ -0,0 +1,27 @@
+//go:build unix
+
+package discovery
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWalker_SkipsFifo(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := syscall.Mkfifo(filepath.Join(root, "pipe.env"), 0o644); err != nil {
+		t.Skipf("mkfifo not supported: %v", err)
+	}
+
+	w := NewWalker(root)
+	artifacts, err := w.Walk()
+	if err != nil {
+		t.Fatalf("Walk() returned unexpected error: %v", err)
+	}
+	if len(artifacts) != 0 {
+		t.Fatalf("expected fifo to be skipped, got %+v", artifacts)
+	}
+}
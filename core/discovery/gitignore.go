@@ -66,8 +66,15 @@ func IsIgnored(path string, patterns []string) bool {
 	if isGitPath(path) {
 		return true
 	}
+	return IgnoringPattern(path, patterns) != ""
+}
 
-	ignored := false
+// IgnoringPattern returns the pattern that makes IsIgnored ignore a
+// relative path: the last of patterns that matches it, unless a later
+// negation pattern un-ignores it. It returns "" when the path is not
+// ignored, and does not treat .git specially.
+func IgnoringPattern(path string, patterns []string) string {
+	by := ""
 	for _, pattern := range patterns {
 		neg := false
 		p := pattern
@@ -79,11 +86,14 @@ func IsIgnored(path string, patterns []string) bool {
 		}
 
 		if matchPattern(path, p) {
-			ignored = !neg
+			by = pattern
+			if neg {
+				by = ""
+			}
 		}
 	}
 
-	return ignored
+	return by
 }
 
 // isGitPath reports whether path is inside the .git directory.
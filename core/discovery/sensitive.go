@@ -0,0 +1,39 @@
+package discovery
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSensitivePatterns are file name patterns for env files, credentials,
+// keys, and backup copies of config files. Matching files are marked
+// Sensitive so that secret detection applies to them whatever their
+// extension, catching names like config/production.env.bak or
+// settings.py.orig that extension-based routing would skip.
+var DefaultSensitivePatterns = []string{
+	".env*",
+	"*.env*",
+	"*credentials*",
+	"*secret*",
+	"*.pem",
+	"*.key",
+	"id_rsa*",
+	"id_dsa*",
+	"id_ecdsa*",
+	"id_ed25519*",
+	"*.bak",
+	"*.orig",
+	"*.old",
+}
+
+// MatchesName reports whether the base name of path matches any of the
+// glob patterns, ignoring case.
+func MatchesName(path string, patterns []string) bool {
+	name := strings.ToLower(filepath.Base(filepath.FromSlash(path)))
+	for _, p := range patterns {
+		if matched, _ := filepath.Match(strings.ToLower(p), name); matched {
+			return true
+		}
+	}
+	return false
+}
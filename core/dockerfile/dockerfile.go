@@ -0,0 +1,354 @@
+// Package dockerfile parses Dockerfiles into build stages so that analyzers
+// can tell the stage that ships as the final image apart from builder stages
+// that are discarded after the build.
+package dockerfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Instruction is a single Dockerfile instruction with line continuations
+// joined.
+type Instruction struct {
+	// Cmd is the upper-cased instruction keyword, e.g. "RUN".
+	Cmd string
+	// Args is the rest of the instruction after the keyword.
+	Args string
+	// Line is the 1-based line the instruction starts on.
+	Line int
+}
+
+// Var is a variable declared by an ARG or ENV instruction.
+type Var struct {
+	Name string
+	// Value is the unquoted value, or the ARG default.
+	Value string
+	// HasValue is false for an ARG declared without a default.
+	HasValue bool
+}
+
+// Vars returns the variables declared by an ARG or ENV instruction, in
+// either the NAME=value form or the legacy "ENV NAME value" form.
+func (ins Instruction) Vars() []Var {
+	words := splitWords(ins.Args)
+	if len(words) == 0 {
+		return nil
+	}
+	if ins.Cmd == "ENV" && !strings.Contains(words[0], "=") {
+		_, rest, _ := strings.Cut(ins.Args, words[0])
+		return []Var{{Name: words[0], Value: unquote(strings.TrimSpace(rest)), HasValue: true}}
+	}
+	vars := make([]Var, 0, len(words))
+	for _, w := range words {
+		name, value, ok := strings.Cut(w, "=")
+		vars = append(vars, Var{Name: name, Value: unquote(value), HasValue: ok})
+	}
+	return vars
+}
+
+// Flag returns the value of the --name=value flag of the instruction.
+func (ins Instruction) Flag(name string) (string, bool) {
+	for _, w := range splitWords(ins.Args) {
+		if !strings.HasPrefix(w, "--") {
+			break
+		}
+		if v, ok := strings.CutPrefix(w, "--"+name+"="); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Sources returns the source paths of a COPY or ADD instruction in either
+// the shell form or the JSON array form.
+func (ins Instruction) Sources() []string {
+	words := splitWords(ins.Args)
+	for len(words) > 0 && strings.HasPrefix(words[0], "--") {
+		words = words[1:]
+	}
+	rest := strings.Join(words, " ")
+	if strings.HasPrefix(rest, "[") {
+		var paths []string
+		if err := json.Unmarshal([]byte(rest), &paths); err != nil {
+			return nil
+		}
+		words = paths
+	}
+	if len(words) < 2 {
+		return nil
+	}
+	return words[:len(words)-1]
+}
+
+// splitWords splits s on whitespace outside of quotes. Quotes are kept so
+// that NAME="a b" stays a single word.
+func splitWords(s string) []string {
+	var (
+		words []string
+		cur   strings.Builder
+		quote rune
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case unicode.IsSpace(r):
+			if cur.Len() > 0 {
+				words = append(words, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if cur.Len() > 0 {
+		words = append(words, cur.String())
+	}
+	return words
+}
+
+// unquote strips one pair of matching surrounding quotes.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Stage is a build stage: a FROM instruction and the instructions after it
+// up to the next FROM.
+type Stage struct {
+	// Index is the position of the stage in the file, starting at 0.
+	Index int
+	// Name is the lower-cased alias from "FROM ... AS name", if any.
+	Name string
+	// RawImage is the image reference as written, before ARG expansion.
+	RawImage string
+	// Image is RawImage with global ARG defaults expanded. It is empty if
+	// the reference uses an ARG that has no default.
+	Image string
+	// Base is the index of the earlier stage this stage is built FROM, or
+	// -1 if it is built from an external image.
+	Base int
+	// Line is the 1-based line of the FROM instruction.
+	Line int
+	// Instructions are the instructions of the stage, excluding FROM.
+	Instructions []Instruction
+	// CopiedFrom lists the --from sources of COPY instructions in the
+	// stage: stage names, stage indexes or image references.
+	CopiedFrom []string
+}
+
+// File is a parsed Dockerfile.
+type File struct {
+	// Args holds the defaults of ARG instructions declared before the first
+	// FROM. These are the only ARGs that FROM lines can reference.
+	Args map[string]string
+	// Global holds the ARG instructions declared before the first FROM.
+	Global []Instruction
+	// Stages are the build stages in file order.
+	Stages []*Stage
+}
+
+// IsDockerfile reports whether the file name follows a Dockerfile naming
+// convention: "Dockerfile", "Dockerfile.*" or "*.dockerfile".
+func IsDockerfile(path string) bool {
+	base := filepath.Base(path)
+	return base == "Dockerfile" ||
+		strings.HasPrefix(base, "Dockerfile.") ||
+		strings.HasSuffix(strings.ToLower(base), ".dockerfile")
+}
+
+// Parse splits Dockerfile content into build stages. Comments and blank
+// lines are skipped and lines ending in a backslash are joined with the
+// next line. Instructions before the first FROM other than ARG are ignored.
+func Parse(content []byte) (*File, error) {
+	f := &File{Args: make(map[string]string)}
+
+	var (
+		buf     strings.Builder
+		start   int
+		lineNum int
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		lineNum++
+		trimmed := strings.TrimSpace(scanner.Text())
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if buf.Len() == 0 {
+			start = lineNum
+		} else {
+			buf.WriteByte(' ')
+		}
+		if body, ok := strings.CutSuffix(trimmed, `\`); ok {
+			buf.WriteString(strings.TrimSpace(body))
+			continue
+		}
+		buf.WriteString(trimmed)
+		f.add(buf.String(), start)
+		buf.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if buf.Len() > 0 {
+		f.add(buf.String(), start)
+	}
+	return f, nil
+}
+
+// add records one complete instruction.
+func (f *File) add(text string, line int) {
+	cmd, args, _ := strings.Cut(text, " ")
+	cmd = strings.ToUpper(cmd)
+	args = strings.TrimSpace(args)
+
+	if cmd == "FROM" {
+		f.addStage(args, line)
+		return
+	}
+	ins := Instruction{Cmd: cmd, Args: args, Line: line}
+	if len(f.Stages) == 0 {
+		if cmd == "ARG" {
+			f.Global = append(f.Global, ins)
+			for _, v := range ins.Vars() {
+				f.Args[v.Name] = v.Value
+			}
+		}
+		return
+	}
+
+	s := f.Stages[len(f.Stages)-1]
+	s.Instructions = append(s.Instructions, ins)
+	if cmd == "COPY" {
+		if from, ok := ins.Flag("from"); ok {
+			s.CopiedFrom = append(s.CopiedFrom, strings.ToLower(from))
+		}
+	}
+}
+
+// addStage starts a new stage for a FROM instruction with the given
+// arguments: optional flags, the image reference and an optional alias.
+func (f *File) addStage(args string, line int) {
+	fields := strings.Fields(args)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		fields = fields[1:]
+	}
+
+	s := &Stage{Index: len(f.Stages), Base: -1, Line: line}
+	if len(fields) > 0 {
+		s.RawImage = fields[0]
+		s.Image = f.expand(s.RawImage)
+	}
+	if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+		s.Name = strings.ToLower(fields[2])
+	}
+	if s.Image != "" {
+		if base := f.Stage(s.Image); base != nil {
+			s.Base = base.Index
+		}
+	}
+	f.Stages = append(f.Stages, s)
+}
+
+// expand replaces $NAME, ${NAME} and ${NAME:-default} references in ref
+// with global ARG defaults. It returns "" if a reference cannot be
+// resolved.
+func (f *File) expand(ref string) string {
+	if !strings.Contains(ref, "$") {
+		return ref
+	}
+	resolved := true
+	out := os.Expand(ref, func(name string) string {
+		name, fallback, hasFallback := strings.Cut(name, ":-")
+		if v := f.Args[name]; v != "" {
+			return v
+		}
+		if hasFallback {
+			return fallback
+		}
+		resolved = false
+		return ""
+	})
+	if !resolved {
+		return ""
+	}
+	return out
+}
+
+// Stage returns the stage named name (case-insensitively), or nil if no
+// stage has that alias.
+func (f *File) Stage(name string) *Stage {
+	name = strings.ToLower(name)
+	for _, s := range f.Stages {
+		if s.Name != "" && s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Final returns the last stage, which produces the image unless the build
+// selects another stage with --target. It returns nil if the file has no
+// FROM instruction.
+func (f *File) Final() *Stage {
+	if len(f.Stages) == 0 {
+		return nil
+	}
+	return f.Stages[len(f.Stages)-1]
+}
+
+// Runtime returns the stages whose instructions end up in the final image:
+// the final stage and, when it is built FROM an earlier stage, that stage
+// and its own bases. Stages are returned in build order, base first.
+func (f *File) Runtime() []*Stage {
+	var chain []*Stage
+	for s := f.Final(); s != nil; {
+		chain = append([]*Stage{s}, chain...)
+		if s.Base < 0 {
+			break
+		}
+		s = f.Stages[s.Base]
+	}
+	return chain
+}
+
+// StageAt returns the stage containing line, or nil if line comes before
+// the first FROM.
+func (f *File) StageAt(line int) *Stage {
+	var found *Stage
+	for _, s := range f.Stages {
+		if s.Line > line {
+			break
+		}
+		found = s
+	}
+	return found
+}
+
+// CopiedFrom reports whether a later stage copies files out of s with
+// COPY --from, referring to it by name or index.
+func (f *File) CopiedFrom(s *Stage) bool {
+	index := strconv.Itoa(s.Index)
+	for _, other := range f.Stages[s.Index+1:] {
+		for _, from := range other.CopiedFrom {
+			if (s.Name != "" && from == s.Name) || from == index {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package dockerfile
+
+import (
+	"testing"
+)
+
+func TestParse_Stages(t *testing.T) {
+	content := []byte(`# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+ARG REGISTRY
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION} AS Builder
+WORKDIR /src
+RUN apt-get update && \
+    apt-get install -y git
+
+RUN go build -o /app ./cmd/app
+
+FROM builder AS test
+RUN go test ./...
+
+FROM ${REGISTRY}/base:1.0
+FROM gcr.io/distroless/static:nonroot
+COPY --from=builder /app /app
+COPY --chown=nonroot --from=0 /etc/ssl /etc/ssl
+USER nonroot
+`)
+
+	f, err := Parse(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.Stages) != 4 {
+		t.Fatalf("expected 4 stages, got %d", len(f.Stages))
+	}
+
+	builder, test, unresolved, final := f.Stages[0], f.Stages[1], f.Stages[2], f.Stages[3]
+	if builder.Name != "builder" || builder.Image != "golang:1.22" || builder.RawImage != "golang:${GO_VERSION}" || builder.Line != 4 || builder.Base != -1 {
+		t.Errorf("unexpected builder stage: %+v", builder)
+	}
+	if len(builder.Instructions) != 3 {
+		t.Fatalf("expected 3 builder instructions, got %+v", builder.Instructions)
+	}
+	if ins := builder.Instructions[1]; ins.Cmd != "RUN" || ins.Line != 6 || ins.Args != "apt-get update && apt-get install -y git" {
+		t.Errorf("expected continuation lines to be joined, got %+v", ins)
+	}
+	if test.Base != 0 || test.Image != "builder" {
+		t.Errorf("expected test stage to build from builder, got %+v", test)
+	}
+	if unresolved.Image != "" || unresolved.RawImage != "${REGISTRY}/base:1.0" {
+		t.Errorf("expected ARG without default to stay unresolved, got %+v", unresolved)
+	}
+	if f.Final() != final || final.Name != "" || final.Line != 15 {
+		t.Errorf("unexpected final stage: %+v", final)
+	}
+	if !f.CopiedFrom(builder) || f.CopiedFrom(test) || f.CopiedFrom(unresolved) {
+		t.Error("expected only the builder stage to be copied from")
+	}
+	if got := f.StageAt(12); got != test {
+		t.Errorf("StageAt(12) = %+v, want the test stage", got)
+	}
+	if got := f.StageAt(2); got != nil {
+		t.Errorf("StageAt(2) = %+v, want nil before the first FROM", got)
+	}
+}
+
+func TestParse_Runtime(t *testing.T) {
+	content := []byte(`FROM node:20 AS base
+USER node
+FROM base AS build
+RUN npm ci
+FROM base AS runtime
+COPY --from=build /app /app
+FROM runtime
+CMD ["node", "server.js"]
+`)
+
+	f, err := Parse(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runtime := f.Runtime()
+	var names []string
+	for _, s := range runtime {
+		names = append(names, s.Name)
+	}
+	if len(runtime) != 3 || names[0] != "base" || names[1] != "runtime" || runtime[2] != f.Final() {
+		t.Errorf("expected base, runtime and the final stage, got %q", names)
+	}
+
+	empty, err := Parse([]byte("# no stages\nARG X=1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if empty.Final() != nil || empty.Runtime() != nil || empty.Args["X"] != "1" {
+		t.Errorf("unexpected result for file without FROM: %+v", empty)
+	}
+}
+
+func TestInstruction_VarsAndSources(t *testing.T) {
+	vars := Instruction{Cmd: "ENV", Args: `A=1 B="two words" C=`}.Vars()
+	if len(vars) != 3 || vars[1] != (Var{Name: "B", Value: "two words", HasValue: true}) || vars[2].Value != "" || !vars[2].HasValue {
+		t.Errorf("unexpected ENV vars: %+v", vars)
+	}
+	vars = Instruction{Cmd: "ENV", Args: `GREETING "hello world"`}.Vars()
+	if len(vars) != 1 || vars[0].Name != "GREETING" || vars[0].Value != "hello world" {
+		t.Errorf("unexpected legacy ENV vars: %+v", vars)
+	}
+	vars = Instruction{Cmd: "ARG", Args: "TOKEN"}.Vars()
+	if len(vars) != 1 || vars[0].HasValue {
+		t.Errorf("expected ARG without default, got %+v", vars)
+	}
+
+	copyIns := Instruction{Cmd: "COPY", Args: "--chown=app --from=build a.txt b.txt /dst/"}
+	if from, ok := copyIns.Flag("from"); !ok || from != "build" {
+		t.Errorf("Flag(from) = %q, %v", from, ok)
+	}
+	if got := copyIns.Sources(); len(got) != 2 || got[0] != "a.txt" || got[1] != "b.txt" {
+		t.Errorf("unexpected sources: %q", got)
+	}
+	if got := (Instruction{Cmd: "COPY", Args: `["my file.txt", "/dst/"]`}).Sources(); len(got) != 1 || got[0] != "my file.txt" {
+		t.Errorf("unexpected JSON form sources: %q", got)
+	}
+}
+
+func TestIsDockerfile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Dockerfile":            true,
+		"build/Dockerfile.prod": true,
+		"images/api.Dockerfile": true,
+		"docker-compose.yml":    false,
+		"Dockerfiles/README.md": false,
+	} {
+		if got := IsDockerfile(name); got != want {
+			t.Errorf("IsDockerfile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
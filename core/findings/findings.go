@@ -5,8 +5,10 @@
 package findings
 
 import (
+	"cmp"
+	"fmt"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
 )
 
@@ -58,6 +60,27 @@ const (
 	ConfidenceLow    Confidence = "low"
 )
 
+// Confidences lists every confidence level from most to least certain.
+var Confidences = []Confidence{ConfidenceHigh, ConfidenceMedium, ConfidenceLow}
+
+// ParseConfidence returns the confidence level named by s, compared
+// case-insensitively.
+func ParseConfidence(s string) (Confidence, error) {
+	if c := Confidence(strings.ToLower(strings.TrimSpace(s))); slices.Contains(Confidences, c) {
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown confidence %q (want high, medium or low)", s)
+}
+
+// Rank returns the position of c in Confidences, from 0 for high to 2 for
+// low. Unknown confidence levels rank after low.
+func (c Confidence) Rank() int {
+	if i := slices.Index(Confidences, c); i >= 0 {
+		return i
+	}
+	return len(Confidences)
+}
+
 // Location pinpoints where a finding was detected within a source file. The
 // fields map directly to the SARIF physicalLocation / region model so that
 // report generation can consume them without translation.
@@ -81,8 +104,123 @@ type Finding struct {
 	Fingerprint string
 	Metadata    map[string]string
 	Status      Status `json:"Status,omitempty"`
+	// Suppressed is set when the finding is not active: suppressed,
+	// baselined or excluded by VEX. SuppressionReason is the reason given
+	// by the nox:ignore comment, override or baseline entry, or the
+	// justification of the VEX statement. FindingSet keeps both in step
+	// with Status.
+	Suppressed        bool   `json:"Suppressed,omitempty"`
+	SuppressionReason string `json:"SuppressionReason,omitempty"`
+	// InChangedFiles records whether the finding's file is one of the
+	// files a change touches, as listed by nox scan --changed-files-from.
+	// It is nil when the scan was not given the changed files.
+	InChangedFiles *bool `json:"InChangedFiles,omitempty"`
 }
 
+// OutsideChangedFiles reports whether f is known to be in a file the
+// change does not touch. Findings without InChangedFiles are not.
+func (f *Finding) OutsideChangedFiles() bool {
+	return f.InChangedFiles != nil && !*f.InChangedFiles
+}
+
+// Metadata keys recording who last changed a secrets finding's lines,
+// set when git blame attribution is enabled. Each is BlameUnknown when the
+// commit cannot be determined, as for uncommitted lines or in shallow
+// clones.
+const (
+	MetaBlameAuthor = "blame_author"
+	MetaBlameEmail  = "blame_email"
+	MetaBlameCommit = "blame_commit"
+	MetaBlameDate   = "blame_date"
+
+	BlameUnknown = "unknown"
+)
+
+// MetaKeyPath is the metadata key holding the dot/bracket path of the key
+// whose value a secrets finding was matched in, such as
+// "global.database.password", for findings in JSON, YAML and TOML files.
+const MetaKeyPath = "key_path"
+
+// MetaReferenceURL is the metadata key holding the documentation URL that
+// references.url_template or scan.rules.references in .nox.yaml gives the
+// rule of a finding, such as an internal runbook. Rules with such a URL
+// hold it under the same key.
+const MetaReferenceURL = "reference_url"
+
+// MetaCategory is the metadata key holding the category of a finding whose
+// rule is not built in, such as one from a custom rule, a rule pack or a
+// plugin, so that commands reading a findings.json can tell it apart.
+const MetaCategory = "rule_category"
+
+// MetaOwners is the metadata key holding the space-separated CODEOWNERS
+// owners of a finding's file, such as "@org/payments @alice", or
+// "unowned" when no rule assigns the file. It is set when the scanned
+// repository has a CODEOWNERS file.
+const MetaOwners = "owners"
+
+// Well-known metadata keys. Analyzers put the details of a finding in
+// Metadata under these keys rather than only in Message, which is for
+// people; reports carry them as they are (JSON Metadata, SARIF result
+// properties). Not every finding has every key.
+const (
+	// MetaPackage, MetaVersion and MetaEcosystem name the dependency a
+	// finding is about, such as "lodash", "4.17.20" and "npm".
+	MetaPackage   = "package"
+	MetaVersion   = "version"
+	MetaEcosystem = "ecosystem"
+	// MetaVulnID is the OSV ID of a vulnerability, MetaCVE its CVE ID when
+	// it has one and MetaFixedVersion the first version OSV lists as
+	// fixing it.
+	MetaVulnID       = "vuln_id"
+	MetaCVE          = "cve"
+	MetaFixedVersion = "fixed_version"
+	// MetaSecretProvider is the service a secrets finding's credential is
+	// for, such as "AWS" or "GitHub". Generic rules have none.
+	MetaSecretProvider = "secret_provider"
+	// MetaResource is the infrastructure resource an IaC finding is about:
+	// a CloudFormation logical ID or a Terraform plan address.
+	MetaResource = "resource"
+	// MetaCommitSHA is the commit that introduced a finding, for history
+	// and push scans.
+	MetaCommitSHA = "commit_sha"
+)
+
+// WellKnownMetadata lists the well-known metadata keys, MetaOwners
+// included, in the order reports write them.
+var WellKnownMetadata = []string{
+	MetaPackage, MetaVersion, MetaEcosystem, MetaVulnID, MetaCVE, MetaFixedVersion,
+	MetaSecretProvider, MetaResource, MetaOwners, MetaCommitSHA,
+}
+
+// Metadata keys grouping secrets findings that expose the same credential.
+// The secrets analyzer sets MetaSecretGroup to a hash of the normalized
+// secret value, salted with a key that lives only as long as the analyzer;
+// NumberSecretGroups then replaces it with the group's number in the
+// report, such as "secret-1". MetaSecretGroupSize is the number of
+// locations in the group.
+const (
+	MetaSecretGroup     = "secret_group"
+	MetaSecretGroupSize = "secret_group_size"
+)
+
+// Metadata keys recording how long a finding has been open, set when the
+// config has an sla section. MetaFirstSeen is when the finding was first
+// reported, as RFC 3339. MetaSLAStatus is one of the SLA* statuses and
+// MetaSLADue the remediation deadline, for severities that have one.
+const (
+	MetaFirstSeen = "first_seen"
+	MetaSLAStatus = "sla_status"
+	MetaSLADue    = "sla_due"
+
+	SLAWithin      = "within"
+	SLAApproaching = "approaching"
+	SLABreached    = "breached"
+)
+
+// MetaOverrideReason is the metadata key holding the reason of the
+// override that suppressed a finding.
+const MetaOverrideReason = "override_reason"
+
 // FindingSet is an ordered, deduplicated collection of findings. It is the
 // primary data structure passed between pipeline stages.
 type FindingSet struct {
@@ -96,13 +234,17 @@ func NewFindingSet() *FindingSet {
 
 // Add appends a finding to the set. If the finding has an empty Fingerprint,
 // one is computed automatically from RuleID, Location, and Message so that
-// every finding in the set is always fingerprintable.
+// every finding in the set is always fingerprintable. The file path is
+// normalized with NormalizePath first, so reports and fingerprints are the
+// same on every platform.
 //
 //nolint:gocritic // Findings are passed by value throughout the pipeline for simplicity.
 func (fs *FindingSet) Add(f Finding) {
+	f.Location.FilePath = NormalizePath(f.Location.FilePath)
 	if f.Fingerprint == "" {
 		f.Fingerprint = ComputeFingerprint(f.RuleID, f.Location, f.Message)
 	}
+	f.Suppressed = !f.Status.IsActive()
 	fs.items = append(fs.items, f)
 }
 
@@ -123,20 +265,29 @@ func (fs *FindingSet) Deduplicate() {
 	fs.items = unique
 }
 
-// SortDeterministic orders findings by RuleID, then FilePath, then StartLine.
-// This guarantees stable, reproducible output regardless of the order in which
-// analyzers emit their results.
+// SortDeterministic orders findings by FilePath, then StartLine, then RuleID.
+// Findings that tie on all three are ordered by the rest of their location,
+// message and fingerprint. This guarantees stable, reproducible output
+// regardless of the order in which analyzers emit their results. A set that
+// is already sorted is not modified, so several reporters can sort and read
+// it concurrently once it has been sorted.
 func (fs *FindingSet) SortDeterministic() {
-	sort.Slice(fs.items, func(i, j int) bool {
-		a, b := fs.items[i], fs.items[j]
-		if a.RuleID != b.RuleID {
-			return a.RuleID < b.RuleID
-		}
-		if a.Location.FilePath != b.Location.FilePath {
-			return a.Location.FilePath < b.Location.FilePath
-		}
-		return a.Location.StartLine < b.Location.StartLine
-	})
+	if !slices.IsSortedFunc(fs.items, compareFindings) {
+		slices.SortStableFunc(fs.items, compareFindings)
+	}
+}
+
+func compareFindings(a, b Finding) int {
+	return cmp.Or(
+		cmp.Compare(a.Location.FilePath, b.Location.FilePath),
+		cmp.Compare(a.Location.StartLine, b.Location.StartLine),
+		cmp.Compare(a.RuleID, b.RuleID),
+		cmp.Compare(a.Location.StartColumn, b.Location.StartColumn),
+		cmp.Compare(a.Location.EndLine, b.Location.EndLine),
+		cmp.Compare(a.Location.EndColumn, b.Location.EndColumn),
+		cmp.Compare(a.Message, b.Message),
+		cmp.Compare(a.Fingerprint, b.Fingerprint),
+	)
 }
 
 // RemoveByRuleIDs removes all findings whose RuleID matches any of the given IDs.
@@ -158,6 +309,18 @@ func (fs *FindingSet) RemoveByRuleIDs(ids []string) {
 	fs.items = kept
 }
 
+// Retain keeps only the findings for which keep returns true. keep may
+// modify the finding it is passed.
+func (fs *FindingSet) Retain(keep func(f *Finding) bool) {
+	kept := fs.items[:0]
+	for i := range fs.items {
+		if keep(&fs.items[i]) {
+			kept = append(kept, fs.items[i])
+		}
+	}
+	fs.items = kept
+}
+
 // OverrideSeverity changes the severity for all findings with the given rule ID.
 func (fs *FindingSet) OverrideSeverity(ruleID string, severity Severity) {
 	for i := range fs.items {
@@ -167,10 +330,39 @@ func (fs *FindingSet) OverrideSeverity(ruleID string, severity Severity) {
 	}
 }
 
-// SetStatus sets the status of the finding at the given index.
+// MarkChangedFiles sets InChangedFiles on every finding: true when its
+// file is one of changed, which are slash-separated paths relative to the
+// scan root, and false otherwise.
+func (fs *FindingSet) MarkChangedFiles(changed []string) {
+	set := make(map[string]bool, len(changed))
+	for _, p := range changed {
+		set[p] = true
+	}
+	for i := range fs.items {
+		in := set[filepath.ToSlash(fs.items[i].Location.FilePath)]
+		fs.items[i].InChangedFiles = &in
+	}
+}
+
+// SetStatus sets the status of the finding at the given index. A finding
+// that becomes active loses its suppression reason.
 func (fs *FindingSet) SetStatus(i int, s Status) {
 	if i >= 0 && i < len(fs.items) {
-		fs.items[i].Status = s
+		f := &fs.items[i]
+		f.Status = s
+		f.Suppressed = !s.IsActive()
+		if !f.Suppressed {
+			f.SuppressionReason = ""
+		}
+	}
+}
+
+// Suppress sets the status of the finding at the given index, as SetStatus
+// does, and records reason as its SuppressionReason.
+func (fs *FindingSet) Suppress(i int, s Status, reason string) {
+	fs.SetStatus(i, s)
+	if i >= 0 && i < len(fs.items) && !s.IsActive() {
+		fs.items[i].SuppressionReason = reason
 	}
 }
 
@@ -1,6 +1,8 @@
 package findings
 
 import (
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -24,6 +26,34 @@ func TestComputeFingerprint_Determinism(t *testing.T) {
 	}
 }
 
+func TestComputeFingerprint_PathSeparators(t *testing.T) {
+	t.Parallel()
+
+	linux := ComputeFingerprint("SEC001", Location{FilePath: "cmd/server/main.go", StartLine: 42}, "x")
+	for _, p := range []string{`cmd\server\main.go`, "./cmd/server/main.go", "cmd//server/main.go"} {
+		if got := ComputeFingerprint("SEC001", Location{FilePath: p, StartLine: 42}, "x"); got != linux {
+			t.Errorf("fingerprint for %q differs from the forward-slash path", p)
+		}
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"":                      "",
+		"a.go":                  "a.go",
+		`sub\dir\a.go`:          "sub/dir/a.go",
+		"./sub/a.go":            "sub/a.go",
+		`C:\Users\me\repo\a.go`: "C:/Users/me/repo/a.go",
+		"/home/me/repo/a.go":    "/home/me/repo/a.go",
+	} {
+		if got := NormalizePath(in); got != want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
 func TestComputeFingerprint_Uniqueness(t *testing.T) {
 	t.Parallel()
 
@@ -140,6 +170,26 @@ func TestFindingSet_Add_PreservesExistingFingerprint(t *testing.T) {
 	}
 }
 
+func TestFindingSet_Add_SlashPaths(t *testing.T) {
+	t.Parallel()
+
+	fs := NewFindingSet()
+	fs.Add(Finding{
+		RuleID:   "SEC001",
+		Location: Location{FilePath: filepath.Join("src", "config", "app.go"), StartLine: 10},
+		Message:  "secret detected",
+	})
+
+	f := fs.Findings()[0]
+	if f.Location.FilePath != "src/config/app.go" {
+		t.Fatalf("expected forward-slash path, got %q", f.Location.FilePath)
+	}
+	want := ComputeFingerprint("SEC001", Location{FilePath: "src/config/app.go", StartLine: 10}, "secret detected")
+	if f.Fingerprint != want {
+		t.Fatal("fingerprint should be computed from the forward-slash path")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // FindingSet.Deduplicate tests
 // ---------------------------------------------------------------------------
@@ -261,10 +311,10 @@ func TestFindingSet_SortDeterministic(t *testing.T) {
 		filePath  string
 		startLine int
 	}{
+		{"SEC002", "a.go", 1},
 		{"SEC001", "a.go", 10},
 		{"SEC001", "a.go", 30},
 		{"SEC001", "b.go", 50},
-		{"SEC002", "a.go", 1},
 		{"SEC003", "z.go", 1},
 	}
 
@@ -410,6 +460,26 @@ func TestFindingSet_OverrideSeverity(t *testing.T) {
 	}
 }
 
+func TestFindingSet_MarkChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	fs := NewFindingSet()
+	fs.Add(Finding{RuleID: "SEC-001", Location: Location{FilePath: "src/a.go", StartLine: 1}, Message: "a"})
+	fs.Add(Finding{RuleID: "SEC-001", Location: Location{FilePath: "b.go", StartLine: 2}, Message: "b"})
+	if fs.Findings()[0].OutsideChangedFiles() {
+		t.Error("an untagged finding is outside the changed files")
+	}
+
+	fs.MarkChangedFiles([]string{"src/a.go"})
+
+	for _, f := range fs.Findings() {
+		want := f.Location.FilePath == "src/a.go"
+		if f.InChangedFiles == nil || *f.InChangedFiles != want || f.OutsideChangedFiles() == want {
+			t.Errorf("%s: InChangedFiles = %v, want %v", f.Location.FilePath, f.InChangedFiles, want)
+		}
+	}
+}
+
 func TestFindingSet_OverrideSeverity_NoMatch(t *testing.T) {
 	t.Parallel()
 
@@ -465,6 +535,28 @@ func TestFindingSet_SetStatus(t *testing.T) {
 	}
 }
 
+func TestFindingSet_Suppress(t *testing.T) {
+	t.Parallel()
+
+	fs := NewFindingSet()
+	fs.Add(Finding{RuleID: "SEC-001", Location: Location{FilePath: "a.go", StartLine: 1}, Message: "a"})
+	fs.Add(Finding{RuleID: "SEC-002", Location: Location{FilePath: "b.go", StartLine: 2}, Message: "b", Status: StatusBaselined})
+
+	fs.Suppress(0, StatusSuppressed, "test fixture")
+	if f := fs.Findings()[0]; !f.Suppressed || f.SuppressionReason != "test fixture" {
+		t.Errorf("suppressed finding = %+v", f)
+	}
+	if f := fs.Findings()[1]; !f.Suppressed {
+		t.Errorf("finding added as baselined is not marked suppressed: %+v", f)
+	}
+
+	// A finding that becomes active again loses the reason.
+	fs.SetStatus(0, StatusNew)
+	if f := fs.Findings()[0]; f.Suppressed || f.SuppressionReason != "" {
+		t.Errorf("reactivated finding = %+v", f)
+	}
+}
+
 func TestFindingSet_SetStatus_OutOfBounds(t *testing.T) {
 	t.Parallel()
 
@@ -797,3 +889,231 @@ func TestMatchRulePatterns(t *testing.T) {
 		}
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Severity ordering tests
+// ---------------------------------------------------------------------------
+
+func TestSeverity_MeetsThreshold_AllPairs(t *testing.T) {
+	t.Parallel()
+
+	// Severities are listed most severe first, so s meets t exactly when s
+	// comes no later than t.
+	for i, s := range Severities {
+		for j, threshold := range Severities {
+			if got, want := s.MeetsThreshold(threshold), i <= j; got != want {
+				t.Errorf("%s.MeetsThreshold(%s) = %v, want %v", s, threshold, got, want)
+			}
+		}
+	}
+
+	// Unknown severities, such as those a plugin or a mistyped override
+	// produces, meet no threshold, and no severity meets an unknown one.
+	for _, unknown := range []Severity{"", "blocker", "hgih", "HIGH", "P1"} {
+		for _, known := range Severities {
+			if unknown.MeetsThreshold(known) {
+				t.Errorf("%q.MeetsThreshold(%s) = true", unknown, known)
+			}
+			if known.MeetsThreshold(unknown) {
+				t.Errorf("%s.MeetsThreshold(%q) = true", known, unknown)
+			}
+		}
+		if unknown.MeetsThreshold(unknown) {
+			t.Errorf("%q.MeetsThreshold(%q) = true", unknown, unknown)
+		}
+		if unknown.Valid() {
+			t.Errorf("%q.Valid() = true", unknown)
+		}
+	}
+}
+
+func TestSeverity_RankAndCompare(t *testing.T) {
+	t.Parallel()
+
+	for i, s := range Severities {
+		if s.Rank() != i || !s.Valid() {
+			t.Errorf("%s: Rank() = %d, Valid() = %v", s, s.Rank(), s.Valid())
+		}
+	}
+	custom := Severity("blocker")
+	if custom.Rank() <= SeverityInfo.Rank() {
+		t.Errorf("unknown severity ranks %d, want after info", custom.Rank())
+	}
+
+	got := []Severity{SeverityLow, custom, SeverityCritical, SeverityInfo, SeverityMedium, SeverityHigh}
+	slices.SortFunc(got, CompareSeverity)
+	want := []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo, custom}
+	if !slices.Equal(got, want) {
+		t.Errorf("sorted = %v, want %v", got, want)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]Severity{
+		"critical": SeverityCritical,
+		"High":     SeverityHigh,
+		" medium ": SeverityMedium,
+		"LOW":      SeverityLow,
+		"info":     SeverityInfo,
+	} {
+		if got, err := ParseSeverity(in); err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "hgih", "warning", "P1"} {
+		if _, err := ParseSeverity(in); err == nil {
+			t.Errorf("ParseSeverity(%q): expected an error", in)
+		}
+	}
+}
+
+func TestParseConfidence(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]Confidence{"High": ConfidenceHigh, " medium ": ConfidenceMedium, "low": ConfidenceLow} {
+		if got, err := ParseConfidence(in); err != nil || got != want {
+			t.Errorf("ParseConfidence(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseConfidence("certain"); err == nil {
+		t.Error("ParseConfidence(\"certain\"): expected an error")
+	}
+	if ConfidenceHigh.Rank() >= ConfidenceMedium.Rank() || ConfidenceMedium.Rank() >= ConfidenceLow.Rank() || Confidence("").Rank() <= ConfidenceLow.Rank() {
+		t.Error("confidence ranks are not ordered from high to low, unknown last")
+	}
+}
+
+func TestSeverityMapping_ParseThreshold(t *testing.T) {
+	t.Parallel()
+
+	m := SeverityMapping{SeverityHigh: {Label: "P2"}}
+	for in, want := range map[string]Severity{"p2": SeverityHigh, "Medium": SeverityMedium} {
+		if got, err := m.ParseThreshold(in); err != nil || got != want {
+			t.Errorf("ParseThreshold(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"hgih", "P3", ""} {
+		if _, err := m.ParseThreshold(in); err == nil {
+			t.Errorf("ParseThreshold(%q): expected an error", in)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Severity mapping tests
+// ---------------------------------------------------------------------------
+
+func TestSeverityMapping_Resolve(t *testing.T) {
+	t.Parallel()
+
+	m := SeverityMapping{
+		SeverityCritical: {Label: "P1"},
+		SeverityHigh:     {Label: "P2"},
+		SeverityLow:      {Label: "P4"},
+		SeverityInfo:     {Label: "P4"},
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	tests := []struct {
+		name string
+		want Severity
+	}{
+		{"high", SeverityHigh},
+		{"HIGH", SeverityHigh},
+		{"p2", SeverityHigh},
+		{"P4", SeverityInfo},
+		{"medium", SeverityMedium},
+		{"P3", "P3"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := m.Resolve(tt.name); got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := m.Matching("p4"); len(got) != 2 || got[0] != SeverityLow || got[1] != SeverityInfo {
+		t.Errorf("Matching(p4) = %v", got)
+	}
+	if got := m.Label(SeverityMedium); got != "medium" {
+		t.Errorf("Label(medium) = %q", got)
+	}
+}
+
+func TestSeverityMapping_Validate(t *testing.T) {
+	t.Parallel()
+
+	for _, m := range []SeverityMapping{
+		{"urgent": {Label: "P1"}},
+		{SeverityHigh: {Label: " "}},
+		{SeverityHigh: {Label: "Critical"}},
+	} {
+		if err := m.Validate(); err == nil {
+			t.Errorf("Validate(%v): expected an error", m)
+		}
+	}
+	if err := (SeverityMapping{SeverityHigh: {Label: "High"}}).Validate(); err != nil {
+		t.Errorf("a label may restate its own severity: %v", err)
+	}
+}
+
+func TestFindingSet_ApplySeverityMapping(t *testing.T) {
+	t.Parallel()
+
+	score := 9.5
+	shared := map[string]string{"cwe": "CWE-798"}
+	fs := NewFindingSet()
+	fs.Add(Finding{RuleID: "SEC-001", Severity: SeverityCritical, Metadata: shared})
+	fs.Add(Finding{RuleID: "SEC-002", Severity: SeverityMedium})
+	fs.ApplySeverityMapping(SeverityMapping{SeverityCritical: {Label: "P1", Score: &score}})
+
+	got := fs.Findings()
+	if got[0].DisplaySeverity() != "P1" || got[0].Metadata[MetaSeverityScore] != "9.5" || got[0].Severity != SeverityCritical {
+		t.Errorf("critical finding = %+v", got[0])
+	}
+	if _, ok := shared[MetaSeverityLabel]; ok {
+		t.Error("the metadata map shared with the caller was modified")
+	}
+	if got[1].DisplaySeverity() != "medium" || got[1].Metadata != nil {
+		t.Errorf("unmapped finding = %+v", got[1])
+	}
+}
+
+func TestFindingSet_NumberSecretGroups(t *testing.T) {
+	fs := NewFindingSet()
+	add := func(rule, path string, line int, group string) {
+		f := Finding{RuleID: rule, Location: Location{FilePath: path, StartLine: line}, Message: rule}
+		if group != "" {
+			f.Metadata = map[string]string{MetaSecretGroup: group}
+		}
+		fs.Add(f)
+	}
+	add("SEC-001", "a.env", 1, "g1")
+	add("SEC-161", "a.env", 1, "g1") // another rule at the same location
+	add("SEC-001", "b.env", 3, "g1")
+	add("SEC-002", "c.env", 2, "g2")
+	add("IAC-001", "Dockerfile", 1, "")
+	fs.NumberSecretGroups()
+
+	ff := fs.Findings()
+	for i, want := range []int{2, 2, 2, 1, 1} {
+		if got := SecretGroupSize(&ff[i]); got != want {
+			t.Errorf("finding %d: group size %d, want %d", i, got, want)
+		}
+	}
+	for i, want := range []string{"secret-1", "secret-1", "secret-1", "secret-2", ""} {
+		if got := ff[i].Metadata[MetaSecretGroup]; got != want {
+			t.Errorf("finding %d: group %q, want %q", i, got, want)
+		}
+	}
+	if _, ok := ff[4].Metadata[MetaSecretGroupSize]; ok {
+		t.Error("group size set on a finding in no group")
+	}
+
+	collapsed := CollapseSecretGroups(ff)
+	if len(collapsed) != 3 || collapsed[0].Location.FilePath != "a.env" || collapsed[1].RuleID != "SEC-002" {
+		t.Errorf("collapsed = %+v", collapsed)
+	}
+}
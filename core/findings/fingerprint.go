@@ -3,17 +3,31 @@ package findings
 import (
 	"crypto/sha256"
 	"fmt"
+	"path"
+	"strings"
 )
 
 // ComputeFingerprint produces a deterministic SHA-256 hex digest from the
 // combination of ruleID, location file path, location start line, and the
 // matched content. The fingerprint is stable across runs as long as the
 // inputs are identical, making it suitable for deduplication and change
-// tracking between scans.
+// tracking between scans. The path is normalized with NormalizePath, so a
+// finding has the same fingerprint on every platform.
 func ComputeFingerprint(ruleID string, loc Location, content string) string {
 	h := sha256.New()
 	// Write each component separated by a null byte to avoid ambiguous
 	// concatenations (e.g. ruleID="ab", path="c" vs ruleID="a", path="bc").
-	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%d\x00%s", ruleID, loc.FilePath, loc.StartLine, content)
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%d\x00%s", ruleID, NormalizePath(loc.FilePath), loc.StartLine, content)
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
+
+// NormalizePath returns p with forward slashes, whichever platform wrote
+// it, and cleaned of "." elements and repeated separators. Backslashes are
+// always treated as separators, so a path recorded on Windows matches the
+// same path on Linux. The empty path is returned unchanged.
+func NormalizePath(p string) string {
+	if p == "" {
+		return p
+	}
+	return path.Clean(strings.ReplaceAll(p, `\`, "/"))
+}
@@ -0,0 +1,70 @@
+package findings
+
+import (
+	"maps"
+	"strconv"
+)
+
+// NumberSecretGroups replaces the salted hash in each finding's
+// MetaSecretGroup with the group's number in the set's order, such as
+// "secret-1", and records the number of distinct locations of the group as
+// MetaSecretGroupSize. The hashes are discarded, so reports of the same
+// tree are identical. Call it after Deduplicate and SortDeterministic, so
+// that a value matched twice at one location counts once.
+func (fs *FindingSet) NumberSecretGroups() {
+	type loc struct {
+		path string
+		line int
+	}
+	ids := make(map[string]string)
+	locations := make(map[string]map[loc]bool)
+	for i := range fs.items {
+		f := &fs.items[i]
+		group := f.Metadata[MetaSecretGroup]
+		if group == "" {
+			continue
+		}
+		if _, ok := ids[group]; !ok {
+			ids[group] = "secret-" + strconv.Itoa(len(ids)+1)
+			locations[group] = make(map[loc]bool)
+		}
+		locations[group][loc{f.Location.FilePath, f.Location.StartLine}] = true
+	}
+	for i := range fs.items {
+		f := &fs.items[i]
+		group := f.Metadata[MetaSecretGroup]
+		if group == "" {
+			continue
+		}
+		f.Metadata = maps.Clone(f.Metadata)
+		f.Metadata[MetaSecretGroup] = ids[group]
+		f.Metadata[MetaSecretGroupSize] = strconv.Itoa(len(locations[group]))
+	}
+}
+
+// SecretGroupSize returns the number of locations of f's secret group, or
+// 1 when f is in no group.
+func SecretGroupSize(f *Finding) int {
+	if n, err := strconv.Atoi(f.Metadata[MetaSecretGroupSize]); err == nil && n > 0 {
+		return n
+	}
+	return 1
+}
+
+// CollapseSecretGroups returns ff with only the first finding of each
+// secret group, keeping the order of ff. Findings in no group are all
+// kept.
+func CollapseSecretGroups(ff []Finding) []Finding {
+	seen := make(map[string]bool)
+	out := make([]Finding, 0, len(ff))
+	for i := range ff {
+		if group := ff[i].Metadata[MetaSecretGroup]; group != "" {
+			if seen[group] {
+				continue
+			}
+			seen[group] = true
+		}
+		out = append(out, ff[i])
+	}
+	return out
+}
@@ -0,0 +1,181 @@
+package findings
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Severities lists every severity from most to least severe. It is the one
+// ordering of severities: thresholds, policies, sorting and grading all
+// compare severities by their position in it.
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// ParseSeverity returns the severity named by s, compared
+// case-insensitively. Names that are not a nox severity are an error.
+func ParseSeverity(s string) (Severity, error) {
+	if sev := Severity(strings.ToLower(strings.TrimSpace(s))); sev.Valid() {
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (want critical, high, medium, low or info)", s)
+}
+
+// Valid reports whether s is one of Severities.
+func (s Severity) Valid() bool {
+	return slices.Contains(Severities, s)
+}
+
+// Rank returns the position of s in Severities, from 0 for critical to 4
+// for info. Unknown severities rank after info.
+func (s Severity) Rank() int {
+	if i := slices.Index(Severities, s); i >= 0 {
+		return i
+	}
+	return len(Severities)
+}
+
+// MeetsThreshold reports whether s is at least as severe as threshold.
+// It is false when either is unknown: an unknown severity neither passes a
+// threshold filter nor fails a policy. Thresholds are checked with
+// ParseSeverity when they are read, and the scan warns about findings with
+// unknown severities.
+func (s Severity) MeetsThreshold(threshold Severity) bool {
+	return s.Valid() && threshold.Valid() && s.Rank() <= threshold.Rank()
+}
+
+// CompareSeverity orders severities from most to least severe, with
+// unknown severities last, for use with slices.SortFunc.
+func CompareSeverity(a, b Severity) int {
+	return cmp.Compare(a.Rank(), b.Rank())
+}
+
+// Metadata keys holding the custom label and score of a finding's severity,
+// set when a severity mapping is applied to a report.
+const (
+	MetaSeverityLabel = "severity_label"
+	MetaSeverityScore = "severity_score"
+)
+
+// SeverityLabel is an organization's own name for a severity, such as "P1".
+// Score is reported next to the label and Weight replaces the severity's
+// weight in the badge grade; both are optional.
+type SeverityLabel struct {
+	Label  string   `yaml:"label" json:"label"`
+	Score  *float64 `yaml:"score,omitempty" json:"score,omitempty"`
+	Weight *int     `yaml:"weight,omitempty" json:"weight,omitempty"`
+}
+
+// SeverityMapping maps nox severities to custom labels. Several severities
+// may share a label. Severities without an entry keep their own name.
+type SeverityMapping map[Severity]SeverityLabel
+
+// Validate reports mappings of unknown severities, empty labels and labels
+// that name a different nox severity.
+func (m SeverityMapping) Validate() error {
+	for _, sev := range slices.Sorted(maps.Keys(m)) {
+		if !sev.Valid() {
+			return fmt.Errorf("unknown severity %q", sev)
+		}
+		label := strings.TrimSpace(m[sev].Label)
+		if label == "" {
+			return fmt.Errorf("%s: label is required", sev)
+		}
+		if other := Severity(strings.ToLower(label)); other.Valid() && other != sev {
+			return fmt.Errorf("%s: label %q names another severity", sev, label)
+		}
+	}
+	return nil
+}
+
+// Label returns the custom label of sev, or sev itself if it is not mapped.
+func (m SeverityMapping) Label(sev Severity) string {
+	if l, ok := m[sev]; ok && l.Label != "" {
+		return l.Label
+	}
+	return string(sev)
+}
+
+// Matching returns the severities, most severe first, that name refers to:
+// a nox severity or a custom label, compared case-insensitively. It returns
+// nil if name is neither.
+func (m SeverityMapping) Matching(name string) []Severity {
+	name = strings.TrimSpace(name)
+	if sev := Severity(strings.ToLower(name)); sev.Valid() {
+		return []Severity{sev}
+	}
+	var out []Severity
+	for _, sev := range Severities {
+		if l, ok := m[sev]; ok && strings.EqualFold(l.Label, name) {
+			out = append(out, sev)
+		}
+	}
+	return out
+}
+
+// Resolve returns the nox severity for a threshold given as a nox severity
+// or a custom label. A label shared by several severities resolves to the
+// least severe of them, so that the threshold includes all of them. Names
+// that are neither are returned unchanged.
+func (m SeverityMapping) Resolve(name string) Severity {
+	if matched := m.Matching(name); len(matched) > 0 {
+		return matched[len(matched)-1]
+	}
+	return Severity(name)
+}
+
+// ParseThreshold is like Resolve, but names that are neither a nox
+// severity nor a custom label are an error.
+func (m SeverityMapping) ParseThreshold(name string) (Severity, error) {
+	if sev := m.Resolve(name); sev.Valid() {
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (want critical, high, medium, low, info or a severity_mapping label)", name)
+}
+
+// Weights returns the badge weights set in the mapping, by severity.
+func (m SeverityMapping) Weights() map[Severity]int {
+	var out map[Severity]int
+	for sev, l := range m {
+		if l.Weight != nil {
+			if out == nil {
+				out = make(map[Severity]int)
+			}
+			out[sev] = *l.Weight
+		}
+	}
+	return out
+}
+
+// ApplySeverityMapping records the custom label and score of each finding's
+// severity in its metadata under MetaSeverityLabel and MetaSeverityScore.
+// The native severity is left unchanged.
+func (fs *FindingSet) ApplySeverityMapping(m SeverityMapping) {
+	for i := range fs.items {
+		f := &fs.items[i]
+		l, ok := m[f.Severity]
+		if !ok || l.Label == "" {
+			continue
+		}
+		meta := maps.Clone(f.Metadata)
+		if meta == nil {
+			meta = make(map[string]string, 2)
+		}
+		meta[MetaSeverityLabel] = l.Label
+		if l.Score != nil {
+			meta[MetaSeverityScore] = strconv.FormatFloat(*l.Score, 'f', -1, 64)
+		}
+		f.Metadata = meta
+	}
+}
+
+// DisplaySeverity returns the custom severity label recorded in the
+// finding's metadata, or its severity if there is none.
+func (f *Finding) DisplaySeverity() string {
+	if label := f.Metadata[MetaSeverityLabel]; label != "" {
+		return label
+	}
+	return string(f.Severity)
+}
@@ -0,0 +1,156 @@
+package fix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// UnifiedDiff returns a unified diff that turns old into new, with path as
+// both file names. It returns "" if the contents are equal.
+func UnifiedDiff(path string, old, new []byte) string {
+	ops := diffLines(splitLines(string(old)), splitLines(string(new)))
+
+	var b strings.Builder
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// Extend the hunk while the next change is within reach of the
+		// context of the previous one.
+		start := max(i-diffContext, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = next
+		}
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			b.WriteByte('\n')
+		}
+
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats a hunk header range. An empty range starts at the line
+// before the change, as in diff -u.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the edit script from a to b. Common leading and
+// trailing lines are matched directly; the rest is diffed with a longest
+// common subsequence table, which stays small for the local edits fixers
+// make.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		ops = append(ops, diffOp{' ', l})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i]})
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', ma[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', mb[j]})
+			j++
+		}
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
@@ -0,0 +1,246 @@
+// Package fix generates and applies patches for findings with a mechanical
+// remediation, such as pinning a GitHub Action to a commit SHA or a
+// container image to a digest. Fixers are deterministic: they look up the
+// values they need (commit SHAs, image digests) but never guess at code.
+package fix
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// ErrNoChange is returned by a Fixer when the finding needs no edit, for
+// example because the line is already pinned.
+var ErrNoChange = errors.New("nothing to change")
+
+// Edit replaces a single line of a file.
+type Edit struct {
+	// Line is the 1-based line number.
+	Line int
+	// Old is the current text of the line, without the line ending. Apply
+	// refuses to change a line whose text no longer matches.
+	Old string
+	// New is the replacement text.
+	New string
+}
+
+// Fixer produces the edits that remediate a finding in a file.
+type Fixer interface {
+	Fix(ctx context.Context, content []byte, f findings.Finding) ([]Edit, error)
+}
+
+// FixerFunc adapts a function to the Fixer interface.
+type FixerFunc func(ctx context.Context, content []byte, f findings.Finding) ([]Edit, error)
+
+// Fix calls fn.
+func (fn FixerFunc) Fix(ctx context.Context, content []byte, f findings.Finding) ([]Edit, error) {
+	return fn(ctx, content, f)
+}
+
+// Registry maps rule IDs to the fixers that remediate their findings.
+type Registry struct {
+	fixers map[string]Fixer
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{fixers: make(map[string]Fixer)}
+}
+
+// Builtin returns a Registry with the built-in fixers, which use r to look
+// up commit SHAs and image digests:
+//
+//   - IAC-013 pins a GitHub Action to the commit its tag or branch points to.
+//   - CONT-001, CONT-002, IAC-002, IAC-031 and IAC-320 pin a container
+//     image to the digest its tag currently resolves to.
+func Builtin(r Resolver) *Registry {
+	reg := NewRegistry()
+	reg.Register("IAC-013", actionPinFixer(r))
+	image := imageDigestFixer(r)
+	for _, id := range []string{"CONT-001", "CONT-002", "IAC-002", "IAC-031", "IAC-320"} {
+		reg.Register(id, image)
+	}
+	return reg
+}
+
+// Register sets the fixer for ruleID, replacing any earlier one.
+func (r *Registry) Register(ruleID string, f Fixer) {
+	r.fixers[ruleID] = f
+}
+
+// Fixer returns the fixer registered for ruleID.
+func (r *Registry) Fixer(ruleID string) (Fixer, bool) {
+	f, ok := r.fixers[ruleID]
+	return f, ok
+}
+
+// RuleIDs returns the IDs of rules with a fixer, sorted.
+func (r *Registry) RuleIDs() []string {
+	ids := make([]string, 0, len(r.fixers))
+	for id := range r.fixers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Patch is the set of edits for one file.
+type Patch struct {
+	// Path is the file path relative to the scan root, as in findings.
+	Path string
+	// RuleIDs are the rules whose findings the patch fixes, sorted.
+	RuleIDs []string
+	// Edits are sorted by line.
+	Edits []Edit
+
+	old, patched []byte
+}
+
+// Diff returns the patch as a unified diff.
+func (p *Patch) Diff() string {
+	return UnifiedDiff(p.Path, p.old, p.patched)
+}
+
+// Skipped records a finding with a fixer that produced no patch.
+type Skipped struct {
+	Finding findings.Finding
+	Err     error
+}
+
+// Plan runs the registered fixers over ff and returns one patch per file
+// that needs changes, sorted by path, together with the findings whose fixer
+// failed. Findings without a fixer and fixers returning ErrNoChange are left
+// out of both. When fixers for several findings edit the same line, the
+// first edit wins and conflicting later edits are dropped.
+func (r *Registry) Plan(ctx context.Context, root string, ff []findings.Finding) ([]*Patch, []Skipped) {
+	byPath := make(map[string][]findings.Finding)
+	var paths []string
+	for _, f := range ff {
+		if _, ok := r.fixers[f.RuleID]; !ok {
+			continue
+		}
+		if _, seen := byPath[f.Location.FilePath]; !seen {
+			paths = append(paths, f.Location.FilePath)
+		}
+		byPath[f.Location.FilePath] = append(byPath[f.Location.FilePath], f)
+	}
+	sort.Strings(paths)
+
+	var (
+		patches []*Patch
+		skipped []Skipped
+	)
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			for _, f := range byPath[path] {
+				skipped = append(skipped, Skipped{Finding: f, Err: err})
+			}
+			continue
+		}
+
+		edits := make(map[int]Edit)
+		rules := make(map[string]bool)
+		for _, f := range byPath[path] {
+			fe, err := r.fixers[f.RuleID].Fix(ctx, content, f)
+			if errors.Is(err, ErrNoChange) {
+				continue
+			}
+			if err != nil {
+				skipped = append(skipped, Skipped{Finding: f, Err: err})
+				continue
+			}
+			for _, e := range fe {
+				if prev, ok := edits[e.Line]; ok && prev.New != e.New {
+					continue
+				}
+				edits[e.Line] = e
+				rules[f.RuleID] = true
+			}
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		p := &Patch{Path: path, old: content}
+		for _, e := range edits {
+			p.Edits = append(p.Edits, e)
+		}
+		sort.Slice(p.Edits, func(i, j int) bool { return p.Edits[i].Line < p.Edits[j].Line })
+		for id := range rules {
+			p.RuleIDs = append(p.RuleIDs, id)
+		}
+		sort.Strings(p.RuleIDs)
+		if p.patched, err = applyEdits(content, p.Edits); err != nil {
+			for _, f := range byPath[path] {
+				skipped = append(skipped, Skipped{Finding: f, Err: err})
+			}
+			continue
+		}
+		patches = append(patches, p)
+	}
+	return patches, skipped
+}
+
+// Apply writes the patched content of each patch under root. A file that
+// changed since the patch was planned is left alone and reported in the
+// returned error.
+func Apply(root string, patches []*Patch) error {
+	var errs []error
+	for _, p := range patches {
+		path := filepath.Join(root, p.Path)
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		current, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !bytes.Equal(current, p.old) {
+			errs = append(errs, fmt.Errorf("%s changed since the fix was planned", p.Path))
+			continue
+		}
+		if err := os.WriteFile(path, p.patched, info.Mode().Perm()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lineAt returns the text of the 1-based line n without its line ending.
+func lineAt(content []byte, n int) (string, bool) {
+	raw := bytes.SplitAfter(content, []byte("\n"))
+	if n < 1 || n > len(raw) {
+		return "", false
+	}
+	return strings.TrimRight(string(raw[n-1]), "\r\n"), true
+}
+
+// applyEdits returns content with the edited lines replaced. Line endings
+// are preserved.
+func applyEdits(content []byte, edits []Edit) ([]byte, error) {
+	raw := bytes.SplitAfter(content, []byte("\n"))
+	for _, e := range edits {
+		if e.Line < 1 || e.Line > len(raw) {
+			return nil, fmt.Errorf("line %d out of range", e.Line)
+		}
+		line := raw[e.Line-1]
+		body := bytes.TrimRight(line, "\r\n")
+		if string(body) != e.Old {
+			return nil, fmt.Errorf("line %d does not match the fix", e.Line)
+		}
+		raw[e.Line-1] = append([]byte(e.New), line[len(body):]...)
+	}
+	return bytes.Join(raw, nil), nil
+}
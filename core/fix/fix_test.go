@@ -0,0 +1,220 @@
+package fix
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+const testSHA = "b4ffde65f46336ab88eb53be808477a3936bae11"
+
+type fakeResolver struct {
+	calls int
+}
+
+func (r *fakeResolver) ActionCommit(_ context.Context, repo, ref string) (string, error) {
+	r.calls++
+	if repo == "missing/action" {
+		return "", errors.New("not found")
+	}
+	return testSHA, nil
+}
+
+func (r *fakeResolver) ImageDigest(_ context.Context, image, tag string) (string, error) {
+	r.calls++
+	return "sha256:" + strings.Repeat("ab", 32), nil
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func finding(ruleID, path string, line int) findings.Finding {
+	return findings.Finding{RuleID: ruleID, Location: findings.Location{FilePath: path, StartLine: line}}
+}
+
+func TestPlan_ActionPin(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".github/workflows/ci.yml", `on: push
+jobs:
+  test:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: github/codeql-action/init@main # keep me
+      - uses: actions/setup-go@`+testSHA+` # v5
+      - uses: missing/action@v1
+`)
+	res := &fakeResolver{}
+	path := ".github/workflows/ci.yml"
+	patches, skipped := Builtin(res).Plan(context.Background(), dir, []findings.Finding{
+		finding("IAC-013", path, 5),
+		finding("IAC-013", path, 6),
+		finding("IAC-013", path, 7),
+		finding("IAC-013", path, 8),
+		finding("SEC-001", path, 1),
+	})
+
+	if len(skipped) != 1 || skipped[0].Finding.Location.StartLine != 8 {
+		t.Fatalf("expected line 8 to be skipped, got %+v", skipped)
+	}
+	if len(patches) != 1 {
+		t.Fatalf("expected 1 patch, got %d", len(patches))
+	}
+	p := patches[0]
+	want := []Edit{
+		{Line: 5, Old: "      - uses: actions/checkout@v4", New: "      - uses: actions/checkout@" + testSHA + " # v4"},
+		{Line: 6, Old: "      - uses: github/codeql-action/init@main # keep me", New: "      - uses: github/codeql-action/init@" + testSHA + " # keep me"},
+	}
+	if len(p.Edits) != len(want) {
+		t.Fatalf("edits = %+v", p.Edits)
+	}
+	for i := range want {
+		if p.Edits[i] != want[i] {
+			t.Errorf("edit %d = %+v, want %+v", i, p.Edits[i], want[i])
+		}
+	}
+	if strings.Join(p.RuleIDs, ",") != "IAC-013" {
+		t.Errorf("RuleIDs = %v", p.RuleIDs)
+	}
+}
+
+func TestPlan_ImageDigest(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Dockerfile", "FROM golang:1.22 AS build\r\nRUN go build\r\nFROM build AS test\r\nFROM --platform=linux/amd64 alpine\r\n")
+	writeFile(t, dir, "k8s/deploy.yaml", "containers:\n  - name: web\n    image: \"ghcr.io/org/web:latest\"\n")
+
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	patches, skipped := Builtin(&fakeResolver{}).Plan(context.Background(), dir, []findings.Finding{
+		finding("CONT-001", "Dockerfile", 1),
+		finding("IAC-002", "Dockerfile", 3),
+		finding("CONT-001", "Dockerfile", 4),
+		finding("CONT-002", "Dockerfile", 4),
+		finding("IAC-031", "k8s/deploy.yaml", 3),
+	})
+	if len(skipped) != 0 {
+		t.Fatalf("unexpected skipped: %+v", skipped)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d", len(patches))
+	}
+
+	docker := patches[0]
+	if docker.Path != "Dockerfile" || len(docker.Edits) != 2 {
+		t.Fatalf("Dockerfile patch = %+v", docker)
+	}
+	if got := docker.Edits[0].New; got != "FROM golang:1.22@"+digest+" AS build" {
+		t.Errorf("line 1 = %q", got)
+	}
+	if got := docker.Edits[1].New; got != "FROM --platform=linux/amd64 alpine:latest@"+digest {
+		t.Errorf("line 4 = %q", got)
+	}
+	if strings.Join(docker.RuleIDs, ",") != "CONT-001,CONT-002" {
+		t.Errorf("RuleIDs = %v", docker.RuleIDs)
+	}
+	if !strings.Contains(string(docker.patched), "AS build\r\nRUN") {
+		t.Error("CRLF line endings were not preserved")
+	}
+
+	if got := patches[1].Edits[0].New; got != `    image: "ghcr.io/org/web:latest@`+digest+`"` {
+		t.Errorf("k8s image = %q", got)
+	}
+}
+
+func TestPlan_AlreadyPinned(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Dockerfile", "FROM alpine:3.20@sha256:"+strings.Repeat("cd", 32)+"\nFROM ${BASE}\n")
+
+	res := &fakeResolver{}
+	patches, skipped := Builtin(res).Plan(context.Background(), dir, []findings.Finding{
+		finding("CONT-001", "Dockerfile", 1),
+		finding("CONT-001", "Dockerfile", 2),
+	})
+	if len(patches) != 0 || res.calls != 0 {
+		t.Fatalf("expected no patches or lookups, got %d patches, %d lookups", len(patches), res.calls)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Err.Error(), "variable") {
+		t.Fatalf("expected the ARG reference to be skipped, got %+v", skipped)
+	}
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a/Dockerfile", "FROM alpine\n")
+	writeFile(t, dir, "b/Dockerfile", "FROM alpine\n")
+
+	reg := Builtin(&fakeResolver{})
+	patches, _ := reg.Plan(context.Background(), dir, []findings.Finding{
+		finding("CONT-001", "a/Dockerfile", 1),
+		finding("CONT-001", "b/Dockerfile", 1),
+	})
+	writeFile(t, dir, "b/Dockerfile", "FROM alpine:3.20\n")
+
+	err := Apply(dir, patches)
+	if err == nil || !strings.Contains(err.Error(), "b/Dockerfile changed") {
+		t.Fatalf("expected an error for the changed file, got %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "a", "Dockerfile"))
+	if !strings.HasPrefix(string(got), "FROM alpine:latest@sha256:") {
+		t.Errorf("a/Dockerfile = %q", got)
+	}
+	got, _ = os.ReadFile(filepath.Join(dir, "b", "Dockerfile"))
+	if string(got) != "FROM alpine:3.20\n" {
+		t.Errorf("b/Dockerfile was modified: %q", got)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	var old, new []string
+	for i := 1; i <= 12; i++ {
+		l := "line " + string(rune('a'+i-1))
+		old = append(old, l)
+		switch i {
+		case 2:
+			new = append(new, "changed b")
+		case 11:
+			new = append(new, "changed k")
+		default:
+			new = append(new, l)
+		}
+	}
+	got := UnifiedDiff("f.txt", []byte(strings.Join(old, "\n")+"\n"), []byte(strings.Join(new, "\n")+"\n"))
+	want := `--- a/f.txt
++++ b/f.txt
+@@ -1,5 +1,5 @@
+ line a
+-line b
++changed b
+ line c
+ line d
+ line e
+@@ -8,5 +8,5 @@
+ line h
+ line i
+ line j
+-line k
++changed k
+ line l
+`
+	if got != want {
+		t.Errorf("diff mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	if d := UnifiedDiff("f.txt", []byte("same\n"), []byte("same\n")); d != "" {
+		t.Errorf("expected empty diff, got %q", d)
+	}
+	if d := UnifiedDiff("f.txt", []byte("a\nb\n"), []byte("a\nx\ny\nb\n")); !strings.Contains(d, "@@ -1,2 +1,4 @@\n a\n+x\n+y\n b\n") {
+		t.Errorf("insertion diff = %q", d)
+	}
+}
@@ -0,0 +1,102 @@
+package fix
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nox-hq/nox/core/dockerfile"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// actionUsesPattern matches a "uses: owner/repo[/path]@ref" step reference.
+// Groups: prefix up to the reference, owner/repo, optional path, ref, rest.
+var actionUsesPattern = regexp.MustCompile(`^(\s*(?:-\s+)?uses:\s*["']?)([\w.-]+/[\w.-]+)(/[^@\s"']*)?@([^\s"'#]+)(.*)$`)
+
+// actionPinFixer pins "uses: owner/repo@ref" to the commit ref points to and
+// keeps the ref as a trailing comment, the form Dependabot and Renovate
+// update.
+func actionPinFixer(r Resolver) Fixer {
+	return FixerFunc(func(ctx context.Context, content []byte, f findings.Finding) ([]Edit, error) {
+		line, ok := lineAt(content, f.Location.StartLine)
+		if !ok {
+			return nil, fmt.Errorf("line %d not found", f.Location.StartLine)
+		}
+		m := actionUsesPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("no action reference on line %d", f.Location.StartLine)
+		}
+		prefix, repo, path, ref, rest := m[1], m[2], m[3], m[4], m[5]
+		if shaPattern.MatchString(ref) {
+			return nil, ErrNoChange
+		}
+		sha, err := r.ActionCommit(ctx, repo, ref)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(rest, "#") {
+			rest = strings.TrimRight(rest, " ") + " # " + ref
+		}
+		return []Edit{{
+			Line: f.Location.StartLine,
+			Old:  line,
+			New:  prefix + repo + path + "@" + sha + rest,
+		}}, nil
+	})
+}
+
+// imageRefPattern finds the image reference of a Dockerfile FROM line, a
+// YAML "image:" key or a "uses: docker://" step. Groups: prefix, reference,
+// rest of the line.
+var imageRefPattern = regexp.MustCompile(`(?i)^(\s*FROM\s+(?:--\S+\s+)*|\s*(?:-\s+)?image:\s*["']?|\s*(?:-\s+)?uses:\s*["']?docker://)([^\s"'#]+)(.*)$`)
+
+// imageDigestFixer adds the digest its tag currently resolves to to a
+// container image reference, keeping the tag for readability:
+// "nginx:1.25" becomes "nginx:1.25@sha256:...". Untagged references are
+// resolved as "latest". References that use build arguments or variables
+// cannot be resolved and are skipped, as are FROM lines that name an
+// earlier build stage.
+func imageDigestFixer(r Resolver) Fixer {
+	return FixerFunc(func(ctx context.Context, content []byte, f findings.Finding) ([]Edit, error) {
+		line, ok := lineAt(content, f.Location.StartLine)
+		if !ok {
+			return nil, fmt.Errorf("line %d not found", f.Location.StartLine)
+		}
+		m := imageRefPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("no image reference on line %d", f.Location.StartLine)
+		}
+		prefix, ref, rest := m[1], m[2], m[3]
+		if strings.Contains(ref, "@") {
+			return nil, ErrNoChange
+		}
+		if strings.ContainsAny(ref, "${}") {
+			return nil, fmt.Errorf("image %s uses a variable", ref)
+		}
+		if strings.EqualFold(ref, "scratch") {
+			return nil, ErrNoChange
+		}
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(prefix)), "FROM") {
+			// FROM may name an earlier build stage rather than an image.
+			if df, err := dockerfile.Parse(content); err == nil {
+				if s := df.StageAt(f.Location.StartLine); s != nil && s.Line == f.Location.StartLine && s.Base >= 0 {
+					return nil, ErrNoChange
+				}
+			}
+		}
+		image, tag := ref, "latest"
+		if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+			image, tag = ref[:i], ref[i+1:]
+		}
+		digest, err := r.ImageDigest(ctx, image, tag)
+		if err != nil {
+			return nil, err
+		}
+		return []Edit{{
+			Line: f.Location.StartLine,
+			Old:  line,
+			New:  prefix + image + ":" + tag + "@" + digest + rest,
+		}}, nil
+	})
+}
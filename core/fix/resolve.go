@@ -0,0 +1,287 @@
+package fix
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver looks up the immutable identifiers that fixers pin references to.
+type Resolver interface {
+	// ActionCommit returns the full commit SHA that ref (a tag or branch)
+	// of the GitHub repository owner/repo points to.
+	ActionCommit(ctx context.Context, repo, ref string) (string, error)
+	// ImageDigest returns the "sha256:..." manifest digest that the tag of
+	// a container image currently resolves to. image is a reference without
+	// the tag, such as "nginx" or "ghcr.io/org/app".
+	ImageDigest(ctx context.Context, image, tag string) (string, error)
+}
+
+// ResolverOption configures an HTTPResolver.
+type ResolverOption func(*HTTPResolver)
+
+// WithHTTPClient sets the HTTP client used for all lookups.
+func WithHTTPClient(c *http.Client) ResolverOption {
+	return func(r *HTTPResolver) { r.client = c }
+}
+
+// WithGitHubBaseURL overrides the GitHub API base URL, for GitHub Enterprise
+// Server or tests.
+func WithGitHubBaseURL(u string) ResolverOption {
+	return func(r *HTTPResolver) { r.githubURL = strings.TrimRight(u, "/") }
+}
+
+// WithGitHubToken authenticates GitHub API requests, which raises the rate
+// limit and gives access to private action repositories.
+func WithGitHubToken(token string) ResolverOption {
+	return func(r *HTTPResolver) { r.githubToken = token }
+}
+
+// WithPlainHTTPRegistries makes the resolver talk to the given registry
+// hosts (host or host:port) over plain HTTP.
+func WithPlainHTTPRegistries(hosts ...string) ResolverOption {
+	return func(r *HTTPResolver) {
+		for _, h := range hosts {
+			r.plainHTTP[h] = true
+		}
+	}
+}
+
+// HTTPResolver resolves action refs with the GitHub REST API and image tags
+// with the OCI distribution API. Anonymous registry tokens are fetched when
+// a registry asks for them. Results are cached for the life of the resolver.
+type HTTPResolver struct {
+	client      *http.Client
+	githubURL   string
+	githubToken string
+	plainHTTP   map[string]bool
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewHTTPResolver returns a resolver for github.com and public registries.
+func NewHTTPResolver(opts ...ResolverOption) *HTTPResolver {
+	r := &HTTPResolver{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		githubURL: "https://api.github.com",
+		plainHTTP: make(map[string]bool),
+		cache:     make(map[string]string),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// ActionCommit implements Resolver.
+func (r *HTTPResolver) ActionCommit(ctx context.Context, repo, ref string) (string, error) {
+	return r.cached("action:"+repo+"@"+ref, func() (string, error) {
+		u := fmt.Sprintf("%s/repos/%s/commits/%s", r.githubURL, repo, url.PathEscape(ref))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Accept", "application/vnd.github.sha")
+		if r.githubToken != "" {
+			req.Header.Set("Authorization", "Bearer "+r.githubToken)
+		}
+		body, err := r.do(req)
+		if err != nil {
+			return "", fmt.Errorf("resolving %s@%s: %w", repo, ref, err)
+		}
+		sha := strings.TrimSpace(string(body))
+		if !shaPattern.MatchString(sha) {
+			return "", fmt.Errorf("resolving %s@%s: unexpected response %q", repo, ref, truncate(sha, 60))
+		}
+		return sha, nil
+	})
+}
+
+// manifestTypes are the manifest media types accepted from registries, index
+// types first so that multi-platform images resolve to their index digest.
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ImageDigest implements Resolver.
+func (r *HTTPResolver) ImageDigest(ctx context.Context, image, tag string) (string, error) {
+	return r.cached("image:"+image+":"+tag, func() (string, error) {
+		host, repo := splitRegistry(image)
+		scheme := "https"
+		if r.plainHTTP[host] {
+			scheme = "http"
+		}
+		u := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, repo, url.PathEscape(tag))
+
+		newReq := func(method, token string) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, u, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			return req, nil
+		}
+
+		token := ""
+		for attempt := 0; ; attempt++ {
+			req, err := newReq(http.MethodGet, token)
+			if err != nil {
+				return "", err
+			}
+			resp, err := r.client.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("resolving %s:%s: %w", image, tag, err)
+			}
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+			_ = resp.Body.Close()
+
+			if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+				token, err = r.registryToken(ctx, resp.Header.Get("WWW-Authenticate"))
+				if err != nil {
+					return "", fmt.Errorf("resolving %s:%s: %w", image, tag, err)
+				}
+				continue
+			}
+			if resp.StatusCode != http.StatusOK {
+				return "", fmt.Errorf("resolving %s:%s: registry returned status %d", image, tag, resp.StatusCode)
+			}
+			if d := resp.Header.Get("Docker-Content-Digest"); strings.HasPrefix(d, "sha256:") {
+				return d, nil
+			}
+			sum := sha256.Sum256(body)
+			return "sha256:" + hex.EncodeToString(sum[:]), nil
+		}
+	})
+}
+
+// registryToken fetches an anonymous bearer token as described by a
+// WWW-Authenticate challenge.
+func (r *HTTPResolver) registryToken(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("registry requires %s authentication", scheme)
+	}
+	attrs := parseChallenge(params)
+	realm := attrs["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry challenge has no realm")
+	}
+	q := url.Values{}
+	for _, k := range []string{"service", "scope"} {
+		if v := attrs[k]; v != "" {
+			q.Set(k, v)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	body, err := r.do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching registry token: %w", err)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("decoding registry token: %w", err)
+	}
+	if tok.Token != "" {
+		return tok.Token, nil
+	}
+	return tok.AccessToken, nil
+}
+
+// parseChallenge parses the comma-separated key="value" parameters of a
+// WWW-Authenticate header.
+func parseChallenge(s string) map[string]string {
+	attrs := make(map[string]string)
+	for s != "" {
+		var key, value string
+		key, s, _ = strings.Cut(strings.TrimLeft(s, " ,"), "=")
+		if strings.HasPrefix(s, `"`) {
+			value, s, _ = strings.Cut(s[1:], `"`)
+		} else {
+			value, s, _ = strings.Cut(s, ",")
+		}
+		attrs[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return attrs
+}
+
+// splitRegistry splits an image reference without tag into the registry
+// host and the repository path, applying Docker Hub defaults.
+func splitRegistry(image string) (host, repo string) {
+	first, rest, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		host, repo = first, rest
+	} else {
+		host, repo = "docker.io", image
+	}
+	if host == "docker.io" || host == "index.docker.io" {
+		host = "registry-1.docker.io"
+		if !strings.Contains(repo, "/") {
+			repo = "library/" + repo
+		}
+	}
+	return host, repo
+}
+
+func (r *HTTPResolver) do(req *http.Request) ([]byte, error) {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+	return body, nil
+}
+
+func (r *HTTPResolver) cached(key string, resolve func() (string, error)) (string, error) {
+	r.mu.Lock()
+	v, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+	v, err := resolve()
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	r.cache[key] = v
+	r.mu.Unlock()
+	return v, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
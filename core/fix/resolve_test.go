@@ -0,0 +1,94 @@
+package fix
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPResolver_ActionCommit(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/repos/actions/checkout/commits/v4" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Accept") != "application/vnd.github.sha" || r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		fmt.Fprint(w, testSHA)
+	}))
+	defer srv.Close()
+
+	r := NewHTTPResolver(WithGitHubBaseURL(srv.URL), WithGitHubToken("tok"))
+	for range 2 {
+		sha, err := r.ActionCommit(context.Background(), "actions/checkout", "v4")
+		if err != nil || sha != testSHA {
+			t.Fatalf("ActionCommit = %q, %v", sha, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the second lookup to be cached, got %d requests", requests)
+	}
+	if _, err := r.ActionCommit(context.Background(), "actions/checkout", "v0"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
+
+func TestHTTPResolver_ImageDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ef", 32)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.URL.Query().Get("scope") != "repository:org/web:pull" {
+				t.Errorf("scope = %q", r.URL.Query().Get("scope"))
+			}
+			fmt.Fprint(w, `{"token":"anon"}`)
+		case "/v2/org/web/manifests/1.0":
+			if r.Header.Get("Authorization") != "Bearer anon" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:org/web:pull"`, srv.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+				t.Errorf("Accept = %q", r.Header.Get("Accept"))
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	r := NewHTTPResolver(WithPlainHTTPRegistries(host))
+	got, err := r.ImageDigest(context.Background(), host+"/org/web", "1.0")
+	if err != nil || got != digest {
+		t.Fatalf("ImageDigest = %q, %v", got, err)
+	}
+	if _, err := r.ImageDigest(context.Background(), host+"/org/web", "2.0"); err == nil {
+		t.Error("expected an error for an unknown tag")
+	}
+}
+
+func TestSplitRegistry(t *testing.T) {
+	tests := []struct{ image, host, repo string }{
+		{"nginx", "registry-1.docker.io", "library/nginx"},
+		{"bitnami/redis", "registry-1.docker.io", "bitnami/redis"},
+		{"docker.io/library/alpine", "registry-1.docker.io", "library/alpine"},
+		{"ghcr.io/org/app", "ghcr.io", "org/app"},
+		{"localhost:5000/app", "localhost:5000", "app"},
+	}
+	for _, tt := range tests {
+		host, repo := splitRegistry(tt.image)
+		if host != tt.host || repo != tt.repo {
+			t.Errorf("splitRegistry(%q) = %q, %q; want %q, %q", tt.image, host, repo, tt.host, tt.repo)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uncommittedSHA is the commit git blame reports for lines that are not
+// committed yet.
+const uncommittedSHA = "0000000000000000000000000000000000000000"
+
+// LineRange is an inclusive, 1-based range of lines.
+type LineRange struct {
+	Start, End int
+}
+
+// BlameLine is the commit that last changed a line according to git blame.
+type BlameLine struct {
+	Commit CommitInfo
+	// Boundary is true when blame stopped at the commit because it could
+	// not look further back, as at the edge of a shallow clone. The commit
+	// may then not be the one that introduced the line.
+	Boundary bool
+}
+
+// Committed reports whether the line is committed. Lines changed in the
+// working tree are blamed on no commit.
+func (b BlameLine) Committed() bool {
+	return b.Commit.SHA != "" && b.Commit.SHA != uncommittedSHA
+}
+
+// Blame runs a single git blame over the given line ranges of path, which
+// is relative to dir, and returns the blame of each line keyed by line
+// number. Root commits, including the grafted first commit of a shallow
+// clone, are reported as boundaries.
+func Blame(dir, path string, ranges []LineRange) (map[int]BlameLine, error) {
+	args := []string{"blame", "--porcelain"}
+	for _, r := range ranges {
+		end := max(r.End, r.Start)
+		args = append(args, "-L", fmt.Sprintf("%d,%d", r.Start, end))
+	}
+	args = append(args, "--", path)
+	out, err := runGit(dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s: %w", path, err)
+	}
+	return parseBlamePorcelain(out), nil
+}
+
+// parseBlamePorcelain parses git blame --porcelain output. Commit details
+// are printed only the first time a commit appears, so they are collected
+// per commit and resolved once the whole output is read.
+func parseBlamePorcelain(out string) map[int]BlameLine {
+	commits := make(map[string]*BlameLine)
+	lines := make(map[int]string)
+
+	var current *BlameLine
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		if len(key) == 40 && isHex(key) {
+			fields := strings.Fields(value)
+			if len(fields) < 2 {
+				continue
+			}
+			final, err := strconv.Atoi(fields[1])
+			if err != nil {
+				continue
+			}
+			lines[final] = key
+			if commits[key] == nil {
+				commits[key] = &BlameLine{Commit: CommitInfo{SHA: key}}
+			}
+			current = commits[key]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			current.Commit.Author = value
+		case "author-mail":
+			current.Commit.Email = strings.Trim(value, "<>")
+		case "author-time":
+			if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.Commit.Date = time.Unix(sec, 0).UTC()
+			}
+		case "summary":
+			current.Commit.Message = value
+		case "boundary":
+			current.Boundary = true
+		}
+	}
+
+	result := make(map[int]BlameLine, len(lines))
+	for n, sha := range lines {
+		result[n] = *commits[sha]
+	}
+	return result
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// IsShallow reports whether the repository containing dir is a shallow
+// clone.
+func IsShallow(dir string) bool {
+	out, err := runGit(dir, "rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// TrackedFiles returns the paths, relative to dir, of the files git tracks
+// under dir.
+func TrackedFiles(dir string) ([]string, error) {
+	out, err := runGit(dir, "ls-files", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	var paths []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBlame(t *testing.T) {
+	dir := setupGitRepo(t)
+	writeFile(t, filepath.Join(dir, "app.env"), "A=1\nB=2\nC=3\n")
+	run(t, dir, "git", "add", "app.env")
+	run(t, dir, "git", "commit", "-m", "add app.env")
+	writeFile(t, filepath.Join(dir, "app.env"), "A=1\nB=changed\nC=3\n")
+
+	blame, err := Blame(dir, "app.env", []LineRange{{Start: 1, End: 1}, {Start: 2, End: 3}})
+	if err != nil {
+		t.Fatalf("Blame: %v", err)
+	}
+	if len(blame) != 3 {
+		t.Fatalf("expected 3 blamed lines, got %v", blame)
+	}
+	first := blame[1]
+	if !first.Committed() || first.Commit.Author != "Test" || first.Commit.Email != "test@test.com" ||
+		first.Commit.Message != "add app.env" || first.Commit.Date.IsZero() || first.Boundary {
+		t.Errorf("line 1 = %+v", first)
+	}
+	if blame[3].Commit.SHA != first.Commit.SHA {
+		t.Errorf("line 3 commit = %s, want %s", blame[3].Commit.SHA, first.Commit.SHA)
+	}
+	if blame[2].Committed() {
+		t.Errorf("expected the modified line 2 to be uncommitted, got %+v", blame[2])
+	}
+
+	if _, err := Blame(dir, "missing.env", []LineRange{{Start: 1, End: 1}}); err == nil {
+		t.Error("expected an error for an untracked file")
+	}
+}
+
+func TestTrackedFilesAndIsShallow(t *testing.T) {
+	dir := setupGitRepo(t)
+	writeFile(t, filepath.Join(dir, "a b.txt"), "x")
+	run(t, dir, "git", "add", ".")
+	run(t, dir, "git", "commit", "-m", "add file")
+	writeFile(t, filepath.Join(dir, "untracked.txt"), "y")
+
+	files, err := TrackedFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0] != "README.md" || files[1] != "a b.txt" {
+		t.Errorf("TrackedFiles() = %q", files)
+	}
+	if IsShallow(dir) {
+		t.Error("expected a full clone not to be shallow")
+	}
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	run(t, dir, "git", "clone", "-q", "--depth", "1", "file://"+dir, clone)
+	if !IsShallow(clone) {
+		t.Error("expected a --depth 1 clone to be shallow")
+	}
+	blame, err := Blame(clone, "README.md", []LineRange{{Start: 1, End: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !blame[1].Boundary {
+		t.Errorf("expected the shallow clone's only commit to be a boundary, got %+v", blame[1])
+	}
+}
@@ -0,0 +1,270 @@
+package git
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Remote is a repository to clone: its URL and the ref to check out.
+type Remote struct {
+	URL string
+	// Ref is the branch, tag or commit to check out. Empty means the
+	// remote's default branch.
+	Ref string
+}
+
+// shorthandRe matches "owner/repo", the GitHub shorthand for a repository.
+var shorthandRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+$`)
+
+// scpRe matches scp-style SSH addresses such as git@github.com:org/repo.git.
+var scpRe = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/\\]`)
+
+// ParseRemote reports whether target names a remote repository and parses
+// it. Accepted are https, http, ssh, git and file URLs, scp-style SSH
+// addresses such as git@github.com:org/repo.git, and "owner/repo" shorthand
+// for a GitHub repository. Any of them may end in "@ref" to select a branch,
+// tag or commit. A URL or ref that starts with "-" is rejected, since git
+// would read it as an option. The shorthand is ambiguous with a relative
+// path, so callers should try target as a local path first.
+func ParseRemote(target string) (Remote, bool) {
+	s := strings.TrimSpace(target)
+	// A ref follows the first "@" in the repository path, which leaves the
+	// user of ssh://git@host/... and git@host:... alone and lets the ref
+	// contain slashes.
+	start := 0
+	if i := strings.Index(s, "://"); i >= 0 {
+		start = i + len("://")
+		if j := strings.IndexByte(s[start:], '/'); j >= 0 {
+			start += j
+		} else {
+			start = len(s)
+		}
+	} else if scpRe.MatchString(s) {
+		start = strings.IndexByte(s, ':')
+	}
+	var ref string
+	if at := strings.IndexByte(s[start:], '@'); at >= 0 {
+		s, ref = s[:start+at], s[start+at+1:]
+	}
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(ref, "-") {
+		return Remote{}, false
+	}
+
+	switch {
+	case scpRe.MatchString(s):
+		return Remote{URL: s, Ref: ref}, true
+	case shorthandRe.MatchString(s):
+		return Remote{URL: "https://github.com/" + strings.TrimSuffix(s, ".git") + ".git", Ref: ref}, true
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Path == "" {
+		return Remote{}, false
+	}
+	switch u.Scheme {
+	case "https", "http", "ssh", "git":
+		if u.Host == "" {
+			return Remote{}, false
+		}
+	case "file":
+	default:
+		return Remote{}, false
+	}
+	return Remote{URL: s, Ref: ref}, true
+}
+
+// String returns the URL and ref of r with any password or token in the
+// URL removed, for reports and logs.
+func (r Remote) String() string {
+	s := r.URL
+	if u, err := url.Parse(s); err == nil && u.User != nil && (u.Scheme == "https" || u.Scheme == "http") {
+		u.User = nil
+		s = u.String()
+	}
+	if r.Ref != "" {
+		s += "@" + r.Ref
+	}
+	return s
+}
+
+// RepoName returns the host and path of the repository at remoteURL, such
+// as "github.com/org/repo", for any form ParseRemote accepts. Credentials,
+// ports and a ".git" suffix are left out.
+func RepoName(remoteURL string) string {
+	s := remoteURL
+	if r, ok := ParseRemote(s); ok {
+		s = r.URL
+	}
+	if scpRe.MatchString(s) {
+		// git@host:path is ssh://git@host/path.
+		at := strings.IndexByte(s, '@')
+		colon := strings.IndexByte(s, ':')
+		s = s[at+1:colon] + "/" + s[colon+1:]
+	} else if u, err := url.Parse(s); err == nil && u.Scheme != "" {
+		s = u.Hostname() + u.Path
+	}
+	return strings.TrimSuffix(strings.Trim(s, "/"), ".git")
+}
+
+// CloneOptions configures Clone.
+type CloneOptions struct {
+	// Token authenticates HTTPS fetches. Without one, git's own
+	// credential helpers and SSH keys are used.
+	Token string
+	// MaxSize is the most the fetched objects may take on disk, in bytes.
+	// The fetch is stopped once it is exceeded. Zero means no limit.
+	MaxSize int64
+}
+
+// ErrTokenOverHTTP is returned by Clone when a token is given for a plain
+// http URL, over which it would be sent unencrypted.
+var ErrTokenOverHTTP = errors.New("refusing to send the git token over plain http")
+
+// ErrCloneTooLarge is returned by Clone when the repository exceeds
+// CloneOptions.MaxSize.
+var ErrCloneTooLarge = errors.New("repository exceeds the clone size limit")
+
+// cloneSizePoll is how often Clone measures a fetch in progress.
+const cloneSizePoll = 200 * time.Millisecond
+
+// Clone fetches the single commit r.Ref points to into a bare repository in
+// dir and checks its files out into a directory in dir, which it returns
+// together with the commit SHA. The checkout is not a git repository. Git
+// never prompts for credentials, and symbolic links are checked out as
+// plain files so that a scan of an untrusted repository cannot follow them
+// out of the checkout.
+func Clone(ctx context.Context, r Remote, dir string, opts CloneOptions) (worktree, sha string, err error) {
+	gitDir := filepath.Join(dir, "repo.git")
+	worktree = filepath.Join(dir, "src")
+	env, err := cloneEnv(r.URL, opts.Token)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := runCloneGit(ctx, env, "", "init", "--quiet", "--bare", gitDir); err != nil {
+		return "", "", fmt.Errorf("git init: %w", err)
+	}
+
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var tooLarge atomic.Bool
+	if opts.MaxSize > 0 {
+		go func() {
+			tick := time.NewTicker(cloneSizePoll)
+			defer tick.Stop()
+			for {
+				select {
+				case <-fetchCtx.Done():
+					return
+				case <-tick.C:
+					if dirSize(gitDir) > opts.MaxSize {
+						tooLarge.Store(true)
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+	}
+	_, err = runCloneGit(fetchCtx, env, gitDir, "fetch", "--quiet", "--depth", "1", "--no-tags", "--", r.URL, ref)
+	cancel()
+	if tooLarge.Load() || (err == nil && opts.MaxSize > 0 && dirSize(gitDir) > opts.MaxSize) {
+		return "", "", fmt.Errorf("%w of %d bytes", ErrCloneTooLarge, opts.MaxSize)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("git fetch %s: %w", r, err)
+	}
+
+	out, err := runCloneGit(ctx, env, gitDir, "rev-parse", "FETCH_HEAD^{commit}")
+	if err != nil {
+		return "", "", fmt.Errorf("git rev-parse FETCH_HEAD: %w", err)
+	}
+	sha = strings.TrimSpace(out)
+
+	if err := os.MkdirAll(worktree, 0o755); err != nil {
+		return "", "", err
+	}
+	checkout := []string{"-c", "core.symlinks=false", "--work-tree", worktree}
+	if _, err := runCloneGit(ctx, env, gitDir, append(checkout, "read-tree", sha)...); err != nil {
+		return "", "", fmt.Errorf("git read-tree: %w", err)
+	}
+	if _, err := runCloneGit(ctx, env, gitDir, append(checkout, "checkout-index", "--all", "--force")...); err != nil {
+		return "", "", fmt.Errorf("git checkout-index: %w", err)
+	}
+	return worktree, sha, nil
+}
+
+// cloneEnv returns the environment for the git commands of Clone. A token
+// is passed as an Authorization header through GIT_CONFIG_* variables,
+// which keeps it out of the process list. The header is scoped to the
+// origin of remoteURL with an http.<url>.extraHeader key, so that git does
+// not send it to submodules or other hosts. It is only set for https URLs:
+// ssh, git and file URLs do not use it, and a plain http URL is an error.
+func cloneEnv(remoteURL, token string) ([]string, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if token == "" {
+		return env, nil
+	}
+	u, err := url.Parse(remoteURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return env, nil
+	}
+	if u.Scheme == "http" {
+		return nil, fmt.Errorf("%w: %s", ErrTokenOverHTTP, Remote{URL: remoteURL})
+	}
+	n, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+	origin := "https://" + u.Host + "/"
+	return append(env,
+		"GIT_CONFIG_COUNT="+strconv.Itoa(n+1),
+		"GIT_CONFIG_KEY_"+strconv.Itoa(n)+"=http."+origin+".extraHeader",
+		"GIT_CONFIG_VALUE_"+strconv.Itoa(n)+"=Authorization: Basic "+auth,
+	), nil
+}
+
+// runCloneGit runs git with env against the repository gitDir, if any.
+func runCloneGit(ctx context.Context, env []string, gitDir string, args ...string) (string, error) {
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
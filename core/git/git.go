@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // ChangedFiles returns the list of files changed between base and head refs.
@@ -18,6 +21,76 @@ func ChangedFiles(repoRoot, base, head string) ([]string, error) {
 	return splitLines(out), nil
 }
 
+// DiffNames returns the paths, relative to dir, of the files git diff
+// lists for spec: one or more space-separated revisions such as
+// "origin/main...HEAD" or "main", as given to git diff.
+func DiffNames(dir, spec string) ([]string, error) {
+	args := append([]string{"diff", "--name-only", "--relative"}, strings.Fields(spec)...)
+	out, err := runGit(dir, append(args, "--")...)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	return splitLines(out), nil
+}
+
+// FileChange is a file added, modified, deleted or renamed between two refs.
+type FileChange struct {
+	// Status is the git status letter: A, C, D, M, R, T or U.
+	Status string
+	// Path is the path at the head ref, or the deleted path for status D.
+	Path string
+	// OldPath is the path at the base ref of a renamed or copied file.
+	OldPath string
+	// Similarity is the similarity index, 0 to 100, of a renamed or
+	// copied file to its original.
+	Similarity int
+}
+
+// DiffFiles returns the files changed between the merge base of base and
+// head and head, with paths relative to the repository root. With renames
+// set, git's rename detection pairs deleted and added files that are
+// similar enough into a single R change; otherwise a renamed file is a D
+// and an A change.
+func DiffFiles(repoRoot, base, head string, renames bool) ([]FileChange, error) {
+	args := []string{"diff", "--name-status", "-z"}
+	if renames {
+		args = append(args, "-M")
+	} else {
+		args = append(args, "--no-renames")
+	}
+	out, err := runGit(repoRoot, append(args, base+"..."+head)...)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	var changes []FileChange
+	for i := 0; i+1 < len(fields); i += 2 {
+		c := FileChange{Status: fields[i][:1], Path: fields[i+1]}
+		if c.Status == "R" || c.Status == "C" {
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("git diff: truncated rename of %s", fields[i+1])
+			}
+			c.Similarity, _ = strconv.Atoi(fields[i][1:])
+			c.OldPath, c.Path = fields[i+1], fields[i+2]
+			i++
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// FileAt returns the content of path, relative to the repository root, at
+// ref.
+func FileAt(repoRoot, ref, path string) ([]byte, error) {
+	cmd := exec.Command("git", "show", ref+":"+path)
+	cmd.Dir = repoRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s:%s: %w", ref, path, err)
+	}
+	return out, nil
+}
+
 // IsGitRepo returns true if path is inside a git repository.
 func IsGitRepo(path string) bool {
 	cmd := exec.Command("git", "-C", path, "rev-parse", "--is-inside-work-tree")
@@ -35,6 +108,16 @@ func RepoRoot(path string) (string, error) {
 	return filepath.Clean(root), nil
 }
 
+// GitDir returns the absolute path of the git directory of the repository
+// at path, which may be bare.
+func GitDir(path string) (string, error) {
+	out, err := runGit(path, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse --absolute-git-dir: %w", err)
+	}
+	return filepath.Clean(strings.TrimSpace(out)), nil
+}
+
 // CurrentBranch returns the current branch name.
 func CurrentBranch(repoRoot string) (string, error) {
 	out, err := runGit(repoRoot, "rev-parse", "--abbrev-ref", "HEAD")
@@ -44,6 +127,44 @@ func CurrentBranch(repoRoot string) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// HeadCommit returns the full SHA of the commit HEAD points to.
+func HeadCommit(repoRoot string) (string, error) {
+	out, err := runGit(repoRoot, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse HEAD: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ResolveCommit returns the full SHA of the commit ref names.
+func ResolveCommit(repoRoot, ref string) (string, error) {
+	out, err := runGit(repoRoot, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a commit", ref)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Describe returns the most recent tag reachable from HEAD as
+// "git describe --tags" prints it, such as "v1.2.0" or "v1.2.0-3-gabc1234"
+// for a commit after the tag. It fails when no tag is reachable.
+func Describe(repoRoot string) (string, error) {
+	out, err := runGit(repoRoot, "describe", "--tags")
+	if err != nil {
+		return "", fmt.Errorf("git describe: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// RemoteURL returns the URL of the named remote, such as "origin".
+func RemoteURL(repoRoot, name string) (string, error) {
+	out, err := runGit(repoRoot, "remote", "get-url", name)
+	if err != nil {
+		return "", fmt.Errorf("git remote get-url %s: %w", name, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
 // MergeBase returns the best common ancestor between two refs.
 func MergeBase(repoRoot, ref1, ref2 string) (string, error) {
 	out, err := runGit(repoRoot, "merge-base", ref1, ref2)
@@ -75,6 +196,84 @@ func StagedContent(repoRoot, path string) ([]byte, error) {
 	return []byte(out), nil
 }
 
+// Add stages paths, which may be absolute or relative to repoRoot.
+func Add(repoRoot string, paths ...string) error {
+	args := append([]string{"add", "--"}, paths...)
+	if _, err := runGit(repoRoot, args...); err != nil {
+		return fmt.Errorf("git add: %w", err)
+	}
+	return nil
+}
+
+// UnstagedFiles returns the paths, relative to the repository root, of files
+// whose working tree content is not in the index: tracked files with
+// unstaged modifications and untracked files that are not ignored.
+func UnstagedFiles(repoRoot string) ([]string, error) {
+	modified, err := runGit(repoRoot, "diff", "--name-only")
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	untracked, err := runGit(repoRoot, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	return append(splitLines(modified), splitLines(untracked)...), nil
+}
+
+// UntrackedFiles returns those of paths, relative to dir, that are neither
+// tracked nor ignored by git.
+func UntrackedFiles(dir string, paths ...string) ([]string, error) {
+	args := append([]string{"ls-files", "--others", "--exclude-standard", "--"}, paths...)
+	out, err := runGit(dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	return splitLines(out), nil
+}
+
+// FilesChangedSince returns the paths, relative to dir, of files under dir
+// changed by the commits in ref..HEAD, together with files that have
+// uncommitted changes and untracked files that are not ignored.
+func FilesChangedSince(dir, ref string) ([]string, error) {
+	return filesChanged(dir, ref+"..HEAD")
+}
+
+// FilesChangedAfter is like FilesChangedSince but takes the commits
+// reachable from HEAD that were committed after t.
+func FilesChangedAfter(dir string, t time.Time) ([]string, error) {
+	return filesChanged(dir, "--since="+t.UTC().Format(time.RFC3339), "HEAD")
+}
+
+// filesChanged lists the files changed by the commits git log selects with
+// logArgs, and the uncommitted and untracked files, relative to dir.
+func filesChanged(dir string, logArgs ...string) ([]string, error) {
+	args := append([]string{"log", "--name-only", "--relative", "--format="}, logArgs...)
+	committed, err := runGit(dir, append(args, "--", ".")...)
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	modified, err := runGit(dir, "diff", "--name-only", "--relative", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	untracked, err := runGit(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, out := range []string{committed, modified, untracked} {
+		for _, p := range splitLines(out) {
+			if p = strings.TrimSpace(p); p != "" && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
 func runGit(dir string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
@@ -4,7 +4,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestIsGitRepo_True(t *testing.T) {
@@ -48,6 +50,32 @@ func TestCurrentBranch(t *testing.T) {
 	}
 }
 
+func TestHeadCommit(t *testing.T) {
+	dir := setupGitRepo(t)
+	sha, err := HeadCommit(dir)
+	if err != nil {
+		t.Fatalf("HeadCommit: %v", err)
+	}
+	if len(sha) != 40 {
+		t.Fatalf("expected a 40-character SHA, got %q", sha)
+	}
+
+	if _, err := HeadCommit(t.TempDir()); err == nil {
+		t.Fatal("expected an error outside a git repository")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	dir := setupGitRepo(t)
+	if _, err := Describe(dir); err == nil {
+		t.Fatal("expected an error without a tag")
+	}
+	run(t, dir, "git", "tag", "v0.3.0")
+	if got, err := Describe(dir); err != nil || got != "v0.3.0" {
+		t.Fatalf("Describe = %q, %v; want v0.3.0", got, err)
+	}
+}
+
 func TestChangedFiles(t *testing.T) {
 	dir := setupGitRepo(t)
 
@@ -80,6 +108,126 @@ func TestChangedFiles_NoChanges(t *testing.T) {
 	}
 }
 
+func TestDiffNames(t *testing.T) {
+	dir := setupGitRepo(t)
+	run(t, dir, "git", "checkout", "-b", "feature")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "sub", "a.txt"), "a")
+	run(t, dir, "git", "add", ".")
+	run(t, dir, "git", "commit", "-m", "add a.txt")
+	writeFile(t, filepath.Join(dir, "README.md"), "# Changed")
+
+	got, err := DiffNames(dir, "main...HEAD")
+	if err != nil {
+		t.Fatalf("DiffNames: %v", err)
+	}
+	if want := []string{"sub/a.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffNames(main...HEAD) = %v, want %v", got, want)
+	}
+	// A single revision compares it with the working tree.
+	got, err = DiffNames(dir, "main")
+	if err != nil {
+		t.Fatalf("DiffNames: %v", err)
+	}
+	if want := []string{"README.md", "sub/a.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffNames(main) = %v, want %v", got, want)
+	}
+	if _, err := DiffNames(dir, "no-such-ref"); err == nil {
+		t.Error("DiffNames of an unknown ref succeeded")
+	}
+}
+
+func TestFilesChangedSince(t *testing.T) {
+	dir := setupGitRepo(t)
+	run(t, dir, "git", "tag", "v1")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "sub", "a.txt"), "a")
+	run(t, dir, "git", "add", ".")
+	run(t, dir, "git", "commit", "-m", "add a.txt")
+	writeFile(t, filepath.Join(dir, "README.md"), "# Changed")
+	writeFile(t, filepath.Join(dir, "sub", "untracked.txt"), "u")
+
+	got, err := FilesChangedSince(dir, "v1")
+	if err != nil {
+		t.Fatalf("FilesChangedSince: %v", err)
+	}
+	if want := []string{"README.md", "sub/a.txt", "sub/untracked.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilesChangedSince = %v, want %v", got, want)
+	}
+
+	// Paths are relative to dir and limited to it.
+	got, err = FilesChangedSince(filepath.Join(dir, "sub"), "v1")
+	if err != nil {
+		t.Fatalf("FilesChangedSince in sub: %v", err)
+	}
+	if want := []string{"a.txt", "untracked.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilesChangedSince in sub = %v, want %v", got, want)
+	}
+
+	if _, err := FilesChangedSince(dir, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
+
+func TestFilesChangedAfter(t *testing.T) {
+	t.Setenv("GIT_COMMITTER_DATE", "2020-01-01T00:00:00Z")
+	dir := setupGitRepo(t)
+	t.Setenv("GIT_COMMITTER_DATE", "")
+	writeFile(t, filepath.Join(dir, "new.txt"), "new")
+	run(t, dir, "git", "add", "new.txt")
+	run(t, dir, "git", "commit", "-m", "add new.txt")
+
+	got, err := FilesChangedAfter(dir, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FilesChangedAfter: %v", err)
+	}
+	if want := []string{"new.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilesChangedAfter = %v, want %v", got, want)
+	}
+}
+
+func TestDiffFiles_Renames(t *testing.T) {
+	dir := setupGitRepo(t)
+	content := "line one\nline two\nline three\nline four\n"
+	writeFile(t, filepath.Join(dir, "old.txt"), content)
+	run(t, dir, "git", "add", "old.txt")
+	run(t, dir, "git", "commit", "-m", "add old.txt")
+
+	run(t, dir, "git", "checkout", "-b", "feature")
+	run(t, dir, "git", "mv", "old.txt", "new.txt")
+	writeFile(t, filepath.Join(dir, "new.txt"), content+"line five\n")
+	run(t, dir, "git", "commit", "-am", "rename")
+
+	changes, err := DiffFiles(dir, "main", "feature", true)
+	if err != nil {
+		t.Fatalf("DiffFiles: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Status != "R" || changes[0].OldPath != "old.txt" || changes[0].Path != "new.txt" || changes[0].Similarity < 50 {
+		t.Fatalf("expected a rename of old.txt to new.txt, got %+v", changes)
+	}
+
+	changes, err = DiffFiles(dir, "main", "feature", false)
+	if err != nil {
+		t.Fatalf("DiffFiles: %v", err)
+	}
+	want := []FileChange{{Status: "A", Path: "new.txt"}, {Status: "D", Path: "old.txt"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("without renames: got %+v, want %+v", changes, want)
+	}
+
+	data, err := FileAt(dir, "main", "old.txt")
+	if err != nil || string(data) != content {
+		t.Fatalf("FileAt(main, old.txt) = %q, %v", data, err)
+	}
+	if _, err := FileAt(dir, "feature", "old.txt"); err == nil {
+		t.Fatal("expected an error for a path missing at the ref")
+	}
+}
+
 func TestMergeBase(t *testing.T) {
 	dir := setupGitRepo(t)
 
@@ -131,6 +279,39 @@ func TestStagedFiles_NoStaged(t *testing.T) {
 	}
 }
 
+func TestUnstagedFiles(t *testing.T) {
+	dir := setupGitRepo(t)
+
+	writeFile(t, filepath.Join(dir, "staged.txt"), "staged")
+	run(t, dir, "git", "add", "staged.txt")
+	writeFile(t, filepath.Join(dir, "README.md"), "# Changed")
+	writeFile(t, filepath.Join(dir, "new.txt"), "new")
+
+	files, err := UnstagedFiles(dir)
+	if err != nil {
+		t.Fatalf("UnstagedFiles: %v", err)
+	}
+	if len(files) != 2 || files[0] != "README.md" || files[1] != "new.txt" {
+		t.Fatalf("expected [README.md new.txt], got %v", files)
+	}
+}
+
+func TestUntrackedFiles(t *testing.T) {
+	dir := setupGitRepo(t)
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), "ignored.json\n")
+	writeFile(t, filepath.Join(dir, "ignored.json"), "{}")
+	writeFile(t, filepath.Join(dir, "new.json"), "{}")
+
+	files, err := UntrackedFiles(dir, "README.md", "ignored.json", "new.json")
+	if err != nil {
+		t.Fatalf("UntrackedFiles: %v", err)
+	}
+	if len(files) != 1 || files[0] != "new.json" {
+		t.Fatalf("expected [new.json], got %v", files)
+	}
+}
+
 func TestStagedContent(t *testing.T) {
 	dir := setupGitRepo(t)
 
@@ -0,0 +1,329 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RefUpdate is one line of the standard input of a pre-receive hook: the
+// object name a ref had before the push, the one it will have after, and
+// the ref's name. A side of the update on which the ref does not exist is
+// all zeros.
+type RefUpdate struct {
+	Old string
+	New string
+	Ref string
+}
+
+// Creates reports whether the update creates the ref.
+func (u RefUpdate) Creates() bool { return isZeroOID(u.Old) }
+
+// Deletes reports whether the update deletes the ref.
+func (u RefUpdate) Deletes() bool { return isZeroOID(u.New) }
+
+// isZeroOID reports whether oid is the all-zeros object name, of either
+// SHA-1 or SHA-256 length, that git uses for a missing side of an update.
+func isZeroOID(oid string) bool {
+	return oid != "" && strings.Trim(oid, "0") == ""
+}
+
+// isObjectName reports whether s is a full SHA-1 or SHA-256 object name.
+func isObjectName(s string) bool {
+	return (len(s) == 40 || len(s) == 64) && isHex(s)
+}
+
+// ParseRefUpdates reads "<old> <new> <ref>" lines, as git passes them to a
+// pre-receive hook. Blank lines are skipped.
+func ParseRefUpdates(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !isObjectName(fields[0]) || !isObjectName(fields[1]) {
+			return nil, fmt.Errorf("line %d: want \"<old> <new> <ref>\", got %q", n, line)
+		}
+		updates = append(updates, RefUpdate{Old: fields[0], New: fields[1], Ref: fields[2]})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading ref updates: %w", err)
+	}
+	return updates, nil
+}
+
+// PushedBlob is a version of a file that a push introduces.
+type PushedBlob struct {
+	// Ref is the ref whose update introduces the blob. A blob that
+	// several updates introduce is reported once, for the first.
+	Ref string
+	// Commit is the oldest pushed commit that adds or changes the file to
+	// this version. Merge commits are not diffed, so for a blob only a
+	// merge introduces, as a conflict resolution does, Commit only has the
+	// SHA of the ref's new tip.
+	Commit CommitInfo
+	Path   string
+	SHA    string
+	// Content is the blob's content and Previous the content of the file
+	// before Commit changed it; Previous is nil when Commit adds the file.
+	Content  []byte
+	Previous []byte
+}
+
+// WalkPush calls fn for each text blob that the ref updates introduce:
+// the blobs reachable from an updated ref's new tip and from no ref the
+// repository has before the push, the old tip included. Deleted refs are
+// skipped. It reads objects with git plumbing only, so it works in a bare
+// repository and in a pre-receive hook, where the pushed objects are still
+// in quarantine. It stops when ctx is done, returning ctx.Err(), and when
+// fn returns an error, returning that error.
+func WalkPush(ctx context.Context, gitDir string, updates []RefUpdate, fn func(PushedBlob) error) error {
+	seen := make(map[string]bool)
+	for _, u := range updates {
+		if u.Deletes() {
+			continue
+		}
+		blobs, err := pushedBlobs(ctx, gitDir, u, seen)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("walk push %s: %w", u.Ref, err)
+		}
+		if len(blobs) == 0 {
+			continue
+		}
+		if err := readPushedBlobs(ctx, gitDir, blobs, fn); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// pendingBlob is a pushed blob whose content, and that of the previous
+// blob of its file, has yet to be read.
+type pendingBlob struct {
+	PushedBlob
+	previous string
+}
+
+// pushedBlobs lists the blobs update u introduces that are not in seen,
+// and adds them to seen.
+func pushedBlobs(ctx context.Context, gitDir string, u RefUpdate, seen map[string]bool) ([]pendingBlob, error) {
+	revs := []string{u.New, "--not", "--all"}
+	if !u.Creates() {
+		revs = append(revs, u.Old)
+	}
+
+	objects, err := runGitContext(ctx, gitDir, nil, append([]string{"rev-list", "--objects"}, revs...)...)
+	if err != nil {
+		return nil, err
+	}
+	// Only trees and blobs are listed with a path; ask for the type of
+	// those.
+	var named bytes.Buffer
+	for line := range strings.SplitSeq(string(objects), "\n") {
+		if sha, path, ok := strings.Cut(line, " "); ok && path != "" {
+			fmt.Fprintf(&named, "%s %s\n", sha, path)
+		}
+	}
+	if named.Len() == 0 {
+		return nil, nil
+	}
+	types, err := runGitContext(ctx, gitDir, &named, "cat-file", "--batch-check=%(objectname) %(objecttype) %(rest)")
+	if err != nil {
+		return nil, err
+	}
+
+	introduced, err := introducingCommits(ctx, gitDir, revs)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []pendingBlob
+	for line := range strings.SplitSeq(string(types), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || fields[1] != "blob" || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		b := pendingBlob{PushedBlob: PushedBlob{Ref: u.Ref, Commit: CommitInfo{SHA: u.New}, Path: fields[2], SHA: fields[0]}}
+		if c, ok := introduced[b.SHA]; ok {
+			b.Commit, b.Path, b.previous = c.commit, c.path, c.previous
+		}
+		blobs = append(blobs, b)
+	}
+	return blobs, nil
+}
+
+// introduction is the commit that first adds a blob, the path it adds it
+// at and the SHA of the file's previous blob, empty when the commit adds
+// the file.
+type introduction struct {
+	commit   CommitInfo
+	path     string
+	previous string
+}
+
+// introducingCommits maps each blob that a non-merge commit in revs adds
+// or changes a file to, to the oldest such commit.
+func introducingCommits(ctx context.Context, gitDir string, revs []string) (map[string]introduction, error) {
+	// Use separators that do not appear in commit metadata.
+	const commitStart, field = "\x1e", "\x1f"
+	format := commitStart + strings.Join([]string{"%H", "%an", "%ae", "%at", "%s"}, field)
+	args := append([]string{"log", "--reverse", "--root", "--raw", "--no-abbrev",
+		"--no-renames", "--diff-filter=AMT", "--format=" + format, "-z"}, revs...)
+	out, err := runGitContext(ctx, gitDir, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make(map[string]introduction)
+	var current CommitInfo
+	parts := strings.Split(string(out), "\x00")
+	for i := 0; i < len(parts); i++ {
+		part := strings.TrimLeft(parts[i], "\n")
+		if header, ok := strings.CutPrefix(part, commitStart); ok {
+			// The header is followed by the commit's first raw entry, or
+			// by nothing for a commit that changes no files.
+			header, raw, _ := strings.Cut(header, "\n")
+			current = parseCommitHeader(header, field)
+			part = raw
+		}
+		if !strings.HasPrefix(part, ":") || i+1 >= len(parts) {
+			continue
+		}
+		// ":old_mode new_mode old_sha new_sha status", then the path.
+		meta := strings.Fields(part[1:])
+		path := parts[i+1]
+		i++
+		if len(meta) < 5 || current.SHA == "" {
+			continue
+		}
+		sha := meta[3]
+		if _, ok := commits[sha]; ok {
+			continue
+		}
+		prev := meta[2]
+		if isZeroOID(prev) {
+			prev = ""
+		}
+		commits[sha] = introduction{commit: current, path: path, previous: prev}
+	}
+	return commits, nil
+}
+
+// parseCommitHeader parses the fields of a commit's log format line.
+func parseCommitHeader(header, sep string) CommitInfo {
+	f := strings.SplitN(header, sep, 5)
+	if len(f) < 5 {
+		return CommitInfo{}
+	}
+	c := CommitInfo{SHA: f[0], Author: f[1], Email: f[2], Message: f[4]}
+	if ts, err := strconv.ParseInt(f[3], 10, 64); err == nil {
+		c.Date = time.Unix(ts, 0).UTC()
+	}
+	return c
+}
+
+// readPushedBlobs reads the content of blobs, and of their previous
+// versions, with one "git cat-file --batch" and calls fn for each text
+// blob.
+func readPushedBlobs(ctx context.Context, gitDir string, blobs []pendingBlob, fn func(PushedBlob) error) error {
+	var in bytes.Buffer
+	for _, b := range blobs {
+		fmt.Fprintln(&in, b.SHA)
+		if b.previous != "" {
+			fmt.Fprintln(&in, b.previous)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = gitDir
+	cmd.Stdin = &in
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("cat-file --batch: %w", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	r := bufio.NewReader(stdout)
+	for _, b := range blobs {
+		content, err := readBatchObject(r)
+		if err != nil {
+			return fmt.Errorf("reading blob %s: %w", b.SHA, err)
+		}
+		b.Content = content
+		if b.previous != "" {
+			if b.Previous, err = readBatchObject(r); err != nil {
+				return fmt.Errorf("reading blob %s: %w", b.previous, err)
+			}
+		}
+		// Skip missing and binary files (files containing null bytes).
+		if b.Content == nil || bytes.ContainsRune(b.Content, 0) {
+			continue
+		}
+		if err := fn(b.PushedBlob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBatchObject reads one object from "git cat-file --batch" output. It
+// returns nil content for a missing object.
+func readBatchObject(r *bufio.Reader) ([]byte, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 2 && fields[1] == "missing" {
+		return nil, nil
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected cat-file output: %q", header)
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected cat-file output: %q", header)
+	}
+	content := make([]byte, size+1) // the content and a newline
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content[:size], nil
+}
+
+// runGitContext runs git in dir with stdin, which may be nil, and returns
+// its standard output.
+func runGitContext(ctx context.Context, dir string, stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setupPush returns a bare repository and the update of its main branch
+// to commits that were pushed to it but that no ref points to yet, as
+// when a pre-receive hook runs.
+func setupPush(t *testing.T) (bare string, update RefUpdate) {
+	t.Helper()
+	bare = t.TempDir()
+	run(t, bare, "git", "init", "--bare", "-b", "main")
+	dir := setupGitRepo(t)
+	run(t, dir, "git", "remote", "add", "origin", bare)
+	run(t, dir, "git", "push", "origin", "main")
+	old := getHEAD(t, dir)
+
+	writeFile(t, filepath.Join(dir, "README.md"), "# Test\nmore\n")
+	writeFile(t, filepath.Join(dir, "logo.png"), "PNG\x00\x01")
+	run(t, dir, "git", "add", ".")
+	run(t, dir, "git", "commit", "-m", "update readme")
+	writeFile(t, filepath.Join(dir, "config.env"), "TOKEN=abc\n")
+	run(t, dir, "git", "add", ".")
+	run(t, dir, "git", "commit", "-m", "add config")
+
+	run(t, dir, "git", "push", "origin", "main:refs/heads/incoming")
+	run(t, bare, "git", "update-ref", "-d", "refs/heads/incoming")
+	return bare, RefUpdate{Old: old, New: getHEAD(t, dir), Ref: "refs/heads/main"}
+}
+
+func TestParseRefUpdates(t *testing.T) {
+	old, zero := strings.Repeat("a", 40), strings.Repeat("0", 40)
+	in := old + " " + zero + " refs/heads/gone\n\n" + zero + " " + old + " refs/tags/v1\n"
+	updates, err := ParseRefUpdates(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 || !updates[0].Deletes() || updates[0].Creates() || !updates[1].Creates() || updates[1].Ref != "refs/tags/v1" {
+		t.Errorf("updates = %+v", updates)
+	}
+	if _, err := ParseRefUpdates(strings.NewReader("main HEAD refs/heads/main\n")); err == nil {
+		t.Error("expected an error for a line without object names")
+	}
+}
+
+func TestWalkPush_Bare(t *testing.T) {
+	bare, u := setupPush(t)
+	zero := strings.Repeat("0", 40)
+	updates := []RefUpdate{
+		u,
+		// A new branch at the same tip introduces nothing more.
+		{Old: zero, New: u.New, Ref: "refs/heads/feature"},
+		{Old: u.Old, New: zero, Ref: "refs/heads/old"},
+	}
+
+	got := make(map[string]PushedBlob)
+	err := WalkPush(context.Background(), bare, updates, func(b PushedBlob) error {
+		if _, dup := got[b.Path]; dup {
+			t.Errorf("%s reported twice", b.Path)
+		}
+		got[b.Path] = b
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkPush: %v", err)
+	}
+
+	var paths []string
+	for p := range got {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	// The binary file is skipped.
+	if !slices.Equal(paths, []string{"README.md", "config.env"}) {
+		t.Fatalf("paths = %v, want [README.md config.env]", paths)
+	}
+	readme, config := got["README.md"], got["config.env"]
+	if string(readme.Content) != "# Test\nmore\n" || string(readme.Previous) != "# Test" || readme.Commit.Message != "update readme" {
+		t.Errorf("README.md = %+v", readme)
+	}
+	if string(config.Content) != "TOKEN=abc\n" || config.Previous != nil || config.Commit.SHA != u.New ||
+		config.Commit.Author != "Test" || config.Ref != "refs/heads/main" {
+		t.Errorf("config.env = %+v", config)
+	}
+}
+
+func TestWalkPush_AlreadyReachable(t *testing.T) {
+	bare, u := setupPush(t)
+	// Once a ref points to the commits, a push of them introduces nothing.
+	run(t, bare, "git", "update-ref", "refs/heads/other", u.New)
+	err := WalkPush(context.Background(), bare, []RefUpdate{u}, func(b PushedBlob) error {
+		t.Errorf("unexpected blob %s", b.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkPush_Cancelled(t *testing.T) {
+	bare, u := setupPush(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := WalkPush(ctx, bare, []RefUpdate{u}, func(PushedBlob) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WalkPush with a cancelled context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Submodule is a git submodule of a repository, as .gitmodules declares it.
+type Submodule struct {
+	// Path is the submodule's directory, relative to the repository the
+	// submodules were listed for and slash-separated. The path of a nested
+	// submodule includes those of its parents.
+	Path string
+	// URL is the submodule's remote URL as .gitmodules gives it, which may
+	// be relative to the superproject's remote.
+	URL string
+	// Commit is the commit the superproject pins the submodule to, or its
+	// checked-out commit when the superproject's index cannot be read.
+	Commit string
+	// Initialized is set when the submodule is checked out.
+	Initialized bool
+	// Depth is 1 for a submodule of the repository the submodules were
+	// listed for, 2 for a submodule of one of those, and so on.
+	Depth int
+}
+
+// Submodules returns the submodules of the repository checked out in dir,
+// sorted by path, and those of the initialized ones down to depth levels
+// of nesting (1 lists only dir's own). A directory without a .gitmodules
+// file has none. Entries whose path leaves the repository are skipped.
+func Submodules(dir string, depth int) ([]Submodule, error) {
+	if depth < 1 {
+		return nil, nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gitmodules")); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	out, err := runGit(dir, "config", "--file", ".gitmodules", "--null", "--get-regexp", `^submodule\..*\.(path|url)$`)
+	if err != nil {
+		// git config exits with status 1 when no key matches.
+		if strings.HasPrefix(err.Error(), "exit status 1:") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading .gitmodules: %w", err)
+	}
+
+	byName := make(map[string]*Submodule)
+	for _, entry := range strings.Split(out, "\x00") {
+		key, value, ok := strings.Cut(entry, "\n")
+		if !ok {
+			continue
+		}
+		name, field := key[len("submodule."):strings.LastIndexByte(key, '.')], key[strings.LastIndexByte(key, '.')+1:]
+		s := byName[name]
+		if s == nil {
+			s = &Submodule{}
+			byName[name] = s
+		}
+		if field == "path" {
+			s.Path = path.Clean(filepath.ToSlash(value))
+		} else {
+			s.URL = value
+		}
+	}
+
+	var subs []Submodule
+	for _, s := range byName {
+		if s.Path == "" || !filepath.IsLocal(filepath.FromSlash(s.Path)) {
+			continue
+		}
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(s.Path), ".git"))
+		s.Initialized, s.Depth = err == nil, 1
+		subs = append(subs, *s)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Path < subs[j].Path })
+	pinSubmodules(dir, subs)
+
+	var nested []Submodule
+	for _, s := range subs {
+		if !s.Initialized {
+			continue
+		}
+		children, err := Submodules(filepath.Join(dir, filepath.FromSlash(s.Path)), depth-1)
+		if err != nil {
+			return nil, fmt.Errorf("submodule %s: %w", s.Path, err)
+		}
+		for _, c := range children {
+			c.Path, c.Depth = s.Path+"/"+c.Path, c.Depth+1
+			nested = append(nested, c)
+		}
+	}
+	subs = append(subs, nested...)
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Path < subs[j].Path })
+	return subs, nil
+}
+
+// pinSubmodules sets the Commit of each of subs from the gitlinks in the
+// index of the repository in dir, falling back to the checked-out commit
+// of an initialized submodule.
+func pinSubmodules(dir string, subs []Submodule) {
+	if len(subs) == 0 {
+		return
+	}
+	args := []string{"ls-files", "--stage", "-z", "--"}
+	for _, s := range subs {
+		args = append(args, s.Path)
+	}
+	pinned := make(map[string]string)
+	if out, err := runGit(dir, args...); err == nil {
+		for _, entry := range strings.Split(out, "\x00") {
+			// <mode> <object> <stage>\t<path>
+			meta, p, ok := strings.Cut(entry, "\t")
+			fields := strings.Fields(meta)
+			if ok && len(fields) == 3 && fields[0] == "160000" {
+				pinned[p] = fields[1]
+			}
+		}
+	}
+	for i := range subs {
+		subs[i].Commit = pinned[subs[i].Path]
+		if subs[i].Commit == "" && subs[i].Initialized {
+			if sha, err := HeadCommit(filepath.Join(dir, filepath.FromSlash(subs[i].Path))); err == nil {
+				subs[i].Commit = sha
+			}
+		}
+	}
+}
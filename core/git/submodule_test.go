@@ -0,0 +1,72 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSubmodules(t *testing.T) {
+	inner := setupGitRepo(t)
+	lib := setupGitRepo(t)
+	run(t, lib, "git", "-c", "protocol.file.allow=always", "submodule", "add", inner, "third_party/inner")
+	run(t, lib, "git", "commit", "-m", "add inner")
+	other := setupGitRepo(t)
+
+	dir := setupGitRepo(t)
+	run(t, dir, "git", "-c", "protocol.file.allow=always", "submodule", "add", lib, "vendor/lib")
+	run(t, dir, "git", "-c", "protocol.file.allow=always", "submodule", "add", other, "vendor/other")
+	run(t, dir, "git", "-c", "protocol.file.allow=always", "submodule", "update", "--init", "--recursive")
+	run(t, dir, "git", "commit", "-m", "add submodules")
+	run(t, dir, "git", "submodule", "deinit", "-f", "vendor/other")
+
+	libSHA, err := HeadCommit(lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	innerSHA, err := HeadCommit(inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSHA, err := HeadCommit(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subs, err := Submodules(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Submodule{
+		{Path: "vendor/lib", URL: lib, Commit: libSHA, Initialized: true, Depth: 1},
+		{Path: "vendor/lib/third_party/inner", URL: inner, Commit: innerSHA, Initialized: true, Depth: 2},
+		{Path: "vendor/other", URL: other, Commit: otherSHA, Depth: 1},
+	}
+	if len(subs) != len(want) {
+		t.Fatalf("submodules = %+v, want %+v", subs, want)
+	}
+	for i := range want {
+		if subs[i] != want[i] {
+			t.Errorf("submodule %d = %+v, want %+v", i, subs[i], want[i])
+		}
+	}
+
+	// A depth of 1 lists only the repository's own submodules.
+	subs, err = Submodules(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 2 || subs[0].Path != "vendor/lib" || subs[1].Path != "vendor/other" {
+		t.Errorf("depth 1 = %+v", subs)
+	}
+}
+
+func TestSubmodules_None(t *testing.T) {
+	subs, err := Submodules(setupGitRepo(t), 3)
+	if err != nil || subs != nil {
+		t.Errorf("Submodules = %v, %v; want none", subs, err)
+	}
+	subs, err = Submodules(filepath.Join(t.TempDir(), "missing"), 3)
+	if err != nil || subs != nil {
+		t.Errorf("Submodules of a missing directory = %v, %v; want none", subs, err)
+	}
+}
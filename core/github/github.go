@@ -0,0 +1,174 @@
+// Package github locates the REST, GraphQL and web endpoints of github.com
+// and GitHub Enterprise Server instances and calls the REST API. On
+// github.com the API lives on its own host, https://api.github.com; on
+// GitHub Enterprise Server it lives under the instance at /api/v3, with
+// GraphQL at /api/graphql.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default endpoints of github.com.
+const (
+	DefaultAPIURL    = "https://api.github.com"
+	DefaultServerURL = "https://github.com"
+)
+
+// Host holds the endpoints of a GitHub instance, without trailing slashes.
+type Host struct {
+	// APIURL is the base URL of the REST API, such as
+	// https://api.github.com or https://ghe.example.com/api/v3.
+	APIURL string
+	// ServerURL is the web URL that repositories live under, such as
+	// https://github.com or https://ghe.example.com.
+	ServerURL string
+}
+
+// NewHost returns the endpoints of the instance that apiURL or serverURL
+// name; either may be empty and is then derived from the other, and both
+// empty mean github.com. An API URL given as the bare address of a GitHub
+// Enterprise Server instance, such as https://ghe.example.com, gets the
+// /api/v3 prefix its REST API lives under. Hosts whose name starts with
+// "api.", such as GHE.com's api.<subdomain>.ghe.com, are taken as is.
+func NewHost(apiURL, serverURL string) Host {
+	apiURL = strings.TrimRight(strings.TrimSpace(apiURL), "/")
+	serverURL = strings.TrimRight(strings.TrimSpace(serverURL), "/")
+	if apiURL != "" {
+		if u, err := url.Parse(apiURL); err == nil && u.Path == "" && !strings.HasPrefix(u.Hostname(), "api.") {
+			apiURL += "/api/v3"
+		}
+	}
+	switch {
+	case apiURL == "" && (serverURL == "" || serverURL == DefaultServerURL):
+		apiURL = DefaultAPIURL
+	case apiURL == "":
+		apiURL = serverURL + "/api/v3"
+	}
+	if serverURL == "" {
+		serverURL = serverFromAPI(apiURL)
+	}
+	return Host{APIURL: apiURL, ServerURL: serverURL}
+}
+
+// FromEnv returns the endpoints that the GITHUB_API_URL and
+// GITHUB_SERVER_URL variables name. GitHub Actions sets both, to the
+// instance the workflow runs on.
+func FromEnv(getenv func(string) string) Host {
+	return NewHost(getenv("GITHUB_API_URL"), getenv("GITHUB_SERVER_URL"))
+}
+
+// serverFromAPI returns the web URL of the instance whose REST API is at
+// apiURL: the API path is dropped, and an "api." host prefix too.
+func serverFromAPI(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return DefaultServerURL
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/api/v3"), "/")
+	u.Host = strings.TrimPrefix(u.Host, "api.")
+	return u.String()
+}
+
+// GraphQLURL returns the GraphQL endpoint: /graphql on an API host and
+// /api/graphql on a GitHub Enterprise Server instance.
+func (h Host) GraphQLURL() string {
+	if base, ok := strings.CutSuffix(h.APIURL, "/v3"); ok {
+		return base + "/graphql"
+	}
+	return h.APIURL + "/graphql"
+}
+
+// Hostname returns the host name of the web URL, such as github.com or
+// ghe.example.com, as the gh CLI's --hostname flag expects it.
+func (h Host) Hostname() string {
+	u, err := url.Parse(h.ServerURL)
+	if err != nil || u.Host == "" {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// IsDotCom reports whether h is github.com.
+func (h Host) IsDotCom() bool {
+	return h.APIURL == DefaultAPIURL
+}
+
+// RepoURL returns the web URL of the repository owner/name.
+func (h Host) RepoURL(repo string) string {
+	return h.ServerURL + "/" + repo
+}
+
+// Client calls the REST API of a GitHub instance.
+type Client struct {
+	Host  Host
+	Token string
+	// HTTPClient sends the requests. Nil means a client with a 30 second
+	// timeout.
+	HTTPClient *http.Client
+}
+
+// Do sends a request with body, if any, to the REST API endpoint, a path
+// such as "repos/org/repo/check-runs", and returns the response body.
+// Responses other than 2xx are returned as errors that include the status
+// as "HTTP <code>" and the message GitHub gave.
+func (c *Client) Do(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
+	u := c.Host.APIURL + "/" + strings.TrimPrefix(endpoint, "/")
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, endpoint, err)
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: reading response: %w", method, endpoint, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: HTTP %d: %s", method, endpoint, resp.StatusCode, errorMessage(out))
+	}
+	return out, nil
+}
+
+// errorMessage returns the message of a GitHub error response, or the
+// start of the body when it is not one.
+func errorMessage(body []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Message != "" {
+		return e.Message
+	}
+	s := strings.TrimSpace(string(body))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewHost(t *testing.T) {
+	tests := []struct {
+		name              string
+		apiURL, serverURL string
+		want              Host
+		graphQL, hostname string
+	}{
+		{"default", "", "", Host{DefaultAPIURL, DefaultServerURL}, "https://api.github.com/graphql", "github.com"},
+		{"github.com runner", "https://api.github.com", "https://github.com", Host{DefaultAPIURL, DefaultServerURL}, "https://api.github.com/graphql", "github.com"},
+		{"GHES runner", "https://ghe.example.com/api/v3", "https://ghe.example.com", Host{"https://ghe.example.com/api/v3", "https://ghe.example.com"}, "https://ghe.example.com/api/graphql", "ghe.example.com"},
+		{"GHES API only", "https://ghe.example.com/api/v3/", "", Host{"https://ghe.example.com/api/v3", "https://ghe.example.com"}, "https://ghe.example.com/api/graphql", "ghe.example.com"},
+		{"GHES bare address", "https://ghe.example.com", "", Host{"https://ghe.example.com/api/v3", "https://ghe.example.com"}, "https://ghe.example.com/api/graphql", "ghe.example.com"},
+		{"GHES server only", "", "https://ghe.example.com:8443", Host{"https://ghe.example.com:8443/api/v3", "https://ghe.example.com:8443"}, "https://ghe.example.com:8443/api/graphql", "ghe.example.com:8443"},
+		{"GHE.com", "https://api.acme.ghe.com", "", Host{"https://api.acme.ghe.com", "https://acme.ghe.com"}, "https://api.acme.ghe.com/graphql", "acme.ghe.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHost(tt.apiURL, tt.serverURL)
+			if h != tt.want {
+				t.Errorf("NewHost(%q, %q) = %+v, want %+v", tt.apiURL, tt.serverURL, h, tt.want)
+			}
+			if got := h.GraphQLURL(); got != tt.graphQL {
+				t.Errorf("GraphQLURL() = %q, want %q", got, tt.graphQL)
+			}
+			if got := h.Hostname(); got != tt.hostname {
+				t.Errorf("Hostname() = %q, want %q", got, tt.hostname)
+			}
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{"GITHUB_API_URL": "https://ghe.example.com/api/v3", "GITHUB_SERVER_URL": "https://ghe.example.com"}
+	h := FromEnv(func(k string) string { return env[k] })
+	if h.IsDotCom() || h.RepoURL("org/repo") != "https://ghe.example.com/org/repo" {
+		t.Errorf("FromEnv = %+v", h)
+	}
+	if h := FromEnv(func(string) string { return "" }); !h.IsDotCom() || h.RepoURL("org/repo") != "https://github.com/org/repo" {
+		t.Errorf("FromEnv without variables = %+v", h)
+	}
+}
+
+// redirect sends every request to srv, keeping the path, so that clients
+// configured with real GitHub URLs can be tested against a mock server.
+type redirect struct{ srv *httptest.Server }
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, _ := url.Parse(r.srv.URL)
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Original-Host", req.URL.Host)
+	req.URL.Scheme, req.URL.Host = u.Scheme, u.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientDo_URLShapes(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.Header.Get("X-Original-Host")+r.URL.Path+" "+r.Header.Get("Authorization")+" "+string(body))
+		_, _ = io.WriteString(w, `{"id":1}`)
+	}))
+	defer srv.Close()
+
+	for _, h := range []Host{NewHost("", ""), NewHost("https://ghe.example.com/api/v3", "")} {
+		c := &Client{Host: h, Token: "t0ken", HTTPClient: &http.Client{Transport: redirect{srv}}}
+		out, err := c.Do(context.Background(), http.MethodPost, "repos/org/repo/check-runs", []byte(`{}`))
+		if err != nil || string(out) != `{"id":1}` {
+			t.Fatalf("Do on %s = %q, %v", h.APIURL, out, err)
+		}
+	}
+	want := []string{
+		"POST api.github.com/repos/org/repo/check-runs Bearer t0ken {}",
+		"POST ghe.example.com/api/v3/repos/org/repo/check-runs Bearer t0ken {}",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestClientDo_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, `{"message":"Resource not accessible by integration"}`)
+	}))
+	defer srv.Close()
+
+	c := &Client{Host: NewHost(srv.URL, "")}
+	_, err := c.Do(context.Background(), http.MethodPost, "repos/org/repo/check-runs", nil)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403: Resource not accessible by integration") {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nox-hq/nox/core/discovery"
+)
+
+// maxGitMetadataSize bounds the git metadata files read, so that a large
+// packed-refs file does not slow the scan down.
+const maxGitMetadataSize = 1 << 20
+
+// gitMetadataArtifacts returns the files of the git directory of target
+// that are scanned for secrets although discovery skips .git: config,
+// packed-refs and the hooks that are not git's *.sample files. Only these
+// known paths are read; the object database is not. Nothing is returned
+// when target/.git is not a directory, as in a submodule or worktree
+// checkout.
+func gitMetadataArtifacts(target string) []discovery.Artifact {
+	gitDir := filepath.Join(target, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return nil
+	}
+	names := []string{"config", "packed-refs"}
+	if hooks, err := os.ReadDir(filepath.Join(gitDir, "hooks")); err == nil {
+		for _, h := range hooks {
+			if !strings.HasSuffix(h.Name(), ".sample") {
+				names = append(names, "hooks/"+h.Name())
+			}
+		}
+	}
+	sort.Strings(names[2:])
+
+	var out []discovery.Artifact
+	for _, name := range names {
+		abs := filepath.Join(gitDir, filepath.FromSlash(name))
+		info, err := os.Lstat(abs)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxGitMetadataSize {
+			continue
+		}
+		out = append(out, discovery.Artifact{
+			Path:      ".git/" + name,
+			AbsPath:   abs,
+			Type:      discovery.Config,
+			Size:      info.Size(),
+			Sensitive: true,
+		})
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/nox-hq/nox/core/baseline"
+	"github.com/nox-hq/nox/core/trend"
+)
+
+// HistoryPath returns the scan history file of target: history.path from
+// cfg, relative to target, or .nox/history.jsonl.
+func HistoryPath(target string, cfg *ScanConfig) string {
+	p := cfg.History.Path
+	switch {
+	case p == "":
+		return filepath.Join(filepath.Dir(baseline.DefaultPath(target)), trend.DefaultFile)
+	case filepath.IsAbs(p):
+		return p
+	default:
+		return filepath.Join(target, p)
+	}
+}
+
+// narrowed reports whether the options scan only part of the tree or run
+// only some of the rules, so that findings missing from the result are not
+// necessarily resolved. Such scans are left out of the scan history.
+func (o *ScanOptions) narrowed() bool {
+	return o.Since != "" || o.Fast ||
+		len(o.OnlyCategories) > 0 || len(o.SkipCategories) > 0 ||
+		len(o.OnlyAnalyzers) > 0 || len(o.DisabledAnalyzers) > 0
+}
+
+// historyRecord completes delta, the comparison with the previous scan,
+// into the history record of result, counting its active findings by
+// severity and rule category.
+func historyRecord(delta trend.Record, result *ScanResult, now time.Time) trend.Record {
+	r := delta
+	r.Time = now.UTC().Truncate(time.Second)
+	r.DurationMS = result.Duration.Milliseconds()
+	if result.Provenance != nil {
+		r.Commit = result.Provenance.GitCommit
+	}
+	r.BySeverity = make(map[string]int)
+	r.ByCategory = make(map[string]int)
+	for _, f := range result.Findings.ActiveFindings() {
+		r.Total++
+		r.BySeverity[string(f.Severity)]++
+		r.ByCategory[result.Rules.CategoryOf(&f)]++
+	}
+	return r
+}
+
+// ScanTrend summarizes the scan history of target recorded since the given
+// age, such as 90d, counted back from now; an empty since covers the whole
+// history.
+func ScanTrend(target string, cfg *ScanConfig, since string) (*trend.Summary, error) {
+	var from time.Time
+	if since != "" {
+		d, err := parseAge(since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since: %w", err)
+		}
+		from = timeNow().Add(-d)
+	}
+	records, err := trend.Load(HistoryPath(target, cfg))
+	if err != nil {
+		return nil, err
+	}
+	return trend.Summarize(records, from), nil
+}
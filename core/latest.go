@@ -0,0 +1,88 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/report"
+)
+
+// FindingsReportLocation returns where nox scan writes the findings.json
+// report of target, going by the output settings of its .nox.yaml: the
+// output directory, or the runs directory in timestamped mode, and a
+// filepath.Match pattern for the report's name relative to it. The pattern
+// is the name output.files gives the json report, with any variables
+// matching every value, or findings.json. Flags of nox scan that override
+// these settings are not known here.
+func FindingsReportLocation(target string) (dir, pattern string, err error) {
+	cfg, err := LoadScanConfig(target)
+	if err != nil {
+		return "", "", err
+	}
+	dir = cfg.Output.Directory
+	if dir == "" {
+		dir = "."
+	}
+	if cfg.Output.Timestamped && dir == "." {
+		dir = report.RunsDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(target, dir)
+	}
+	name := report.FileNames["json"]
+	if custom := cfg.Output.Files["json"]; custom != "" {
+		name = custom
+	}
+	return dir, report.NameGlob(name), nil
+}
+
+// LatestFindingsReport returns the findings.json that nox scan last wrote
+// for target, or "" if there is none. It looks in the directory
+// FindingsReportLocation returns and in the latest timestamped run under
+// it. When the name is a template, the most recently written match is
+// taken. An encrypted report, with report.EncryptedSuffix, is returned
+// when there is no plaintext one in the same place.
+func LatestFindingsReport(target string) string {
+	dir, pattern, err := FindingsReportLocation(target)
+	if err != nil {
+		return ""
+	}
+	for _, base := range []string{dir, filepath.Join(dir, report.LatestLink)} {
+		for _, p := range []string{pattern, pattern + report.EncryptedSuffix} {
+			if path := newestFindingsReport(filepath.Join(base, p)); path != "" {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// newestFindingsReport returns the most recently modified findings report
+// matching the glob pattern, or "".
+func newestFindingsReport(pattern string) string {
+	matches, _ := filepath.Glob(pattern)
+	var newest string
+	var newestTime time.Time
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || !isFindingsReport(path) {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// isFindingsReport reports whether path is a findings.json report, which
+// may have been written under a custom name: report.IsReportFile for the
+// default name, a regular file otherwise.
+func isFindingsReport(path string) bool {
+	if name := strings.TrimSuffix(filepath.Base(path), report.EncryptedSuffix); name == report.FileNames["json"] {
+		return report.IsReportFile(path)
+	}
+	return true
+}
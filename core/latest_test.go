@@ -0,0 +1,42 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatestFindingsReport_NewestTemplateMatch(t *testing.T) {
+	dir := t.TempDir()
+	writeLatestFile(t, dir, ".nox.yaml", "output:\n  files:\n    json: \"{target}-{date}.json\"\n")
+	writeLatestFile(t, dir, "app-2025-01-01.json", `{"findings":[]}`)
+	writeLatestFile(t, dir, "app-2025-01-02.json", `{"findings":[]}`)
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "app-2025-01-01.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := LatestFindingsReport(dir); got != filepath.Join(dir, "app-2025-01-02.json") {
+		t.Errorf("LatestFindingsReport = %q, want the newest match", got)
+	}
+}
+
+func TestLatestFindingsReport_DefaultNameMustBeNoxReport(t *testing.T) {
+	dir := t.TempDir()
+	writeLatestFile(t, dir, "findings.json", `{"findings":[]}`)
+	if got := LatestFindingsReport(dir); got != "" {
+		t.Errorf("LatestFindingsReport = %q, want none for another tool's findings.json", got)
+	}
+	writeLatestFile(t, dir, "findings.json", `{"meta":{"tool_name":"nox"},"findings":[]}`)
+	if got := LatestFindingsReport(dir); got != filepath.Join(dir, "findings.json") {
+		t.Errorf("LatestFindingsReport = %q, want findings.json", got)
+	}
+}
+
+func writeLatestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
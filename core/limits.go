@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Defaults of scan.max_file_size and scan.memory_budget.
+const (
+	// DefaultMaxFileSize is the size above which the content analyzers
+	// scan only the start of a file.
+	DefaultMaxFileSize = "10MB"
+	// DefaultMemoryBudget is the memory above which the install script
+	// workers of the dependency analyzer run one at a time.
+	DefaultMemoryBudget = "2GB"
+)
+
+// byteUnits are the size suffixes ParseByteSize accepts, longest first.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"B", 1},
+}
+
+// ParseByteSize parses a size such as 500MB, 2GiB or 1048576 (bytes). Zero
+// means no limit.
+func ParseByteSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(t, u.suffix) {
+			t, unit = strings.TrimSpace(strings.TrimSuffix(t, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(t, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500MB, 2GB)", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// configByteSize parses the size setting key of value, or def when value
+// is empty.
+func configByteSize(key, value, def string) (int64, error) {
+	if value == "" {
+		value = def
+	}
+	n, err := ParseByteSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// maxFileSize returns the scan.max_file_size of cfg in bytes. Zero means
+// files are scanned whole.
+func maxFileSize(cfg *ScanConfig) (int64, error) {
+	return configByteSize("scan.max_file_size", cfg.Scan.MaxFileSize, DefaultMaxFileSize)
+}
+
+// memoryBudget returns the scan.memory_budget of cfg in bytes. Zero means
+// the install script workers are never throttled.
+func memoryBudget(cfg *ScanConfig) (uint64, error) {
+	n, err := configByteSize("scan.memory_budget", cfg.Scan.MemoryBudget, DefaultMemoryBudget)
+	return uint64(n), err
+}
@@ -0,0 +1,46 @@
+package core
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"1048576", 1 << 20},
+		{"500MB", 500e6},
+		{"2gb", 2e9},
+		{"1.5 GiB", 3 << 29},
+		{"64KiB", 64 << 10},
+		{"10B", 10},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "lots", "-1MB", "MB"} {
+		if _, err := ParseByteSize(bad); err == nil {
+			t.Errorf("ParseByteSize(%q) should fail", bad)
+		}
+	}
+}
+
+func TestMaxFileSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"", 10e6},
+		{"0", 0},
+		{"512KiB", 512 << 10},
+	}
+	for _, tt := range tests {
+		cfg := &ScanConfig{Scan: ScanSettings{MaxFileSize: tt.in}}
+		if got, err := maxFileSize(cfg); err != nil || got != tt.want {
+			t.Errorf("maxFileSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package logging configures the structured slog logger shared by the CLI,
+// the scan engine, the MCP server, and watch mode. Logs are diagnostic
+// output only: human-facing results are printed separately on stdout, while
+// log records are written to stderr so they can be collected by log
+// aggregators without interfering with reports or the MCP stdio transport.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Format identifies the encoding used for log records.
+type Format string
+
+// Supported log formats.
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseLevel converts a level name (debug, info, warn, error) into a
+// slog.Level. Matching is case-insensitive and "warning" is accepted as an
+// alias for "warn".
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level %q (must be debug, info, warn, or error)", s)
+	}
+}
+
+// ParseFormat validates a log format name. An empty string selects text.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(s))) {
+	case FormatText, "":
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid log format %q (must be text or json)", s)
+	}
+}
+
+// New returns a logger that writes records at or above level to w using the
+// given format.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch f {
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(h), nil
+}
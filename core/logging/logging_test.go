@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil {
+			t.Fatalf("ParseLevel(%q) returned error: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != FormatText {
+		t.Fatalf("ParseFormat(\"\") = %q, %v; want text", f, err)
+	}
+	if f, err := ParseFormat("JSON"); err != nil || f != FormatJSON {
+		t.Fatalf("ParseFormat(\"JSON\") = %q, %v; want json", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestNew_JSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.Info("analyzer finished", "analyzer", "secrets", "findings", 3)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if rec["msg"] != "analyzer finished" {
+		t.Errorf("msg = %v, want %q", rec["msg"], "analyzer finished")
+	}
+	if rec["analyzer"] != "secrets" {
+		t.Errorf("analyzer = %v, want %q", rec["analyzer"], "secrets")
+	}
+}
+
+func TestNew_LevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "text")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.Debug("hidden debug")
+	logger.Info("hidden info")
+	logger.Warn("visible warning")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("expected records below warn to be dropped, got:\n%s", out)
+	}
+	if !strings.Contains(out, "visible warning") {
+		t.Errorf("expected warn record in output, got:\n%s", out)
+	}
+}
+
+func TestNew_InvalidArgs(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "loud", "text"); err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+	if _, err := New(&bytes.Buffer{}, "info", "yaml"); err == nil {
+		t.Fatal("expected error for invalid format")
+	}
+}
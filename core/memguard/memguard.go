@@ -0,0 +1,94 @@
+// Package memguard throttles the worker pools of a scan when the memory
+// the process holds crosses a budget, so that a repository of large files
+// slows the scan down rather than running it out of memory.
+package memguard
+
+import (
+	"log/slog"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync"
+)
+
+// Guard limits how many workers run at once: any number while the process
+// is under its budget and one at a time once it crosses it. A nil Guard,
+// or one with a zero budget, never throttles.
+type Guard struct {
+	budget uint64
+	// resident reports the memory the process holds; Resident unless a
+	// test replaces it.
+	resident func() uint64
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	active    int
+	throttled bool
+}
+
+// New returns a Guard with the given budget in bytes. Zero disables it.
+func New(budget uint64) *Guard {
+	g := &Guard{budget: budget, resident: Resident}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// Acquire blocks until a worker may run. While the process is over budget
+// it waits for the other workers to finish, so the pool shrinks to a single
+// worker until memory falls back under the budget. Every Acquire must be
+// paired with a Release.
+func (g *Guard) Acquire() {
+	if g == nil || g.budget == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.active > 0 && g.over() {
+		g.cond.Wait()
+	}
+	g.active++
+}
+
+// Release marks a worker started by Acquire as finished.
+func (g *Guard) Release() {
+	if g == nil || g.budget == 0 {
+		return
+	}
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+// over reports whether the process is over budget. On crossing it, it
+// logs a warning and returns freed memory to the operating system, which
+// may bring the process back under budget at once. g.mu must be held.
+func (g *Guard) over() bool {
+	over := g.resident() > g.budget
+	if over && !g.throttled {
+		slog.Warn("memory budget exceeded; running workers one at a time", "budget", g.budget)
+		debug.FreeOSMemory()
+		over = g.resident() > g.budget
+	}
+	g.throttled = over
+	return over
+}
+
+// residentMetrics are the runtime metrics Resident reads: the memory
+// mapped by the Go runtime and the part of it returned to the system.
+var residentMetrics = []string{"/memory/classes/total:bytes", "/memory/classes/heap/released:bytes"}
+
+// Resident returns the memory the Go runtime holds from the operating
+// system, less what it has released: an estimate of the resident set size
+// of the process that leaves out memory allocated by C code.
+func Resident() uint64 {
+	samples := make([]metrics.Sample, len(residentMetrics))
+	for i, name := range residentMetrics {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	total, released := samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	if released > total {
+		return 0
+	}
+	return total - released
+}
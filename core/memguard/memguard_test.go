@@ -0,0 +1,68 @@
+package memguard
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runWorkers runs n workers under g that each hold their slot for a
+// moment, and returns the most that ran at once.
+func runWorkers(g *Guard, n int) int32 {
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Acquire()
+			defer g.Release()
+			cur := running.Add(1)
+			for {
+				p := peak.Load()
+				if cur <= p || peak.CompareAndSwap(p, cur) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	return peak.Load()
+}
+
+func TestGuard_ThrottlesOverBudget(t *testing.T) {
+	g := New(100)
+	g.resident = func() uint64 { return 200 }
+	if peak := runWorkers(g, 8); peak != 1 {
+		t.Errorf("over budget, %d workers ran at once; want 1", peak)
+	}
+}
+
+func TestGuard_UnderBudget(t *testing.T) {
+	g := New(100)
+	g.resident = func() uint64 { return 50 }
+	if peak := runWorkers(g, 8); peak < 2 {
+		t.Errorf("under budget, at most %d worker ran at once; want several", peak)
+	}
+}
+
+func TestGuard_NilAndZeroBudget(t *testing.T) {
+	var nilGuard *Guard
+	nilGuard.Acquire()
+	nilGuard.Release()
+
+	g := New(0)
+	g.resident = func() uint64 { t.Fatal("a zero budget should not read the memory"); return 0 }
+	if peak := runWorkers(g, 4); peak < 2 {
+		t.Errorf("with no budget, at most %d worker ran at once; want several", peak)
+	}
+}
+
+func TestResident(t *testing.T) {
+	if Resident() == 0 {
+		t.Error("Resident() = 0, want the memory of the test process")
+	}
+}
@@ -0,0 +1,361 @@
+// Package metrics renders scan results as OpenMetrics gauges, for the
+// Prometheus node exporter's textfile collector and for /metrics endpoints.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/atomicfile"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// ContentType is the media type of the exposition format Write produces.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// metric is a gauge family: its name and HELP text.
+type metric struct {
+	name string
+	help string
+}
+
+// The metrics below are a stable interface that dashboards and alerts are
+// built on: add new metrics rather than renaming these or changing their
+// labels. Every sample also carries the labels of its Snapshot, which
+// always include repo.
+var (
+	// nox_build_info{version, commit, go_version} is always 1.
+	metricBuildInfo = metric{"nox_build_info", "Version of nox that produced the metrics."}
+
+	// nox_findings{severity, category} counts the active findings, those
+	// not suppressed, baselined or resolved through VEX. Every severity
+	// and rule category is present, at zero when nothing was found.
+	// Findings of rules missing from the rule set have category "unknown".
+	metricFindings = metric{"nox_findings", "Active findings of the last scan by severity and rule category."}
+
+	// nox_findings_suppressed{status} counts the inactive findings by
+	// status: suppressed, baselined, vex_not_affected or vex_fixed.
+	metricSuppressed = metric{"nox_findings_suppressed", "Findings of the last scan left out of the results, by status."}
+
+	// nox_dependencies counts the packages in the dependency inventory.
+	metricDependencies = metric{"nox_dependencies", "Dependencies found by the last scan."}
+
+	// nox_scan_duration_seconds is the wall time of the scan.
+	metricDuration = metric{"nox_scan_duration_seconds", "Duration of the last scan in seconds."}
+
+	// nox_analyzer_duration_seconds{analyzer} is the time each analyzer
+	// pass took: secrets, dotenv, data, iac, ai, deps and custom.
+	metricAnalyzerDuration = metric{"nox_analyzer_duration_seconds", "Duration of each analyzer pass of the last scan in seconds."}
+
+	// nox_pattern_cache_hit_ratio is the fraction of rule pattern lookups
+	// served by the compiled pattern cache, 0 when there were none.
+	metricCacheHitRatio = metric{"nox_pattern_cache_hit_ratio", "Fraction of rule pattern lookups of the last scan served from the compiled pattern cache."}
+
+	// nox_scan_partial is 1 when the scan was cancelled or timed out
+	// before every analyzer finished.
+	metricPartial = metric{"nox_scan_partial", "Whether the last scan stopped before every analyzer finished."}
+
+	// nox_scan_timestamp_seconds is when the scan finished, in seconds
+	// since the Unix epoch.
+	metricTimestamp = metric{"nox_scan_timestamp_seconds", "Unix time at which the last scan finished."}
+)
+
+// reservedLabels are the label names the metrics above use themselves.
+var reservedLabels = []string{"severity", "category", "status", "analyzer", "version", "commit", "go_version"}
+
+// labelNameRe matches a valid label name.
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// suppressedStatuses are the inactive statuses counted by
+// nox_findings_suppressed.
+var suppressedStatuses = []findings.Status{
+	findings.StatusSuppressed, findings.StatusBaselined,
+	findings.StatusVEXNotAffected, findings.StatusVEXFixed,
+}
+
+// severities are the finding severities, most severe first.
+var severities = []findings.Severity{
+	findings.SeverityCritical, findings.SeverityHigh, findings.SeverityMedium,
+	findings.SeverityLow, findings.SeverityInfo,
+}
+
+// BuildInfo identifies the nox binary for nox_build_info.
+type BuildInfo struct {
+	Version string
+	Commit  string
+}
+
+// Snapshot is the result of one scan and the labels its samples carry.
+type Snapshot struct {
+	Labels map[string]string
+	Result *nox.ScanResult
+	// Time is when the scan finished.
+	Time time.Time
+}
+
+// ParseLabel parses a --metrics-label value of the form name=value. The
+// name must be a valid label name not used by the metrics themselves.
+func ParseLabel(s string) (name, value string, err error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || !labelNameRe.MatchString(name) || strings.HasPrefix(name, "__") {
+		return "", "", fmt.Errorf("invalid metrics label %q (want name=value)", s)
+	}
+	if slices.Contains(reservedLabels, name) {
+		return "", "", fmt.Errorf("metrics label %q is reserved", name)
+	}
+	return name, value, nil
+}
+
+// RepoLabel returns the repo label for a scan of dir: the repository name
+// of its origin remote, such as "github.com/org/repo", or else the name of
+// the directory.
+func RepoLabel(dir string) string {
+	if u, err := git.RemoteURL(dir, "origin"); err == nil && u != "" {
+		if name := git.RepoName(u); name != "" {
+			return name
+		}
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.Base(dir)
+}
+
+// Write writes the metrics of snaps to w in the OpenMetrics text format.
+// Snapshots are written in the order of their labels, so the output is
+// deterministic.
+func Write(w io.Writer, build BuildInfo, snaps ...Snapshot) error {
+	snaps = slices.Clone(snaps)
+	sort.Slice(snaps, func(i, j int) bool {
+		return labelKey(snaps[i].Labels) < labelKey(snaps[j].Labels)
+	})
+
+	var b bytes.Buffer
+	family(&b, metricBuildInfo)
+	buildLabels := []string{"version", build.Version, "commit", build.Commit, "go_version", runtime.Version()}
+	if len(snaps) == 0 {
+		sample(&b, metricBuildInfo, nil, 1, buildLabels...)
+	}
+	for _, s := range snaps {
+		sample(&b, metricBuildInfo, s.Labels, 1, buildLabels...)
+	}
+
+	family(&b, metricFindings)
+	for _, s := range snaps {
+		counts := findingCounts(s.Result)
+		for _, sev := range severities {
+			for _, cat := range categories(counts) {
+				sample(&b, metricFindings, s.Labels, float64(counts[sev][cat]), "severity", string(sev), "category", cat)
+			}
+		}
+	}
+
+	family(&b, metricSuppressed)
+	for _, s := range snaps {
+		var byStatus map[findings.Status]int
+		if s.Result.Findings != nil {
+			byStatus = s.Result.Findings.CountByStatus()
+		}
+		for _, st := range suppressedStatuses {
+			sample(&b, metricSuppressed, s.Labels, float64(byStatus[st]), "status", string(st))
+		}
+	}
+
+	family(&b, metricDependencies)
+	for _, s := range snaps {
+		n := 0
+		if s.Result.Inventory != nil {
+			n = len(s.Result.Inventory.Packages())
+		}
+		sample(&b, metricDependencies, s.Labels, float64(n))
+	}
+
+	family(&b, metricDuration)
+	for _, s := range snaps {
+		sample(&b, metricDuration, s.Labels, s.Result.Duration.Seconds())
+	}
+
+	family(&b, metricAnalyzerDuration)
+	for _, s := range snaps {
+		for _, t := range s.Result.AnalyzerTimings {
+			sample(&b, metricAnalyzerDuration, s.Labels, t.Duration.Seconds(), "analyzer", t.Analyzer)
+		}
+	}
+
+	family(&b, metricCacheHitRatio)
+	for _, s := range snaps {
+		sample(&b, metricCacheHitRatio, s.Labels, s.Result.PatternCache.HitRatio())
+	}
+
+	family(&b, metricPartial)
+	for _, s := range snaps {
+		partial := 0.0
+		if s.Result.Partial {
+			partial = 1
+		}
+		sample(&b, metricPartial, s.Labels, partial)
+	}
+
+	family(&b, metricTimestamp)
+	for _, s := range snaps {
+		sample(&b, metricTimestamp, s.Labels, float64(s.Time.UnixMilli())/1000)
+	}
+
+	b.WriteString("# EOF\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteFile writes the metrics of snaps to path. The file is replaced
+// atomically so that a collector never reads a partly written file.
+func WriteFile(path string, build BuildInfo, snaps ...Snapshot) error {
+	var buf bytes.Buffer
+	if err := Write(&buf, build, snaps...); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Registry holds the latest snapshot of each label set and serves them
+// over HTTP. It is safe for concurrent use.
+type Registry struct {
+	build BuildInfo
+
+	mu    sync.Mutex
+	snaps map[string]Snapshot
+}
+
+// NewRegistry returns an empty registry reporting build as nox_build_info.
+func NewRegistry(build BuildInfo) *Registry {
+	return &Registry{build: build, snaps: make(map[string]Snapshot)}
+}
+
+// Set records s, replacing the previous snapshot with the same labels.
+func (r *Registry) Set(s Snapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snaps[labelKey(s.Labels)] = s
+}
+
+// ServeHTTP writes the recorded snapshots in the OpenMetrics text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	snaps := make([]Snapshot, 0, len(r.snaps))
+	for _, s := range r.snaps {
+		snaps = append(snaps, s)
+	}
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", ContentType)
+	_ = Write(w, r.build, snaps...)
+}
+
+// findingCounts counts the active findings of res by severity and rule
+// category.
+func findingCounts(res *nox.ScanResult) map[findings.Severity]map[string]int {
+	counts := make(map[findings.Severity]map[string]int)
+	if res.Findings == nil {
+		return counts
+	}
+	for _, f := range res.Findings.ActiveFindings() {
+		cat := "unknown"
+		if res.Rules != nil {
+			if rule, ok := res.Rules.ByID(f.RuleID); ok && rule.Category != "" {
+				cat = rule.Category
+			}
+		}
+		if counts[f.Severity] == nil {
+			counts[f.Severity] = make(map[string]int)
+		}
+		counts[f.Severity][cat]++
+	}
+	return counts
+}
+
+// categories returns the rule categories followed by any other category in
+// counts, sorted.
+func categories(counts map[findings.Severity]map[string]int) []string {
+	cats := slices.Clone(rules.Categories)
+	var extra []string
+	for _, byCat := range counts {
+		for c := range byCat {
+			if !slices.Contains(cats, c) && !slices.Contains(extra, c) {
+				extra = append(extra, c)
+			}
+		}
+	}
+	sort.Strings(extra)
+	return append(cats, extra...)
+}
+
+// family writes the HELP and TYPE lines of m.
+func family(b *bytes.Buffer, m metric) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, escapeHelp(m.help), m.name)
+}
+
+// sample writes one sample of m with the snapshot labels common followed
+// by the name/value pairs in extra.
+func sample(b *bytes.Buffer, m metric, common map[string]string, v float64, extra ...string) {
+	b.WriteString(m.name)
+	names := make([]string, 0, len(common))
+	for k := range common {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names)*2+len(extra))
+	for _, k := range names {
+		pairs = append(pairs, k, common[k])
+	}
+	pairs = append(pairs, extra...)
+	if len(pairs) > 0 {
+		b.WriteByte('{')
+		for i := 0; i < len(pairs); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", pairs[i], escapeLabel(pairs[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(b, " %s\n", strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// labelKey returns a string identifying a label set.
+func labelKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, k := range names {
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(labels[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/analyzers/deps"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+func testResult() *nox.ScanResult {
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{ID: "SEC-001", Category: rules.CategorySecrets})
+	rs.Add(&rules.Rule{ID: "IAC-001", Category: rules.CategoryIaC})
+
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{RuleID: "SEC-001", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "a.env", StartLine: 1}})
+	fs.Add(findings.Finding{RuleID: "SEC-001", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "b.env", StartLine: 1}})
+	fs.Add(findings.Finding{RuleID: "IAC-001", Severity: findings.SeverityMedium, Location: findings.Location{FilePath: "main.tf", StartLine: 3}})
+	fs.Add(findings.Finding{RuleID: "PLUGIN-1", Severity: findings.SeverityLow, Location: findings.Location{FilePath: "x", StartLine: 1}})
+	fs.Add(findings.Finding{RuleID: "SEC-001", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "c.env", StartLine: 1}, Status: findings.StatusBaselined})
+
+	inv := &deps.PackageInventory{}
+	inv.Add(deps.Package{Name: "lodash", Version: "4.17.21", Ecosystem: "npm"})
+
+	return &nox.ScanResult{
+		Findings:  fs,
+		Rules:     rs,
+		Inventory: inv,
+		Duration:  1500 * time.Millisecond,
+		AnalyzerTimings: []nox.AnalyzerTiming{
+			{Analyzer: "secrets", Duration: 250 * time.Millisecond},
+		},
+		PatternCache: rules.CacheStats{Hits: 3, Misses: 1},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	snap := Snapshot{
+		Labels: map[string]string{"repo": "github.com/org/repo", "team": `a"b`},
+		Result: testResult(),
+		Time:   time.Unix(1700000000, 0),
+	}
+	if err := Write(&b, BuildInfo{Version: "1.2.3", Commit: "abc"}, snap); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	labels := `repo="github.com/org/repo",team="a\"b"`
+	for _, want := range []string{
+		"# HELP nox_findings Active findings",
+		"# TYPE nox_findings gauge",
+		`nox_build_info{` + labels + `,version="1.2.3",commit="abc",go_version="go`,
+		`nox_findings{` + labels + `,severity="high",category="secrets"} 2`,
+		`nox_findings{` + labels + `,severity="medium",category="iac"} 1`,
+		`nox_findings{` + labels + `,severity="critical",category="ai"} 0`,
+		`nox_findings{` + labels + `,severity="low",category="unknown"} 1`,
+		`nox_findings_suppressed{` + labels + `,status="baselined"} 1`,
+		`nox_findings_suppressed{` + labels + `,status="suppressed"} 0`,
+		`nox_dependencies{` + labels + `} 1`,
+		`nox_scan_duration_seconds{` + labels + `} 1.5`,
+		`nox_analyzer_duration_seconds{` + labels + `,analyzer="secrets"} 0.25`,
+		`nox_pattern_cache_hit_ratio{` + labels + `} 0.75`,
+		`nox_scan_partial{` + labels + `} 0`,
+		`nox_scan_timestamp_seconds{` + labels + `} 1700000000`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Error("output should end with # EOF")
+	}
+}
+
+func TestWrite_NoSnapshots(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, BuildInfo{Version: "dev", Commit: "none"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `nox_build_info{version="dev",commit="none",go_version=`) {
+		t.Errorf("expected build info without snapshots:\n%s", b.String())
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nox.prom")
+	snap := Snapshot{Labels: map[string]string{"repo": "r"}, Result: testResult(), Time: time.Now()}
+	if err := WriteFile(path, BuildInfo{}, snap); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `nox_dependencies{repo="r"} 1`) {
+		t.Errorf("unexpected file contents:\n%s", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the metrics file, found %v", entries)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry(BuildInfo{Version: "dev"})
+	res := testResult()
+	reg.Set(Snapshot{Labels: map[string]string{"repo": "a"}, Result: res})
+	reg.Set(Snapshot{Labels: map[string]string{"repo": "b"}, Result: res})
+	res2 := testResult()
+	res2.Partial = true
+	reg.Set(Snapshot{Labels: map[string]string{"repo": "a"}, Result: res2})
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`nox_scan_partial{repo="a"} 1`, `nox_scan_partial{repo="b"} 0`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body is missing %q", want)
+		}
+	}
+	if n := strings.Count(body, "nox_scan_partial{"); n != 2 {
+		t.Errorf("expected one sample per label set, got %d", n)
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	if k, v, err := ParseLabel("env=prod=eu"); err != nil || k != "env" || v != "prod=eu" {
+		t.Errorf("ParseLabel = %q, %q, %v", k, v, err)
+	}
+	for _, bad := range []string{"env", "1env=x", "my-env=x", "__name=x", "severity=x", "=x"} {
+		if _, _, err := ParseLabel(bad); err == nil {
+			t.Errorf("ParseLabel(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRepoLabel(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-service")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := RepoLabel(dir); got != "my-service" {
+		t.Errorf("RepoLabel without a remote = %q, want the directory name", got)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", "https://github.com/org/repo.git"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git not available: %v: %s", err, out)
+		}
+	}
+	if got := RepoLabel(dir); got != "github.com/org/repo" {
+		t.Errorf("RepoLabel = %q, want github.com/org/repo", got)
+	}
+}
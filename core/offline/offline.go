@@ -0,0 +1,85 @@
+// Package offline implements nox's offline mode, in which nox makes no
+// outbound network connections. Offline mode is enabled with the global
+// --offline flag or by setting NOX_OFFLINE=1.
+//
+// Commands skip or refuse their network lookups in offline mode, and
+// Install backs that up by replacing http.DefaultTransport with a
+// Transport that rejects requests before anything is dialed, so that an
+// HTTP client added later cannot connect by accident.
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EnvVar is the environment variable that enables offline mode.
+const EnvVar = "NOX_OFFLINE"
+
+// ErrOffline is returned for requests made in offline mode.
+var ErrOffline = errors.New("network access is disabled in offline mode (--offline or " + EnvVar + ")")
+
+// FromEnv reports whether NOX_OFFLINE is set to a true value, such as 1 or
+// true.
+func FromEnv() bool {
+	on, _ := strconv.ParseBool(os.Getenv(EnvVar))
+	return on
+}
+
+// Transport is an http.RoundTripper that fails every request with
+// ErrOffline without dialing. Requests to loopback hosts, such as a model
+// server on localhost, are passed to Loopback instead when it is set.
+type Transport struct {
+	Loopback http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Loopback != nil && IsLoopback(req.URL.Hostname()) {
+		return t.Loopback.RoundTrip(req)
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrOffline, req.Method, req.URL.Redacted())
+}
+
+// Client returns an HTTP client whose requests all fail with ErrOffline.
+func Client() *http.Client {
+	return &http.Client{Transport: &Transport{}}
+}
+
+// Install replaces http.DefaultTransport, which every client without a
+// transport of its own uses, with a Transport that lets only loopback
+// requests through to the previous default. It returns a function that
+// restores the previous default.
+func Install() (restore func()) {
+	prev := http.DefaultTransport
+	http.DefaultTransport = &Transport{Loopback: prev}
+	return func() { http.DefaultTransport = prev }
+}
+
+// IsLoopback reports whether host, a host name or IP address without a
+// port, refers to the local machine. Names are not resolved: only
+// "localhost" and loopback addresses count.
+func IsLoopback(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
+
+// IsLoopbackURL reports whether the host of rawURL refers to the local
+// machine, as IsLoopback does.
+func IsLoopbackURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Hostname() != "" && IsLoopback(u.Hostname())
+}
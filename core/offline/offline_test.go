@@ -0,0 +1,103 @@
+package offline
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport_RejectsRequests(t *testing.T) {
+	resp, err := Client().Get("https://api.osv.dev/v1/querybatch")
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("err = %v, want ErrOffline", err)
+	}
+}
+
+func TestTransport_LoopbackPassthrough(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Loopback: http.DefaultTransport}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("loopback request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+
+	// Without Loopback even local requests are rejected.
+	if _, err := Client().Get(srv.URL); !errors.Is(err, ErrOffline) {
+		t.Fatalf("err = %v, want ErrOffline", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	prev := http.DefaultTransport
+	restore := Install()
+	if _, ok := http.DefaultTransport.(*Transport); !ok {
+		restore()
+		t.Fatalf("DefaultTransport = %T, want *Transport", http.DefaultTransport)
+	}
+	restore()
+	if http.DefaultTransport != prev {
+		t.Fatal("restore did not reinstate the previous transport")
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost", true},
+		{"LOCALHOST.", true},
+		{"ollama.localhost", true},
+		{"127.0.0.1", true},
+		{"127.1.2.3", true},
+		{"::1", true},
+		{"10.0.0.1", false},
+		{"api.openai.com", false},
+		{"localhost.example.com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsLoopback(tt.host); got != tt.want {
+			t.Errorf("IsLoopback(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestIsLoopbackURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"http://localhost:11434/v1", true},
+		{"http://[::1]:8080", true},
+		{"https://api.openai.com/v1", false},
+		{"", false},
+		{"localhost:11434", false},
+	}
+	for _, tt := range tests {
+		if got := IsLoopbackURL(tt.url); got != tt.want {
+			t.Errorf("IsLoopbackURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	for val, want := range map[string]bool{"1": true, "true": true, "0": false, "": false, "yes": false} {
+		t.Setenv(EnvVar, val)
+		if got := FromEnv(); got != want {
+			t.Errorf("FromEnv() with %s=%q = %v, want %v", EnvVar, val, got, want)
+		}
+	}
+}
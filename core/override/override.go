@@ -0,0 +1,224 @@
+// Package override implements emergency overrides for staged scans. A
+// commit trailer such as
+//
+//	Nox-Override: SEC-003:deploy/config.env:12 reason=hotfix, token rotated after merge
+//
+// lets a single finding through the commit hook. Every override that is
+// applied is appended to .nox/overrides.log so that later full scans can
+// report it until the finding is fixed or baselined.
+package override
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// TrailerKey is the commit trailer that requests an override.
+const TrailerKey = "Nox-Override"
+
+// minFingerprintPrefix is the shortest fingerprint prefix accepted in place
+// of a finding ID.
+const minFingerprintPrefix = 12
+
+// Override is a single Nox-Override trailer.
+type Override struct {
+	// FindingID is the finding ID (RULE:path:line) or fingerprint the
+	// override targets.
+	FindingID string
+	// Reason is the text after "reason=". Overrides without one are
+	// rejected.
+	Reason string
+}
+
+// Entry is one line of the override log.
+type Entry struct {
+	Time        string `json:"time"`
+	FindingID   string `json:"finding_id"`
+	Fingerprint string `json:"fingerprint"`
+	RuleID      string `json:"rule_id"`
+	Path        string `json:"path"`
+	Reason      string `json:"reason"`
+}
+
+// Rejection is an override that was not applied, with the reason why.
+type Rejection struct {
+	Override Override
+	Reason   string
+}
+
+// ParseMessage returns the Nox-Override trailers of a commit message. Like
+// git, it only looks at the last paragraph and ignores comment lines
+// starting with '#'.
+func ParseMessage(msg string) []Override {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(msg, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	start := len(lines)
+	for start > 0 && lines[start-1] != "" {
+		start--
+	}
+
+	var out []Override
+	for _, line := range lines[start:] {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), TrailerKey) {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		id, rest, _ := strings.Cut(value, " ")
+		if id == "" {
+			continue
+		}
+		o := Override{FindingID: id}
+		if r, ok := strings.CutPrefix(strings.TrimSpace(rest), "reason="); ok {
+			o.Reason = strings.Trim(strings.TrimSpace(r), `"`)
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// Apply suppresses the active findings in fs that overrides target and
+// returns a log entry for each finding let through. Overrides without a
+// reason, overrides of critical secrets findings and overrides that match
+// no active finding are rejected and leave fs unchanged.
+func Apply(fs *findings.FindingSet, rs *rules.RuleSet, overrides []Override, now time.Time) ([]Entry, []Rejection) {
+	var (
+		entries  []Entry
+		rejected []Rejection
+	)
+	items := fs.Findings()
+	for _, o := range overrides {
+		if o.Reason == "" {
+			rejected = append(rejected, Rejection{o, "no reason given (add reason=<text>)"})
+			continue
+		}
+		matched := false
+		for i := range items {
+			f := &items[i]
+			if !f.Status.IsActive() || !matches(f, o.FindingID) {
+				continue
+			}
+			if isCriticalSecret(f, rs) {
+				rejected = append(rejected, Rejection{o, fmt.Sprintf("%s is a critical secrets finding and cannot be overridden; remove and rotate the secret", f.RuleID)})
+				matched = true
+				break
+			}
+			fs.Suppress(i, findings.StatusSuppressed, o.Reason)
+			// Findings of one rule may share a metadata map.
+			f.Metadata = maps.Clone(f.Metadata)
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			f.Metadata[findings.MetaOverrideReason] = o.Reason
+			entries = append(entries, Entry{
+				Time:        now.UTC().Format(time.RFC3339),
+				FindingID:   f.ID,
+				Fingerprint: f.Fingerprint,
+				RuleID:      f.RuleID,
+				Path:        f.Location.FilePath,
+				Reason:      o.Reason,
+			})
+			matched = true
+		}
+		if !matched {
+			rejected = append(rejected, Rejection{o, "no active finding matches"})
+		}
+	}
+	return entries, rejected
+}
+
+// matches reports whether id names f, either by finding ID or by a
+// fingerprint prefix of at least minFingerprintPrefix characters.
+func matches(f *findings.Finding, id string) bool {
+	if f.ID == id || f.Fingerprint == id {
+		return true
+	}
+	return len(id) >= minFingerprintPrefix && strings.HasPrefix(f.Fingerprint, id)
+}
+
+func isCriticalSecret(f *findings.Finding, rs *rules.RuleSet) bool {
+	if f.Severity != findings.SeverityCritical {
+		return false
+	}
+	if rs != nil {
+		if r, ok := rs.ByID(f.RuleID); ok {
+			return r.Category == rules.CategorySecrets
+		}
+	}
+	return strings.HasPrefix(f.RuleID, "SEC-")
+}
+
+// DefaultLogPath returns the conventional override log location within a
+// project.
+func DefaultLogPath(root string) string {
+	return filepath.Join(root, ".nox", "overrides.log")
+}
+
+// Append adds entries to the log at path, one JSON object per line,
+// creating the file and its directory if needed. Existing lines are never
+// rewritten.
+func Append(path string, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encoding override log entry: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating override log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening override log: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("writing override log: %w", err)
+	}
+	return f.Close()
+}
+
+// Load reads the override log at path. A missing file is an empty log;
+// lines that are not valid entries are skipped.
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading override log: %w", err)
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && (e.Fingerprint != "" || e.FindingID != "") {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
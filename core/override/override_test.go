@@ -0,0 +1,123 @@
+package override
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+func TestParseMessage(t *testing.T) {
+	msg := `Hotfix: restore payments
+
+Nox-Override: SEC-003:not-a-trailer.env:1 reason=body text, not a trailer
+
+Signed-off-by: Dev <dev@example.com>
+Nox-Override: SEC-001:config.env:1 reason=rotated after merge, see INC-42
+nox-override: 3f2a9c1b0e7d4a55 reason="test fixture"
+Nox-Override: SEC-009:a.env:2
+# Nox-Override: SEC-010:b.env:3 reason=commented out
+`
+	got := ParseMessage(msg)
+	want := []Override{
+		{FindingID: "SEC-001:config.env:1", Reason: "rotated after merge, see INC-42"},
+		{FindingID: "3f2a9c1b0e7d4a55", Reason: "test fixture"},
+		{FindingID: "SEC-009:a.env:2"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseMessage() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("override %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := ParseMessage("Fix typo\n"); len(got) != 0 {
+		t.Errorf("expected no overrides in a subject-only message, got %+v", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{ID: "SEC-001:config.env:1", RuleID: "SEC-001", Severity: findings.SeverityHigh,
+		Location: findings.Location{FilePath: "config.env", StartLine: 1}, Message: "AWS key"})
+	fs.Add(findings.Finding{ID: "SEC-004:id_rsa:1", RuleID: "SEC-004", Severity: findings.SeverityCritical,
+		Location: findings.Location{FilePath: "id_rsa", StartLine: 1}, Message: "private key"})
+	fs.Add(findings.Finding{ID: "IAC-001:main.tf:3", RuleID: "IAC-001", Severity: findings.SeverityHigh,
+		Location: findings.Location{FilePath: "main.tf", StartLine: 3}, Message: "public bucket"})
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{ID: "SEC-001", Category: rules.CategorySecrets})
+	rs.Add(&rules.Rule{ID: "SEC-004", Category: rules.CategorySecrets})
+	rs.Add(&rules.Rule{ID: "IAC-001", Category: rules.CategoryIaC})
+	iacFingerprint := fs.Findings()[2].Fingerprint
+
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	entries, rejected := Apply(fs, rs, []Override{
+		{FindingID: "SEC-001:config.env:1", Reason: "rotated"},
+		{FindingID: "SEC-004:id_rsa:1", Reason: "test key"},
+		{FindingID: iacFingerprint[:minFingerprintPrefix], Reason: "bucket is a public website"},
+		{FindingID: "IAC-001:main.tf:3"},
+		{FindingID: "SEC-001:other.env:1", Reason: "typo"},
+	}, now)
+
+	if len(entries) != 2 || entries[0].FindingID != "SEC-001:config.env:1" || entries[1].Fingerprint != iacFingerprint {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if entries[0].Time != "2026-10-16T09:30:00Z" || entries[0].Reason != "rotated" || entries[0].Path != "config.env" {
+		t.Errorf("entry = %+v", entries[0])
+	}
+	if len(rejected) != 3 {
+		t.Fatalf("rejected = %+v", rejected)
+	}
+	for i, id := range []string{"SEC-004:id_rsa:1", "IAC-001:main.tf:3", "SEC-001:other.env:1"} {
+		if rejected[i].Override.FindingID != id {
+			t.Errorf("rejection %d = %+v, want %s", i, rejected[i], id)
+		}
+	}
+
+	active := fs.ActiveFindings()
+	if len(active) != 1 || active[0].RuleID != "SEC-004" {
+		t.Errorf("active findings = %+v, want only SEC-004", active)
+	}
+	if got := fs.Findings()[0].Metadata["override_reason"]; got != "rotated" {
+		t.Errorf("override_reason = %q", got)
+	}
+}
+
+func TestAppendLoad(t *testing.T) {
+	path := DefaultLogPath(t.TempDir())
+
+	if entries, err := Load(path); err != nil || len(entries) != 0 {
+		t.Fatalf("Load(missing) = %v, %v", entries, err)
+	}
+	first := Entry{Time: "2026-10-16T09:30:00Z", FindingID: "SEC-001:config.env:1", Fingerprint: "fp1", RuleID: "SEC-001", Path: "config.env", Reason: "rotated"}
+	second := Entry{Time: "2026-10-17T10:00:00Z", FindingID: "IAC-001:main.tf:3", Fingerprint: "fp2", RuleID: "IAC-001", Path: "main.tf", Reason: "website"}
+	if err := Append(path, []Entry{first}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(path, []Entry{second}); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(filepath.Dir(path)) != ".nox" {
+		t.Errorf("DefaultLogPath() = %s", path)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("not json\n")
+	f.Close()
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0] != first || entries[1] != second {
+		t.Errorf("Load() = %+v", entries)
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/codeowners"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/git"
+)
+
+// assignOwners records the CODEOWNERS owners of each finding's file. The
+// CODEOWNERS file is looked up in target and, when target is inside a git
+// repository, in the repository root, whose rules are matched against the
+// paths relative to that root. Nothing is recorded without a CODEOWNERS
+// file.
+func assignOwners(target string, fs *findings.FindingSet) {
+	co, prefix := loadCodeowners(target)
+	if co == nil {
+		return
+	}
+	co.Assign(fs.Findings(), prefix)
+	slog.Debug("assigned finding owners", "codeowners", co.Path)
+}
+
+// loadCodeowners returns the CODEOWNERS file that applies to target and
+// the path of target relative to the directory it was found in.
+func loadCodeowners(target string) (*codeowners.File, string) {
+	co, err := codeowners.Load(target)
+	if err != nil {
+		slog.Warn("skipping CODEOWNERS", "error", err)
+		return nil, ""
+	}
+	if co != nil || !git.IsGitRepo(target) {
+		return co, ""
+	}
+	root, err := git.RepoRoot(target)
+	if err != nil {
+		return nil, ""
+	}
+	co, err = codeowners.Load(root)
+	if err != nil {
+		slog.Warn("skipping CODEOWNERS", "error", err)
+		return nil, ""
+	}
+	if co == nil {
+		return nil, ""
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return nil, ""
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, ""
+	}
+	if rel == "." {
+		rel = ""
+	}
+	return co, filepath.ToSlash(rel)
+}
@@ -0,0 +1,288 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/nox-hq/nox/core/analyzers/ai"
+	"github.com/nox-hq/nox/core/analyzers/data"
+	"github.com/nox-hq/nox/core/analyzers/dotenv"
+	"github.com/nox-hq/nox/core/analyzers/iac"
+	"github.com/nox-hq/nox/core/analyzers/secrets"
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/dockerfile"
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// ScanPlan is what a scan of a target would cover: the files it would
+// analyze, with the analyzers that would read each, and the files and
+// directories it would leave out, with the reason.
+type ScanPlan struct {
+	Files   []PlannedFile `json:"files"`
+	Skipped []SkippedPath `json:"skipped"`
+	Totals  PlanTotals    `json:"totals"`
+}
+
+// PlannedFile is a file a scan would analyze.
+type PlannedFile struct {
+	Path      string   `json:"path"`
+	Type      string   `json:"type"`
+	Size      int64    `json:"size"`
+	Analyzers []string `json:"analyzers"`
+}
+
+// SkippedPath is a file, or a directory with everything in it, that a
+// scan would leave out.
+type SkippedPath struct {
+	Path   string `json:"path"`
+	Dir    bool   `json:"dir,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// PlanTotals sums up a ScanPlan. Analyzers counts the files each analyzer
+// would read; custom rules and rule packs count as "custom".
+type PlanTotals struct {
+	Files     int            `json:"files"`
+	Bytes     int64          `json:"bytes"`
+	Skipped   int            `json:"skipped"`
+	Analyzers map[string]int `json:"analyzers"`
+}
+
+// discovered is the outcome of the discovery phase of a scan.
+type discovered struct {
+	// artifacts are the files the scan covers, and content those whose
+	// content the analyzers read, which ScanOptions.Since narrows.
+	artifacts, content []discovery.Artifact
+	fileErrors         []discovery.FileError
+	configTree         *ConfigTree
+	submodules         []git.Submodule
+	// gitMetadata are the files of the git directory that only the
+	// secrets analyzer reads; see gitMetadataArtifacts.
+	gitMetadata []discovery.Artifact
+	// skipped lists the paths discovery left out.
+	skipped []SkippedPath
+	// truncated lists the content artifacts larger than
+	// scan.max_file_size, of which the analyzers read only the start.
+	truncated []discovery.TruncatedFile
+}
+
+// discover runs the discovery phase of a scan of target: it walks the
+// target, then drops the artifacts that cannot be opened, those the
+// exclude patterns of nested .nox.yaml files cover and those of excluded
+// artifact types. It caps the content artifacts larger than
+// scan.max_file_size at that size. Unless opts.Since narrows the scan, it
+// also lists the git metadata files of target that the secrets analyzer
+// reads. RunScanContext and PlanScan share it, so that a dry run lists
+// exactly the files a scan reads.
+func discover(target string, cfg *ScanConfig, opts ScanOptions) (*discovered, error) {
+	maxSize, err := maxFileSize(cfg)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	walker := discovery.NewWalker(target)
+	walker.IgnorePatterns = append(walker.IgnorePatterns, cfg.Scan.Exclude...)
+	submodules, submoduleIgnores := discoverSubmodules(target, cfg.Scan.Submodules == nil || *cfg.Scan.Submodules)
+	walker.IgnorePatterns = append(walker.IgnorePatterns, submoduleIgnores...)
+	walker.SensitivePatterns = append(slices.Clone(walker.SensitivePatterns), cfg.Scan.Secrets.SensitiveFiles...)
+	walker.Strict = opts.StrictIO
+	artifacts, err := walker.Walk()
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("discovery finished", "artifacts", len(artifacts), "duration", time.Since(start))
+
+	d := &discovered{submodules: submodules}
+	for _, s := range walker.Skipped {
+		d.skipped = append(d.skipped, SkippedPath{Path: s.Path, Dir: s.Dir, Reason: ignoreReason(s.Pattern, cfg.Scan.Exclude, submoduleIgnores)})
+	}
+
+	// Drop artifacts that cannot be opened so that a single unreadable
+	// file does not abort the whole scan.
+	d.fileErrors = walker.Errors
+	if !opts.StrictIO {
+		var unreadable []discovery.FileError
+		artifacts, unreadable = filterReadable(artifacts)
+		d.fileErrors = append(d.fileErrors, unreadable...)
+	}
+	for _, fe := range d.fileErrors {
+		d.skipped = append(d.skipped, SkippedPath{Path: fe.Path, Reason: "unreadable: " + fe.Message})
+	}
+
+	// Load nested .nox.yaml files and drop the artifacts their exclude
+	// patterns cover.
+	d.configTree, err = LoadConfigTree(target, cfg, artifacts)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	artifacts = d.dropped(artifacts, d.configTree.FilterArtifacts(artifacts), func(a discovery.Artifact) string {
+		return "excluded by scan.exclude in " + d.configTree.ExcludedBy(a.Path)
+	})
+
+	// Filter artifacts by excluded artifact types.
+	var excludeArtifactTypes []string
+	for _, et := range cfg.Scan.ExcludeArtifactTypes {
+		excludeArtifactTypes = append(excludeArtifactTypes, et.ArtifactTypes...)
+	}
+	artifacts = d.dropped(artifacts, filterArtifactsByType(artifacts, excludeArtifactTypes), func(a discovery.Artifact) string {
+		return fmt.Sprintf("artifact type %s is in scan.exclude_artifact_types", a.Type)
+	})
+	d.artifacts = artifacts
+
+	// Limit content analysis to the files changed since opts.Since. The
+	// dependency analyzer still reads every artifact, so the package
+	// inventory and OSV lookups cover all manifests.
+	d.content = artifacts
+	if opts.Since != "" {
+		changed, err := changedSince(target, opts.Since)
+		if err != nil {
+			return nil, err
+		}
+		d.content = filterChanged(artifacts, changed)
+		slog.Debug("limited to changed files", "since", opts.Since, "artifacts", len(d.content))
+	} else if cfg.Scan.Secrets.GitMetadata == nil || *cfg.Scan.Secrets.GitMetadata {
+		d.gitMetadata = gitMetadataArtifacts(target)
+	}
+	d.truncated = discovery.LimitSize(d.content, maxSize)
+	return d, nil
+}
+
+// dropped records why each of before that a filter left out of after was
+// dropped, and returns after.
+func (d *discovered) dropped(before, after []discovery.Artifact, reason func(discovery.Artifact) string) []discovery.Artifact {
+	if len(after) == len(before) {
+		return after
+	}
+	kept := make(map[string]bool, len(after))
+	for _, a := range after {
+		kept[a.Path] = true
+	}
+	for _, a := range before {
+		if !kept[a.Path] {
+			d.skipped = append(d.skipped, SkippedPath{Path: a.Path, Reason: reason(a)})
+		}
+	}
+	return after
+}
+
+// ignoreReason explains why the walk left out a path that pattern, one of
+// the walker's ignore patterns, matched.
+func ignoreReason(pattern string, exclude, submoduleIgnores []string) string {
+	switch {
+	case pattern == "":
+		return "not a regular file"
+	case slices.Contains(submoduleIgnores, pattern):
+		return "submodule not scanned (scan.submodules is false, or it is nested too deeply)"
+	case slices.Contains(exclude, pattern):
+		return fmt.Sprintf("excluded by scan.exclude pattern %q", pattern)
+	default:
+		return fmt.Sprintf("ignored by .gitignore or .noxignore pattern %q", pattern)
+	}
+}
+
+// PlanScan works out what RunScanContext would cover in target with opts
+// without running any analyzer, looking up vulnerabilities or writing
+// reports. It runs the discovery of a scan, so the files it lists are the
+// files a scan reads; an analyzer is listed for a file when one of its
+// rules applies to the file's name.
+func PlanScan(target string, opts ScanOptions) (*ScanPlan, error) {
+	cfg, err := LoadRootConfig(target, opts.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	extraSources, err := loadExtraRules(target, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := scanCategoryRegistry(cfg, extraSources)
+	if err != nil {
+		return nil, err
+	}
+	enabled, disabledAnalyzers, err := scanSelection(cfg, opts, categories)
+	if err != nil {
+		return nil, err
+	}
+	runs := func(analyzer string) bool {
+		return !slices.Contains(disabledAnalyzers, analyzer) && enabled(analyzer)
+	}
+	d, err := discover(target, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rule patterns are not compiled: only their file patterns are used.
+	contentAnalyzers := []struct {
+		name string
+		rs   *rules.RuleSet
+	}{
+		{"secrets", secrets.NewAnalyzer(secrets.WithLazyCompile()).Rules()},
+		{"data", data.NewAnalyzer(data.WithLazyCompile()).Rules()},
+		{"iac", iac.NewAnalyzer(iac.WithLazyCompile()).Rules()},
+		{"ai", ai.NewAnalyzer(ai.WithLazyCompile()).Rules()},
+	}
+	runDotenv := !slices.Contains(disabledAnalyzers, "dotenv") && enabled(rules.CategorySecrets)
+	inContent := make(map[string]bool, len(d.content))
+	for _, a := range d.content {
+		inContent[a.Path] = true
+	}
+
+	plan := &ScanPlan{Files: []PlannedFile{}, Skipped: d.skipped, Totals: PlanTotals{Analyzers: make(map[string]int)}}
+	for _, a := range d.artifacts {
+		var names []string
+		if inContent[a.Path] {
+			for _, c := range contentAnalyzers {
+				switch {
+				case c.name == "secrets" && runDotenv && dotenv.IsEnvFile(a.Path):
+					names = append(names, "dotenv")
+				case runs(c.name) && c.rs.AppliesToAny([]string{a.Path}):
+					names = append(names, c.name)
+				}
+			}
+		}
+		if !opts.Fast {
+			if runs("deps") && a.Type == discovery.Lockfile {
+				names = append(names, "deps")
+			}
+			if runs("container") && dockerfile.IsDockerfile(a.Path) {
+				names = append(names, "container")
+			}
+		}
+		if inContent[a.Path] && slices.ContainsFunc(extraSources, func(s customRuleSource) bool {
+			return s.rules.AppliesToAny([]string{a.Path})
+		}) {
+			names = append(names, "custom")
+		}
+
+		if len(names) == 0 {
+			reason := "no enabled analyzer applies"
+			if !inContent[a.Path] {
+				reason = "not changed since " + opts.Since
+			}
+			plan.Skipped = append(plan.Skipped, SkippedPath{Path: a.Path, Reason: reason})
+			continue
+		}
+		plan.Files = append(plan.Files, PlannedFile{Path: a.Path, Type: string(a.Type), Size: a.Size, Analyzers: names})
+		plan.Totals.Bytes += a.Size
+		for _, n := range names {
+			plan.Totals.Analyzers[n]++
+		}
+	}
+	if runs("secrets") && enabled(rules.CategorySecrets) {
+		for _, a := range d.gitMetadata {
+			plan.Files = append(plan.Files, PlannedFile{Path: a.Path, Type: string(a.Type), Size: a.Size, Analyzers: []string{"secrets"}})
+			plan.Totals.Bytes += a.Size
+			plan.Totals.Analyzers["secrets"]++
+		}
+	}
+	sort.SliceStable(plan.Skipped, func(i, j int) bool { return plan.Skipped[i].Path < plan.Skipped[j].Path })
+	if plan.Skipped == nil {
+		plan.Skipped = []SkippedPath{}
+	}
+	plan.Totals.Files = len(plan.Files)
+	plan.Totals.Skipped = len(plan.Skipped)
+	return plan, nil
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPlanScan(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".gitignore":        "*.log\n",
+		".nox.yaml":         "scan:\n  exclude: [\"generated/\"]\n  exclude_artifact_types:\n    - artifact_types: [container]\n",
+		"app.log":           "debug\n",
+		"generated/api.go":  "package api\n",
+		"svc/.nox.yaml":     "scan:\n  exclude: [\"fixtures/*\"]\n",
+		"svc/fixtures/a.go": "package fixtures\n",
+		"svc/main.go":       "package main\n",
+		"main.tf":           "resource \"aws_s3_bucket\" \"b\" {}\n",
+		"Dockerfile":        "FROM alpine\n",
+		"package-lock.json": "{}\n",
+		".env":              "TOKEN=x\n",
+	})
+
+	plan, err := PlanScan(dir, ScanOptions{})
+	if err != nil {
+		t.Fatalf("PlanScan: %v", err)
+	}
+	analyzers := make(map[string][]string)
+	for _, f := range plan.Files {
+		analyzers[f.Path] = f.Analyzers
+	}
+	for path, want := range map[string]string{
+		"main.tf":           "iac",
+		"package-lock.json": "deps",
+		".env":              "dotenv",
+		"svc/main.go":       "secrets",
+	} {
+		if !slices.Contains(analyzers[path], want) {
+			t.Errorf("%s: analyzers %v, want %s among them", path, analyzers[path], want)
+		}
+	}
+	if slices.Contains(analyzers[".env"], "secrets") {
+		t.Errorf(".env goes to the secrets analyzer as well as dotenv: %v", analyzers[".env"])
+	}
+
+	reasons := make(map[string]string)
+	for _, s := range plan.Skipped {
+		reasons[s.Path] = s.Reason
+	}
+	for path, want := range map[string]string{
+		"app.log":           `.gitignore or .noxignore pattern "*.log"`,
+		"generated/api.go":  `scan.exclude pattern "generated/"`,
+		"svc/fixtures/a.go": "scan.exclude in svc/.nox.yaml",
+		"Dockerfile":        "artifact type container",
+	} {
+		if !strings.Contains(reasons[path], want) {
+			t.Errorf("%s: skip reason %q, want it to mention %s", path, reasons[path], want)
+		}
+	}
+
+	if plan.Totals.Files != len(plan.Files) || plan.Totals.Skipped != len(plan.Skipped) {
+		t.Errorf("totals %+v do not match %d files and %d skipped", plan.Totals, len(plan.Files), len(plan.Skipped))
+	}
+	if plan.Totals.Analyzers["deps"] != 1 {
+		t.Errorf("deps total = %d, want 1", plan.Totals.Analyzers["deps"])
+	}
+}
+
+func TestPlanScan_Selection(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.tf":           "resource \"aws_s3_bucket\" \"b\" {}\n",
+		"package-lock.json": "{}\n",
+		".env":              "TOKEN=x\n",
+	})
+
+	plan, err := PlanScan(dir, ScanOptions{OnlyCategories: []string{"iac"}})
+	if err != nil {
+		t.Fatalf("PlanScan: %v", err)
+	}
+	for _, f := range plan.Files {
+		if !slices.Equal(f.Analyzers, []string{"iac"}) {
+			t.Errorf("%s: analyzers %v with --only-category iac", f.Path, f.Analyzers)
+		}
+	}
+	var skipped []string
+	for _, s := range plan.Skipped {
+		skipped = append(skipped, s.Path+": "+s.Reason)
+	}
+	if want := []string{".env: no enabled analyzer applies"}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped = %q, want %q", skipped, want)
+	}
+
+	plan, err = PlanScan(dir, ScanOptions{Fast: true, DisabledAnalyzers: []string{"dotenv"}})
+	if err != nil {
+		t.Fatalf("PlanScan: %v", err)
+	}
+	for _, f := range plan.Files {
+		if slices.Contains(f.Analyzers, "deps") {
+			t.Errorf("%s: fast scan lists the deps analyzer", f.Path)
+		}
+		if f.Path == ".env" && !slices.Contains(f.Analyzers, "secrets") {
+			t.Errorf(".env with dotenv disabled: analyzers %v, want secrets", f.Analyzers)
+		}
+	}
+
+	if _, err := PlanScan(dir, ScanOptions{OnlyAnalyzers: []string{"nope"}}); err == nil {
+		t.Error("expected an error for an unknown analyzer")
+	}
+}
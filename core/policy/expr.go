@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+
+	"github.com/nox-hq/nox/core/detail"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// Expression is a compiled CEL policy expression. It is evaluated against
+// the variables described in Input and returns either a bool, where true
+// allows the scan, or a map with an "allow" bool and an optional "messages"
+// list of strings:
+//
+//	counts.by_severity.critical == 0 && counts.by_severity.high <= 10
+//
+//	{"allow": !findings.exists(f, f.new && f.path.glob("services/payments/**")),
+//	 "messages": ["no new findings allowed in payments"]}
+type Expression struct {
+	source  string
+	program cel.Program
+}
+
+// InventoryStats summarises the dependency inventory for policy expressions.
+type InventoryStats struct {
+	Packages           int
+	VulnerablePackages int
+	Vulnerabilities    int
+	ByEcosystem        map[string]int
+}
+
+// Input is the data a policy expression is evaluated against.
+type Input struct {
+	Findings  []findings.Finding
+	Inventory InventoryStats
+	// Now is the time finding ages are measured at (default: the current
+	// time).
+	Now time.Time
+}
+
+// celEnv declares the variables and functions available to expressions.
+func celEnv() (*cel.Env, error) {
+	obj := cel.MapType(cel.StringType, cel.DynType)
+	return cel.NewEnv(
+		cel.Variable("findings", cel.ListType(obj)),
+		cel.Variable("counts", obj),
+		cel.Variable("baseline", obj),
+		cel.Variable("inventory", obj),
+		cel.Function("glob",
+			cel.MemberOverload("string_glob_string", []*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(func(path, pattern ref.Val) ref.Val {
+					p, ok1 := path.(types.String)
+					g, ok2 := pattern.(types.String)
+					if !ok1 || !ok2 {
+						return types.MaybeNoSuchOverloadErr(path)
+					}
+					return types.Bool(detail.MatchGlob(string(g), string(p)))
+				}),
+			),
+		),
+	)
+}
+
+// CompileExpression parses and type-checks a CEL policy expression.
+func CompileExpression(source string) (*Expression, error) {
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compiling policy expression: %w", issues.Err())
+	}
+	switch ast.OutputType().Kind() {
+	case types.BoolKind, types.MapKind, types.DynKind:
+	default:
+		return nil, fmt.Errorf("policy expression must return a bool or a map, not %s", ast.OutputType())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("building policy expression: %w", err)
+	}
+	return &Expression{source: source, program: prg}, nil
+}
+
+// String returns the expression source.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against in and reports whether the scan is
+// allowed, along with any messages the expression returned.
+func (e *Expression) Eval(in Input) (allow bool, messages []string, err error) {
+	out, _, err := e.program.Eval(activation(in))
+	if err != nil {
+		return false, nil, fmt.Errorf("evaluating policy expression: %w", err)
+	}
+
+	switch v := out.(type) {
+	case types.Bool:
+		return bool(v), nil, nil
+	case traits.Mapper:
+		a, found := v.Find(types.String("allow"))
+		if !found {
+			return false, nil, fmt.Errorf("policy expression result has no \"allow\" key")
+		}
+		b, ok := a.(types.Bool)
+		if !ok {
+			return false, nil, fmt.Errorf("policy expression \"allow\" is %s, not bool", a.Type())
+		}
+		if m, found := v.Find(types.String("messages")); found {
+			list, ok := m.(traits.Lister)
+			if !ok {
+				return false, nil, fmt.Errorf("policy expression \"messages\" is %s, not list", m.Type())
+			}
+			for it := list.Iterator(); it.HasNext() == types.True; {
+				messages = append(messages, fmt.Sprint(it.Next().Value()))
+			}
+		}
+		return bool(b), messages, nil
+	default:
+		return false, nil, fmt.Errorf("policy expression returned %s, want bool or map", out.Type())
+	}
+}
+
+// activation builds the expression variables from in. Counts cover new
+// findings only: those that are neither baselined, suppressed nor resolved
+// through VEX.
+func activation(in Input) map[string]any {
+	list := make([]any, 0, len(in.Findings))
+	bySeverity := map[string]int64{}
+	for _, sev := range findings.Severities {
+		bySeverity[string(sev)] = 0
+	}
+	byRule := map[string]int64{}
+	byPath := map[string]int64{}
+	byOwner := map[string]int64{}
+	var total, baselined, suppressed, breached int64
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	for i := range in.Findings {
+		f := &in.Findings[i]
+		isNew := f.Status.IsActive()
+		// age_days is -1 when the first-seen date is unknown.
+		ageDays := int64(-1)
+		if seen, err := time.Parse(time.RFC3339, f.Metadata[findings.MetaFirstSeen]); err == nil {
+			ageDays = int64(now.Sub(seen) / (24 * time.Hour))
+		}
+		sla := f.Metadata[findings.MetaSLAStatus]
+		owners := strings.Fields(f.Metadata[findings.MetaOwners])
+		ownerList := make([]any, len(owners))
+		for j, o := range owners {
+			ownerList[j] = o
+		}
+		list = append(list, map[string]any{
+			"id":         f.ID,
+			"rule_id":    f.RuleID,
+			"severity":   string(f.Severity),
+			"confidence": string(f.Confidence),
+			"path":       f.Location.FilePath,
+			"line":       int64(f.Location.StartLine),
+			"message":    f.Message,
+			"status":     string(f.Status),
+			"new":        isNew,
+			"first_seen": f.Metadata[findings.MetaFirstSeen],
+			"age_days":   ageDays,
+			"sla":        sla,
+			"owners":     ownerList,
+			// Untagged findings count as changed, as for ScopeChanged.
+			"in_changed_files": !f.OutsideChangedFiles(),
+		})
+		switch {
+		case isNew:
+			total++
+			bySeverity[string(f.Severity)]++
+			byRule[f.RuleID]++
+			byPath[f.Location.FilePath]++
+			for _, o := range owners {
+				byOwner[o]++
+			}
+			if sla == findings.SLABreached {
+				breached++
+			}
+		case f.Status == findings.StatusBaselined:
+			baselined++
+		default:
+			suppressed++
+		}
+	}
+
+	byEcosystem := map[string]int64{}
+	for eco, n := range in.Inventory.ByEcosystem {
+		byEcosystem[eco] = int64(n)
+	}
+
+	return map[string]any{
+		"findings": list,
+		"counts": map[string]any{
+			"total":        total,
+			"by_severity":  bySeverity,
+			"by_rule":      byRule,
+			"by_path":      byPath,
+			"by_owner":     byOwner,
+			"sla_breached": breached,
+		},
+		"baseline": map[string]any{
+			"new":        total,
+			"baselined":  baselined,
+			"suppressed": suppressed,
+		},
+		"inventory": map[string]any{
+			"packages":            int64(in.Inventory.Packages),
+			"vulnerable_packages": int64(in.Inventory.VulnerablePackages),
+			"vulnerabilities":     int64(in.Inventory.Vulnerabilities),
+			"by_ecosystem":        byEcosystem,
+		},
+	}
+}
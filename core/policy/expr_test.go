@@ -0,0 +1,188 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// gate is the example from the docs: fail on any new critical, more than
+// two new highs, or any new secret under services/payments.
+const gate = `{
+  "allow": !(counts.by_severity.critical > 0 ||
+             counts.by_severity.high > 2 ||
+             findings.exists(f, f.new && f.rule_id.startsWith("SEC-") && f.path.glob("services/payments/**"))),
+  "messages": findings.filter(f, f.new && f.path.glob("services/payments/**")).map(f, "payments: " + f.rule_id)
+}`
+
+func finding(rule string, sev findings.Severity, path string, status findings.Status) findings.Finding {
+	return findings.Finding{RuleID: rule, Severity: sev, Status: status, Location: findings.Location{FilePath: path}}
+}
+
+func TestExpression_Gate(t *testing.T) {
+	expr, err := CompileExpression(gate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ff       []findings.Finding
+		allow    bool
+		messages string
+	}{
+		{"clean", nil, true, ""},
+		{"two highs", []findings.Finding{
+			finding("IAC-001", findings.SeverityHigh, "a.tf", findings.StatusNew),
+			finding("IAC-002", findings.SeverityHigh, "b.tf", findings.StatusNew),
+		}, true, ""},
+		{"three highs", []findings.Finding{
+			finding("IAC-001", findings.SeverityHigh, "a.tf", findings.StatusNew),
+			finding("IAC-002", findings.SeverityHigh, "b.tf", findings.StatusNew),
+			finding("IAC-003", findings.SeverityHigh, "c.tf", findings.StatusNew),
+		}, false, ""},
+		{"baselined critical", []findings.Finding{
+			finding("SEC-001", findings.SeverityCritical, "a.go", findings.StatusBaselined),
+		}, true, ""},
+		{"payments secret", []findings.Finding{
+			finding("SEC-004", findings.SeverityLow, "services/payments/api/config.env", findings.StatusNew),
+		}, false, "payments: SEC-004"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow, messages, err := expr.Eval(Input{Findings: tt.ff})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allow != tt.allow || strings.Join(messages, ";") != tt.messages {
+				t.Errorf("Eval = %v, %q; want %v, %q", allow, messages, tt.allow, tt.messages)
+			}
+		})
+	}
+}
+
+func TestExpression_Variables(t *testing.T) {
+	in := Input{
+		Findings: []findings.Finding{
+			finding("SEC-001", findings.SeverityHigh, "a.go", findings.StatusNew),
+			finding("SEC-001", findings.SeverityHigh, "a.go", findings.StatusNew),
+			finding("SEC-002", findings.SeverityLow, "b.go", findings.StatusBaselined),
+			finding("SEC-003", findings.SeverityLow, "c.go", findings.StatusSuppressed),
+		},
+		Inventory: InventoryStats{Packages: 12, VulnerablePackages: 1, Vulnerabilities: 3, ByEcosystem: map[string]int{"npm": 12}},
+	}
+	in.Findings[0].Metadata = map[string]string{findings.MetaOwners: "@org/api"}
+	in.Findings[1].Metadata = map[string]string{findings.MetaOwners: "unowned"}
+	for _, src := range []string{
+		`counts.total == 2 && counts.by_rule["SEC-001"] == 2 && counts.by_path["a.go"] == 2`,
+		`baseline.new == 2 && baseline.baselined == 1 && baseline.suppressed == 1`,
+		`inventory.packages == 12 && inventory.vulnerable_packages == 1 && inventory.vulnerabilities == 3 && inventory.by_ecosystem.npm == 12`,
+		`size(findings) == 4 && findings[0].severity == "high" && !findings[2].new`,
+		`counts.by_owner["@org/api"] == 1 && counts.by_owner["unowned"] == 1 && "@org/api" in findings[0].owners && size(findings[2].owners) == 0`,
+	} {
+		expr, err := CompileExpression(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if allow, _, err := expr.Eval(in); err != nil || !allow {
+			t.Errorf("%s = %v, %v; want true", src, allow, err)
+		}
+	}
+}
+
+func TestCompileExpression_Errors(t *testing.T) {
+	for _, src := range []string{
+		`counts.total >`,
+		`unknown_var == 1`,
+		`"allow"`,
+	} {
+		if _, err := CompileExpression(src); err == nil {
+			t.Errorf("CompileExpression(%q): expected error", src)
+		}
+	}
+}
+
+func TestEvaluate_Expression(t *testing.T) {
+	deny, err := CompileExpression(`{"allow": counts.total < 1, "messages": ["too many findings"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := []findings.Finding{finding("SEC-001", findings.SeverityLow, "a.go", findings.StatusNew)}
+
+	r := Evaluate(Config{Expression: deny}, ff)
+	if r.Pass || r.ExitCode != 1 || len(r.Warnings) != 1 || r.Warnings[0] != "too many findings" {
+		t.Errorf("expected denial with message, got %+v", r)
+	}
+
+	// Without fail_on, the expression alone decides.
+	allow, _ := CompileExpression(`counts.by_severity.critical == 0`)
+	if r := Evaluate(Config{Expression: allow}, ff); !r.Pass {
+		t.Errorf("expected pass, got %+v", r)
+	}
+
+	// A runtime error fails closed.
+	broken, _ := CompileExpression(`counts.by_rule["IAC-001"] == 0`)
+	if r := Evaluate(Config{Expression: broken}, ff); r.Pass || r.ExitCode != 2 {
+		t.Errorf("expected exit code 2 for an evaluation error, got %+v", r)
+	}
+}
+
+func TestExpression_FindingAge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	aged := func(rule string, sev findings.Severity, firstSeen time.Time, sla string) findings.Finding {
+		f := finding(rule, sev, "a.go", findings.StatusNew)
+		f.Metadata = map[string]string{findings.MetaFirstSeen: firstSeen.Format(time.RFC3339), findings.MetaSLAStatus: sla}
+		return f
+	}
+	in := Input{
+		Findings: []findings.Finding{
+			aged("SEC-001", findings.SeverityHigh, now.AddDate(0, 0, -45), findings.SLABreached),
+			aged("SEC-002", findings.SeverityMedium, now.AddDate(0, 0, -45), findings.SLAWithin),
+			finding("SEC-003", findings.SeverityHigh, "b.go", findings.StatusNew),
+		},
+		Now: now,
+	}
+	for src, want := range map[string]bool{
+		// "Fail the build if any high finding is older than 30 days."
+		`!findings.exists(f, f.severity == "high" && f.age_days > 30)`: false,
+		`findings[0].age_days == 45 && findings[2].age_days == -1`:     true,
+		`counts.sla_breached == 1 && findings[1].sla == "within"`:      true,
+	} {
+		expr, err := CompileExpression(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if allow, _, err := expr.Eval(in); err != nil || allow != want {
+			t.Errorf("%s = %v, %v; want %v", src, allow, err, want)
+		}
+	}
+
+	if r := Evaluate(Config{FailOn: findings.SeverityCritical}, in.Findings); !strings.Contains(r.Summary, "1 past SLA") {
+		t.Errorf("summary = %q, want it to count the breached finding", r.Summary)
+	}
+}
+
+func TestExpression_InChangedFiles(t *testing.T) {
+	in, out := true, false
+	ff := []findings.Finding{
+		finding("SEC-001", findings.SeverityHigh, "old.go", findings.StatusNew),
+		finding("SEC-002", findings.SeverityLow, "new.go", findings.StatusNew),
+		finding("SEC-003", findings.SeverityLow, "c.go", findings.StatusNew),
+	}
+	ff[0].InChangedFiles, ff[1].InChangedFiles = &out, &in
+
+	// "Fail on high findings in the files the PR touches."
+	expr, err := CompileExpression(`!findings.exists(f, f.in_changed_files && f.severity == "high")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allow, _, err := expr.Eval(Input{Findings: ff}); err != nil || !allow {
+		t.Errorf("high finding outside the changed files denied: %v, %v", allow, err)
+	}
+	expr, _ = CompileExpression(`findings.filter(f, f.in_changed_files).size() == 2`)
+	if allow, _, err := expr.Eval(Input{Findings: ff}); err != nil || !allow {
+		t.Errorf("untagged finding not counted as changed: %v, %v", allow, err)
+	}
+}
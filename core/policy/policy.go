@@ -4,6 +4,8 @@ package policy
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/nox-hq/nox/core/findings"
@@ -21,11 +23,93 @@ const (
 	BaselineModeOff BaselineMode = "off"
 )
 
+// Scope selects the findings that can fail the policy.
+type Scope string
+
+const (
+	// ScopeAll lets every new finding fail the policy.
+	ScopeAll Scope = "all"
+	// ScopeChanged lets only findings in the files a change touches fail
+	// the policy; those in other files are reported as a warning. Findings
+	// not tagged with InChangedFiles count as changed.
+	ScopeChanged Scope = "changed"
+)
+
+// ParseScope returns the scope named by s; "" is ScopeAll.
+func ParseScope(s string) (Scope, error) {
+	switch Scope(strings.ToLower(strings.TrimSpace(s))) {
+	case "", ScopeAll:
+		return ScopeAll, nil
+	case ScopeChanged:
+		return ScopeChanged, nil
+	}
+	return "", fmt.Errorf("unknown scope %q (want changed or all)", s)
+}
+
+// SplitFailOn splits a fail-on threshold with an optional scope qualifier,
+// such as "high:changed", into the severity and the scope. The scope is
+// "" when s has no qualifier.
+func SplitFailOn(s string) (string, Scope, error) {
+	sev, qualifier, ok := strings.Cut(s, ":")
+	if !ok {
+		return s, "", nil
+	}
+	scope, err := ParseScope(qualifier)
+	if err != nil {
+		return "", "", err
+	}
+	return sev, scope, nil
+}
+
 // Config defines the policy evaluation parameters.
 type Config struct {
 	FailOn       findings.Severity `yaml:"fail_on"`
 	WarnOn       findings.Severity `yaml:"warn_on"`
 	BaselineMode BaselineMode      `yaml:"baseline_mode"`
+	// Scope limits the findings FailOn applies to (default ScopeAll).
+	Scope Scope `yaml:"scope"`
+	// Expression is an optional CEL gate evaluated after the thresholds.
+	// When set without FailOn, new findings no longer fail on their own.
+	Expression *Expression `yaml:"-"`
+	// Categories holds the thresholds of the findings of a category, as
+	// returned by Category. A threshold that is set replaces FailOn or
+	// WarnOn for them. Categories is ignored when Category is nil.
+	Categories map[string]Thresholds          `yaml:"-"`
+	Category   func(*findings.Finding) string `yaml:"-"`
+}
+
+// Thresholds are the fail and warn thresholds of a finding category.
+type Thresholds struct {
+	FailOn findings.Severity
+	WarnOn findings.Severity
+}
+
+// thresholds returns the fail and warn thresholds that apply to f.
+func (c *Config) thresholds(f *findings.Finding) (failOn, warnOn findings.Severity) {
+	failOn, warnOn = c.FailOn, c.WarnOn
+	if c.Category == nil || len(c.Categories) == 0 {
+		return failOn, warnOn
+	}
+	if t, ok := c.Categories[c.Category(f)]; ok {
+		if t.FailOn != "" {
+			failOn = t.FailOn
+		}
+		if t.WarnOn != "" {
+			warnOn = t.WarnOn
+		}
+	}
+	return failOn, warnOn
+}
+
+// failing reports whether the new finding f fails the policy on its own:
+// it meets its fail threshold or, when it has none, the policy has no
+// expression to decide instead.
+func (c *Config) failing(f *findings.Finding) bool {
+	failOn, _ := c.thresholds(f)
+	if failOn == "" {
+		return c.Expression == nil
+	}
+	return f.Severity.Valid() && meetsThreshold(f.Severity, failOn)
 }
 
 // Result holds the outcome of a policy evaluation.
@@ -38,19 +122,17 @@ type Result struct {
 	Summary   string
 }
 
-// severityRank maps severity levels to numeric ranks for comparison.
-// Lower rank = more severe.
-var severityRank = map[findings.Severity]int{
-	findings.SeverityCritical: 0,
-	findings.SeverityHigh:     1,
-	findings.SeverityMedium:   2,
-	findings.SeverityLow:      3,
-	findings.SeverityInfo:     4,
-}
-
 // Evaluate applies policy rules to the given findings and returns the result.
 func Evaluate(cfg Config, all []findings.Finding) *Result {
+	return EvaluateInput(cfg, Input{Findings: all})
+}
+
+// EvaluateInput is like Evaluate but also passes inventory statistics to
+// the policy expression. An expression that fails to evaluate fails the
+// policy with exit code 2.
+func EvaluateInput(cfg Config, in Input) *Result {
 	r := &Result{Pass: true, ExitCode: 0}
+	all := in.Findings
 
 	for i := range all {
 		finding := all[i]
@@ -62,31 +144,55 @@ func Evaluate(cfg Config, all []findings.Finding) *Result {
 		}
 	}
 
-	// Check new findings against fail threshold.
-	if cfg.FailOn != "" {
-		maxNew := maxSeverity(r.New)
-		if maxNew != "" && meetsThreshold(maxNew, cfg.FailOn) {
-			r.Pass = false
-			r.ExitCode = 1
+	// With ScopeChanged, findings outside the changed files cannot fail
+	// the policy.
+	gated, gatedBaselined := r.New, r.Baselined
+	var outside []findings.Finding
+	if cfg.Scope == ScopeChanged {
+		gated, outside = splitChanged(r.New)
+		gatedBaselined, _ = splitChanged(r.Baselined)
+	}
+
+	// Unknown severities match no threshold; say so rather than pass
+	// silently.
+	type threshold struct {
+		name string
+		sev  findings.Severity
+	}
+	checked := []threshold{{"fail_on", cfg.FailOn}, {"warn_on", cfg.WarnOn}}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Categories)) {
+		t := cfg.Categories[name]
+		checked = append(checked, threshold{"categories." + name + ".fail_on", t.FailOn}, threshold{"categories." + name + ".warn_on", t.WarnOn})
+	}
+	for _, t := range checked {
+		if t.sev != "" && !t.sev.Valid() {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("unknown %s severity %q; no finding meets it", t.name, t.sev))
 		}
-	} else if len(r.New) > 0 {
-		// No explicit threshold: any new finding fails.
+	}
+	if n := countUnknownSeverities(gated); n > 0 && cfg.FailOn != "" {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("%d new finding(s) with an unknown severity cannot fail the policy", n))
+	}
+
+	// Check new findings against their fail threshold. Without one, any
+	// new finding fails.
+	if countFailing(&cfg, gated) > 0 {
 		r.Pass = false
 		r.ExitCode = 1
 	}
+	if n := countFailing(&cfg, outside); n > 0 {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("%d new finding(s) outside the changed files would fail the policy with scope all", n))
+	}
 
 	// Handle baselined findings per mode.
 	switch cfg.BaselineMode {
 	case BaselineModeStrict:
-		if cfg.FailOn != "" {
-			maxBaselined := maxSeverity(r.Baselined)
-			if maxBaselined != "" && meetsThreshold(maxBaselined, cfg.FailOn) {
+		for i := range gatedBaselined {
+			f := &gatedBaselined[i]
+			if failOn, _ := cfg.thresholds(f); failOn == "" || f.Severity.Valid() && meetsThreshold(f.Severity, failOn) {
 				r.Pass = false
 				r.ExitCode = 1
+				break
 			}
-		} else if len(r.Baselined) > 0 {
-			r.Pass = false
-			r.ExitCode = 1
 		}
 	case BaselineModeWarn:
 		if len(r.Baselined) > 0 {
@@ -95,14 +201,30 @@ func Evaluate(cfg Config, all []findings.Finding) *Result {
 	}
 
 	// Check warnings threshold.
-	if cfg.WarnOn != "" {
-		for i := range r.New {
-			finding := r.New[i]
-			if meetsThreshold(finding.Severity, cfg.WarnOn) && !meetsThreshold(finding.Severity, cfg.FailOn) {
-				r.Warnings = append(r.Warnings, fmt.Sprintf("warning: %s finding %s in %s",
-					finding.Severity, finding.RuleID, finding.Location.FilePath))
+	for i := range r.New {
+		finding := r.New[i]
+		failOn, warnOn := cfg.thresholds(&finding)
+		if warnOn != "" && meetsThreshold(finding.Severity, warnOn) && !meetsThreshold(finding.Severity, failOn) {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("warning: %s finding %s in %s",
+				finding.Severity, finding.RuleID, finding.Location.FilePath))
+		}
+	}
+
+	if cfg.Expression != nil {
+		allow, messages, err := cfg.Expression.Eval(in)
+		switch {
+		case err != nil:
+			r.Pass = false
+			r.ExitCode = 2
+			r.Warnings = append(r.Warnings, err.Error())
+		case !allow:
+			r.Pass = false
+			r.ExitCode = 1
+			if len(messages) == 0 {
+				messages = []string{"policy expression denied the scan"}
 			}
 		}
+		r.Warnings = append(r.Warnings, messages...)
 	}
 
 	// Build summary.
@@ -111,6 +233,12 @@ func Evaluate(cfg Config, all []findings.Finding) *Result {
 	if len(r.Baselined) > 0 {
 		parts = append(parts, fmt.Sprintf("%d baselined", len(r.Baselined)))
 	}
+	if n := countSLABreached(r.New); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d past SLA", n))
+	}
+	if len(outside) > 0 {
+		parts = append(parts, fmt.Sprintf("%d outside changed files", len(outside)))
+	}
 	if r.Pass {
 		r.Summary = fmt.Sprintf("policy: pass (%s)", strings.Join(parts, ", "))
 	} else {
@@ -122,25 +250,54 @@ func Evaluate(cfg Config, all []findings.Finding) *Result {
 
 // meetsThreshold returns true if severity is at or above the threshold.
 func meetsThreshold(severity, threshold findings.Severity) bool {
-	sr, ok1 := severityRank[severity]
-	tr, ok2 := severityRank[threshold]
-	if !ok1 || !ok2 {
-		return false
+	return severity.MeetsThreshold(threshold)
+}
+
+// splitChanged splits ff into the findings in the changed files, which
+// include those not tagged either way, and the findings outside them.
+func splitChanged(ff []findings.Finding) (changed, outside []findings.Finding) {
+	for i := range ff {
+		if ff[i].OutsideChangedFiles() {
+			outside = append(outside, ff[i])
+		} else {
+			changed = append(changed, ff[i])
+		}
+	}
+	return changed, outside
+}
+
+// countFailing returns the number of findings in ff that would fail the
+// policy cfg, as reported by Config.failing.
+func countFailing(cfg *Config, ff []findings.Finding) int {
+	n := 0
+	for i := range ff {
+		if cfg.failing(&ff[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// countUnknownSeverities returns the number of findings whose severity is
+// not a nox severity.
+func countUnknownSeverities(ff []findings.Finding) int {
+	n := 0
+	for i := range ff {
+		if !ff[i].Severity.Valid() {
+			n++
+		}
 	}
-	return sr <= tr
+	return n
 }
 
-// maxSeverity returns the most severe severity in the given findings.
-func maxSeverity(ff []findings.Finding) findings.Severity {
-	best := findings.Severity("")
-	bestRank := 999
+// countSLABreached returns the number of findings past their remediation
+// deadline.
+func countSLABreached(ff []findings.Finding) int {
+	n := 0
 	for i := range ff {
-		finding := ff[i]
-		r, ok := severityRank[finding.Severity]
-		if ok && r < bestRank {
-			bestRank = r
-			best = finding.Severity
+		if ff[i].Metadata[findings.MetaSLAStatus] == findings.SLABreached {
+			n++
 		}
 	}
-	return best
+	return n
 }
@@ -1,6 +1,8 @@
 package policy
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/nox-hq/nox/core/findings"
@@ -134,3 +136,128 @@ func TestMeetsThreshold(t *testing.T) {
 		}
 	}
 }
+
+func TestEvaluate_UnknownSeverities(t *testing.T) {
+	// A finding with a severity a plugin made up cannot meet fail_on, and
+	// the policy says so instead of passing silently.
+	ff := []findings.Finding{{RuleID: "PLUGIN-1", Severity: "blocker"}}
+	r := Evaluate(Config{FailOn: findings.SeverityHigh}, ff)
+	if !r.Pass {
+		t.Error("unknown severity failed the policy")
+	}
+	if !slices.ContainsFunc(r.Warnings, func(w string) bool { return strings.Contains(w, "unknown severity") }) {
+		t.Errorf("warnings = %q, want one about the unknown severity", r.Warnings)
+	}
+
+	// An unknown threshold is reported too.
+	ff = []findings.Finding{{RuleID: "SEC-001", Severity: findings.SeverityCritical}}
+	r = Evaluate(Config{FailOn: "hgih"}, ff)
+	if !r.Pass {
+		t.Error("unknown fail_on failed the policy")
+	}
+	if !slices.ContainsFunc(r.Warnings, func(w string) bool { return strings.Contains(w, `unknown fail_on severity "hgih"`) }) {
+		t.Errorf("warnings = %q, want one about fail_on", r.Warnings)
+	}
+}
+
+func TestEvaluate_ScopeChanged(t *testing.T) {
+	in, out := true, false
+	ff := []findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityCritical, Location: findings.Location{FilePath: "old.go"}, InChangedFiles: &out},
+		{RuleID: "SEC-002", Severity: findings.SeverityLow, Location: findings.Location{FilePath: "new.go"}, InChangedFiles: &in},
+	}
+
+	r := Evaluate(Config{FailOn: findings.SeverityHigh, Scope: ScopeChanged}, ff)
+	if !r.Pass {
+		t.Fatalf("a critical finding outside the changed files failed the policy: %s", r.Summary)
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "1 new finding(s) outside the changed files") {
+		t.Errorf("warnings = %q", r.Warnings)
+	}
+	if !strings.Contains(r.Summary, "1 outside changed files") {
+		t.Errorf("summary = %q", r.Summary)
+	}
+
+	if r := Evaluate(Config{FailOn: findings.SeverityHigh, Scope: ScopeAll}, ff); r.Pass {
+		t.Error("scope all passed with a critical finding")
+	}
+
+	ff[1].Severity = findings.SeverityHigh
+	if r := Evaluate(Config{FailOn: findings.SeverityHigh, Scope: ScopeChanged}, ff); r.Pass {
+		t.Error("a high finding in a changed file passed")
+	}
+
+	// Untagged findings count as changed, and without a threshold any of
+	// them fails.
+	if r := Evaluate(Config{Scope: ScopeChanged}, []findings.Finding{{RuleID: "SEC-001", Severity: findings.SeverityLow}}); r.Pass {
+		t.Error("an untagged finding passed with scope changed")
+	}
+	if r := Evaluate(Config{Scope: ScopeChanged}, ff[:1]); !r.Pass {
+		t.Error("without a threshold, a finding outside the changed files failed")
+	}
+}
+
+func TestEvaluate_CategoryThresholds(t *testing.T) {
+	category := func(f *findings.Finding) string {
+		if strings.HasPrefix(f.RuleID, "LIC-") {
+			return "license"
+		}
+		return "secrets"
+	}
+	cfg := Config{
+		FailOn:     findings.SeverityCritical,
+		WarnOn:     findings.SeverityHigh,
+		Categories: map[string]Thresholds{"license": {FailOn: findings.SeverityMedium}},
+		Category:   category,
+	}
+
+	// A medium finding of the license category fails, a high secret only
+	// warns.
+	ff := []findings.Finding{{RuleID: "SEC-001", Severity: findings.SeverityHigh}}
+	r := Evaluate(cfg, ff)
+	if !r.Pass || len(r.Warnings) != 1 {
+		t.Errorf("high secret: pass %v, warnings %q", r.Pass, r.Warnings)
+	}
+	ff = append(ff, findings.Finding{RuleID: "LIC-X", Severity: findings.SeverityMedium})
+	if r := Evaluate(cfg, ff); r.Pass {
+		t.Error("a medium license finding passed with categories.license.fail_on medium")
+	}
+	// The category keeps warn_on from the policy when it sets none.
+	ff[1].Severity = findings.SeverityLow
+	if r := Evaluate(cfg, ff[1:]); !r.Pass || len(r.Warnings) != 0 {
+		t.Errorf("low license finding: pass %v, warnings %q", r.Pass, r.Warnings)
+	}
+
+	// Without a category function the category thresholds are ignored.
+	cfg.Category = nil
+	ff[1].Severity = findings.SeverityMedium
+	if r := Evaluate(cfg, ff[1:]); !r.Pass {
+		t.Error("category thresholds applied without a category function")
+	}
+
+	cfg.Categories["license"] = Thresholds{FailOn: "hgih"}
+	r = Evaluate(cfg, nil)
+	if !slices.ContainsFunc(r.Warnings, func(w string) bool { return strings.Contains(w, `categories.license.fail_on severity "hgih"`) }) {
+		t.Errorf("warnings = %q, want one about categories.license.fail_on", r.Warnings)
+	}
+}
+
+func TestSplitFailOn(t *testing.T) {
+	tests := []struct {
+		in    string
+		sev   string
+		scope Scope
+		err   bool
+	}{
+		{"high", "high", "", false},
+		{"high:changed", "high", ScopeChanged, false},
+		{"critical:ALL", "critical", ScopeAll, false},
+		{"high:new", "", "", true},
+	}
+	for _, tt := range tests {
+		sev, scope, err := SplitFailOn(tt.in)
+		if (err != nil) != tt.err || sev != tt.sev || scope != tt.scope {
+			t.Errorf("SplitFailOn(%q) = %q, %q, %v", tt.in, sev, scope, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nox-hq/nox/core/analyzers/ai"
+	"github.com/nox-hq/nox/core/analyzers/deps"
+	"github.com/nox-hq/nox/core/analyzers/secrets"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/report"
+	"github.com/nox-hq/nox/core/rules"
+	"github.com/nox-hq/nox/core/suppress"
+)
+
+// RunPushScan scans the files that a push introduces for secrets, for a
+// pre-receive hook on a git server. gitDir is the repository receiving the
+// push, which may be bare, and updates are the ref updates git passed the
+// hook. Only the blobs the pushed commits introduce are read (see
+// git.WalkPush). A finding on lines the file already had before the commit
+// that changed it is left out, as the push does not introduce the secret,
+// and nox:ignore directives in the pushed files apply. Each finding records
+// the ref and the commit that introduced it in its metadata.
+//
+// When ctx is done before every blob is scanned, the result holds the
+// findings of the blobs scanned so far and Partial is set.
+func RunPushScan(ctx context.Context, gitDir string, updates []git.RefUpdate) (*ScanResult, error) {
+	scanStart := time.Now()
+	now := timeNow()
+	allFindings := findings.NewFindingSet()
+	allRules := rules.NewRuleSet()
+
+	secretsAnalyzer := secrets.NewAnalyzer()
+	for _, r := range secretsAnalyzer.Rules().Rules() {
+		allRules.Add(r)
+	}
+
+	err := git.WalkPush(ctx, gitDir, updates, func(b git.PushedBlob) error {
+		matches, scanErr := secretsAnalyzer.ScanFile(b.Path, b.Content)
+		if scanErr != nil {
+			return nil // skip files that fail to scan
+		}
+
+		var directives []suppress.Suppression
+		if bytes.Contains(b.Content, suppressionMarker) {
+			directives = suppress.ScanForSuppressions(b.Content, b.Path)
+		}
+		content := rules.NormalizeLineEndings(b.Content)
+		previous := rules.NormalizeLineEndings(b.Previous)
+		for i := range matches {
+			f := &matches[i]
+			if b.Previous != nil && hadLines(previous, content, f.Location.StartLine, f.Location.EndLine) {
+				continue
+			}
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			f.Metadata["ref"] = b.Ref
+			f.Metadata[findings.MetaCommitSHA] = b.Commit.SHA
+			if b.Commit.Author != "" {
+				f.Metadata["commit_author"] = b.Commit.Author
+				f.Metadata["commit_date"] = b.Commit.Date.Format("2006-01-02T15:04:05Z")
+				f.Metadata["commit_message"] = b.Commit.Message
+			}
+			for _, s := range directives {
+				if s.MatchesFinding(f.RuleID, f.Location.StartLine, now) {
+					f.Status, f.SuppressionReason = findings.StatusSuppressed, s.Reason
+					break
+				}
+			}
+			allFindings.Add(*f)
+		}
+		return nil
+	})
+	partial := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	if err != nil && !partial {
+		return nil, fmt.Errorf("push scan: %w", err)
+	}
+
+	allFindings.Deduplicate()
+	allFindings.SortDeterministic()
+	allFindings.NumberSecretGroups()
+
+	return &ScanResult{
+		Findings:    allFindings,
+		Inventory:   &deps.PackageInventory{},
+		AIInventory: &ai.Inventory{},
+		Rules:       allRules,
+		Categories:  rules.NewCategoryRegistry(),
+		Partial:     partial,
+		Duration:    time.Since(scanStart),
+		Provenance: &report.Provenance{
+			RulesHash:  rulesHash(allRules),
+			Target:     gitDir,
+			StartedAt:  scanStart.UTC().Format(time.RFC3339),
+			FinishedAt: time.Now().UTC().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// hadLines reports whether previous, the content of a file before a
+// commit, already has lines start to end of content, its content after.
+func hadLines(previous, content []byte, start, end int) bool {
+	if start < 1 {
+		return false
+	}
+	if end < start {
+		end = start
+	}
+	lines := bytes.Split(content, []byte("\n"))
+	if end > len(lines) {
+		return false
+	}
+	span := bytes.Join(lines[start-1:end], []byte("\n"))
+	framed := append(append([]byte("\n"), previous...), '\n')
+	return bytes.Contains(framed, append(append([]byte("\n"), span...), '\n'))
+}
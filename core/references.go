@@ -0,0 +1,129 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// ReferenceSettings links rules to documentation kept outside nox, such as
+// internal runbooks.
+type ReferenceSettings struct {
+	// URLTemplate is expanded for every rule and added to its references,
+	// e.g. https://wiki.corp/security/nox/{rule_id}. It may use the
+	// variables {rule_id}, {category} and {cwe}; scan.rules.references
+	// overrides it for single rules.
+	URLTemplate string `yaml:"url_template,omitempty"`
+}
+
+// referenceVariable matches a variable of a reference URL template.
+var referenceVariable = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validateReferences checks the reference URL templates of cfg for unknown
+// variables.
+func validateReferences(cfg *ScanConfig) error {
+	if err := validateURLTemplate(cfg.References.URLTemplate); err != nil {
+		return fmt.Errorf("references.url_template: %w", err)
+	}
+	for _, id := range slices.Sorted(maps.Keys(cfg.Scan.Rules.References)) {
+		if err := validateURLTemplate(cfg.Scan.Rules.References[id]); err != nil {
+			return fmt.Errorf("scan.rules.references.%s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func validateURLTemplate(tmpl string) error {
+	for _, m := range referenceVariable.FindAllStringSubmatch(tmpl, -1) {
+		switch m[1] {
+		case "rule_id", "category", "cwe":
+		default:
+			return fmt.Errorf("unknown variable %s (want {rule_id}, {category} or {cwe})", m[0])
+		}
+	}
+	return nil
+}
+
+// referenceURL returns the reference URL cfg gives rule r: the template
+// in scan.rules.references for r, or references.url_template. It returns
+// "" when neither is set or the template names a variable r has no value
+// for, such as {cwe} for a rule without a CWE.
+func (c *ScanConfig) referenceURL(r *rules.Rule) string {
+	tmpl, ok := c.Scan.Rules.References[r.ID]
+	if !ok {
+		tmpl = c.References.URLTemplate
+	}
+	if tmpl == "" {
+		return ""
+	}
+	vars := map[string]string{"rule_id": r.ID, "category": r.Category, "cwe": r.Metadata["cwe"]}
+	missing := false
+	expanded := referenceVariable.ReplaceAllStringFunc(tmpl, func(v string) string {
+		value := vars[v[1:len(v)-1]]
+		if value == "" {
+			missing = true
+		}
+		return url.PathEscape(value)
+	})
+	if missing {
+		return ""
+	}
+	return expanded
+}
+
+// linkReferences adds the reference URL the config gives each rule of rs
+// to its references and records it in its metadata and that of the
+// findings of fs under findings.MetaReferenceURL. The rules of rs are
+// shared with the analyzers, so the rules that get a URL are copied and
+// the returned set holds the copies. A finding whose rule is not in rs,
+// such as one from a Terraform plan, is linked by its rule ID and CWE.
+func linkReferences(cfg *ScanConfig, rs *rules.RuleSet, fs *findings.FindingSet) *rules.RuleSet {
+	if cfg.References.URLTemplate == "" && len(cfg.Scan.Rules.References) == 0 {
+		return rs
+	}
+	linked := rules.NewRuleSet()
+	urls := make(map[string]string)
+	for _, r := range rs.Rules() {
+		u := cfg.referenceURL(r)
+		if u == "" {
+			linked.Add(r)
+			continue
+		}
+		urls[r.ID] = u
+		c := *r
+		c.Metadata = maps.Clone(r.Metadata)
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]string)
+		}
+		c.Metadata[findings.MetaReferenceURL] = u
+		if !slices.Contains(r.References, u) {
+			c.References = append(slices.Clone(r.References), u)
+		}
+		linked.Add(&c)
+	}
+
+	items := fs.Findings()
+	for i := range items {
+		f := &items[i]
+		u, ok := urls[f.RuleID]
+		if !ok && !linked.HasID(f.RuleID) {
+			u = cfg.referenceURL(&rules.Rule{ID: f.RuleID, Metadata: map[string]string{"cwe": f.Metadata["cwe"]}})
+		}
+		if u == "" {
+			continue
+		}
+		// Findings may share their metadata with the rule or with each
+		// other.
+		f.Metadata = maps.Clone(f.Metadata)
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[findings.MetaReferenceURL] = u
+	}
+	return linked
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+func TestReferenceURL(t *testing.T) {
+	cfg := &ScanConfig{
+		References: ReferenceSettings{URLTemplate: "https://wiki.corp/nox/{category}/{rule_id}?cwe={cwe}"},
+		Scan:       ScanSettings{Rules: RulesConfig{References: map[string]string{"SEC-002": "https://runbooks.corp/{rule_id}"}}},
+	}
+	tests := []struct {
+		rule *rules.Rule
+		want string
+	}{
+		{&rules.Rule{ID: "SEC-001", Category: "secrets", Metadata: map[string]string{"cwe": "CWE-798"}}, "https://wiki.corp/nox/secrets/SEC-001?cwe=CWE-798"},
+		{&rules.Rule{ID: "SEC-002", Category: "secrets"}, "https://runbooks.corp/SEC-002"},
+		{&rules.Rule{ID: "IAC-001", Category: "iac"}, ""},
+		{&rules.Rule{ID: "a/b c", Category: "custom", Metadata: map[string]string{"cwe": "CWE-1"}}, "https://wiki.corp/nox/custom/a%2Fb%20c?cwe=CWE-1"},
+	}
+	for _, tt := range tests {
+		if got := cfg.referenceURL(tt.rule); got != tt.want {
+			t.Errorf("%s: referenceURL = %q, want %q", tt.rule.ID, got, tt.want)
+		}
+	}
+
+	cfg.References.URLTemplate = "https://wiki.corp/{rule}"
+	if err := validateReferences(cfg); err == nil || !strings.Contains(err.Error(), "{rule}") {
+		t.Errorf("validateReferences = %v, want an error naming {rule}", err)
+	}
+}
+
+func TestRunScan_ReferenceURLs(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".nox.yaml":  "references:\n  url_template: https://wiki.corp/security/nox/{rule_id}\n",
+		"config.env": "AWS_ACCESS_KEY_ID=" + "AKIA" + "IOSFODNN7EXAMPLE\n",
+	})
+
+	result, err := RunScanWithOptions(dir, ScanOptions{DisableOSV: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := result.Findings.Findings()
+	if len(ff) == 0 {
+		t.Fatal("expected findings")
+	}
+	for _, f := range ff {
+		want := "https://wiki.corp/security/nox/" + f.RuleID
+		if got := f.Metadata[findings.MetaReferenceURL]; got != want {
+			t.Errorf("%s: reference URL %q, want %q", f.RuleID, got, want)
+		}
+		r, ok := result.Rules.ByID(f.RuleID)
+		if !ok {
+			continue
+		}
+		if !slices.Contains(r.References, want) || r.Metadata[findings.MetaReferenceURL] != want {
+			t.Errorf("%s: rule references %v, metadata %v", f.RuleID, r.References, r.Metadata)
+		}
+	}
+
+	// The built-in rules shared with other scans are left alone.
+	if err := os.Remove(filepath.Join(dir, ".nox.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	result, err = RunScanWithOptions(dir, ScanOptions{DisableOSV: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range result.Rules.Rules() {
+		if _, ok := r.Metadata[findings.MetaReferenceURL]; ok {
+			t.Fatalf("%s keeps the reference URL of an earlier scan", r.ID)
+		}
+	}
+}
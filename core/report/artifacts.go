@@ -0,0 +1,289 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportFiles lists the names of the report files nox scan writes.
+var ReportFiles = []string{
+	"findings.json",
+	"results.sarif",
+	"sbom.cdx.json",
+	"sbom.spdx.json",
+	"ai.inventory.json",
+	"gl-sast-report.json",
+	"gl-secret-detection-report.json",
+}
+
+const (
+	// RunsDir is the directory timestamped runs are written to when no
+	// output directory is configured.
+	RunsDir = "nox-reports"
+	// LatestLink is the name of the symlink to the newest timestamped run.
+	LatestLink = "latest"
+	// DefaultKeep is the number of timestamped runs kept when pruning.
+	DefaultKeep = 10
+)
+
+// runNameLayout is the time layout of timestamped run directory names.
+const runNameLayout = "20060102-150405"
+
+// runNamePattern matches run directory names, including the numeric suffix
+// added when two runs start in the same second.
+var runNamePattern = regexp.MustCompile(`^\d{8}-\d{6}(-\d+)?$`)
+
+// IsReportFile reports whether path is a report written by nox: its name is
+// one of ReportFiles and its content carries the field each format uses to
+// name the tool that produced it. Files that merely share a name, such as a
+// findings.json from another tool, are not reports. An encrypted report is
+// recognised by its name with EncryptedSuffix and the age header.
+func IsReportFile(path string) bool {
+	name := filepath.Base(path)
+	if plain, ok := strings.CutSuffix(name, EncryptedSuffix); ok {
+		return slices.Contains(ReportFiles, plain) && isAgeFile(path)
+	}
+	known := false
+	for _, f := range ReportFiles {
+		if name == f {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	switch name {
+	case "findings.json":
+		var doc struct {
+			Meta struct {
+				ToolName string `json:"tool_name"`
+			} `json:"meta"`
+		}
+		return json.Unmarshal(data, &doc) == nil && doc.Meta.ToolName == "nox"
+	case "results.sarif":
+		var doc struct {
+			Runs []struct {
+				Tool struct {
+					Driver struct {
+						Name string `json:"name"`
+					} `json:"driver"`
+				} `json:"tool"`
+			} `json:"runs"`
+		}
+		if json.Unmarshal(data, &doc) != nil {
+			return false
+		}
+		for _, r := range doc.Runs {
+			if r.Tool.Driver.Name == "nox" {
+				return true
+			}
+		}
+	case "gl-sast-report.json", "gl-secret-detection-report.json":
+		var doc struct {
+			Scan struct {
+				Scanner struct {
+					ID string `json:"id"`
+				} `json:"scanner"`
+			} `json:"scan"`
+		}
+		return json.Unmarshal(data, &doc) == nil && doc.Scan.Scanner.ID == "nox"
+	case "sbom.cdx.json":
+		var doc struct {
+			Metadata struct {
+				Tools []struct {
+					Name string `json:"name"`
+				} `json:"tools"`
+			} `json:"metadata"`
+		}
+		if json.Unmarshal(data, &doc) != nil {
+			return false
+		}
+		for _, t := range doc.Metadata.Tools {
+			if t.Name == "nox" {
+				return true
+			}
+		}
+	case "sbom.spdx.json":
+		var doc struct {
+			CreationInfo struct {
+				Creators []string `json:"creators"`
+			} `json:"creationInfo"`
+		}
+		if json.Unmarshal(data, &doc) != nil {
+			return false
+		}
+		for _, c := range doc.CreationInfo.Creators {
+			if strings.HasPrefix(c, "Tool: nox-") {
+				return true
+			}
+		}
+	case "ai.inventory.json":
+		// The inventory has no tool field; require both top-level keys it
+		// always writes.
+		var doc struct {
+			SchemaVersion string          `json:"schema_version"`
+			Components    json.RawMessage `json:"components"`
+		}
+		return json.Unmarshal(data, &doc) == nil && doc.SchemaVersion != "" && len(doc.Components) > 0
+	}
+	return false
+}
+
+// isAgeFile reports whether the file at path starts with the age header.
+func isAgeFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 64)
+	n, _ := io.ReadFull(f, head)
+	return IsEncrypted(head[:n])
+}
+
+// NewRunDir creates a directory for a timestamped run under base, named
+// after now in UTC (e.g., 20250102-150405). If a run with that name already
+// exists, a numeric suffix is added.
+func NewRunDir(base string, now time.Time) (string, error) {
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		return "", err
+	}
+	name := now.UTC().Format(runNameLayout)
+	for i := 2; ; i++ {
+		dir := filepath.Join(base, name)
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+		name = fmt.Sprintf("%s-%d", now.UTC().Format(runNameLayout), i)
+	}
+}
+
+// LinkLatest points the LatestLink symlink in base at run, replacing an
+// earlier link. The link target is relative so that base can be moved.
+func LinkLatest(base, run string) error {
+	link := filepath.Join(base, LatestLink)
+	if fi, err := os.Lstat(link); err == nil {
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("%s exists and is not a symlink", link)
+		}
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(filepath.Base(run), link)
+}
+
+// runDirs returns the timestamped run directories in base, oldest first.
+func runDirs(base string) ([]string, error) {
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && runNamePattern.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// removeRun deletes the report files in a run directory and then the
+// directory itself if nothing else is left in it.
+func removeRun(dir string) ([]string, error) {
+	removed, err := removeReports(dir)
+	if err != nil {
+		return removed, err
+	}
+	if err := os.Remove(dir); err == nil {
+		removed = append(removed, dir)
+	}
+	return removed, nil
+}
+
+// removeReports deletes the report files directly in dir.
+func removeReports(dir string) ([]string, error) {
+	var removed []string
+	for _, name := range ReportFiles {
+		for _, path := range []string{filepath.Join(dir, name), filepath.Join(dir, name+EncryptedSuffix)} {
+			if !IsReportFile(path) {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return removed, err
+			}
+			removed = append(removed, path)
+		}
+	}
+	return removed, nil
+}
+
+// PruneRuns removes all but the newest keep timestamped runs in base and
+// returns the removed paths. A run directory that holds files other than
+// reports keeps those files and is not removed.
+func PruneRuns(base string, keep int) ([]string, error) {
+	names, err := runDirs(base)
+	if err != nil || len(names) <= keep {
+		return nil, err
+	}
+	var removed []string
+	for _, name := range names[:len(names)-keep] {
+		r, err := removeRun(filepath.Join(base, name))
+		removed = append(removed, r...)
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Clean removes the report files in dir, the timestamped runs under it and
+// its LatestLink symlink, and returns the removed paths. Only files that
+// IsReportFile recognises are deleted.
+func Clean(dir string) ([]string, error) {
+	removed, err := removeReports(dir)
+	if err != nil {
+		return removed, err
+	}
+	r, err := PruneRuns(dir, 0)
+	removed = append(removed, r...)
+	if err != nil {
+		return removed, err
+	}
+
+	link := filepath.Join(dir, LatestLink)
+	if fi, err := os.Lstat(link); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Readlink(link); err == nil && runNamePattern.MatchString(target) {
+			if _, err := os.Stat(link); errors.Is(err, os.ErrNotExist) {
+				if err := os.Remove(link); err != nil {
+					return removed, err
+				}
+				removed = append(removed, link)
+			}
+		}
+	}
+	return removed, nil
+}
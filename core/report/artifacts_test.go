@@ -0,0 +1,151 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeReports writes one of each nox report file to dir.
+func writeReports(t *testing.T, dir string) {
+	t.Helper()
+	if err := NewJSONReporter("test").WriteToFile(findings.NewFindingSet(), filepath.Join(dir, "findings.json")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "results.sarif"), `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"nox"}}}]}`)
+	writeTestFile(t, filepath.Join(dir, "sbom.cdx.json"), `{"bomFormat":"CycloneDX","metadata":{"tools":[{"vendor":"nox","name":"nox"}]}}`)
+	writeTestFile(t, filepath.Join(dir, "sbom.spdx.json"), `{"spdxVersion":"SPDX-2.3","creationInfo":{"creators":["Tool: nox-test"]}}`)
+	writeTestFile(t, filepath.Join(dir, "ai.inventory.json"), `{"schema_version":"2.0.0","components":[]}`)
+	for _, name := range []string{"gl-sast-report.json", "gl-secret-detection-report.json"} {
+		writeTestFile(t, filepath.Join(dir, name), `{"version":"15.0.7","scan":{"scanner":{"id":"nox"}},"vulnerabilities":[]}`)
+	}
+}
+
+func TestIsReportFile(t *testing.T) {
+	dir := t.TempDir()
+	writeReports(t, dir)
+	for _, name := range ReportFiles {
+		if !IsReportFile(filepath.Join(dir, name)) {
+			t.Errorf("%s not recognised as a nox report", name)
+		}
+	}
+
+	other := t.TempDir()
+	writeTestFile(t, filepath.Join(other, "findings.json"), `{"findings":[]}`)
+	writeTestFile(t, filepath.Join(other, "results.sarif"), `{"runs":[{"tool":{"driver":{"name":"semgrep"}}}]}`)
+	writeTestFile(t, filepath.Join(other, "sbom.spdx.json"), `not json`)
+	writeTestFile(t, filepath.Join(other, "package.json"), `{"meta":{"tool_name":"nox"}}`)
+	writeTestFile(t, filepath.Join(other, "gl-sast-report.json"), `{"version":"15.0.7","scan":{"scanner":{"id":"semgrep"}},"vulnerabilities":[]}`)
+	for _, name := range []string{"findings.json", "results.sarif", "sbom.spdx.json", "package.json", "gl-sast-report.json", "missing.json"} {
+		if IsReportFile(filepath.Join(other, name)) {
+			t.Errorf("%s recognised as a nox report", name)
+		}
+	}
+}
+
+func TestNewRunDir(t *testing.T) {
+	base := filepath.Join(t.TempDir(), RunsDir)
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	first, err := NewRunDir(base, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewRunDir(base, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(first) != "20250102-150405" || filepath.Base(second) != "20250102-150405-2" {
+		t.Errorf("run dirs = %s, %s", first, second)
+	}
+
+	if err := LinkLatest(base, second); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkLatest(base, first); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := os.Readlink(filepath.Join(base, LatestLink)); err != nil || target != "20250102-150405" {
+		t.Errorf("latest -> %q, %v", target, err)
+	}
+}
+
+func TestPruneRuns(t *testing.T) {
+	base := t.TempDir()
+	var runs []string
+	for i := range 4 {
+		run, err := NewRunDir(base, time.Date(2025, 1, 1+i, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeReports(t, run)
+		runs = append(runs, run)
+	}
+	// A file nox did not write keeps its run directory.
+	writeTestFile(t, filepath.Join(runs[0], "notes.txt"), "keep me")
+
+	removed, err := PruneRuns(base, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2*len(ReportFiles)+1 {
+		t.Errorf("removed %d paths: %v", len(removed), removed)
+	}
+	if _, err := os.Stat(filepath.Join(runs[0], "notes.txt")); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+	if _, err := os.Stat(runs[1]); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", runs[1])
+	}
+	for _, run := range runs[2:] {
+		if !IsReportFile(filepath.Join(run, "findings.json")) {
+			t.Errorf("expected %s to be kept", run)
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	writeReports(t, dir)
+	writeTestFile(t, filepath.Join(dir, "results.sarif"), `{"runs":[{"tool":{"driver":{"name":"semgrep"}}}]}`)
+	run, err := NewRunDir(dir, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeReports(t, run)
+	if err := LinkLatest(dir, run); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Clean(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The top-level reports but the SARIF, those in the run, the run
+	// directory and the link.
+	if len(removed) != len(ReportFiles)-1+len(ReportFiles)+2 {
+		t.Errorf("removed %d paths: %v", len(removed), removed)
+	}
+	entries, _ := os.ReadDir(dir)
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	if strings.Join(left, ",") != "results.sarif" {
+		t.Errorf("left in dir: %v", left)
+	}
+}
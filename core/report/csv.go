@@ -0,0 +1,130 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// CSVColumns is the header row of the CSV report and the findings sheet of
+// the XLSX report. Columns are only ever added at the end, so spreadsheets
+// and scripts that read them by position keep working.
+var CSVColumns = []string{
+	"id", "rule_id", "severity", "confidence", "category", "path",
+	"start_line", "end_line", "message", "remediation", "first_seen",
+	"status", "owners",
+}
+
+// Positions of the CSVColumns fields that the XLSX report reads back.
+const (
+	colCategory  = 4
+	colStartLine = 6
+	colEndLine   = 7
+	colStatus    = 11
+	colOwners    = 12
+)
+
+// CSVReporter writes findings as CSV, one row per finding under the
+// CSVColumns header, for audit spreadsheets.
+type CSVReporter struct {
+	// Rules, when set, is used to look up the category and remediation of
+	// each finding's rule.
+	Rules *rules.RuleSet
+}
+
+// NewCSVReporter returns a CSVReporter that looks up rule details in rs,
+// which may be nil.
+func NewCSVReporter(rs *rules.RuleSet) *CSVReporter {
+	return &CSVReporter{Rules: rs}
+}
+
+// Generate sorts the finding set deterministically and returns it as CSV.
+func (r *CSVReporter) Generate(fs *findings.FindingSet) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.Write(&buf, fs); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write sorts the finding set deterministically and writes it to w as
+// RFC 4180 CSV: fields holding commas, quotes or newlines are quoted, and
+// lines end in CRLF as spreadsheets expect. Text fields that a spreadsheet
+// would run as a formula are prefixed with a single quote.
+func (r *CSVReporter) Write(w io.Writer, fs *findings.FindingSet) error {
+	fs.SortDeterministic()
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if err := cw.Write(CSVColumns); err != nil {
+		return err
+	}
+	for i := range fs.Findings() {
+		row := findingRow(&fs.Findings()[i], r.Rules)
+		for j, v := range row {
+			row[j] = csvSafe(v, j == colOwners)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// findingRow returns the CSVColumns fields of f. Findings without a status
+// are new; category and remediation are empty when f's rule is not in rs.
+func findingRow(f *findings.Finding, rs *rules.RuleSet) []string {
+	var category, remediation string
+	if rs != nil {
+		if rule, ok := rs.ByID(f.RuleID); ok {
+			category, remediation = rule.Category, rule.Remediation
+		}
+	}
+	status := f.Status
+	if status == "" {
+		status = findings.StatusNew
+	}
+	return []string{
+		f.ID,
+		f.RuleID,
+		string(f.Severity),
+		string(f.Confidence),
+		category,
+		f.Location.FilePath,
+		lineField(f.Location.StartLine),
+		lineField(f.Location.EndLine),
+		f.Message,
+		remediation,
+		f.Metadata[findings.MetaFirstSeen],
+		string(status),
+		f.Metadata[findings.MetaOwners],
+	}
+}
+
+// lineField formats a line number, leaving unknown (zero) lines empty.
+func lineField(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// csvSafe prefixes v with a single quote if it starts with a character
+// that makes spreadsheets treat the cell as a formula, so that text taken
+// from scanned files cannot run in the auditor's spreadsheet. A leading @
+// is left alone when allowAt is set, for CODEOWNERS handles.
+func csvSafe(v string, allowAt bool) string {
+	if v == "" || (allowAt && v[0] == '@') {
+		return v
+	}
+	if strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
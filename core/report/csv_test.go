@@ -0,0 +1,133 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// spreadsheetFindingSet returns findings whose messages hold commas, quotes,
+// newlines, unicode and formula-like text, and a rule set for the first.
+func spreadsheetFindingSet() (*findings.FindingSet, *rules.RuleSet) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{
+		ID:         "f-1",
+		RuleID:     "SEC-001",
+		Severity:   findings.SeverityHigh,
+		Confidence: findings.ConfidenceHigh,
+		Location:   findings.Location{FilePath: "config/app, prod.env", StartLine: 3, EndLine: 3},
+		Message:    `AWS key in "prod", rotate it`,
+		Metadata: map[string]string{
+			findings.MetaFirstSeen: "2026-01-02T03:04:05Z",
+			findings.MetaOwners:    "@org/platform @alice",
+		},
+	})
+	fs.Add(findings.Finding{
+		ID:         "f-2",
+		RuleID:     "CUSTOM-1",
+		Severity:   findings.SeverityLow,
+		Confidence: findings.ConfidenceMedium,
+		Location:   findings.Location{FilePath: "docs/naïve.md", StartLine: 10, EndLine: 12},
+		Message:    "Zugangsdaten für 東京 gefunden\nsecond line",
+		Status:     findings.StatusBaselined,
+	})
+	fs.Add(findings.Finding{
+		ID:       "f-3",
+		RuleID:   "CUSTOM-2",
+		Severity: findings.SeverityInfo,
+		Location: findings.Location{FilePath: "x.csv"},
+		Message:  `=HYPERLINK("http://evil.example","click")`,
+	})
+
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{
+		ID:          "SEC-001",
+		Category:    rules.CategorySecrets,
+		Remediation: "Revoke the key, then remove it from the file.",
+	})
+	return fs, rs
+}
+
+// checkGolden compares got with testdata/name, rewriting the file instead
+// when the test runs with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file:\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestCSVReporter_Golden(t *testing.T) {
+	fs, rs := spreadsheetFindingSet()
+	got, err := NewCSVReporter(rs).Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "findings.csv", got)
+}
+
+func TestCSVReporter_RoundTrips(t *testing.T) {
+	fs, rs := spreadsheetFindingSet()
+	data, err := NewCSVReporter(rs).Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want a header and 3 findings", len(records))
+	}
+	for i, rec := range records {
+		if len(rec) != len(CSVColumns) {
+			t.Errorf("record %d has %d fields, want %d", i, len(rec), len(CSVColumns))
+		}
+	}
+
+	// Findings are sorted by path: the comma in "config/app, prod.env"
+	// sorts it first.
+	byID := make(map[string][]string)
+	for _, rec := range records[1:] {
+		byID[rec[0]] = rec
+	}
+	if got := byID["f-1"]; got[5] != "config/app, prod.env" || got[8] != `AWS key in "prod", rotate it` ||
+		got[4] != "secrets" || got[9] == "" || got[10] != "2026-01-02T03:04:05Z" || got[11] != "new" || got[12] != "@org/platform @alice" {
+		t.Errorf("f-1 = %q", got)
+	}
+	if got := byID["f-2"]; got[8] != "Zugangsdaten für 東京 gefunden\nsecond line" || got[11] != "baselined" || got[4] != "" {
+		t.Errorf("f-2 = %q", got)
+	}
+	if got := byID["f-3"]; got[8] != `'=HYPERLINK("http://evil.example","click")` || got[6] != "" {
+		t.Errorf("f-3 = %q", got)
+	}
+}
+
+func TestCSVReporter_EmptySetWritesHeader(t *testing.T) {
+	got, err := NewCSVReporter(nil).Generate(findings.NewFindingSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,rule_id,severity,confidence,category,path,start_line,end_line,message,remediation,first_seen,status,owners\r\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
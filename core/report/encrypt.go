@@ -0,0 +1,182 @@
+package report
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+)
+
+// EncryptedSuffix is appended to the name of a report encrypted with age,
+// e.g. findings.json.age.
+const EncryptedSuffix = ".age"
+
+// IdentityEnv names the environment variable holding the path of the age
+// identity file, or SSH private key, used to read encrypted reports.
+const IdentityEnv = "NOX_AGE_IDENTITY"
+
+// ageIntro is the first line of every age encrypted file.
+const ageIntro = "age-encryption.org/v1\n"
+
+// ParseRecipients parses age recipients ("age1…", "ssh-ed25519 AAAA…" or
+// "ssh-rsa AAAA…").
+// Each spec may hold several comma-separated recipients; empty specs are
+// skipped.
+func ParseRecipients(specs []string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, spec := range specs {
+		for _, s := range strings.Split(spec, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			r, err := parseRecipient(s)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, r)
+		}
+	}
+	return recipients, nil
+}
+
+func parseRecipient(s string) (age.Recipient, error) {
+	switch {
+	case strings.HasPrefix(s, "age1"):
+		return age.ParseX25519Recipient(s)
+	case strings.HasPrefix(s, "ssh-"):
+		return agessh.ParseRecipient(s)
+	default:
+		return nil, fmt.Errorf("unknown recipient type %q: want age1… or an SSH public key", s)
+	}
+}
+
+// IsEncrypted reports whether data starts with the age header.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ageIntro))
+}
+
+// Encrypt encrypts data to every recipient in the age format.
+func Encrypt(data []byte, recipients ...age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts an age encrypted file with the first identity that
+// matches one of its recipients.
+func Decrypt(data []byte, identities ...age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// WriteFile writes a generated report to path with 0644 permissions. With
+// recipients, the report is encrypted to all of them and written to
+// path+EncryptedSuffix instead, and a plaintext report left at path by an
+// earlier scan is removed. It returns the path written.
+func WriteFile(path string, data []byte, recipients []age.Recipient) (string, error) {
+	if len(recipients) == 0 {
+		return path, os.WriteFile(path, data, 0o644)
+	}
+	enc, err := Encrypt(data, recipients...)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path+EncryptedSuffix, enc, 0o644); err != nil {
+		return "", err
+	}
+	if IsReportFile(path) {
+		if err := os.Remove(path); err != nil {
+			return "", err
+		}
+	}
+	return path + EncryptedSuffix, nil
+}
+
+// ReadFile reads a report written by WriteFile, decrypting it if it is
+// encrypted. If path does not exist but path+EncryptedSuffix does, the
+// encrypted report is read. Encrypted reports are opened with
+// LoadIdentities("").
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !strings.HasSuffix(path, EncryptedSuffix) {
+		if enc, encErr := os.ReadFile(path + EncryptedSuffix); encErr == nil {
+			data, err, path = enc, nil, path+EncryptedSuffix
+		}
+	}
+	if err != nil || !IsEncrypted(data) {
+		return data, err
+	}
+	ids, err := LoadIdentities("")
+	if err != nil {
+		return nil, fmt.Errorf("%s is encrypted: %w", path, err)
+	}
+	plain, err := Decrypt(data, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// LoadIdentities reads the age identities in the identity file at path:
+// "AGE-SECRET-KEY-1…" lines or an unencrypted OpenSSH private key.
+// An empty path means $NOX_AGE_IDENTITY, or else ~/.ssh/id_ed25519 when it
+// exists.
+func LoadIdentities(path string) ([]age.Identity, error) {
+	if path == "" {
+		path = os.Getenv(IdentityEnv)
+	}
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if p := filepath.Join(home, ".ssh", "id_ed25519"); fileExists(p) {
+				path = p
+			}
+		}
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no identity available: set %s to an age identity file or SSH private key", IdentityEnv)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading identity: %w", err)
+	}
+	ids, err := parseIdentities(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing identity %s: %w", path, err)
+	}
+	return ids, nil
+}
+
+func parseIdentities(data []byte) ([]age.Identity, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		id, err := agessh.ParseIdentity(data)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{id}, nil
+	}
+	return age.ParseIdentities(bytes.NewReader(data))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,131 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestWriteFileReadFile_Encrypted(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idFile := filepath.Join(t.TempDir(), "key.txt")
+	writeTestFile(t, idFile, "# test identity\n"+id.String()+"\n")
+	t.Setenv(IdentityEnv, idFile)
+
+	dir := t.TempDir()
+	writeReports(t, dir)
+	path := filepath.Join(dir, "findings.json")
+	recipients, err := ParseRecipients([]string{id.Recipient().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const content = `{"meta":{"tool_name":"nox"},"findings":[{"rule_id":"SEC-001"}]}`
+	written, err := WriteFile(path, []byte(content), recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != path+EncryptedSuffix {
+		t.Errorf("wrote %s, want %s", written, path+EncryptedSuffix)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the stale plaintext findings.json to be removed")
+	}
+	if !IsReportFile(written) {
+		t.Error("encrypted report not recognised")
+	}
+	data, _ := os.ReadFile(written)
+	if strings.Contains(string(data), "SEC-001") {
+		t.Error("encrypted report contains plaintext")
+	}
+
+	// The plaintext name falls back to the encrypted report.
+	for _, p := range []string{path, written} {
+		got, err := ReadFile(p)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", p, err)
+		}
+		if string(got) != content {
+			t.Errorf("ReadFile(%s) = %q", p, got)
+		}
+	}
+
+	t.Setenv(IdentityEnv, "")
+	t.Setenv("HOME", t.TempDir())
+	if _, err := ReadFile(path); err == nil || !strings.Contains(err.Error(), IdentityEnv) {
+		t.Errorf("err = %v, want a hint to set %s", err, IdentityEnv)
+	}
+
+	removed, err := Clean(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(removed, " "), "findings.json.age") {
+		t.Errorf("Clean did not remove the encrypted report: %v", removed)
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	a, _ := age.GenerateX25519Identity()
+	b, _ := age.GenerateX25519Identity()
+	got, err := ParseRecipients([]string{a.Recipient().String() + ", " + b.Recipient().String(), ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d recipients, want 2", len(got))
+	}
+	if _, err := ParseRecipients([]string{"age1notakey"}); err == nil {
+		t.Error("expected an error for a malformed recipient")
+	}
+}
+
+// The files in testdata/age were written by the age CLI (age -r and age -R)
+// to the identities next to them, so these tests check interoperability
+// with upstream age rather than a round trip through our own code.
+func TestReadFile_UpstreamAge(t *testing.T) {
+	const want = `{"meta":{"tool_name":"nox"},"findings":[{"rule_id":"SEC-001"}]}` + "\n"
+	for _, tc := range []struct{ identity, file string }{
+		{"key.txt", "findings.x25519.json.age"},
+		{"id_ed25519", "findings.ssh.json.age"},
+	} {
+		t.Run(tc.identity, func(t *testing.T) {
+			t.Setenv(IdentityEnv, filepath.Join("testdata", "age", tc.identity))
+			got, err := ReadFile(filepath.Join("testdata", "age", tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != want {
+				t.Errorf("ReadFile = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseRecipients_SSH(t *testing.T) {
+	pub, err := os.ReadFile(filepath.Join("testdata", "age", "id_ed25519.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := ParseRecipients([]string{string(pub)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := Encrypt([]byte("{}"), recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := LoadIdentities(filepath.Join("testdata", "age", "id_ed25519"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decrypt(enc, ids...)
+	if err != nil || string(got) != "{}" {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,366 @@
+// Package gitlab generates GitLab security reports from findings.
+//
+// GitLab's merge request security widget and vulnerability report only
+// ingest reports in GitLab's own schema, one file per report type. This
+// package writes version 15 SAST and secret detection reports: findings of
+// secrets rules go into the secret detection report and all others into
+// the SAST report.
+package gitlab
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/report"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// SchemaVersion is the version of the GitLab security report schemas the
+// reports follow.
+const SchemaVersion = "15.0.7"
+
+// ReportType is a GitLab security report type.
+type ReportType string
+
+// The report types nox writes.
+const (
+	SAST            ReportType = "sast"
+	SecretDetection ReportType = "secret_detection"
+)
+
+// FileName returns the conventional name of a report of type t, as the
+// GitLab analyzers write it.
+func (t ReportType) FileName() string {
+	return "gl-" + strings.ReplaceAll(string(t), "_", "-") + "-report.json"
+}
+
+// schemaURL returns the URL of the published schema of reports of type t.
+func (t ReportType) schemaURL() string {
+	return fmt.Sprintf("https://gitlab.com/gitlab-org/security-products/security-report-schemas/-/raw/v%s/dist/%s-report-format.json",
+		SchemaVersion, strings.ReplaceAll(string(t), "_", "-"))
+}
+
+const (
+	// toolID identifies nox as the analyzer and scanner of a report.
+	toolID = "nox"
+
+	// toolURL is the project URL of the analyzer and scanner.
+	toolURL = "https://github.com/nox-hq/nox"
+
+	// unknownCommit is the commit GitLab's secret detection analyzer
+	// records for secrets found in the working tree rather than in a
+	// commit of the history.
+	unknownCommit = "0000000"
+
+	// maxName is the maximum length of a vulnerability name.
+	maxName = 255
+)
+
+// ---------------------------------------------------------------------------
+// Report types
+// ---------------------------------------------------------------------------
+
+// Report is a GitLab security report.
+type Report struct {
+	Version         string          `json:"version"`
+	Schema          string          `json:"schema"`
+	Scan            Scan            `json:"scan"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Scan describes the scan that produced a report.
+type Scan struct {
+	Analyzer Tool       `json:"analyzer"`
+	Scanner  Tool       `json:"scanner"`
+	Type     ReportType `json:"type"`
+	// StartTime and EndTime are UTC times without a zone, as
+	// 2006-01-02T15:04:05.
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Status    string `json:"status"`
+}
+
+// Tool identifies the analyzer or scanner of a report.
+type Tool struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Version string `json:"version"`
+	Vendor  Vendor `json:"vendor"`
+}
+
+// Vendor names the vendor of a tool.
+type Vendor struct {
+	Name string `json:"name"`
+}
+
+// Vulnerability is a finding in a GitLab security report.
+type Vulnerability struct {
+	ID          string       `json:"id"`
+	Category    ReportType   `json:"category"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Severity    string       `json:"severity"`
+	Solution    string       `json:"solution,omitempty"`
+	Location    Location     `json:"location"`
+	Identifiers []Identifier `json:"identifiers"`
+	Links       []Link       `json:"links,omitempty"`
+}
+
+// Location is the place in the code of a vulnerability. Commit is only
+// set in secret detection reports.
+type Location struct {
+	File      string  `json:"file"`
+	StartLine int     `json:"start_line,omitempty"`
+	EndLine   int     `json:"end_line,omitempty"`
+	Commit    *Commit `json:"commit,omitempty"`
+}
+
+// Commit is the commit a secret was found in.
+type Commit struct {
+	SHA string `json:"sha"`
+}
+
+// Identifier names a vulnerability in some scheme: the nox rule ID, or a
+// CWE.
+type Identifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Link is a reference about a vulnerability.
+type Link struct {
+	URL string `json:"url"`
+}
+
+// ---------------------------------------------------------------------------
+// Reporter implementation
+// ---------------------------------------------------------------------------
+
+// Reporter produces GitLab security reports of one type from a
+// FindingSet. It implements the report.Reporter interface.
+type Reporter struct {
+	// Type is the report type, which decides the findings it includes.
+	Type ReportType
+
+	// ToolVersion is the version of nox recorded as analyzer and scanner.
+	ToolVersion string
+
+	// Rules is used to look up the category, name, remediation, CWE and
+	// references of each finding's rule. It may be nil.
+	Rules *rules.RuleSet
+
+	// Provenance, when set, supplies the scan's start and end times and
+	// the commit that secrets were found in.
+	Provenance *report.Provenance
+}
+
+// NewReporter returns a Reporter of reports of type t, looking up rule
+// details in rs, which may be nil.
+func NewReporter(t ReportType, version string, rs *rules.RuleSet) *Reporter {
+	return &Reporter{Type: t, ToolVersion: version, Rules: rs}
+}
+
+// Generate returns the report as indented JSON.
+func (r *Reporter) Generate(fs *findings.FindingSet) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.Write(&buf, fs); err != nil {
+		return nil, fmt.Errorf("gitlab: generate report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Write sorts the finding set deterministically and writes the report to
+// w. It includes the active findings of the report's type; suppressed,
+// baselined and VEX-resolved findings are left out, as GitLab would show
+// them as open vulnerabilities.
+func (r *Reporter) Write(w io.Writer, fs *findings.FindingSet) error {
+	fs.SortDeterministic()
+
+	items := fs.Findings()
+	start, end := r.scanTimes()
+	tool := Tool{ID: toolID, Name: toolID, URL: toolURL, Version: r.ToolVersion, Vendor: Vendor{Name: "Nox"}}
+
+	s := report.NewJSONStream(w)
+	s.BeginObject()
+	s.Key("version")
+	s.Value(SchemaVersion)
+	s.Key("schema")
+	s.Value(r.Type.schemaURL())
+	s.Key("scan")
+	s.Value(Scan{
+		Analyzer:  tool,
+		Scanner:   tool,
+		Type:      r.Type,
+		StartTime: start,
+		EndTime:   end,
+		Status:    "success",
+	})
+	s.Key("vulnerabilities")
+	s.BeginArray()
+	for i := range items {
+		f := &items[i]
+		if !f.Status.IsActive() || r.typeOf(f) != r.Type {
+			continue
+		}
+		s.Value(r.vulnerability(f))
+	}
+	s.EndArray()
+	s.EndObject()
+	return s.Flush()
+}
+
+// WriteToFile streams the report to the specified path with 0644
+// permissions. Parent directories must already exist.
+func (r *Reporter) WriteToFile(fs *findings.FindingSet, path string) error {
+	_, err := report.WriteStream(path, func(w io.Writer) error { return r.Write(w, fs) }, nil)
+	if err != nil {
+		return fmt.Errorf("gitlab: write report: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// typeOf returns the report type of f: secret detection for findings of
+// secrets rules, and SAST otherwise. Findings whose rule is unknown are
+// secrets when their rule ID has the SEC- prefix of built-in secrets rules.
+func (r *Reporter) typeOf(f *findings.Finding) ReportType {
+	if rule := r.rule(f.RuleID); rule != nil && rule.Category != "" {
+		if rule.Category == rules.CategorySecrets {
+			return SecretDetection
+		}
+		return SAST
+	}
+	if strings.HasPrefix(f.RuleID, "SEC-") {
+		return SecretDetection
+	}
+	return SAST
+}
+
+func (r *Reporter) rule(id string) *rules.Rule {
+	if r.Rules == nil {
+		return nil
+	}
+	rule, _ := r.Rules.ByID(id)
+	return rule
+}
+
+// vulnerability converts f into a vulnerability of the report's type.
+func (r *Reporter) vulnerability(f *findings.Finding) Vulnerability {
+	rule := r.rule(f.RuleID)
+	name, solution, cwe := f.Message, "", f.Metadata["cwe"]
+	var links []Link
+	if rule != nil {
+		if rule.Description != "" {
+			name = rule.Description
+		}
+		solution = rule.Remediation
+		if cwe == "" {
+			cwe = rule.Metadata["cwe"]
+		}
+		for _, ref := range rule.References {
+			links = append(links, Link{URL: ref})
+		}
+	}
+	if len(name) > maxName {
+		name = name[:maxName-3] + "..."
+	}
+
+	id := f.Fingerprint
+	if id == "" {
+		id = f.ID
+	}
+	loc := Location{
+		// Report paths use forward slashes on every platform.
+		File:      filepath.ToSlash(f.Location.FilePath),
+		StartLine: f.Location.StartLine,
+		EndLine:   f.Location.EndLine,
+	}
+	if r.Type == SecretDetection {
+		sha := unknownCommit
+		if r.Provenance != nil && r.Provenance.GitCommit != "" {
+			sha = r.Provenance.GitCommit
+		}
+		loc.Commit = &Commit{SHA: sha}
+	}
+
+	return Vulnerability{
+		ID:          id,
+		Category:    r.Type,
+		Name:        name,
+		Description: f.Message,
+		Severity:    severity(f.Severity),
+		Solution:    solution,
+		Location:    loc,
+		Identifiers: identifiers(f.RuleID, cwe),
+		Links:       links,
+	}
+}
+
+// identifiers returns the nox rule ID identifier of a finding followed by
+// one for each CWE in cwe, a comma-separated list such as
+// "CWE-798, CWE-259". The rule ID comes first because GitLab uses the
+// first identifier to track a vulnerability across pipelines.
+func identifiers(ruleID, cwe string) []Identifier {
+	ids := []Identifier{{Type: "nox_rule_id", Name: "nox " + ruleID, Value: ruleID}}
+	for c := range strings.SplitSeq(cwe, ",") {
+		c = strings.TrimSpace(c)
+		num, ok := strings.CutPrefix(strings.ToUpper(c), "CWE-")
+		if !ok || num == "" {
+			continue
+		}
+		ids = append(ids, Identifier{
+			Type:  "cwe",
+			Name:  "CWE-" + num,
+			Value: num,
+			URL:   "https://cwe.mitre.org/data/definitions/" + num + ".html",
+		})
+	}
+	return ids
+}
+
+// severity maps a nox severity to a GitLab severity.
+func severity(s findings.Severity) string {
+	switch s {
+	case findings.SeverityCritical:
+		return "Critical"
+	case findings.SeverityHigh:
+		return "High"
+	case findings.SeverityMedium:
+		return "Medium"
+	case findings.SeverityLow:
+		return "Low"
+	case findings.SeverityInfo:
+		return "Info"
+	default:
+		return "Unknown"
+	}
+}
+
+// scanTimes returns the start and end of the scan in the format the schema
+// requires, from the provenance when it has them and the current time
+// otherwise.
+func (r *Reporter) scanTimes() (start, end string) {
+	now := time.Now()
+	startT, endT := now, now
+	if p := r.Provenance; p != nil {
+		if t, err := time.Parse(time.RFC3339, p.StartedAt); err == nil {
+			startT = t
+		}
+		if t, err := time.Parse(time.RFC3339, p.FinishedAt); err == nil {
+			endT = t
+		}
+	}
+	const layout = "2006-01-02T15:04:05"
+	return startT.UTC().Format(layout), endT.UTC().Format(layout)
+}
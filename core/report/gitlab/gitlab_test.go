@@ -0,0 +1,238 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/report"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+func testRules() *rules.RuleSet {
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{
+		ID: "SEC-001", Description: "AWS Access Key ID detected", Category: rules.CategorySecrets,
+		Severity: findings.SeverityHigh, Remediation: "Rotate the key.",
+		Metadata: map[string]string{"cwe": "CWE-798"}, References: []string{"https://cwe.mitre.org/data/definitions/798.html"},
+	})
+	rs.Add(&rules.Rule{
+		ID: "IAC-001", Description: "Container runs as root", Category: rules.CategoryIaC,
+		Severity: findings.SeverityMedium, Remediation: "Add a USER instruction.",
+		Metadata: map[string]string{"cwe": "CWE-250, CWE-269"},
+	})
+	// A custom rule that finds secrets.
+	rs.Add(&rules.Rule{ID: "ACME-TOKEN", Description: "Acme API token", Category: rules.CategorySecrets, Severity: findings.SeverityCritical})
+	return rs
+}
+
+func testFindings() *findings.FindingSet {
+	fs := findings.NewFindingSet()
+	for _, f := range []findings.Finding{
+		{RuleID: "SEC-001", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "config/aws.go", StartLine: 3, EndLine: 3}, Message: "AWS Access Key ID detected", Fingerprint: "fp-sec"},
+		{RuleID: "IAC-001", Severity: findings.SeverityMedium, Location: findings.Location{FilePath: "Dockerfile", StartLine: 1, EndLine: 1}, Message: "Container runs as root", Fingerprint: "fp-iac"},
+		{RuleID: "ACME-TOKEN", Severity: findings.SeverityCritical, Location: findings.Location{FilePath: "deploy.sh", StartLine: 7, EndLine: 7}, Message: "Acme API token", Fingerprint: "fp-acme"},
+		// A rule the rule set does not know, told apart by its prefix.
+		{RuleID: "SEC-999", Severity: findings.SeverityInfo, Location: findings.Location{FilePath: "x.env", StartLine: 1}, Message: "Generic secret", Fingerprint: "fp-unknown"},
+		{RuleID: "IAC-001", Severity: findings.SeverityMedium, Location: findings.Location{FilePath: "old/Dockerfile", StartLine: 1}, Message: "Container runs as root", Fingerprint: "fp-baselined", Status: findings.StatusBaselined},
+	} {
+		fs.Add(f)
+	}
+	return fs
+}
+
+// Constraints of the version 15 SAST and secret detection report schemas
+// published at security-report-schemas on gitlab.com.
+var (
+	versionPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+	timePattern    = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`)
+	severities     = []string{"Info", "Unknown", "Low", "Medium", "High", "Critical"}
+)
+
+// validate checks doc, a decoded report, against the required properties,
+// enums, patterns and lengths of the report schema of type t.
+func validate(doc map[string]any, t ReportType) []string {
+	var errs []string
+	fail := func(format string, args ...any) { errs = append(errs, fmt.Sprintf(format, args...)) }
+	str := func(m map[string]any, key string) string { s, _ := m[key].(string); return s }
+	obj := func(m map[string]any, key string) map[string]any { o, _ := m[key].(map[string]any); return o }
+
+	if !versionPattern.MatchString(str(doc, "version")) {
+		fail("version %q does not match %s", doc["version"], versionPattern)
+	}
+	scan := obj(doc, "scan")
+	if scan == nil {
+		return append(errs, "scan is required")
+	}
+	for _, key := range []string{"analyzer", "scanner"} {
+		tool := obj(scan, key)
+		for _, field := range []string{"id", "name", "version"} {
+			if str(tool, field) == "" {
+				fail("scan.%s.%s is required", key, field)
+			}
+		}
+		if str(obj(tool, "vendor"), "name") == "" {
+			fail("scan.%s.vendor.name is required", key)
+		}
+	}
+	if str(scan, "type") != string(t) {
+		fail("scan.type = %q, want %q", scan["type"], t)
+	}
+	for _, key := range []string{"start_time", "end_time"} {
+		if !timePattern.MatchString(str(scan, key)) {
+			fail("scan.%s %q does not match %s", key, scan[key], timePattern)
+		}
+	}
+	if s := str(scan, "status"); s != "success" && s != "failure" {
+		fail("scan.status %q is not success or failure", s)
+	}
+
+	vulns, ok := doc["vulnerabilities"].([]any)
+	if !ok {
+		return append(errs, "vulnerabilities is required")
+	}
+	for i, v := range vulns {
+		vuln, _ := v.(map[string]any)
+		if str(vuln, "id") == "" {
+			fail("vulnerabilities[%d].id is required", i)
+		}
+		if len(str(vuln, "name")) > maxName {
+			fail("vulnerabilities[%d].name is longer than %d", i, maxName)
+		}
+		if s, ok := vuln["severity"]; ok && !slices.Contains(severities, s.(string)) {
+			fail("vulnerabilities[%d].severity %q is not one of %v", i, s, severities)
+		}
+		ids, _ := vuln["identifiers"].([]any)
+		if len(ids) == 0 {
+			fail("vulnerabilities[%d].identifiers needs at least one item", i)
+		}
+		for j, id := range ids {
+			for _, field := range []string{"type", "name", "value"} {
+				if str(id.(map[string]any), field) == "" {
+					fail("vulnerabilities[%d].identifiers[%d].%s is required", i, j, field)
+				}
+			}
+		}
+		loc := obj(vuln, "location")
+		if loc == nil {
+			fail("vulnerabilities[%d].location is required", i)
+			continue
+		}
+		if str(loc, "file") == "" {
+			fail("vulnerabilities[%d].location.file is required", i)
+		}
+		if t == SecretDetection && str(obj(loc, "commit"), "sha") == "" {
+			fail("vulnerabilities[%d].location.commit.sha is required", i)
+		}
+	}
+	return errs
+}
+
+func generate(t *testing.T, typ ReportType, prov *report.Provenance) (map[string]any, Report) {
+	t.Helper()
+	r := NewReporter(typ, "1.2.3", testRules())
+	r.Provenance = prov
+	data, err := r.Generate(testFindings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	var rep Report
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatal(err)
+	}
+	return doc, rep
+}
+
+func TestGenerate_Schema(t *testing.T) {
+	prov := &report.Provenance{StartedAt: "2026-03-01T10:00:00Z", FinishedAt: "2026-03-01T10:00:42+01:00", GitCommit: "3f2a9c1"}
+	for _, typ := range []ReportType{SAST, SecretDetection} {
+		doc, rep := generate(t, typ, prov)
+		for _, err := range validate(doc, typ) {
+			t.Errorf("%s: %s", typ, err)
+		}
+		if rep.Scan.StartTime != "2026-03-01T10:00:00" || rep.Scan.EndTime != "2026-03-01T09:00:42" {
+			t.Errorf("%s: scan times = %s, %s", typ, rep.Scan.StartTime, rep.Scan.EndTime)
+		}
+		if rep.Scan.Scanner.ID != "nox" || rep.Scan.Analyzer.Version != "1.2.3" || !strings.HasSuffix(rep.Schema, "/"+strings.ReplaceAll(string(typ), "_", "-")+"-report-format.json") {
+			t.Errorf("%s: scan = %+v, schema %s", typ, rep.Scan, rep.Schema)
+		}
+	}
+
+	// Without provenance the times are still in the schema's format.
+	doc, _ := generate(t, SAST, nil)
+	for _, err := range validate(doc, SAST) {
+		t.Errorf("without provenance: %s", err)
+	}
+}
+
+func TestGenerate_SplitsByCategory(t *testing.T) {
+	_, sast := generate(t, SAST, nil)
+	_, secrets := generate(t, SecretDetection, &report.Provenance{GitCommit: "3f2a9c1"})
+
+	ruleIDs := func(r Report) []string {
+		var ids []string
+		for _, v := range r.Vulnerabilities {
+			ids = append(ids, v.Identifiers[0].Value)
+		}
+		return ids
+	}
+	// The baselined IAC-001 finding is left out.
+	if got := ruleIDs(sast); !slices.Equal(got, []string{"IAC-001"}) {
+		t.Errorf("SAST rules = %v, want [IAC-001]", got)
+	}
+	if got := ruleIDs(secrets); !slices.Equal(got, []string{"SEC-001", "ACME-TOKEN", "SEC-999"}) {
+		t.Errorf("secret detection rules = %v, want [SEC-001 ACME-TOKEN SEC-999]", got)
+	}
+	for _, v := range secrets.Vulnerabilities {
+		if v.Category != SecretDetection || v.Location.Commit == nil || v.Location.Commit.SHA != "3f2a9c1" {
+			t.Errorf("secret detection vulnerability = %+v", v)
+		}
+	}
+	if v := sast.Vulnerabilities[0]; v.Category != SAST || v.Location.Commit != nil {
+		t.Errorf("SAST vulnerability = %+v", v)
+	}
+}
+
+func TestGenerate_Vulnerability(t *testing.T) {
+	_, rep := generate(t, SecretDetection, nil)
+	var v Vulnerability
+	for _, x := range rep.Vulnerabilities {
+		if x.ID == "fp-sec" {
+			v = x
+		}
+	}
+	want := Vulnerability{
+		ID: "fp-sec", Category: SecretDetection, Name: "AWS Access Key ID detected", Description: "AWS Access Key ID detected",
+		Severity: "High", Solution: "Rotate the key.",
+		Location: Location{File: "config/aws.go", StartLine: 3, EndLine: 3, Commit: &Commit{SHA: unknownCommit}},
+		Identifiers: []Identifier{
+			{Type: "nox_rule_id", Name: "nox SEC-001", Value: "SEC-001"},
+			{Type: "cwe", Name: "CWE-798", Value: "798", URL: "https://cwe.mitre.org/data/definitions/798.html"},
+		},
+		Links: []Link{{URL: "https://cwe.mitre.org/data/definitions/798.html"}},
+	}
+	got, _ := json.Marshal(v)
+	exp, _ := json.Marshal(want)
+	if string(got) != string(exp) {
+		t.Errorf("vulnerability:\n got %s\nwant %s", got, exp)
+	}
+
+	_, sast := generate(t, SAST, nil)
+	if ids := sast.Vulnerabilities[0].Identifiers; len(ids) != 3 || ids[1].Value != "250" || ids[2].Value != "269" {
+		t.Errorf("identifiers of a rule with two CWEs = %+v", ids)
+	}
+}
+
+func TestReportType_FileName(t *testing.T) {
+	if SAST.FileName() != "gl-sast-report.json" || SecretDetection.FileName() != "gl-secret-detection-report.json" {
+		t.Errorf("file names = %s, %s", SAST.FileName(), SecretDetection.FileName())
+	}
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileNames maps each format nox scan writes to the default name of its
+// report file. "ai-inventory" is the AI inventory, written whenever a scan
+// finds AI components.
+var FileNames = map[string]string{
+	"json":                    "findings.json",
+	"sarif":                   "results.sarif",
+	"cdx":                     "sbom.cdx.json",
+	"spdx":                    "sbom.spdx.json",
+	"csv":                     "findings.csv",
+	"xlsx":                    "findings.xlsx",
+	"gitlab-sast":             "gl-sast-report.json",
+	"gitlab-secret-detection": "gl-secret-detection-report.json",
+	"ai-inventory":            "ai.inventory.json",
+}
+
+// NameVars are the values of the variables of an output file name
+// template.
+type NameVars struct {
+	// Target is the name of the scanned directory or repository.
+	Target string
+	// Commit is the scanned commit, or "" outside a git repository.
+	Commit string
+	// Date is when the scan started.
+	Date time.Time
+}
+
+// NameTemplateVars lists the variables an output file name template may
+// use: {target}, {format}, {date}, {commit} and {ext}.
+var NameTemplateVars = []string{"target", "format", "date", "commit", "ext"}
+
+// nameVarRe matches a {variable} of an output file name template.
+var nameVarRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// unsafeNameRe matches the runs of characters replaced in variable values
+// so that they cannot add directories or odd characters to a file name.
+var unsafeNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// OutputNames names the report files of a scan, relative to the output
+// directory. Template, when set, names the file of every format; otherwise
+// Files names the formats it lists and the rest keep their FileNames
+// default. Both may use the variables in NameTemplateVars.
+type OutputNames struct {
+	Template string
+	Files    map[string]string
+	Vars     NameVars
+}
+
+// Name returns the file name of the report of format.
+func (n OutputNames) Name(format string) string {
+	tmpl := n.Template
+	if tmpl == "" {
+		tmpl = n.Files[format]
+	}
+	if tmpl == "" {
+		return FileNames[format]
+	}
+	return expandName(tmpl, format, n.Vars)
+}
+
+// Validate checks the templates before a scan so that a mistake does not
+// surface only when the reports are written: each template may use only
+// the known variables and must name a file inside the output directory,
+// Files may list only known formats, and no two of formats may be written
+// to the same file.
+func (n OutputNames) Validate(formats []string) error {
+	if n.Template != "" {
+		if err := validateNameTemplate(n.Template); err != nil {
+			return fmt.Errorf("output template %q: %w", n.Template, err)
+		}
+	}
+	keys := make([]string, 0, len(n.Files))
+	for k := range n.Files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, format := range keys {
+		if _, ok := FileNames[format]; !ok {
+			return fmt.Errorf("output.files: unknown format %q", format)
+		}
+		if err := validateNameTemplate(n.Files[format]); err != nil {
+			return fmt.Errorf("output.files.%s %q: %w", format, n.Files[format], err)
+		}
+	}
+
+	byName := make(map[string]string)
+	for _, format := range formats {
+		name := filepath.Clean(n.Name(format))
+		if other, ok := byName[name]; ok && other != format {
+			return fmt.Errorf("the %s and %s reports would both be written to %s; add {format} or {ext} to the name", other, format, name)
+		}
+		byName[name] = format
+	}
+	return nil
+}
+
+// validateNameTemplate checks that tmpl uses only known variables and,
+// expanded, is a local path: relative and without "..".
+func validateNameTemplate(tmpl string) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return errors.New("empty file name")
+	}
+	for _, m := range nameVarRe.FindAllStringSubmatch(tmpl, -1) {
+		if !slices.Contains(NameTemplateVars, m[1]) {
+			return fmt.Errorf("unknown variable {%s} (want one of {%s})", m[1], strings.Join(NameTemplateVars, "}, {"))
+		}
+	}
+	if rest := nameVarRe.ReplaceAllString(tmpl, ""); strings.ContainsAny(rest, "{}") {
+		return errors.New("unbalanced braces")
+	}
+	name := expandName(tmpl, "json", NameVars{Target: "target", Commit: "commit"})
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return errors.New("must be a path inside the output directory")
+	}
+	return nil
+}
+
+// NameGlob returns a filepath.Match pattern for the names that tmpl
+// expands to, with each variable matching any run of characters within a
+// path element. Characters of tmpl that are special to filepath.Match are
+// escaped, except on Windows, where filepath.Match has no escapes.
+func NameGlob(tmpl string) string {
+	escape := globEscaper.Replace
+	if filepath.Separator == '\\' {
+		escape = func(s string) string { return s }
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range nameVarRe.FindAllStringIndex(tmpl, -1) {
+		b.WriteString(escape(tmpl[last:m[0]]))
+		b.WriteString("*")
+		last = m[1]
+	}
+	b.WriteString(escape(tmpl[last:]))
+	return filepath.FromSlash(b.String())
+}
+
+// globEscaper escapes the characters filepath.Match treats as special.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+
+// expandName replaces the variables of tmpl for the report of format.
+// {ext} is the default file name's extension, which for the SBOMs includes
+// the format ("cdx.json"), and {commit} the abbreviated commit or
+// "nocommit".
+func expandName(tmpl, format string, v NameVars) string {
+	return nameVarRe.ReplaceAllStringFunc(tmpl, func(m string) string {
+		var value string
+		switch m[1 : len(m)-1] {
+		case "target":
+			value = v.Target
+		case "format":
+			value = format
+		case "date":
+			value = v.Date.Format("2006-01-02")
+		case "commit":
+			value = v.Commit
+			if len(value) > 12 {
+				value = value[:12]
+			}
+			if value == "" {
+				value = "nocommit"
+			}
+		case "ext":
+			_, value, _ = strings.Cut(FileNames[format], ".")
+		default:
+			return m
+		}
+		value = strings.Trim(unsafeNameRe.ReplaceAllString(value, "-"), "-")
+		if value == "" || value == "." || value == ".." {
+			value = "unknown"
+		}
+		return value
+	})
+}
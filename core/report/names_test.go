@@ -0,0 +1,87 @@
+package report
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputNames_Name(t *testing.T) {
+	vars := NameVars{Target: "my api", Commit: "0123456789abcdef0123", Date: time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)}
+	tests := []struct {
+		names  OutputNames
+		format string
+		want   string
+	}{
+		{OutputNames{}, "json", "findings.json"},
+		{OutputNames{}, "gitlab-sast", "gl-sast-report.json"},
+		{OutputNames{Template: "reports/{target}-{date}.{ext}"}, "json", "reports/my-api-2025-01-02.json"},
+		{OutputNames{Template: "reports/{target}-{date}.{ext}"}, "cdx", "reports/my-api-2025-01-02.cdx.json"},
+		{OutputNames{Template: "{commit}/{format}.out"}, "sarif", "0123456789ab/sarif.out"},
+		{OutputNames{Template: "{commit}-{ext}", Vars: NameVars{Target: "api"}}, "ai-inventory", "nocommit-inventory.json"},
+		{OutputNames{Files: map[string]string{"sarif": "nox.sarif"}}, "sarif", "nox.sarif"},
+		{OutputNames{Files: map[string]string{"sarif": "nox.sarif"}}, "json", "findings.json"},
+		{OutputNames{Template: "{format}.{ext}", Files: map[string]string{"json": "x.json"}}, "json", "json.json"},
+		{OutputNames{Template: "{target}.{ext}", Vars: NameVars{Target: ".."}}, "json", "unknown.json"},
+	}
+	for _, tc := range tests {
+		n := tc.names
+		if n.Vars.Target == "" {
+			n.Vars = vars
+		}
+		if got := n.Name(tc.format); got != tc.want {
+			t.Errorf("%+v.Name(%q) = %q, want %q", tc.names, tc.format, got, tc.want)
+		}
+	}
+}
+
+func TestOutputNames_Validate(t *testing.T) {
+	tests := []struct {
+		names   OutputNames
+		formats []string
+		wantErr string
+	}{
+		{OutputNames{}, []string{"json", "sarif", "ai-inventory"}, ""},
+		{OutputNames{Template: "{target}-{format}.{ext}"}, []string{"json", "sarif", "cdx", "spdx"}, ""},
+		{OutputNames{Template: "{target}.{ext}"}, []string{"json", "sarif"}, ""},
+		{OutputNames{Template: "{taget}.json"}, []string{"json"}, "unknown variable {taget}"},
+		{OutputNames{Template: "{target.json"}, []string{"json"}, "unbalanced braces"},
+		{OutputNames{Template: "../{target}.{ext}"}, []string{"json"}, "inside the output directory"},
+		{OutputNames{Template: "/tmp/{target}.{ext}"}, []string{"json"}, "inside the output directory"},
+		{OutputNames{Template: "{target}.{ext}"}, []string{"json", "gitlab-sast"}, "json and gitlab-sast reports would both be written to target.json"},
+		{OutputNames{Files: map[string]string{"jsn": "x.json"}}, []string{"json"}, `unknown format "jsn"`},
+		{OutputNames{Files: map[string]string{"sarif": "findings.json"}}, []string{"json", "sarif"}, "both be written to findings.json"},
+	}
+	for _, tc := range tests {
+		tc.names.Vars.Target = "target"
+		err := tc.names.Validate(tc.formats)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%+v: unexpected error %v", tc.names, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%+v: error = %v, want %q", tc.names, err, tc.wantErr)
+		}
+	}
+}
+
+func TestNameGlob(t *testing.T) {
+	tests := []struct {
+		tmpl, name string
+		want       bool
+	}{
+		{"findings.json", "findings.json", true},
+		{"reports/{target}-{date}.{ext}", "reports/api-2025-01-02.json", true},
+		{"reports/{target}-{date}.{ext}", "other/api-2025-01-02.json", false},
+		{"{commit}.json", "0123456789ab.json", true},
+		{"{commit}.json", "sub/0123456789ab.json", false},
+		{"scan[1].json", "scan[1].json", true},
+		{"scan[1].json", "scan1.json", false},
+	}
+	for _, tc := range tests {
+		got, err := filepath.Match(NameGlob(tc.tmpl), filepath.FromSlash(tc.name))
+		if err != nil || got != tc.want {
+			t.Errorf("Match(NameGlob(%q), %q) = %v, %v; want %v", tc.tmpl, tc.name, got, err, tc.want)
+		}
+	}
+}
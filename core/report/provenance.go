@@ -0,0 +1,83 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Provenance records what produced a report: the nox build, the effective
+// rule set and config, the scan parameters that affect results, and the
+// scanned tree. Two reports with the same Hash were produced by the same
+// rules, config and parameters and can be compared directly.
+type Provenance struct {
+	// Hash summarises ToolVersion, RulesHash, ConfigHash and Parameters.
+	// It is set by Seal.
+	Hash        string         `json:"hash"`
+	ToolVersion string         `json:"tool_version"`
+	ToolCommit  string         `json:"tool_commit,omitempty"`
+	RulesHash   string         `json:"rules_hash"`
+	ConfigHash  string         `json:"config_hash"`
+	Target      string         `json:"target"`
+	GitCommit   string         `json:"git_commit,omitempty"`
+	Parameters  ScanParameters `json:"parameters"`
+	StartedAt   string         `json:"started_at"`
+	FinishedAt  string         `json:"finished_at"`
+	// Remote is the URL and ref of the repository a remote scan cloned,
+	// without credentials. GitCommit is then the commit that was scanned.
+	Remote string `json:"remote,omitempty"`
+	// Scope is ScopePartial when only part of the target's files were
+	// analyzed, as with --since, and empty for a full scan.
+	Scope string `json:"scope,omitempty"`
+}
+
+// ScopePartial is the Provenance.Scope of a scan that analyzed only part
+// of the target's files.
+const ScopePartial = "partial"
+
+// ScanParameters are the scan options that change which findings a report
+// contains.
+type ScanParameters struct {
+	SeverityThreshold string   `json:"severity_threshold,omitempty"`
+	OnlyCategories    []string `json:"only_categories,omitempty"`
+	SkipCategories    []string `json:"skip_categories,omitempty"`
+	NoOSV             bool     `json:"no_osv"`
+	Staged            bool     `json:"staged,omitempty"`
+	Fast              bool     `json:"fast,omitempty"`
+	// VerifyPins is set when pinned digests and commits were looked up
+	// online, which adds the SUPPLY-004 and SUPPLY-005 findings.
+	VerifyPins bool `json:"verify_pins,omitempty"`
+	// DisabledAnalyzers are the analyzers that did not run, so the report
+	// has no findings from them.
+	DisabledAnalyzers []string `json:"disabled_analyzers,omitempty"`
+	// Since is the git ref or duration that limited content analysis to
+	// the files changed since then.
+	Since string `json:"since,omitempty"`
+	// ChangedFilesFrom is the file or git diff spec that listed the files
+	// the change touches, by which findings were tagged InChangedFiles.
+	ChangedFilesFrom string `json:"changed_files_from,omitempty"`
+}
+
+// Seal sets p.Hash from the fields that determine the findings. The target,
+// git commit and timestamps are left out so that scans of different trees
+// with the same setup share a hash.
+func (p *Provenance) Seal() {
+	p.Hash = HashJSON(struct {
+		ToolVersion string
+		RulesHash   string
+		ConfigHash  string
+		Parameters  ScanParameters
+	}{p.ToolVersion, p.RulesHash, p.ConfigHash, p.Parameters})
+}
+
+// HashJSON returns "sha256:" followed by the hex SHA-256 of v's JSON
+// encoding. Map keys are encoded in sorted order, so equal values hash
+// equally.
+func HashJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProvenanceSeal(t *testing.T) {
+	base := Provenance{
+		ToolVersion: "1.2.0",
+		RulesHash:   "sha256:aa",
+		ConfigHash:  "sha256:bb",
+		Target:      "/src/app",
+		StartedAt:   "2026-01-01T00:00:00Z",
+		Parameters:  ScanParameters{SeverityThreshold: "high"},
+	}
+	a := base
+	a.Seal()
+	if !strings.HasPrefix(a.Hash, "sha256:") {
+		t.Fatalf("Hash = %q, want a sha256 digest", a.Hash)
+	}
+
+	// The target, commit and timestamps do not affect the hash.
+	b := base
+	b.Target, b.GitCommit, b.StartedAt = "/other", "abc", "2026-02-01T00:00:00Z"
+	b.Seal()
+	if a.Hash != b.Hash {
+		t.Errorf("hash changed with target and timestamps: %s != %s", a.Hash, b.Hash)
+	}
+
+	for name, change := range map[string]func(p *Provenance){
+		"rules":      func(p *Provenance) { p.RulesHash = "sha256:cc" },
+		"config":     func(p *Provenance) { p.ConfigHash = "sha256:cc" },
+		"version":    func(p *Provenance) { p.ToolVersion = "1.3.0" },
+		"parameters": func(p *Provenance) { p.Parameters.NoOSV = true },
+	} {
+		c := base
+		change(&c)
+		c.Seal()
+		if c.Hash == a.Hash {
+			t.Errorf("expected the hash to change with %s", name)
+		}
+	}
+}
@@ -4,11 +4,15 @@
 package report
 
 import (
-	"encoding/json"
-	"os"
+	"bytes"
+	"io"
 	"time"
 
+	"github.com/nox-hq/nox/core/ci"
+	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+	"github.com/nox-hq/nox/core/suppress"
 )
 
 // Reporter defines the contract for serializing a FindingSet into a byte
@@ -19,24 +23,107 @@ type Reporter interface {
 }
 
 // Meta contains metadata about the report itself, including schema
-// version, generation timestamp, and tool identification.
+// version, generation timestamp, tool identification, and the CI run that
+// produced the report, if any.
 type Meta struct {
-	SchemaVersion string `json:"schema_version"`
-	GeneratedAt   string `json:"generated_at"`
-	ToolName      string `json:"tool_name"`
-	ToolVersion   string `json:"tool_version"`
+	SchemaVersion string  `json:"schema_version"`
+	GeneratedAt   string  `json:"generated_at"`
+	ToolName      string  `json:"tool_name"`
+	ToolVersion   string  `json:"tool_version"`
+	CI            *ci.Env `json:"ci,omitempty"`
 }
 
 // JSONReport is the top-level structure serialized to JSON. It pairs report
-// metadata with the ordered list of findings.
+// metadata with the ordered list of findings and any files that were skipped
+// because they could not be read.
 type JSONReport struct {
-	Meta     Meta               `json:"meta"`
-	Findings []findings.Finding `json:"findings"`
+	Meta       Meta               `json:"meta"`
+	Provenance *Provenance        `json:"provenance,omitempty"`
+	Summary    *Summary           `json:"summary,omitempty"`
+	Findings   []findings.Finding `json:"findings"`
+	// Suppressions inventories the nox:ignore directives in the scanned
+	// files.
+	Suppressions []suppress.Record     `json:"suppressions,omitempty"`
+	Errors       []discovery.FileError `json:"errors"`
+	// Truncated lists the files of which only the start was scanned
+	// because they are larger than scan.max_file_size.
+	Truncated []discovery.TruncatedFile `json:"truncated,omitempty"`
+	// LineNotes lists the lines that were matched only in part, because
+	// they are longer than the line length cap or a rule exceeded the
+	// per-line matching budget on them.
+	LineNotes []rules.LineNote `json:"line_notes,omitempty"`
+	Partial   bool             `json:"partial,omitempty"`
+}
+
+// Summary counts the active findings of a report by rule category and,
+// when set, records where the time of the scan went. Categories lists the
+// categories registered for the scan, built in and declared.
+type Summary struct {
+	ByCategory  map[string]int       `json:"by_category"`
+	Categories  []rules.CategoryInfo `json:"categories,omitempty"`
+	Performance *Performance         `json:"performance,omitempty"`
+}
+
+// Performance records the wall time of a scan, of each analyzer pass and
+// of the slowest rules. Durations are in milliseconds.
+type Performance struct {
+	DurationMS   float64               `json:"duration_ms"`
+	Analyzers    []AnalyzerPerformance `json:"analyzers"`
+	SlowestRules []RulePerformance     `json:"slowest_rules"`
+}
+
+// AnalyzerPerformance is the wall time of one analyzer pass and the
+// findings it produced.
+type AnalyzerPerformance struct {
+	Analyzer   string  `json:"analyzer"`
+	DurationMS float64 `json:"duration_ms"`
+	Findings   int     `json:"findings"`
+}
+
+// RulePerformance is the cumulative matching time of one rule, the files
+// it was matched against and the matches it produced.
+type RulePerformance struct {
+	RuleID     string  `json:"rule_id"`
+	DurationMS float64 `json:"duration_ms"`
+	Files      int     `json:"files"`
+	Matches    int     `json:"matches"`
+}
+
+// Milliseconds returns d in milliseconds, rounded to the microsecond, for
+// the durations of a Performance block.
+func Milliseconds(d time.Duration) float64 {
+	return float64(d.Round(time.Microsecond)) / float64(time.Millisecond)
 }
 
 // JSONReporter produces deterministic JSON output from a FindingSet.
 type JSONReporter struct {
 	ToolVersion string
+	// Errors lists skipped files to include in the report so CI can detect
+	// partial scans.
+	Errors []discovery.FileError
+	// Truncated lists the files scanned only in part because of their
+	// size, so that readers know their findings may be incomplete.
+	Truncated []discovery.TruncatedFile
+	// LineNotes lists the lines that were matched only in part.
+	LineNotes []rules.LineNote
+	// Partial marks the report as incomplete because the scan was
+	// interrupted before every analyzer finished.
+	Partial bool
+	// Rules, when set, is used to look up the category of each finding's
+	// rule for the report summary.
+	Rules *rules.RuleSet
+	// Categories, when set with Rules, is written to the report summary.
+	Categories *rules.CategoryRegistry
+	// CI records the pipeline, commit and branch of the CI run in the
+	// report metadata.
+	CI *ci.Env
+	// Provenance, when set, is written as the report's provenance block.
+	Provenance *Provenance
+	// Suppressions is written as the report's inventory of inline
+	// suppression directives.
+	Suppressions []suppress.Record
+	// Performance, when set with Rules, is written to the report summary.
+	Performance *Performance
 }
 
 // NewJSONReporter returns a JSONReporter configured with the given tool version
@@ -49,35 +136,96 @@ func NewJSONReporter(version string) *JSONReporter {
 // pretty-printed JSON with 2-space indentation. The output is stable across
 // runs given the same input findings (aside from the GeneratedAt timestamp).
 func (r *JSONReporter) Generate(fs *findings.FindingSet) ([]byte, error) {
-	fs.SortDeterministic()
+	var buf bytes.Buffer
+	if err := r.Write(&buf, fs); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	f := fs.Findings()
+// Write sorts the finding set deterministically and streams the report
+// that Generate returns to w. Findings are encoded one at a time, so the
+// memory it needs does not grow with the number of findings.
+func (r *JSONReporter) Write(w io.Writer, fs *findings.FindingSet) error {
+	fs.SortDeterministic()
 
-	// Guarantee a non-nil slice so JSON output renders "findings": [] rather
-	// than "findings": null for an empty finding set.
-	if f == nil {
-		f = []findings.Finding{}
+	s := NewJSONStream(w)
+	s.BeginObject()
+	s.Key("meta")
+	s.Value(Meta{
+		SchemaVersion: "1.0.0",
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		ToolName:      "nox",
+		ToolVersion:   r.ToolVersion,
+		CI:            r.CI,
+	})
+	if r.Provenance != nil {
+		s.Key("provenance")
+		s.Value(r.Provenance)
 	}
-
-	report := JSONReport{
-		Meta: Meta{
-			SchemaVersion: "1.0.0",
-			GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
-			ToolName:      "nox",
-			ToolVersion:   r.ToolVersion,
-		},
-		Findings: f,
+	if summary := r.summary(fs); summary != nil {
+		s.Key("summary")
+		s.Value(summary)
+	}
+	// The findings array is written even when empty, as "findings": [].
+	s.Key("findings")
+	s.BeginArray()
+	for i := range fs.Findings() {
+		s.Value(&fs.Findings()[i])
+	}
+	s.EndArray()
+	if len(r.Suppressions) > 0 {
+		s.Key("suppressions")
+		s.Value(r.Suppressions)
+	}
+	// The errors array is written even when empty, so that CI can tell a
+	// complete scan from one that skipped files without checking for the
+	// key.
+	s.Key("errors")
+	if r.Errors == nil {
+		s.Value([]discovery.FileError{})
+	} else {
+		s.Value(r.Errors)
+	}
+	if len(r.Truncated) > 0 {
+		s.Key("truncated")
+		s.Value(r.Truncated)
+	}
+	if len(r.LineNotes) > 0 {
+		s.Key("line_notes")
+		s.Value(r.LineNotes)
 	}
+	if r.Partial {
+		s.Key("partial")
+		s.Value(true)
+	}
+	s.EndObject()
+	return s.Flush()
+}
 
-	return json.MarshalIndent(report, "", "  ")
+// summary counts the active findings in fs by category, as returned by
+// RuleSet.CategoryOf, and adds r.Categories and r.Performance. It returns
+// nil when r.Rules is not set.
+func (r *JSONReporter) summary(fs *findings.FindingSet) *Summary {
+	if r.Rules == nil {
+		return nil
+	}
+	s := &Summary{ByCategory: make(map[string]int), Performance: r.Performance}
+	if r.Categories != nil {
+		s.Categories = r.Categories.Categories()
+	}
+	for _, f := range fs.Findings() {
+		if !f.Status.IsActive() {
+			continue
+		}
+		s.ByCategory[r.Rules.CategoryOf(&f)]++
+	}
+	return s
 }
 
-// WriteToFile generates the JSON report and writes it to the specified path
-// with 0644 permissions. Parent directories must already exist.
+// WriteToFile streams the JSON report to the specified path with 0644
+// permissions. Parent directories must already exist.
 func (r *JSONReporter) WriteToFile(fs *findings.FindingSet, path string) error {
-	data, err := r.Generate(fs)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0o644)
+	_, err := WriteStream(path, func(w io.Writer) error { return r.Write(w, fs) }, nil)
+	return err
 }
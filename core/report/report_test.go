@@ -5,9 +5,15 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/nox-hq/nox/core/ci"
+	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+	"github.com/nox-hq/nox/core/suppress"
 )
 
 // sampleFindingSet returns a FindingSet with two findings added in reverse
@@ -97,6 +103,204 @@ func TestGenerateContainsCorrectMeta(t *testing.T) {
 	}
 }
 
+func TestGenerateIncludesErrors(t *testing.T) {
+	r := NewJSONReporter("1.2.3")
+	r.Errors = []discovery.FileError{{Path: "locked.go", Message: "permission denied"}}
+
+	data, err := r.Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Path != "locked.go" {
+		t.Fatalf("expected errors to round-trip, got %+v", report.Errors)
+	}
+
+	// Clean scans write an empty errors array.
+	data, err = NewJSONReporter("1.2.3").Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if !strings.Contains(string(data), `"errors": []`) {
+		t.Errorf("expected an empty errors array for a clean scan:\n%s", data)
+	}
+}
+
+func TestGenerateKeepsWellKnownMetadata(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{
+		RuleID:   "VULN-001",
+		Severity: findings.SeverityHigh,
+		Location: findings.Location{FilePath: "package-lock.json", StartLine: 1},
+		Message:  "lodash@4.17.20 is affected by GHSA-35jh-r3h4-6jhm",
+		Metadata: map[string]string{
+			findings.MetaPackage:      "lodash",
+			findings.MetaVersion:      "4.17.20",
+			findings.MetaCVE:          "CVE-2021-23337",
+			findings.MetaFixedVersion: "4.17.21",
+		},
+	})
+	data, err := NewJSONReporter("0.1.0").Generate(fs)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	// Consumers read the metadata by key from the raw report.
+	var raw struct {
+		Findings []struct {
+			Message  string
+			Metadata map[string]string
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if len(raw.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(raw.Findings))
+	}
+	got := raw.Findings[0]
+	if got.Metadata["package"] != "lodash" || got.Metadata["version"] != "4.17.20" ||
+		got.Metadata["cve"] != "CVE-2021-23337" || got.Metadata["fixed_version"] != "4.17.21" {
+		t.Errorf("metadata = %v", got.Metadata)
+	}
+	if got.Message != "lodash@4.17.20 is affected by GHSA-35jh-r3h4-6jhm" {
+		t.Errorf("message = %q", got.Message)
+	}
+}
+
+func TestGenerateIncludesSuppressions(t *testing.T) {
+	r := NewJSONReporter("1.2.3")
+	r.Suppressions = []suppress.Record{{FilePath: "a.go", DirectiveLine: 3, TargetLine: 4, RuleIDs: []string{"SEC-001"}, Until: "2025-12-31", Expired: true}}
+
+	data, err := r.Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if len(report.Suppressions) != 1 || report.Suppressions[0].FilePath != "a.go" || !report.Suppressions[0].Expired {
+		t.Fatalf("expected suppressions to round-trip, got %+v", report.Suppressions)
+	}
+	if !strings.Contains(string(data), `"until": "2025-12-31"`) {
+		t.Errorf("expected the expiry date in the report:\n%s", data)
+	}
+}
+
+func TestGenerateSummaryPerformance(t *testing.T) {
+	r := NewJSONReporter("0.1.0")
+	r.Rules = rules.NewRuleSet()
+	r.Performance = &Performance{
+		DurationMS:   Milliseconds(1500 * time.Millisecond),
+		Analyzers:    []AnalyzerPerformance{{Analyzer: "secrets", DurationMS: 1200, Findings: 2}},
+		SlowestRules: []RulePerformance{{RuleID: "SEC-001", DurationMS: 0.25, Files: 10, Matches: 1}},
+	}
+	data, err := r.Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	for _, want := range []string{`"duration_ms": 1500`, `"analyzer": "secrets"`, `"rule_id": "SEC-001"`, `"matches": 1`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in the report:\n%s", want, data)
+		}
+	}
+
+	// Without a performance block the summary has only the categories.
+	r.Performance = nil
+	data, err = r.Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if strings.Contains(string(data), `"performance"`) {
+		t.Error("expected no performance key without timings")
+	}
+}
+
+func TestGenerateIncludesCIMetadata(t *testing.T) {
+	r := NewJSONReporter("0.1.0")
+	r.CI = &ci.Env{Provider: ci.GitLabCI, PipelineURL: "https://gitlab.com/acme/app/-/pipelines/7", Commit: "abc123", Branch: "main"}
+	data, err := r.Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if report.Meta.CI == nil || *report.Meta.CI != *r.CI {
+		t.Errorf("meta.ci = %+v, want %+v", report.Meta.CI, r.CI)
+	}
+}
+
+func TestGenerateSummaryByCategory(t *testing.T) {
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{ID: "rule-001", Category: rules.CategorySecrets})
+
+	r := NewJSONReporter("0.1.0")
+	data, err := r.Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if report.Summary != nil {
+		t.Errorf("expected no summary without a RuleSet, got %+v", report.Summary)
+	}
+
+	r.Rules = rs
+	data, err = r.Generate(sampleFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if report.Summary == nil {
+		t.Fatal("expected a summary")
+	}
+	// rule-002 is not in the RuleSet and counts as custom.
+	want := map[string]int{"secrets": 1, "custom": 1}
+	for c, n := range want {
+		if report.Summary.ByCategory[c] != n {
+			t.Errorf("by_category[%s] = %d, want %d", c, report.Summary.ByCategory[c], n)
+		}
+	}
+	if report.Summary.Categories != nil {
+		t.Errorf("expected no categories without a registry, got %+v", report.Summary.Categories)
+	}
+
+	// A finding whose rule is not in the RuleSet, such as a plugin's,
+	// counts in the category of its metadata; the summary lists the
+	// registered categories.
+	r.Categories = rules.NewCategoryRegistry()
+	if err := r.Categories.Register(rules.CategoryInfo{Name: "license", Label: "Licensing", BadgeWeight: 1}); err != nil {
+		t.Fatal(err)
+	}
+	fs := sampleFindingSet()
+	fs.Add(findings.Finding{ID: "plugin-1", RuleID: "PLUGIN-1", Metadata: map[string]string{findings.MetaCategory: "license"}})
+	data, err = r.Generate(fs)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	report = JSONReport{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if report.Summary.ByCategory["license"] != 1 {
+		t.Errorf("by_category = %v, want license 1", report.Summary.ByCategory)
+	}
+	if n := len(report.Summary.Categories); n != len(rules.Categories)+1 || report.Summary.Categories[n-1].Label != "Licensing" {
+		t.Errorf("categories = %+v", report.Summary.Categories)
+	}
+}
+
 func TestGenerateSortsFindingsDeterministically(t *testing.T) {
 	r := NewJSONReporter("0.1.0")
 	// Findings are added in reverse order (rule-002 before rule-001).
@@ -7,12 +7,16 @@
 package sarif
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
-	"os"
+	"io"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 
 	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/report"
 	"github.com/nox-hq/nox/core/rules"
 )
 
@@ -44,8 +48,9 @@ type Report struct {
 
 // Run represents a single invocation of an analysis tool.
 type Run struct {
-	Tool    Tool     `json:"tool"`
-	Results []Result `json:"results"`
+	Tool       Tool           `json:"tool"`
+	Results    []Result       `json:"results"`
+	Properties map[string]any `json:"properties,omitempty"`
 }
 
 // Tool describes the analysis tool that produced the run.
@@ -71,7 +76,7 @@ type ReportingDescriptor struct {
 	Help                 *MultiformatMessage `json:"help,omitempty"`
 	HelpURI              string              `json:"helpUri,omitempty"`
 	DefaultConfiguration Configuration       `json:"defaultConfiguration"`
-	Properties           map[string]string   `json:"properties,omitempty"`
+	Properties           map[string]any      `json:"properties,omitempty"`
 }
 
 // MultiformatMessage is a SARIF message that can carry both plain text and
@@ -99,6 +104,21 @@ type Result struct {
 	Message      Message           `json:"message"`
 	Locations    []Location        `json:"locations"`
 	Fingerprints map[string]string `json:"fingerprints"`
+	// Properties holds the custom severity label and score of the finding
+	// when a severity mapping is applied.
+	Properties map[string]any `json:"properties,omitempty"`
+	// Suppressions is set for findings that are suppressed, baselined or
+	// excluded by VEX.
+	Suppressions []Suppression `json:"suppressions,omitempty"`
+}
+
+// Suppression records that a result is not active and why. Kind is
+// "inSource" for a nox:ignore comment and "external" for a baseline entry,
+// an override or a VEX statement.
+type Suppression struct {
+	Kind          string `json:"kind"`
+	Status        string `json:"status,omitempty"`
+	Justification string `json:"justification,omitempty"`
 }
 
 // Location wraps a physical location within a source artifact.
@@ -138,14 +158,25 @@ type Reporter struct {
 	// Rules is an optional RuleSet used to populate the SARIF rule catalog.
 	// When nil, the catalog is derived from the findings themselves.
 	Rules *rules.RuleSet
+
+	// Provenance, when set, is written to the run's properties under
+	// "provenance".
+	Provenance *report.Provenance
+
+	// IncludeSuppressed writes findings that are not active as results
+	// with a suppressions array instead of leaving them out, so code
+	// scanning keeps their alerts and history when a baseline changes.
+	IncludeSuppressed bool
 }
 
 // NewReporter returns a Reporter configured with the given tool
-// version and optional rule set. The rule set may be nil.
+// version and optional rule set. The rule set may be nil. Suppressed
+// findings are included.
 func NewReporter(version string, ruleSet *rules.RuleSet) *Reporter {
 	return &Reporter{
-		ToolVersion: version,
-		Rules:       ruleSet,
+		ToolVersion:       version,
+		Rules:             ruleSet,
+		IncludeSuppressed: true,
 	}
 }
 
@@ -153,17 +184,51 @@ func NewReporter(version string, ruleSet *rules.RuleSet) *Reporter {
 // FindingSet. Findings are sorted deterministically before serialization to
 // guarantee reproducible output. The returned bytes are pretty-printed JSON.
 func (r *Reporter) Generate(fs *findings.FindingSet) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.Write(&buf, fs); err != nil {
+		return nil, fmt.Errorf("sarif: generate report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Write sorts the finding set deterministically and streams the document
+// that Generate returns to w. Results are encoded one at a time, so the
+// memory it needs does not grow with the number of findings.
+func (r *Reporter) Write(w io.Writer, fs *findings.FindingSet) error {
 	fs.SortDeterministic()
 
-	items := fs.ActiveFindings()
+	items := fs.Findings()
 
 	// Build the rule catalog and a lookup from rule ID to index.
 	ruleCatalog, ruleIndex := r.buildRuleCatalog(items)
 
+	s := report.NewJSONStream(w)
+	s.BeginObject()
+	s.Key("version")
+	s.Value(sarifVersion)
+	s.Key("$schema")
+	s.Value(sarifSchema)
+	s.Key("runs")
+	s.BeginArray()
+	s.BeginObject()
+	s.Key("tool")
+	s.Value(Tool{
+		Driver: Driver{
+			Name:           toolName,
+			Version:        r.ToolVersion,
+			InformationURI: informationURI,
+			Rules:          ruleCatalog,
+		},
+	})
+
 	// Map findings to SARIF results.
-	results := make([]Result, 0, len(items))
+	s.Key("results")
+	s.BeginArray()
 	for i := range items {
-		f := items[i]
+		f := &items[i]
+		if !r.reported(f) {
+			continue
+		}
 		idx, ok := ruleIndex[f.RuleID]
 		if !ok {
 			// This should not happen if buildRuleCatalog is correct, but
@@ -171,7 +236,7 @@ func (r *Reporter) Generate(fs *findings.FindingSet) ([]byte, error) {
 			idx = 0
 		}
 
-		result := Result{
+		s.Value(Result{
 			RuleID:    f.RuleID,
 			RuleIndex: idx,
 			Level:     severityToLevel(f.Severity),
@@ -179,7 +244,8 @@ func (r *Reporter) Generate(fs *findings.FindingSet) ([]byte, error) {
 			Locations: []Location{
 				{
 					PhysicalLocation: PhysicalLocation{
-						ArtifactLocation: ArtifactLocation{URI: f.Location.FilePath},
+						// SARIF URIs use forward slashes on every platform.
+						ArtifactLocation: ArtifactLocation{URI: filepath.ToSlash(f.Location.FilePath)},
 						Region: Region{
 							StartLine:   f.Location.StartLine,
 							StartColumn: f.Location.StartColumn,
@@ -192,45 +258,81 @@ func (r *Reporter) Generate(fs *findings.FindingSet) ([]byte, error) {
 			Fingerprints: map[string]string{
 				"nox/v1": f.Fingerprint,
 			},
-		}
-		results = append(results, result)
+			Properties:   resultProperties(f),
+			Suppressions: suppressions(f),
+		})
 	}
-
-	report := Report{
-		Version: sarifVersion,
-		Schema:  sarifSchema,
-		Runs: []Run{
-			{
-				Tool: Tool{
-					Driver: Driver{
-						Name:           toolName,
-						Version:        r.ToolVersion,
-						InformationURI: informationURI,
-						Rules:          ruleCatalog,
-					},
-				},
-				Results: results,
-			},
-		},
+	s.EndArray()
+	if r.Provenance != nil {
+		s.Key("properties")
+		s.Value(map[string]any{"provenance": r.Provenance})
 	}
-
-	return json.MarshalIndent(report, "", "  ")
+	s.EndObject()
+	s.EndArray()
+	s.EndObject()
+	return s.Flush()
 }
 
-// WriteToFile generates the SARIF report and writes it to the specified path
-// with 0644 permissions. Parent directories must already exist.
+// WriteToFile streams the SARIF report to the specified path with 0644
+// permissions. Parent directories must already exist.
 func (r *Reporter) WriteToFile(fs *findings.FindingSet, path string) error {
-	data, err := r.Generate(fs)
+	_, err := report.WriteStream(path, func(w io.Writer) error { return r.Write(w, fs) }, nil)
 	if err != nil {
-		return fmt.Errorf("sarif: generate report: %w", err)
+		return fmt.Errorf("sarif: write report: %w", err)
 	}
-	return os.WriteFile(path, data, 0o644)
+	return nil
 }
 
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
 
+// reported reports whether f is written as a result: it is active, or
+// suppressed findings are included.
+func (r *Reporter) reported(f *findings.Finding) bool {
+	return r.IncludeSuppressed || f.Status.IsActive()
+}
+
+// suppressions returns the suppressions of f, or nil if it is active. A
+// nox:ignore comment is an in-source suppression; baseline entries,
+// overrides and VEX statements live outside the code. The justification is
+// the reason given for the suppression, if any.
+func suppressions(f *findings.Finding) []Suppression {
+	if f.Status.IsActive() {
+		return nil
+	}
+	kind := "external"
+	if f.Status == findings.StatusSuppressed && f.Metadata[findings.MetaOverrideReason] == "" {
+		kind = "inSource"
+	}
+	return []Suppression{{Kind: kind, Status: "accepted", Justification: f.SuppressionReason}}
+}
+
+// resultProperties returns the result properties carrying the custom
+// severity label and score, the first-seen date and SLA status, and the
+// well-known metadata such as the package and CVE from the finding's
+// metadata, or nil if it has none of them. The SARIF level keeps the nox
+// severity.
+func resultProperties(f *findings.Finding) map[string]any {
+	props := make(map[string]any)
+	if label := f.Metadata[findings.MetaSeverityLabel]; label != "" {
+		props[findings.MetaSeverityLabel] = label
+		if score, err := strconv.ParseFloat(f.Metadata[findings.MetaSeverityScore], 64); err == nil {
+			props[findings.MetaSeverityScore] = score
+		}
+	}
+	keys := append([]string{findings.MetaFirstSeen, findings.MetaSLAStatus, findings.MetaSLADue}, findings.WellKnownMetadata...)
+	for _, k := range keys {
+		if v := f.Metadata[k]; v != "" {
+			props[k] = v
+		}
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return props
+}
+
 // severityToLevel maps a Nox severity to the corresponding SARIF level
 // string. Critical and high map to "error", medium to "warning", and low/info
 // to "note".
@@ -258,6 +360,34 @@ func (r *Reporter) buildRuleCatalog(items []findings.Finding) (catalog []Reporti
 	return r.buildCatalogFromFindings(items)
 }
 
+// ruleProperties returns the SARIF property bag for rule: its metadata, its
+// category, and "tags" holding the category followed by the rule's tags, so
+// code scanning UIs can filter on the category. It is nil for a rule with
+// none of these.
+func ruleProperties(rule *rules.Rule) map[string]any {
+	if len(rule.Metadata) == 0 && rule.Category == "" && len(rule.Tags) == 0 {
+		return nil
+	}
+	props := make(map[string]any, len(rule.Metadata)+2)
+	for k, v := range rule.Metadata {
+		props[k] = v
+	}
+	var tags []string
+	if rule.Category != "" {
+		props["category"] = rule.Category
+		tags = append(tags, rule.Category)
+	}
+	for _, tag := range rule.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > 0 {
+		props["tags"] = tags
+	}
+	return props
+}
+
 // buildCatalogFromRuleSet creates catalog entries for every rule in the
 // RuleSet, sorted by rule ID for deterministic output.
 func (r *Reporter) buildCatalogFromRuleSet() (catalog []ReportingDescriptor, index map[string]int) {
@@ -288,9 +418,7 @@ func (r *Reporter) buildCatalogFromRuleSet() (catalog []ReportingDescriptor, ind
 			},
 		}
 
-		if len(rule.Metadata) > 0 {
-			desc.Properties = rule.Metadata
-		}
+		desc.Properties = ruleProperties(rule)
 
 		// Populate help text from Remediation for GitHub Code Scanning.
 		if rule.Remediation != "" {
@@ -311,8 +439,11 @@ func (r *Reporter) buildCatalogFromRuleSet() (catalog []ReportingDescriptor, ind
 			}
 		}
 
-		// Use the first reference as helpUri.
-		if len(rule.References) > 0 {
+		// Use the reference URL from the config as helpUri, or else the
+		// first reference.
+		if u := rule.Metadata[findings.MetaReferenceURL]; u != "" {
+			desc.HelpURI = u
+		} else if len(rule.References) > 0 {
 			desc.HelpURI = rule.References[0]
 		}
 
@@ -323,7 +454,7 @@ func (r *Reporter) buildCatalogFromRuleSet() (catalog []ReportingDescriptor, ind
 }
 
 // buildCatalogFromFindings creates minimal catalog entries derived from the
-// unique rule IDs in the findings. The entries are sorted by rule ID.
+// unique rule IDs of the reported findings. The entries are sorted by rule ID.
 func (r *Reporter) buildCatalogFromFindings(items []findings.Finding) (catalog []ReportingDescriptor, index map[string]int) {
 	// Collect unique rule IDs preserving the first finding's data for each.
 	type ruleInfo struct {
@@ -336,8 +467,8 @@ func (r *Reporter) buildCatalogFromFindings(items []findings.Finding) (catalog [
 	var unique []ruleInfo
 
 	for i := range items {
-		f := items[i]
-		if _, exists := seen[f.RuleID]; exists {
+		f := &items[i]
+		if _, exists := seen[f.RuleID]; exists || !r.reported(f) {
 			continue
 		}
 		seen[f.RuleID] = struct{}{}
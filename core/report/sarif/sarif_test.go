@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/nox-hq/nox/core/findings"
@@ -296,6 +297,56 @@ func TestFingerprintsAreIncludedInResults(t *testing.T) {
 	}
 }
 
+func TestResultPropertiesCarrySeverityLabel(t *testing.T) {
+	score := 9.0
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{RuleID: "SEC-001", Severity: findings.SeverityCritical, Location: findings.Location{FilePath: "a.env", StartLine: 1}})
+	fs.Add(findings.Finding{RuleID: "SEC-002", Severity: findings.SeverityLow, Location: findings.Location{FilePath: "b.env", StartLine: 1}})
+	fs.ApplySeverityMapping(findings.SeverityMapping{findings.SeverityCritical: {Label: "P1", Score: &score}})
+
+	data, err := NewReporter("0.1.0", nil).Generate(fs)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	results := mustUnmarshal(t, data).Runs[0].Results
+	if results[0].Level != "error" || results[0].Properties["severity_label"] != "P1" || results[0].Properties["severity_score"] != 9.0 {
+		t.Errorf("mapped result = %+v", results[0])
+	}
+	if results[1].Properties != nil {
+		t.Errorf("expected no properties for an unmapped severity, got %v", results[1].Properties)
+	}
+}
+
+func TestResultPropertiesCarryWellKnownMetadata(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{
+		RuleID:   "VULN-001",
+		Severity: findings.SeverityHigh,
+		Location: findings.Location{FilePath: "package-lock.json", StartLine: 1},
+		Metadata: map[string]string{
+			findings.MetaPackage:      "lodash",
+			findings.MetaCVE:          "CVE-2021-23337",
+			findings.MetaFixedVersion: "4.17.21",
+			"aliases":                 "CVE-2021-23337",
+		},
+	})
+
+	data, err := NewReporter("0.1.0", nil).Generate(fs)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	props := mustUnmarshal(t, data).Runs[0].Results[0].Properties
+	want := map[string]any{"package": "lodash", "cve": "CVE-2021-23337", "fixed_version": "4.17.21"}
+	if len(props) != len(want) {
+		t.Errorf("properties = %v, want %v", props, want)
+	}
+	for k, v := range want {
+		if props[k] != v {
+			t.Errorf("properties[%s] = %v, want %v", k, props[k], v)
+		}
+	}
+}
+
 func TestRuleCatalogPopulatedFromRuleSet(t *testing.T) {
 	rs := sampleRuleSet()
 	r := NewReporter("0.1.0", rs)
@@ -343,6 +394,31 @@ func TestRuleCatalogPopulatedFromRuleSet(t *testing.T) {
 	}
 }
 
+func TestRuleCatalogTagsIncludeCategory(t *testing.T) {
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{
+		ID:          "rule-001",
+		Description: "Detects hardcoded credentials",
+		Severity:    findings.SeverityHigh,
+		Category:    rules.CategorySecrets,
+		Tags:        []string{"secrets", "aws"},
+	})
+
+	data, err := NewReporter("0.1.0", rs).Generate(findings.NewFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	props := mustUnmarshal(t, data).Runs[0].Tool.Driver.Rules[0].Properties
+	if props["category"] != "secrets" {
+		t.Errorf("properties[category] = %v, want secrets", props["category"])
+	}
+	tags, _ := json.Marshal(props["tags"])
+	if string(tags) != `["secrets","aws"]` {
+		t.Errorf("properties[tags] = %s, want [\"secrets\",\"aws\"]", tags)
+	}
+}
+
 func TestRuleCatalogHasHelpAndHelpURI(t *testing.T) {
 	rs := sampleRuleSet()
 	r := NewReporter("0.1.0", rs)
@@ -385,6 +461,27 @@ func TestRuleCatalogHasHelpAndHelpURI(t *testing.T) {
 	}
 }
 
+func TestRuleCatalogHelpURIFromReferenceURL(t *testing.T) {
+	const url = "https://wiki.corp/security/nox/rule-001"
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{
+		ID:          "rule-001",
+		Description: "Hardcoded secret",
+		Severity:    findings.SeverityHigh,
+		Remediation: "Rotate the key.",
+		Metadata:    map[string]string{findings.MetaReferenceURL: url},
+		References:  []string{"https://cwe.mitre.org/data/definitions/798.html", url},
+	})
+
+	data, err := NewReporter("0.1.0", rs).Generate(findings.NewFindingSet())
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if got := mustUnmarshal(t, data).Runs[0].Tool.Driver.Rules[0].HelpURI; got != url {
+		t.Errorf("helpUri = %q, want %q", got, url)
+	}
+}
+
 func TestRuleCatalogOmitsHelpWhenNoRemediation(t *testing.T) {
 	rs := rules.NewRuleSet()
 	rs.Add(&rules.Rule{
@@ -733,3 +830,65 @@ func TestWriteToFile_ErrorOnInvalidPath(t *testing.T) {
 		t.Fatal("expected error writing to invalid path, got nil")
 	}
 }
+
+func TestWrite_MatchesMarshalIndent(t *testing.T) {
+	fs := sampleFindingSet()
+	fs.Add(findings.Finding{ID: "f-3", RuleID: "rule-003", Severity: findings.SeverityLow, Status: findings.StatusSuppressed})
+
+	r := NewReporter("1.0.0", nil)
+	r.IncludeSuppressed = false
+	var buf bytes.Buffer
+	if err := r.Write(&buf, fs); err != nil {
+		t.Fatal(err)
+	}
+	var doc Report
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	if n := len(doc.Runs[0].Results); n != 2 || len(doc.Runs[0].Tool.Driver.Rules) != 2 {
+		t.Fatalf("expected the suppressed finding and its rule to be left out, got %d results", n)
+	}
+	want, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Write:\n%s\nwant:\n%s", buf.Bytes(), want)
+	}
+}
+
+func TestWrite_Suppressions(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{ID: "f-1", RuleID: "SEC-001", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "a.go", StartLine: 1}})
+	fs.Add(findings.Finding{ID: "f-2", RuleID: "SEC-002", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "a.go", StartLine: 2}})
+	fs.Add(findings.Finding{ID: "f-3", RuleID: "SEC-003", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "a.go", StartLine: 3}})
+	fs.Add(findings.Finding{ID: "f-4", RuleID: "SEC-004", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "a.go", StartLine: 4},
+		Metadata: map[string]string{findings.MetaOverrideReason: "accepted risk"}})
+	fs.Suppress(1, findings.StatusSuppressed, "test fixture")
+	fs.Suppress(2, findings.StatusBaselined, "")
+	fs.Suppress(3, findings.StatusSuppressed, "accepted risk")
+
+	data, err := NewReporter("1.0.0", nil).Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc Report
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	results := doc.Runs[0].Results
+	if len(results) != 4 || len(doc.Runs[0].Tool.Driver.Rules) != 4 {
+		t.Fatalf("expected every finding and its rule, got %d results", len(results))
+	}
+	want := map[string][]Suppression{
+		"SEC-001": nil,
+		"SEC-002": {{Kind: "inSource", Status: "accepted", Justification: "test fixture"}},
+		"SEC-003": {{Kind: "external", Status: "accepted"}},
+		"SEC-004": {{Kind: "external", Status: "accepted", Justification: "accepted risk"}},
+	}
+	for _, res := range results {
+		if got := res.Suppressions; !reflect.DeepEqual(got, want[res.RuleID]) {
+			t.Errorf("%s suppressions = %+v, want %+v", res.RuleID, got, want[res.RuleID])
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/core/analyzers/deps"
+	"github.com/nox-hq/nox/core/git"
+)
+
+// Project is the application an SBOM describes: the root component of a
+// CycloneDX BOM and the root package of an SPDX document.
+type Project struct {
+	Name    string
+	Version string
+	// VCSURL is the URL of the repository, without credentials.
+	VCSURL string
+	// Commit is the full SHA of the commit the SBOM was generated from.
+	Commit string
+	// ecosystem is the inventory ecosystem of the manifest that named the
+	// project, "go" or "npm", or "" for a directory name.
+	ecosystem string
+}
+
+// DescribeProject returns the project in dir. Its name is the module path
+// in go.mod, the name in package.json, or the directory name. In a git
+// repository the version is the nearest tag as "git describe --tags"
+// prints it, or the abbreviated commit when there is no tag, and the VCS
+// URL is that of the "origin" remote.
+func DescribeProject(dir string) *Project {
+	p := &Project{}
+	p.Name, p.ecosystem = manifestName(dir)
+	if p.Name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			abs = dir
+		}
+		p.Name = filepath.Base(abs)
+	}
+	if !git.IsGitRepo(dir) {
+		return p
+	}
+	if sha, err := git.HeadCommit(dir); err == nil {
+		p.Commit = sha
+		p.Version = shortCommit(sha)
+	}
+	if tag, err := git.Describe(dir); err == nil {
+		p.Version = tag
+	}
+	if u, err := git.RemoteURL(dir, "origin"); err == nil {
+		p.VCSURL = git.Remote{URL: u}.String()
+	}
+	return p
+}
+
+// RemoteProject returns the project checked out in dir from remoteURL at
+// commit. The checkout has no tags, so the version is the abbreviated
+// commit, and the name falls back to the repository name.
+func RemoteProject(dir, remoteURL, commit string) *Project {
+	p := &Project{
+		Version: shortCommit(commit),
+		VCSURL:  git.Remote{URL: remoteURL}.String(),
+		Commit:  commit,
+	}
+	p.Name, p.ecosystem = manifestName(dir)
+	if p.Name == "" {
+		p.Name = path.Base(git.RepoName(remoteURL))
+	}
+	return p
+}
+
+// manifestName returns the module path in dir/go.mod or the name in
+// dir/package.json, with the ecosystem of the manifest, or "" when neither
+// declares one.
+func manifestName(dir string) (name, ecosystem string) {
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			if rest, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "module "); ok {
+				return strings.Trim(strings.TrimSpace(rest), `"`), "go"
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Name != "" {
+			return pkg.Name, "npm"
+		}
+	}
+	return "", ""
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// spdxProjectID is the SPDX identifier of the project's root package.
+const spdxProjectID = "SPDXRef-Project"
+
+// spdxPackage returns the project as the root package of an SPDX document.
+// Its download location is the repository at the commit, in the SPDX VCS
+// locator form "git+<url>@<commit>", and the commit is also an external
+// reference.
+func (p *Project) spdxPackage() SPDXPackage {
+	pkg := SPDXPackage{
+		SPDXID:           spdxProjectID,
+		Name:             p.Name,
+		VersionInfo:      p.Version,
+		DeclaredLicense:  "NOASSERTION",
+		DownloadLocation: "NOASSERTION",
+		Purpose:          "APPLICATION",
+	}
+	if strings.Contains(p.VCSURL, "://") {
+		pkg.DownloadLocation = "git+" + strings.TrimPrefix(p.VCSURL, "git+")
+		if p.Commit != "" {
+			pkg.DownloadLocation += "@" + p.Commit
+		}
+	}
+	if purl := p.purl(); purl != "" {
+		pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  purl,
+		})
+	}
+	if p.Commit != "" {
+		pkg.ExternalRefs = append(pkg.ExternalRefs, SPDXExternalRef{
+			ReferenceCategory: "OTHER",
+			ReferenceType:     "vcs-commit",
+			ReferenceLocator:  p.Commit,
+		})
+	}
+	return pkg
+}
+
+// purl returns the project's purl when a go.mod or package.json named it
+// and it has a version, and "" otherwise.
+func (p *Project) purl() string {
+	if p.ecosystem == "" || p.Version == "" {
+		return ""
+	}
+	return buildPURL(deps.Package{Name: p.Name, Version: p.Version, Ecosystem: p.ecosystem})
+}
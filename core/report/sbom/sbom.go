@@ -7,11 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/nox-hq/nox/core/analyzers/deps"
+	"github.com/nox-hq/nox/core/git"
 )
 
 // ---------------------------------------------------------------------------
@@ -29,10 +31,12 @@ type CDXReport struct {
 	Vulnerabilities []CDXVulnerability `json:"vulnerabilities,omitempty"`
 }
 
-// CDXMetadata holds tool and timestamp information.
+// CDXMetadata holds tool and timestamp information and the application
+// the BOM describes.
 type CDXMetadata struct {
-	Timestamp string    `json:"timestamp"`
-	Tools     []CDXTool `json:"tools"`
+	Timestamp string        `json:"timestamp"`
+	Tools     []CDXTool     `json:"tools"`
+	Component *CDXComponent `json:"component,omitempty"`
 }
 
 // CDXTool identifies the tool that generated the BOM.
@@ -53,14 +57,24 @@ type CDXLicenseWrapper struct {
 	License CDXLicense `json:"license"`
 }
 
-// CDXComponent represents a single dependency.
+// CDXComponent represents a single dependency or the described
+// application.
 type CDXComponent struct {
-	Type     string              `json:"type"`
-	BOMRef   string              `json:"bom-ref"`
-	Name     string              `json:"name"`
-	Version  string              `json:"version"`
-	PURL     string              `json:"purl"`
-	Licenses []CDXLicenseWrapper `json:"licenses,omitempty"`
+	Type               string                 `json:"type"`
+	BOMRef             string                 `json:"bom-ref"`
+	Name               string                 `json:"name"`
+	Version            string                 `json:"version,omitempty"`
+	PURL               string                 `json:"purl,omitempty"`
+	Licenses           []CDXLicenseWrapper    `json:"licenses,omitempty"`
+	ExternalReferences []CDXExternalReference `json:"externalReferences,omitempty"`
+}
+
+// CDXExternalReference points to a resource about a component, such as its
+// source repository.
+type CDXExternalReference struct {
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Comment string `json:"comment,omitempty"`
 }
 
 // CDXVulnerability represents a known vulnerability in the CycloneDX format.
@@ -91,6 +105,9 @@ type CDXAffect struct {
 // CycloneDXReporter generates CycloneDX 1.5 JSON SBOMs.
 type CycloneDXReporter struct {
 	ToolVersion string
+	// Project, when set, is the application the BOM describes, written as
+	// its metadata component.
+	Project *Project
 }
 
 // NewCycloneDXReporter returns a reporter configured with the given tool version.
@@ -100,30 +117,7 @@ func NewCycloneDXReporter(version string) *CycloneDXReporter {
 
 // Generate produces a CycloneDX JSON byte slice from the package inventory.
 func (r *CycloneDXReporter) Generate(inventory *deps.PackageInventory) ([]byte, error) {
-	pkgs := inventory.Packages()
-
-	// Build a map from original index to package for vulnerability lookup.
-	// We need to track original indices through sorting.
-	type indexedPkg struct {
-		pkg     deps.Package
-		origIdx int
-	}
-	indexed := make([]indexedPkg, len(pkgs))
-	for i, p := range pkgs {
-		indexed[i] = indexedPkg{pkg: p, origIdx: i}
-	}
-
-	// Sort deterministically by ecosystem, then name, then version.
-	sort.Slice(indexed, func(i, j int) bool {
-		a, b := indexed[i].pkg, indexed[j].pkg
-		if a.Ecosystem != b.Ecosystem {
-			return a.Ecosystem < b.Ecosystem
-		}
-		if a.Name != b.Name {
-			return a.Name < b.Name
-		}
-		return a.Version < b.Version
-	})
+	indexed := sortedPackages(inventory)
 
 	components := make([]CDXComponent, 0, len(indexed))
 	bomRefs := make(map[int]string) // origIdx -> bom-ref
@@ -131,12 +125,15 @@ func (r *CycloneDXReporter) Generate(inventory *deps.PackageInventory) ([]byte,
 		bomRef := fmt.Sprintf("pkg:%d", i)
 		bomRefs[ip.origIdx] = bomRef
 		comp := CDXComponent{
-			Type:    "library",
+			Type:    componentType(ip.pkg.Ecosystem),
 			BOMRef:  bomRef,
 			Name:    ip.pkg.Name,
 			Version: ip.pkg.Version,
 			PURL:    buildPURL(ip.pkg),
 		}
+		if u := submoduleVCSURL(ip.pkg); u != "" {
+			comp.ExternalReferences = []CDXExternalReference{{Type: "vcs", URL: u, Comment: "commit " + ip.pkg.Version}}
+		}
 		if ip.pkg.License != "" {
 			comp.Licenses = []CDXLicenseWrapper{
 				{License: CDXLicense{ID: ip.pkg.License}},
@@ -155,12 +152,13 @@ func (r *CycloneDXReporter) Generate(inventory *deps.PackageInventory) ([]byte,
 			vuln    deps.Vulnerability
 		}
 		var entries []vulnEntry
-		for idx, vulns := range allVulns {
-			for _, v := range vulns {
-				entries = append(entries, vulnEntry{origIdx: idx, vuln: v})
+		for _, ip := range indexed {
+			for _, v := range allVulns[ip.origIdx] {
+				entries = append(entries, vulnEntry{origIdx: ip.origIdx, vuln: v})
 			}
 		}
-		sort.Slice(entries, func(i, j int) bool {
+		// Entries for the same vulnerability keep their component order.
+		sort.SliceStable(entries, func(i, j int) bool {
 			return entries[i].vuln.ID < entries[j].vuln.ID
 		})
 
@@ -203,6 +201,23 @@ func (r *CycloneDXReporter) Generate(inventory *deps.PackageInventory) ([]byte,
 		Components:      components,
 		Vulnerabilities: cdxVulns,
 	}
+	if p := r.Project; p != nil {
+		root := &CDXComponent{
+			Type:    "application",
+			BOMRef:  "project",
+			Name:    p.Name,
+			Version: p.Version,
+			PURL:    p.purl(),
+		}
+		if p.VCSURL != "" {
+			ref := CDXExternalReference{Type: "vcs", URL: p.VCSURL}
+			if p.Commit != "" {
+				ref.Comment = "commit " + p.Commit
+			}
+			root.ExternalReferences = []CDXExternalReference{ref}
+		}
+		report.Metadata.Component = root
+	}
 
 	return json.MarshalIndent(report, "", "  ")
 }
@@ -247,6 +262,7 @@ type SPDXPackage struct {
 	DownloadLocation string            `json:"downloadLocation"`
 	FilesAnalyzed    bool              `json:"filesAnalyzed"`
 	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+	Purpose          string            `json:"primaryPackagePurpose,omitempty"`
 }
 
 // SPDXExternalRef is a reference to an external resource.
@@ -266,6 +282,9 @@ type SPDXRelationship struct {
 // SPDXReporter generates SPDX 2.3 JSON SBOMs.
 type SPDXReporter struct {
 	ToolVersion string
+	// Project, when set, is the application the document describes. It is
+	// written as the root package, which depends on every other package.
+	Project *Project
 }
 
 // NewSPDXReporter returns a reporter configured with the given tool version.
@@ -275,29 +294,7 @@ func NewSPDXReporter(version string) *SPDXReporter {
 
 // Generate produces an SPDX 2.3 JSON byte slice from the package inventory.
 func (r *SPDXReporter) Generate(inventory *deps.PackageInventory) ([]byte, error) {
-	pkgs := inventory.Packages()
-
-	// Build a map from original index to package for vulnerability lookup.
-	type indexedPkg struct {
-		pkg     deps.Package
-		origIdx int
-	}
-	indexed := make([]indexedPkg, len(pkgs))
-	for i, p := range pkgs {
-		indexed[i] = indexedPkg{pkg: p, origIdx: i}
-	}
-
-	// Sort deterministically.
-	sort.Slice(indexed, func(i, j int) bool {
-		a, b := indexed[i].pkg, indexed[j].pkg
-		if a.Ecosystem != b.Ecosystem {
-			return a.Ecosystem < b.Ecosystem
-		}
-		if a.Name != b.Name {
-			return a.Name < b.Name
-		}
-		return a.Version < b.Version
-	})
+	indexed := sortedPackages(inventory)
 
 	allVulns := inventory.AllVulnerabilities()
 
@@ -321,6 +318,12 @@ func (r *SPDXReporter) Generate(inventory *deps.PackageInventory) ([]byte, error
 			DownloadLocation: "NOASSERTION",
 			FilesAnalyzed:    false,
 		}
+		if t := componentType(ip.pkg.Ecosystem); t != "library" {
+			pkg.Purpose = strings.ToUpper(t)
+		}
+		if u := submoduleVCSURL(ip.pkg); strings.Contains(u, "://") {
+			pkg.DownloadLocation = "git+" + strings.TrimPrefix(u, "git+") + "@" + ip.pkg.Version
+		}
 
 		var refs []SPDXExternalRef
 		if purl != "" {
@@ -330,6 +333,13 @@ func (r *SPDXReporter) Generate(inventory *deps.PackageInventory) ([]byte, error
 				ReferenceLocator:  purl,
 			})
 		}
+		if ip.pkg.Ecosystem == "git-submodule" {
+			refs = append(refs, SPDXExternalRef{
+				ReferenceCategory: "OTHER",
+				ReferenceType:     "vcs-commit",
+				ReferenceLocator:  ip.pkg.Version,
+			})
+		}
 
 		// Add SECURITY external refs for known vulnerabilities.
 		if allVulns != nil {
@@ -355,6 +365,14 @@ func (r *SPDXReporter) Generate(inventory *deps.PackageInventory) ([]byte, error
 
 		spdxPkgs = append(spdxPkgs, pkg)
 
+		if r.Project != nil {
+			relationships = append(relationships, SPDXRelationship{
+				SPDXElementID:      spdxProjectID,
+				RelationshipType:   "DEPENDS_ON",
+				RelatedSPDXElement: spdxID,
+			})
+			continue
+		}
 		relationships = append(relationships, SPDXRelationship{
 			SPDXElementID:      "SPDXRef-DOCUMENT",
 			RelationshipType:   "DESCRIBES",
@@ -362,11 +380,22 @@ func (r *SPDXReporter) Generate(inventory *deps.PackageInventory) ([]byte, error
 		})
 	}
 
+	name := "nox-scan"
+	if p := r.Project; p != nil {
+		name = p.Name
+		spdxPkgs = append([]SPDXPackage{p.spdxPackage()}, spdxPkgs...)
+		relationships = append([]SPDXRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: spdxProjectID,
+		}}, relationships...)
+	}
+
 	doc := SPDXDocument{
 		SPDXVersion:       "SPDX-2.3",
 		DataLicense:       "CC0-1.0",
 		SPDXID:            "SPDXRef-DOCUMENT",
-		Name:              "nox-scan",
+		Name:              name,
 		DocumentNamespace: "https://github.com/nox-hq/nox/scans",
 		CreationInfo: SPDXCreationInfo{
 			Created:  time.Now().UTC().Format(time.RFC3339),
@@ -392,6 +421,40 @@ func (r *SPDXReporter) WriteToFile(inventory *deps.PackageInventory, path string
 // Shared helpers
 // ---------------------------------------------------------------------------
 
+// indexedPkg is an inventory package with its index in the inventory, which
+// keys its vulnerabilities.
+type indexedPkg struct {
+	pkg     deps.Package
+	origIdx int
+}
+
+// sortedPackages returns the packages of inventory sorted by purl, then
+// ecosystem, name and version, so that SBOM components are listed in the
+// same order on every run.
+func sortedPackages(inventory *deps.PackageInventory) []indexedPkg {
+	pkgs := inventory.Packages()
+	indexed := make([]indexedPkg, len(pkgs))
+	purls := make([]string, len(pkgs))
+	for i, p := range pkgs {
+		indexed[i] = indexedPkg{pkg: p, origIdx: i}
+		purls[i] = buildPURL(p)
+	}
+	sort.SliceStable(indexed, func(i, j int) bool {
+		a, b := indexed[i], indexed[j]
+		if pa, pb := purls[a.origIdx], purls[b.origIdx]; pa != pb {
+			return pa < pb
+		}
+		if a.pkg.Ecosystem != b.pkg.Ecosystem {
+			return a.pkg.Ecosystem < b.pkg.Ecosystem
+		}
+		if a.pkg.Name != b.pkg.Name {
+			return a.pkg.Name < b.pkg.Name
+		}
+		return a.pkg.Version < b.pkg.Version
+	})
+	return indexed
+}
+
 // purlEcosystems maps internal ecosystem names to PURL type prefixes.
 var purlEcosystems = map[string]string{
 	"go":       "golang",
@@ -404,9 +467,31 @@ var purlEcosystems = map[string]string{
 	"nuget":    "nuget",
 }
 
+// componentType returns the CycloneDX component type for an ecosystem.
+// Container base images are "container" and GitHub Actions, which run as
+// programs in CI rather than being linked in, are "application".
+func componentType(ecosystem string) string {
+	switch ecosystem {
+	case "docker":
+		return "container"
+	case "github-actions":
+		return "application"
+	default:
+		return "library"
+	}
+}
+
 // buildPURL constructs a Package URL (purl) for the given package.
 // See https://github.com/package-url/purl-spec for the format.
 func buildPURL(p deps.Package) string {
+	switch p.Ecosystem {
+	case "docker":
+		return ociPURL(p)
+	case "github-actions":
+		return githubPURL(p)
+	case "git-submodule":
+		return submodulePURL(p)
+	}
 	purlType, ok := purlEcosystems[p.Ecosystem]
 	if !ok {
 		return ""
@@ -418,3 +503,87 @@ func buildPURL(p deps.Package) string {
 	}
 	return fmt.Sprintf("pkg:%s/%s@%s", purlType, p.Name, p.Version)
 }
+
+// ociPURL builds a pkg:oci purl for a container image. The digest, when the
+// image is pinned to one, is the version; the registry path and tag are
+// qualifiers:
+//
+//	node:18                   -> pkg:oci/node?repository_url=docker.io/library/node&tag=18
+//	ghcr.io/org/app@sha256:ab -> pkg:oci/app@sha256%3Aab?repository_url=ghcr.io/org/app
+func ociPURL(p deps.Package) string {
+	name, tag, digest := p.Name, p.Version, ""
+	if strings.HasPrefix(tag, "sha256:") {
+		digest, tag = tag, ""
+		// "node:18@sha256:..." keeps the tag in the name.
+		if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+			name, tag = name[:i], name[i+1:]
+		}
+	}
+	name = strings.ToLower(name)
+
+	repo := name
+	first, _, hasSlash := strings.Cut(name, "/")
+	if !hasSlash || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		if !hasSlash {
+			repo = "library/" + name
+		}
+		repo = "docker.io/" + repo
+	}
+
+	purl := "pkg:oci/" + name[strings.LastIndex(name, "/")+1:]
+	if digest != "" {
+		purl += "@" + strings.Replace(digest, ":", "%3A", 1)
+	}
+	purl += "?repository_url=" + repo
+	if tag != "" {
+		purl += "&tag=" + tag
+	}
+	return purl
+}
+
+// githubPURL builds a pkg:github purl for a GitHub Action. An action in a
+// subdirectory of its repository ("github/codeql-action/init") gets the
+// directory as the purl subpath.
+func githubPURL(p deps.Package) string {
+	parts := strings.SplitN(p.Name, "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	purl := fmt.Sprintf("pkg:github/%s/%s@%s", parts[0], parts[1], p.Version)
+	if len(parts) == 3 && parts[2] != "" {
+		purl += "#" + parts[2]
+	}
+	return purl
+}
+
+// submodulePURL builds a purl for a git submodule, whose version is the
+// pinned commit. A submodule on GitHub gets a pkg:github purl; any other
+// gets a pkg:generic purl named after the repository with its URL in the
+// vcs_url qualifier:
+//
+//	github.com/org/lib         -> pkg:github/org/lib@<commit>
+//	https://git.example/x/lib  -> pkg:generic/lib@<commit>?vcs_url=git+https://git.example/x/lib
+func submodulePURL(p deps.Package) string {
+	if owner, ok := strings.CutPrefix(p.Name, "github.com/"); ok && strings.Count(owner, "/") == 1 {
+		return fmt.Sprintf("pkg:github/%s@%s", strings.ToLower(owner), p.Version)
+	}
+	purl := fmt.Sprintf("pkg:generic/%s@%s", path.Base(p.Name), p.Version)
+	if u := submoduleVCSURL(p); strings.Contains(u, "://") {
+		purl += "?vcs_url=git+" + strings.TrimPrefix(u, "git+")
+	}
+	return purl
+}
+
+// submoduleVCSURL returns the repository URL of a git submodule package
+// without credentials, or "" for any other package and for a submodule
+// whose URL is relative to the superproject's.
+func submoduleVCSURL(p deps.Package) string {
+	if p.Ecosystem != "git-submodule" {
+		return ""
+	}
+	r, ok := git.ParseRemote(p.Resolved)
+	if !ok {
+		return ""
+	}
+	return r.String()
+}
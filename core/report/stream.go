@@ -0,0 +1,143 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// JSONStream writes an indented JSON document piece by piece, so that large
+// arrays can be encoded one element at a time instead of being built in
+// memory first. The output is the same as that of json.MarshalIndent with
+// an empty prefix and an indent of two spaces. Errors are sticky: after the
+// first write error every call is a no-op and Flush returns the error.
+type JSONStream struct {
+	w      *bufio.Writer
+	buf    bytes.Buffer
+	enc    *json.Encoder
+	frames []int // number of members written to each open container
+	keyed  bool  // a key was written and awaits its value
+	err    error
+}
+
+// NewJSONStream returns a JSONStream writing to w.
+func NewJSONStream(w io.Writer) *JSONStream {
+	s := &JSONStream{w: bufio.NewWriter(w)}
+	s.enc = json.NewEncoder(&s.buf)
+	return s
+}
+
+func (s *JSONStream) write(str string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(str)
+	}
+}
+
+func (s *JSONStream) indent() string {
+	return strings.Repeat("  ", len(s.frames))
+}
+
+// next writes the separator and line break before a member of the
+// innermost container, unless it follows its key.
+func (s *JSONStream) next() {
+	if s.keyed {
+		s.keyed = false
+		return
+	}
+	if len(s.frames) == 0 {
+		return
+	}
+	if s.frames[len(s.frames)-1] > 0 {
+		s.write(",")
+	}
+	s.frames[len(s.frames)-1]++
+	s.write("\n" + s.indent())
+}
+
+// Key writes the name of the next member of the current object.
+func (s *JSONStream) Key(name string) {
+	s.next()
+	k, _ := json.Marshal(name)
+	s.write(string(k) + ": ")
+	s.keyed = true
+}
+
+// Value writes v, encoded with encoding/json, as the value of the last key
+// or as the next element of the current array.
+func (s *JSONStream) Value(v any) {
+	s.next()
+	if s.err != nil {
+		return
+	}
+	s.buf.Reset()
+	s.enc.SetIndent(s.indent(), "  ")
+	if s.err = s.enc.Encode(v); s.err != nil {
+		return
+	}
+	_, s.err = s.w.Write(bytes.TrimSuffix(s.buf.Bytes(), []byte("\n")))
+}
+
+// BeginObject opens an object as the value of the last key, as the next
+// array element, or as the document.
+func (s *JSONStream) BeginObject() { s.begin("{") }
+
+// EndObject closes the innermost object.
+func (s *JSONStream) EndObject() { s.end("}") }
+
+// BeginArray opens an array as the value of the last key, as the next
+// array element, or as the document.
+func (s *JSONStream) BeginArray() { s.begin("[") }
+
+// EndArray closes the innermost array.
+func (s *JSONStream) EndArray() { s.end("]") }
+
+func (s *JSONStream) begin(open string) {
+	s.next()
+	s.write(open)
+	s.frames = append(s.frames, 0)
+}
+
+func (s *JSONStream) end(closer string) {
+	n := s.frames[len(s.frames)-1]
+	s.frames = s.frames[:len(s.frames)-1]
+	if n > 0 {
+		s.write("\n" + s.indent())
+	}
+	s.write(closer)
+}
+
+// Flush writes any buffered output and returns the first error.
+func (s *JSONStream) Flush() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.w.Flush()
+}
+
+// WriteStream writes the report produced by write to path, like WriteFile.
+// Without recipients the report is streamed to the file; a report that
+// fails part way is removed. Encrypted reports are built in memory first.
+func WriteStream(path string, write func(io.Writer) error, recipients []age.Recipient) (string, error) {
+	if len(recipients) > 0 {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			return "", err
+		}
+		return WriteFile(path, buf.Bytes(), recipients)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if err := errors.Join(write(f), f.Close()); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
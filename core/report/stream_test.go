@@ -0,0 +1,195 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+func TestJSONStream_MatchesMarshalIndent(t *testing.T) {
+	type doc struct {
+		Name   string         `json:"name"`
+		Empty  []int          `json:"empty"`
+		Items  []any          `json:"items"`
+		Nested map[string]any `json:"nested"`
+	}
+	want, err := json.MarshalIndent(doc{
+		Name:   "<nox>",
+		Empty:  []int{},
+		Items:  []any{1, map[string]any{"a": []int{1, 2}}, []string{}},
+		Nested: map[string]any{"x": map[string]any{}},
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	s := NewJSONStream(&buf)
+	s.BeginObject()
+	s.Key("name")
+	s.Value("<nox>")
+	s.Key("empty")
+	s.BeginArray()
+	s.EndArray()
+	s.Key("items")
+	s.BeginArray()
+	s.Value(1)
+	s.BeginObject()
+	s.Key("a")
+	s.Value([]int{1, 2})
+	s.EndObject()
+	s.Value([]string{})
+	s.EndArray()
+	s.Key("nested")
+	s.Value(map[string]any{"x": map[string]any{}})
+	s.EndObject()
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(want) {
+		t.Errorf("stream output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestJSONReporter_WriteMatchesJSONReport(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{ID: "SEC-001:b.env:1", RuleID: "SEC-001", Severity: findings.SeverityHigh, Location: findings.Location{FilePath: "b.env", StartLine: 1}, Metadata: map[string]string{"k": "v"}})
+	fs.Add(findings.Finding{ID: "SEC-002:a.env:3", RuleID: "SEC-002", Severity: findings.SeverityLow, Location: findings.Location{FilePath: "a.env", StartLine: 3}, Status: findings.StatusSuppressed})
+	rs := rules.NewRuleSet()
+	rs.Add(&rules.Rule{ID: "SEC-001", Category: rules.CategorySecrets})
+
+	r := NewJSONReporter("1.2.3")
+	r.Rules = rs
+	r.Errors = []discovery.FileError{{Path: "locked.txt", Message: "permission denied"}}
+	r.Partial = true
+	r.Provenance = &Provenance{Target: "."}
+	got, err := r.Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed JSONReport
+	if err := json.Unmarshal(got, &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want, err := json.MarshalIndent(JSONReport{
+		Meta:       parsed.Meta,
+		Provenance: r.Provenance,
+		Summary:    &Summary{ByCategory: map[string]int{rules.CategorySecrets: 1}},
+		Findings:   fs.Findings(),
+		Errors:     r.Errors,
+		Partial:    true,
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Generate:\n%s\nwant:\n%s", got, want)
+	}
+
+	// An empty set still has a findings array.
+	got, err = NewJSONReporter("1.2.3").Generate(findings.NewFindingSet())
+	if err != nil || !bytes.Contains(got, []byte(`"findings": []`)) {
+		t.Errorf("empty report: %s (%v)", got, err)
+	}
+}
+
+func TestWriteStream_RemovesFailedReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.json")
+	_, err := WriteStream(path, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "{")
+		return fmt.Errorf("boom")
+	}, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Errorf("expected the partial report to be removed, got %v", statErr)
+	}
+}
+
+// syntheticFindings returns a set of n findings spread over n/10 files.
+func syntheticFindings(n int) *findings.FindingSet {
+	fs := findings.NewFindingSet()
+	for i := range n {
+		path := fmt.Sprintf("src/pkg%d/file%d.go", i%97, i/10)
+		fs.Add(findings.Finding{
+			ID:          fmt.Sprintf("SEC-%03d:%s:%d", i%50, path, i),
+			RuleID:      fmt.Sprintf("SEC-%03d", i%50),
+			Severity:    findings.SeverityHigh,
+			Confidence:  findings.ConfidenceMedium,
+			Location:    findings.Location{FilePath: path, StartLine: i%400 + 1, EndLine: i%400 + 1, StartColumn: 5, EndColumn: 45},
+			Message:     "Hardcoded credential detected in source file",
+			Fingerprint: fmt.Sprintf("%064x", i),
+			Metadata:    map[string]string{"cwe": "CWE-798"},
+		})
+	}
+	return fs
+}
+
+// peakHeapWriter discards its input and samples the live heap every few
+// hundred writes.
+type peakHeapWriter struct {
+	writes int
+	peak   uint64
+}
+
+func (w *peakHeapWriter) Write(p []byte) (int, error) {
+	if w.writes%256 == 0 {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		w.peak = max(w.peak, m.HeapAlloc)
+	}
+	w.writes++
+	return len(p), nil
+}
+
+func TestJSONReporter_WriteBoundedMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates 100k findings")
+	}
+	const n = 100_000
+	// Building the report in memory needs well over this; streaming it
+	// needs a few buffers.
+	const budget = 16 << 20
+
+	fs := syntheticFindings(n)
+	fs.SortDeterministic()
+
+	// Collect garbage eagerly so the heap measures what is live.
+	defer debug.SetGCPercent(debug.SetGCPercent(5))
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	w := &peakHeapWriter{}
+	if err := NewJSONReporter("test").Write(w, fs); err != nil {
+		t.Fatal(err)
+	}
+	if w.peak > before.HeapAlloc && w.peak-before.HeapAlloc > budget {
+		t.Errorf("writing %d findings grew the heap by %d MiB, budget %d MiB", n, (w.peak-before.HeapAlloc)>>20, budget>>20)
+	}
+	runtime.KeepAlive(fs)
+}
+
+func BenchmarkJSONReporter_Write(b *testing.B) {
+	fs := syntheticFindings(100_000)
+	fs.SortDeterministic()
+	r := NewJSONReporter("bench")
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := r.Write(io.Discard, fs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
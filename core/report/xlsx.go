@@ -0,0 +1,224 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+)
+
+// xlsxMaxCell is the most characters Excel accepts in a cell. Longer text
+// is cut short rather than producing a workbook Excel has to repair.
+const xlsxMaxCell = 32767
+
+// XLSXReporter writes findings as an Excel workbook with two sheets:
+// Findings, one row per finding under the CSVColumns header, and Summary,
+// counting findings by severity, status and category. It needs no
+// spreadsheet library; the workbook is the minimal set of SpreadsheetML
+// parts that Excel, LibreOffice and Google Sheets open.
+type XLSXReporter struct {
+	ToolVersion string
+	// Rules, when set, is used to look up the category and remediation of
+	// each finding's rule.
+	Rules *rules.RuleSet
+}
+
+// NewXLSXReporter returns an XLSXReporter that records version in the
+// summary sheet and looks up rule details in rs, which may be nil.
+func NewXLSXReporter(version string, rs *rules.RuleSet) *XLSXReporter {
+	return &XLSXReporter{ToolVersion: version, Rules: rs}
+}
+
+// Generate sorts the finding set deterministically and returns it as an
+// XLSX workbook.
+func (r *XLSXReporter) Generate(fs *findings.FindingSet) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.Write(&buf, fs); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write sorts the finding set deterministically and writes it to w as an
+// XLSX workbook. The output is identical for identical findings.
+func (r *XLSXReporter) Write(w io.Writer, fs *findings.FindingSet) error {
+	fs.SortDeterministic()
+
+	zw := zip.NewWriter(w)
+	for _, part := range []struct {
+		name string
+		rows [][]any
+	}{
+		{"[Content_Types].xml", nil},
+		{"_rels/.rels", nil},
+		{"xl/workbook.xml", nil},
+		{"xl/_rels/workbook.xml.rels", nil},
+		{"xl/worksheets/sheet1.xml", r.findingsSheet(fs)},
+		{"xl/worksheets/sheet2.xml", r.summarySheet(fs)},
+	} {
+		// A zero modification time keeps the archive byte-for-byte stable.
+		pw, err := zw.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if part.rows == nil {
+			_, err = io.WriteString(pw, xlsxParts[part.name])
+		} else {
+			err = writeSheet(pw, part.rows, part.name == "xl/worksheets/sheet1.xml")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// findingsSheet returns the rows of the Findings sheet: the CSVColumns
+// header and one row per finding, with line numbers as numbers.
+func (r *XLSXReporter) findingsSheet(fs *findings.FindingSet) [][]any {
+	rows := [][]any{toCells(CSVColumns)}
+	for i := range fs.Findings() {
+		f := &fs.Findings()[i]
+		row := toCells(findingRow(f, r.Rules))
+		for col, line := range map[int]int{colStartLine: f.Location.StartLine, colEndLine: f.Location.EndLine} {
+			if line > 0 {
+				row[col] = line
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// summarySheet returns the rows of the Summary sheet: the active findings
+// by severity, all findings by status, and the active findings by rule
+// category, in blocks separated by empty rows.
+func (r *XLSXReporter) summarySheet(fs *findings.FindingSet) [][]any {
+	bySeverity := make(map[findings.Severity]int)
+	byStatus := make(map[string]int)
+	byCategory := make(map[string]int)
+	for i := range fs.Findings() {
+		f := &fs.Findings()[i]
+		row := findingRow(f, r.Rules)
+		byStatus[row[colStatus]]++
+		if !f.Status.IsActive() {
+			continue
+		}
+		bySeverity[f.Severity]++
+		category := row[colCategory]
+		if category == "" {
+			category = rules.CategoryCustom
+		}
+		byCategory[category]++
+	}
+
+	rows := [][]any{
+		{"tool", "nox " + r.ToolVersion},
+		{"findings", len(fs.Findings())},
+		{},
+		{"severity", "active findings"},
+	}
+	for _, sev := range findings.Severities {
+		rows = append(rows, []any{string(sev), bySeverity[sev]})
+	}
+	rows = append(rows, []any{}, []any{"status", "findings"})
+	for _, status := range slices.Sorted(maps.Keys(byStatus)) {
+		rows = append(rows, []any{status, byStatus[status]})
+	}
+	rows = append(rows, []any{}, []any{"category", "active findings"})
+	for _, category := range slices.Sorted(maps.Keys(byCategory)) {
+		rows = append(rows, []any{category, byCategory[category]})
+	}
+	return rows
+}
+
+// toCells converts a row of strings to sheet cells.
+func toCells(fields []string) []any {
+	row := make([]any, len(fields))
+	for i, v := range fields {
+		row[i] = v
+	}
+	return row
+}
+
+// writeSheet writes rows as a worksheet. Cells are strings or ints; empty
+// strings are left out. With frozenHeader the first row stays in view
+// when scrolling.
+func writeSheet(w io.Writer, rows [][]any, frozenHeader bool) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if frozenHeader {
+		buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	buf.WriteString(`<sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			switch v := cell.(type) {
+			case int:
+				fmt.Fprintf(&buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case string:
+				if v == "" {
+					continue
+				}
+				if r := []rune(v); len(r) > xlsxMaxCell {
+					v = string(r[:xlsxMaxCell])
+				}
+				fmt.Fprintf(&buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+				if err := xml.EscapeText(&buf, []byte(v)); err != nil {
+					return err
+				}
+				buf.WriteString(`</t></is></c>`)
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// xlsxColumn returns the letters naming the zero-based column i: A to Z,
+// then AA, AB and so on.
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxParts holds the fixed parts of the workbook that tie its two sheets
+// together.
+var xlsxParts = map[string]string{
+	"[Content_Types].xml": xml.Header +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`,
+	"_rels/.rels": xml.Header +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`,
+	"xl/workbook.xml": xml.Header +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Findings" sheetId="1" r:id="rId1"/><sheet name="Summary" sheetId="2" r:id="rId2"/></sheets>` +
+		`</workbook>`,
+	"xl/_rels/workbook.xml.rels": xml.Header +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+		`</Relationships>`,
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// xlsxSheets opens the workbook in data and returns the content of each
+// part by name, checking that every XML part is well-formed.
+func xlsxSheets(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := xml.NewDecoder(bytes.NewReader(b))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(b)
+	}
+	return parts
+}
+
+func TestXLSXReporter_Golden(t *testing.T) {
+	fs, rs := spreadsheetFindingSet()
+	data, err := NewXLSXReporter("1.2.3", rs).Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := xlsxSheets(t, data)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook has no %s", name)
+		}
+	}
+	checkGolden(t, "findings.sheet1.xml", []byte(parts["xl/worksheets/sheet1.xml"]))
+	checkGolden(t, "findings.sheet2.xml", []byte(parts["xl/worksheets/sheet2.xml"]))
+}
+
+func TestXLSXReporter_IsDeterministic(t *testing.T) {
+	fs, rs := spreadsheetFindingSet()
+	a, err := NewXLSXReporter("1.2.3", rs).Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, rs = spreadsheetFindingSet()
+	b, err := NewXLSXReporter("1.2.3", rs).Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("two workbooks of the same findings differ")
+	}
+}
+
+func TestXLSXReporter_TruncatesLongCells(t *testing.T) {
+	fs := findings.NewFindingSet()
+	fs.Add(findings.Finding{ID: "f-1", RuleID: "R", Severity: findings.SeverityLow, Message: strings.Repeat("é", xlsxMaxCell+10)})
+	data, err := NewXLSXReporter("1.2.3", nil).Generate(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := xlsxSheets(t, data)["xl/worksheets/sheet1.xml"]
+	if n := strings.Count(sheet, "é"); n != xlsxMaxCell {
+		t.Errorf("message cell has %d characters, want %d", n, xlsxMaxCell)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 12: "M", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package rulepack manages versioned custom rule packs installed from a
+// plugin registry. Installed packs live under a local directory (by default
+// $NOX_HOME/rulepacks) as <name>/<version>/ with a manifest recording the
+// SHA-256 digest of every rule file, so packs resolve and verify fully
+// offline at scan time.
+package rulepack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nox-hq/nox/core/rules"
+	"github.com/nox-hq/nox/registry"
+	"github.com/nox-hq/nox/registry/trust"
+)
+
+// ManifestFile is the name of the manifest written into each installed pack
+// version directory.
+const ManifestFile = "pack.json"
+
+// ErrNotInstalled indicates that no installed version of a pack satisfies
+// the requested constraint.
+var ErrNotInstalled = errors.New("rule pack not installed")
+
+// ErrIntegrity indicates that an installed rule file no longer matches the
+// digest recorded at install time.
+var ErrIntegrity = errors.New("rule pack integrity check failed")
+
+// Manifest describes an installed rule pack version.
+type Manifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Digest is the registry artifact digest the pack was installed from.
+	Digest string `json:"digest"`
+	// Files maps each rule file (relative to the version directory) to its
+	// sha256 digest.
+	Files map[string]string `json:"files"`
+}
+
+// Pack is an installed rule pack version resolved from the local store.
+type Pack struct {
+	Manifest
+	Dir string
+}
+
+// DefaultDir returns the rule pack directory, respecting NOX_HOME.
+func DefaultDir() string {
+	if h := os.Getenv("NOX_HOME"); h != "" {
+		return filepath.Join(h, "rulepacks")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".nox", "rulepacks")
+}
+
+// ParseRef splits "name@constraint" into its parts. The constraint defaults
+// to "*" when omitted.
+func ParseRef(ref string) (name, constraint string) {
+	if idx := strings.LastIndex(ref, "@"); idx > 0 {
+		return ref[:idx], ref[idx+1:]
+	}
+	return ref, "*"
+}
+
+// packDir returns the directory holding all versions of name, rejecting
+// names that would escape dir.
+func packDir(dir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid rule pack name %q", name)
+	}
+	return filepath.Join(dir, clean), nil
+}
+
+// Install writes the rule files of a pack version into dir and records their
+// digests in a manifest. Only .yaml and .yml files are accepted. An existing
+// installation of the same version is replaced.
+func Install(dir, name, version, digest string, files map[string][]byte) (*Pack, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("rule pack %s@%s contains no rule files", name, version)
+	}
+	base, err := packDir(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := registry.ParseVersion(version); err != nil {
+		return nil, fmt.Errorf("rule pack %s: invalid version %q: %w", name, version, err)
+	}
+
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		return nil, fmt.Errorf("creating rule pack directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(base, ".install-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating rule pack directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	m := Manifest{Name: name, Version: version, Digest: digest, Files: make(map[string]string, len(files))}
+	for rel, data := range files {
+		clean := filepath.Clean(filepath.FromSlash(rel))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("rule pack %s@%s: invalid file path %q", name, version, rel)
+		}
+		ext := strings.ToLower(filepath.Ext(clean))
+		if ext != ".yaml" && ext != ".yml" {
+			return nil, fmt.Errorf("rule pack %s@%s: unexpected file %q (only YAML rule files are allowed)", name, version, rel)
+		}
+		dst := filepath.Join(tmp, clean)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, fmt.Errorf("writing rule pack: %w", err)
+		}
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return nil, fmt.Errorf("writing rule pack: %w", err)
+		}
+		m.Files[filepath.ToSlash(clean)] = trust.ComputeDigest(data).String()
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ManifestFile), data, 0o644); err != nil {
+		return nil, fmt.Errorf("writing rule pack manifest: %w", err)
+	}
+
+	final := filepath.Join(base, version)
+	if err := os.RemoveAll(final); err != nil {
+		return nil, fmt.Errorf("replacing rule pack: %w", err)
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		return nil, fmt.Errorf("installing rule pack: %w", err)
+	}
+	return &Pack{Manifest: m, Dir: final}, nil
+}
+
+// Remove deletes every installed version of name from dir.
+func Remove(dir, name string) error {
+	base, err := packDir(dir, name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(base)
+}
+
+// Resolve returns the highest installed version of the pack referenced by
+// ref ("name" or "name@constraint") that satisfies its constraint.
+func Resolve(dir, ref string) (*Pack, error) {
+	name, constraint := ParseRef(ref)
+	con, err := registry.ParseConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("rule pack %s: %w", ref, err)
+	}
+	base, err := packDir(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(base)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading rule pack directory: %w", err)
+	}
+
+	var (
+		best    *Pack
+		bestVer registry.Version
+	)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		v, err := registry.ParseVersion(e.Name())
+		if err != nil || !con.Match(v) {
+			continue
+		}
+		if best != nil && v.Compare(bestVer) <= 0 {
+			continue
+		}
+		m, err := readManifest(filepath.Join(base, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("rule pack %s@%s: %w", name, e.Name(), err)
+		}
+		best = &Pack{Manifest: *m, Dir: filepath.Join(base, e.Name())}
+		bestVer = v
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s (install it with: nox plugin install %s)", ErrNotInstalled, ref, ref)
+	}
+	return best, nil
+}
+
+func readManifest(versionDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(versionDir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Load verifies every rule file against the manifest digests and returns
+// the combined rule set. Files are loaded in sorted order, and a rule may
+// be in a category that any file of the pack declares.
+func (p *Pack) Load() (*rules.RuleSet, error) {
+	names := make([]string, 0, len(p.Files))
+	for rel := range p.Files {
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+
+	paths := make([]string, 0, len(names))
+	for _, rel := range names {
+		path := filepath.Join(p.Dir, filepath.FromSlash(rel))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("rule pack %s@%s: %w", p.Name, p.Version, err)
+		}
+		match, err := trust.VerifyDigest(data, p.Files[rel])
+		if err != nil {
+			return nil, fmt.Errorf("rule pack %s@%s: %s: %w", p.Name, p.Version, rel, err)
+		}
+		if !match {
+			return nil, fmt.Errorf("%w: %s@%s: %s was modified after install", ErrIntegrity, p.Name, p.Version, rel)
+		}
+		paths = append(paths, path)
+	}
+	rs, err := rules.LoadRulesFromFiles(paths)
+	if err != nil {
+		return nil, fmt.Errorf("rule pack %s@%s: %w", p.Name, p.Version, err)
+	}
+	seen := make(map[string]bool, len(rs.Rules()))
+	for _, r := range rs.Rules() {
+		if seen[r.ID] {
+			return nil, fmt.Errorf("rule pack %s@%s: duplicate rule ID %q", p.Name, p.Version, r.ID)
+		}
+		seen[r.ID] = true
+	}
+	return rs, nil
+}
@@ -0,0 +1,125 @@
+package rulepack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const packRules = `rules:
+  - id: ACME-001
+    version: "1.0"
+    description: "ACME internal token"
+    severity: high
+    confidence: high
+    matcher_type: regex
+    pattern: 'acme_[a-z0-9]{16}'
+`
+
+func TestInstallResolveLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{"tokens.yaml": []byte(packRules)}
+
+	for _, v := range []string{"1.0.0", "1.2.0", "2.0.0"} {
+		if _, err := Install(dir, "acme/secret-rules", v, "sha256:abc", files); err != nil {
+			t.Fatalf("Install %s: %v", v, err)
+		}
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"acme/secret-rules", "2.0.0"},
+		{"acme/secret-rules@1.2.0", "1.2.0"},
+		{"acme/secret-rules@^1.0.0", "1.2.0"},
+	}
+	for _, tt := range tests {
+		pack, err := Resolve(dir, tt.ref)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", tt.ref, err)
+		}
+		if pack.Version != tt.want {
+			t.Errorf("Resolve(%q) = %s, want %s", tt.ref, pack.Version, tt.want)
+		}
+	}
+
+	pack, err := Resolve(dir, "acme/secret-rules@1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := pack.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !rs.HasID("ACME-001") {
+		t.Fatal("expected ACME-001 in loaded pack")
+	}
+}
+
+func TestResolve_NotInstalled(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Install(dir, "acme/rules", "1.0.0", "", map[string][]byte{"r.yaml": []byte(packRules)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{"acme/other", "acme/rules@2.0.0"} {
+		if _, err := Resolve(dir, ref); !errors.Is(err, ErrNotInstalled) {
+			t.Errorf("Resolve(%q) error = %v, want ErrNotInstalled", ref, err)
+		}
+	}
+}
+
+func TestLoad_DetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	pack, err := Install(dir, "acme/rules", "1.0.0", "", map[string][]byte{"r.yaml": []byte(packRules)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pack.Dir, "r.yaml"), []byte(packRules+"\n# edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pack.Load(); !errors.Is(err, ErrIntegrity) {
+		t.Fatalf("Load error = %v, want ErrIntegrity", err)
+	}
+}
+
+func TestInstall_RejectsInvalidInput(t *testing.T) {
+	dir := t.TempDir()
+	rules := []byte(packRules)
+
+	tests := []struct {
+		name    string
+		pack    string
+		version string
+		files   map[string][]byte
+	}{
+		{"traversal name", "../escape", "1.0.0", map[string][]byte{"r.yaml": rules}},
+		{"traversal file", "acme/rules", "1.0.0", map[string][]byte{"../r.yaml": rules}},
+		{"non-yaml file", "acme/rules", "1.0.0", map[string][]byte{"run.sh": []byte("#!/bin/sh")}},
+		{"bad version", "acme/rules", "latest", map[string][]byte{"r.yaml": rules}},
+		{"empty", "acme/rules", "1.0.0", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Install(dir, tt.pack, tt.version, "", tt.files); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Install(dir, "acme/rules", "1.0.0", "", map[string][]byte{"r.yaml": []byte(packRules)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(dir, "acme/rules"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := Resolve(dir, "acme/rules"); !errors.Is(err, ErrNotInstalled) {
+		t.Fatalf("expected pack to be gone, got %v", err)
+	}
+}
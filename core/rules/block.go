@@ -0,0 +1,108 @@
+package rules
+
+import (
+	"regexp"
+)
+
+// DefaultBlockMaxLines bounds a block when Block.MaxLines is zero.
+const DefaultBlockMaxLines = 100
+
+// Block makes a regex rule match a span of lines, such as a PEM private key
+// or a JSON key file, instead of a single line. The resulting findings cover
+// StartLine..EndLine of the whole block.
+//
+// With End set, Pattern matches the first line of the block and End the
+// line that closes it; End is searched for from the end of the Pattern match
+// up to MaxLines lines further. Without End, Pattern is a multi-line regex
+// that is only run against windows of MaxLines lines starting at lines that
+// contain one of the rule's Keywords, which such rules must have.
+type Block struct {
+	End string `yaml:"end"`
+	// MaxLines is the largest number of lines a block may span. Zero means
+	// DefaultBlockMaxLines.
+	MaxLines int `yaml:"max_lines"`
+	// EndOptional reports the Pattern match on its own when no End match
+	// follows within MaxLines. By default such matches are dropped.
+	EndOptional bool `yaml:"end_optional"`
+}
+
+func (b *Block) maxLines() int {
+	if b.MaxLines > 0 {
+		return b.MaxLines
+	}
+	return DefaultBlockMaxLines
+}
+
+// matchBlock implements RegexMatcher.Match for rules with a Block. In
+// begin/end mode MatchText is the Pattern match alone, so fingerprints do not
+// change when a line rule gains an End.
+func matchBlock(content []byte, rule *Rule, re *regexp.Regexp, lineStarts []int) []MatchResult {
+	b := rule.Block
+	// windowEnd returns the offset just past the last line of a block that
+	// starts on the 0-based line.
+	windowEnd := func(line int) int {
+		if n := line + b.maxLines(); n < len(lineStarts) {
+			return lineStarts[n]
+		}
+		return len(content)
+	}
+	result := func(start, end int, text string) MatchResult {
+		line := findLine(lineStarts, start)
+		endLine := line
+		if end > start {
+			endLine = findLine(lineStarts, end-1)
+		}
+		return MatchResult{
+			Line:      line + 1,
+			Column:    start - lineStarts[line] + 1,
+			EndLine:   endLine + 1,
+			EndColumn: end - lineStarts[endLine] + 1,
+			MatchText: text,
+		}
+	}
+
+	var results []MatchResult
+	covered := 0 // blocks do not overlap
+	if b.End != "" {
+		endRe, err := CompilePattern(b.End)
+		if err != nil {
+			return nil
+		}
+		for _, loc := range re.FindAllIndex(content, -1) {
+			if loc[0] < covered {
+				continue
+			}
+			begin := string(content[loc[0]:loc[1]])
+			limit := windowEnd(findLine(lineStarts, loc[0]))
+			if e := endRe.FindIndex(content[loc[1]:limit]); e != nil {
+				covered = loc[1] + e[1]
+				results = append(results, result(loc[0], covered, begin))
+			} else if b.EndOptional {
+				covered = loc[1]
+				results = append(results, result(loc[0], loc[1], begin))
+			}
+		}
+		return results
+	}
+
+	contentFolded := foldASCII(content)
+	for i, start := range lineStarts {
+		if start < covered {
+			continue
+		}
+		lineEnd := len(content)
+		if i+1 < len(lineStarts) {
+			lineEnd = lineStarts[i+1]
+		}
+		if !containsAnyKeyword(contentFolded[start:lineEnd], rule.Keywords) {
+			continue
+		}
+		loc := re.FindIndex(content[start:windowEnd(i)])
+		if loc == nil {
+			continue
+		}
+		covered = start + loc[1]
+		results = append(results, result(start+loc[0], covered, string(content[start+loc[0]:covered])))
+	}
+	return results
+}
@@ -0,0 +1,101 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEngine_ScanFile_BlockBeginEnd(t *testing.T) {
+	yaml := `rules:
+  - id: "BLK-001"
+    severity: "high"
+    matcher_type: "regex"
+    pattern: "BEGIN KEY"
+    keywords: ["begin key"]
+    block:
+      end: "END KEY"
+      max_lines: 4
+`
+	rs, err := LoadRulesFromFile(writeTemp(t, t.TempDir(), "rules.yaml", yaml))
+	if err != nil {
+		t.Fatalf("loading rules: %v", err)
+	}
+	content := []byte("x\nBEGIN KEY\naaa\nbbb\nEND KEY\n\nBEGIN KEY\n1\n2\n3\n4\nEND KEY\nBEGIN KEY END KEY\n")
+	results, err := NewEngine(rs).ScanFile("key.pem", content)
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	// The second block is longer than max_lines and has no match.
+	if len(results) != 2 {
+		t.Fatalf("expected 2 findings, got %d: %+v", len(results), results)
+	}
+	if loc := results[0].Location; loc.StartLine != 2 || loc.EndLine != 5 || loc.StartColumn != 1 || loc.EndColumn != 8 {
+		t.Errorf("first block location = %+v, want lines 2-5, columns 1-8", loc)
+	}
+	if loc := results[1].Location; loc.StartLine != 13 || loc.EndLine != 13 || loc.EndColumn != 18 {
+		t.Errorf("single-line block location = %+v, want line 13, end column 18", loc)
+	}
+}
+
+func TestEngine_ScanFile_BlockEndOptional(t *testing.T) {
+	rs := NewRuleSet()
+	line := &Rule{ID: "BLK-002", Severity: "high", MatcherType: "regex", Pattern: "BEGIN KEY"}
+	rs.Add(line)
+	e := NewEngine(rs)
+	content := []byte("BEGIN KEY\nbody\n")
+	before, _ := e.ScanFile("a.pem", content)
+
+	line.Block = &Block{End: "END KEY", EndOptional: true}
+	after, _ := e.ScanFile("a.pem", content)
+	if len(after) != 1 || after[0].Location.EndLine != 1 {
+		t.Fatalf("expected the begin line alone without an end, got %+v", after)
+	}
+	if after[0].Fingerprint != before[0].Fingerprint {
+		t.Error("adding a block changed the fingerprint")
+	}
+
+	spanned, _ := e.ScanFile("a.pem", []byte("BEGIN KEY\nbody\nEND KEY\n"))
+	if len(spanned) != 1 || spanned[0].Location.EndLine != 3 || spanned[0].Fingerprint != before[0].Fingerprint {
+		t.Errorf("expected a block over lines 1-3 with the same fingerprint, got %+v", spanned)
+	}
+}
+
+func TestEngine_ScanFile_BlockRegexWindow(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{
+		ID: "BLK-003", Severity: "high", MatcherType: "regex",
+		Pattern:  `(?s)start.{3,}?stop`,
+		Keywords: []string{"start"},
+		Block:    &Block{MaxLines: 3},
+	})
+	e := NewEngine(rs)
+
+	content := []byte("start\nab\nstop\nstart\n1\n2\n3\nstop\n")
+	results, err := e.ScanFile("a.txt", content)
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected only the block within max_lines, got %+v", results)
+	}
+	if loc := results[0].Location; loc.StartLine != 1 || loc.EndLine != 3 {
+		t.Errorf("location = %+v, want lines 1-3", loc)
+	}
+}
+
+func TestLoadRulesFromFile_InvalidBlock(t *testing.T) {
+	tests := map[string]string{
+		"matcher":  "matcher_type: \"entropy\"\n    block:\n      end: \"x\"",
+		"keywords": "matcher_type: \"regex\"\n    block:\n      max_lines: 3",
+		"end":      "matcher_type: \"regex\"\n    block:\n      end: \"(\"",
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := "rules:\n  - id: \"BLK-004\"\n    severity: \"high\"\n    pattern: \"x\"\n    " + body + "\n"
+			_, err := LoadRulesFromFile(writeTemp(t, t.TempDir(), "rules.yaml", yaml))
+			if err == nil || !strings.Contains(err.Error(), "block") {
+				t.Errorf("expected a block validation error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// CategoryInfo describes a finding category: its name, the label reports
+// show for it and the weight of its findings in the badge grade, which
+// multiplies their severity weight.
+type CategoryInfo struct {
+	Name        string  `yaml:"name" json:"name"`
+	Label       string  `yaml:"label" json:"label"`
+	BadgeWeight float64 `yaml:"badge_weight" json:"badge_weight"`
+}
+
+// CategoryDecl declares a category in a rules file or in .nox.yaml. Label
+// defaults to the name and BadgeWeight to 1.
+type CategoryDecl struct {
+	Name        string   `yaml:"name"`
+	Label       string   `yaml:"label"`
+	BadgeWeight *float64 `yaml:"badge_weight"`
+}
+
+// Info returns the category declared by d.
+func (d CategoryDecl) Info() CategoryInfo {
+	info := CategoryInfo{Name: d.Name, Label: d.Label, BadgeWeight: 1}
+	if info.Label == "" {
+		info.Label = d.Name
+	}
+	if d.BadgeWeight != nil {
+		info.BadgeWeight = *d.BadgeWeight
+	}
+	return info
+}
+
+// builtinCategories describes the categories of Categories.
+var builtinCategories = []CategoryInfo{
+	{Name: CategorySecrets, Label: "Secrets", BadgeWeight: 1},
+	{Name: CategoryData, Label: "Data", BadgeWeight: 1},
+	{Name: CategoryAI, Label: "AI", BadgeWeight: 1},
+	{Name: CategoryIaC, Label: "IaC", BadgeWeight: 1},
+	{Name: CategoryDeps, Label: "Dependencies", BadgeWeight: 1},
+	{Name: CategoryContainer, Label: "Containers", BadgeWeight: 1},
+	{Name: CategoryAudit, Label: "Audit", BadgeWeight: 1},
+	{Name: CategoryCustom, Label: "Custom", BadgeWeight: 1},
+}
+
+// categoryName is the form of a declared category name.
+var categoryName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// CategoryRegistry holds the finding categories of a scan: the built-in
+// ones and those declared by rule files, rule packs and the config. A nil
+// registry holds the built-in categories.
+type CategoryRegistry struct {
+	infos  []CategoryInfo
+	byName map[string]int
+}
+
+// NewCategoryRegistry returns a registry of the built-in categories.
+func NewCategoryRegistry() *CategoryRegistry {
+	c := &CategoryRegistry{byName: make(map[string]int)}
+	for _, info := range builtinCategories {
+		c.byName[info.Name] = len(c.infos)
+		c.infos = append(c.infos, info)
+	}
+	return c
+}
+
+// builtinRegistry is the registry a nil *CategoryRegistry stands for.
+var builtinRegistry = NewCategoryRegistry()
+
+func (c *CategoryRegistry) orBuiltin() *CategoryRegistry {
+	if c == nil {
+		return builtinRegistry
+	}
+	return c
+}
+
+// Register adds the category info. A built-in name, a malformed name, a
+// negative weight or a second declaration of a name that differs from the
+// first is an error; registering the same info twice is not.
+func (c *CategoryRegistry) Register(info CategoryInfo) error {
+	if !categoryName.MatchString(info.Name) {
+		return fmt.Errorf("invalid category name %q (want lowercase letters, digits, - and _)", info.Name)
+	}
+	if ValidCategory(info.Name) {
+		return fmt.Errorf("category %q is built in", info.Name)
+	}
+	if info.BadgeWeight < 0 {
+		return fmt.Errorf("category %q: negative badge_weight %g", info.Name, info.BadgeWeight)
+	}
+	if i, ok := c.byName[info.Name]; ok {
+		if c.infos[i] != info {
+			return fmt.Errorf("category %q is already declared as %q with badge_weight %g", info.Name, c.infos[i].Label, c.infos[i].BadgeWeight)
+		}
+		return nil
+	}
+	c.byName[info.Name] = len(c.infos)
+	c.infos = append(c.infos, info)
+	return nil
+}
+
+// Lookup returns the info of the category name.
+func (c *CategoryRegistry) Lookup(name string) (CategoryInfo, bool) {
+	c = c.orBuiltin()
+	i, ok := c.byName[name]
+	if !ok {
+		return CategoryInfo{}, false
+	}
+	return c.infos[i], true
+}
+
+// Valid reports whether name is a registered category.
+func (c *CategoryRegistry) Valid(name string) bool {
+	_, ok := c.Lookup(name)
+	return ok
+}
+
+// Categories returns the registered categories, the built-in ones first
+// and the others in the order they were registered.
+func (c *CategoryRegistry) Categories() []CategoryInfo {
+	return slices.Clone(c.orBuiltin().infos)
+}
+
+// Names returns the names of the registered categories, in the order of
+// Categories.
+func (c *CategoryRegistry) Names() []string {
+	c = c.orBuiltin()
+	names := make([]string, len(c.infos))
+	for i, info := range c.infos {
+		names[i] = info.Name
+	}
+	return names
+}
+
+// Weight returns the badge weight of the category name, or 1 for a
+// category that is not registered.
+func (c *CategoryRegistry) Weight(name string) float64 {
+	if info, ok := c.Lookup(name); ok {
+		return info.BadgeWeight
+	}
+	return 1
+}
+
+// String lists the registered category names, for error messages.
+func (c *CategoryRegistry) String() string {
+	return strings.Join(c.Names(), ", ")
+}
+
+// CategoryOf returns the category of the rule in rs that produced f or,
+// when rs is nil or does not hold it, the category in the metadata of f
+// under findings.MetaCategory, as a plugin may set it. It is
+// CategoryCustom otherwise.
+func (rs *RuleSet) CategoryOf(f *findings.Finding) string {
+	if rs != nil {
+		if r, ok := rs.ByID(f.RuleID); ok && r.Category != "" {
+			return r.Category
+		}
+	}
+	if c := f.Metadata[findings.MetaCategory]; c != "" {
+		return c
+	}
+	return CategoryCustom
+}
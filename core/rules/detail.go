@@ -0,0 +1,96 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// GroupMatch is a single capture group within a MatchDetail.
+type GroupMatch struct {
+	Index int    `json:"index"`
+	Name  string `json:"name,omitempty"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// MatchDetail describes one match of a rule with its byte span and, for
+// regex rules, the captured groups. It is intended for rule authoring and
+// debugging rather than the scan hot path.
+type MatchDetail struct {
+	Line   int          `json:"line"`
+	Column int          `json:"column"`
+	Start  int          `json:"start"`
+	End    int          `json:"end"`
+	Text   string       `json:"text"`
+	Groups []GroupMatch `json:"groups,omitempty"`
+}
+
+// MatchDetails runs a single rule against content and returns every match.
+// Unlike Engine.ScanFile it ignores FilePatterns and Keywords so authors can
+// see what the pattern itself matches; use KeywordsPresent to check whether
+// the engine's keyword pre-filter would skip the content. Block rules report
+// the start of each block without capture groups. An invalid regex pattern is
+// returned as an error.
+func MatchDetails(rule *Rule, content []byte) ([]MatchDetail, error) {
+	if rule.Block != nil {
+		if _, err := CompilePattern(rule.Pattern); err != nil {
+			return nil, err
+		}
+	}
+	if rule.MatcherType != "regex" || rule.Block != nil {
+		matcher := NewDefaultMatcherRegistry().Get(rule.MatcherType)
+		if matcher == nil {
+			return nil, fmt.Errorf("no matcher registered for type %q (rule %s)", rule.MatcherType, rule.ID)
+		}
+		var out []MatchDetail
+		for _, mr := range matcher.Match(content, rule) {
+			out = append(out, MatchDetail{Line: mr.Line, Column: mr.Column, Text: mr.MatchText})
+		}
+		return out, nil
+	}
+
+	re, err := CompilePattern(rule.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	names := re.SubexpNames()
+
+	var out []MatchDetail
+	for _, loc := range re.FindAllSubmatchIndex(content, -1) {
+		line := 1 + bytes.Count(content[:loc[0]], []byte("\n"))
+		col := loc[0] - (bytes.LastIndexByte(content[:loc[0]], '\n') + 1) + 1
+		d := MatchDetail{
+			Line:   line,
+			Column: col,
+			Start:  loc[0],
+			End:    loc[1],
+			Text:   string(content[loc[0]:loc[1]]),
+		}
+		for g := 1; g*2 < len(loc); g++ {
+			start, end := loc[g*2], loc[g*2+1]
+			if start < 0 {
+				continue
+			}
+			d.Groups = append(d.Groups, GroupMatch{
+				Index: g,
+				Name:  names[g],
+				Start: start,
+				End:   end,
+				Text:  string(content[start:end]),
+			})
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// KeywordsPresent reports whether content would pass the rule's keyword
+// pre-filter: it must contain one of the rule's Keywords, if any, and one
+// of its ContextKeywords, if any. Rules without either always pass.
+func KeywordsPresent(rule *Rule, content []byte) bool {
+	if len(rule.Keywords) == 0 && len(rule.ContextKeywords) == 0 {
+		return true
+	}
+	return passesKeywords(rule, foldASCII(content))
+}
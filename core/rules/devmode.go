@@ -0,0 +1,7 @@
+//go:build !noxdev
+
+package rules
+
+// devMode is set in builds with the noxdev tag, which rule authors use to
+// have every loaded rule checked against its examples.
+const devMode = false
@@ -0,0 +1,5 @@
+//go:build noxdev
+
+package rules
+
+const devMode = true
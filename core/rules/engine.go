@@ -2,32 +2,203 @@ package rules
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"path/filepath"
-	"strings"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/nox-hq/nox/core/findings"
 )
 
+// ErrFileTimeout is returned by ScanFileContext when the per-file time
+// budget set with SetFileTimeout is exhausted before every rule has run.
+var ErrFileTimeout = errors.New("file scan timed out")
+
+// DefaultMaxLineLength caps how many bytes of a single line are matched
+// against rules. Longer lines (minified bundles, embedded blobs) are
+// truncated for matching so one huge line cannot dominate the scan.
+const DefaultMaxLineLength = 32 * 1024
+
+// DefaultLineBudget is the time one rule may spend matching a single long
+// line. A rule that exceeds it is not matched against the later long lines
+// of the file.
+const DefaultLineBudget = 100 * time.Millisecond
+
+// longLineLength is the length above which a line is matched against each
+// regex rule on its own, so that the per-line budget can be enforced
+// between lines.
+const longLineLength = 4 * 1024
+
+// LineNote records a line that was not fully matched against the rules:
+// one longer than the line length cap, of which only the first Scanned
+// bytes were matched, or, when RuleID is set, one on which that rule
+// exceeded the per-line budget and after which it skipped the file's long
+// lines.
+type LineNote struct {
+	Path     string        `json:"path"`
+	Line     int           `json:"line"`
+	Length   int           `json:"length"`
+	Scanned  int           `json:"scanned,omitempty"`
+	RuleID   string        `json:"rule_id,omitempty"`
+	Duration time.Duration `json:"duration_ns,omitempty"`
+}
+
+// RuleTiming records the cumulative matching time spent on a single rule,
+// the number of files it was matched against and the matches it produced.
+type RuleTiming struct {
+	RuleID   string
+	Duration time.Duration
+	Files    int
+	Matches  int
+}
+
 // Engine ties a RuleSet and a MatcherRegistry together to scan file content
 // and produce findings.
 type Engine struct {
-	rules    *RuleSet
-	matchers *MatcherRegistry
+	rules         *RuleSet
+	matchers      *MatcherRegistry
+	fileTimeout   time.Duration
+	maxLineLength int
+	lineBudget    time.Duration
+
+	timingMu sync.Mutex
+	timings  map[string]*RuleTiming
+	notes    []LineNote
 }
 
 // NewEngine creates an Engine with the given rules and the default matcher
-// registry.
+// registry. Regex patterns are compiled up front so the per-file hot path
+// only performs cache lookups.
 func NewEngine(rules *RuleSet) *Engine {
+	for _, r := range rules.Rules() {
+		if r.MatcherType == "regex" {
+			_, _ = CompilePattern(r.Pattern)
+			if r.Block != nil && r.Block.End != "" {
+				_, _ = CompilePattern(r.Block.End)
+			}
+		}
+	}
+	return &Engine{
+		rules:         rules,
+		matchers:      NewDefaultMatcherRegistry(),
+		maxLineLength: DefaultMaxLineLength,
+		lineBudget:    DefaultLineBudget,
+	}
+}
+
+// NewLazyEngine is like NewEngine but compiles each regex pattern the first
+// time a file passes the rule's path and keyword filters, so rules that never
+// apply are never compiled. It suits scans of a handful of files, such as
+// pre-commit hooks, where compiling every pattern would dominate.
+func NewLazyEngine(rules *RuleSet) *Engine {
 	return &Engine{
-		rules:    rules,
-		matchers: NewDefaultMatcherRegistry(),
+		rules:         rules,
+		matchers:      NewDefaultMatcherRegistry(),
+		maxLineLength: DefaultMaxLineLength,
+		lineBudget:    DefaultLineBudget,
 	}
 }
 
+// SetMaxLineLength changes the per-line matching cap. Zero disables it.
+func (e *Engine) SetMaxLineLength(n int) { e.maxLineLength = n }
+
+// SetLineBudget changes the time a regex rule may spend matching a single
+// long line. Zero disables it, so long lines are matched with the rest of
+// the file.
+func (e *Engine) SetLineBudget(d time.Duration) { e.lineBudget = d }
+
+// LineNotes returns the lines that were capped or on which a rule exceeded
+// the line budget, ordered by path, line and rule.
+func (e *Engine) LineNotes() []LineNote {
+	e.timingMu.Lock()
+	defer e.timingMu.Unlock()
+
+	out := slices.Clone(e.notes)
+	SortLineNotes(out)
+	return out
+}
+
+// SortLineNotes orders notes by path, line and rule.
+func SortLineNotes(notes []LineNote) {
+	sort.Slice(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.RuleID < b.RuleID
+	})
+}
+
+func (e *Engine) addNotes(notes ...LineNote) {
+	if len(notes) == 0 {
+		return
+	}
+	e.timingMu.Lock()
+	defer e.timingMu.Unlock()
+	e.notes = append(e.notes, notes...)
+}
+
+// RuleTimings returns the cumulative matching time per rule, slowest first.
+// Rules skipped by path or keyword filtering are not included.
+func (e *Engine) RuleTimings() []RuleTiming {
+	e.timingMu.Lock()
+	defer e.timingMu.Unlock()
+
+	out := make([]RuleTiming, 0, len(e.timings))
+	for _, t := range e.timings {
+		out = append(out, *t)
+	}
+	return SlowestRules(out, 0)
+}
+
+// SlowestRules sorts timings by descending duration (ties broken by rule ID)
+// and returns the first n entries. n <= 0 returns all of them.
+func SlowestRules(timings []RuleTiming, n int) []RuleTiming {
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Duration != timings[j].Duration {
+			return timings[i].Duration > timings[j].Duration
+		}
+		return timings[i].RuleID < timings[j].RuleID
+	})
+	if n > 0 && len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings
+}
+
+// recordTiming adds d and matches to the cumulative totals for ruleID.
+func (e *Engine) recordTiming(ruleID string, d time.Duration, matches int) {
+	e.timingMu.Lock()
+	defer e.timingMu.Unlock()
+
+	if e.timings == nil {
+		e.timings = make(map[string]*RuleTiming)
+	}
+	t, ok := e.timings[ruleID]
+	if !ok {
+		t = &RuleTiming{RuleID: ruleID}
+		e.timings[ruleID] = t
+	}
+	t.Duration += d
+	t.Files++
+	t.Matches += matches
+}
+
 // Rules returns the engine's RuleSet.
 func (e *Engine) Rules() *RuleSet { return e.rules }
 
+// SetFileTimeout bounds the time ScanFileContext spends on a single file.
+// Zero (the default) means no per-file limit.
+func (e *Engine) SetFileTimeout(d time.Duration) { e.fileTimeout = d }
+
 // ScanFile runs every applicable rule against the given file content and
 // returns the resulting findings. A rule applies if its FilePatterns list is
 // empty (matches everything) or if at least one of its patterns matches the
@@ -35,24 +206,65 @@ func (e *Engine) Rules() *RuleSet { return e.rules }
 // bytes in the first 512 bytes) are skipped to avoid false positives from
 // compiled binaries that embed rule patterns.
 func (e *Engine) ScanFile(path string, content []byte) ([]findings.Finding, error) {
+	return e.ScanFileContext(context.Background(), path, content)
+}
+
+// ScanFileContext is like ScanFile but stops between rules once ctx is done
+// or the per-file timeout expires. The findings produced so far are returned
+// together with ctx.Err() or ErrFileTimeout respectively.
+func (e *Engine) ScanFileContext(ctx context.Context, path string, content []byte) ([]findings.Finding, error) {
+	return e.scanFile(ctx, path, content, false)
+}
+
+// ScanFileAllRulesContext is like ScanFileContext but applies every rule
+// regardless of its FilePatterns. It is used for files whose names mark
+// them as likely to hold secrets even though their extension does not.
+func (e *Engine) ScanFileAllRulesContext(ctx context.Context, path string, content []byte) ([]findings.Finding, error) {
+	return e.scanFile(ctx, path, content, true)
+}
+
+func (e *Engine) scanFile(ctx context.Context, path string, content []byte, allRules bool) ([]findings.Finding, error) {
 	if isBinary(content) {
 		return nil, nil
 	}
 
+	var deadline time.Time
+	if e.fileTimeout > 0 {
+		deadline = time.Now().Add(e.fileTimeout)
+	}
+
+	content, capped := capLineLength(NormalizeLineEndings(content), e.maxLineLength)
+	for i := range capped {
+		capped[i].Path = path
+	}
+	e.addNotes(capped...)
+	var short []byte
+	var long []longLine
+	if e.lineBudget > 0 {
+		short, long = splitLongLines(content, longLineLength)
+	}
+
 	var out []findings.Finding
 
-	// Pre-compute a lowercase copy of content for keyword filtering.
-	var contentLower []byte
+	// Pre-compute an ASCII-folded copy of content for keyword filtering.
+	var contentFolded []byte
 	for _, rule := range e.rules.Rules() {
-		if !fileMatchesRule(path, rule) {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return out, fmt.Errorf("%s: %w (%s)", path, ErrFileTimeout, e.fileTimeout)
+		}
+
+		if !allRules && !fileMatchesRule(path, rule) {
 			continue
 		}
 
-		if len(rule.Keywords) > 0 {
-			if contentLower == nil {
-				contentLower = bytes.ToLower(content)
+		if len(rule.Keywords) > 0 || len(rule.ContextKeywords) > 0 {
+			if contentFolded == nil {
+				contentFolded = foldASCII(content)
 			}
-			if !containsAnyKeyword(contentLower, rule.Keywords) {
+			if !passesKeywords(rule, contentFolded) {
 				continue
 			}
 		}
@@ -62,7 +274,18 @@ func (e *Engine) ScanFile(path string, content []byte) ([]findings.Finding, erro
 			return nil, fmt.Errorf("no matcher registered for type %q (rule %s)", rule.MatcherType, rule.ID)
 		}
 
-		results := matcher.Match(content, rule)
+		matchStart := time.Now()
+		var results []MatchResult
+		if len(long) > 0 && rule.MatcherType == "regex" && rule.Block == nil {
+			results = matcher.Match(short, rule)
+			results = append(results, e.matchLongLines(path, rule, matcher, long)...)
+		} else {
+			results = matcher.Match(content, rule)
+		}
+		e.recordTiming(rule.ID, time.Since(matchStart), len(results))
+		if len(results) > 0 {
+			slog.Debug("rule matched", "rule_id", rule.ID, "path", path, "matches", len(results))
+		}
 		for _, mr := range results {
 			loc := findings.Location{
 				FilePath:    path,
@@ -71,6 +294,9 @@ func (e *Engine) ScanFile(path string, content []byte) ([]findings.Finding, erro
 				StartColumn: mr.Column,
 				EndColumn:   mr.Column + len(mr.MatchText),
 			}
+			if mr.EndLine > 0 {
+				loc.EndLine, loc.EndColumn = mr.EndLine, mr.EndColumn
+			}
 
 			f := findings.Finding{
 				ID:         fmt.Sprintf("%s:%s:%d", rule.ID, path, mr.Line),
@@ -92,15 +318,111 @@ func (e *Engine) ScanFile(path string, content []byte) ([]findings.Finding, erro
 	return out, nil
 }
 
-// containsAnyKeyword returns true if content contains at least one of the
-// keywords. Content must be lowercase; keywords are lowered automatically.
-func containsAnyKeyword(contentLower []byte, keywords []string) bool {
-	for _, kw := range keywords {
-		if bytes.Contains(contentLower, []byte(strings.ToLower(kw))) {
-			return true
+// matchLongLines matches rule against each long line on its own. Once the
+// rule takes longer than the line budget on one line, it is not matched
+// against the later ones, and a LineNote records the line.
+func (e *Engine) matchLongLines(path string, rule *Rule, m Matcher, long []longLine) []MatchResult {
+	var out []MatchResult
+	for _, ll := range long {
+		start := time.Now()
+		for _, mr := range m.Match(ll.text, rule) {
+			mr.Line += ll.line - 1
+			if mr.EndLine > 0 {
+				mr.EndLine += ll.line - 1
+			}
+			out = append(out, mr)
+		}
+		if d := time.Since(start); d > e.lineBudget {
+			e.addNotes(LineNote{Path: path, Line: ll.line, Length: len(ll.text), RuleID: rule.ID, Duration: d})
+			break
 		}
 	}
-	return false
+	return out
+}
+
+// longLine is a line of a file that is matched on its own.
+type longLine struct {
+	line int // 1-based
+	text []byte
+}
+
+// splitLongLines returns content with the lines longer than limit emptied,
+// so that line numbers are unchanged, and those lines. A match can then no
+// longer span a long line and its neighbours. content is returned as-is
+// when no line exceeds limit.
+func splitLongLines(content []byte, limit int) ([]byte, []longLine) {
+	var long []longLine
+	var short []byte
+	last := 0
+	for start, line := 0, 1; start < len(content); line++ {
+		end := bytes.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content) - start
+		}
+		if end > limit {
+			if short == nil {
+				short = make([]byte, 0, len(content))
+			}
+			short = append(short, content[last:start]...)
+			long = append(long, longLine{line: line, text: content[start : start+end]})
+			last = start + end
+		}
+		start += end + 1
+	}
+	if long == nil {
+		return content, nil
+	}
+	return append(short, content[last:]...), long
+}
+
+// capLineLength returns content with every line longer than limit truncated to
+// limit bytes, and a LineNote, without a path, for each truncated line.
+// Newlines are preserved so line numbers and the columns of the retained
+// prefix are unchanged. content is returned as-is when no line exceeds
+// limit.
+func capLineLength(content []byte, limit int) ([]byte, []LineNote) {
+	if limit <= 0 || len(content) <= limit {
+		return content, nil
+	}
+
+	long := false
+	start := 0
+	for start < len(content) {
+		end := bytes.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content) - start
+		}
+		if end > limit {
+			long = true
+			break
+		}
+		start += end + 1
+	}
+	if !long {
+		return content, nil
+	}
+
+	var notes []LineNote
+	out := make([]byte, 0, len(content))
+	for start, n := 0, 1; start < len(content); n++ {
+		end := bytes.IndexByte(content[start:], '\n')
+		last := end < 0
+		if last {
+			end = len(content) - start
+		}
+		line := content[start : start+end]
+		if len(line) > limit {
+			notes = append(notes, LineNote{Line: n, Length: len(line), Scanned: limit})
+			line = line[:limit]
+		}
+		out = append(out, line...)
+		if last {
+			break
+		}
+		out = append(out, '\n')
+		start += end + 1
+	}
+	return out, notes
 }
 
 // fileMatchesRule returns true if the file path matches at least one of the
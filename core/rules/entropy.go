@@ -170,6 +170,50 @@ func hasSecretContext(lineLower string) bool {
 	return false
 }
 
+// IsSecretName reports whether a variable name such as NPM_TOKEN, dbPassword
+// or AWS_SECRET_ACCESS_KEY contains one of the secret hint keywords. Names
+// are split into words at underscores, dashes, dots and camelCase
+// boundaries. "key" must be a whole word so that KEYCLOAK_URL does not
+// match, and "private" alone is not enough (PRIVATE_REGISTRY); the other
+// keywords may also start or end a word (SECRETKEY, GITHUB_TOKENS).
+func IsSecretName(name string) bool {
+	words := strings.FieldsFunc(splitCamel(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		w = strings.ToLower(w)
+		for _, hint := range secretHints {
+			switch hint {
+			case "private":
+			case "key":
+				if w == hint {
+					return true
+				}
+			default:
+				if strings.HasPrefix(w, hint) || strings.HasSuffix(w, hint) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// splitCamel inserts an underscore at each lower-to-upper case boundary so
+// that camelCase names split into words.
+func splitCamel(s string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range s {
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
 // extractQuoted finds single- and double-quoted strings in line that are
 // at least minCandidateLen characters long (excluding quotes). It calls
 // addFn with the 1-based column of the quoted value and the value itself.
@@ -1009,3 +1009,25 @@ func TestIsTokenChar(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSecretName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"NPM_TOKEN":             true,
+		"AWS_SECRET_ACCESS_KEY": true,
+		"API_KEY":               true,
+		"dbPassword":            true,
+		"MYSQL_ROOT_PASSWORD":   true,
+		"SECRETKEY":             true,
+		"GITHUB_TOKENS":         true,
+		"registry-credentials":  true,
+		"KEYCLOAK_URL":          false,
+		"MONKEY":                false,
+		"PRIVATE_REGISTRY":      false,
+		"NODE_ENV":              false,
+		"PORT":                  false,
+	} {
+		if got := IsSecretName(name); got != want {
+			t.Errorf("IsSecretName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
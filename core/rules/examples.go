@@ -0,0 +1,97 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+)
+
+// Examples are inputs a rule must match and inputs it must not, which
+// "nox rules selftest" checks. Each example is scanned as the whole content
+// of a file, so it may span several lines.
+type Examples struct {
+	Match   []string `yaml:"match"`
+	NoMatch []string `yaml:"nomatch"`
+}
+
+// ExampleIssue describes an example its rule does not handle as declared.
+type ExampleIssue struct {
+	RuleID  string
+	Example string
+	// WantMatch is true for an example from Examples.Match.
+	WantMatch bool
+	Err       error
+}
+
+// String returns a one-line description of the issue.
+func (i ExampleIssue) String() string {
+	switch {
+	case i.Err != nil:
+		return fmt.Sprintf("%s: %v", i.RuleID, i.Err)
+	case i.WantMatch:
+		return fmt.Sprintf("%s: does not match %q", i.RuleID, i.Example)
+	default:
+		return fmt.Sprintf("%s: matches %q", i.RuleID, i.Example)
+	}
+}
+
+// MatchesExample reports whether r matches example. As in a scan, one of
+// the rule's keywords must appear in it; the rule's file patterns are
+// ignored. Analyzer-specific filters, such as the secrets analyzer's
+// placeholder filter, are not applied.
+func MatchesExample(r *Rule, example string) (bool, error) {
+	rs := NewRuleSet()
+	rs.Add(r)
+	results, err := NewEngine(rs).ScanFileAllRulesContext(context.Background(), "example", []byte(example))
+	if err != nil {
+		return false, err
+	}
+	return len(results) > 0, nil
+}
+
+// CheckExamples runs every rule in rs against its examples and returns an
+// issue for each example that a rule does not match as declared.
+func CheckExamples(rs *RuleSet) []ExampleIssue {
+	var issues []ExampleIssue
+	for _, r := range rs.Rules() {
+		issues = append(issues, checkRuleExamples(r)...)
+	}
+	return issues
+}
+
+// checkRuleExamples returns the issues of r's examples. A rule whose
+// pattern does not compile yields a single issue.
+func checkRuleExamples(r *Rule) []ExampleIssue {
+	if len(r.Examples.Match) == 0 && len(r.Examples.NoMatch) == 0 {
+		return nil
+	}
+	if r.MatcherType == "regex" {
+		if _, err := CompilePattern(r.Pattern); err != nil {
+			return []ExampleIssue{{RuleID: r.ID, Err: err}}
+		}
+	}
+	var issues []ExampleIssue
+	check := func(examples []string, want bool) {
+		for _, ex := range examples {
+			got, err := MatchesExample(r, ex)
+			if err != nil || got != want {
+				issues = append(issues, ExampleIssue{RuleID: r.ID, Example: ex, WantMatch: want, Err: err})
+			}
+		}
+	}
+	check(r.Examples.Match, true)
+	check(r.Examples.NoMatch, false)
+	return issues
+}
+
+// WithoutExamples returns the rules in rs that have a pattern but no
+// positive example. Rules without a pattern are implemented by their
+// analyzer and are skipped.
+func WithoutExamples(rs *RuleSet) []*Rule {
+	var out []*Rule
+	for _, r := range rs.Rules() {
+		if r.Pattern != "" && len(r.Examples.Match) == 0 {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,227 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+// Keywords gate a rule on the raw bytes of a file: the rule's pattern only
+// runs if the content contains one of them, compared ASCII
+// case-insensitively. Only A-Z and a-z are folded. Other bytes, including
+// every byte of a non-ASCII character, must match exactly, so the gate
+// never lets through content that the pattern could not see either: a
+// Unicode lookalike such as the Kelvin sign does not stand in for "k".
+// ContextKeywords gate the same way but name the surroundings of a match
+// rather than its content.
+
+// foldASCII returns a copy of b with A-Z lowercased and every other byte
+// unchanged. Unlike bytes.ToLower it never changes the length of the
+// content, so offsets in the copy are offsets in b.
+func foldASCII(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// containsAnyKeyword reports whether content, already folded with
+// foldASCII, contains one of keywords, compared ASCII case-insensitively.
+func containsAnyKeyword(folded []byte, keywords []string) bool {
+	for _, kw := range keywords {
+		if bytes.Contains(folded, foldASCII([]byte(kw))) {
+			return true
+		}
+	}
+	return false
+}
+
+// passesKeywords reports whether folded content, as returned by foldASCII,
+// contains one of the rule's Keywords and one of its ContextKeywords. An
+// empty list passes.
+func passesKeywords(rule *Rule, folded []byte) bool {
+	if len(rule.Keywords) > 0 && !containsAnyKeyword(folded, rule.Keywords) {
+		return false
+	}
+	return len(rule.ContextKeywords) == 0 || containsAnyKeyword(folded, rule.ContextKeywords)
+}
+
+// UnmatchableKeywords returns the keywords of a regex rule that no match of
+// its pattern can contain, compared ASCII case-insensitively as the
+// keyword gate does. Such a keyword is usually a typo: the gate lets files
+// through on a string the pattern can never report. The analysis treats
+// anchors and word boundaries as always satisfied, so it only reports
+// keywords that are impossible. It returns nil for other matcher types and
+// for patterns that do not compile.
+func UnmatchableKeywords(r *Rule) []string {
+	if r.MatcherType != "regex" || r.Pattern == "" || len(r.Keywords) == 0 {
+		return nil
+	}
+	re, err := syntax.Parse(r.Pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, kw := range r.Keywords {
+		if kw != "" && !canMatchContaining(prog, []rune(string(foldASCII([]byte(kw))))) {
+			out = append(out, kw)
+		}
+	}
+	return out
+}
+
+// canMatchContaining reports whether prog matches some string that
+// contains kw, an ASCII-folded keyword, ASCII case-insensitively. It
+// explores the product of the program with a KMP automaton for kw: a
+// state is an instruction and the length of the keyword prefix matched so
+// far, and the search succeeds at a match instruction reached after the
+// whole keyword.
+func canMatchContaining(prog *syntax.Prog, kw []rune) bool {
+	m := len(kw)
+	fail := kmpFailure(kw)
+	next := func(j int, c rune) int {
+		if j == m {
+			return m
+		}
+		for j > 0 && kw[j] != c {
+			j = fail[j-1]
+		}
+		if kw[j] == c {
+			j++
+		}
+		return j
+	}
+
+	type state struct {
+		pc uint32
+		j  int
+	}
+	seen := make(map[state]bool)
+	stack := []state{{uint32(prog.Start), 0}}
+	push := func(pc uint32, j int) {
+		if s := (state{pc, j}); !seen[s] {
+			seen[s] = true
+			stack = append(stack, s)
+		}
+	}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		inst := &prog.Inst[s.pc]
+		switch inst.Op {
+		case syntax.InstMatch:
+			if s.j == m {
+				return true
+			}
+		case syntax.InstAlt, syntax.InstAltMatch:
+			push(inst.Out, s.j)
+			push(inst.Arg, s.j)
+		case syntax.InstCapture, syntax.InstNop, syntax.InstEmptyWidth:
+			push(inst.Out, s.j)
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			if s.j == m {
+				push(inst.Out, m)
+				continue
+			}
+			for _, c := range kw {
+				if matchesFolded(inst, c) {
+					push(inst.Out, next(s.j, c))
+				}
+			}
+			if matchesOther(inst, kw) {
+				push(inst.Out, 0)
+			}
+		}
+	}
+	return false
+}
+
+// matchesFolded reports whether inst matches c or, for a lowercase ASCII
+// letter, its uppercase form.
+func matchesFolded(inst *syntax.Inst, c rune) bool {
+	if inst.MatchRune(c) {
+		return true
+	}
+	return 'a' <= c && c <= 'z' && inst.MatchRune(c-('a'-'A'))
+}
+
+// matchesOther reports whether inst may match a rune that is not in kw in
+// either ASCII case. It errs towards true, which only widens the search.
+func matchesOther(inst *syntax.Inst, kw []rune) bool {
+	switch inst.Op {
+	case syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+		return true
+	}
+	if syntax.Flags(inst.Arg)&syntax.FoldCase != 0 {
+		return true
+	}
+	in := func(r rune) bool {
+		for _, c := range kw {
+			if r == c || ('a' <= c && c <= 'z' && r == c-('a'-'A')) {
+				return true
+			}
+		}
+		return false
+	}
+	ranges := inst.Rune
+	if inst.Op == syntax.InstRune1 || len(ranges) == 1 {
+		ranges = []rune{ranges[0], ranges[0]}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if int(hi-lo+1) > 2*len(kw) {
+			return true
+		}
+		for r := lo; r <= hi; r++ {
+			if !in(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kmpFailure returns the KMP failure function of kw: the length of the
+// longest proper prefix of kw[:i+1] that is also its suffix.
+func kmpFailure(kw []rune) []int {
+	fail := make([]int, len(kw))
+	for i, k := 1, 0; i < len(kw); i++ {
+		for k > 0 && kw[i] != kw[k] {
+			k = fail[k-1]
+		}
+		if kw[i] == kw[k] {
+			k++
+		}
+		fail[i] = k
+	}
+	return fail
+}
+
+// CheckKeywords returns an issue for each regex rule in rs none of whose
+// keywords can appear in a match of its pattern. The gate of such a rule
+// only passes files by coincidence; words that describe the context of a
+// match belong in ContextKeywords. A rule may mix the two kinds in Keywords
+// as long as one keyword can be matched.
+func CheckKeywords(rs *RuleSet) []ExampleIssue {
+	var issues []ExampleIssue
+	for _, r := range rs.Rules() {
+		dead := UnmatchableKeywords(r)
+		if len(dead) == 0 || len(dead) < len(r.Keywords) {
+			continue
+		}
+		issues = append(issues, ExampleIssue{
+			RuleID: r.ID,
+			Err:    fmt.Errorf("no keyword (%s) can appear in a match of the pattern; move context words to context_keywords", strings.Join(dead, ", ")),
+		})
+	}
+	return issues
+}
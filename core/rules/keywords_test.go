@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFoldASCII(t *testing.T) {
+	// U+212A KELVIN SIGN lowercases to "k" under Unicode rules; the gate
+	// must not fold it, or the offsets of every later byte would shift.
+	in := []byte("GHP_Token \u212Aey AIza É")
+	got := foldASCII(in)
+	if want := "ghp_token \u212Aey aiza É"; string(got) != want {
+		t.Errorf("foldASCII = %q, want %q", got, want)
+	}
+	if len(got) != len(in) {
+		t.Errorf("foldASCII changed the length from %d to %d", len(in), len(got))
+	}
+}
+
+func TestEngine_ScanFile_KeywordMixedCase(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{
+		ID: "KW-GH", Severity: "high", Confidence: "high", MatcherType: "regex",
+		Pattern: `(?i)ghp_[a-z0-9]{36}`, Keywords: []string{"ghp_"},
+	})
+	rs.Add(&Rule{
+		ID: "KW-GOOG", Severity: "high", Confidence: "high", MatcherType: "regex",
+		Pattern: `AIza[0-9A-Za-z\-_]{35}`, Keywords: []string{"aiza"},
+	})
+	engine := NewEngine(rs)
+	token := strings.Repeat("a1", 18)
+
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"t = GHP_" + token + "\n", []string{"KW-GH"}},
+		{"t = Ghp_" + token + "\n", []string{"KW-GH"}},
+		{"k = AIza" + token[:35] + "\n", []string{"KW-GOOG"}},
+		// The keyword gate passes, but the pattern stays case-sensitive.
+		{"k = Aiza" + token[:35] + "\n", nil},
+		// A lookalike is not folded, so neither the gate nor the pattern
+		// sees a token.
+		{"t = \u212Aghp" + "\n", nil},
+	}
+	for _, tt := range tests {
+		results, err := engine.ScanFile("test.txt", []byte(tt.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range results {
+			got = append(got, f.RuleID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: rules %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestKeywordsPresent_ContextKeywords(t *testing.T) {
+	rule := &Rule{Keywords: []string{"sk_"}, ContextKeywords: []string{"Acme"}}
+	if !KeywordsPresent(rule, []byte("ACME_KEY = sk_123")) {
+		t.Error("expected both keyword lists to match")
+	}
+	if KeywordsPresent(rule, []byte("KEY = sk_123")) {
+		t.Error("expected a missing context keyword to fail the gate")
+	}
+	if KeywordsPresent(rule, []byte("acme = 123")) {
+		t.Error("expected a missing keyword to fail the gate")
+	}
+	if !KeywordsPresent(&Rule{ContextKeywords: []string{"acme"}}, []byte("Acme 123")) {
+		t.Error("expected context keywords alone to gate the rule")
+	}
+}
+
+func TestUnmatchableKeywords(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		keywords []string
+		want     []string
+	}{
+		{`gh[pso]_[A-Za-z0-9]{36}`, []string{"ghp_", "GHS_", "ghx_"}, []string{"ghx_"}},
+		// With "_" in the class, "ghx_" can appear inside the token.
+		{`gh[pso]_[A-Za-z0-9_]{36}`, []string{"ghx_"}, nil},
+		{`AIza[0-9A-Za-z\-_]{35}`, []string{"aiza"}, nil},
+		// A keyword may span a literal and a repeated class.
+		{`sk-[A-Za-z0-9]{20}T3BlbkFJ`, []string{"sk-a", "t3blbkfj"}, nil},
+		{`\b(?:live|test)_[a-f0-9]{32,}`, []string{"live_", "lob"}, []string{"lob"}},
+		{`(?i)acme[_-]?key\s*=\s*\w+`, []string{"ACME_KEY", "acme"}, nil},
+		{`[a-f0-9]{40}`, []string{"sourcegraph", "cafe"}, []string{"sourcegraph"}},
+		{`x.y`, []string{"x\ny", "XaY"}, []string{"x\ny"}},
+	}
+	for _, tt := range tests {
+		r := &Rule{ID: "T", MatcherType: "regex", Pattern: tt.pattern, Keywords: tt.keywords}
+		if got := UnmatchableKeywords(r); !slices.Equal(got, tt.want) {
+			t.Errorf("UnmatchableKeywords(%q, %q) = %q, want %q", tt.pattern, tt.keywords, got, tt.want)
+		}
+	}
+	if got := UnmatchableKeywords(&Rule{MatcherType: "entropy", Keywords: []string{"x"}}); got != nil {
+		t.Errorf("entropy rule: %q", got)
+	}
+}
+
+func TestCheckKeywords(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{ID: "KW-1", MatcherType: "regex", Pattern: `ghp_[A-Za-z0-9]+`, Keywords: []string{"github_token"}})
+	rs.Add(&Rule{ID: "KW-2", MatcherType: "regex", Pattern: `ghp_[A-Za-z0-9]+`, Keywords: []string{"github", "ghp_"}})
+	rs.Add(&Rule{ID: "KW-3", MatcherType: "regex", Pattern: `ghp_[A-Za-z0-9]+`, ContextKeywords: []string{"github_token"}})
+
+	issues := CheckKeywords(rs)
+	if len(issues) != 1 || issues[0].RuleID != "KW-1" || !strings.Contains(issues[0].String(), `github_token`) {
+		t.Errorf("issues = %v", issues)
+	}
+}
@@ -0,0 +1,41 @@
+package rules
+
+import "bytes"
+
+// NormalizeLineEndings returns content with every CRLF line ending replaced
+// by LF, or content itself when it has none. Patterns are matched against
+// the normalized content so that `$` anchors and character classes such as
+// \s or [^"]* do not pick up the CR of Windows line endings.
+//
+// A CR is only removed where it precedes the LF that ends its line, so every
+// line keeps its number and every byte before the line ending keeps its
+// column: a line and column computed on the normalized content locate the
+// same byte in the original file.
+func NormalizeLineEndings(content []byte) []byte {
+	if !bytes.Contains(content, []byte("\r\n")) {
+		return content
+	}
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// Offset returns the byte offset in content of the 1-based line and column
+// of a finding, or -1 if content has no such position. content may be the
+// original file, CRLF endings and all, so that a redaction can rewrite the
+// file in place.
+func Offset(content []byte, line, col int) int {
+	if line < 1 || col < 1 {
+		return -1
+	}
+	start := 0
+	for ; line > 1; line-- {
+		i := bytes.IndexByte(content[start:], '\n')
+		if i < 0 {
+			return -1
+		}
+		start += i + 1
+	}
+	if start+col-1 >= len(content) {
+		return -1
+	}
+	return start + col - 1
+}
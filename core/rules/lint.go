@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// DefaultLintBudget is the time a single pattern may spend matching the
+// adversarial lint inputs before it is reported as slow.
+const DefaultLintBudget = time.Second
+
+// LintIssue describes a regex rule whose pattern failed to compile or
+// exceeded the lint budget.
+type LintIssue struct {
+	RuleID   string
+	Duration time.Duration
+	Err      error
+}
+
+// String returns a one-line description of the issue.
+func (i LintIssue) String() string {
+	if i.Err != nil {
+		return fmt.Sprintf("%s: %v", i.RuleID, i.Err)
+	}
+	return fmt.Sprintf("%s: pattern took %s on adversarial input", i.RuleID, i.Duration.Round(time.Millisecond))
+}
+
+// adversarialInputs returns single-line inputs of DefaultMaxLineLength bytes
+// that exercise the worst case of patterns with heavy alternation and
+// unbounded repetition: long runs of token characters, repeated keyword
+// prefixes that start many candidate matches, and whitespace padding.
+func adversarialInputs() [][]byte {
+	n := DefaultMaxLineLength
+	fill := func(unit string) []byte {
+		return bytes.Repeat([]byte(unit), n/len(unit)+1)[:n]
+	}
+	return [][]byte{
+		fill("a"),
+		fill("aB3+/"),
+		fill("0123456789abcdef"),
+		fill(" \t"),
+		fill("curl -H 'Authorization: Bearer "),
+		fill("kind: secret data: key: "),
+		fill("ZXlKaGJHY2lPaU"),
+		fill("api_key=token="),
+	}
+}
+
+// LintPattern compiles pattern and measures how long it takes to match the
+// adversarial inputs. The returned duration covers matching only.
+func LintPattern(pattern string) (time.Duration, error) {
+	re, err := CompilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	for _, in := range adversarialInputs() {
+		re.FindAllIndex(in, -1)
+	}
+	return time.Since(start), nil
+}
+
+// LintRules lints every regex rule in rs, including block end patterns, and
+// returns an issue for each rule whose patterns fail to compile or take
+// longer than budget.
+func LintRules(rs *RuleSet, budget time.Duration) []LintIssue {
+	var issues []LintIssue
+	for _, r := range rs.Rules() {
+		if r.MatcherType != "regex" {
+			continue
+		}
+		d, err := LintPattern(r.Pattern)
+		if err == nil && r.Block != nil && r.Block.End != "" {
+			var de time.Duration
+			de, err = LintPattern(r.Block.End)
+			d += de
+		}
+		if err != nil || d > budget {
+			issues = append(issues, LintIssue{RuleID: r.ID, Duration: d, Err: err})
+		}
+	}
+	return issues
+}
+
+// CheckPatterns returns an issue for each regex rule in rs whose pattern or
+// block end pattern does not compile. Unlike LintRules it does not time the
+// patterns.
+func CheckPatterns(rs *RuleSet) []LintIssue {
+	var issues []LintIssue
+	for _, r := range rs.Rules() {
+		if r.MatcherType != "regex" {
+			continue
+		}
+		_, err := CompilePattern(r.Pattern)
+		if err == nil && r.Block != nil && r.Block.End != "" {
+			_, err = CompilePattern(r.Block.End)
+		}
+		if err != nil {
+			issues = append(issues, LintIssue{RuleID: r.ID, Err: err})
+		}
+	}
+	return issues
+}
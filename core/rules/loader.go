@@ -10,9 +10,12 @@ import (
 )
 
 // ruleFile is the top-level structure of a YAML rules file. It expects a
-// single key "rules" containing an array of rule definitions.
+// key "rules" containing an array of rule definitions and, optionally, a
+// key "categories" declaring the categories of its rules that are not
+// built in.
 type ruleFile struct {
-	Rules []Rule `yaml:"rules"`
+	Categories []CategoryDecl `yaml:"categories"`
+	Rules      []Rule         `yaml:"rules"`
 }
 
 // validSeverities is the set of recognised severity values.
@@ -26,26 +29,64 @@ var validSeverities = map[string]bool{
 
 // LoadRulesFromFile reads a single YAML file and returns a validated RuleSet.
 func LoadRulesFromFile(path string) (*RuleSet, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("reading rules file %s: %w", path, err)
-	}
+	return LoadRulesFromFiles([]string{path})
+}
 
-	var rf ruleFile
-	if err := yaml.Unmarshal(data, &rf); err != nil {
-		return nil, fmt.Errorf("parsing rules file %s: %w", path, err)
+// LoadRulesFromFiles reads the YAML files at paths, in order, and merges
+// them into a single validated RuleSet. A rule may be in a category that
+// any of the files declares.
+func LoadRulesFromFiles(paths []string) (*RuleSet, error) {
+	files := make([]ruleFile, len(paths))
+	registry := NewCategoryRegistry()
+	rs := NewRuleSet()
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading rules file %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(data, &files[i]); err != nil {
+			return nil, fmt.Errorf("parsing rules file %s: %w", path, err)
+		}
+		for _, d := range files[i].Categories {
+			info := d.Info()
+			if err := registry.Register(info); err != nil {
+				return nil, fmt.Errorf("categories in %s: %w", path, err)
+			}
+			rs.DeclareCategory(info)
+		}
 	}
 
-	rs := NewRuleSet()
-	for i := range rf.Rules {
-		if err := validateRule(&rf.Rules[i]); err != nil {
-			return nil, fmt.Errorf("rule %d in %s: %w", i, path, err)
+	for i, path := range paths {
+		for j := range files[i].Rules {
+			r := &files[i].Rules[j]
+			if r.Category == "" {
+				r.Category = categoryFromTags(r.Tags)
+			}
+			if err := validateRule(r, registry); err != nil {
+				return nil, fmt.Errorf("rule %d in %s: %w", j, path, err)
+			}
+			if devMode {
+				if err := validateExamples(r); err != nil {
+					return nil, fmt.Errorf("rule %d in %s: %w", j, path, err)
+				}
+			}
+			rs.Add(r)
 		}
-		rs.Add(&rf.Rules[i])
 	}
 	return rs, nil
 }
 
+// categoryFromTags returns the first tag naming a category, for rule files
+// written before the category key existed, or CategoryCustom.
+func categoryFromTags(tags []string) string {
+	for _, tag := range tags {
+		if c := strings.ToLower(tag); ValidCategory(c) {
+			return c
+		}
+	}
+	return CategoryCustom
+}
+
 // LoadRulesFromDir reads all .yaml and .yml files in the given directory and
 // merges them into a single RuleSet. Files are processed in lexicographic
 // order for determinism.
@@ -55,7 +96,7 @@ func LoadRulesFromDir(dir string) (*RuleSet, error) {
 		return nil, fmt.Errorf("reading rules directory %s: %w", dir, err)
 	}
 
-	rs := NewRuleSet()
+	var paths []string
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -64,19 +105,14 @@ func LoadRulesFromDir(dir string) (*RuleSet, error) {
 		if ext != ".yaml" && ext != ".yml" {
 			continue
 		}
-		fileRS, err := LoadRulesFromFile(filepath.Join(dir, entry.Name()))
-		if err != nil {
-			return nil, err
-		}
-		for _, r := range fileRS.Rules() {
-			rs.Add(r)
-		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
 	}
-	return rs, nil
+	return LoadRulesFromFiles(paths)
 }
 
-// validateRule checks that a rule satisfies all mandatory constraints.
-func validateRule(r *Rule) error {
+// validateRule checks that a rule satisfies all mandatory constraints. Its
+// category must be in categories.
+func validateRule(r *Rule, categories *CategoryRegistry) error {
 	if r.ID == "" {
 		return fmt.Errorf("rule ID must not be empty")
 	}
@@ -86,5 +122,35 @@ func validateRule(r *Rule) error {
 	if !validSeverities[string(r.Severity)] {
 		return fmt.Errorf("invalid severity %q for rule %s", r.Severity, r.ID)
 	}
+	if !categories.Valid(r.Category) {
+		return fmt.Errorf("invalid category %q for rule %s (want one of %s, or declare it under categories)", r.Category, r.ID, categories)
+	}
+	if b := r.Block; b != nil {
+		switch {
+		case r.MatcherType != "regex":
+			return fmt.Errorf("block requires matcher_type regex for rule %s", r.ID)
+		case b.MaxLines < 0:
+			return fmt.Errorf("invalid block max_lines %d for rule %s", b.MaxLines, r.ID)
+		case b.End == "" && len(r.Keywords) == 0:
+			return fmt.Errorf("block without end requires keywords for rule %s", r.ID)
+		}
+		if b.End != "" {
+			if _, err := CompilePattern(b.End); err != nil {
+				return fmt.Errorf("invalid block end for rule %s: %w", r.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateExamples checks that a rule has a positive example and matches
+// its examples as declared. It runs on load in noxdev builds only.
+func validateExamples(r *Rule) error {
+	if len(r.Examples.Match) == 0 {
+		return fmt.Errorf("rule %s has no examples.match", r.ID)
+	}
+	if issues := checkRuleExamples(r); len(issues) > 0 {
+		return fmt.Errorf("examples: %s", issues[0])
+	}
 	return nil
 }
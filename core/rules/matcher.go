@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"regexp"
 	"sync"
+	"sync/atomic"
 )
 
 // MatchResult describes a single match of a rule pattern within file content.
+// EndLine and EndColumn are set for matches that span several lines; when
+// EndLine is zero the match ends on Line, len(MatchText) bytes after Column.
 type MatchResult struct {
 	Line      int
 	Column    int
+	EndLine   int
+	EndColumn int
 	MatchText string
 }
 
@@ -21,35 +26,71 @@ type Matcher interface {
 	Match(content []byte, rule *Rule) []MatchResult
 }
 
-// RegexMatcher implements Matcher using compiled regular expressions. It
-// caches compiled patterns to avoid redundant compilation across calls.
-type RegexMatcher struct {
-	mu    sync.Mutex
-	cache map[string]*regexp.Regexp
+// compiledPatterns is a process-wide cache of compiled regular expressions
+// keyed by pattern source. It is shared by every RegexMatcher so each pattern
+// is compiled once per process, no matter how many engines or scans are
+// created (watch mode and the MCP server create a fresh engine per scan).
+var compiledPatterns sync.Map // map[string]*regexp.Regexp
+
+// patternCacheHits and patternCacheMisses count CompilePattern lookups that
+// found a compiled pattern and those that had to compile one.
+var patternCacheHits, patternCacheMisses atomic.Uint64
+
+// CacheStats counts the lookups of the compiled pattern cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
 }
 
-// NewRegexMatcher returns a RegexMatcher with an initialised pattern cache.
-func NewRegexMatcher() *RegexMatcher {
-	return &RegexMatcher{
-		cache: make(map[string]*regexp.Regexp),
-	}
+// PatternCacheStats returns the lookups of the compiled pattern cache since
+// the process started.
+func PatternCacheStats() CacheStats {
+	return CacheStats{Hits: patternCacheHits.Load(), Misses: patternCacheMisses.Load()}
 }
 
-// compile returns a compiled regexp for the given pattern, using the cache
-// when possible.
-func (m *RegexMatcher) compile(pattern string) (*regexp.Regexp, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// Sub returns the lookups counted in s but not in prev, an earlier reading.
+func (s CacheStats) Sub(prev CacheStats) CacheStats {
+	return CacheStats{Hits: s.Hits - prev.Hits, Misses: s.Misses - prev.Misses}
+}
+
+// HitRatio returns the fraction of lookups that were hits, or 0 when there
+// were none.
+func (s CacheStats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
 
-	if re, ok := m.cache[pattern]; ok {
-		return re, nil
+// CompilePattern returns the compiled form of pattern, compiling and caching
+// it on first use. Patterns use RE2 syntax, which guarantees matching time
+// linear in the input size.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := compiledPatterns.Load(pattern); ok {
+		patternCacheHits.Add(1)
+		return re.(*regexp.Regexp), nil
 	}
+	patternCacheMisses.Add(1)
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("compiling pattern %q: %w", pattern, err)
 	}
-	m.cache[pattern] = re
-	return re, nil
+	actual, _ := compiledPatterns.LoadOrStore(pattern, re)
+	return actual.(*regexp.Regexp), nil
+}
+
+// RegexMatcher implements Matcher using compiled regular expressions. Patterns
+// are looked up in the process-wide cache populated by CompilePattern.
+type RegexMatcher struct{}
+
+// NewRegexMatcher returns a RegexMatcher.
+func NewRegexMatcher() *RegexMatcher {
+	return &RegexMatcher{}
+}
+
+// compile returns a compiled regexp for the given pattern.
+func (m *RegexMatcher) compile(pattern string) (*regexp.Regexp, error) {
+	return CompilePattern(pattern)
 }
 
 // Match finds all occurrences of the rule pattern in content and returns
@@ -69,6 +110,10 @@ func (m *RegexMatcher) Match(content []byte, rule *Rule) []MatchResult {
 		offset += len(line)
 	}
 
+	if rule.Block != nil {
+		return matchBlock(content, rule, re, lineStarts)
+	}
+
 	matches := re.FindAllIndex(content, -1)
 	results := make([]MatchResult, 0, len(matches))
 
@@ -0,0 +1,48 @@
+package rules
+
+// renamedRules maps the IDs of built-in rules that were removed when they
+// were merged into another rule to the ID of that rule. Baselines,
+// nox:ignore directives and scan.rules settings that name a removed ID
+// apply to its replacement.
+var renamedRules = map[string]string{
+	// Telegram bot tokens.
+	"SEC-341": "SEC-028",
+	// Stripe and Clerk secret keys.
+	"SEC-103": "SEC-030",
+	"SEC-338": "SEC-030",
+	"SEC-438": "SEC-030",
+	"SEC-547": "SEC-030",
+	"SEC-548": "SEC-030",
+	"SEC-549": "SEC-030",
+	// Lob API keys.
+	"SEC-262": "SEC-070",
+	// JWTs.
+	"SEC-251": "SEC-084",
+	"SEC-371": "SEC-084",
+}
+
+// RenamedTo returns the ID of the rule that replaced the removed built-in
+// rule id, and whether id names such a rule.
+func RenamedTo(id string) (string, bool) {
+	to, ok := renamedRules[id]
+	return to, ok
+}
+
+// CanonicalID returns the ID findings of rule id are reported under: the
+// replacement of a removed rule, or id itself.
+func CanonicalID(id string) string {
+	if to, ok := renamedRules[id]; ok {
+		return to
+	}
+	return id
+}
+
+// RenamedRules returns a copy of the map from removed rule IDs to the IDs
+// of the rules that replaced them.
+func RenamedRules() map[string]string {
+	out := make(map[string]string, len(renamedRules))
+	for from, to := range renamedRules {
+		out[from] = to
+	}
+	return out
+}
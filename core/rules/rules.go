@@ -5,6 +5,8 @@
 package rules
 
 import (
+	"slices"
+
 	"github.com/nox-hq/nox/core/findings"
 )
 
@@ -18,9 +20,34 @@ var ValidMatcherTypes = map[string]bool{
 	"entropy":   true,
 }
 
+// Rule categories. Every built-in rule belongs to exactly one; custom rules
+// default to CategoryCustom.
+const (
+	CategorySecrets   = "secrets"
+	CategoryData      = "data"
+	CategoryAI        = "ai"
+	CategoryIaC       = "iac"
+	CategoryDeps      = "deps"
+	CategoryContainer = "container"
+	CategoryAudit     = "audit"
+	CategoryCustom    = "custom"
+)
+
+// Categories lists the valid rule categories.
+var Categories = []string{
+	CategorySecrets, CategoryData, CategoryAI, CategoryIaC,
+	CategoryDeps, CategoryContainer, CategoryAudit, CategoryCustom,
+}
+
+// ValidCategory reports whether c is one of Categories.
+func ValidCategory(c string) bool {
+	return slices.Contains(Categories, c)
+}
+
 // Rule is a single declarative security rule loaded from YAML. It describes
 // what to look for (Pattern + MatcherType), where to look (FilePatterns), and
-// how to classify the result (Severity, Confidence).
+// how to classify the result (Severity, Confidence, Category). Regex rules
+// with a Block match multi-line spans.
 type Rule struct {
 	ID           string              `yaml:"id"`
 	Version      string              `yaml:"version"`
@@ -31,10 +58,20 @@ type Rule struct {
 	Pattern      string              `yaml:"pattern"`
 	FilePatterns []string            `yaml:"file_patterns"`
 	Keywords     []string            `yaml:"keywords"`
-	Tags         []string            `yaml:"tags"`
-	Metadata     map[string]string   `yaml:"metadata"`
-	Remediation  string              `yaml:"remediation"`
-	References   []string            `yaml:"references"`
+	// ContextKeywords are words the file must also mention for the rule
+	// to run, such as a provider name near a token with no vendor prefix.
+	// Unlike Keywords they need not appear in a match; see
+	// UnmatchableKeywords.
+	ContextKeywords []string          `yaml:"context_keywords"`
+	Block           *Block            `yaml:"block"`
+	Category        string            `yaml:"category"`
+	Tags            []string          `yaml:"tags"`
+	Metadata        map[string]string `yaml:"metadata"`
+	Remediation     string            `yaml:"remediation"`
+	References      []string          `yaml:"references"`
+	// Examples are inputs the rule must and must not match; see
+	// CheckExamples.
+	Examples Examples `yaml:"examples"`
 }
 
 // RuleSet is an ordered collection of rules with fast lookup by ID and tag.
@@ -42,6 +79,8 @@ type RuleSet struct {
 	rules []*Rule
 	byID  map[string]int
 	byTag map[string][]int
+	// categories are the categories the rules files of the set declare.
+	categories []CategoryInfo
 }
 
 // NewRuleSet returns an initialised, empty RuleSet.
@@ -62,6 +101,19 @@ func (rs *RuleSet) Add(r *Rule) {
 	}
 }
 
+// DeclareCategory records that the set declares the category info, for
+// the rules files that add categories to the built-in ones.
+func (rs *RuleSet) DeclareCategory(info CategoryInfo) {
+	if !slices.Contains(rs.categories, info) {
+		rs.categories = append(rs.categories, info)
+	}
+}
+
+// DeclaredCategories returns the categories declared by the set.
+func (rs *RuleSet) DeclaredCategories() []CategoryInfo {
+	return rs.categories
+}
+
 // Rules returns all rules in insertion order.
 func (rs *RuleSet) Rules() []*Rule {
 	return rs.rules
@@ -83,6 +135,19 @@ func (rs *RuleSet) HasID(id string) bool {
 	return ok
 }
 
+// AppliesToAny reports whether the FilePatterns of at least one rule in the
+// set match one of paths.
+func (rs *RuleSet) AppliesToAny(paths []string) bool {
+	for _, r := range rs.rules {
+		for _, p := range paths {
+			if fileMatchesRule(p, r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ByTag returns all rules that carry the given tag. If no rules match, an
 // empty slice is returned.
 func (rs *RuleSet) ByTag(tag string) []*Rule {
@@ -1,9 +1,16 @@
 package rules
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nox-hq/nox/core/findings"
 )
@@ -198,6 +205,99 @@ func TestLoadRulesFromFile_InvalidSeverity(t *testing.T) {
 	}
 }
 
+func TestLoadRulesFromFile_Category(t *testing.T) {
+	yaml := `rules:
+  - id: "CAT-001"
+    matcher_type: "regex"
+    severity: "high"
+    pattern: "a"
+    category: "iac"
+  - id: "CAT-002"
+    matcher_type: "regex"
+    severity: "high"
+    pattern: "b"
+    tags: ["team", "Secrets"]
+  - id: "CAT-003"
+    matcher_type: "regex"
+    severity: "high"
+    pattern: "c"
+`
+	dir := t.TempDir()
+	rs, err := LoadRulesFromFile(writeTemp(t, dir, "rules.yaml", yaml))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for id, want := range map[string]string{"CAT-001": CategoryIaC, "CAT-002": CategorySecrets, "CAT-003": CategoryCustom} {
+		if r, _ := rs.ByID(id); r.Category != want {
+			t.Errorf("%s category = %q, want %q", id, r.Category, want)
+		}
+	}
+
+	bad := writeTemp(t, dir, "bad.yaml", "rules:\n  - id: BAD-003\n    matcher_type: regex\n    severity: high\n    pattern: x\n    category: network\n")
+	if _, err := LoadRulesFromFile(bad); err == nil {
+		t.Fatal("expected error for invalid category")
+	}
+}
+
+func TestLoadRulesFromDir_DeclaredCategories(t *testing.T) {
+	dir := t.TempDir()
+	// The rule comes before the file declaring its category.
+	writeTemp(t, dir, "a.yaml", "rules:\n  - id: LIC-100\n    matcher_type: regex\n    severity: high\n    pattern: GPL\n    category: license\n")
+	writeTemp(t, dir, "b.yaml", "categories:\n  - name: license\n    label: Licensing\n    badge_weight: 0.5\n  - name: perf\n")
+
+	rs, err := LoadRulesFromDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []CategoryInfo{{Name: "license", Label: "Licensing", BadgeWeight: 0.5}, {Name: "perf", Label: "perf", BadgeWeight: 1}}
+	if got := rs.DeclaredCategories(); !slices.Equal(got, want) {
+		t.Errorf("declared categories = %+v, want %+v", got, want)
+	}
+	if r, _ := rs.ByID("LIC-100"); r.Category != "license" {
+		t.Errorf("LIC-100 category = %q, want license", r.Category)
+	}
+
+	// A file on its own cannot use a category another file declares.
+	if _, err := LoadRulesFromFile(filepath.Join(dir, "a.yaml")); err == nil || !strings.Contains(err.Error(), "declare it under categories") {
+		t.Errorf("expected an invalid category error, got %v", err)
+	}
+
+	for name, yaml := range map[string]string{
+		"builtin.yaml":  "categories:\n  - name: secrets\n",
+		"name.yaml":     "categories:\n  - name: Bad Name\n",
+		"weight.yaml":   "categories:\n  - name: perf\n    badge_weight: -1\n",
+		"conflict.yaml": "categories:\n  - name: perf\n  - name: perf\n    label: Performance\n",
+	} {
+		if _, err := LoadRulesFromFile(writeTemp(t, t.TempDir(), name, yaml)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCategoryRegistry(t *testing.T) {
+	c := NewCategoryRegistry()
+	if err := c.Register(CategoryInfo{Name: "license", Label: "Licensing", BadgeWeight: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register(CategoryInfo{Name: "license", Label: "Licensing", BadgeWeight: 2}); err != nil {
+		t.Errorf("registering the same category twice: %v", err)
+	}
+	if !c.Valid("license") || !c.Valid(CategoryIaC) || c.Valid("network") {
+		t.Errorf("Valid: names %v", c.Names())
+	}
+	if c.Weight("license") != 2 || c.Weight(CategorySecrets) != 1 || c.Weight("network") != 1 {
+		t.Errorf("weights: license %g, secrets %g, network %g", c.Weight("license"), c.Weight(CategorySecrets), c.Weight("network"))
+	}
+	if names := c.Names(); len(names) != len(Categories)+1 || names[len(names)-1] != "license" {
+		t.Errorf("names = %v", names)
+	}
+
+	var builtin *CategoryRegistry
+	if !builtin.Valid(CategoryAudit) || builtin.Valid("license") {
+		t.Errorf("nil registry names %v, want the built-in categories", builtin.Names())
+	}
+}
+
 func TestLoadRulesFromFile_NonexistentFile(t *testing.T) {
 	_, err := LoadRulesFromFile("/nonexistent/path/rules.yaml")
 	if err == nil {
@@ -441,6 +541,15 @@ func TestEngine_ScanFile_FilePatternFiltering(t *testing.T) {
 			t.Fatalf("expected 0 findings for .py file, got %d", len(results))
 		}
 	})
+	t.Run("all rules ignores patterns", func(t *testing.T) {
+		results, err := engine.ScanFileAllRulesContext(context.Background(), "main.go.orig", content)
+		if err != nil {
+			t.Fatalf("scan error: %v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("expected 1 finding when file patterns are ignored, got %d", len(results))
+		}
+	})
 }
 
 func TestEngine_ScanFile_NoFilePatterns_MatchesAll(t *testing.T) {
@@ -764,6 +873,69 @@ func TestEngine_ScanFile_SkipsBinaryContent(t *testing.T) {
 	}
 }
 
+func TestEngine_ScanFile_CRLF(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{
+		ID:          "CRLF-001",
+		Description: "Password assignment",
+		Severity:    "high",
+		Confidence:  "high",
+		MatcherType: "regex",
+		Pattern:     `password = .+`,
+	})
+	engine := NewEngine(rs)
+
+	lf, err := engine.ScanFile("a.txt", []byte("x\n  password = hunter2\n"))
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	crlf, err := engine.ScanFile("a.txt", []byte("x\r\n  password = hunter2\r\n"))
+	if err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	if len(lf) != 1 || len(crlf) != 1 {
+		t.Fatalf("expected 1 finding each, got %d (LF) and %d (CRLF)", len(lf), len(crlf))
+	}
+	if lf[0].Location != crlf[0].Location {
+		t.Errorf("CRLF location %+v differs from LF location %+v", crlf[0].Location, lf[0].Location)
+	}
+	if lf[0].Fingerprint != crlf[0].Fingerprint {
+		t.Error("CRLF and LF content should produce the same fingerprint")
+	}
+	if strings.Contains(crlf[0].Metadata["match"], "\r") || strings.Contains(crlf[0].Message, "\r") {
+		t.Errorf("finding contains a carriage return: %+v", crlf[0])
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	in := []byte("a\r\nb\rc\n")
+	if got := string(NormalizeLineEndings(in)); got != "a\nb\rc\n" {
+		t.Errorf("NormalizeLineEndings = %q", got)
+	}
+	lf := []byte("a\nb\n")
+	if got := NormalizeLineEndings(lf); &got[0] != &lf[0] {
+		t.Error("content without CRLF should be returned as is")
+	}
+}
+
+func TestOffset_CRLF(t *testing.T) {
+	content := []byte("x\r\n  password = hunter2\r\n")
+	rs := NewRuleSet()
+	rs.Add(&Rule{ID: "CRLF-002", Severity: "high", Confidence: "high", MatcherType: "regex", Pattern: `hunter2`})
+	results, err := NewEngine(rs).ScanFile("a.txt", content)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("expected 1 finding, got %d (err %v)", len(results), err)
+	}
+	loc := results[0].Location
+	off := Offset(content, loc.StartLine, loc.StartColumn)
+	if off < 0 || !bytes.HasPrefix(content[off:], []byte("hunter2")) {
+		t.Errorf("Offset(%d, %d) = %d does not locate the match in the original content", loc.StartLine, loc.StartColumn, off)
+	}
+	if got := Offset(content, 5, 1); got != -1 {
+		t.Errorf("Offset past the end = %d, want -1", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // HasID tests (0% → covered)
 // ---------------------------------------------------------------------------
@@ -929,3 +1101,343 @@ func TestEngine_ScanFile_UnknownMatcherType(t *testing.T) {
 		t.Fatal("expected error for unknown matcher type, got nil")
 	}
 }
+
+// slowMatcher reports a match on line 1 after sleeping, simulating a
+// pathological pattern.
+type slowMatcher struct{ delay time.Duration }
+
+func (m *slowMatcher) Match(_ []byte, _ *Rule) []MatchResult {
+	time.Sleep(m.delay)
+	return []MatchResult{{Line: 1, Column: 1, MatchText: "x"}}
+}
+
+func slowEngine(n int, delay time.Duration) *Engine {
+	rs := NewRuleSet()
+	for i := 0; i < n; i++ {
+		rs.Add(&Rule{
+			ID:          fmt.Sprintf("SLOW-%03d", i),
+			Severity:    "low",
+			Confidence:  "low",
+			MatcherType: "slow",
+		})
+	}
+	reg := NewMatcherRegistry()
+	reg.Register("slow", &slowMatcher{delay: delay})
+	return &Engine{rules: rs, matchers: reg}
+}
+
+func TestEngine_ScanFileContext_FileTimeout(t *testing.T) {
+	engine := slowEngine(10, 20*time.Millisecond)
+	engine.SetFileTimeout(30 * time.Millisecond)
+
+	results, err := engine.ScanFileContext(context.Background(), "a.txt", []byte("x"))
+	if !errors.Is(err, ErrFileTimeout) {
+		t.Fatalf("expected ErrFileTimeout, got %v", err)
+	}
+	if len(results) == 0 || len(results) >= 10 {
+		t.Fatalf("expected partial results, got %d", len(results))
+	}
+}
+
+func TestEngine_ScanFileContext_Cancelled(t *testing.T) {
+	engine := slowEngine(3, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := engine.ScanFileContext(ctx, "a.txt", []byte("x"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected no results after cancellation, got %d", len(results))
+	}
+}
+
+func TestCapLineLength(t *testing.T) {
+	content := []byte("short\n" + strings.Repeat("x", 20) + "\ntail")
+	got, notes := capLineLength(content, 10)
+	want := "short\n" + strings.Repeat("x", 10) + "\ntail"
+	if string(got) != want {
+		t.Fatalf("capLineLength = %q, want %q", got, want)
+	}
+	if len(notes) != 1 || notes[0] != (LineNote{Line: 2, Length: 20, Scanned: 10}) {
+		t.Fatalf("notes = %+v", notes)
+	}
+
+	// Content without long lines is returned unchanged.
+	if got, notes := capLineLength([]byte("a\nb\n"), 10); string(got) != "a\nb\n" || notes != nil {
+		t.Fatalf("expected content unchanged, got %q %+v", got, notes)
+	}
+}
+
+func TestSplitLongLines(t *testing.T) {
+	long := strings.Repeat("x", 8)
+	short, lines := splitLongLines([]byte("a\n"+long+"\nb\n"+long), 4)
+	if string(short) != "a\n\nb\n" {
+		t.Errorf("short = %q", short)
+	}
+	if len(lines) != 2 || lines[0].line != 2 || lines[1].line != 4 || string(lines[1].text) != long {
+		t.Errorf("long lines = %+v", lines)
+	}
+	if short, lines := splitLongLines([]byte("a\nb"), 4); string(short) != "a\nb" || lines != nil {
+		t.Errorf("expected content unchanged, got %q %+v", short, lines)
+	}
+}
+
+func TestEngine_ScanFile_LineBudget(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{ID: "LB-001", Severity: "low", Confidence: "low", MatcherType: "regex", Pattern: `SECRET`})
+	long := strings.Repeat("a", longLineLength) + "SECRET"
+	content := []byte("SECRET\n" + long + "\n" + long + "\n")
+
+	// Long lines are matched on their own, at their own line numbers.
+	engine := NewEngine(rs)
+	results, err := engine.ScanFile("a.txt", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	for _, f := range results {
+		lines = append(lines, f.Location.StartLine)
+	}
+	slices.Sort(lines)
+	if fmt.Sprint(lines) != "[1 2 3]" || results[1].Location.StartColumn != longLineLength+1 {
+		t.Fatalf("matches at lines %v: %+v", lines, results)
+	}
+	if notes := engine.LineNotes(); len(notes) != 0 {
+		t.Fatalf("unexpected notes %+v", notes)
+	}
+
+	// A rule over the budget skips the file's later long lines.
+	engine = NewEngine(rs)
+	engine.SetLineBudget(time.Nanosecond)
+	results, err = engine.ScanFile("a.txt", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected matches on lines 1 and 2 only, got %+v", results)
+	}
+	notes := engine.LineNotes()
+	if len(notes) != 1 || notes[0].Path != "a.txt" || notes[0].Line != 2 || notes[0].RuleID != "LB-001" {
+		t.Fatalf("notes = %+v", notes)
+	}
+
+	// Capped lines are noted too.
+	engine = NewEngine(rs)
+	engine.SetMaxLineLength(10)
+	if _, err := engine.ScanFile("b.txt", content); err != nil {
+		t.Fatal(err)
+	}
+	notes = engine.LineNotes()
+	if len(notes) != 2 || notes[0] != (LineNote{Path: "b.txt", Line: 2, Length: len(long), Scanned: 10}) {
+		t.Fatalf("notes = %+v", notes)
+	}
+}
+
+func TestEngine_ScanFile_LongLineCapped(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{
+		ID:          "CAP-001",
+		Severity:    "low",
+		Confidence:  "low",
+		MatcherType: "regex",
+		Pattern:     `SECRET`,
+	})
+	engine := NewEngine(rs)
+	engine.SetMaxLineLength(100)
+
+	content := []byte(strings.Repeat("a", 200) + "SECRET\nSECRET\n")
+	results, err := engine.ScanFile("a.txt", content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Location.StartLine != 2 {
+		t.Fatalf("expected only the line-2 match, got %+v", results)
+	}
+
+	timings := engine.RuleTimings()
+	if len(timings) != 1 || timings[0].RuleID != "CAP-001" || timings[0].Files != 1 || timings[0].Matches != 1 {
+		t.Fatalf("unexpected rule timings: %+v", timings)
+	}
+}
+
+func TestSlowestRules(t *testing.T) {
+	timings := []RuleTiming{
+		{RuleID: "B", Duration: time.Millisecond},
+		{RuleID: "A", Duration: 3 * time.Millisecond},
+		{RuleID: "C", Duration: time.Millisecond},
+	}
+	got := SlowestRules(timings, 2)
+	if len(got) != 2 || got[0].RuleID != "A" || got[1].RuleID != "B" {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{ID: "OK-001", MatcherType: "regex", Pattern: `AKIA[0-9A-Z]{16}`})
+	rs.Add(&Rule{ID: "BAD-001", MatcherType: "regex", Pattern: `(unclosed`})
+	rs.Add(&Rule{ID: "ENT-001", MatcherType: "entropy"})
+
+	issues := LintRules(rs, DefaultLintBudget)
+	if len(issues) != 1 || issues[0].RuleID != "BAD-001" || issues[0].Err == nil {
+		t.Fatalf("expected a single compile issue for BAD-001, got %+v", issues)
+	}
+
+	// A zero budget flags every regex pattern that compiles.
+	issues = LintRules(rs, 0)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues with zero budget, got %+v", issues)
+	}
+
+	issues = CheckPatterns(rs)
+	if len(issues) != 1 || issues[0].RuleID != "BAD-001" || issues[0].Err == nil {
+		t.Fatalf("expected CheckPatterns to report only BAD-001, got %+v", issues)
+	}
+}
+
+func TestMatchDetails_RegexGroups(t *testing.T) {
+	rule := &Rule{
+		ID:          "GRP-001",
+		MatcherType: "regex",
+		Pattern:     `(?P<name>token)=([a-z]+)`,
+	}
+	content := []byte("first line\n  token=abc\n")
+
+	details, err := MatchDetails(rule, content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(details) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(details))
+	}
+	d := details[0]
+	if d.Line != 2 || d.Column != 3 || d.Start != 13 || d.End != 22 {
+		t.Fatalf("unexpected span: %+v", d)
+	}
+	if len(d.Groups) != 2 || d.Groups[0].Name != "name" || d.Groups[1].Text != "abc" {
+		t.Fatalf("unexpected groups: %+v", d.Groups)
+	}
+}
+
+func TestMatchDetails_InvalidRegex(t *testing.T) {
+	rule := &Rule{ID: "BAD-001", MatcherType: "regex", Pattern: `(unclosed`}
+	if _, err := MatchDetails(rule, []byte("x")); err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+}
+
+func TestKeywordsPresent(t *testing.T) {
+	rule := &Rule{Keywords: []string{"AKIA"}}
+	if !KeywordsPresent(rule, []byte("key akia123")) {
+		t.Error("expected keyword match to be case-insensitive")
+	}
+	if KeywordsPresent(rule, []byte("nothing here")) {
+		t.Error("expected no keyword match")
+	}
+	if !KeywordsPresent(&Rule{}, []byte("anything")) {
+		t.Error("rules without keywords should always pass")
+	}
+}
+
+func TestPatternCacheStats(t *testing.T) {
+	before := PatternCacheStats()
+	pattern := `pattern-cache-stats-[0-9]+`
+	for range 3 {
+		if _, err := CompilePattern(pattern); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := PatternCacheStats().Sub(before)
+	if got.Hits < 2 || got.Misses < 1 {
+		t.Errorf("stats = %+v, want at least 2 hits and 1 miss", got)
+	}
+	if r := (CacheStats{Hits: 3, Misses: 1}).HitRatio(); r != 0.75 {
+		t.Errorf("HitRatio = %v, want 0.75", r)
+	}
+	if r := (CacheStats{}).HitRatio(); r != 0 {
+		t.Errorf("HitRatio with no lookups = %v, want 0", r)
+	}
+}
+
+func TestCheckExamples(t *testing.T) {
+	rs := NewRuleSet()
+	rs.Add(&Rule{
+		ID: "EX-001", MatcherType: "regex", Pattern: `\bwise_api_key\s*=\s*([a-z0-9]{8})\b`, Keywords: []string{"wise_api_key"},
+		Examples: Examples{
+			Match:   []string{"wise_api_key = 1a2b3c4d"},
+			NoMatch: []string{"otherwise 1a2b3c4d", "wise_api_key = short"},
+		},
+	})
+	rs.Add(&Rule{
+		ID: "EX-002", MatcherType: "regex", Pattern: `[a-z0-9]{8}`, Keywords: []string{"wise"},
+		Examples: Examples{
+			Match:   []string{"wise 1a2b3c4d", "1a2b3c4d"},
+			NoMatch: []string{"otherwise 1a2b3c4d"},
+		},
+	})
+	rs.Add(&Rule{ID: "EX-003", MatcherType: "regex", Pattern: `(unclosed`, Examples: Examples{Match: []string{"x"}}})
+	rs.Add(&Rule{ID: "EX-004", MatcherType: "regex", Pattern: `x`})
+
+	var got []string
+	for _, issue := range CheckExamples(rs) {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		`EX-002: does not match "1a2b3c4d"`,
+		`EX-002: matches "otherwise 1a2b3c4d"`,
+	}
+	if len(got) != 3 || got[0] != want[0] || got[1] != want[1] || !strings.HasPrefix(got[2], "EX-003: compiling pattern") {
+		t.Errorf("issues = %q", got)
+	}
+
+	var ids []string
+	for _, r := range WithoutExamples(rs) {
+		ids = append(ids, r.ID)
+	}
+	if len(ids) != 1 || ids[0] != "EX-004" {
+		t.Errorf("WithoutExamples = %v, want [EX-004]", ids)
+	}
+}
+
+func TestValidateExamples(t *testing.T) {
+	r := &Rule{ID: "EX-001", MatcherType: "regex", Pattern: `token_[0-9]{4}`}
+	if err := validateExamples(r); err == nil || !strings.Contains(err.Error(), "no examples.match") {
+		t.Errorf("expected an error for a rule without examples, got %v", err)
+	}
+	r.Examples.Match = []string{"token_12"}
+	if err := validateExamples(r); err == nil {
+		t.Error("expected an error for a failing example")
+	}
+	r.Examples.Match = []string{"token_1234"}
+	if err := validateExamples(r); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadRulesFromFile_Examples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	data := `rules:
+  - id: EX-001
+    matcher_type: regex
+    severity: high
+    pattern: 'token_[0-9]{4}'
+    examples:
+      match:
+        - token_1234
+      nomatch:
+        - token_12
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := LoadRulesFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := rs.ByID("EX-001")
+	if len(r.Examples.Match) != 1 || len(r.Examples.NoMatch) != 1 {
+		t.Errorf("examples = %+v", r.Examples)
+	}
+}
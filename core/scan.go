@@ -2,23 +2,36 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/nox-hq/nox/core/analyzers/ai"
 	"github.com/nox-hq/nox/core/analyzers/data"
 	"github.com/nox-hq/nox/core/analyzers/deps"
+	"github.com/nox-hq/nox/core/analyzers/dotenv"
 	"github.com/nox-hq/nox/core/analyzers/iac"
 	"github.com/nox-hq/nox/core/analyzers/secrets"
 	"github.com/nox-hq/nox/core/baseline"
 	"github.com/nox-hq/nox/core/discovery"
 	"github.com/nox-hq/nox/core/findings"
 	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/memguard"
+	"github.com/nox-hq/nox/core/offline"
+	"github.com/nox-hq/nox/core/override"
 	"github.com/nox-hq/nox/core/policy"
+	"github.com/nox-hq/nox/core/report"
+	"github.com/nox-hq/nox/core/rulepack"
 	"github.com/nox-hq/nox/core/rules"
 	"github.com/nox-hq/nox/core/suppress"
+	"github.com/nox-hq/nox/core/trend"
 	"github.com/nox-hq/nox/core/vex"
 )
 
@@ -48,6 +61,92 @@ type ScanResult struct {
 	AIInventory  *ai.Inventory
 	PolicyResult *policy.Result
 	Rules        *rules.RuleSet
+	// Categories holds the finding categories of the scan: the built-in
+	// ones and those declared in the config and by the rules files of the
+	// custom rules and rule packs.
+	Categories *rules.CategoryRegistry
+	// Errors lists files that were skipped because they could not be read.
+	// A non-empty list means some files were not scanned.
+	Errors []discovery.FileError
+	// Truncated lists the files larger than scan.max_file_size, of which
+	// the content analyzers scanned only the start.
+	Truncated []discovery.TruncatedFile
+	// LineNotes lists the lines longer than the line length cap, which
+	// were matched only in part, and those on which a rule exceeded the
+	// per-line matching budget, once each, ordered by file and line.
+	LineNotes []rules.LineNote
+	// Partial is true when the scan was cancelled or timed out before every
+	// analyzer finished.
+	Partial bool
+	// RuleTimings holds the cumulative matching time per rule across all
+	// analyzers, slowest first.
+	RuleTimings []rules.RuleTiming
+	// Provenance records the rule set, config and parameters of the scan.
+	// The tool version and CLI-only parameters such as the severity
+	// threshold are left for the caller to fill in before sealing it.
+	Provenance *report.Provenance
+	// Overrides lists the findings let through by ScanOptions.Overrides
+	// and RejectedOverrides the overrides that were refused.
+	Overrides         []override.Entry
+	RejectedOverrides []override.Rejection
+	// SeverityMapping is the severity_mapping from the config, for reports
+	// that show the custom severity labels.
+	SeverityMapping findings.SeverityMapping
+	// Duration is how long the scan took, and AnalyzerTimings how long
+	// each analyzer pass took, in the order they ran.
+	Duration        time.Duration
+	AnalyzerTimings []AnalyzerTiming
+	// PatternCache counts the compiled pattern cache lookups made during
+	// the scan. The cache is shared by the whole process, so concurrent
+	// scans count each other's lookups.
+	PatternCache rules.CacheStats
+	// SecretFilters counts the secrets matches that the placeholder and
+	// test directory heuristics suppressed, downgraded or lowered.
+	SecretFilters secrets.FilterStats
+	// Suppressions inventories the nox:ignore directives in the scanned
+	// files, ordered by file and line.
+	Suppressions []suppress.Record
+	// Submodules lists the git submodules the scan covered, including
+	// those that are not checked out and so had nothing to scan. It is
+	// empty when scan.submodules is false.
+	Submodules []git.Submodule
+}
+
+// AnalyzerTiming is the time one analyzer pass of a scan took.
+type AnalyzerTiming struct {
+	Analyzer string
+	Duration time.Duration
+	Findings int
+}
+
+// SlowestRulesReported is how many of the slowest rules Performance lists.
+const SlowestRulesReported = 20
+
+// Performance returns the wall time of the scan, of each analyzer pass in
+// the order they ran and of the SlowestRulesReported slowest rules, for the
+// performance block of the JSON report summary.
+func (r *ScanResult) Performance() *report.Performance {
+	p := &report.Performance{
+		DurationMS:   report.Milliseconds(r.Duration),
+		Analyzers:    []report.AnalyzerPerformance{},
+		SlowestRules: []report.RulePerformance{},
+	}
+	for _, t := range r.AnalyzerTimings {
+		p.Analyzers = append(p.Analyzers, report.AnalyzerPerformance{
+			Analyzer:   t.Analyzer,
+			DurationMS: report.Milliseconds(t.Duration),
+			Findings:   t.Findings,
+		})
+	}
+	for _, t := range rules.SlowestRules(slices.Clone(r.RuleTimings), SlowestRulesReported) {
+		p.SlowestRules = append(p.SlowestRules, report.RulePerformance{
+			RuleID:     t.RuleID,
+			DurationMS: report.Milliseconds(t.Duration),
+			Files:      t.Files,
+			Matches:    t.Matches,
+		})
+	}
+	return p
 }
 
 // ScanOptions holds optional parameters for RunScanWithOptions. The zero
@@ -64,6 +163,19 @@ type ScanOptions struct {
 	// calls.
 	DisableOSV bool
 
+	// Offline guarantees that the scan makes no network requests: OSV and
+	// package registry lookups are skipped, and the dependency analyzer's
+	// HTTP client rejects any request it is asked to make.
+	Offline bool
+
+	// VerifyPins looks up the image digests and action commits that
+	// Dockerfiles and workflows pin on their registries and the GitHub
+	// API, reporting pins that do not exist (SUPPLY-004) or do not match
+	// the tag they are annotated with (SUPPLY-005). It is ignored when
+	// Offline is set. GITHUB_TOKEN, if set, authenticates the GitHub API
+	// requests.
+	VerifyPins bool
+
 	// VEXPath is a path to an OpenVEX document. When set, VEX statements
 	// are applied to VULN-001 findings after baseline matching.
 	VEXPath string
@@ -71,6 +183,71 @@ type ScanOptions struct {
 	// TerraformPlanPath is a path to a terraform plan JSON file. When set,
 	// the plan is scanned for security issues in addition to normal scanning.
 	TerraformPlanPath string
+
+	// StrictIO restores fail-fast behavior: the scan aborts on the first
+	// unreadable file or directory instead of recording it in
+	// ScanResult.Errors and continuing.
+	StrictIO bool
+
+	// RulePackDir overrides the directory from which scan.rule_packs are
+	// resolved. Defaults to $NOX_HOME/rulepacks.
+	RulePackDir string
+
+	// Fast trades completeness for latency on scans of a few files. Rule
+	// patterns are compiled only when a file contains one of their
+	// keywords, the data, IaC and AI analyzers are skipped when none of
+	// their rules apply to the scanned paths, and the dependency analyzer
+	// does not run, so the result has no package inventory, OSV findings
+	// or SBOM data.
+	Fast bool
+
+	// OnlyCategories limits the scan to rules in these categories and
+	// SkipCategories leaves out rules in these. When either is set, both
+	// replace scan.categories from .nox.yaml.
+	OnlyCategories []string
+	SkipCategories []string
+
+	// OnlyAnalyzers runs only these analyzers and DisabledAnalyzers leaves
+	// these out; Analyzers lists the names. When either is set, both
+	// replace scan.analyzers from .nox.yaml. The analyzers that did not run
+	// are recorded in the provenance of the result.
+	OnlyAnalyzers     []string
+	DisabledAnalyzers []string
+
+	// Blame attributes secrets findings in git-tracked files to the author
+	// and commit that last changed their lines, as recorded in the
+	// blame_* finding metadata. It is also enabled by secrets.blame in
+	// .nox.yaml.
+	Blame bool
+
+	// Overrides are Nox-Override commit trailers to apply after baseline
+	// and VEX matching. Each lets one finding through; see the override
+	// package for the findings that cannot be overridden.
+	Overrides []override.Override
+
+	// ConfigPath is a config file to use instead of the .nox.yaml in the
+	// scan root, loaded with LoadScanConfigFile. Nested .nox.yaml files in
+	// subdirectories still apply.
+	ConfigPath string
+
+	// Since limits content analysis to the files changed since a git ref,
+	// or within a duration such as "30d", "2w" or "36h", according to git
+	// log; uncommitted and untracked files count as changed. The
+	// dependency analyzer still reads every manifest. The report records
+	// Since in its provenance and is marked as a partial scan.
+	Since string
+
+	// ChangedFilesFrom names the files a change touches: a file listing
+	// their paths, one per line, or a git diff revision spec such as
+	// "origin/main...HEAD". Every finding is then tagged with
+	// InChangedFiles, which policy.scope and CEL expressions can use.
+	// Unlike Since, it does not limit what is scanned.
+	ChangedFilesFrom string
+
+	// trackedFiles are the paths reported as committed to git instead of
+	// those git ls-files lists in the target. Staged scans set them to the
+	// staged paths.
+	trackedFiles []string
 }
 
 // RunScan executes the full scan pipeline against the given target path.
@@ -85,32 +262,143 @@ func RunScan(target string) (*ScanResult, error) {
 // RunScanWithOptions executes the full scan pipeline with the given options.
 // See RunScan for a description of the pipeline stages.
 func RunScanWithOptions(target string, opts ScanOptions) (*ScanResult, error) {
+	return RunScanContext(context.Background(), target, opts)
+}
+
+// RunScanContext executes the full scan pipeline and stops analysis once ctx
+// is done. On cancellation the findings gathered so far still go through
+// suppression, baseline, and policy evaluation, and the resulting ScanResult
+// is returned with Partial set together with an error wrapping ctx.Err(), so
+// callers can persist partial reports before failing.
+func RunScanContext(ctx context.Context, target string, opts ScanOptions) (*ScanResult, error) {
+	scanStart := time.Now()
+	cacheStart := rules.PatternCacheStats()
+	slog.Debug("scan started", "target", target)
+
 	// Load project config.
-	cfg, err := LoadScanConfig(target)
+	cfg, err := LoadRootConfig(target, opts.ConfigPath)
 	if err != nil {
 		return nil, fmt.Errorf("loading config: %w", err)
 	}
 
-	// Phase 1: Discover artifacts.
-	walker := discovery.NewWalker(target)
-	walker.IgnorePatterns = append(walker.IgnorePatterns, cfg.Scan.Exclude...)
-	artifacts, err := walker.Walk()
+	var fileTimeout time.Duration
+	if cfg.Scan.FileTimeout != "" {
+		fileTimeout, err = time.ParseDuration(cfg.Scan.FileTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scan.file_timeout %q: %w", cfg.Scan.FileTimeout, err)
+		}
+	}
+
+	budget, err := memoryBudget(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	// Phase 1b: Filter artifacts by excluded artifact types.
-	var excludeArtifactTypes []string
-	for _, et := range cfg.Scan.ExcludeArtifactTypes {
-		excludeArtifactTypes = append(excludeArtifactTypes, et.ArtifactTypes...)
+	placeholderAction, err := secrets.ParsePlaceholderAction(cfg.Scan.Secrets.PlaceholderAction)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scan.secrets.placeholder_action: %w", err)
+	}
+
+	policyExpr, err := LoadPolicyExpression(target, cfg.Policy)
+	if err != nil {
+		return nil, err
+	}
+	policyScope, err := policy.ParseScope(cfg.Policy.Scope)
+	if err != nil {
+		return nil, fmt.Errorf("policy.scope: %w", err)
+	}
+	sla, err := parseSLA(cfg.SLA)
+	if err != nil {
+		return nil, err
+	}
+	var changedFiles []string
+	if opts.ChangedFilesFrom != "" {
+		if changedFiles, err = changedFilesFrom(target, opts.ChangedFilesFrom); err != nil {
+			return nil, err
+		}
+	} else if policyScope == policy.ScopeChanged {
+		slog.Warn("policy.scope is changed but the scan has no --changed-files-from; every finding counts as changed")
+	}
+
+	// The custom rules and rule packs are loaded ahead of the analyzers,
+	// for the categories they declare.
+	extraSources, err := loadExtraRules(target, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	categoryRegistry, err := scanCategoryRegistry(cfg, extraSources)
+	if err != nil {
+		return nil, err
+	}
+	enabled, disabledAnalyzers, err := scanSelection(cfg, opts, categoryRegistry)
+	if err != nil {
+		return nil, err
+	}
+	runs := func(analyzer string) bool { return !slices.Contains(disabledAnalyzers, analyzer) }
+
+	// Phase 1: Discover artifacts.
+	d, err := discover(target, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	artifacts, contentArtifacts := d.artifacts, d.content
+	fileErrors, configTree, submodules := d.fileErrors, d.configTree, d.submodules
+	for _, fe := range fileErrors {
+		slog.Warn("skipping unreadable file", "path", fe.Path, "error", fe.Message)
+	}
+	for _, tf := range d.truncated {
+		slog.Warn("file truncated for scanning", "path", tf.Path, "size", tf.Size, "scanned", tf.Scanned)
 	}
-	artifacts = filterArtifactsByType(artifacts, excludeArtifactTypes)
 
 	// Phase 2: Run analyzers.
 	allFindings := findings.NewFindingSet()
 
 	// Secrets scanner.
-	secretsAnalyzer := secrets.NewAnalyzer()
+	var (
+		secretsOpts []secrets.AnalyzerOption
+		dataOpts    []data.AnalyzerOption
+		iacOpts     []iac.AnalyzerOption
+		aiOpts      []ai.AnalyzerOption
+	)
+	if opts.Fast {
+		secretsOpts = append(secretsOpts, secrets.WithLazyCompile())
+		dataOpts = append(dataOpts, data.WithLazyCompile())
+		iacOpts = append(iacOpts, iac.WithLazyCompile())
+		aiOpts = append(aiOpts, ai.WithLazyCompile())
+	}
+	// Files that pass discovery but fail to read or scan in an analyzer
+	// are skipped and reported with the discovery errors, unless strict
+	// I/O makes them fail the scan.
+	var skipped *discovery.FileErrors
+	if !opts.StrictIO {
+		skipped = &discovery.FileErrors{}
+		secretsOpts = append(secretsOpts, secrets.WithFileErrors(skipped))
+		dataOpts = append(dataOpts, data.WithFileErrors(skipped))
+		iacOpts = append(iacOpts, iac.WithFileErrors(skipped))
+		aiOpts = append(aiOpts, ai.WithFileErrors(skipped))
+	}
+	artifactPaths := make([]string, 0, len(contentArtifacts))
+	for _, a := range contentArtifacts {
+		artifactPaths = append(artifactPaths, a.Path)
+	}
+	// skip reports whether an analyzer, whose rules are in the category of
+	// the same name, can be left out because it is disabled, its category
+	// is filtered out or, in a fast scan, none of its rules apply to the
+	// artifacts.
+	skip := func(analyzer string, rs *rules.RuleSet) bool {
+		return !runs(analyzer) || !enabled(analyzer) || opts.Fast && !rs.AppliesToAny(artifactPaths)
+	}
+
+	secretsAnalyzer := secrets.NewAnalyzer(secretsOpts...)
+	secretsAnalyzer.SetFileTimeout(fileTimeout)
+	secretsAnalyzer.AddKeyFilePatterns(cfg.Scan.Secrets.KeyFiles...)
+	if w := cfg.Scan.Secrets.PairWindow; w != nil {
+		secretsAnalyzer.SetPairWindow(*w)
+	}
+	secretsAnalyzer.SetPlaceholderAction(placeholderAction)
+	if dirs := cfg.Scan.Secrets.TestDirs; dirs != nil {
+		secretsAnalyzer.SetTestDirs(dirs)
+	}
 
 	// Apply entropy config overrides from .nox.yaml.
 	if ec := cfg.Scan.Entropy; ec.Threshold > 0 || ec.HexThreshold > 0 || ec.Base64Threshold > 0 || ec.RequireContext != nil {
@@ -122,68 +410,206 @@ func RunScanWithOptions(target string, opts ScanOptions) (*ScanResult, error) {
 		})
 	}
 
-	secretsFindings, err := secretsAnalyzer.ScanArtifacts(artifacts)
-	if err != nil {
-		return nil, err
+	// Env files go to the dotenv analyzer, which applies the secret rules
+	// with the variable names as context, unless it is disabled.
+	runDotenv := runs("dotenv") && enabled(rules.CategorySecrets)
+	secretsArtifacts, envArtifacts := contentArtifacts, []discovery.Artifact(nil)
+	if runDotenv {
+		secretsArtifacts = nil
+		for _, a := range contentArtifacts {
+			if dotenv.IsEnvFile(a.Path) {
+				envArtifacts = append(envArtifacts, a)
+			} else {
+				secretsArtifacts = append(secretsArtifacts, a)
+			}
+		}
+	}
+
+	// The git metadata files are read by the secrets analyzer only.
+	secretsArtifacts = append(slices.Clone(secretsArtifacts), d.gitMetadata...)
+
+	var analyzerTimings []AnalyzerTiming
+	phaseStart := time.Now()
+	secretsFindings := findings.NewFindingSet()
+	if runs("secrets") && enabled(rules.CategorySecrets) {
+		secretsFindings, err = secretsAnalyzer.ScanArtifactsContext(ctx, secretsArtifacts)
+		if err != nil && !interrupted(ctx, err) {
+			return nil, err
+		}
 	}
 	secretsItems := secretsFindings.Findings()
 	for i := range secretsItems {
 		allFindings.Add(secretsItems[i])
 	}
+	analyzerTimings = append(analyzerTimings, logAnalyzer("secrets", len(secretsItems), phaseStart))
+
+	// Dotenv scanner.
+	phaseStart = time.Now()
+	dotenvAnalyzer := dotenv.NewAnalyzer(secretsAnalyzer)
+	dotenvAnalyzer.SetFileErrors(skipped)
+	dotenvFindings := findings.NewFindingSet()
+	if runDotenv && len(envArtifacts) > 0 {
+		tracked := opts.trackedFiles
+		if tracked == nil && git.IsGitRepo(target) {
+			if tracked, err = git.TrackedFiles(target); err != nil {
+				slog.Debug("not reporting committed env files", "error", err)
+			}
+		}
+		dotenvAnalyzer.SetTrackedFiles(tracked)
+		dotenvFindings, err = dotenvAnalyzer.ScanArtifactsContext(ctx, envArtifacts)
+		if err != nil && !interrupted(ctx, err) {
+			return nil, err
+		}
+	}
+	dotenvItems := dotenvFindings.Findings()
+	for i := range dotenvItems {
+		allFindings.Add(dotenvItems[i])
+	}
+	analyzerTimings = append(analyzerTimings, logAnalyzer("dotenv", len(dotenvItems), phaseStart))
 
 	// Data sensitivity scanner.
-	dataAnalyzer := data.NewAnalyzer()
-	dataFindings, err := dataAnalyzer.ScanArtifacts(artifacts)
-	if err != nil {
-		return nil, err
+	phaseStart = time.Now()
+	dataAnalyzer := data.NewAnalyzer(dataOpts...)
+	dataAnalyzer.SetFileTimeout(fileTimeout)
+	dataFindings := findings.NewFindingSet()
+	if !skip(rules.CategoryData, dataAnalyzer.Rules()) {
+		dataFindings, err = dataAnalyzer.ScanArtifactsContext(ctx, contentArtifacts)
+		if err != nil && !interrupted(ctx, err) {
+			return nil, err
+		}
 	}
 	dataResults := dataFindings.Findings()
 	for i := range dataResults {
 		allFindings.Add(dataResults[i])
 	}
+	analyzerTimings = append(analyzerTimings, logAnalyzer("data", len(dataResults), phaseStart))
 
 	// IaC scanner.
-	iacAnalyzer := iac.NewAnalyzer()
-	iacFindings, err := iacAnalyzer.ScanArtifacts(artifacts)
-	if err != nil {
-		return nil, err
+	phaseStart = time.Now()
+	iacAnalyzer := iac.NewAnalyzer(iacOpts...)
+	iacAnalyzer.SetFileTimeout(fileTimeout)
+	iacFindings := findings.NewFindingSet()
+	if !skip(rules.CategoryIaC, iacAnalyzer.Rules()) {
+		iacFindings, err = iacAnalyzer.ScanArtifactsContext(ctx, contentArtifacts)
+		if err != nil && !interrupted(ctx, err) {
+			return nil, err
+		}
 	}
 	iacItems := iacFindings.Findings()
 	for i := range iacItems {
 		allFindings.Add(iacItems[i])
 	}
+	analyzerTimings = append(analyzerTimings, logAnalyzer("iac", len(iacItems), phaseStart))
 
 	// AI security scanner.
-	aiAnalyzer := ai.NewAnalyzer()
-	aiFindings, aiInventory, err := aiAnalyzer.ScanArtifacts(artifacts)
-	if err != nil {
-		return nil, err
+	phaseStart = time.Now()
+	aiAnalyzer := ai.NewAnalyzer(aiOpts...)
+	aiAnalyzer.SetFileTimeout(fileTimeout)
+	aiFindings, aiInventory := findings.NewFindingSet(), &ai.Inventory{}
+	if !skip(rules.CategoryAI, aiAnalyzer.Rules()) {
+		aiFindings, aiInventory, err = aiAnalyzer.ScanArtifactsContext(ctx, contentArtifacts)
+		if err != nil && !interrupted(ctx, err) {
+			return nil, err
+		}
 	}
 	aiItems := aiFindings.Findings()
 	for i := range aiItems {
 		allFindings.Add(aiItems[i])
 	}
+	analyzerTimings = append(analyzerTimings, logAnalyzer("ai", len(aiItems), phaseStart))
 
 	// Dependency scanner.
 	var depsOpts []deps.AnalyzerOption
-	if opts.DisableOSV || cfg.Scan.OSV.Disabled {
+	runDeps, runContainer := runs("deps"), runs("container")
+	if opts.DisableOSV || opts.Offline || cfg.Scan.OSV.Disabled || !runDeps {
 		depsOpts = append(depsOpts, deps.WithOSVDisabled())
 	}
+	if opts.Offline {
+		depsOpts = append(depsOpts, deps.WithHTTPClient(offline.Client()))
+	}
+	if opts.VerifyPins && !opts.Offline {
+		depsOpts = append(depsOpts, deps.WithPinVerification(os.Getenv("GITHUB_TOKEN")))
+	}
+	if !runContainer {
+		depsOpts = append(depsOpts, deps.WithContainerDisabled())
+	}
+	if ci := cfg.SBOM.IncludeCI; ci != nil && !*ci {
+		depsOpts = append(depsOpts, deps.WithCIComponentsDisabled())
+	}
+	if prefixes := cfg.Dependencies.InternalPrefixes; len(prefixes) > 0 {
+		depsOpts = append(depsOpts, deps.WithInternalPrefixes(prefixes...))
+	}
+	if base := cfg.Dependencies.InstallScriptBase; base != "" {
+		if read, err := baseFileReader(target, base); err != nil {
+			slog.Warn("not checking install scripts against the base revision", "base", base, "error", err)
+		} else {
+			depsOpts = append(depsOpts, deps.WithInstallScriptBase(base, read))
+		}
+	}
+	depsOpts = append(depsOpts, deps.WithMemoryGuard(memguard.New(budget)), deps.WithFileErrors(skipped))
+	if lp := cfg.licensePolicy(); lp.configured() {
+		historyPath := lp.HistoryPath
+		if historyPath == "" {
+			historyPath = filepath.Join(filepath.Dir(baseline.DefaultPath(target)), licenseHistoryFile)
+		} else if !filepath.IsAbs(historyPath) {
+			historyPath = filepath.Join(target, historyPath)
+		}
+		depsOpts = append(depsOpts, deps.WithLicensePolicy(deps.LicensePolicy{
+			Deny:        lp.Deny,
+			Allow:       lp.Allow,
+			Warn:        lp.Warn,
+			HistoryPath: historyPath,
+		}))
+	}
+	phaseStart = time.Now()
 	depsAnalyzer := deps.NewAnalyzer(depsOpts...)
-	inventory, depsFindings, err := depsAnalyzer.ScanArtifacts(artifacts)
-	if err != nil {
-		return nil, err
+	inventory, depsFindings := &deps.PackageInventory{}, findings.NewFindingSet()
+	if !opts.Fast && (runDeps && enabled(rules.CategoryDeps) || runContainer && enabled(rules.CategoryContainer)) {
+		inventory, depsFindings, err = depsAnalyzer.ScanArtifactsContext(ctx, artifacts)
+		if err != nil && !interrupted(ctx, err) {
+			return nil, err
+		}
+	}
+	if !runDeps {
+		// The container checks share the dependency analyzer; keep only
+		// their findings and no package inventory.
+		inventory = &deps.PackageInventory{}
+		depsFindings.Retain(func(f *findings.Finding) bool {
+			return depsAnalyzer.Rules().CategoryOf(f) == rules.CategoryContainer
+		})
+	} else {
+		// Submodules are vendored source; list them with the packages
+		// for the SBOM. Their own manifests were found by the walk.
+		for _, s := range submodules {
+			if s.Commit != "" {
+				inventory.Add(submodulePackage(s))
+			}
+		}
 	}
 	depsItems := depsFindings.Findings()
 	for i := range depsItems {
 		allFindings.Add(depsItems[i])
 	}
+	analyzerTimings = append(analyzerTimings, logAnalyzer("deps", len(depsItems), phaseStart))
+
+	var ruleTimings []rules.RuleTiming
+	ruleTimings = append(ruleTimings, secretsAnalyzer.RuleTimings()...)
+	ruleTimings = append(ruleTimings, dataAnalyzer.RuleTimings()...)
+	ruleTimings = append(ruleTimings, iacAnalyzer.RuleTimings()...)
+	ruleTimings = append(ruleTimings, aiAnalyzer.RuleTimings()...)
+	var lineNotes []rules.LineNote
+	for _, notes := range [][]rules.LineNote{secretsAnalyzer.LineNotes(), dataAnalyzer.LineNotes(), iacAnalyzer.LineNotes(), aiAnalyzer.LineNotes()} {
+		lineNotes = append(lineNotes, notes...)
+	}
 
 	// Merge all analyzer rule sets for SARIF reporting.
 	allRules := rules.NewRuleSet()
 	for _, r := range secretsAnalyzer.Rules().Rules() {
 		allRules.Add(r)
 	}
+	for _, r := range dotenvAnalyzer.Rules().Rules() {
+		allRules.Add(r)
+	}
 	for _, r := range dataAnalyzer.Rules().Rules() {
 		allRules.Add(r)
 	}
@@ -196,54 +622,82 @@ func RunScanWithOptions(target string, opts ScanOptions) (*ScanResult, error) {
 	for _, r := range depsAnalyzer.Rules().Rules() {
 		allRules.Add(r)
 	}
-
-	// Phase 2b: Load and merge custom rules (CLI flag > config > none).
-	customPath := opts.CustomRulesPath
-	if customPath == "" {
-		customPath = cfg.Scan.RulesDir
+	for _, r := range auditRules().Rules() {
+		allRules.Add(r)
 	}
-	if customPath != "" {
-		if !filepath.IsAbs(customPath) {
-			customPath = filepath.Join(target, customPath)
-		}
-		customRules, err := loadCustomRules(customPath)
-		if err != nil {
-			return nil, fmt.Errorf("loading custom rules: %w", err)
-		}
-		// Check for duplicates before merging.
-		for _, cr := range customRules.Rules() {
-			if allRules.HasID(cr.ID) {
-				return nil, fmt.Errorf("custom rule ID %q conflicts with a built-in rule", cr.ID)
+
+	// Phase 2b: Merge custom rules (CLI flag > config > none) and the
+	// installed rule packs listed in scan.rule_packs.
+	if len(extraSources) > 0 {
+		phaseStart = time.Now()
+		customRules := rules.NewRuleSet()
+		origin := make(map[string]string)
+		for _, src := range extraSources {
+			for _, issue := range rules.LintRules(src.rules, rules.DefaultLintBudget) {
+				if issue.Err != nil {
+					slog.Warn("invalid custom rule pattern; rule will never match", "rule_id", issue.RuleID, "source", src.label, "error", issue.Err)
+					continue
+				}
+				slog.Warn("slow custom rule pattern", "rule_id", issue.RuleID, "source", src.label, "duration", issue.Duration, "budget", rules.DefaultLintBudget)
+			}
+			// Check for duplicates before merging.
+			for _, cr := range src.rules.Rules() {
+				if allRules.HasID(cr.ID) {
+					return nil, fmt.Errorf("%s ID %q conflicts with a built-in rule", src.label, cr.ID)
+				}
+				if other, ok := origin[cr.ID]; ok {
+					return nil, fmt.Errorf("%s ID %q conflicts with %s", src.label, cr.ID, other)
+				}
+				origin[cr.ID] = src.label
+				customRules.Add(cr)
 			}
 		}
 		// Run custom rules against artifacts.
 		customEngine := rules.NewEngine(customRules)
-		for _, artifact := range artifacts {
-			content, readErr := os.ReadFile(artifact.AbsPath)
+		customEngine.SetFileTimeout(fileTimeout)
+		customCount := 0
+		for _, artifact := range contentArtifacts {
+			if ctx.Err() != nil {
+				break
+			}
+			content, readErr := discovery.ReadContent(artifact)
 			if readErr != nil {
+				if skipped.Skip(artifact.Path, readErr) {
+					continue
+				}
 				return nil, fmt.Errorf("reading artifact %s for custom rules: %w", artifact.Path, readErr)
 			}
-			customFindings, scanErr := customEngine.ScanFile(artifact.Path, content)
-			if scanErr != nil {
+			customFindings, scanErr := customEngine.ScanFileContext(ctx, artifact.Path, content)
+			if scanErr != nil && !interrupted(ctx, scanErr) && !errors.Is(scanErr, rules.ErrFileTimeout) &&
+				!skipped.Skip(artifact.Path, scanErr) {
 				return nil, fmt.Errorf("scanning %s with custom rules: %w", artifact.Path, scanErr)
 			}
 			for i := range customFindings {
 				allFindings.Add(customFindings[i])
 			}
+			customCount += len(customFindings)
 		}
-		// Add custom rules to the rule set for SARIF reporting.
+		// Record the category of the custom findings for the commands
+		// that read findings.json, then add custom rules to the rule set
+		// for SARIF reporting.
+		markCustomCategories(allFindings, customRules)
 		for _, cr := range customRules.Rules() {
 			allRules.Add(cr)
 		}
+		ruleTimings = append(ruleTimings, customEngine.RuleTimings()...)
+		lineNotes = append(lineNotes, customEngine.LineNotes()...)
+		analyzerTimings = append(analyzerTimings, logAnalyzer("custom", customCount, phaseStart))
 	}
 
-	// Phase 3: Apply rule config.
-	if len(cfg.Scan.Rules.Disable) > 0 {
-		allFindings.RemoveByRuleIDs(cfg.Scan.Rules.Disable)
-	}
-	for ruleID, sev := range cfg.Scan.Rules.SeverityOverride {
-		allFindings.OverrideSeverity(ruleID, findings.Severity(sev))
-	}
+	// Phase 2c: Drop findings from filtered-out categories, such as the
+	// container findings of the dependency analyzer when only deps is
+	// enabled, or custom rules outside the selected categories.
+	allFindings.Retain(func(f *findings.Finding) bool {
+		return enabled(allRules.CategoryOf(f))
+	})
+
+	// Phase 3: Apply rule config, scoped by nested .nox.yaml files.
+	configTree.ApplyRules(allFindings)
 
 	// Phase 3b: Apply analyzer_rules (disable rules for specific paths).
 	for _, ar := range cfg.Scan.AnalyzerRules {
@@ -262,20 +716,36 @@ func RunScanWithOptions(target string, opts ScanOptions) (*ScanResult, error) {
 		}
 	}
 
-	// Phase 4: Deduplicate and sort.
+	// Phase 3d: Warn about findings whose severity is not a nox severity,
+	// such as a severity override with a typo: no threshold includes them.
+	warnUnknownSeverities(allFindings)
+
+	// Phase 4: Deduplicate and sort, then number the groups of findings
+	// that share a secret value.
 	allFindings.Deduplicate()
 	allFindings.SortDeterministic()
+	allFindings.NumberSecretGroups()
+
+	// Phase 5: Apply inline suppressions, inventorying every nox:ignore
+	// directive in the scanned files.
+	suppressionRecords := applySuppressions(allFindings, target, collectSuppressions(ctx, contentArtifacts), timeNow())
 
-	// Phase 5: Apply inline suppressions.
-	applySuppressions(allFindings, target)
+	// Phase 5a: Attribute secrets findings to the commits that introduced
+	// them.
+	if opts.Blame || cfg.Scan.Secrets.Blame {
+		attributeSecrets(target, allFindings, allRules)
+	}
 
 	// Phase 5b: Scan Terraform plan if provided.
-	if opts.TerraformPlanPath != "" {
+	if opts.TerraformPlanPath != "" && runs("iac") && enabled(rules.CategoryIaC) {
 		tfPlanPath := opts.TerraformPlanPath
 		if !filepath.IsAbs(tfPlanPath) {
 			tfPlanPath = filepath.Join(target, tfPlanPath)
 		}
-		tfFindings, tfErr := iac.ScanTerraformPlan(tfPlanPath)
+		tfFindings, tfErr := iac.ScanTerraformPlanAs(tfPlanPath, targetRelative(target, tfPlanPath))
+		if tfErr != nil {
+			slog.Warn("skipping terraform plan", "path", tfPlanPath, "error", tfErr)
+		}
 		if tfErr == nil && tfFindings != nil {
 			tfItems := tfFindings.Findings()
 			for i := range tfItems {
@@ -304,31 +774,286 @@ func RunScanWithOptions(target string, opts ScanOptions) (*ScanResult, error) {
 		}
 		if vexDoc, vexErr := vex.LoadVEX(vexPath); vexErr == nil {
 			vex.ApplyVEX(allFindings, vexDoc)
+		} else {
+			slog.Warn("skipping VEX document", "path", vexPath, "error", vexErr)
+		}
+	}
+
+	// Phase 6c: Apply Nox-Override commit trailers.
+	var (
+		applied  []override.Entry
+		rejected []override.Rejection
+	)
+	if len(opts.Overrides) > 0 {
+		applied, rejected = override.Apply(allFindings, allRules, opts.Overrides, time.Now())
+	}
+
+	// Phase 6d: Self-audit the config and baseline for settings that weaken
+	// the scan. This runs after suppression and baseline matching so that
+	// its findings cannot be hidden by the settings they report.
+	depsRules, containerRules := rules.NewRuleSet(), rules.NewRuleSet()
+	for _, r := range depsAnalyzer.Rules().Rules() {
+		if r.Category == rules.CategoryContainer {
+			containerRules.Add(r)
+		} else {
+			depsRules.Add(r)
+		}
+	}
+	if enabled(rules.CategoryAudit) {
+		for _, f := range auditScan(target, cfg, configTree, baselinePath, []ruleCategory{
+			{"secrets", secretsAnalyzer.Rules()},
+			{"data", dataAnalyzer.Rules()},
+			{"ai", aiAnalyzer.Rules()},
+			{"iac", iacAnalyzer.Rules()},
+			{"deps", depsRules},
+			{"container", containerRules},
+		}, allFindings) {
+			allFindings.Add(f)
+		}
+		for _, f := range suppressionAuditFindings(suppressionRecords, cfg.Suppressions.RequireReason) {
+			allFindings.Add(f)
 		}
 	}
 
+	// Phase 6e: Date findings from the findings history, work out their
+	// SLA status and, for the scan history, compare them with those of the
+	// previous full scan.
+	recordHistory := cfg.History.Enabled && !opts.narrowed() && ctx.Err() == nil
+	var delta *trend.Record
+	if sla != nil || recordHistory {
+		historyPath := cfg.SLA.HistoryPath
+		if historyPath == "" {
+			historyPath = filepath.Join(filepath.Dir(baseline.DefaultPath(target)), findingsHistoryFile)
+		} else if !filepath.IsAbs(historyPath) {
+			historyPath = filepath.Join(target, historyPath)
+		}
+		delta = trackFindings(allFindings, sla, historyPath, baselinePath, recordHistory, timeNow())
+	}
+
+	// Phase 6f: Record the CODEOWNERS owners of each finding's file.
+	assignOwners(target, allFindings)
+
+	// Phase 6g: Tag the findings in the files the change touches.
+	if opts.ChangedFilesFrom != "" {
+		allFindings.MarkChangedFiles(changedFiles)
+	}
+
+	// Phase 6h: Link rules and findings to the reference URLs of the
+	// config.
+	allRules = linkReferences(cfg, allRules, allFindings)
+
 	// Phase 7: Evaluate policy.
 	var policyResult *policy.Result
-	if cfg.Policy.FailOn != "" || cfg.Policy.BaselineMode != "" {
+	if cfg.Policy.FailOn != "" || cfg.Policy.BaselineMode != "" || policyExpr != nil {
 		policyCfg := policy.Config{
-			FailOn:       findings.Severity(cfg.Policy.FailOn),
-			WarnOn:       findings.Severity(cfg.Policy.WarnOn),
+			FailOn:       cfg.SeverityMapping.Resolve(cfg.Policy.FailOn),
+			WarnOn:       cfg.SeverityMapping.Resolve(cfg.Policy.WarnOn),
 			BaselineMode: policy.BaselineMode(cfg.Policy.BaselineMode),
+			Scope:        policyScope,
+			Expression:   policyExpr,
+			Categories:   PolicyCategories(cfg, categoryRegistry),
+			Category:     allRules.CategoryOf,
 		}
-		policyResult = policy.Evaluate(policyCfg, allFindings.Findings())
+		policyResult = policy.EvaluateInput(policyCfg, policy.Input{
+			Findings:  allFindings.Findings(),
+			Inventory: inventoryStats(inventory),
+			Now:       timeNow(),
+		})
 	}
 
-	return &ScanResult{
-		Findings:     allFindings,
-		Inventory:    inventory,
-		AIInventory:  aiInventory,
-		PolicyResult: policyResult,
-		Rules:        allRules,
-	}, nil
+	slog.Debug("scan finished", "target", target, "findings", len(allFindings.Findings()), "duration", time.Since(scanStart))
+
+	gitCommit, _ := git.HeadCommit(target)
+	categories := scanCategories(cfg, opts)
+	result := &ScanResult{
+		Findings:          allFindings,
+		Inventory:         inventory,
+		AIInventory:       aiInventory,
+		PolicyResult:      policyResult,
+		Rules:             allRules,
+		Categories:        categoryRegistry,
+		Overrides:         applied,
+		RejectedOverrides: rejected,
+		SeverityMapping:   cfg.SeverityMapping,
+		Errors:            append(fileErrors, skipped.List()...),
+		Truncated:         d.truncated,
+		LineNotes:         uniqueLineNotes(lineNotes),
+		RuleTimings:       rules.SlowestRules(ruleTimings, 0),
+		Duration:          time.Since(scanStart),
+		AnalyzerTimings:   analyzerTimings,
+		PatternCache:      rules.PatternCacheStats().Sub(cacheStart),
+		SecretFilters:     secretsAnalyzer.FilterStats(),
+		Suppressions:      suppressionRecords,
+		Submodules:        submodules,
+		Provenance: &report.Provenance{
+			RulesHash:  rulesHash(allRules),
+			ConfigHash: configTree.Hash(),
+			Target:     target,
+			GitCommit:  gitCommit,
+			Parameters: report.ScanParameters{
+				OnlyCategories:    categories.Only,
+				SkipCategories:    categories.Skip,
+				NoOSV:             opts.DisableOSV || opts.Offline || cfg.Scan.OSV.Disabled || !runDeps,
+				Fast:              opts.Fast,
+				VerifyPins:        opts.VerifyPins && !opts.Offline && runDeps && !opts.Fast,
+				DisabledAnalyzers: disabledAnalyzers,
+				Since:             opts.Since,
+				ChangedFilesFrom:  opts.ChangedFilesFrom,
+			},
+			StartedAt:  scanStart.UTC().Format(time.RFC3339),
+			FinishedAt: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	if opts.Since != "" {
+		result.Provenance.Scope = report.ScopePartial
+	}
+	if err := ctx.Err(); err != nil {
+		result.Partial = true
+		slog.Warn("scan interrupted; results are partial", "target", target, "error", err)
+		return result, fmt.Errorf("scan interrupted: %w", err)
+	}
+	if delta != nil {
+		path := HistoryPath(target, cfg)
+		if err := trend.Append(path, historyRecord(*delta, result, timeNow())); err != nil {
+			slog.Warn("could not record the scan in the history", "path", path, "error", err)
+		}
+	}
+	return result, nil
 }
 
-// loadCustomRules loads rules from a path, which can be a file or directory.
-func loadCustomRules(path string) (*rules.RuleSet, error) {
+// rulesHash hashes every rule in rs, ordered by ID, so that the hash
+// changes whenever a built-in, custom or rule pack rule is added, removed
+// or edited.
+func rulesHash(rs *rules.RuleSet) string {
+	sorted := slices.Clone(rs.Rules())
+	slices.SortFunc(sorted, func(a, b *rules.Rule) int { return strings.Compare(a.ID, b.ID) })
+	return report.HashJSON(sorted)
+}
+
+// interrupted reports whether err is the result of ctx being cancelled, in
+// which case the analyzer's partial results are kept instead of failing.
+func interrupted(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// filterReadable returns the artifacts that can be opened for reading, along
+// with a FileError for each one that cannot.
+func filterReadable(artifacts []discovery.Artifact) ([]discovery.Artifact, []discovery.FileError) {
+	readable := make([]discovery.Artifact, 0, len(artifacts))
+	var errs []discovery.FileError
+	for _, a := range artifacts {
+		f, err := os.Open(a.AbsPath)
+		if err != nil {
+			errs = append(errs, discovery.FileError{Path: a.Path, Message: err.Error()})
+			continue
+		}
+		_ = f.Close()
+		readable = append(readable, a)
+	}
+	return readable, errs
+}
+
+// logAnalyzer records the outcome of a single analyzer pass at debug level
+// and returns its timing.
+func logAnalyzer(name string, count int, start time.Time) AnalyzerTiming {
+	d := time.Since(start)
+	slog.Debug("analyzer finished", "analyzer", name, "findings", count, "duration", d)
+	return AnalyzerTiming{Analyzer: name, Duration: d, Findings: count}
+}
+
+// uniqueLineNotes sorts the line notes of all analyzers, which see the same
+// files, and drops repeats. Each note is logged.
+func uniqueLineNotes(notes []rules.LineNote) []rules.LineNote {
+	rules.SortLineNotes(notes)
+	var out []rules.LineNote
+	for i, n := range notes {
+		if i > 0 && n.Path == notes[i-1].Path && n.Line == notes[i-1].Line && n.RuleID == notes[i-1].RuleID {
+			continue
+		}
+		if n.RuleID != "" {
+			slog.Warn("rule exceeded the line matching budget; later long lines of the file skipped", "path", n.Path, "line", n.Line, "rule_id", n.RuleID, "duration", n.Duration)
+		} else {
+			slog.Debug("line capped for matching", "path", n.Path, "line", n.Line, "length", n.Length, "scanned", n.Scanned)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// customRuleSource is a set of non-built-in rules together with a label
+// naming where they came from, used in conflict errors and lint warnings.
+type customRuleSource struct {
+	label string
+	rules *rules.RuleSet
+}
+
+// scanSelection returns whether rules of a category take part in a scan
+// with cfg and opts, and the analyzers that do not run.
+func scanSelection(cfg *ScanConfig, opts ScanOptions, categories *rules.CategoryRegistry) (enabled func(category string) bool, disabled []string, err error) {
+	if enabled, err = NewCategoryFilter(scanCategories(cfg, opts), categories); err != nil {
+		return nil, nil, err
+	}
+	if disabled, err = resolveDisabledAnalyzers(cfg.Scan.Analyzers, opts.OnlyAnalyzers, opts.DisabledAnalyzers); err != nil {
+		return nil, nil, err
+	}
+	return enabled, disabled, nil
+}
+
+// scanCategories returns the category selection of a scan: the
+// --only/--skip options when set, otherwise scan.categories.
+func scanCategories(cfg *ScanConfig, opts ScanOptions) CategoriesConfig {
+	if len(opts.OnlyCategories) > 0 || len(opts.SkipCategories) > 0 {
+		return CategoriesConfig{Only: opts.OnlyCategories, Skip: opts.SkipCategories}
+	}
+	return cfg.Scan.Categories
+}
+
+// loadExtraRules loads the custom rules, from opts.CustomRulesPath or
+// scan.rules_dir, and the installed rule packs listed in scan.rule_packs.
+func loadExtraRules(target string, cfg *ScanConfig, opts ScanOptions) ([]customRuleSource, error) {
+	var extraSources []customRuleSource
+	customPath := opts.CustomRulesPath
+	if customPath == "" {
+		customPath = cfg.Scan.RulesDir
+	}
+	if customPath != "" {
+		if !filepath.IsAbs(customPath) {
+			customPath = filepath.Join(target, customPath)
+		}
+		customRules, err := LoadCustomRules(customPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading custom rules: %w", err)
+		}
+		slog.Debug("custom rules loaded", "path", customPath, "rules", len(customRules.Rules()))
+		extraSources = append(extraSources, customRuleSource{label: "custom rule", rules: customRules})
+	}
+	if len(cfg.Scan.RulePacks) > 0 {
+		packDir := opts.RulePackDir
+		if packDir == "" {
+			packDir = rulepack.DefaultDir()
+		}
+		for _, ref := range cfg.Scan.RulePacks {
+			pack, err := rulepack.Resolve(packDir, ref)
+			if err != nil {
+				return nil, fmt.Errorf("loading rule packs: %w", err)
+			}
+			packRules, err := pack.Load()
+			if err != nil {
+				return nil, fmt.Errorf("loading rule packs: %w", err)
+			}
+			slog.Debug("rule pack loaded", "pack", pack.Name, "version", pack.Version, "rules", len(packRules.Rules()))
+			extraSources = append(extraSources, customRuleSource{
+				label: fmt.Sprintf("rule pack %s@%s", pack.Name, pack.Version),
+				rules: packRules,
+			})
+		}
+	}
+	return extraSources, nil
+}
+
+// LoadCustomRules loads custom rules from path, which can be a YAML file or
+// a directory of YAML files.
+func LoadCustomRules(path string) (*rules.RuleSet, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, fmt.Errorf("custom rules path %s: %w", path, err)
@@ -344,11 +1069,13 @@ func loadCustomRules(path string) (*rules.RuleSet, error) {
 // pre-commit hooks scan exactly what will be committed. A temporary directory
 // is created with the staged content, scanned using the standard pipeline, and
 // finding paths are remapped to their original repository-relative locations.
+// The scan takes the fast path described by ScanOptions.Fast.
 func RunStagedScan(repoRoot string) (*ScanResult, error) {
-	return RunStagedScanWithOptions(repoRoot, ScanOptions{})
+	return RunStagedScanWithOptions(repoRoot, ScanOptions{Fast: true})
 }
 
 // RunStagedScanWithOptions executes a staged-files scan with the given options.
+// Relative paths in opts are resolved against repoRoot.
 func RunStagedScanWithOptions(repoRoot string, opts ScanOptions) (*ScanResult, error) {
 	stagedPaths, err := git.StagedFiles(repoRoot)
 	if err != nil {
@@ -362,6 +1089,7 @@ func RunStagedScanWithOptions(repoRoot string, opts ScanOptions) (*ScanResult, e
 			Inventory:   &deps.PackageInventory{},
 			AIInventory: &ai.Inventory{},
 			Rules:       rules.NewRuleSet(),
+			Categories:  rules.NewCategoryRegistry(),
 		}, nil
 	}
 
@@ -392,19 +1120,53 @@ func RunStagedScanWithOptions(repoRoot string, opts ScanOptions) (*ScanResult, e
 		}
 	}
 
-	// Copy .nox.yaml config if it exists so exclusion patterns apply.
-	if cfgData, err := os.ReadFile(filepath.Join(repoRoot, ".nox.yaml")); err == nil {
-		_ = os.WriteFile(filepath.Join(tmpDir, ".nox.yaml"), cfgData, 0o644)
+	// Copy .nox.yaml configs if they exist so exclusion patterns apply,
+	// including nested configs in the directories of staged files.
+	configDirs := map[string]bool{".": true}
+	for _, p := range stagedPaths {
+		for _, dir := range ancestors(path.Dir(filepath.ToSlash(p))) {
+			configDirs[dir] = true
+		}
+	}
+	for dir := range configDirs {
+		rel := filepath.Join(filepath.FromSlash(dir), ConfigFileName)
+		if cfgData, err := os.ReadFile(filepath.Join(repoRoot, rel)); err == nil {
+			_ = os.WriteFile(filepath.Join(tmpDir, rel), cfgData, 0o644)
+		}
+	}
+
+	for _, p := range []*string{&opts.CustomRulesPath, &opts.VEXPath, &opts.TerraformPlanPath, &opts.ConfigPath} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(repoRoot, *p)
+		}
 	}
 
 	// Run the standard scan against the temp directory. Paths in findings
 	// will be relative to tmpDir, which mirrors the repository-relative
 	// structure, so no remapping is needed.
-	result, err := RunScan(tmpDir)
+	opts.trackedFiles = stagedPaths
+	result, err := RunScanWithOptions(tmpDir, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	// Record the repository rather than the temp directory that was scanned.
+	result.Provenance.Target = repoRoot
+	result.Provenance.GitCommit, _ = git.HeadCommit(repoRoot)
+	result.Provenance.Parameters.Staged = true
+
+	// Record the overrides that were applied in the repository's override
+	// log and stage it so that the audit trail is committed.
+	if len(result.Overrides) > 0 {
+		logPath := override.DefaultLogPath(repoRoot)
+		if err := override.Append(logPath, result.Overrides); err != nil {
+			return nil, err
+		}
+		if err := git.Add(repoRoot, logPath); err != nil {
+			slog.Warn("could not stage override log", "path", logPath, "error", err)
+		}
+	}
+
 	return result, nil
 }
 
@@ -429,6 +1191,7 @@ type HistoryScanOptions struct {
 // file content through the secrets analyzer. Findings include commit
 // metadata (SHA, author, date) in their Metadata map.
 func RunHistoryScan(repoRoot string, opts *HistoryScanOptions) (*ScanResult, error) {
+	scanStart := time.Now()
 	allFindings := findings.NewFindingSet()
 	allRules := rules.NewRuleSet()
 
@@ -437,8 +1200,6 @@ func RunHistoryScan(repoRoot string, opts *HistoryScanOptions) (*ScanResult, err
 		allRules.Add(r)
 	}
 
-	engine := rules.NewEngine(secretsAnalyzer.Rules())
-
 	walkOpts := git.WalkHistoryOptions{
 		MaxDepth: opts.MaxDepth,
 		Branch:   opts.Branch,
@@ -446,7 +1207,7 @@ func RunHistoryScan(repoRoot string, opts *HistoryScanOptions) (*ScanResult, err
 	}
 
 	err := git.WalkHistory(repoRoot, walkOpts, func(diff git.HistoryDiff) error {
-		matches, scanErr := engine.ScanFile(diff.FilePath, diff.Content)
+		matches, scanErr := secretsAnalyzer.ScanFile(diff.FilePath, diff.Content)
 		if scanErr != nil {
 			return nil // skip files that fail to scan
 		}
@@ -456,7 +1217,7 @@ func RunHistoryScan(repoRoot string, opts *HistoryScanOptions) (*ScanResult, err
 			if matches[i].Metadata == nil {
 				matches[i].Metadata = make(map[string]string)
 			}
-			matches[i].Metadata["commit_sha"] = diff.Commit.SHA
+			matches[i].Metadata[findings.MetaCommitSHA] = diff.Commit.SHA
 			matches[i].Metadata["commit_author"] = diff.Commit.Author
 			matches[i].Metadata["commit_date"] = diff.Commit.Date.Format("2006-01-02T15:04:05Z")
 			matches[i].Metadata["commit_message"] = diff.Commit.Message
@@ -472,89 +1233,128 @@ func RunHistoryScan(repoRoot string, opts *HistoryScanOptions) (*ScanResult, err
 
 	allFindings.Deduplicate()
 	allFindings.SortDeterministic()
+	allFindings.NumberSecretGroups()
 
+	gitCommit, _ := git.HeadCommit(repoRoot)
 	return &ScanResult{
 		Findings:    allFindings,
 		Inventory:   &deps.PackageInventory{},
 		AIInventory: &ai.Inventory{},
 		Rules:       allRules,
+		Categories:  rules.NewCategoryRegistry(),
+		Duration:    time.Since(scanStart),
+		Provenance: &report.Provenance{
+			RulesHash:  rulesHash(allRules),
+			Target:     repoRoot,
+			GitCommit:  gitCommit,
+			StartedAt:  scanStart.UTC().Format(time.RFC3339),
+			FinishedAt: time.Now().UTC().Format(time.RFC3339),
+		},
 	}, nil
 }
 
 // SeverityMeetsThreshold returns true if the given severity is at or above the
-// threshold severity. Lower rank = more severe (critical=0, high=1, etc.).
+// threshold severity; see findings.Severity.MeetsThreshold.
 func SeverityMeetsThreshold(severity, threshold findings.Severity) bool {
-	rank := map[findings.Severity]int{
-		findings.SeverityCritical: 0,
-		findings.SeverityHigh:     1,
-		findings.SeverityMedium:   2,
-		findings.SeverityLow:      3,
-		findings.SeverityInfo:     4,
-	}
-	sr, ok1 := rank[severity]
-	tr, ok2 := rank[threshold]
-	if !ok1 || !ok2 {
-		return false
-	}
-	return sr <= tr
+	return severity.MeetsThreshold(threshold)
 }
 
-// applySuppressions reads files that have findings and marks suppressed findings.
-func applySuppressions(fs *findings.FindingSet, target string) {
-	// Group findings by file.
-	byFile := make(map[string][]int)
-	items := fs.Findings()
-	for i := range items {
-		byFile[items[i].Location.FilePath] = append(byFile[items[i].Location.FilePath], i)
+// warnUnknownSeverities logs a warning for each rule whose findings have a
+// severity that is not a nox severity.
+func warnUnknownSeverities(fs *findings.FindingSet) {
+	type key struct {
+		rule     string
+		severity findings.Severity
 	}
-
-	for filePath, indices := range byFile {
-		fullPath := filePath
-		if !filepath.IsAbs(fullPath) {
-			fullPath = filepath.Join(target, fullPath)
-		}
-
-		content, err := os.ReadFile(fullPath)
-		if err != nil {
+	counts := make(map[key]int)
+	var order []key
+	for _, f := range fs.Findings() {
+		if f.Severity.Valid() {
 			continue
 		}
-
-		suppressions := suppress.ScanForSuppressions(content, filePath)
-		if len(suppressions) == 0 {
-			continue
-		}
-
-		items := fs.Findings()
-		for _, idx := range indices {
-			f := items[idx]
-			for _, s := range suppressions {
-				if s.MatchesFinding(f.RuleID, f.Location.StartLine, timeNow()) {
-					fs.SetStatus(idx, findings.StatusSuppressed)
-					break
-				}
-			}
+		k := key{f.RuleID, f.Severity}
+		if counts[k] == 0 {
+			order = append(order, k)
 		}
+		counts[k]++
+	}
+	for _, k := range order {
+		slog.Warn("findings have an unknown severity; no severity threshold or fail_on includes them",
+			"rule_id", k.rule, "severity", string(k.severity), "findings", counts[k])
 	}
 }
 
-// applyBaseline loads a baseline file and marks matched findings.
+// applyBaseline loads a baseline file and marks matched findings. Entries
+// recorded under another path for the same file are migrated in memory
+// first; "nox baseline update" writes the migration back.
 func applyBaseline(fs *findings.FindingSet, baselinePath string) {
 	bl, err := baseline.Load(baselinePath)
-	if err != nil || bl.Len() == 0 {
+	if err != nil {
+		slog.Warn("skipping baseline", "path", baselinePath, "error", err)
+		return
+	}
+	if bl.Len() == 0 {
 		return
 	}
 
 	items := fs.Findings()
+	if n := bl.Migrate(items); n > 0 {
+		slog.Debug("migrated baseline entries to current paths", "path", baselinePath, "entries", n)
+	}
+	if n := bl.RemovedRules(); n > 0 {
+		slog.Warn("baseline entries name removed rules and match no finding; nox baseline review can remove them",
+			"path", baselinePath, "entries", n)
+	}
 	for i := range items {
 		f := items[i]
 		if f.Status != "" && f.Status != findings.StatusNew {
 			continue // already suppressed
 		}
-		if bl.Match(&f) != nil {
-			fs.SetStatus(i, findings.StatusBaselined)
+		if e := bl.Match(&f); e != nil {
+			fs.Suppress(i, findings.StatusBaselined, e.Reason)
 		}
 	}
 }
 
+// targetRelative returns p relative to target, with forward slashes, when
+// p is inside target, and p normalized otherwise. Findings located this way
+// keep their paths and fingerprints when the scan target is given as an
+// absolute path or the repository is checked out somewhere else.
+func targetRelative(target, p string) string {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return findings.NormalizePath(p)
+	}
+	absPath, err := filepath.Abs(p)
+	if err != nil {
+		return findings.NormalizePath(p)
+	}
+	rel, err := filepath.Rel(absTarget, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return findings.NormalizePath(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
 // timeNow returns the current time. It is a variable so tests can override it.
 var timeNow = time.Now
+
+// inventoryStats summarises inv for policy expressions.
+func inventoryStats(inv *deps.PackageInventory) policy.InventoryStats {
+	stats := policy.InventoryStats{ByEcosystem: map[string]int{}}
+	if inv == nil {
+		return stats
+	}
+	pkgs := inv.Packages()
+	stats.Packages = len(pkgs)
+	for _, p := range pkgs {
+		stats.ByEcosystem[p.Ecosystem]++
+	}
+	for _, vulns := range inv.AllVulnerabilities() {
+		if len(vulns) > 0 {
+			stats.VulnerablePackages++
+			stats.Vulnerabilities += len(vulns)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/git"
+)
+
+// changedSince returns the paths, relative to target, of the files changed
+// since since: a duration such as 30d, 2w or 36h counted back from now, or
+// otherwise a git ref. Uncommitted and untracked files count as changed.
+func changedSince(target, since string) ([]string, error) {
+	if !git.IsGitRepo(target) {
+		return nil, fmt.Errorf("scanning files changed since %s needs a git repository", since)
+	}
+	if d, err := parseAge(since); err == nil {
+		return git.FilesChangedAfter(target, timeNow().Add(-d))
+	}
+	return git.FilesChangedSince(target, since)
+}
+
+// filterChanged returns the artifacts whose path is in changed.
+func filterChanged(artifacts []discovery.Artifact, changed []string) []discovery.Artifact {
+	set := make(map[string]bool, len(changed))
+	for _, p := range changed {
+		set[p] = true
+	}
+	var filtered []discovery.Artifact
+	for _, a := range artifacts {
+		if set[filepath.ToSlash(a.Path)] {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// baseFileReader returns a function that reads a file, by its path relative
+// to target, at the git revision base. A file that is not in the base
+// commit yields an error wrapping os.ErrNotExist.
+func baseFileReader(target, base string) (func(path string) ([]byte, error), error) {
+	root, err := git.RepoRoot(target)
+	if err != nil {
+		return nil, fmt.Errorf("comparing with %s needs a git repository", base)
+	}
+	commit, err := git.ResolveCommit(root, base)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	return func(path string) ([]byte, error) {
+		rel, err := filepath.Rel(root, filepath.Join(abs, path))
+		if err != nil {
+			return nil, err
+		}
+		data, err := git.FileAt(root, commit, filepath.ToSlash(rel))
+		if err != nil {
+			return nil, fmt.Errorf("%s at %s: %w", path, base, os.ErrNotExist)
+		}
+		return data, nil
+	}, nil
+}
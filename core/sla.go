@@ -0,0 +1,296 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/atomicfile"
+	"github.com/nox-hq/nox/core/baseline"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/trend"
+)
+
+// findingsHistoryFile records when each finding was first seen. It lives
+// next to the default baseline in the .nox directory.
+const findingsHistoryFile = "findings-history.json"
+
+// historyRetention is how long a finding that is no longer reported stays
+// in the history, so that one reappearing after a revert or a scan of a
+// subset of the tree keeps its first-seen date.
+const historyRetention = 90 * 24 * time.Hour
+
+// SLASettings sets remediation deadlines: the maximum age of an open
+// finding, per severity. Ages are Go durations or a number of days or
+// weeks, such as "7d" or "2w". A severity without an age has no deadline.
+type SLASettings struct {
+	Critical string `yaml:"critical,omitempty"`
+	High     string `yaml:"high,omitempty"`
+	Medium   string `yaml:"medium,omitempty"`
+	Low      string `yaml:"low,omitempty"`
+	Info     string `yaml:"info,omitempty"`
+	// Approaching is how long before the deadline a finding is reported
+	// as approaching it (default: a quarter of the maximum age).
+	Approaching string `yaml:"approaching,omitempty"`
+	// HistoryPath is the file recording when each finding was first seen
+	// (default .nox/findings-history.json).
+	HistoryPath string `yaml:"history_path,omitempty"`
+}
+
+// slaPolicy is the parsed form of SLASettings.
+type slaPolicy struct {
+	maxAge      map[findings.Severity]time.Duration
+	approaching time.Duration
+}
+
+// parseSLA parses s. It returns nil if no severity has a deadline.
+func parseSLA(s SLASettings) (*slaPolicy, error) {
+	p := &slaPolicy{maxAge: make(map[findings.Severity]time.Duration)}
+	for sev, v := range map[findings.Severity]string{
+		findings.SeverityCritical: s.Critical,
+		findings.SeverityHigh:     s.High,
+		findings.SeverityMedium:   s.Medium,
+		findings.SeverityLow:      s.Low,
+		findings.SeverityInfo:     s.Info,
+	} {
+		if v == "" {
+			continue
+		}
+		d, err := parseAge(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sla.%s: %w", sev, err)
+		}
+		p.maxAge[sev] = d
+	}
+	if s.Approaching != "" {
+		d, err := parseAge(s.Approaching)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sla.approaching: %w", err)
+		}
+		p.approaching = d
+	}
+	if len(p.maxAge) == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// ageUnits are the units parseAge accepts beyond those of Go durations.
+var ageUnits = map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+
+// parseAge parses a positive Go duration or a number of days ("30d") or
+// weeks ("2w").
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	d, err := time.ParseDuration(s)
+	if err != nil && s != "" {
+		if unit, ok := ageUnits[s[len(s)-1]]; ok {
+			if n, convErr := strconv.Atoi(s[:len(s)-1]); convErr == nil {
+				d, err = time.Duration(n)*unit, nil
+			}
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration such as 7d, 2w or 36h", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive duration", s)
+	}
+	return d, nil
+}
+
+// status returns the SLA status of a finding of severity sev first seen
+// at firstSeen, and its deadline. ok is false when sev has no deadline.
+func (p *slaPolicy) status(sev findings.Severity, firstSeen, now time.Time) (status string, due time.Time, ok bool) {
+	maxAge, ok := p.maxAge[sev]
+	if !ok {
+		return "", time.Time{}, false
+	}
+	approaching := p.approaching
+	if approaching == 0 {
+		approaching = maxAge / 4
+	}
+	due = firstSeen.Add(maxAge)
+	switch {
+	case now.After(due):
+		return findings.SLABreached, due, true
+	case !now.Before(due.Add(-approaching)):
+		return findings.SLAApproaching, due, true
+	default:
+		return findings.SLAWithin, due, true
+	}
+}
+
+// historyEntry records when a finding was first and last reported.
+type historyEntry struct {
+	RuleID    string    `json:"rule_id"`
+	FilePath  string    `json:"file_path"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// findingsHistory is the content of the findings history file, keyed by
+// finding fingerprint so that a finding keeps its first-seen date through
+// any edit that leaves its fingerprint unchanged.
+type findingsHistory struct {
+	Findings map[string]*historyEntry `json:"findings"`
+	// LastScan is the time of the last full scan compared with the one
+	// before for the scan history. Findings seen since then count as
+	// reported by the previous scan.
+	LastScan time.Time `json:"last_scan,omitzero"`
+}
+
+// loadFindingsHistory reads the history file at path. A missing file
+// yields an empty history.
+func loadFindingsHistory(path string) (*findingsHistory, error) {
+	h := &findingsHistory{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading findings history %s: %w", path, err)
+	default:
+		if err := json.Unmarshal(data, h); err != nil {
+			return nil, fmt.Errorf("parsing findings history %s: %w", path, err)
+		}
+	}
+	if h.Findings == nil {
+		h.Findings = make(map[string]*historyEntry)
+	}
+	return h, nil
+}
+
+// save writes the history to path, replacing the file atomically.
+func (h *findingsHistory) save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// observe records the findings in items as seen at now. A finding seen for
+// the first time is dated from its baseline entry when it has one, since it
+// has been open at least that long. Entries not seen for historyRetention
+// are dropped.
+func (h *findingsHistory) observe(items []findings.Finding, bl *baseline.Baseline, now time.Time) {
+	for i := range items {
+		f := &items[i]
+		e, ok := h.Findings[f.Fingerprint]
+		if !ok {
+			e = &historyEntry{RuleID: f.RuleID, FirstSeen: now}
+			h.Findings[f.Fingerprint] = e
+		}
+		if bl != nil {
+			if b := bl.Match(f); b != nil && !b.CreatedAt.IsZero() && b.CreatedAt.Before(e.FirstSeen) {
+				e.FirstSeen = b.CreatedAt.UTC()
+			}
+		}
+		e.FilePath = f.Location.FilePath
+		e.LastSeen = now
+	}
+	for fp, e := range h.Findings {
+		if now.Sub(e.LastSeen) > historyRetention {
+			delete(h.Findings, fp)
+		}
+	}
+}
+
+// compare returns how the findings in items differ from those of the
+// previous full scan, as a history record without its counts, and marks
+// now as the time of the last full scan. Findings are told apart by
+// fingerprint; those seen by scans of part of the tree since the previous
+// full scan count as reported by it. It must be called before observe.
+func (h *findingsHistory) compare(items []findings.Finding, now time.Time) trend.Record {
+	var r trend.Record
+	defer func() { h.LastScan = now }()
+	if h.LastScan.IsZero() {
+		r.First = true
+		return r
+	}
+	seen := make(map[string]bool, len(items))
+	for i := range items {
+		f := &items[i]
+		if seen[f.Fingerprint] {
+			continue
+		}
+		seen[f.Fingerprint] = true
+		if e, ok := h.Findings[f.Fingerprint]; ok && !e.LastSeen.Before(h.LastScan) {
+			r.Carried++
+			continue
+		}
+		r.New++
+		if r.NewByRule == nil {
+			r.NewByRule = make(map[string]int)
+		}
+		r.NewByRule[f.RuleID]++
+	}
+	for fp, e := range h.Findings {
+		if seen[fp] || e.LastSeen.Before(h.LastScan) {
+			continue
+		}
+		r.Resolved++
+		if r.ResolvedByRule == nil {
+			r.ResolvedByRule = make(map[string]int)
+		}
+		r.ResolvedByRule[e.RuleID]++
+		r.ResolvedAgeS += int64(now.Sub(e.FirstSeen) / time.Second)
+	}
+	return r
+}
+
+// trackFindings dates each finding from the findings history at
+// historyPath and updates the history. With an SLA policy, it records the
+// first-seen date and, for severities with a deadline, the SLA status and
+// due date in the finding metadata. Baseline entries date the findings they
+// match when those are older than the history. When compare is set, the
+// findings are also compared with those of the previous full scan and the
+// difference returned; it is nil otherwise.
+func trackFindings(fs *findings.FindingSet, p *slaPolicy, historyPath, baselinePath string, compare bool, now time.Time) *trend.Record {
+	h, err := loadFindingsHistory(historyPath)
+	if err != nil {
+		slog.Warn("starting a new findings history", "error", err)
+		h = &findingsHistory{Findings: make(map[string]*historyEntry)}
+	}
+	bl, _ := baseline.Load(baselinePath)
+	now = now.UTC().Truncate(time.Second)
+	items := fs.Findings()
+	var delta *trend.Record
+	if compare {
+		r := h.compare(items, now)
+		delta = &r
+	}
+	h.observe(items, bl, now)
+	if err := h.save(historyPath); err != nil {
+		slog.Warn("could not save findings history", "path", historyPath, "error", err)
+	}
+	if p == nil {
+		return delta
+	}
+
+	for i := range items {
+		f := &items[i]
+		e := h.Findings[f.Fingerprint]
+		// Findings of one rule may share a metadata map.
+		f.Metadata = maps.Clone(f.Metadata)
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[findings.MetaFirstSeen] = e.FirstSeen.Format(time.RFC3339)
+		if status, due, ok := p.status(f.Severity, e.FirstSeen, now); ok {
+			f.Metadata[findings.MetaSLAStatus] = status
+			f.Metadata[findings.MetaSLADue] = due.Format(time.RFC3339)
+		}
+	}
+	return delta
+}
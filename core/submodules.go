@@ -0,0 +1,55 @@
+package core
+
+import (
+	"log/slog"
+
+	"github.com/nox-hq/nox/core/analyzers/deps"
+	"github.com/nox-hq/nox/core/git"
+)
+
+// maxSubmoduleDepth is how deeply nested submodules are scanned: those of
+// the target, of its submodules and of theirs.
+const maxSubmoduleDepth = 3
+
+// discoverSubmodules lists the submodules of the repository in target
+// that a scan covers, and returns the ignore patterns for the submodule
+// directories the walk must leave out: every submodule when enabled is
+// false, and those nested deeper than maxSubmoduleDepth otherwise.
+// Submodules that are not checked out have no content to walk; they are
+// listed so that the caller can say they were skipped.
+func discoverSubmodules(target string, enabled bool) (scanned []git.Submodule, ignore []string) {
+	all, err := git.Submodules(target, maxSubmoduleDepth+1)
+	if err != nil {
+		slog.Warn("could not list git submodules", "error", err)
+		return nil, nil
+	}
+	for _, s := range all {
+		switch {
+		case !enabled:
+			if s.Depth == 1 {
+				ignore = append(ignore, "/"+s.Path+"/")
+			}
+		case s.Depth > maxSubmoduleDepth:
+			slog.Info("skipping nested submodule", "path", s.Path, "max_depth", maxSubmoduleDepth)
+			ignore = append(ignore, "/"+s.Path+"/")
+		default:
+			if !s.Initialized {
+				slog.Info("submodule is not checked out; skipping", "path", s.Path)
+			}
+			scanned = append(scanned, s)
+		}
+	}
+	return scanned, ignore
+}
+
+// submodulePackage returns submodule s as a package of the inventory, so
+// that SBOMs list it: its name is the repository its URL points to, or its
+// path for a relative URL, its version the pinned commit, and Resolved its
+// URL.
+func submodulePackage(s git.Submodule) deps.Package {
+	name := s.Path
+	if _, ok := git.ParseRemote(s.URL); ok {
+		name = git.RepoName(s.URL)
+	}
+	return deps.Package{Name: name, Version: s.Commit, Ecosystem: "git-submodule", Resolved: s.URL}
+}
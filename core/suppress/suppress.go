@@ -6,28 +6,37 @@
 //	<!-- nox:ignore AI-001 -->
 //	/* nox:ignore IAC-001 */
 //	-- nox:ignore DEP-001 -- known issue expires:2025-12-31
+//	# nox:ignore SEC-005 until=2025-12-31 -- rotating in Q4
+//
+// A directive with an expiry date suppresses through that date (UTC) and
+// stops suppressing the day after.
 package suppress
 
 import (
 	"bufio"
 	"bytes"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/nox-hq/nox/core/rules"
 )
 
 // Suppression represents a single inline suppression directive found in source.
 type Suppression struct {
-	RuleIDs  []string
-	FilePath string
-	Line     int // the line the suppression applies to
-	Reason   string
-	Expires  *time.Time
+	RuleIDs       []string
+	FilePath      string
+	Line          int // the line the suppression applies to
+	DirectiveLine int // the line of the nox:ignore comment
+	Reason        string
+	Expires       *time.Time
 }
 
-// suppressionRE matches nox:ignore directives in any comment style.
+// suppressionRE matches nox:ignore directives in any comment style, with an
+// optional until=YYYY-MM-DD after the rule IDs.
 var suppressionRE = regexp.MustCompile(
-	`(?://|#|--|/\*|<!--)\s*nox:ignore\s+([\w-]+(?:,[\w-]+)*)\s*(?:--\s*(.*))?`,
+	`(?://|#|--|/\*|<!--)\s*nox:ignore\s+([\w-]+(?:,[\w-]+)*)(?:\s+until=(\S+))?\s*(?:--\s*(.*))?`,
 )
 
 // expiresRE extracts an expires:YYYY-MM-DD from the reason text.
@@ -53,21 +62,27 @@ func ScanForSuppressions(content []byte, filePath string) []Suppression {
 		}
 
 		ruleIDs := strings.Split(match[1], ",")
-		reason := strings.TrimSpace(match[2])
+		reason := strings.TrimSpace(match[3])
 
 		// Clean up reason: remove closing comment markers.
 		reason = strings.TrimSuffix(reason, "*/")
 		reason = strings.TrimSuffix(reason, "-->")
 		reason = strings.TrimSpace(reason)
 
-		// Parse expiration from reason.
+		// Parse expiration from until= or from expires: in the reason.
 		var expires *time.Time
+		date := strings.TrimSuffix(strings.TrimSuffix(match[2], "-->"), "*/")
 		if em := expiresRE.FindStringSubmatch(reason); em != nil {
-			if t, err := time.Parse("2006-01-02", em[1]); err == nil {
-				expires = &t
+			if date == "" {
+				date = em[1]
 			}
 			reason = strings.TrimSpace(expiresRE.ReplaceAllString(reason, ""))
 		}
+		if date != "" {
+			if t, err := time.Parse("2006-01-02", date); err == nil {
+				expires = &t
+			}
+		}
 
 		// Determine target line: if the line is only a comment (suppression
 		// directive), it applies to the next non-blank line. If the suppression
@@ -79,11 +94,12 @@ func ScanForSuppressions(content []byte, filePath string) []Suppression {
 		}
 
 		result = append(result, Suppression{
-			RuleIDs:  ruleIDs,
-			FilePath: filePath,
-			Line:     targetLine,
-			Reason:   reason,
-			Expires:  expires,
+			RuleIDs:       ruleIDs,
+			FilePath:      filePath,
+			Line:          targetLine,
+			DirectiveLine: lineNum,
+			Reason:        reason,
+			Expires:       expires,
 		})
 	}
 
@@ -93,18 +109,54 @@ func ScanForSuppressions(content []byte, filePath string) []Suppression {
 // MatchesFinding returns true if this suppression applies to the given rule
 // and line, considering expiration.
 func (s Suppression) MatchesFinding(ruleID string, line int, now time.Time) bool {
-	if s.Line != line {
-		return false
-	}
-	if s.Expires != nil && now.After(*s.Expires) {
-		return false
+	return s.Covers(ruleID, line) && !s.Expired(now)
+}
+
+// Covers returns true if this suppression names the given rule, or a
+// removed rule it replaced, and applies to the given line, whether or not
+// it has expired.
+func (s Suppression) Covers(ruleID string, line int) bool {
+	return s.Line == line && slices.ContainsFunc(s.RuleIDs, func(id string) bool {
+		return rules.CanonicalID(id) == ruleID
+	})
+}
+
+// Expired returns true if the suppression has an expiry date and now is
+// after the end of that day.
+func (s Suppression) Expired(now time.Time) bool {
+	return s.Expires != nil && !now.Before(s.Expires.AddDate(0, 0, 1))
+}
+
+// Record is an entry in the inventory of a scan's inline suppressions, as
+// written to the suppressions section of findings.json.
+type Record struct {
+	FilePath      string   `json:"file"`
+	DirectiveLine int      `json:"line"`
+	TargetLine    int      `json:"target_line"`
+	RuleIDs       []string `json:"rule_ids"`
+	Reason        string   `json:"reason,omitempty"`
+	Until         string   `json:"until,omitempty"`
+	Expired       bool     `json:"expired,omitempty"`
+	// Suppressed is the number of findings the directive suppressed in
+	// the scan. A directive that suppresses nothing may be stale.
+	Suppressed int `json:"suppressed"`
+}
+
+// NewRecord returns the inventory record of s as of now, with no
+// suppressed findings counted yet.
+func NewRecord(s Suppression, now time.Time) Record {
+	r := Record{
+		FilePath:      s.FilePath,
+		DirectiveLine: s.DirectiveLine,
+		TargetLine:    s.Line,
+		RuleIDs:       s.RuleIDs,
+		Reason:        s.Reason,
+		Expired:       s.Expired(now),
 	}
-	for _, id := range s.RuleIDs {
-		if id == ruleID {
-			return true
-		}
+	if s.Expires != nil {
+		r.Until = s.Expires.Format("2006-01-02")
 	}
-	return false
+	return r
 }
 
 // isOnlyComment returns true if the line consists entirely of a comment.
@@ -131,6 +131,20 @@ func TestMatchesFinding_WrongRule(t *testing.T) {
 	}
 }
 
+func TestMatchesFinding_RemovedRule(t *testing.T) {
+	s := Suppression{
+		RuleIDs: []string{"SEC-438"},
+		Line:    5,
+	}
+
+	if !s.MatchesFinding("SEC-030", 5, time.Now()) {
+		t.Fatal("expected a removed rule ID to match the rule that replaced it")
+	}
+	if s.MatchesFinding("SEC-438", 5, time.Now()) {
+		t.Fatal("expected no match for the removed rule ID itself")
+	}
+}
+
 func TestMatchesFinding_WrongLine(t *testing.T) {
 	s := Suppression{
 		RuleIDs: []string{"SEC-001"},
@@ -189,3 +203,51 @@ func TestScanForSuppressions_NextLineSkipsBlank(t *testing.T) {
 		t.Fatalf("expected line 3, got %d", supps[0].Line)
 	}
 }
+
+func TestScanForSuppressions_Until(t *testing.T) {
+	for _, line := range []string{
+		"# nox:ignore SEC-005 until=2025-12-31 -- rotating soon",
+		"<!-- nox:ignore SEC-005 until=2025-12-31 -- rotating soon -->",
+		"# nox:ignore SEC-005 -- rotating soon expires:2025-12-31",
+	} {
+		supps := ScanForSuppressions([]byte(line+"\nkey = 1\n"), "a.py")
+		if len(supps) != 1 {
+			t.Fatalf("%q: expected 1 suppression, got %d", line, len(supps))
+		}
+		s := supps[0]
+		if s.Expires == nil || !s.Expires.Equal(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%q: expires = %v", line, s.Expires)
+		}
+		if s.Reason != "rotating soon" || s.DirectiveLine != 1 || s.Line != 2 {
+			t.Errorf("%q: got %+v", line, s)
+		}
+	}
+
+	supps := ScanForSuppressions([]byte("// nox:ignore SEC-001 until=2025-12-31\n"), "a.go")
+	if len(supps) != 1 || supps[0].Expires == nil || supps[0].Reason != "" {
+		t.Errorf("until without a reason: got %+v", supps)
+	}
+}
+
+func TestExpired_CoversTheWholeDay(t *testing.T) {
+	until := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	s := Suppression{RuleIDs: []string{"SEC-001"}, Line: 1, Expires: &until}
+	if s.Expired(time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC)) {
+		t.Error("expired during its last day")
+	}
+	if !s.Expired(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("not expired the day after")
+	}
+	if !s.Covers("SEC-001", 1) || s.MatchesFinding("SEC-001", 1, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("an expired suppression should cover but not match its finding")
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	until := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	s := Suppression{RuleIDs: []string{"SEC-001"}, FilePath: "a.go", Line: 4, DirectiveLine: 3, Reason: "r", Expires: &until}
+	r := NewRecord(s, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if r.FilePath != "a.go" || r.DirectiveLine != 3 || r.TargetLine != 4 || r.Until != "2025-12-31" || !r.Expired || r.Reason != "r" {
+		t.Errorf("NewRecord = %+v", r)
+	}
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nox-hq/nox/core/discovery"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/rules"
+	"github.com/nox-hq/nox/core/suppress"
+)
+
+// SuppressionSettings configures the handling of nox:ignore comments.
+type SuppressionSettings struct {
+	// RequireReason reports every nox:ignore directive without a
+	// "-- reason" as an AUDIT-008 finding. The directive still suppresses.
+	RequireReason bool `yaml:"require_reason,omitempty"`
+}
+
+// suppressionMarker is the text every inline suppression directive
+// contains, used to skip files without one before parsing.
+var suppressionMarker = []byte("nox:ignore")
+
+// collectSuppressions reads the artifacts and returns the nox:ignore
+// directives in each, by artifact path. Files without a directive and
+// files that cannot be read are left out.
+func collectSuppressions(ctx context.Context, artifacts []discovery.Artifact) map[string][]suppress.Suppression {
+	out := make(map[string][]suppress.Suppression)
+	for _, a := range artifacts {
+		if ctx.Err() != nil {
+			break
+		}
+		content, err := discovery.ReadContent(a)
+		if err != nil || !bytes.Contains(content, suppressionMarker) {
+			continue
+		}
+		if s := suppress.ScanForSuppressions(content, a.Path); len(s) > 0 {
+			out[a.Path] = s
+		}
+	}
+	return out
+}
+
+// applySuppressions marks the findings that an unexpired directive covers
+// as suppressed and returns the inventory of directives, ordered by file
+// and line, with the number of findings each suppressed. Directives are
+// taken from collected; files with findings that were not collected, such
+// as files outside a --since scan, are read from target.
+func applySuppressions(fs *findings.FindingSet, target string, collected map[string][]suppress.Suppression, now time.Time) []suppress.Record {
+	// Group findings by file.
+	byFile := make(map[string][]int)
+	items := fs.Findings()
+	for i := range items {
+		byFile[items[i].Location.FilePath] = append(byFile[items[i].Location.FilePath], i)
+	}
+
+	for filePath := range byFile {
+		if _, ok := collected[filePath]; ok {
+			continue
+		}
+		fullPath := filePath
+		if !filepath.IsAbs(fullPath) {
+			fullPath = filepath.Join(target, fullPath)
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			continue
+		}
+		if s := suppress.ScanForSuppressions(content, filePath); len(s) > 0 {
+			collected[filePath] = s
+		}
+	}
+
+	var records []suppress.Record
+	for _, file := range slices.Sorted(maps.Keys(collected)) {
+		suppressions := collected[file]
+		start := len(records)
+		for _, s := range suppressions {
+			records = append(records, suppress.NewRecord(s, now))
+			for _, id := range s.RuleIDs {
+				if to, ok := rules.RenamedTo(id); ok {
+					slog.Warn("nox:ignore names a removed rule; it now applies to the rule that replaced it",
+						"path", file, "line", s.DirectiveLine, "rule_id", id, "replaced_by", to)
+				}
+			}
+		}
+		for _, idx := range byFile[file] {
+			f := items[idx]
+			for j, s := range suppressions {
+				if s.MatchesFinding(f.RuleID, f.Location.StartLine, now) {
+					fs.Suppress(idx, findings.StatusSuppressed, s.Reason)
+					records[start+j].Suppressed++
+					break
+				}
+			}
+		}
+	}
+	return records
+}
+
+// suppressionAuditFindings reports each expired directive as an AUDIT-007
+// finding and, with requireReason, each directive without a reason as an
+// AUDIT-008 finding, located at the directive.
+func suppressionAuditFindings(records []suppress.Record, requireReason bool) []findings.Finding {
+	var out []findings.Finding
+	for _, r := range records {
+		ids := strings.Join(r.RuleIDs, ",")
+		if r.Expired {
+			out = append(out, suppressionFinding(r, "AUDIT-007",
+				fmt.Sprintf("nox:ignore %s expired after %s and no longer suppresses findings", ids, r.Until)))
+		}
+		if requireReason && r.Reason == "" {
+			out = append(out, suppressionFinding(r, "AUDIT-008",
+				fmt.Sprintf("nox:ignore %s has no reason; add one after \"--\"", ids)))
+		}
+	}
+	return out
+}
+
+func suppressionFinding(r suppress.Record, ruleID, message string) findings.Finding {
+	meta := map[string]string{
+		"setting":  "nox:ignore",
+		"rule_ids": strings.Join(r.RuleIDs, ","),
+	}
+	if r.Reason != "" {
+		meta["reason"] = r.Reason
+	}
+	if r.Until != "" {
+		meta["until"] = r.Until
+	}
+	return findings.Finding{
+		RuleID:     ruleID,
+		Severity:   findings.SeverityInfo,
+		Confidence: findings.ConfidenceHigh,
+		Location: findings.Location{
+			FilePath:  r.FilePath,
+			StartLine: r.DirectiveLine,
+		},
+		Message:  message,
+		Metadata: meta,
+	}
+}
@@ -0,0 +1,192 @@
+// Package trend records a compact summary of each scan in a history file
+// and summarizes how the findings of a repository change over time. The
+// history is a JSON Lines file with one record per scan, appended to and
+// never rewritten, so that copies of it on different branches merge
+// cleanly with git's union merge driver.
+package trend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultFile is the name of the history file, in the .nox directory of
+// the scan root.
+const DefaultFile = "history.jsonl"
+
+// Record summarizes one scan. Counts are of the active findings, those
+// neither suppressed nor baselined. New, Resolved and Carried compare every
+// finding reported, suppressed or not, with those of the previous scan, so
+// that suppressing a finding does not count as resolving it.
+type Record struct {
+	Time   time.Time `json:"time"`
+	Commit string    `json:"commit,omitempty"`
+	// DurationMS is how long the scan took, in milliseconds.
+	DurationMS int64          `json:"duration_ms"`
+	Total      int            `json:"total"`
+	BySeverity map[string]int `json:"by_severity,omitempty"`
+	ByCategory map[string]int `json:"by_category,omitempty"`
+	// First is true when there was no previous scan to compare with, in
+	// which case New, Resolved and Carried are zero.
+	First    bool `json:"first,omitempty"`
+	New      int  `json:"new"`
+	Resolved int  `json:"resolved"`
+	Carried  int  `json:"carried"`
+	// NewByRule and ResolvedByRule count New and Resolved by rule ID.
+	NewByRule      map[string]int `json:"new_by_rule,omitempty"`
+	ResolvedByRule map[string]int `json:"resolved_by_rule,omitempty"`
+	// ResolvedAgeS is the sum, in seconds, of the time each resolved
+	// finding was open: from when it was first seen to this scan.
+	ResolvedAgeS int64 `json:"resolved_age_s,omitempty"`
+}
+
+// Append adds r to the history file at path as one line, creating the
+// file and its directory if needed.
+func Append(path string, r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Load reads the history file at path, ordered by time. A missing file
+// yields no records. Lines that are not records, such as conflict markers
+// left by a merge, are skipped with a warning.
+func Load(path string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading history %s: %w", path, err)
+	}
+	var out []Record
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil || r.Time.IsZero() {
+			slog.Warn("skipping invalid history line", "path", path, "line", n)
+			continue
+		}
+		out = append(out, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading history %s: %w", path, err)
+	}
+	slices.SortStableFunc(out, func(a, b Record) int { return a.Time.Compare(b.Time) })
+	return out, nil
+}
+
+// RuleChange is the net change in the findings of one rule over a period.
+type RuleChange struct {
+	RuleID   string `json:"rule_id"`
+	New      int    `json:"new"`
+	Resolved int    `json:"resolved"`
+	Net      int    `json:"net"`
+}
+
+// Summary describes the trajectory of the findings over a period.
+type Summary struct {
+	// Since is the start of the period; zero means the whole history.
+	Since time.Time `json:"since,omitzero"`
+	// Scans are the records of the period, oldest first.
+	Scans []Record `json:"scans"`
+	// Change is the difference in active findings between the first and
+	// the last scan of the period.
+	Change int `json:"change"`
+	// New and Resolved total the findings that appeared and disappeared in
+	// the period, after its first scan.
+	New      int `json:"new"`
+	Resolved int `json:"resolved"`
+	// Regressions are the rules with more new than resolved findings in
+	// the period, largest net increase first.
+	Regressions []RuleChange `json:"regressions"`
+	// MeanTimeToResolution is the mean time the resolved findings were
+	// open, in seconds; zero when none were resolved.
+	MeanTimeToResolution float64 `json:"mean_time_to_resolution_s"`
+}
+
+// MaxRegressions is the number of rules Summarize reports as regressions.
+const MaxRegressions = 10
+
+// Summarize summarizes the records at or after since, which are ordered by
+// time. The deltas of the first record of the period compare it with a
+// scan before the period and are left out of the totals.
+func Summarize(records []Record, since time.Time) *Summary {
+	s := &Summary{Since: since, Scans: []Record{}, Regressions: []RuleChange{}}
+	for _, r := range records {
+		if !r.Time.Before(since) {
+			s.Scans = append(s.Scans, r)
+		}
+	}
+	if len(s.Scans) == 0 {
+		return s
+	}
+	s.Change = s.Scans[len(s.Scans)-1].Total - s.Scans[0].Total
+
+	byRule := make(map[string]*RuleChange)
+	change := func(id string) *RuleChange {
+		c, ok := byRule[id]
+		if !ok {
+			c = &RuleChange{RuleID: id}
+			byRule[id] = c
+		}
+		return c
+	}
+	var resolvedAge int64
+	for _, r := range s.Scans[1:] {
+		s.New += r.New
+		s.Resolved += r.Resolved
+		resolvedAge += r.ResolvedAgeS
+		for id, n := range r.NewByRule {
+			change(id).New += n
+		}
+		for id, n := range r.ResolvedByRule {
+			change(id).Resolved += n
+		}
+	}
+	if s.Resolved > 0 {
+		s.MeanTimeToResolution = float64(resolvedAge) / float64(s.Resolved)
+	}
+	for _, c := range byRule {
+		if c.Net = c.New - c.Resolved; c.Net > 0 {
+			s.Regressions = append(s.Regressions, *c)
+		}
+	}
+	slices.SortFunc(s.Regressions, func(a, b RuleChange) int {
+		if a.Net != b.Net {
+			return b.Net - a.Net
+		}
+		return strings.Compare(a.RuleID, b.RuleID)
+	})
+	if len(s.Regressions) > MaxRegressions {
+		s.Regressions = s.Regressions[:MaxRegressions]
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package trend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var day1 = time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".nox", DefaultFile)
+	if got, err := Load(path); err != nil || got != nil {
+		t.Fatalf("Load of a missing file = %v, %v; want nothing", got, err)
+	}
+	for _, r := range []Record{
+		{Time: day1.AddDate(0, 0, 2), Total: 3, New: 1},
+		{Time: day1, Total: 2, First: true, BySeverity: map[string]int{"high": 2}},
+	} {
+		if err := Append(path, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("expected one line per record, got %d lines:\n%s", n, data)
+	}
+
+	// A merge that kept both sides' conflict markers still loads.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("<<<<<<< HEAD\n=======\n>>>>>>> feature\n\n")
+	f.Close()
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].Time.Equal(day1) || got[0].BySeverity["high"] != 2 || got[1].New != 1 {
+		t.Errorf("Load = %+v, want both records oldest first", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	records := []Record{
+		// Before the period.
+		{Time: day1, Total: 10, First: true},
+		// Its deltas compare it with the scan before the period.
+		{Time: day1.AddDate(0, 0, 10), Total: 12, New: 5, Resolved: 3, NewByRule: map[string]int{"SEC-001": 5}, ResolvedByRule: map[string]int{"SEC-002": 3}, ResolvedAgeS: 300},
+		{Time: day1.AddDate(0, 0, 11), Total: 13, New: 3, Resolved: 2,
+			NewByRule:      map[string]int{"SEC-001": 1, "IAC-001": 2},
+			ResolvedByRule: map[string]int{"SEC-001": 2},
+			ResolvedAgeS:   2 * 86400},
+		{Time: day1.AddDate(0, 0, 12), Total: 11, New: 1, Resolved: 3,
+			NewByRule:      map[string]int{"VULN-001": 1},
+			ResolvedByRule: map[string]int{"SEC-002": 1, "SEC-001": 2},
+			ResolvedAgeS:   4 * 86400},
+	}
+	s := Summarize(records, day1.AddDate(0, 0, 5))
+	if len(s.Scans) != 3 || s.Change != -1 || s.New != 4 || s.Resolved != 5 {
+		t.Errorf("summary = %d scans, change %d, %d new, %d resolved; want 3, -1, 4, 5", len(s.Scans), s.Change, s.New, s.Resolved)
+	}
+	if want := float64(6*86400) / 5; s.MeanTimeToResolution != want {
+		t.Errorf("MeanTimeToResolution = %v, want %v", s.MeanTimeToResolution, want)
+	}
+	want := []RuleChange{{"IAC-001", 2, 0, 2}, {"VULN-001", 1, 0, 1}}
+	if len(s.Regressions) != len(want) {
+		t.Fatalf("Regressions = %+v, want %+v", s.Regressions, want)
+	}
+	for i := range want {
+		if s.Regressions[i] != want[i] {
+			t.Errorf("Regressions[%d] = %+v, want %+v", i, s.Regressions[i], want[i])
+		}
+	}
+
+	if s := Summarize(records, day1.AddDate(1, 0, 0)); len(s.Scans) != 0 || s.Regressions == nil {
+		t.Errorf("summary of an empty period = %+v", s)
+	}
+	if s := Summarize(records, time.Time{}); len(s.Scans) != 4 || s.New != 9 {
+		t.Errorf("summary of the whole history = %d scans, %d new; want 4, 9", len(s.Scans), s.New)
+	}
+}
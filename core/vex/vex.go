@@ -91,13 +91,13 @@ func ApplyVEX(fs *findings.FindingSet, doc *Document) int {
 
 			switch stmt.Status {
 			case StatusNotAffected:
-				fs.SetStatus(i, findings.StatusVEXNotAffected)
+				fs.Suppress(i, findings.StatusVEXNotAffected, stmt.Justification)
 				applied++
 			case StatusUnderInvestigation:
 				fs.SetStatus(i, findings.StatusVEXUnderInvestigation)
 				applied++
 			case StatusFixed:
-				fs.SetStatus(i, findings.StatusVEXFixed)
+				fs.Suppress(i, findings.StatusVEXFixed, stmt.Justification)
 				applied++
 			}
 			break // first match wins
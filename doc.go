@@ -0,0 +1,30 @@
+// Package nox is the supported Go API for embedding the nox scanner.
+//
+// A Scanner is built with New and functional options and scans any fs.FS:
+//
+//	s := nox.New(nox.WithoutOSV(), nox.WithoutAnalyzers("container"))
+//	result, err := s.Scan(ctx, nox.Dir("/path/to/repo"))
+//
+// File systems returned by Dir are scanned in place, so the .nox.yaml,
+// baseline and git history of the directory apply exactly as they do for
+// "nox scan". Other file systems, such as embed.FS or fstest.MapFS, are
+// copied to a temporary directory first; a .nox.yaml at their root still
+// applies. ScanStream delivers findings to a callback instead of
+// returning them.
+//
+// The nox command uses this package for its own scans.
+//
+// # Compatibility
+//
+// This package follows semantic versioning. Within a major version, its
+// exported identifiers are not removed or changed incompatibly, and the
+// documented behaviour of Scan, ScanStream, the options, Result and Finding
+// stays the same. Minor releases may add options, fields and Finding
+// metadata keys, and may add rules, which can change which findings a scan
+// reports.
+//
+// The packages under github.com/nox-hq/nox/core implement the scanner and
+// carry no such guarantee: their types may change between minor releases.
+// Result.Core exposes them for callers that need report generation or other
+// internals and accept that.
+package nox
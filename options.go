@@ -0,0 +1,132 @@
+package nox
+
+import (
+	"github.com/nox-hq/nox/core/override"
+)
+
+// Option configures a Scanner.
+type Option func(*Scanner)
+
+// WithConfigFile uses the config file at path instead of the .nox.yaml at
+// the root of the scanned file system. Nested .nox.yaml files still apply.
+func WithConfigFile(path string) Option {
+	return func(s *Scanner) { s.opts.ConfigPath = path }
+}
+
+// WithRules loads custom rules from a YAML file or a directory of them, in
+// addition to the built-in rules. It replaces scan.rules_dir from
+// .nox.yaml.
+func WithRules(path string) Option {
+	return func(s *Scanner) { s.opts.CustomRulesPath = path }
+}
+
+// WithAnalyzers runs only the named analyzers; see AnalyzerNames. Together
+// with WithoutAnalyzers it replaces scan.analyzers from .nox.yaml. Unknown
+// names make Scan fail.
+func WithAnalyzers(names ...string) Option {
+	return func(s *Scanner) { s.opts.OnlyAnalyzers = append(s.opts.OnlyAnalyzers, names...) }
+}
+
+// WithoutAnalyzers leaves the named analyzers out of the scan.
+func WithoutAnalyzers(names ...string) Option {
+	return func(s *Scanner) { s.opts.DisabledAnalyzers = append(s.opts.DisabledAnalyzers, names...) }
+}
+
+// WithCategories limits the scan to rules in the named categories
+// (secrets, data, ai, iac, deps, container, audit, custom). Together with
+// WithoutCategories it replaces scan.categories from .nox.yaml.
+func WithCategories(names ...string) Option {
+	return func(s *Scanner) { s.opts.OnlyCategories = append(s.opts.OnlyCategories, names...) }
+}
+
+// WithoutCategories leaves rules in the named categories out of the scan.
+func WithoutCategories(names ...string) Option {
+	return func(s *Scanner) { s.opts.SkipCategories = append(s.opts.SkipCategories, names...) }
+}
+
+// WithoutOSV disables OSV.dev vulnerability lookups, so the scan makes no
+// network calls.
+func WithoutOSV() Option {
+	return func(s *Scanner) { s.opts.DisableOSV = true }
+}
+
+// WithOffline guarantees that the scan makes no network requests, as
+// nox --offline does. Like WithoutOSV it skips OSV.dev and package
+// registry lookups.
+func WithOffline() Option {
+	return func(s *Scanner) { s.opts.Offline = true }
+}
+
+// WithPinVerification looks up the image digests and action commits that
+// Dockerfiles and workflows pin, as nox scan --verify-pins does, and
+// reports pins that do not exist or do not match their tag annotation.
+// WithOffline turns it off.
+func WithPinVerification() Option {
+	return func(s *Scanner) { s.opts.VerifyPins = true }
+}
+
+// WithFast trades completeness for latency, as nox scan --staged does:
+// analyzers with no rules for the scanned files are skipped and the
+// dependency scan does not run.
+func WithFast() Option {
+	return func(s *Scanner) { s.opts.Fast = true }
+}
+
+// WithStrictIO makes the scan fail on the first unreadable file instead of
+// listing it in Result.SkippedFiles.
+func WithStrictIO() Option {
+	return func(s *Scanner) { s.opts.StrictIO = true }
+}
+
+// WithVEX applies the OpenVEX document at path to vulnerability findings.
+func WithVEX(path string) Option {
+	return func(s *Scanner) { s.opts.VEXPath = path }
+}
+
+// WithTerraformPlan also scans the terraform plan JSON file at path.
+func WithTerraformPlan(path string) Option {
+	return func(s *Scanner) { s.opts.TerraformPlanPath = path }
+}
+
+// WithBlame attributes secrets findings in git-tracked files to the author
+// and commit that last changed their lines, in the blame_* metadata keys.
+// It needs a file system from Dir.
+func WithBlame() Option {
+	return func(s *Scanner) { s.opts.Blame = true }
+}
+
+// WithSince limits content analysis to the files changed since a git ref,
+// or within a duration such as "30d", "2w" or "36h", as nox scan --since
+// does. Manifests are still read for the package inventory and OSV
+// lookups. It needs a file system from Dir in a git repository.
+func WithSince(refOrDuration string) Option {
+	return func(s *Scanner) { s.opts.Since = refOrDuration }
+}
+
+// WithChangedFiles tags each finding with whether its file is one of the
+// files a change touches, as nox scan --changed-files-from does. from is a
+// file listing the paths, one per line, or a git diff revision spec such
+// as "origin/main...HEAD", which needs a file system from Dir in a git
+// repository. Every file is still scanned.
+func WithChangedFiles(from string) Option {
+	return func(s *Scanner) { s.opts.ChangedFilesFrom = from }
+}
+
+// WithCommitMessage applies the Nox-Override trailers of a commit message,
+// each of which lets one finding through.
+func WithCommitMessage(msg string) Option {
+	return func(s *Scanner) { s.opts.Overrides = override.ParseMessage(msg) }
+}
+
+// WithStaged scans only the files staged in the git index of the
+// directory, with their staged content. It needs a file system from Dir.
+func WithStaged() Option {
+	return func(s *Scanner) { s.mode = modeStaged }
+}
+
+// WithHistory scans the git history of the directory for secrets instead
+// of its working tree, going back at most depth commits (0 for all). It
+// needs a file system from Dir.
+func WithHistory(depth int) Option {
+	return func(s *Scanner) { s.mode, s.historyDepth = modeHistory, depth }
+}
@@ -0,0 +1,91 @@
+package nox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/nox-hq/nox/core"
+)
+
+// Plan is what Scan would cover: the files it would analyze and those it
+// would leave out.
+type Plan struct {
+	Files   []PlannedFile `json:"files"`
+	Skipped []SkippedPath `json:"skipped"`
+	Totals  PlanTotals    `json:"totals"`
+}
+
+// PlannedFile is a file Scan would analyze.
+type PlannedFile struct {
+	// Path is slash-separated and relative to the root of the scanned
+	// file system.
+	Path string `json:"path"`
+	// Type is the artifact type: source, config, lockfile, container,
+	// ai_component or unknown.
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+	// Analyzers are the analyzers that would read the file, in the order
+	// they run; see AnalyzerNames. Custom rules and rule packs are
+	// "custom".
+	Analyzers []string `json:"analyzers"`
+}
+
+// SkippedPath is a file, or a directory with everything in it, that Scan
+// would leave out.
+type SkippedPath struct {
+	Path string `json:"path"`
+	Dir  bool   `json:"dir,omitempty"`
+	// Reason says why, e.g. the ignore or exclude pattern that matched.
+	Reason string `json:"reason"`
+}
+
+// PlanTotals sums up a Plan. Analyzers counts the files each analyzer
+// would read.
+type PlanTotals struct {
+	Files     int            `json:"files"`
+	Bytes     int64          `json:"bytes"`
+	Skipped   int            `json:"skipped"`
+	Analyzers map[string]int `json:"analyzers"`
+}
+
+// DryRun runs the discovery of Scan on fsys and returns what the scan
+// would cover, without running any analyzer or looking up
+// vulnerabilities. Staged and history scans have no dry run.
+func (s *Scanner) DryRun(ctx context.Context, fsys fs.FS) (*Plan, error) {
+	if s.mode != modeTree {
+		return nil, errors.New("staged and history scans have no dry run")
+	}
+	d, inPlace := fsys.(dirFS)
+	root := d.dir
+	if !inPlace {
+		if s.opts.Since != "" {
+			return nil, errors.New("since scans need a file system from nox.Dir")
+		}
+		tmp, err := copyToTemp(ctx, fsys)
+		if err != nil {
+			return nil, fmt.Errorf("copying files to scan: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		root = tmp
+	}
+
+	raw, err := core.PlanScan(root, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	p := &Plan{
+		Files:   make([]PlannedFile, len(raw.Files)),
+		Skipped: make([]SkippedPath, len(raw.Skipped)),
+		Totals:  PlanTotals(raw.Totals),
+	}
+	for i, f := range raw.Files {
+		p.Files[i] = PlannedFile(f)
+	}
+	for i, sp := range raw.Skipped {
+		p.Skipped[i] = SkippedPath(sp)
+	}
+	return p, nil
+}
@@ -48,6 +48,8 @@ func ProtoLocationToGo(pl *pluginv1.Location) findings.Location {
 }
 
 // ProtoSeverityToGo maps a protobuf Severity enum to the domain Severity string.
+// Unspecified and unknown values map to info; callers warn about them with
+// KnownProtoSeverity.
 func ProtoSeverityToGo(ps pluginv1.Severity) findings.Severity {
 	switch ps {
 	case pluginv1.Severity_SEVERITY_CRITICAL:
@@ -65,6 +67,17 @@ func ProtoSeverityToGo(ps pluginv1.Severity) findings.Severity {
 	}
 }
 
+// KnownProtoSeverity reports whether ps is one of the nox severities rather
+// than unspecified or a value this version does not know.
+func KnownProtoSeverity(ps pluginv1.Severity) bool {
+	switch ps {
+	case pluginv1.Severity_SEVERITY_CRITICAL, pluginv1.Severity_SEVERITY_HIGH, pluginv1.Severity_SEVERITY_MEDIUM,
+		pluginv1.Severity_SEVERITY_LOW, pluginv1.Severity_SEVERITY_INFO:
+		return true
+	}
+	return false
+}
+
 // ProtoConfidenceToGo maps a protobuf Confidence enum to the domain Confidence string.
 func ProtoConfidenceToGo(pc pluginv1.Confidence) findings.Confidence {
 	switch pc {
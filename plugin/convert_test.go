@@ -32,6 +32,25 @@ func TestProtoSeverityToGo(t *testing.T) {
 	}
 }
 
+func TestKnownProtoSeverity(t *testing.T) {
+	for _, ps := range []pluginv1.Severity{
+		pluginv1.Severity_SEVERITY_CRITICAL, pluginv1.Severity_SEVERITY_HIGH, pluginv1.Severity_SEVERITY_MEDIUM,
+		pluginv1.Severity_SEVERITY_LOW, pluginv1.Severity_SEVERITY_INFO,
+	} {
+		if !KnownProtoSeverity(ps) {
+			t.Errorf("KnownProtoSeverity(%v) = false", ps)
+		}
+	}
+	for _, ps := range []pluginv1.Severity{pluginv1.Severity_SEVERITY_UNSPECIFIED, pluginv1.Severity(42)} {
+		if KnownProtoSeverity(ps) {
+			t.Errorf("KnownProtoSeverity(%v) = true", ps)
+		}
+		if got := ProtoSeverityToGo(ps); got != findings.SeverityInfo {
+			t.Errorf("ProtoSeverityToGo(%v) = %q, want info", ps, got)
+		}
+	}
+}
+
 func TestGoSeverityToProto(t *testing.T) {
 	tests := []struct {
 		goSev findings.Severity
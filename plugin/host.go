@@ -429,6 +429,10 @@ func (h *Host) MergeResults(resp *pluginv1.InvokeToolResponse, result *core.Scan
 	}
 
 	for _, pf := range resp.GetFindings() {
+		if !KnownProtoSeverity(pf.GetSeverity()) {
+			h.logger.Warn("plugin finding has an unknown severity; reporting it as info",
+				"rule_id", pf.GetRuleId(), "severity", pf.GetSeverity().String())
+		}
 		result.Findings.Add(ProtoFindingToGo(pf))
 	}
 
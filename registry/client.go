@@ -205,6 +205,23 @@ func hasAllTags(pluginTags, required []string) bool {
 	return true
 }
 
+// Lookup returns the first entry named exactly name across all sources.
+func (c *Client) Lookup(ctx context.Context, name string) (*PluginEntry, error) {
+	indexes, err := c.loadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range indexes {
+		for i := range idx.Plugins {
+			if idx.Plugins[i].Name == name {
+				entry := idx.Plugins[i]
+				return &entry, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%q not found in registries", name)
+}
+
 // ResolveOption configures the behavior of Resolve.
 type ResolveOption func(*resolveConfig)
 
@@ -223,6 +240,14 @@ func WithFilter(fn func(PluginEntry) bool) ResolveOption {
 // given constraint string across all sources. Returns an error if no matching
 // version is found.
 func (c *Client) Resolve(ctx context.Context, name, constraint string, opts ...ResolveOption) (*VersionEntry, error) {
+	ve, _, err := c.ResolveSource(ctx, name, constraint, opts...)
+	return ve, err
+}
+
+// ResolveSource is like Resolve but also returns the source whose index
+// provided the selected version, so callers can verify signatures against
+// that registry's trusted keys.
+func (c *Client) ResolveSource(ctx context.Context, name, constraint string, opts ...ResolveOption) (*VersionEntry, Source, error) {
 	var rc resolveConfig
 	for _, opt := range opts {
 		opt(&rc)
@@ -230,18 +255,19 @@ func (c *Client) Resolve(ctx context.Context, name, constraint string, opts ...R
 
 	con, err := ParseConstraint(constraint)
 	if err != nil {
-		return nil, fmt.Errorf("invalid constraint: %w", err)
+		return nil, Source{}, fmt.Errorf("invalid constraint: %w", err)
 	}
 
-	indexes, err := c.loadAll(ctx)
+	indexes, sources, err := c.loadAllSources(ctx)
 	if err != nil {
-		return nil, err
+		return nil, Source{}, err
 	}
 
 	var best *VersionEntry
 	var bestVer Version
+	var bestSource Source
 
-	for _, idx := range indexes {
+	for si, idx := range indexes {
 		for _, p := range idx.Plugins {
 			if p.Name != name {
 				continue
@@ -261,24 +287,33 @@ func (c *Client) Resolve(ctx context.Context, name, constraint string, opts ...R
 				if best == nil || v.Compare(bestVer) > 0 {
 					best = ve
 					bestVer = v
+					bestSource = sources[si]
 				}
 			}
 		}
 	}
 
 	if best == nil {
-		return nil, fmt.Errorf("no version of %q matches constraint %q", name, constraint)
+		return nil, Source{}, fmt.Errorf("no version of %q matches constraint %q", name, constraint)
 	}
 
 	// Return a copy to prevent mutation.
 	result := *best
-	return &result, nil
+	return &result, bestSource, nil
 }
 
 // loadAll returns indexes for all sources, using cache when fresh and fetching
 // otherwise.
 func (c *Client) loadAll(ctx context.Context) ([]*Index, error) {
+	indexes, _, err := c.loadAllSources(ctx)
+	return indexes, err
+}
+
+// loadAllSources is like loadAll but also returns the source of each index,
+// in the same order.
+func (c *Client) loadAllSources(ctx context.Context) ([]*Index, []Source, error) {
 	var indexes []*Index
+	var sources []Source
 	var errs []error
 
 	for _, src := range c.sources {
@@ -288,12 +323,13 @@ func (c *Client) loadAll(ctx context.Context) ([]*Index, error) {
 			continue
 		}
 		indexes = append(indexes, idx)
+		sources = append(sources, src)
 	}
 
 	if len(indexes) == 0 && len(errs) > 0 {
-		return nil, errors.Join(errs...)
+		return nil, nil, errors.Join(errs...)
 	}
-	return indexes, nil
+	return indexes, sources, nil
 }
 
 // getIndex returns the index for a source, using cache if fresh.
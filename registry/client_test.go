@@ -685,3 +685,38 @@ func TestClientInvalidSchemaVersionV99(t *testing.T) {
 		t.Error("expected error for unsupported schema version 99")
 	}
 }
+
+func TestClientLookup(t *testing.T) {
+	idx := testIndex()
+	idx.Plugins = append(idx.Plugins, PluginEntry{
+		Name:     "acme/secret-rules",
+		Kind:     KindRulePack,
+		Versions: []VersionEntry{{Version: "1.2.0", Digest: "sha256:rrr"}},
+	})
+	srv := serveIndex(t, idx)
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	_ = c.AddSource(Source{Name: "test", URL: srv.URL})
+	ctx := context.Background()
+
+	entry, err := c.Lookup(ctx, "acme/secret-rules")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if !entry.IsRulePack() {
+		t.Errorf("expected rule pack, got kind %q", entry.Kind)
+	}
+
+	entry, err = c.Lookup(ctx, "nox/dast")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if entry.IsRulePack() {
+		t.Error("plugin entry without kind reported as rule pack")
+	}
+
+	if _, err := c.Lookup(ctx, "nox/missing"); err == nil {
+		t.Error("expected error for unknown entry")
+	}
+}
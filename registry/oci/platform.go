@@ -15,12 +15,19 @@ func SelectArtifact(artifacts []registry.PlatformArtifact) (*registry.PlatformAr
 	return SelectArtifactFor(artifacts, runtime.GOOS, runtime.GOARCH)
 }
 
-// SelectArtifactFor returns the first artifact matching the given OS and architecture.
+// SelectArtifactFor returns the first artifact matching the given OS and
+// architecture. Platform-independent artifacts (OS and Arch set to
+// registry.PlatformAny) are used when no exact match exists.
 func SelectArtifactFor(artifacts []registry.PlatformArtifact, goos, goarch string) (*registry.PlatformArtifact, error) {
 	for i := range artifacts {
 		if artifacts[i].OS == goos && artifacts[i].Arch == goarch {
 			return &artifacts[i], nil
 		}
 	}
+	for i := range artifacts {
+		if artifacts[i].OS == registry.PlatformAny && artifacts[i].Arch == registry.PlatformAny {
+			return &artifacts[i], nil
+		}
+	}
 	return nil, ErrNoPlatformMatch
 }
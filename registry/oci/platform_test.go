@@ -151,3 +151,26 @@ func TestSelectArtifactEmptyList(t *testing.T) {
 		t.Errorf("error = %v, want %v", err, ErrNoPlatformMatch)
 	}
 }
+
+func TestSelectArtifactForPlatformAny(t *testing.T) {
+	artifacts := []registry.PlatformArtifact{
+		{OS: registry.PlatformAny, Arch: registry.PlatformAny, URL: "any"},
+		{OS: "linux", Arch: "amd64", URL: "linux"},
+	}
+
+	got, err := SelectArtifactFor(artifacts, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.URL != "linux" {
+		t.Errorf("URL = %q, want exact platform match preferred", got.URL)
+	}
+
+	got, err = SelectArtifactFor(artifacts, "windows", "arm64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.URL != "any" {
+		t.Errorf("URL = %q, want platform-independent fallback", got.URL)
+	}
+}
@@ -0,0 +1,132 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nox-hq/nox/registry"
+	"github.com/nox-hq/nox/registry/trust"
+)
+
+// RulePackArtifact is a fetched and verified rule pack together with the
+// YAML rule files it contains.
+type RulePackArtifact struct {
+	InstalledArtifact
+	// Files maps each rule file path (slash-separated, relative to the pack
+	// root) to its contents.
+	Files map[string][]byte
+}
+
+// FetchRulePack downloads, verifies, and caches a rule pack artifact. The
+// artifact is either a single YAML file or a tar.gz of YAML files; other
+// files in an archive are ignored. Unlike Fetch, nothing is marked
+// executable.
+func (s *Store) FetchRulePack(ctx context.Context, name string, ve registry.VersionEntry) (*RulePackArtifact, error) {
+	artifact, err := SelectArtifact(ve.Artifacts)
+	if err != nil {
+		return nil, fmt.Errorf("selecting artifact for %s: %w", name, err)
+	}
+
+	blobPath, err := s.ensureBlob(ctx, name, artifact)
+	if err != nil {
+		return nil, err
+	}
+	blobData, err := os.ReadFile(blobPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading cached blob: %w", err)
+	}
+	// Re-check the digest on cache hits too: rule packs are small, and the
+	// unpacked files are only checked against their own manifest afterwards.
+	match, err := trust.VerifyDigest(blobData, artifact.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("verifying digest: %w", err)
+	}
+	if !match {
+		return nil, ErrDigestMismatch
+	}
+
+	format, err := DetectFormat(blobPath)
+	if err != nil {
+		return nil, fmt.Errorf("detecting format: %w", err)
+	}
+
+	verifyResult, err := s.verify(ctx, ve, artifact, blobData)
+	if err != nil {
+		return nil, err
+	}
+
+	pack := &RulePackArtifact{
+		InstalledArtifact: InstalledArtifact{
+			PluginName:   name,
+			Version:      ve.Version,
+			OS:           artifact.OS,
+			Arch:         artifact.Arch,
+			Digest:       artifact.Digest,
+			BlobPath:     blobPath,
+			Format:       format,
+			Size:         int64(len(blobData)),
+			VerifyResult: verifyResult,
+		},
+		Files: make(map[string][]byte),
+	}
+
+	switch format {
+	case FormatTarGz:
+		// Always extract from the verified blob, as Fetch does.
+		extractDir := s.extractPath(artifact.Digest)
+		if _, err := ExtractTarGz(blobPath, extractDir); err != nil {
+			return nil, fmt.Errorf("extracting rule pack: %w", err)
+		}
+		pack.ExtractDir = extractDir
+		err := filepath.WalkDir(extractDir, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() || !isYAMLFile(p) {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(extractDir, p)
+			if err != nil {
+				return err
+			}
+			pack.Files[filepath.ToSlash(rel)] = data
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading rule pack: %w", err)
+		}
+
+	case FormatRawBinary:
+		pack.Files[rulePackFileName(artifact.URL)] = blobData
+	}
+
+	if len(pack.Files) == 0 {
+		return nil, fmt.Errorf("rule pack %s@%s contains no YAML rule files", name, ve.Version)
+	}
+	return pack, nil
+}
+
+// rulePackFileName derives the file name of a single-file rule pack from its
+// download URL, defaulting to rules.yaml.
+func rulePackFileName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); isYAMLFile(base) {
+			return base
+		}
+	}
+	return "rules.yaml"
+}
+
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,76 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nox-hq/nox/registry"
+)
+
+const testRulePackYAML = "rules:\n  - id: ACME-001\n"
+
+func serveBlob(t *testing.T, data []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func rulePackVersion(url, digest string) registry.VersionEntry {
+	return registry.VersionEntry{
+		Version:    "1.2.0",
+		APIVersion: "v1",
+		Artifacts: []registry.PlatformArtifact{
+			{OS: registry.PlatformAny, Arch: registry.PlatformAny, URL: url, Digest: digest},
+		},
+	}
+}
+
+func TestFetchRulePackSingleFile(t *testing.T) {
+	data := []byte(testRulePackYAML)
+	srv := serveBlob(t, data)
+	store := NewStore(WithCacheDir(t.TempDir()), WithHTTPClient(srv.Client()))
+
+	pack, err := store.FetchRulePack(context.Background(), "acme/rules", rulePackVersion(srv.URL+"/acme.yaml", sha256Digest(data)))
+	if err != nil {
+		t.Fatalf("FetchRulePack: %v", err)
+	}
+	if string(pack.Files["acme.yaml"]) != testRulePackYAML {
+		t.Fatalf("files = %v, want acme.yaml", pack.Files)
+	}
+	if pack.BinaryPath != "" {
+		t.Errorf("rule pack should not have a binary path, got %q", pack.BinaryPath)
+	}
+}
+
+func TestFetchRulePackTarGz(t *testing.T) {
+	data := buildTarGz(t, map[string]string{
+		"rules/tokens.yaml": testRulePackYAML,
+		"README.md":         "docs",
+	})
+	srv := serveBlob(t, data)
+	store := NewStore(WithCacheDir(t.TempDir()), WithHTTPClient(srv.Client()))
+
+	pack, err := store.FetchRulePack(context.Background(), "acme/rules", rulePackVersion(srv.URL+"/pack.tar.gz", sha256Digest(data)))
+	if err != nil {
+		t.Fatalf("FetchRulePack: %v", err)
+	}
+	if len(pack.Files) != 1 || string(pack.Files["rules/tokens.yaml"]) != testRulePackYAML {
+		t.Fatalf("unexpected files: %v", pack.Files)
+	}
+}
+
+func TestFetchRulePackDigestMismatch(t *testing.T) {
+	srv := serveBlob(t, []byte(testRulePackYAML))
+	store := NewStore(WithCacheDir(t.TempDir()), WithHTTPClient(srv.Client()))
+
+	_, err := store.FetchRulePack(context.Background(), "acme/rules", rulePackVersion(srv.URL+"/acme.yaml", sha256Digest([]byte("other"))))
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("error = %v, want ErrDigestMismatch", err)
+	}
+}
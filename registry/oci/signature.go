@@ -0,0 +1,75 @@
+package oci
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nox-hq/nox/registry"
+	"github.com/nox-hq/nox/registry/trust"
+)
+
+// maxSignatureSize bounds detached signature downloads. An Ed25519
+// signature is 64 bytes raw or 88 bytes base64.
+const maxSignatureSize = 4096
+
+// verify runs trust verification for a fetched artifact. Artifact-level
+// signatures (inline or detached) are checked against the verifier's
+// keyring; the version-level signature is checked against its embedded
+// signer key, which must also be in the keyring to count as verified.
+func (s *Store) verify(ctx context.Context, ve registry.VersionEntry, artifact *registry.PlatformArtifact, blob []byte) (trust.VerifyResult, error) {
+	sig := artifact.Signature
+	if len(sig) == 0 && artifact.SignatureURL != "" {
+		var err error
+		sig, err = s.fetchSignature(ctx, artifact.SignatureURL)
+		if err != nil {
+			return trust.VerifyResult{}, fmt.Errorf("fetching signature: %w", err)
+		}
+	}
+
+	var signerKeyPEM []byte
+	if len(sig) == 0 {
+		sig = ve.Signature
+		signerKeyPEM = ve.SignerKeyPEM
+	}
+
+	return s.verifier.VerifyArtifact(blob, artifact.Digest, sig, signerKeyPEM, ve.APIVersion), nil
+}
+
+// fetchSignature downloads a detached signature file containing either the
+// raw signature bytes or their base64 encoding.
+func (s *Store) fetchSignature(ctx context.Context, rawURL string) ([]byte, error) {
+	finalURL, err := s.rewriteURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("rewriting URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, finalURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signature download returned HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+	if len(data) == ed25519.SignatureSize {
+		return data, nil
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("signature is neither raw nor base64: %w", err)
+	}
+	return sig, nil
+}
@@ -0,0 +1,82 @@
+package oci
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/nox-hq/nox/registry"
+	"github.com/nox-hq/nox/registry/trust"
+)
+
+func TestStoreFetchDetachedSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := trust.NewKey("acme", trust.ExportKeyPEM(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr := trust.NewKeyring()
+	kr.Add(key)
+
+	binary := []byte("#!/bin/sh\necho plugin\n")
+	sig := ed25519.Sign(priv, binary)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plugin.sig":
+			w.Write([]byte(base64.StdEncoding.EncodeToString(sig) + "\n"))
+		case "/bad.sig":
+			w.Write(make([]byte, ed25519.SignatureSize))
+		default:
+			w.Write(binary)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		sigURL string
+		want   trust.TrustLevel
+	}{
+		{"trusted detached signature", srv.URL + "/plugin.sig", trust.TrustVerified},
+		{"mismatched detached signature", srv.URL + "/bad.sig", trust.TrustUnverified},
+		{"unsigned", "", trust.TrustUnverified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(
+				WithCacheDir(t.TempDir()),
+				WithHTTPClient(srv.Client()),
+				WithVerifier(trust.NewVerifier(trust.WithKeyring(kr))),
+			)
+			ve := registry.VersionEntry{
+				Version:    "1.0.0",
+				APIVersion: "v1",
+				Artifacts: []registry.PlatformArtifact{{
+					OS: runtime.GOOS, Arch: runtime.GOARCH,
+					URL: srv.URL + "/plugin", Digest: sha256Digest(binary),
+					SignatureURL: tt.sigURL,
+				}},
+			}
+
+			installed, err := store.Fetch(context.Background(), "acme/plugin", ve)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if installed.VerifyResult.TrustLevel != tt.want {
+				t.Errorf("trust = %v, want %v (violations: %v)", installed.VerifyResult.TrustLevel, tt.want, installed.VerifyResult.Violations)
+			}
+			if tt.want == trust.TrustVerified && installed.VerifyResult.SignerName != "acme" {
+				t.Errorf("signer = %q, want acme", installed.VerifyResult.SignerName)
+			}
+		})
+	}
+}
@@ -34,6 +34,7 @@ type InstalledArtifact struct {
 	BlobPath     string // content-addressed blob path
 	ExtractDir   string // extracted directory (empty for raw binary)
 	BinaryPath   string // path to the executable
+	BinaryDigest string // digest of the file at BinaryPath
 	Format       ArtifactFormat
 	Size         int64
 	VerifyResult trust.VerifyResult
@@ -114,42 +115,10 @@ func (s *Store) FetchFor(ctx context.Context, name string, ve registry.VersionEn
 }
 
 func (s *Store) fetchArtifact(ctx context.Context, name string, ve registry.VersionEntry, artifact *registry.PlatformArtifact) (*InstalledArtifact, error) {
-	blobPath := s.BlobPath(artifact.Digest)
-
-	// 2. Check cache.
-	if !s.Has(artifact.Digest) {
-		// 3. Download.
-		tmpPath, _, err := s.download(ctx, artifact.URL, artifact.Size)
-		if err != nil {
-			return nil, fmt.Errorf("downloading %s: %w", name, err)
-		}
-		defer func() {
-			// Clean up temp file if it still exists (e.g. on error before rename).
-			_ = os.Remove(tmpPath)
-		}()
-
-		// 4. Verify digest.
-		data, err := os.ReadFile(tmpPath)
-		if err != nil {
-			return nil, fmt.Errorf("reading downloaded artifact: %w", err)
-		}
-
-		match, err := trust.VerifyDigest(data, artifact.Digest)
-		if err != nil {
-			return nil, fmt.Errorf("verifying digest: %w", err)
-		}
-		if !match {
-			return nil, ErrDigestMismatch
-		}
-
-		// 5. Atomic rename to content-addressed path.
-		shardDir := filepath.Dir(blobPath)
-		if err := os.MkdirAll(shardDir, 0o755); err != nil {
-			return nil, fmt.Errorf("creating shard dir: %w", err)
-		}
-		if err := os.Rename(tmpPath, blobPath); err != nil {
-			return nil, fmt.Errorf("storing blob: %w", err)
-		}
+	// 2-5. Download into the cache when missing.
+	blobPath, err := s.ensureBlob(ctx, name, artifact)
+	if err != nil {
+		return nil, err
 	}
 
 	// 6. Trust verification (always run, even on cache hit, for result reporting).
@@ -158,13 +127,10 @@ func (s *Store) fetchArtifact(ctx context.Context, name string, ve registry.Vers
 		return nil, fmt.Errorf("reading cached blob: %w", err)
 	}
 
-	verifyResult := s.verifier.VerifyArtifact(
-		blobData,
-		artifact.Digest,
-		ve.Signature,
-		ve.SignerKeyPEM,
-		ve.APIVersion,
-	)
+	verifyResult, err := s.verify(ctx, ve, artifact, blobData)
+	if err != nil {
+		return nil, err
+	}
 
 	// 7. Detect format and extract/set executable.
 	format, err := DetectFormat(blobPath)
@@ -186,26 +152,72 @@ func (s *Store) fetchArtifact(ctx context.Context, name string, ve registry.Vers
 
 	switch format {
 	case FormatTarGz:
+		// Always extract from the verified blob: an extract dir left by an
+		// earlier fetch may have been modified since.
 		extractDir := s.extractPath(artifact.Digest)
-		if _, err := os.Stat(extractDir); os.IsNotExist(err) {
-			if _, err := ExtractTarGz(blobPath, extractDir); err != nil {
-				return nil, fmt.Errorf("extracting artifact: %w", err)
-			}
+		if _, err := ExtractTarGz(blobPath, extractDir); err != nil {
+			return nil, fmt.Errorf("extracting artifact: %w", err)
 		}
 		installed.ExtractDir = extractDir
 		// Look for a binary with the plugin base name in the extracted directory.
 		installed.BinaryPath = filepath.Join(extractDir, filepath.Base(name))
+		bin, err := os.ReadFile(installed.BinaryPath)
+		if err != nil {
+			return nil, fmt.Errorf("artifact has no executable named %s: %w", filepath.Base(name), err)
+		}
+		installed.BinaryDigest = trust.ComputeDigest(bin).String()
 
 	case FormatRawBinary:
 		if err := SetExecutable(blobPath); err != nil {
 			return nil, fmt.Errorf("setting executable: %w", err)
 		}
 		installed.BinaryPath = blobPath
+		installed.BinaryDigest = trust.ComputeDigest(blobData).String()
 	}
 
 	return installed, nil
 }
 
+// ensureBlob downloads the artifact into its content-addressed path unless it
+// is already cached, verifying the digest before the blob is stored.
+func (s *Store) ensureBlob(ctx context.Context, name string, artifact *registry.PlatformArtifact) (string, error) {
+	blobPath := s.BlobPath(artifact.Digest)
+	if s.Has(artifact.Digest) {
+		return blobPath, nil
+	}
+
+	tmpPath, _, err := s.download(ctx, artifact.URL, artifact.Size)
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", name, err)
+	}
+	defer func() {
+		// Clean up temp file if it still exists (e.g. on error before rename).
+		_ = os.Remove(tmpPath)
+	}()
+
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("reading downloaded artifact: %w", err)
+	}
+
+	match, err := trust.VerifyDigest(data, artifact.Digest)
+	if err != nil {
+		return "", fmt.Errorf("verifying digest: %w", err)
+	}
+	if !match {
+		return "", ErrDigestMismatch
+	}
+
+	// Atomic rename to content-addressed path.
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0o755); err != nil {
+		return "", fmt.Errorf("creating shard dir: %w", err)
+	}
+	if err := os.Rename(tmpPath, blobPath); err != nil {
+		return "", fmt.Errorf("storing blob: %w", err)
+	}
+	return blobPath, nil
+}
+
 // Has reports whether a blob with the given digest exists in the cache.
 func (s *Store) Has(digest string) bool {
 	_, err := os.Stat(s.BlobPath(digest))
@@ -176,10 +176,19 @@ func TestStoreFetchCacheHit(t *testing.T) {
 	ctx := context.Background()
 
 	// First fetch: downloads.
-	_, err := store.FetchFor(ctx, "test/plugin", ve, "linux", "amd64")
+	installed, err := store.FetchFor(ctx, "test/plugin", ve, "linux", "amd64")
 	if err != nil {
 		t.Fatalf("first Fetch: %v", err)
 	}
+	wantBinary := sha256Digest([]byte("cached binary"))
+	if installed.BinaryDigest != wantBinary {
+		t.Errorf("BinaryDigest = %q, want %q", installed.BinaryDigest, wantBinary)
+	}
+
+	// A modified extract dir is replaced from the verified blob.
+	if err := os.WriteFile(installed.BinaryPath, []byte("tampered"), 0o755); err != nil {
+		t.Fatal(err)
+	}
 
 	if requestCount.Load() != 1 {
 		t.Fatalf("first Fetch HTTP requests = %d, want 1", requestCount.Load())
@@ -198,6 +207,9 @@ func TestStoreFetchCacheHit(t *testing.T) {
 	if installed2.PluginName != "test/plugin" {
 		t.Errorf("cached result PluginName = %q", installed2.PluginName)
 	}
+	if data, _ := os.ReadFile(installed2.BinaryPath); string(data) != "cached binary" {
+		t.Errorf("extracted binary = %q, want it re-extracted", data)
+	}
 }
 
 func TestStoreFetchDigestMismatch(t *testing.T) {
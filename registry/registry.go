@@ -77,10 +77,28 @@ func TrackCatalog() []TrackInfo {
 	}
 }
 
+// Kind identifies what a registry entry distributes.
+type Kind string
+
+// Registry entry kinds. Entries without a kind are plugins.
+const (
+	KindPlugin   Kind = "plugin"
+	KindRulePack Kind = "rules"
+)
+
+// PlatformAny is the OS and Arch value of a platform-independent artifact,
+// such as a rule pack.
+const PlatformAny = "any"
+
 // Source represents a registry endpoint that serves plugin indexes.
 type Source struct {
 	Name string `json:"name"` // e.g. "official", "enterprise"
 	URL  string `json:"url"`  // e.g. "https://registry.nox-hq.dev/index.json"
+
+	// TrustedKeys holds the PEM-encoded Ed25519 public keys the registry
+	// signs artifacts with. Several keys may be trusted at once so that a
+	// registry can rotate keys without breaking installs.
+	TrustedKeys []string `json:"trusted_keys,omitempty"`
 }
 
 // Index is the top-level registry index document served by a Source.
@@ -103,6 +121,15 @@ type PluginEntry struct {
 	Maintainers []string `json:"maintainers,omitempty"`
 	License     string   `json:"license,omitempty"`
 	Repository  string   `json:"repository,omitempty"`
+
+	// Kind distinguishes rule packs from plugins. Empty means plugin.
+	Kind Kind `json:"kind,omitempty"`
+}
+
+// IsRulePack reports whether the entry distributes custom rules rather than
+// a plugin binary.
+func (p PluginEntry) IsRulePack() bool {
+	return p.Kind == KindRulePack
 }
 
 // VersionEntry describes a specific version of a plugin.
@@ -129,4 +156,10 @@ type PlatformArtifact struct {
 	URL    string `json:"url"`
 	Size   int64  `json:"size"`
 	Digest string `json:"digest"`
+
+	// Signature is an Ed25519 signature over the artifact bytes. When empty,
+	// SignatureURL may point to a detached signature file (raw or base64).
+	// Either takes precedence over the version-level signature.
+	Signature    []byte `json:"signature,omitempty"`
+	SignatureURL string `json:"signature_url,omitempty"`
 }
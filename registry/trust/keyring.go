@@ -49,6 +49,18 @@ func (kr *Keyring) Find(fingerprint string) *Key {
 	return nil
 }
 
+// verifyAny returns the first key in the keyring whose public key verifies
+// signature over content, or nil if none does.
+func (kr *Keyring) verifyAny(content, signature []byte) *Key {
+	for i := range kr.Keys {
+		valid, err := VerifySignature(content, signature, []byte(kr.Keys[i].PublicKeyPEM))
+		if err == nil && valid {
+			return &kr.Keys[i]
+		}
+	}
+	return nil
+}
+
 // Remove deletes the key with the given fingerprint.
 // Returns an error if the fingerprint is not found.
 func (kr *Keyring) Remove(fingerprint string) error {
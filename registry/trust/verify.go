@@ -37,7 +37,8 @@ func NewVerifier(opts ...VerifierOption) *Verifier {
 
 // VerifyArtifact performs full artifact verification:
 //  1. Verify content digest matches expected
-//  2. If signature provided, verify Ed25519 signature
+//  2. If signature provided, verify Ed25519 signature against signerKeyPEM,
+//     or against each keyring key when signerKeyPEM is empty
 //  3. Classify trust level based on signature and keyring
 //  4. Check API version compatibility
 //  5. Enforce minimum trust level
@@ -74,7 +75,8 @@ func (v *Verifier) VerifyArtifact(
 	}
 
 	// Step 2: Signature verification.
-	if len(signature) > 0 && len(signerKeyPEM) > 0 {
+	switch {
+	case len(signature) > 0 && len(signerKeyPEM) > 0:
 		valid, err := VerifySignature(content, signature, signerKeyPEM)
 		if err != nil {
 			result.Violations = append(result.Violations, TrustViolation{
@@ -106,6 +108,22 @@ func (v *Verifier) VerifyArtifact(
 				}
 			}
 		}
+
+	case len(signature) > 0:
+		// Detached signature without a signer key: accept it only if one of
+		// the keyring keys produced it. Trying every key lets publishers
+		// rotate keys while both old and new keys are trusted.
+		if key := v.keyring.verifyAny(content, signature); key != nil {
+			result.SignatureValid = true
+			result.TrustLevel = TrustVerified
+			result.SignerKey = key.Fingerprint
+			result.SignerName = key.Name
+		} else {
+			result.Violations = append(result.Violations, TrustViolation{
+				Field:   "signature",
+				Message: "signature does not match any trusted key",
+			})
+		}
 	}
 
 	// Step 4: API version check.
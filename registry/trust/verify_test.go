@@ -247,3 +247,39 @@ func TestVerifierDefaults(t *testing.T) {
 		t.Error("unsigned should fail default policy")
 	}
 }
+
+func TestVerifyArtifactDetachedSignatureRotatedKeys(t *testing.T) {
+	_, oldPriv, _, kr := setupVerifyTest(t)
+	newPub, newPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKey("new-signer", ExportKeyPEM(newPub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr.Add(k)
+	v := NewVerifier(WithKeyring(kr), WithTrustPolicy(EnterpriseTrustPolicy()))
+
+	content := []byte("artifact")
+	digest := ComputeDigest(content).String()
+
+	for name, priv := range map[string]ed25519.PrivateKey{"trusted-signer": oldPriv, "new-signer": newPriv} {
+		result := v.VerifyArtifact(content, digest, ed25519.Sign(priv, content), nil, "v1")
+		if !result.OK() || result.TrustLevel != TrustVerified {
+			t.Fatalf("%s: expected verified, got %v %v", name, result.TrustLevel, result.Violations)
+		}
+		if result.SignerName != name {
+			t.Errorf("SignerName = %q, want %q", result.SignerName, name)
+		}
+	}
+
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := v.VerifyArtifact(content, digest, ed25519.Sign(otherPriv, content), nil, "v1")
+	if result.SignatureValid || result.TrustLevel != TrustUnverified || result.OK() {
+		t.Fatalf("expected untrusted detached signature to fail, got %+v", result)
+	}
+}
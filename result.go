@@ -0,0 +1,112 @@
+package nox
+
+import (
+	"maps"
+
+	"github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/findings"
+)
+
+// Result is the outcome of a scan.
+type Result struct {
+	// Findings are all findings of the scan, including suppressed and
+	// baselined ones, ordered by file, line and rule.
+	Findings []Finding `json:"findings"`
+	// SkippedFiles lists files that could not be read and were not
+	// scanned.
+	SkippedFiles []string `json:"skipped_files,omitempty"`
+	// Partial is set when the scan was cancelled before every analyzer
+	// finished.
+	Partial bool `json:"partial,omitempty"`
+	// PolicyPassed reports whether the scan passes the policy in .nox.yaml.
+	// It is true when no policy is configured.
+	PolicyPassed bool `json:"policy_passed"`
+
+	core *core.ScanResult
+}
+
+// Core returns the result in the form of the core packages, for report
+// generation and other internals. It is not covered by the compatibility
+// guarantee of this package.
+func (r *Result) Core() *core.ScanResult {
+	return r.core
+}
+
+// Active returns the findings that are neither suppressed, baselined nor
+// marked as not affecting the project by VEX.
+func (r *Result) Active() []Finding {
+	var out []Finding
+	for _, f := range r.Findings {
+		if f.Active() {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Finding is a single issue reported by a scan.
+type Finding struct {
+	// ID is RuleID:Path:StartLine.
+	ID     string `json:"id"`
+	RuleID string `json:"rule_id"`
+	// Category is the category of the rule: secrets, data, ai, iac, deps,
+	// container, audit, custom or a category declared by a rules file or
+	// the config.
+	Category string `json:"category"`
+	// Severity is critical, high, medium, low or info.
+	Severity string `json:"severity"`
+	// Confidence is high, medium or low.
+	Confidence string `json:"confidence"`
+	// Path is slash-separated and relative to the root of the scanned
+	// file system.
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Message   string `json:"message"`
+	// Fingerprint identifies the finding across scans, independent of its
+	// line number.
+	Fingerprint string `json:"fingerprint"`
+	// Status is empty or "new" for an active finding, or suppressed,
+	// baselined, vex_not_affected, vex_under_investigation or vex_fixed.
+	Status string `json:"status,omitempty"`
+	// SuppressionReason is the reason given for an inactive finding's
+	// nox:ignore comment, override or baseline entry, or its VEX
+	// justification.
+	SuppressionReason string            `json:"suppression_reason,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+}
+
+// Active reports whether f counts towards the policy: it is not
+// suppressed, baselined or resolved by VEX.
+func (f Finding) Active() bool {
+	return findings.Status(f.Status).IsActive()
+}
+
+func newResult(raw *core.ScanResult) *Result {
+	r := &Result{
+		Partial:      raw.Partial,
+		PolicyPassed: raw.PolicyResult == nil || raw.PolicyResult.Pass,
+		core:         raw,
+	}
+	for _, fe := range raw.Errors {
+		r.SkippedFiles = append(r.SkippedFiles, fe.Path)
+	}
+	for _, f := range raw.Findings.Findings() {
+		r.Findings = append(r.Findings, Finding{
+			ID:                f.ID,
+			RuleID:            f.RuleID,
+			Category:          raw.Rules.CategoryOf(&f),
+			Severity:          string(f.Severity),
+			Confidence:        string(f.Confidence),
+			Path:              f.Location.FilePath,
+			StartLine:         f.Location.StartLine,
+			EndLine:           f.Location.EndLine,
+			Message:           f.Message,
+			Fingerprint:       f.Fingerprint,
+			Status:            string(f.Status),
+			SuppressionReason: f.SuppressionReason,
+			Metadata:          maps.Clone(f.Metadata),
+		})
+	}
+	return r
+}
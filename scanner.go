@@ -0,0 +1,143 @@
+package nox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/nox-hq/nox/core"
+)
+
+// Scanner runs nox scans. It is safe for concurrent use; each Scan is
+// independent.
+type Scanner struct {
+	opts         core.ScanOptions
+	mode         scanMode
+	historyDepth int
+}
+
+type scanMode int
+
+const (
+	modeTree scanMode = iota
+	modeStaged
+	modeHistory
+)
+
+// New returns a Scanner configured by opts.
+func New(opts ...Option) *Scanner {
+	s := &Scanner{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// AnalyzerNames returns the names accepted by WithAnalyzers and
+// WithoutAnalyzers.
+func AnalyzerNames() []string {
+	return core.AnalyzerNames()
+}
+
+// Dir returns a file system for the directory dir that Scan reads in
+// place rather than copying.
+func Dir(dir string) fs.FS {
+	return dirFS{FS: os.DirFS(dir), dir: dir}
+}
+
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+// Scan scans fsys and returns the findings. When ctx is done before the
+// scan finishes, Scan returns the partial result, with Partial set,
+// together with an error wrapping ctx.Err().
+func (s *Scanner) Scan(ctx context.Context, fsys fs.FS) (*Result, error) {
+	d, inPlace := fsys.(dirFS)
+	root := d.dir
+	if !inPlace {
+		if s.mode != modeTree || s.opts.Blame || s.opts.Since != "" {
+			return nil, errors.New("staged, history, blame and since scans need a file system from nox.Dir")
+		}
+		tmp, err := copyToTemp(ctx, fsys)
+		if err != nil {
+			return nil, fmt.Errorf("copying files to scan: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		root = tmp
+	}
+
+	var (
+		raw *core.ScanResult
+		err error
+	)
+	switch s.mode {
+	case modeStaged:
+		raw, err = core.RunStagedScanWithOptions(root, s.opts)
+	case modeHistory:
+		raw, err = core.RunHistoryScan(root, &core.HistoryScanOptions{MaxDepth: s.historyDepth, ScanOptions: s.opts})
+	default:
+		raw, err = core.RunScanContext(ctx, root, s.opts)
+	}
+	if raw == nil {
+		return nil, err
+	}
+	if !inPlace && raw.Provenance != nil {
+		raw.Provenance.Target = "."
+	}
+	return newResult(raw), err
+}
+
+// ScanStream scans fsys and calls fn for each finding, in the order Scan
+// would return them. Findings are delivered once they are final, that is
+// after suppressions, the baseline and VEX have been applied. On
+// cancellation the findings of the partial result are delivered before the
+// error is returned.
+func (s *Scanner) ScanStream(ctx context.Context, fsys fs.FS, fn func(Finding)) error {
+	result, err := s.Scan(ctx, fsys)
+	if result != nil {
+		for _, f := range result.Findings {
+			fn(f)
+		}
+	}
+	return err
+}
+
+// copyToTemp copies the directories and regular files of fsys to a new
+// temporary directory, so that the scan pipeline, which reads from disk,
+// can consume it unchanged. The caller removes the directory.
+func copyToTemp(ctx context.Context, fsys fs.FS) (string, error) {
+	tmp, err := os.MkdirTemp("", "nox-fs-*")
+	if err != nil {
+		return "", err
+	}
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		dest := filepath.Join(tmp, filepath.FromSlash(p))
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dest, data, 0o644)
+	})
+	if err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
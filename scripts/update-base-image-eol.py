@@ -0,0 +1,88 @@
+#!/usr/bin/env python3
+"""Refresh the base image end-of-life database from endoflife.date.
+
+Usage: scripts/update-base-image-eol.py [path]
+
+The path defaults to core/analyzers/deps/data/base_image_eol.json. Product
+keys in the file are endoflife.date product slugs; the release cycles of
+each product are replaced with the current API data while the name, images
+and variant fields are kept. Add a product by adding an entry with those
+fields and an empty cycles list, then run this script.
+"""
+
+import datetime
+import json
+import sys
+import urllib.request
+
+DEFAULT_PATH = "core/analyzers/deps/data/base_image_eol.json"
+API = "https://endoflife.date/api/{}.json"
+
+
+def version_key(cycle):
+    """Sort key for cycle names such as "3.9" and "3.10"."""
+    return [int(p) if p.isdigit() else p for p in cycle.split(".")]
+
+
+def fetch_cycles(product):
+    """Fetch the release cycles of a product from endoflife.date."""
+    with urllib.request.urlopen(API.format(product), timeout=30) as resp:
+        entries = json.load(resp)
+
+    cycles = []
+    for entry in entries:
+        cycle = {"cycle": str(entry["cycle"])}
+        codename = entry.get("codename")
+        if codename:
+            # Ubuntu codenames are "Noble Numbat"; image tags use "noble".
+            cycle["codename"] = codename.split()[0].lower()
+        # eol is a date, or a boolean when no date is announced.
+        if isinstance(entry.get("eol"), str):
+            cycle["eol"] = entry["eol"]
+        cycles.append(cycle)
+    cycles.sort(key=lambda c: version_key(c["cycle"]))
+    return cycles
+
+
+def write(path, db):
+    """Write the database with one cycle per line, as checked in."""
+    lines = ["{"]
+    lines.append('  "source": %s,' % json.dumps(db["source"]))
+    lines.append('  "updated": %s,' % json.dumps(db["updated"]))
+    lines.append('  "products": {')
+    keys = list(db["products"])
+    for i, key in enumerate(keys):
+        product = db["products"][key]
+        lines.append("    %s: {" % json.dumps(key))
+        lines.append('      "name": %s,' % json.dumps(product["name"]))
+        lines.append('      "images": %s,' % json.dumps(product["images"]))
+        if product.get("variant"):
+            lines.append('      "variant": %s,' % json.dumps(product["variant"]))
+        lines.append('      "cycles": [')
+        cycles = product["cycles"]
+        for j, cycle in enumerate(cycles):
+            sep = "," if j < len(cycles) - 1 else ""
+            lines.append("        %s%s" % (json.dumps(cycle), sep))
+        lines.append("      ]")
+        lines.append("    }" + ("," if i < len(keys) - 1 else ""))
+    lines.append("  }")
+    lines.append("}")
+    with open(path, "w") as f:
+        f.write("\n".join(lines) + "\n")
+
+
+def main():
+    path = sys.argv[1] if len(sys.argv) > 1 else DEFAULT_PATH
+    with open(path) as f:
+        db = json.load(f)
+
+    for key, product in db["products"].items():
+        product["cycles"] = fetch_cycles(key)
+        print("%s: %d cycles" % (key, len(product["cycles"])))
+
+    db["updated"] = datetime.date.today().isoformat()
+    write(path, db)
+
+
+if __name__ == "__main__":
+    main()
@@ -24,6 +24,10 @@ type dashboardData struct {
 	Packages     []dashboardPackage `json:"packages"`
 	AIComponents []dashboardAIComp  `json:"ai_components"`
 	Baseline     *dashboardBaseline `json:"baseline,omitempty"`
+	// SeverityLabels and SeverityWeights come from the severity_mapping
+	// config and replace the severity names and grade weights shown.
+	SeverityLabels  map[findings.Severity]string `json:"severity_labels,omitempty"`
+	SeverityWeights map[findings.Severity]int    `json:"severity_weights,omitempty"`
 }
 
 type dashboardPackage struct {
@@ -72,9 +76,16 @@ func buildDashboardData(result *nox.ScanResult, version, basePath string) dashbo
 	total := len(result.Findings.Findings())
 
 	data := dashboardData{
-		Version:    version,
-		Findings:   active,
-		Suppressed: total - len(active),
+		Version:         version,
+		Findings:        active,
+		Suppressed:      total - len(active),
+		SeverityWeights: result.SeverityMapping.Weights(),
+	}
+	for sev, l := range result.SeverityMapping {
+		if data.SeverityLabels == nil {
+			data.SeverityLabels = make(map[findings.Severity]string)
+		}
+		data.SeverityLabels[sev] = l.Label
 	}
 
 	// Generate timestamp from badge score (reuses badge logic).
@@ -5,11 +5,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -24,6 +28,7 @@ import (
 	"github.com/nox-hq/nox/core/diff"
 	"github.com/nox-hq/nox/core/findings"
 	"github.com/nox-hq/nox/core/git"
+	"github.com/nox-hq/nox/core/metrics"
 	"github.com/nox-hq/nox/core/report"
 	"github.com/nox-hq/nox/core/report/sarif"
 	"github.com/nox-hq/nox/core/report/sbom"
@@ -47,6 +52,17 @@ type Server struct {
 
 	host    *plugin.Host      // optional plugin host
 	aliases map[string]string // tool name aliases
+	logger  *slog.Logger      // structured logger for tool calls and errors
+	offline bool              // scan without network lookups
+
+	metrics       *metrics.Registry // optional; updated after each scan
+	metricsLabels map[string]string // labels of the scan metrics
+
+	resourceURIs map[string]bool // URIs of registered resources
+
+	subMu  sync.Mutex
+	subs   map[string]bool  // resource URIs the client subscribed to
+	notify func(uri string) // sends resources/updated; nil until serving
 }
 
 // ServerOption is a functional option for configuring a Server.
@@ -64,6 +80,25 @@ func WithAliases(aliases map[string]string) ServerOption {
 	return func(s *Server) { s.aliases = aliases }
 }
 
+// WithLogger sets the structured logger used for tool call and transport
+// logging. Defaults to slog.Default().
+func WithLogger(l *slog.Logger) ServerOption {
+	return func(s *Server) { s.logger = l }
+}
+
+// WithOffline makes the scan and diff tools skip every network lookup, as
+// nox --offline does.
+func WithOffline() ServerOption {
+	return func(s *Server) { s.offline = true }
+}
+
+// WithMetrics records the metrics of each scan in reg with labels. Unless
+// labels sets repo, it is derived from the scanned path as by
+// metrics.RepoLabel.
+func WithMetrics(reg *metrics.Registry, labels map[string]string) ServerOption {
+	return func(s *Server) { s.metrics, s.metricsLabels = reg, labels }
+}
+
 // New creates a new MCP server. If allowedPaths is empty, any path is allowed.
 func New(version string, allowedPaths []string, opts ...ServerOption) *Server {
 	// Resolve allowed paths to absolute for consistent comparison.
@@ -77,6 +112,7 @@ func New(version string, allowedPaths []string, opts ...ServerOption) *Server {
 	s := &Server{
 		version:      version,
 		allowedPaths: resolved,
+		logger:       slog.Default(),
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -84,6 +120,25 @@ func New(version string, allowedPaths []string, opts ...ServerOption) *Server {
 	return s
 }
 
+// logToolCalls is a tool handler middleware that records each tool
+// invocation with its name, duration, and outcome.
+func (s *Server) logToolCalls(next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, req)
+		attrs := []any{"tool", req.Params.Name, "duration", time.Since(start)}
+		switch {
+		case err != nil:
+			s.logger.Warn("tool call failed", append(attrs, "error", err)...)
+		case result != nil && result.IsError:
+			s.logger.Info("tool call returned error", attrs...)
+		default:
+			s.logger.Debug("tool call finished", attrs...)
+		}
+		return result, err
+	}
+}
+
 // Serve starts the MCP server on stdio and blocks until the client disconnects.
 func (s *Server) Serve() error {
 	srv := mcpserver.NewMCPServer(
@@ -91,13 +146,37 @@ func (s *Server) Serve() error {
 		s.version,
 		mcpserver.WithRecovery(),
 		mcpserver.WithToolCapabilities(false),
-		mcpserver.WithResourceCapabilities(false, false),
+		mcpserver.WithResourceCapabilities(true, false),
+		mcpserver.WithToolHandlerMiddleware(s.logToolCalls),
 	)
 
 	s.registerTools(srv)
 	s.registerResources(srv)
 
-	return mcpserver.ServeStdio(srv)
+	s.subMu.Lock()
+	s.notify = func(uri string) {
+		srv.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+	}
+	s.subMu.Unlock()
+
+	stopWatch, err := s.watchWorkspaces()
+	if err != nil {
+		// Subscriptions still work for scan results; only file-backed
+		// resources lose change notifications.
+		s.logger.Warn("watching workspace files", "error", err)
+	} else {
+		defer stopWatch()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	stdio := mcpserver.NewStdioServer(srv)
+	stdio.SetErrorLogger(slog.NewLogLogger(s.logger.Handler(), slog.LevelError))
+
+	s.logger.Info("MCP server started", "version", s.version, "allowed_paths", len(s.allowedPaths))
+	out := &syncWriter{w: os.Stdout}
+	return stdio.Listen(ctx, newSubscriptionReader(s, os.Stdin, out), out)
 }
 
 func (s *Server) registerTools(srv *mcpserver.MCPServer) {
@@ -375,7 +454,7 @@ func (s *Server) registerPluginTools(srv *mcpserver.MCPServer) {
 }
 
 func (s *Server) registerResources(srv *mcpserver.MCPServer) {
-	srv.AddResource(
+	s.addResource(srv,
 		mcp.NewResource("nox://findings", "Findings JSON",
 			mcp.WithResourceDescription("Security findings in nox JSON format"),
 			mcp.WithMIMEType("application/json"),
@@ -383,7 +462,7 @@ func (s *Server) registerResources(srv *mcpserver.MCPServer) {
 		s.handleResourceFindings,
 	)
 
-	srv.AddResource(
+	s.addResource(srv,
 		mcp.NewResource("nox://sarif", "SARIF Report",
 			mcp.WithResourceDescription("Security findings in SARIF 2.1.0 format"),
 			mcp.WithMIMEType("application/json"),
@@ -391,7 +470,7 @@ func (s *Server) registerResources(srv *mcpserver.MCPServer) {
 		s.handleResourceSARIF,
 	)
 
-	srv.AddResource(
+	s.addResource(srv,
 		mcp.NewResource("nox://sbom/cdx", "CycloneDX SBOM",
 			mcp.WithResourceDescription("Software bill of materials in CycloneDX format"),
 			mcp.WithMIMEType("application/json"),
@@ -399,7 +478,7 @@ func (s *Server) registerResources(srv *mcpserver.MCPServer) {
 		s.handleResourceCDX,
 	)
 
-	srv.AddResource(
+	s.addResource(srv,
 		mcp.NewResource("nox://sbom/spdx", "SPDX SBOM",
 			mcp.WithResourceDescription("Software bill of materials in SPDX format"),
 			mcp.WithMIMEType("application/json"),
@@ -407,7 +486,7 @@ func (s *Server) registerResources(srv *mcpserver.MCPServer) {
 		s.handleResourceSPDX,
 	)
 
-	srv.AddResource(
+	s.addResource(srv,
 		mcp.NewResource("nox://ai-inventory", "AI Inventory",
 			mcp.WithResourceDescription("Inventory of AI components discovered during scan"),
 			mcp.WithMIMEType("application/json"),
@@ -415,21 +494,49 @@ func (s *Server) registerResources(srv *mcpserver.MCPServer) {
 		s.handleResourceAIInventory,
 	)
 
-	srv.AddResource(
+	s.addResource(srv,
 		mcp.NewResource("nox://rules", "Security Rules",
-			mcp.WithResourceDescription("All available security rules with metadata"),
+			mcp.WithResourceDescription("Rule catalog with descriptions, remediation, and compliance metadata"),
 			mcp.WithMIMEType("application/json"),
 		),
 		s.handleResourceRules,
 	)
 
-	srv.AddResource(
+	s.addResource(srv,
 		mcp.NewResource("nox://dashboard", "Security Dashboard",
 			mcp.WithResourceDescription("Interactive HTML security dashboard with finding summary, rule breakdown, and dependency overview"),
 			mcp.WithMIMEType("text/html"),
 		),
 		s.handleResourceDashboard,
 	)
+
+	s.addResource(srv,
+		mcp.NewResource("nox://findings/latest", "Latest Findings Reports",
+			mcp.WithResourceDescription("Most recent findings.json written by nox scan in each allowed workspace, found through its output settings and read from disk"),
+			mcp.WithMIMEType("application/json"),
+		),
+		s.handleResourceLatestFindings,
+	)
+
+	s.addResource(srv,
+		mcp.NewResource("nox://baseline", "Baselines",
+			mcp.WithResourceDescription("Baseline file (.nox/baseline.json) of each allowed workspace"),
+			mcp.WithMIMEType("application/json"),
+		),
+		s.handleResourceBaseline,
+	)
+}
+
+// addResource registers a resource and remembers its URI so clients can
+// subscribe to it.
+func (s *Server) addResource(srv *mcpserver.MCPServer, resource mcp.Resource, handler mcpserver.ResourceHandlerFunc) {
+	s.subMu.Lock()
+	if s.resourceURIs == nil {
+		s.resourceURIs = make(map[string]bool)
+	}
+	s.resourceURIs[resource.URI] = true
+	s.subMu.Unlock()
+	srv.AddResource(resource, handler)
 }
 
 // isPathAllowed checks if the given path is under one of the allowed workspace roots.
@@ -458,7 +565,7 @@ func (s *Server) isPathAllowed(path string) error {
 	return fmt.Errorf("path %q is outside allowed workspaces", path)
 }
 
-func (s *Server) handleScan(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *Server) handleScan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	path, err := request.RequireString("path")
 	if err != nil {
 		return mcp.NewToolResultError("missing required argument: path"), nil
@@ -468,7 +575,7 @@ func (s *Server) handleScan(_ context.Context, request mcp.CallToolRequest) (*mc
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	result, err := nox.RunScan(path)
+	result, err := nox.RunScanContext(ctx, path, nox.ScanOptions{Offline: s.offline})
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("scan failed: %v", err)), nil
 	}
@@ -478,6 +585,17 @@ func (s *Server) handleScan(_ context.Context, request mcp.CallToolRequest) (*mc
 	s.cache = result
 	s.scanBasePath = path
 	s.mu.Unlock()
+	s.resourceUpdated(scanResourceURIs...)
+	if s.metrics != nil {
+		labels := maps.Clone(s.metricsLabels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		if labels["repo"] == "" {
+			labels["repo"] = metrics.RepoLabel(path)
+		}
+		s.metrics.Set(metrics.Snapshot{Labels: labels, Result: result, Time: time.Now()})
+	}
 
 	findingCount := len(result.Findings.Findings())
 	pkgCount := len(result.Inventory.Packages())
@@ -485,6 +603,9 @@ func (s *Server) handleScan(_ context.Context, request mcp.CallToolRequest) (*mc
 
 	summary := fmt.Sprintf("Scan complete: %d findings, %d dependencies, %d AI components",
 		findingCount, pkgCount, aiCount)
+	if n := len(result.Errors); n > 0 {
+		summary += fmt.Sprintf(" (%d unreadable files skipped)", n)
+	}
 
 	return mcp.NewToolResultText(summary), nil
 }
@@ -509,6 +630,7 @@ func (s *Server) handleGetFindings(_ context.Context, request mcp.CallToolReques
 		data, err = r.Generate(cache.Findings)
 	default:
 		r := report.NewJSONReporter(s.version)
+		r.Errors = cache.Errors
 		data, err = r.Generate(cache.Findings)
 	}
 
@@ -519,6 +641,18 @@ func (s *Server) handleGetFindings(_ context.Context, request mcp.CallToolReques
 	return mcp.NewToolResultText(truncate(string(data))), nil
 }
 
+// sbomProject describes the project of the last scan for an SBOM, or
+// returns nil when no scan has run.
+func (s *Server) sbomProject() *sbom.Project {
+	s.mu.RLock()
+	base := s.scanBasePath
+	s.mu.RUnlock()
+	if base == "" {
+		return nil
+	}
+	return sbom.DescribeProject(base)
+}
+
 func (s *Server) handleGetSBOM(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	s.mu.RLock()
 	cache := s.cache
@@ -536,9 +670,11 @@ func (s *Server) handleGetSBOM(_ context.Context, request mcp.CallToolRequest) (
 	switch format {
 	case "spdx":
 		r := sbom.NewSPDXReporter(s.version)
+		r.Project = s.sbomProject()
 		data, err = r.Generate(cache.Inventory)
 	default:
 		r := sbom.NewCycloneDXReporter(s.version)
+		r.Project = s.sbomProject()
 		data, err = r.Generate(cache.Inventory)
 	}
 
@@ -677,10 +813,14 @@ func (s *Server) handleListFindings(_ context.Context, request mcp.CallToolReque
 	var filter detail.Filter
 	if sev := request.GetString("severity", ""); sev != "" {
 		for _, s := range strings.Split(sev, ",") {
-			s = strings.TrimSpace(s)
-			if s != "" {
-				filter.Severities = append(filter.Severities, findings.Severity(s))
+			if strings.TrimSpace(s) == "" {
+				continue
 			}
+			parsed, err := findings.ParseSeverity(s)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			filter.Severities = append(filter.Severities, parsed)
 		}
 	}
 	filter.RulePattern = request.GetString("rule", "")
@@ -733,6 +873,7 @@ func (s *Server) handleResourceFindings(_ context.Context, request mcp.ReadResou
 	}
 
 	r := report.NewJSONReporter(s.version)
+	r.Errors = cache.Errors
 	data, err := r.Generate(cache.Findings)
 	if err != nil {
 		return nil, fmt.Errorf("generating findings JSON: %w", err)
@@ -781,6 +922,7 @@ func (s *Server) handleResourceCDX(_ context.Context, request mcp.ReadResourceRe
 	}
 
 	r := sbom.NewCycloneDXReporter(s.version)
+	r.Project = s.sbomProject()
 	data, err := r.Generate(cache.Inventory)
 	if err != nil {
 		return nil, fmt.Errorf("generating CycloneDX SBOM: %w", err)
@@ -805,6 +947,7 @@ func (s *Server) handleResourceSPDX(_ context.Context, request mcp.ReadResourceR
 	}
 
 	r := sbom.NewSPDXReporter(s.version)
+	r.Project = s.sbomProject()
 	data, err := r.Generate(cache.Inventory)
 	if err != nil {
 		return nil, fmt.Errorf("generating SPDX SBOM: %w", err)
@@ -963,8 +1106,9 @@ func (s *Server) handleDiff(_ context.Context, request mcp.CallToolRequest) (*mc
 	head := request.GetString("head", "HEAD")
 
 	result, err := diff.Run(path, diff.Options{
-		Base: base,
-		Head: head,
+		Base:    base,
+		Head:    head,
+		Offline: s.offline,
 	})
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("diff failed: %v", err)), nil
@@ -992,7 +1136,9 @@ func (s *Server) handleBadge(_ context.Context, request mcp.CallToolRequest) (*m
 	label := request.GetString("label", "nox")
 	ff := cache.Findings.ActiveFindings()
 
-	result := badge.GenerateFromFindings(ff, label)
+	result := badge.GenerateCategoryWeighted(ff, label, cache.SeverityMapping.Weights(), func(f *findings.Finding) float64 {
+		return cache.Categories.Weight(cache.Rules.CategoryOf(f))
+	})
 
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -1100,7 +1246,7 @@ func (s *Server) handleAnnotate(_ context.Context, _ mcp.CallToolRequest) (*mcp.
 	}
 
 	ff := cache.Findings.ActiveFindings()
-	payload := annotate.BuildReviewPayload(ff)
+	payload := annotate.BuildReviewPayload(ff, annotate.SummaryOptions{})
 	if payload == nil {
 		return mcp.NewToolResultText(`{"message":"no findings to annotate"}`), nil
 	}
@@ -1132,6 +1278,104 @@ func (s *Server) handleResourceRules(_ context.Context, request mcp.ReadResource
 	}, nil
 }
 
+// Workspace file resource handlers.
+
+func (s *Server) handleResourceLatestFindings(_ context.Context, _ mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	var encrypted []string
+	contents, err := s.readWorkspaceFiles(func(root string) string {
+		path := latestFindingsPath(root)
+		if strings.HasSuffix(path, report.EncryptedSuffix) {
+			encrypted = append(encrypted, path)
+			return ""
+		}
+		return path
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) == 0 {
+		if len(encrypted) > 0 {
+			return nil, fmt.Errorf("no findings available: %s is encrypted and the server cannot read it", strings.Join(encrypted, ", "))
+		}
+		return nil, fmt.Errorf("no findings available in allowed workspaces — run nox scan first")
+	}
+	return contents, nil
+}
+
+func (s *Server) handleResourceBaseline(_ context.Context, _ mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	contents, err := s.readWorkspaceFiles(baseline.DefaultPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) == 0 {
+		return nil, fmt.Errorf("no baseline found in allowed workspaces")
+	}
+	return contents, nil
+}
+
+// latestFindingsPath returns the findings.json that nox scan last wrote
+// for a workspace, found through the output settings of its .nox.yaml, or
+// "" if there is none.
+func latestFindingsPath(root string) string {
+	return nox.LatestFindingsReport(root)
+}
+
+// workspaces returns the workspace roots exposed through file-backed
+// resources: the allowed paths, or the working directory when any path is
+// allowed.
+func (s *Server) workspaces() []string {
+	if len(s.allowedPaths) > 0 {
+		return s.allowedPaths
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	return []string{cwd}
+}
+
+// readWorkspaceFiles reads the file pathFor(root) from every workspace,
+// skipping workspaces where it is "" or does not exist. Files that resolve
+// outside their workspace (e.g. through a symlink) are refused.
+func (s *Server) readWorkspaceFiles(pathFor func(root string) string) ([]mcp.ResourceContents, error) {
+	var contents []mcp.ResourceContents
+	for _, root := range s.workspaces() {
+		path := pathFor(root)
+		if path == "" {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("resolving %s: %w", path, err)
+		}
+		if !withinRoot(root, resolved) {
+			return nil, fmt.Errorf("%s resolves outside workspace %s", path, root)
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		contents = append(contents, mcp.TextResourceContents{
+			URI:      "file://" + filepath.ToSlash(path),
+			MIMEType: "application/json",
+			Text:     truncate(string(data)),
+		})
+	}
+	return contents, nil
+}
+
+// withinRoot reports whether the symlink-resolved path lies under root.
+func withinRoot(root, resolved string) bool {
+	if r, err := filepath.EvalSymlinks(root); err == nil {
+		root = r
+	}
+	rel, err := filepath.Rel(root, resolved)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // Dashboard resource handler.
 
 func (s *Server) handleResourceDashboard(_ context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
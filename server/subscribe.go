@@ -0,0 +1,294 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mark3labs/mcp-go/mcp"
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/baseline"
+	"github.com/nox-hq/nox/core/report"
+)
+
+// scanResourceURIs lists the resources derived from the cached scan result,
+// which change whenever the scan tool completes.
+var scanResourceURIs = []string{
+	"nox://findings",
+	"nox://sarif",
+	"nox://sbom/cdx",
+	"nox://sbom/spdx",
+	"nox://ai-inventory",
+	"nox://dashboard",
+}
+
+// JSON-RPC methods for resource subscriptions. The MCP library does not
+// route these, so subscriptionReader answers them before they reach it.
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+)
+
+// subscribe records a client subscription. It reports false if uri is not
+// a registered resource.
+func (s *Server) subscribe(uri string) bool {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	if !s.resourceURIs[uri] {
+		return false
+	}
+	if s.subs == nil {
+		s.subs = make(map[string]bool)
+	}
+	s.subs[uri] = true
+	return true
+}
+
+// unsubscribe removes a client subscription.
+func (s *Server) unsubscribe(uri string) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	delete(s.subs, uri)
+}
+
+// resourceUpdated notifies the client about each uri it has subscribed to.
+func (s *Server) resourceUpdated(uris ...string) {
+	s.subMu.Lock()
+	notify := s.notify
+	var pending []string
+	for _, uri := range uris {
+		if s.subs[uri] {
+			pending = append(pending, uri)
+		}
+	}
+	s.subMu.Unlock()
+
+	if notify == nil {
+		return
+	}
+	for _, uri := range pending {
+		notify(uri)
+	}
+}
+
+// syncWriter serialises writes so responses written by subscriptionReader
+// never interleave with messages written by the MCP library.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// subscriptionReader passes newline-delimited client messages through to
+// the MCP server, answering resources/subscribe and resources/unsubscribe
+// requests itself.
+type subscriptionReader struct {
+	s   *Server
+	in  *bufio.Reader
+	out io.Writer
+	buf []byte
+}
+
+func newSubscriptionReader(s *Server, in io.Reader, out io.Writer) *subscriptionReader {
+	return &subscriptionReader{s: s, in: bufio.NewReader(in), out: out}
+}
+
+func (r *subscriptionReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		line, err := r.in.ReadBytes('\n')
+		if len(line) > 0 && !r.intercept(line) {
+			r.buf = line
+		}
+		if err != nil {
+			if len(r.buf) > 0 {
+				break
+			}
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// intercept handles line if it is a subscription request and reports
+// whether it did.
+func (r *subscriptionReader) intercept(line []byte) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(line, &msg); err != nil || len(msg.ID) == 0 {
+		return false
+	}
+
+	resp := map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": msg.ID}
+	switch msg.Method {
+	case methodResourcesSubscribe:
+		if !r.s.subscribe(msg.Params.URI) {
+			resp["error"] = map[string]any{
+				"code":    mcp.INVALID_PARAMS,
+				"message": "unknown resource: " + msg.Params.URI,
+			}
+		} else {
+			resp["result"] = struct{}{}
+		}
+	case methodResourcesUnsubscribe:
+		r.s.unsubscribe(msg.Params.URI)
+		resp["result"] = struct{}{}
+	default:
+		return false
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return true
+	}
+	_, _ = r.out.Write(append(data, '\n'))
+	return true
+}
+
+// watchWorkspaces watches the files behind nox://findings/latest and
+// nox://baseline in every workspace and notifies subscribers when they
+// change. The findings report is watched where the output settings of the
+// workspace's .nox.yaml, read when the watcher starts, put it. The returned
+// function stops the watcher.
+func (s *Server) watchWorkspaces() (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	var workspaces []watchedWorkspace
+	for _, root := range s.workspaces() {
+		ws := watchedWorkspace{root: root}
+		if dir, pattern, err := nox.FindingsReportLocation(root); err != nil {
+			s.logger.Warn("reading workspace output settings", "path", root, "error", err)
+		} else {
+			ws.reportDir, ws.reportPattern = dir, pattern
+		}
+		workspaces = append(workspaces, ws)
+		if err := watcher.Add(root); err != nil {
+			s.logger.Warn("watching workspace", "path", root, "error", err)
+		}
+		// These directories may not exist yet; they are added when created.
+		for _, dir := range ws.dirs() {
+			_ = watcher.Add(dir)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				s.handleWorkspaceEvent(watcher, workspaces, event)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				s.logger.Warn("workspace watch error", "error", err)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		watcher.Close()
+	}, nil
+}
+
+// watchedWorkspace is a workspace root and where nox scan writes its
+// findings report: reportPattern matches the report's path relative to
+// reportDir. Both are empty when the output settings could not be read.
+type watchedWorkspace struct {
+	root                     string
+	reportDir, reportPattern string
+}
+
+// dirs returns the directories besides the root that hold the files
+// behind the workspace's resources.
+func (w watchedWorkspace) dirs() []string {
+	dirs := []string{filepath.Dir(baseline.DefaultPath(w.root))}
+	if w.reportDir == "" {
+		return dirs
+	}
+	dirs = append(dirs, w.reportDir)
+	// A name with a directory part, such as reports/{date}.json, is
+	// written below reportDir.
+	if sub := filepath.Dir(w.reportPattern); sub != "." && !strings.ContainsAny(sub, `*?[`) {
+		dirs = append(dirs, filepath.Join(w.reportDir, sub))
+	}
+	return dirs
+}
+
+// isFindingsReport reports whether path may be the workspace's findings
+// report, encrypted or not, or the link to its latest timestamped run.
+func (w watchedWorkspace) isFindingsReport(path string) bool {
+	if w.reportDir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(w.reportDir, path)
+	if err != nil {
+		return false
+	}
+	if rel == report.LatestLink {
+		return true
+	}
+	for _, p := range []string{w.reportPattern, w.reportPattern + report.EncryptedSuffix} {
+		if ok, _ := filepath.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// handleWorkspaceEvent maps a file system event to the resource it affects.
+// A created directory that holds resource files is watched from then on.
+func (s *Server) handleWorkspaceEvent(watcher *fsnotify.Watcher, workspaces []watchedWorkspace, event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+	for _, ws := range workspaces {
+		blPath := baseline.DefaultPath(ws.root)
+		if event.Name == blPath {
+			s.resourceUpdated("nox://baseline")
+		}
+		if ws.isFindingsReport(event.Name) {
+			s.resourceUpdated("nox://findings/latest")
+		}
+		if !event.Has(fsnotify.Create) || !slices.Contains(ws.dirs(), event.Name) {
+			continue
+		}
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			_ = watcher.Add(event.Name)
+		}
+		// Files written before the watch was added raise no event.
+		if event.Name == filepath.Dir(blPath) {
+			if _, err := os.Stat(blPath); err == nil {
+				s.resourceUpdated("nox://baseline")
+			}
+		} else if latestFindingsPath(ws.root) != "" {
+			s.resourceUpdated("nox://findings/latest")
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// newSubscribableServer returns a server with resources registered and
+// notifications delivered to the returned channel.
+func newSubscribableServer(t *testing.T, allowed []string) (*Server, chan string) {
+	t.Helper()
+	s := New("0.1.0", allowed)
+	s.registerResources(mcpserver.NewMCPServer("nox", "0.1.0"))
+
+	notified := make(chan string, 16)
+	s.notify = func(uri string) { notified <- uri }
+	return s, notified
+}
+
+func TestSubscriptionReader_InterceptsSubscribe(t *testing.T) {
+	s, _ := newSubscribableServer(t, nil)
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"nox://findings"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/subscribe","params":{"uri":"nox://unknown"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/unsubscribe","params":{"uri":"nox://sarif"}}`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	r := newSubscriptionReader(s, strings.NewReader(input), &out)
+	passed, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(passed)); got != `{"jsonrpc":"2.0","id":2,"method":"tools/list"}` {
+		t.Fatalf("passed through %q, want only the tools/list request", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 responses, got %d: %s", len(lines), out.String())
+	}
+	var resp struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 3 || resp.Error == nil || resp.Error.Code != mcp.INVALID_PARAMS {
+		t.Fatalf("expected invalid params error for unknown resource, got %s", lines[1])
+	}
+
+	if !s.subs["nox://findings"] {
+		t.Fatal("expected subscription to nox://findings")
+	}
+}
+
+func TestScanNotifiesSubscribers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+
+	s, notified := newSubscribableServer(t, nil)
+	if !s.subscribe("nox://findings") {
+		t.Fatal("subscribe failed")
+	}
+
+	req := makeToolRequest(t, "scan", map[string]any{"path": dir})
+	if _, err := s.handleScan(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case uri := <-notified:
+		if uri != "nox://findings" {
+			t.Fatalf("notified %q, want nox://findings", uri)
+		}
+	default:
+		t.Fatal("expected a resources/updated notification after scan")
+	}
+	select {
+	case uri := <-notified:
+		t.Fatalf("unexpected notification for unsubscribed %q", uri)
+	default:
+	}
+}
+
+// noxReport is a minimal findings.json as nox scan writes it.
+const noxReport = `{"meta":{"tool_name":"nox"},"findings":[]}`
+
+func TestResourceLatestFindingsAndBaseline(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := newSubscribableServer(t, []string{dir})
+
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = "nox://findings/latest"
+	if _, err := s.handleResourceLatestFindings(context.Background(), req); err == nil {
+		t.Fatal("expected error when no findings.json exists")
+	}
+
+	writeFile(t, dir, "findings.json", noxReport)
+	contents, err := s.handleResourceLatestFindings(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tc := contents[0].(mcp.TextResourceContents)
+	if tc.Text != noxReport || !strings.HasSuffix(tc.URI, "/findings.json") {
+		t.Fatalf("unexpected contents: %+v", tc)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".nox"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, ".nox/baseline.json", `{"entries":[]}`)
+	req.Params.URI = "nox://baseline"
+	contents, err = s.handleResourceBaseline(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tc := contents[0].(mcp.TextResourceContents); tc.Text != `{"entries":[]}` {
+		t.Fatalf("unexpected baseline contents: %s", tc.Text)
+	}
+}
+
+func TestResourceLatestFindings_RejectsSymlinkEscape(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "findings.json")
+	if err := os.WriteFile(outside, []byte(noxReport), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "findings.json")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	s, _ := newSubscribableServer(t, []string{dir})
+	_, err := s.handleResourceLatestFindings(context.Background(), mcp.ReadResourceRequest{})
+	if err == nil || !strings.Contains(err.Error(), "outside workspace") {
+		t.Fatalf("expected symlink outside the workspace to be refused, got %v", err)
+	}
+}
+
+func TestResourceLatestFindings_OutputSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		files  map[string]string
+	}{
+		{"output directory", "output:\n  directory: reports\n", map[string]string{"reports/findings.json": noxReport}},
+		{"timestamped run", "output:\n  timestamped: true\n", map[string]string{"nox-reports/20250102-150405/findings.json": noxReport}},
+		{"name template", "output:\n  files:\n    json: \"{target}-{date}.json\"\n", map[string]string{"app-2025-01-02.json": noxReport}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, ".nox.yaml", tt.config)
+			for name, content := range tt.files {
+				if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				writeFile(t, dir, name, content)
+			}
+			if tt.name == "timestamped run" {
+				if err := os.Symlink("20250102-150405", filepath.Join(dir, "nox-reports", "latest")); err != nil {
+					t.Skipf("symlinks unsupported: %v", err)
+				}
+			}
+
+			s, _ := newSubscribableServer(t, []string{dir})
+			contents, err := s.handleResourceLatestFindings(context.Background(), mcp.ReadResourceRequest{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc := contents[0].(mcp.TextResourceContents); tc.Text != noxReport {
+				t.Fatalf("unexpected contents: %+v", tc)
+			}
+		})
+	}
+}
+
+func TestResourceLatestFindings_Encrypted(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "findings.json.age", "age-encryption.org/v1\n")
+
+	s, _ := newSubscribableServer(t, []string{dir})
+	_, err := s.handleResourceLatestFindings(context.Background(), mcp.ReadResourceRequest{})
+	if err == nil || !strings.Contains(err.Error(), "no findings available") || !strings.Contains(err.Error(), "encrypted") {
+		t.Fatalf("expected an encrypted report error, got %v", err)
+	}
+}
+
+func TestWatchWorkspacesNotifiesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	s, notified := newSubscribableServer(t, []string{dir})
+	s.subscribe("nox://findings/latest")
+	s.subscribe("nox://baseline")
+
+	stop, err := s.watchWorkspaces()
+	if err != nil {
+		t.Skipf("file watching unavailable: %v", err)
+	}
+	defer stop()
+
+	wait := func(want string) {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case uri := <-notified:
+				if uri == want {
+					return
+				}
+			case <-deadline:
+				t.Fatalf("timed out waiting for %s notification", want)
+			}
+		}
+	}
+
+	writeFile(t, dir, "findings.json", noxReport)
+	wait("nox://findings/latest")
+
+	if err := os.MkdirAll(filepath.Join(dir, ".nox"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Give the watcher a moment to pick up the new directory.
+	time.Sleep(100 * time.Millisecond)
+	writeFile(t, dir, ".nox/baseline.json", `{"entries":[]}`)
+	wait("nox://baseline")
+}
+
+func TestWatchWorkspacesNotifiesOnOutputDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".nox.yaml", "output:\n  directory: reports\n")
+	s, notified := newSubscribableServer(t, []string{dir})
+	s.subscribe("nox://findings/latest")
+
+	stop, err := s.watchWorkspaces()
+	if err != nil {
+		t.Skipf("file watching unavailable: %v", err)
+	}
+	defer stop()
+
+	// The output directory does not exist until the first scan.
+	if err := os.MkdirAll(filepath.Join(dir, "reports"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "reports/findings.json", noxReport)
+	select {
+	case uri := <-notified:
+		if uri != "nox://findings/latest" {
+			t.Fatalf("unexpected notification for %s", uri)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for nox://findings/latest notification")
+	}
+}
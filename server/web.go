@@ -0,0 +1,377 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	nox "github.com/nox-hq/nox/core"
+	"github.com/nox-hq/nox/core/baseline"
+	"github.com/nox-hq/nox/core/detail"
+	"github.com/nox-hq/nox/core/findings"
+	"github.com/nox-hq/nox/core/offline"
+)
+
+//go:embed web
+var webFS embed.FS
+
+// maxWebFileSize is the largest file the web UI's file viewer returns.
+const maxWebFileSize = 2 << 20
+
+// WebConfig configures the web UI served by WebUI.
+type WebConfig struct {
+	// Dir is the scanned directory. Finding paths are relative to it, and
+	// its .nox.yaml gives the baseline path and severity labels.
+	Dir     string
+	Version string
+	// Report is the findings.json to show at start, or "" to scan Dir.
+	Report string
+	// Scan scans Dir, at start when Report is "" and for the rescan
+	// button.
+	Scan func(ctx context.Context) (*nox.ScanResult, error)
+	// ReadOnly turns off the baseline and rescan actions, for sharing the
+	// UI on a network.
+	ReadOnly bool
+	// LoopbackOnly refuses requests whose Host is not localhost or a
+	// loopback address, so that other sites cannot reach the UI through
+	// DNS rebinding. Set it when the UI listens on a loopback address.
+	LoopbackOnly bool
+}
+
+// WebUI serves a browser UI for the findings of one directory: severity
+// filters, grouping by rule, a file viewer with the finding highlighted,
+// baseline actions and a rescan button. The frontend is embedded and loads
+// no external assets.
+type WebUI struct {
+	cfg WebConfig
+
+	mu       sync.RWMutex
+	findings []findings.Finding
+	source   string
+	loadedAt time.Time
+	scanning bool
+}
+
+// NewWebUI returns a WebUI for cfg. Call Load before serving it.
+func NewWebUI(cfg WebConfig) *WebUI {
+	return &WebUI{cfg: cfg}
+}
+
+// Load reads the configured report, or scans the directory when there is
+// none. A scan that fails part way shows the findings gathered so far.
+func (w *WebUI) Load(ctx context.Context) error {
+	if w.cfg.Report == "" {
+		if replaced, err := w.rescan(ctx); !replaced {
+			return err
+		}
+		return nil
+	}
+	store, err := detail.LoadFromFile(w.cfg.Report)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.findings = store.All()
+	w.source = w.cfg.Report
+	w.loadedAt = time.Now().UTC()
+	w.mu.Unlock()
+	return nil
+}
+
+var errScanRunning = errors.New("a scan is already running")
+
+// rescan replaces the findings with those of a new scan and reports
+// whether it did. A scan that fails part way still replaces them with the
+// findings gathered so far.
+func (w *WebUI) rescan(ctx context.Context) (bool, error) {
+	if w.cfg.Scan == nil {
+		return false, errors.New("scanning is not available")
+	}
+	w.mu.Lock()
+	if w.scanning {
+		w.mu.Unlock()
+		return false, errScanRunning
+	}
+	w.scanning = true
+	w.mu.Unlock()
+
+	result, err := w.cfg.Scan(ctx)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.scanning = false
+	if result == nil {
+		if err == nil {
+			err = errors.New("scan returned no result")
+		}
+		return false, err
+	}
+	w.findings = result.Findings.Findings()
+	w.source = "scan"
+	w.loadedAt = time.Now().UTC()
+	return true, err
+}
+
+// Handler returns the HTTP handler of the UI and its JSON API.
+func (w *WebUI) Handler() http.Handler {
+	mux := http.NewServeMux()
+	static, _ := fs.Sub(webFS, "web")
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /api/findings", w.handleFindings)
+	mux.HandleFunc("GET /api/file", w.handleFile)
+	mux.HandleFunc("POST /api/rescan", w.writeAction(w.handleRescan))
+	mux.HandleFunc("POST /api/baseline", w.writeAction(w.handleBaselineAdd))
+	mux.HandleFunc("DELETE /api/baseline/{fingerprint}", w.writeAction(w.handleBaselineRemove))
+	if !w.cfg.LoopbackOnly {
+		return mux
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !offline.IsLoopback(strings.Trim(host, "[]")) {
+			writeWebError(rw, http.StatusMisdirectedRequest, fmt.Errorf("host %q is not a loopback address", r.Host))
+			return
+		}
+		mux.ServeHTTP(rw, r)
+	})
+}
+
+// webFindings is the response of GET /api/findings.
+type webFindings struct {
+	Version        string                       `json:"version"`
+	Source         string                       `json:"source"`
+	LoadedAt       time.Time                    `json:"loaded_at"`
+	ReadOnly       bool                         `json:"read_only"`
+	Scanning       bool                         `json:"scanning"`
+	Findings       []findings.Finding           `json:"findings"`
+	SeverityLabels map[findings.Severity]string `json:"severity_labels,omitempty"`
+}
+
+func (w *WebUI) handleFindings(rw http.ResponseWriter, _ *http.Request) {
+	w.mu.RLock()
+	resp := webFindings{
+		Version:  w.cfg.Version,
+		Source:   w.source,
+		LoadedAt: w.loadedAt,
+		ReadOnly: w.cfg.ReadOnly,
+		Scanning: w.scanning,
+		Findings: append([]findings.Finding{}, w.findings...),
+	}
+	w.mu.RUnlock()
+	if cfg, err := nox.LoadScanConfig(w.cfg.Dir); err == nil {
+		for sev, l := range cfg.SeverityMapping {
+			if resp.SeverityLabels == nil {
+				resp.SeverityLabels = make(map[findings.Severity]string)
+			}
+			resp.SeverityLabels[sev] = l.Label
+		}
+	}
+	writeWebJSON(rw, http.StatusOK, resp)
+}
+
+// webFile is the response of GET /api/file.
+type webFile struct {
+	Path  string   `json:"path"`
+	Lines []string `json:"lines"`
+}
+
+// handleFile returns a file that has findings. Other files, and paths
+// that leave the directory, are not served.
+func (w *WebUI) handleFile(rw http.ResponseWriter, r *http.Request) {
+	rel := r.URL.Query().Get("path")
+	if !filepath.IsLocal(filepath.FromSlash(rel)) || !w.hasFindingsIn(rel) {
+		writeWebError(rw, http.StatusNotFound, fmt.Errorf("no findings in %q", rel))
+		return
+	}
+	f, err := os.OpenInRoot(w.cfg.Dir, filepath.FromSlash(rel))
+	if err != nil {
+		writeWebError(rw, http.StatusNotFound, err)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxWebFileSize+1))
+	if err != nil {
+		writeWebError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	switch {
+	case len(data) > maxWebFileSize:
+		writeWebError(rw, http.StatusUnprocessableEntity, fmt.Errorf("%s is larger than %d bytes", rel, maxWebFileSize))
+		return
+	case bytes.IndexByte(data, 0) >= 0:
+		writeWebError(rw, http.StatusUnprocessableEntity, fmt.Errorf("%s is a binary file", rel))
+		return
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	writeWebJSON(rw, http.StatusOK, webFile{Path: rel, Lines: lines})
+}
+
+func (w *WebUI) hasFindingsIn(rel string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for i := range w.findings {
+		if filepath.ToSlash(w.findings[i].Location.FilePath) == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAction wraps the handlers that change files or findings. They are
+// refused in read-only mode, and cross-origin requests are refused so that
+// other pages open in the browser cannot call them.
+func (w *WebUI) writeAction(h http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if w.cfg.ReadOnly {
+			writeWebError(rw, http.StatusForbidden, errors.New("the web UI is read-only"))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Host != r.Host {
+				writeWebError(rw, http.StatusForbidden, errors.New("cross-origin request refused"))
+				return
+			}
+		}
+		h(rw, r)
+	}
+}
+
+func (w *WebUI) handleRescan(rw http.ResponseWriter, r *http.Request) {
+	replaced, err := w.rescan(r.Context())
+	switch {
+	case errors.Is(err, errScanRunning):
+		writeWebError(rw, http.StatusConflict, err)
+		return
+	case !replaced:
+		writeWebError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	w.handleFindings(rw, r)
+}
+
+// webBaselineRequest is the body of POST /api/baseline.
+type webBaselineRequest struct {
+	Fingerprint string `json:"fingerprint"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// handleBaselineAdd adds the finding with the requested fingerprint to the
+// baseline and marks it baselined.
+func (w *WebUI) handleBaselineAdd(rw http.ResponseWriter, r *http.Request) {
+	var req webBaselineRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil || req.Fingerprint == "" {
+		writeWebError(rw, http.StatusBadRequest, errors.New("want a JSON body with a fingerprint"))
+		return
+	}
+	f, ok := w.findingByFingerprint(req.Fingerprint)
+	if !ok {
+		writeWebError(rw, http.StatusNotFound, fmt.Errorf("no finding with fingerprint %s", req.Fingerprint))
+		return
+	}
+	err := w.updateBaseline(func(bl *baseline.Baseline) {
+		if bl.Match(&f) != nil {
+			return
+		}
+		e := baseline.FromFindings([]findings.Finding{f})[0]
+		e.Reason = req.Reason
+		bl.Add(&e)
+	})
+	if err != nil {
+		writeWebError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	w.setStatus(req.Fingerprint, findings.StatusNew, findings.StatusBaselined)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// handleBaselineRemove removes a fingerprint from the baseline and marks
+// its findings new.
+func (w *WebUI) handleBaselineRemove(rw http.ResponseWriter, r *http.Request) {
+	fingerprint := r.PathValue("fingerprint")
+	removed := false
+	err := w.updateBaseline(func(bl *baseline.Baseline) {
+		removed = bl.Remove(fingerprint)
+	})
+	if err != nil {
+		writeWebError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	if !removed {
+		writeWebError(rw, http.StatusNotFound, fmt.Errorf("%s is not in the baseline", fingerprint))
+		return
+	}
+	w.setStatus(fingerprint, findings.StatusBaselined, findings.StatusNew)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// updateBaseline loads the directory's baseline, applies update and saves
+// it. The path is policy.baseline_path from .nox.yaml, by default
+// .nox/baseline.json.
+func (w *WebUI) updateBaseline(update func(*baseline.Baseline)) error {
+	path := baseline.DefaultPath(w.cfg.Dir)
+	if cfg, err := nox.LoadScanConfig(w.cfg.Dir); err == nil && cfg.Policy.BaselinePath != "" {
+		path = cfg.Policy.BaselinePath
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	bl, err := baseline.Load(path)
+	if err != nil {
+		return err
+	}
+	update(bl)
+	return bl.Save(path)
+}
+
+func (w *WebUI) findingByFingerprint(fingerprint string) (findings.Finding, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, f := range w.findings {
+		if f.Fingerprint == fingerprint {
+			return f, true
+		}
+	}
+	return findings.Finding{}, false
+}
+
+// setStatus changes the status of the findings with fingerprint from one
+// status to another. Findings without a status count as new.
+func (w *WebUI) setStatus(fingerprint string, from, to findings.Status) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.findings {
+		f := &w.findings[i]
+		status := f.Status
+		if status == "" {
+			status = findings.StatusNew
+		}
+		if f.Fingerprint == fingerprint && status == from {
+			f.Status, f.Suppressed = to, !to.IsActive()
+		}
+	}
+}
+
+func writeWebJSON(rw http.ResponseWriter, code int, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	_ = json.NewEncoder(rw).Encode(v)
+}
+
+func writeWebError(rw http.ResponseWriter, code int, err error) {
+	writeWebJSON(rw, code, map[string]string{"error": err.Error()})
+}